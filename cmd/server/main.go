@@ -124,7 +124,7 @@ func run(log *zap.Logger, pool database.Querier) (*http.Server, error) {
 	appWorker.Start()
 
 	// 7. Initialiseer de API Server
-	apiServer := api.NewServer(dbStore, log, googleOAuthConfig)
+	apiServer := api.NewServer(dbStore, log, googleOAuthConfig, appWorker)
 
 	// 8. Maak de HTTP Server
 	port := os.Getenv("API_PORT")
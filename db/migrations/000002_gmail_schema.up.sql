@@ -13,31 +13,6 @@ BEGIN
             'spam'
         );
     END IF;
-
-    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'gmail_rule_trigger_type') THEN
-        CREATE TYPE gmail_rule_trigger_type AS ENUM (
-            'new_message',
-            'sender_match',
-            'subject_match',
-            'label_added',
-            'starred'
-        );
-    END IF;
-
-    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'gmail_rule_action_type') THEN
-        CREATE TYPE gmail_rule_action_type AS ENUM (
-            'auto_reply',
-            'forward',
-            'add_label',
-            'remove_label',
-            'mark_read',
-            'mark_unread',
-            'archive',
-            'trash',
-            'star',
-            'unstar'
-        );
-    END IF;
 END$$;
 
 -- Gmail Labels table
@@ -112,9 +87,9 @@ CREATE TABLE IF NOT EXISTS gmail_automation_rules (
     name text NOT NULL,
     description text,
     is_active boolean NOT NULL DEFAULT true,
-    trigger_type gmail_rule_trigger_type NOT NULL,
+    trigger_type text NOT NULL, -- Validated by the rules engine registry
     trigger_conditions jsonb NOT NULL, -- Conditions for when to trigger
-    action_type gmail_rule_action_type NOT NULL,
+    action_type text NOT NULL, -- Validated by the rules engine registry
     action_params jsonb NOT NULL, -- Parameters for the action
     priority integer NOT NULL DEFAULT 0, -- For rule ordering
     created_at timestamptz NOT NULL DEFAULT now(),
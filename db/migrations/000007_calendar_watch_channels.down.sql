@@ -0,0 +1,5 @@
+-- Rollback Calendar Push Notification Channels
+-- Migration: 000007_calendar_watch_channels.down.sql
+
+DROP INDEX IF EXISTS idx_calendar_watch_channels_expiration;
+DROP TABLE IF EXISTS calendar_watch_channels;
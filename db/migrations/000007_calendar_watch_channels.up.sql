@@ -0,0 +1,19 @@
+-- Calendar Push Notification Channels
+-- Migration: 000007_calendar_watch_channels.up.sql
+
+-- Active events.watch channels per watched calendar
+CREATE TABLE IF NOT EXISTS calendar_watch_channels (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    connected_account_id uuid NOT NULL REFERENCES connected_accounts(id) ON DELETE CASCADE,
+    calendar_id text NOT NULL,
+    channel_id text NOT NULL UNIQUE, -- Our own channel id, echoed as X-Goog-Channel-ID
+    resource_id text NOT NULL, -- Google's opaque resource id, needed to stop the channel
+    token text NOT NULL, -- Shared secret, echoed as X-Goog-Channel-Token
+    expiration timestamptz NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    UNIQUE (connected_account_id, calendar_id)
+);
+
+-- Renewal sweep looks for channels that are about to expire
+CREATE INDEX IF NOT EXISTS idx_calendar_watch_channels_expiration ON calendar_watch_channels(expiration);
@@ -0,0 +1,6 @@
+-- Rollback Gmail Snooze Support
+-- Migration: 000008_gmail_snoozes.down.sql
+
+DROP INDEX IF EXISTS idx_gmail_snoozes_wake_at;
+DROP INDEX IF EXISTS idx_gmail_snoozes_pending_message;
+DROP TABLE IF EXISTS gmail_snoozes;
@@ -0,0 +1,23 @@
+-- Gmail Snooze Support
+-- Migration: 000008_gmail_snoozes.up.sql
+
+-- Snoozed messages waiting to be restored to the inbox
+CREATE TABLE IF NOT EXISTS gmail_snoozes (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    connected_account_id uuid NOT NULL REFERENCES connected_accounts(id) ON DELETE CASCADE,
+    gmail_message_id text NOT NULL,
+    gmail_thread_id text NOT NULL,
+    wake_at timestamptz NOT NULL,
+    status text NOT NULL DEFAULT 'snoozed', -- 'snoozed', 'woken', 'cancelled' or 'failed'
+    error_message text,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT chk_gmail_snoozes_status CHECK (status IN ('snoozed', 'woken', 'cancelled', 'failed'))
+);
+
+-- A message can only have one pending snooze
+CREATE UNIQUE INDEX IF NOT EXISTS idx_gmail_snoozes_pending_message
+    ON gmail_snoozes(connected_account_id, gmail_message_id) WHERE status = 'snoozed';
+
+-- The scheduler looks up pending snoozes by wake-up time
+CREATE INDEX IF NOT EXISTS idx_gmail_snoozes_wake_at ON gmail_snoozes(wake_at) WHERE status = 'snoozed';
@@ -0,0 +1,6 @@
+-- Rollback Gmail Scheduled Send Queue
+-- Migration: 000009_gmail_scheduled_sends.down.sql
+
+DROP INDEX IF EXISTS idx_gmail_scheduled_sends_send_at;
+DROP INDEX IF EXISTS idx_gmail_scheduled_sends_account_id;
+DROP TABLE IF EXISTS gmail_scheduled_sends;
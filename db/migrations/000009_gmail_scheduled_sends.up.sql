@@ -0,0 +1,24 @@
+-- Gmail Scheduled Send Queue
+-- Migration: 000009_gmail_scheduled_sends.up.sql
+
+-- Outgoing messages waiting to be sent; the composed message is stored encrypted
+CREATE TABLE IF NOT EXISTS gmail_scheduled_sends (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    connected_account_id uuid NOT NULL REFERENCES connected_accounts(id) ON DELETE CASCADE,
+    rule_id uuid REFERENCES gmail_automation_rules(id) ON DELETE SET NULL,
+    payload bytea NOT NULL,
+    send_at timestamptz NOT NULL,
+    status text NOT NULL DEFAULT 'scheduled', -- 'scheduled', 'sent', 'cancelled' or 'failed'
+    attempts integer NOT NULL DEFAULT 0,
+    last_error text,
+    gmail_message_id text,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT chk_gmail_scheduled_sends_status CHECK (status IN ('scheduled', 'sent', 'cancelled', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_gmail_scheduled_sends_account_id ON gmail_scheduled_sends(connected_account_id);
+
+-- The scheduler looks up pending sends by send time
+CREATE INDEX IF NOT EXISTS idx_gmail_scheduled_sends_send_at
+    ON gmail_scheduled_sends(send_at) WHERE status = 'scheduled';
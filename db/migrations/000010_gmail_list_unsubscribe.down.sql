@@ -0,0 +1,10 @@
+-- Rollback Gmail List-Unsubscribe Support
+-- Migration: 000010_gmail_list_unsubscribe.down.sql
+
+DROP INDEX IF EXISTS idx_gmail_unsubscribes_sender;
+DROP TABLE IF EXISTS gmail_unsubscribes;
+
+DROP INDEX IF EXISTS idx_gmail_messages_newsletters;
+ALTER TABLE gmail_messages DROP COLUMN IF EXISTS unsubscribe_one_click;
+ALTER TABLE gmail_messages DROP COLUMN IF EXISTS unsubscribe_mailto;
+ALTER TABLE gmail_messages DROP COLUMN IF EXISTS unsubscribe_url;
@@ -0,0 +1,29 @@
+-- Gmail List-Unsubscribe Support
+-- Migration: 000010_gmail_list_unsubscribe.up.sql
+
+-- Parsed List-Unsubscribe / List-Unsubscribe-Post headers (RFC 2369, RFC 8058)
+ALTER TABLE gmail_messages ADD COLUMN IF NOT EXISTS unsubscribe_url text;
+ALTER TABLE gmail_messages ADD COLUMN IF NOT EXISTS unsubscribe_mailto text;
+ALTER TABLE gmail_messages ADD COLUMN IF NOT EXISTS unsubscribe_one_click boolean NOT NULL DEFAULT false;
+
+-- The newsletter report groups messages with unsubscribe options by sender
+CREATE INDEX IF NOT EXISTS idx_gmail_messages_newsletters ON gmail_messages(connected_account_id, sender)
+    WHERE unsubscribe_url IS NOT NULL OR unsubscribe_mailto IS NOT NULL;
+
+-- Results of unsubscribe attempts
+CREATE TABLE IF NOT EXISTS gmail_unsubscribes (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    connected_account_id uuid NOT NULL REFERENCES connected_accounts(id) ON DELETE CASCADE,
+    gmail_message_id text NOT NULL,
+    sender text,
+    method text NOT NULL, -- 'one_click' or 'mailto'
+    target text NOT NULL,
+    status text NOT NULL, -- 'success' or 'failed'
+    error_message text,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT chk_gmail_unsubscribes_method CHECK (method IN ('one_click', 'mailto')),
+    CONSTRAINT chk_gmail_unsubscribes_status CHECK (status IN ('success', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_gmail_unsubscribes_sender ON gmail_unsubscribes(connected_account_id, sender);
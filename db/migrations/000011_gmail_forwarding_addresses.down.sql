@@ -0,0 +1,4 @@
+-- Rollback Gmail Forwarding Address Allowlist
+-- Migration: 000011_gmail_forwarding_addresses.down.sql
+
+DROP TABLE IF EXISTS gmail_forwarding_addresses;
@@ -0,0 +1,16 @@
+-- Gmail Forwarding Address Allowlist
+-- Migration: 000011_gmail_forwarding_addresses.up.sql
+
+-- Forward rules may only send to addresses the user has verified,
+-- so a compromised rule cannot exfiltrate mail to an arbitrary destination
+CREATE TABLE IF NOT EXISTS gmail_forwarding_addresses (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email text NOT NULL, -- stored lowercase
+    verification_code_hash text, -- sha256 of the emailed code, cleared after verification
+    verification_sent_at timestamptz,
+    verified_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT uq_gmail_forwarding_addresses_user_email UNIQUE (user_id, email)
+);
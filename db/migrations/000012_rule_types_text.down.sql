@@ -0,0 +1,44 @@
+-- Rollback Rule Types as Text
+-- Migration: 000012_rule_types_text.down.sql
+
+-- The enums list every trigger and action type the Gmail rules registry can write, including
+-- types added after this migration (create_calendar_event, webhook). A type registered later
+-- must be added here too; until then, delete the rules that use it before rolling back, or the
+-- cast below fails.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'gmail_rule_trigger_type') THEN
+        CREATE TYPE gmail_rule_trigger_type AS ENUM (
+            'new_message',
+            'sender_match',
+            'subject_match',
+            'label_added',
+            'starred'
+        );
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'gmail_rule_action_type') THEN
+        CREATE TYPE gmail_rule_action_type AS ENUM (
+            'auto_reply',
+            'forward',
+            'add_label',
+            'remove_label',
+            'mark_read',
+            'mark_unread',
+            'archive',
+            'trash',
+            'star',
+            'unstar',
+            'snooze',
+            'schedule_send',
+            'unsubscribe',
+            'create_calendar_event',
+            'webhook'
+        );
+    END IF;
+END$$;
+
+ALTER TABLE gmail_automation_rules
+    ALTER COLUMN trigger_type TYPE gmail_rule_trigger_type USING trigger_type::gmail_rule_trigger_type;
+ALTER TABLE gmail_automation_rules
+    ALTER COLUMN action_type TYPE gmail_rule_action_type USING action_type::gmail_rule_action_type;
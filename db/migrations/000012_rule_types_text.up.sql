@@ -0,0 +1,25 @@
+-- Rule Types as Text
+-- Migration: 000012_rule_types_text.up.sql
+
+-- Trigger and action types are validated by the rules engine registry,
+-- so adding a type no longer needs an enum migration. Databases created
+-- before this migration still have enum columns; convert those once.
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'gmail_automation_rules' AND column_name = 'trigger_type' AND data_type = 'USER-DEFINED'
+    ) THEN
+        ALTER TABLE gmail_automation_rules ALTER COLUMN trigger_type TYPE text USING trigger_type::text;
+    END IF;
+
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'gmail_automation_rules' AND column_name = 'action_type' AND data_type = 'USER-DEFINED'
+    ) THEN
+        ALTER TABLE gmail_automation_rules ALTER COLUMN action_type TYPE text USING action_type::text;
+    END IF;
+END$$;
+
+DROP TYPE IF EXISTS gmail_rule_trigger_type;
+DROP TYPE IF EXISTS gmail_rule_action_type;
@@ -0,0 +1,9 @@
+-- Rollback Rule Versions
+-- Migration: 000013_rule_versions.down.sql
+
+DROP FUNCTION IF EXISTS rule_snapshot_diff(jsonb, jsonb);
+DROP TABLE IF EXISTS rule_versions;
+
+ALTER TABLE automation_logs DROP COLUMN IF EXISTS rule_version;
+ALTER TABLE gmail_automation_rules DROP COLUMN IF EXISTS version;
+ALTER TABLE automation_rules DROP COLUMN IF EXISTS version;
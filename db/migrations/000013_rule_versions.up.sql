@@ -0,0 +1,80 @@
+-- Rule Versions
+-- Migration: 000013_rule_versions.up.sql
+
+-- Every create, update, toggle and restore of a rule bumps its version and stores a snapshot,
+-- so an automation log can be traced back to the exact rule definition that fired
+ALTER TABLE automation_rules ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+ALTER TABLE gmail_automation_rules ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+ALTER TABLE automation_logs ADD COLUMN IF NOT EXISTS rule_version integer;
+
+-- rule_id points to automation_rules or gmail_automation_rules depending on rule_type,
+-- so there is no foreign key; the history is kept after a rule is deleted
+CREATE TABLE IF NOT EXISTS rule_versions (
+    id bigserial PRIMARY KEY,
+    rule_type text NOT NULL CHECK (rule_type IN ('calendar', 'gmail')),
+    rule_id uuid NOT NULL,
+    version integer NOT NULL,
+    change_type text NOT NULL,
+    changed_by uuid REFERENCES users(id) ON DELETE SET NULL,
+    snapshot jsonb NOT NULL,
+    diff jsonb, -- {"field": {"old": ..., "new": ...}}, NULL for create
+    restored_from integer, -- the version a restore copied
+    created_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT uq_rule_versions_rule_version UNIQUE (rule_type, rule_id, version)
+);
+
+-- The only definition of the allowed change types; every startup replays it with the full list.
+-- expire and pause are written by the worker when it switches a rule off, without changed_by.
+ALTER TABLE rule_versions DROP CONSTRAINT IF EXISTS rule_versions_change_type_check;
+ALTER TABLE rule_versions ADD CONSTRAINT rule_versions_change_type_check
+    CHECK (change_type IN ('create', 'update', 'toggle', 'restore', 'expire', 'pause'));
+
+-- rule_snapshot_diff returns the top-level fields that differ between two snapshots
+CREATE OR REPLACE FUNCTION rule_snapshot_diff(old_snapshot jsonb, new_snapshot jsonb)
+RETURNS jsonb
+LANGUAGE sql
+IMMUTABLE
+AS $$
+    SELECT COALESCE(
+        jsonb_object_agg(n.key, jsonb_build_object('old', o.value, 'new', n.value)),
+        '{}'::jsonb
+    )
+    FROM jsonb_each(new_snapshot) n
+    LEFT JOIN jsonb_each(old_snapshot) o ON o.key = n.key
+    WHERE o.value IS DISTINCT FROM n.value;
+$$;
+
+-- Existing rules start with their current definition as version 1, with the same keys as the
+-- snapshots the store writes (rule.SnapshotSQL and gmail.RuleSnapshotSQL). Columns added by later
+-- migrations are read through to_jsonb, so a fresh database gets their defaults and a replay the
+-- stored values.
+INSERT INTO rule_versions (rule_type, rule_id, version, change_type, snapshot, created_at)
+SELECT 'calendar', id, version, 'create',
+       jsonb_build_object(
+           'name', name,
+           'is_active', is_active,
+           'trigger_type', COALESCE(to_jsonb(r) -> 'trigger_type', '"event_match"'),
+           'trigger_conditions', trigger_conditions,
+           'action_type', COALESCE(to_jsonb(r) -> 'action_type', '"create_reminder"'),
+           'action_params', action_params,
+           'schedule', to_jsonb(r) -> 'schedule'
+       ) || jsonb_strip_nulls(jsonb_build_object('account_selector', to_jsonb(r) -> 'account_selector')),
+       updated_at
+FROM automation_rules r
+ON CONFLICT (rule_type, rule_id, version) DO NOTHING;
+
+INSERT INTO rule_versions (rule_type, rule_id, version, change_type, snapshot, created_at)
+SELECT 'gmail', id, version, 'create',
+       jsonb_build_object(
+           'name', name,
+           'description', description,
+           'is_active', is_active,
+           'trigger_type', trigger_type,
+           'trigger_conditions', trigger_conditions,
+           'action_type', action_type,
+           'action_params', action_params,
+           'schedule', to_jsonb(g) -> 'schedule'
+       ) || jsonb_strip_nulls(jsonb_build_object('account_selector', to_jsonb(g) -> 'account_selector')),
+       updated_at
+FROM gmail_automation_rules g
+ON CONFLICT (rule_type, rule_id, version) DO NOTHING;
@@ -0,0 +1,10 @@
+-- Rollback Rule Schedules
+-- Migration: 000014_rule_schedules.down.sql
+
+UPDATE rule_versions SET change_type = 'toggle' WHERE change_type = 'expire';
+UPDATE rule_versions SET snapshot = snapshot - 'schedule';
+
+ALTER TABLE gmail_automation_rules DROP COLUMN IF EXISTS execution_count;
+ALTER TABLE automation_rules DROP COLUMN IF EXISTS execution_count;
+ALTER TABLE gmail_automation_rules DROP COLUMN IF EXISTS schedule;
+ALTER TABLE automation_rules DROP COLUMN IF EXISTS schedule;
@@ -0,0 +1,14 @@
+-- Rule Schedules
+-- Migration: 000014_rule_schedules.up.sql
+
+-- schedule limits when an active rule may run: valid_from/valid_until, weekdays and time windows
+-- in a timezone, and max_executions. NULL means the rule always runs while it is active.
+ALTER TABLE automation_rules ADD COLUMN IF NOT EXISTS schedule jsonb;
+ALTER TABLE gmail_automation_rules ADD COLUMN IF NOT EXISTS schedule jsonb;
+
+-- Successful executions since the rule was last switched on, checked against max_executions
+ALTER TABLE automation_rules ADD COLUMN IF NOT EXISTS execution_count integer NOT NULL DEFAULT 0;
+ALTER TABLE gmail_automation_rules ADD COLUMN IF NOT EXISTS execution_count integer NOT NULL DEFAULT 0;
+
+-- Snapshots now include the schedule; give older ones the key too so the next diff stays clean
+UPDATE rule_versions SET snapshot = snapshot || '{"schedule": null}'::jsonb WHERE NOT snapshot ? 'schedule';
@@ -0,0 +1,7 @@
+-- Rollback Rule Rate Limits
+-- Migration: 000015_rule_rate_limits.down.sql
+
+UPDATE rule_versions SET change_type = 'toggle' WHERE change_type = 'pause';
+
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS rule_execution_counters;
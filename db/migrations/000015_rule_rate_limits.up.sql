@@ -0,0 +1,26 @@
+-- Rule Rate Limits
+-- Migration: 000015_rule_rate_limits.up.sql
+
+-- Executions per rule per hour and per account per day, checked by the worker against the
+-- configured caps before a rule acts. Rows of past windows are removed as the counters move on.
+CREATE TABLE IF NOT EXISTS rule_execution_counters (
+    scope_id uuid NOT NULL, -- rule ID or connected account ID
+    scope text NOT NULL CHECK (scope IN ('rule', 'account')),
+    window_start timestamptz NOT NULL,
+    executions integer NOT NULL DEFAULT 0,
+    PRIMARY KEY (scope_id, scope, window_start)
+);
+
+-- Messages for the user, e.g. when the worker pauses a runaway rule
+CREATE TABLE IF NOT EXISTS notifications (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    connected_account_id uuid REFERENCES connected_accounts(id) ON DELETE CASCADE,
+    type text NOT NULL,
+    message text NOT NULL,
+    details jsonb,
+    read_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_created ON notifications (user_id, created_at DESC);
@@ -0,0 +1,5 @@
+-- Rollback Log Reverts
+-- Migration: 000016_log_reverts.down.sql
+
+DROP INDEX IF EXISTS idx_automation_logs_rule_timestamp;
+ALTER TABLE automation_logs DROP COLUMN IF EXISTS reverted_at;
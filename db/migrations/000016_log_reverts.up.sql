@@ -0,0 +1,10 @@
+-- Log Reverts
+-- Migration: 000016_log_reverts.up.sql
+
+-- reverted_at is set when POST /rules/{ruleId}/revert undid the action of a log,
+-- so a second revert over the same range does not touch it again
+ALTER TABLE automation_logs ADD COLUMN IF NOT EXISTS reverted_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS idx_automation_logs_rule_timestamp
+    ON automation_logs (rule_id, timestamp DESC)
+    WHERE status = 'success' AND reverted_at IS NULL;
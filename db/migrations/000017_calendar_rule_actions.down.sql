@@ -0,0 +1,8 @@
+-- Rollback Calendar Rule Actions
+-- Migration: 000017_calendar_rule_actions.down.sql
+
+-- Rules with another action than create_reminder cannot be expressed without the column
+DELETE FROM automation_rules WHERE action_type <> 'create_reminder';
+DELETE FROM gmail_automation_rules WHERE action_type = 'create_calendar_event';
+UPDATE rule_versions SET snapshot = snapshot - 'action_type' WHERE rule_type = 'calendar';
+ALTER TABLE automation_rules DROP COLUMN IF EXISTS action_type;
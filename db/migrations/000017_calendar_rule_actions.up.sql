@@ -0,0 +1,11 @@
+-- Calendar Rule Actions
+-- Migration: 000017_calendar_rule_actions.up.sql
+
+-- Calendar rules can now run other actions than creating a reminder, such as sending an email
+-- through Gmail. Existing rules keep create_reminder. The Gmail create_calendar_event action
+-- needs no schema change since 000012.
+ALTER TABLE automation_rules ADD COLUMN IF NOT EXISTS action_type text NOT NULL DEFAULT 'create_reminder';
+
+-- Snapshots now include the action type; give older ones the key too so the next diff stays clean
+UPDATE rule_versions SET snapshot = snapshot || '{"action_type": "create_reminder"}'::jsonb
+WHERE rule_type = 'calendar' AND NOT snapshot ? 'action_type';
@@ -0,0 +1,7 @@
+-- Rollback Webhook Secrets
+-- Migration: 000018_webhook_secrets.down.sql
+
+-- Webhook rules cannot be signed without a secret
+DELETE FROM automation_rules WHERE action_type = 'webhook';
+DELETE FROM gmail_automation_rules WHERE action_type = 'webhook';
+DROP TABLE IF EXISTS webhook_secrets;
@@ -0,0 +1,10 @@
+-- Webhook Secrets
+-- Migration: 000018_webhook_secrets.up.sql
+
+-- One signing secret per user for outgoing webhooks, encrypted like the OAuth tokens.
+-- It is created on first use and can be rotated through the API.
+CREATE TABLE IF NOT EXISTS webhook_secrets (
+    user_id uuid PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret bytea NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
@@ -0,0 +1,5 @@
+-- Rollback Inbound Hooks
+-- Migration: 000019_inbound_hooks.down.sql
+
+DROP TABLE IF EXISTS inbound_hook_deliveries;
+DROP TABLE IF EXISTS inbound_hooks;
@@ -0,0 +1,41 @@
+-- Inbound Hooks
+-- Migration: 000019_inbound_hooks.up.sql
+
+-- Webhook endpoints (POST /hooks/{token}) through which external systems start actions on an account.
+-- Only the SHA-256 hash of the token is stored; the token itself is shown once, when the hook is created.
+-- A revoked hook keeps its deliveries but no longer accepts new ones.
+CREATE TABLE IF NOT EXISTS inbound_hooks (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    connected_account_id uuid NOT NULL REFERENCES connected_accounts(id) ON DELETE CASCADE,
+    name text NOT NULL,
+    token_hash bytea NOT NULL UNIQUE,
+    actions jsonb NOT NULL DEFAULT '[]',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    last_delivery_at timestamptz,
+    revoked_at timestamptz
+);
+
+-- 000025 moves hooks from an account to its user and drops connected_account_id; a replay must not
+-- recreate this index on the dropped column.
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'inbound_hooks' AND column_name = 'connected_account_id'
+    ) THEN
+        CREATE INDEX IF NOT EXISTS idx_inbound_hooks_account ON inbound_hooks (connected_account_id, created_at DESC);
+    END IF;
+END$$;
+
+-- One row per received request, with the body and the outcome of every action
+CREATE TABLE IF NOT EXISTS inbound_hook_deliveries (
+    id bigserial PRIMARY KEY,
+    hook_id uuid NOT NULL REFERENCES inbound_hooks(id) ON DELETE CASCADE,
+    received_at timestamptz NOT NULL DEFAULT now(),
+    status text NOT NULL,
+    payload jsonb,
+    results jsonb NOT NULL DEFAULT '[]',
+    error_message text
+);
+
+CREATE INDEX IF NOT EXISTS idx_inbound_hook_deliveries_hook ON inbound_hook_deliveries (hook_id, received_at DESC);
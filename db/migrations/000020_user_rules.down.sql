@@ -0,0 +1,18 @@
+-- Rollback User Rules
+-- Migration: 000020_user_rules.down.sql
+
+DELETE FROM gmail_automation_rules WHERE connected_account_id IS NULL;
+DROP INDEX IF EXISTS idx_gmail_automation_rules_user;
+ALTER TABLE gmail_automation_rules
+    DROP CONSTRAINT IF EXISTS gmail_automation_rules_owner_check,
+    DROP COLUMN IF EXISTS account_selector,
+    DROP COLUMN IF EXISTS user_id,
+    ALTER COLUMN connected_account_id SET NOT NULL;
+
+DELETE FROM automation_rules WHERE connected_account_id IS NULL;
+DROP INDEX IF EXISTS idx_automation_rules_user;
+ALTER TABLE automation_rules
+    DROP CONSTRAINT IF EXISTS automation_rules_owner_check,
+    DROP COLUMN IF EXISTS account_selector,
+    DROP COLUMN IF EXISTS user_id,
+    ALTER COLUMN connected_account_id SET NOT NULL;
@@ -0,0 +1,36 @@
+-- User Rules
+-- Migration: 000020_user_rules.up.sql
+
+-- Rules can belong to a user instead of a single connected account. Such a rule has no
+-- connected_account_id; account_selector decides for which of the user's accounts the worker runs it:
+--   {"mode": "all"}
+--   {"mode": "accounts", "account_ids": ["..."]}
+--   {"mode": "provider", "provider": "google"}
+-- Logs keep the connected_account_id of the account the rule ran for.
+ALTER TABLE automation_rules
+    ALTER COLUMN connected_account_id DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS user_id uuid REFERENCES users(id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS account_selector jsonb;
+
+ALTER TABLE automation_rules
+    DROP CONSTRAINT IF EXISTS automation_rules_owner_check,
+    ADD CONSTRAINT automation_rules_owner_check CHECK (
+        (connected_account_id IS NOT NULL AND user_id IS NULL)
+        OR (connected_account_id IS NULL AND user_id IS NOT NULL AND account_selector IS NOT NULL)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_automation_rules_user ON automation_rules (user_id) WHERE user_id IS NOT NULL;
+
+ALTER TABLE gmail_automation_rules
+    ALTER COLUMN connected_account_id DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS user_id uuid REFERENCES users(id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS account_selector jsonb;
+
+ALTER TABLE gmail_automation_rules
+    DROP CONSTRAINT IF EXISTS gmail_automation_rules_owner_check,
+    ADD CONSTRAINT gmail_automation_rules_owner_check CHECK (
+        (connected_account_id IS NOT NULL AND user_id IS NULL)
+        OR (connected_account_id IS NULL AND user_id IS NOT NULL AND account_selector IS NOT NULL)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_gmail_automation_rules_user ON gmail_automation_rules (user_id) WHERE user_id IS NOT NULL;
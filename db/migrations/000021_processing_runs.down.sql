@@ -0,0 +1,4 @@
+-- Rollback Processing Runs
+-- Migration: 000021_processing_runs.down.sql
+
+DROP TABLE IF EXISTS processing_runs;
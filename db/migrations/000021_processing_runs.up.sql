@@ -0,0 +1,22 @@
+-- Processing Runs
+-- Migration: 000021_processing_runs.up.sql
+
+-- Manual processing passes started through the API ("run now"): a sync of one account, or one
+-- calendar rule. A rule run without connected_account_id is a user-level rule and covers every
+-- account it applies to. results counts the automation logs the run wrote, per status.
+CREATE TABLE IF NOT EXISTS processing_runs (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    connected_account_id uuid REFERENCES connected_accounts(id) ON DELETE CASCADE,
+    rule_id uuid REFERENCES automation_rules(id) ON DELETE CASCADE,
+    status text NOT NULL DEFAULT 'queued',
+    error_message text,
+    results jsonb,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    started_at timestamptz,
+    finished_at timestamptz,
+    CONSTRAINT processing_runs_target_check CHECK (connected_account_id IS NOT NULL OR rule_id IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_processing_runs_unfinished ON processing_runs (created_at)
+    WHERE status IN ('queued', 'running');
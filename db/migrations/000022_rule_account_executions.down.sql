@@ -0,0 +1,4 @@
+-- Rollback Rule Account Executions
+-- Migration: 000022_rule_account_executions.down.sql
+
+DROP TABLE IF EXISTS rule_account_executions;
@@ -0,0 +1,17 @@
+-- Rule Account Executions
+-- Migration: 000022_rule_account_executions.up.sql
+
+-- Executed actions per rule per account per UTC day. The account's daily cap is checked against
+-- the sum of its rows, and the rule with the most executions is the one paused when the cap is
+-- reached. rule_id points to automation_rules or gmail_automation_rules depending on rule_type.
+CREATE TABLE IF NOT EXISTS rule_account_executions (
+    connected_account_id uuid NOT NULL REFERENCES connected_accounts(id) ON DELETE CASCADE,
+    rule_type text NOT NULL CHECK (rule_type IN ('calendar', 'gmail')),
+    rule_id uuid NOT NULL,
+    day date NOT NULL,
+    executions integer NOT NULL DEFAULT 0,
+    PRIMARY KEY (connected_account_id, day, rule_id)
+);
+
+-- The daily account counters in rule_execution_counters are replaced by the rows above
+DELETE FROM rule_execution_counters WHERE scope = 'account';
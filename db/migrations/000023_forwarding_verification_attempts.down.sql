@@ -0,0 +1,4 @@
+-- Rollback Forwarding Verification Attempts
+-- Migration: 000023_forwarding_verification_attempts.down.sql
+
+ALTER TABLE gmail_forwarding_addresses DROP COLUMN IF EXISTS verification_attempts;
@@ -0,0 +1,6 @@
+-- Forwarding Verification Attempts
+-- Migration: 000023_forwarding_verification_attempts.up.sql
+
+-- Codes entered for the current verification code; a new code resets the count.
+-- After the maximum the address needs a new code, so a code cannot be guessed.
+ALTER TABLE gmail_forwarding_addresses ADD COLUMN IF NOT EXISTS verification_attempts integer NOT NULL DEFAULT 0;
@@ -0,0 +1,12 @@
+-- Rollback Run Logs
+-- Migration: 000024_run_logs.down.sql
+
+DELETE FROM processing_runs WHERE gmail_rule_id IS NOT NULL;
+
+ALTER TABLE processing_runs DROP CONSTRAINT IF EXISTS processing_runs_target_check;
+ALTER TABLE processing_runs ADD CONSTRAINT processing_runs_target_check
+    CHECK (connected_account_id IS NOT NULL OR rule_id IS NOT NULL);
+ALTER TABLE processing_runs DROP COLUMN IF EXISTS gmail_rule_id;
+
+DROP INDEX IF EXISTS idx_automation_logs_run_id;
+ALTER TABLE automation_logs DROP COLUMN IF EXISTS run_id;
@@ -0,0 +1,17 @@
+-- Run Logs
+-- Migration: 000024_run_logs.up.sql
+
+-- The manual run that wrote a log. The results and logs of a run are the logs with its id,
+-- instead of the logs of its account and rule in the time it ran.
+ALTER TABLE automation_logs ADD COLUMN IF NOT EXISTS run_id uuid REFERENCES processing_runs(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_automation_logs_run_id ON automation_logs (run_id) WHERE run_id IS NOT NULL;
+
+-- A run of one Gmail rule. Calendar and Gmail rules live in different tables, so each gets its
+-- own column with its own foreign key.
+ALTER TABLE processing_runs ADD COLUMN IF NOT EXISTS gmail_rule_id uuid
+    REFERENCES gmail_automation_rules(id) ON DELETE CASCADE;
+
+ALTER TABLE processing_runs DROP CONSTRAINT IF EXISTS processing_runs_target_check;
+ALTER TABLE processing_runs ADD CONSTRAINT processing_runs_target_check
+    CHECK (connected_account_id IS NOT NULL OR rule_id IS NOT NULL OR gmail_rule_id IS NOT NULL);
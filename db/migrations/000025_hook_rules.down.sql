@@ -0,0 +1,19 @@
+-- Rollback Hook Rules
+-- Migration: 000025_hook_rules.down.sql
+
+-- The rules bound to hooks can not be turned back into hook actions; they are removed with the
+-- hooks, which can not be assigned to one account again.
+DELETE FROM automation_rules WHERE trigger_type = 'hook_received';
+DELETE FROM inbound_hooks;
+
+DROP INDEX IF EXISTS idx_inbound_hook_deliveries_queued;
+ALTER TABLE inbound_hook_deliveries DROP COLUMN IF EXISTS finished_at;
+
+DROP INDEX IF EXISTS idx_inbound_hooks_user;
+ALTER TABLE inbound_hooks DROP COLUMN IF EXISTS user_id;
+ALTER TABLE inbound_hooks ADD COLUMN IF NOT EXISTS connected_account_id uuid NOT NULL
+    REFERENCES connected_accounts(id) ON DELETE CASCADE;
+ALTER TABLE inbound_hooks ADD COLUMN IF NOT EXISTS actions jsonb NOT NULL DEFAULT '[]';
+
+DROP INDEX IF EXISTS idx_automation_rules_hook;
+ALTER TABLE automation_rules DROP COLUMN IF EXISTS trigger_type;
@@ -0,0 +1,71 @@
+-- Hook Rules
+-- Migration: 000025_hook_rules.up.sql
+
+-- Calendar rules get a trigger type. event_match rules run on calendar events; hook_received rules
+-- run when the inbound hook in trigger_conditions.hook_id receives a delivery.
+ALTER TABLE automation_rules ADD COLUMN IF NOT EXISTS trigger_type text NOT NULL DEFAULT 'event_match';
+
+-- Snapshots now include the trigger type; older calendar snapshots were all event_match rules
+UPDATE rule_versions SET snapshot = snapshot || '{"trigger_type": "event_match"}'::jsonb
+WHERE rule_type = 'calendar' AND NOT snapshot ? 'trigger_type';
+
+CREATE INDEX IF NOT EXISTS idx_automation_rules_hook ON automation_rules ((trigger_conditions->>'hook_id'))
+    WHERE trigger_type = 'hook_received';
+
+-- Hooks belong to a user instead of an account, and no longer carry their own actions: rules bind
+-- to a hook and choose the accounts they run on.
+ALTER TABLE inbound_hooks ADD COLUMN IF NOT EXISTS user_id uuid REFERENCES users(id) ON DELETE CASCADE;
+
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'inbound_hooks' AND column_name = 'actions'
+    ) THEN
+        UPDATE inbound_hooks h
+        SET user_id = a.user_id
+        FROM connected_accounts a
+        WHERE a.id = h.connected_account_id AND h.user_id IS NULL;
+
+        -- Every action of an existing hook becomes a rule on the account of the hook, bound to the
+        -- hook, with the same version 1 as a rule created through the API.
+        WITH converted AS (
+            INSERT INTO automation_rules (
+                connected_account_id, name, is_active, trigger_type, trigger_conditions, action_type, action_params
+            )
+            SELECT h.connected_account_id, h.name || ': ' || (a.action->>'action_type'), h.revoked_at IS NULL,
+                   'hook_received', jsonb_build_object('hook_id', h.id),
+                   a.action->>'action_type', COALESCE(a.action->'action_params', '{}')
+            FROM inbound_hooks h
+            CROSS JOIN LATERAL jsonb_array_elements(h.actions) AS a(action)
+            RETURNING id, version, name, is_active, trigger_type, trigger_conditions, action_type, action_params,
+                      schedule, account_selector
+        )
+        INSERT INTO rule_versions (rule_type, rule_id, version, change_type, snapshot)
+        SELECT 'calendar', id, version, 'create',
+               jsonb_build_object(
+                   'name', name,
+                   'is_active', is_active,
+                   'trigger_type', trigger_type,
+                   'trigger_conditions', trigger_conditions,
+                   'action_type', action_type,
+                   'action_params', action_params,
+                   'schedule', schedule
+               ) || jsonb_strip_nulls(jsonb_build_object('account_selector', account_selector))
+        FROM converted;
+
+        ALTER TABLE inbound_hooks DROP COLUMN actions;
+        ALTER TABLE inbound_hooks DROP COLUMN connected_account_id;
+    END IF;
+END$$;
+
+ALTER TABLE inbound_hooks ALTER COLUMN user_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_inbound_hooks_user ON inbound_hooks (user_id, created_at DESC);
+
+-- Deliveries are queued and run by the worker. status is 'queued' until the bound rules ran;
+-- finished_at is set together with the final status.
+ALTER TABLE inbound_hook_deliveries ADD COLUMN IF NOT EXISTS finished_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS idx_inbound_hook_deliveries_queued ON inbound_hook_deliveries (received_at)
+    WHERE status = 'queued';
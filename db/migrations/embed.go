@@ -64,6 +64,196 @@ var ConnectedAccountsOptimizationUp string
 //go:embed 000006_connected_accounts_optimization.down.sql
 var ConnectedAccountsOptimizationDown string
 
+// CalendarWatchChannelsUp contains the up migration for calendar push notification channels.
+//
+//go:embed 000007_calendar_watch_channels.up.sql
+var CalendarWatchChannelsUp string
+
+// CalendarWatchChannelsDown contains the down migration for calendar push notification channels.
+//
+//go:embed 000007_calendar_watch_channels.down.sql
+var CalendarWatchChannelsDown string
+
+// GmailSnoozesUp contains the up migration for Gmail snoozes.
+//
+//go:embed 000008_gmail_snoozes.up.sql
+var GmailSnoozesUp string
+
+// GmailSnoozesDown contains the down migration for Gmail snoozes.
+//
+//go:embed 000008_gmail_snoozes.down.sql
+var GmailSnoozesDown string
+
+// GmailScheduledSendsUp contains the up migration for the Gmail scheduled send queue.
+//
+//go:embed 000009_gmail_scheduled_sends.up.sql
+var GmailScheduledSendsUp string
+
+// GmailScheduledSendsDown contains the down migration for the Gmail scheduled send queue.
+//
+//go:embed 000009_gmail_scheduled_sends.down.sql
+var GmailScheduledSendsDown string
+
+// GmailListUnsubscribeUp contains the up migration for List-Unsubscribe support.
+//
+//go:embed 000010_gmail_list_unsubscribe.up.sql
+var GmailListUnsubscribeUp string
+
+// GmailListUnsubscribeDown contains the down migration for List-Unsubscribe support.
+//
+//go:embed 000010_gmail_list_unsubscribe.down.sql
+var GmailListUnsubscribeDown string
+
+// GmailForwardingAddressesUp contains the up migration for the forwarding address allowlist.
+//
+//go:embed 000011_gmail_forwarding_addresses.up.sql
+var GmailForwardingAddressesUp string
+
+// GmailForwardingAddressesDown contains the down migration for the forwarding address allowlist.
+//
+//go:embed 000011_gmail_forwarding_addresses.down.sql
+var GmailForwardingAddressesDown string
+
+// RuleTypesTextUp contains the up migration that turns Gmail rule types into text.
+//
+//go:embed 000012_rule_types_text.up.sql
+var RuleTypesTextUp string
+
+// RuleTypesTextDown contains the down migration that restores the Gmail rule type enums.
+//
+//go:embed 000012_rule_types_text.down.sql
+var RuleTypesTextDown string
+
+// RuleVersionsUp contains the up migration for rule versioning.
+//
+//go:embed 000013_rule_versions.up.sql
+var RuleVersionsUp string
+
+// RuleVersionsDown contains the down migration for rule versioning.
+//
+//go:embed 000013_rule_versions.down.sql
+var RuleVersionsDown string
+
+// RuleSchedulesUp contains the up migration for rule schedules and execution counts.
+//
+//go:embed 000014_rule_schedules.up.sql
+var RuleSchedulesUp string
+
+// RuleSchedulesDown contains the down migration for rule schedules and execution counts.
+//
+//go:embed 000014_rule_schedules.down.sql
+var RuleSchedulesDown string
+
+// RuleRateLimitsUp contains the up migration for rule execution counters and notifications.
+//
+//go:embed 000015_rule_rate_limits.up.sql
+var RuleRateLimitsUp string
+
+// RuleRateLimitsDown contains the down migration for rule execution counters and notifications.
+//
+//go:embed 000015_rule_rate_limits.down.sql
+var RuleRateLimitsDown string
+
+// LogRevertsUp contains the up migration for reverted automation logs.
+//
+//go:embed 000016_log_reverts.up.sql
+var LogRevertsUp string
+
+// LogRevertsDown contains the down migration for reverted automation logs.
+//
+//go:embed 000016_log_reverts.down.sql
+var LogRevertsDown string
+
+// CalendarRuleActionsUp contains the up migration for calendar rule action types.
+//
+//go:embed 000017_calendar_rule_actions.up.sql
+var CalendarRuleActionsUp string
+
+// CalendarRuleActionsDown contains the down migration for calendar rule action types.
+//
+//go:embed 000017_calendar_rule_actions.down.sql
+var CalendarRuleActionsDown string
+
+// WebhookSecretsUp contains the up migration for webhook signing secrets.
+//
+//go:embed 000018_webhook_secrets.up.sql
+var WebhookSecretsUp string
+
+// WebhookSecretsDown contains the down migration for webhook signing secrets.
+//
+//go:embed 000018_webhook_secrets.down.sql
+var WebhookSecretsDown string
+
+// InboundHooksUp contains the up migration for inbound webhook endpoints.
+//
+//go:embed 000019_inbound_hooks.up.sql
+var InboundHooksUp string
+
+// InboundHooksDown contains the down migration for inbound webhook endpoints.
+//
+//go:embed 000019_inbound_hooks.down.sql
+var InboundHooksDown string
+
+// UserRulesUp contains the migration for rules owned by a user instead of one account.
+//
+//go:embed 000020_user_rules.up.sql
+var UserRulesUp string
+
+// UserRulesDown contains the down migration for user-level rules.
+//
+//go:embed 000020_user_rules.down.sql
+var UserRulesDown string
+
+// ProcessingRunsUp contains the migration for manual processing runs.
+//
+//go:embed 000021_processing_runs.up.sql
+var ProcessingRunsUp string
+
+// ProcessingRunsDown contains the down migration for manual processing runs.
+//
+//go:embed 000021_processing_runs.down.sql
+var ProcessingRunsDown string
+
+// RuleAccountExecutionsUp contains the migration for executions per rule per account per day.
+//
+//go:embed 000022_rule_account_executions.up.sql
+var RuleAccountExecutionsUp string
+
+// RuleAccountExecutionsDown contains the down migration for executions per rule per account per day.
+//
+//go:embed 000022_rule_account_executions.down.sql
+var RuleAccountExecutionsDown string
+
+// ForwardingVerificationAttemptsUp contains the migration for forwarding verification attempts.
+//
+//go:embed 000023_forwarding_verification_attempts.up.sql
+var ForwardingVerificationAttemptsUp string
+
+// ForwardingVerificationAttemptsDown contains the down migration for forwarding verification attempts.
+//
+//go:embed 000023_forwarding_verification_attempts.down.sql
+var ForwardingVerificationAttemptsDown string
+
+// RunLogsUp contains the up migration for run logs.
+//
+//go:embed 000024_run_logs.up.sql
+var RunLogsUp string
+
+// RunLogsDown contains the down migration for run logs.
+//
+//go:embed 000024_run_logs.down.sql
+var RunLogsDown string
+
+// HookRulesUp contains the up migration for rules bound to inbound hooks.
+//
+//go:embed 000025_hook_rules.up.sql
+var HookRulesUp string
+
+// HookRulesDown contains the down migration for rules bound to inbound hooks.
+//
+//go:embed 000025_hook_rules.down.sql
+var HookRulesDown string
+
 // SQLFiles optionally contains all SQL files as an embedded filesystem.
 //
 //go:embed *.sql
@@ -6,8 +6,6 @@ import (
 	"agenda-automator-api/internal/api/common"
 	"agenda-automator-api/internal/store"
 
-	"github.com/go-chi/chi/v5"
-	"github.com/google/uuid"
 	"go.uber.org/zap" // <-- MOEST WORDEN TOEGEVOEGD
 )
 
@@ -38,27 +36,15 @@ func HandleGetConnectedAccounts(store store.Storer, log *zap.Logger) http.Handle
 // AANGEPAST: Accepteert nu *zap.Logger
 func HandleDeleteConnectedAccount(store store.Storer, log *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		accountIDStr := chi.URLParam(r, "accountId")
-		accountID, err := uuid.Parse(accountIDStr)
+		account, err := common.GetAccountFromContext(r.Context())
 		if err != nil {
-			// AANGEPAST: log meegegeven
-			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig account ID", log)
-			return
-		}
-
-		userID, err := common.GetUserIDFromContext(r.Context())
-		if err != nil {
-			// AANGEPAST: log meegegeven
-			common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
-			return
-		}
-
-		account, err := store.GetConnectedAccountByID(r.Context(), accountID)
-		if err != nil || account.UserID != userID {
-			// AANGEPAST: log meegegeven
 			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
 			return
 		}
+		accountID := account.ID
+
+		// Stop eerst de push channels; na het verwijderen hebben we geen token meer
+		common.StopCalendarWatchChannels(r.Context(), store, accountID, log)
 
 		err = store.DeleteConnectedAccount(r.Context(), accountID)
 		if err != nil {
@@ -12,7 +12,6 @@ import (
 	"agenda-automator-api/internal/domain"
 	"agenda-automator-api/internal/store"
 
-	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -137,7 +136,6 @@ func TestHandleGetConnectedAccounts_StoreError(t *testing.T) {
 }
 
 func TestHandleDeleteConnectedAccount(t *testing.T) {
-	// AANGEPAST: Maak een test-logger
 	testLogger := zap.NewNop()
 
 	// Create a mock store
@@ -152,26 +150,22 @@ func TestHandleDeleteConnectedAccount(t *testing.T) {
 	}
 
 	// Set up the mocks
-	mockStore.On("GetConnectedAccountByID", mock.Anything, accountID).Return(account, nil)
+	mockStore.On("GetCalendarWatchChannelsForAccount", mock.Anything, accountID).
+		Return([]domain.CalendarWatchChannel{}, nil)
 	mockStore.On("DeleteConnectedAccount", mock.Anything, accountID).Return(nil)
 
-	// Create a request with context containing user ID and URL param
+	// Create a request; de ownership middleware heeft het account al in de context gezet
 	req, err := http.NewRequest("DELETE", "/api/v1/accounts/"+accountID.String(), http.NoBody)
 	assert.NoError(t, err)
 
 	ctx := context.WithValue(req.Context(), common.UserContextKey, userID)
+	ctx = common.WithAccount(ctx, account)
 	req = req.WithContext(ctx)
 
-	// Set up chi router context for URL params
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("accountId", accountID.String())
-	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
-
 	// Create a ResponseRecorder
 	rr := httptest.NewRecorder()
 
 	// Call the handler
-	// AANGEPAST: testLogger meegegeven
 	handler := HandleDeleteConnectedAccount(mockStore, testLogger)
 	handler.ServeHTTP(rr, req)
 
@@ -182,122 +176,66 @@ func TestHandleDeleteConnectedAccount(t *testing.T) {
 	mockStore.AssertExpectations(t)
 }
 
-func TestHandleDeleteConnectedAccount_InvalidAccountID(t *testing.T) {
-	// AANGEPAST: Maak een test-logger
-	testLogger := zap.NewNop()
-
-	// Create a mock store
-	mockStore := &store.MockStore{}
-
-	userID := uuid.New()
-
-	// Create a request with invalid account ID
-	req, err := http.NewRequest("DELETE", "/api/v1/accounts/invalid-id", http.NoBody)
-	assert.NoError(t, err)
-
-	ctx := context.WithValue(req.Context(), common.UserContextKey, userID)
-	req = req.WithContext(ctx)
-
-	// Set up chi router context for URL params
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("accountId", "invalid-id")
-	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
-
-	// Create a ResponseRecorder
-	rr := httptest.NewRecorder()
-
-	// Call the handler
-	// AANGEPAST: testLogger meegegeven
-	handler := HandleDeleteConnectedAccount(mockStore, testLogger)
-	handler.ServeHTTP(rr, req)
-
-	// Check the status code
-	assert.Equal(t, http.StatusBadRequest, rr.Code)
-}
-
-func TestHandleDeleteConnectedAccount_AccountNotFound(t *testing.T) {
-	// AANGEPAST: Maak een test-logger
+func TestHandleDeleteConnectedAccount_WithoutAccount(t *testing.T) {
 	testLogger := zap.NewNop()
 
 	// Create a mock store
 	mockStore := &store.MockStore{}
 
 	userID := uuid.New()
-	accountID := uuid.New()
 
-	// Set up the mock to return an error (account not found)
-	mockStore.On("GetConnectedAccountByID", mock.Anything, accountID).Return(domain.ConnectedAccount{}, assert.AnError)
-
-	// Create a request with context containing user ID and URL param
-	req, err := http.NewRequest("DELETE", "/api/v1/accounts/"+accountID.String(), http.NoBody)
+	// Create a request zonder account in de context (middleware niet doorlopen)
+	req, err := http.NewRequest("DELETE", "/api/v1/accounts/"+uuid.New().String(), http.NoBody)
 	assert.NoError(t, err)
 
 	ctx := context.WithValue(req.Context(), common.UserContextKey, userID)
 	req = req.WithContext(ctx)
 
-	// Set up chi router context for URL params
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("accountId", accountID.String())
-	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
-
 	// Create a ResponseRecorder
 	rr := httptest.NewRecorder()
 
 	// Call the handler
-	// AANGEPAST: testLogger meegegeven
 	handler := HandleDeleteConnectedAccount(mockStore, testLogger)
 	handler.ServeHTTP(rr, req)
 
 	// Check the status code
 	assert.Equal(t, http.StatusNotFound, rr.Code)
 
-	// Verify that the mock was called
-	mockStore.AssertExpectations(t)
+	// Er mag niets verwijderd worden
+	mockStore.AssertNotCalled(t, "DeleteConnectedAccount", mock.Anything, mock.Anything)
 }
 
-func TestHandleDeleteConnectedAccount_Unauthorized(t *testing.T) {
-	// AANGEPAST: Maak een test-logger
+func TestHandleDeleteConnectedAccount_StoreError(t *testing.T) {
 	testLogger := zap.NewNop()
 
 	// Create a mock store
 	mockStore := &store.MockStore{}
 
 	userID := uuid.New()
-	otherUserID := uuid.New()
 	accountID := uuid.New()
 
-	// Account belongs to different user
-	account := domain.ConnectedAccount{
-		ID:     accountID,
-		UserID: otherUserID, // Different user
-	}
-
-	// Set up the mock
-	mockStore.On("GetConnectedAccountByID", mock.Anything, accountID).Return(account, nil)
+	// Set up the mocks
+	mockStore.On("GetCalendarWatchChannelsForAccount", mock.Anything, accountID).
+		Return([]domain.CalendarWatchChannel{}, nil)
+	mockStore.On("DeleteConnectedAccount", mock.Anything, accountID).Return(assert.AnError)
 
-	// Create a request with context containing user ID and URL param
 	req, err := http.NewRequest("DELETE", "/api/v1/accounts/"+accountID.String(), http.NoBody)
 	assert.NoError(t, err)
 
 	ctx := context.WithValue(req.Context(), common.UserContextKey, userID)
+	ctx = common.WithAccount(ctx, domain.ConnectedAccount{ID: accountID, UserID: userID})
 	req = req.WithContext(ctx)
 
-	// Set up chi router context for URL params
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("accountId", accountID.String())
-	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
-
 	// Create a ResponseRecorder
 	rr := httptest.NewRecorder()
 
 	// Call the handler
-	// AANGEPAST: testLogger meegegeven
 	handler := HandleDeleteConnectedAccount(mockStore, testLogger)
 	handler.ServeHTTP(rr, req)
 
 	// Check the status code
-	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
 
-	// Verify that the mock was called
+	// Verify that the mocks were called
 	mockStore.AssertExpectations(t)
 }
@@ -20,12 +20,12 @@ const defaultCalendarID = "primary"
 // HandleCreateEvent creates a new event in Google Calendar.
 func HandleCreateEvent(store store.Storer, logger *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		accountIDStr := chi.URLParam(r, "accountId")
-		accountID, err := uuid.Parse(accountIDStr)
+		account, err := common.GetAccountFromContext(r.Context())
 		if err != nil {
-			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig account ID", logger) // <-- AANGEPAST
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", logger)
 			return
 		}
+		accountID := account.ID
 
 		calendarID := r.URL.Query().Get("calendarId") // Ondersteun secundaire calendars
 		if calendarID == "" {
@@ -68,13 +68,13 @@ func HandleCreateEvent(store store.Storer, logger *zap.Logger) http.HandlerFunc
 // HandleUpdateEvent updates an existing event.
 func HandleUpdateEvent(store store.Storer, logger *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		accountIDStr := chi.URLParam(r, "accountId")
 		eventID := chi.URLParam(r, "eventId")
-		accountID, err := uuid.Parse(accountIDStr)
+		account, err := common.GetAccountFromContext(r.Context())
 		if err != nil {
-			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig account ID", logger) // <-- AANGEPAST
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", logger)
 			return
 		}
+		accountID := account.ID
 
 		calendarID := r.URL.Query().Get("calendarId") // Ondersteun secundaire calendars
 		if calendarID == "" {
@@ -117,18 +117,18 @@ func HandleUpdateEvent(store store.Storer, logger *zap.Logger) http.HandlerFunc
 // HandleDeleteEvent deletes an event.
 func HandleDeleteEvent(store store.Storer, logger *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		accountIDStr := chi.URLParam(r, "accountId")
 		eventID := chi.URLParam(r, "eventId")
 		calendarID := r.URL.Query().Get("calendarId") // Optioneel param voor secundaire calendar
 		if calendarID == "" {
 			calendarID = defaultCalendarID
 		}
 
-		accountID, err := uuid.Parse(accountIDStr)
+		account, err := common.GetAccountFromContext(r.Context())
 		if err != nil {
-			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig account ID", logger) // <-- AANGEPAST
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", logger)
 			return
 		}
+		accountID := account.ID
 
 		ctx := r.Context()
 		client, err := common.GetCalendarClient(ctx, store, accountID, logger)
@@ -160,12 +160,12 @@ func HandleDeleteEvent(store store.Storer, logger *zap.Logger) http.HandlerFunc
 // HandleGetCalendarEvents retrieves events (optional calendarId param).
 func HandleGetCalendarEvents(store store.Storer, logger *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		accountIDStr := chi.URLParam(r, "accountId")
-		accountID, err := uuid.Parse(accountIDStr)
+		account, err := common.GetAccountFromContext(r.Context())
 		if err != nil {
-			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig account ID", logger) // <-- AANGEPAST
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", logger)
 			return
 		}
+		accountID := account.ID
 
 		calendarID := r.URL.Query().Get("calendarId") // Nieuw: Ondersteun secundaire calendars
 		if calendarID == "" {
@@ -256,12 +256,12 @@ func HandleGetCalendarEvents(store store.Storer, logger *zap.Logger) http.Handle
 // HandleListCalendars lists calendars for an account.
 func HandleListCalendars(store store.Storer, logger *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		accountIDStr := chi.URLParam(r, "accountId")
-		accountID, err := uuid.Parse(accountIDStr)
+		account, err := common.GetAccountFromContext(r.Context())
 		if err != nil {
-			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig account ID", logger) // <-- AANGEPAST
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", logger)
 			return
 		}
+		accountID := account.ID
 
 		ctx := r.Context()
 		userID, _ := common.GetUserIDFromContext(ctx) // Get user ID for logging
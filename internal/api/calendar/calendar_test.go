@@ -32,10 +32,14 @@ func setupTestHandlers(t *testing.T) (*store.MockStore, *zap.Logger) {
 	return mockStore, testLogger
 }
 
-// addTestContexts voegt de chi URL parameters en de user ID context toe.
+// addTestContexts voegt de chi URL parameters, de user ID en (net als de
+// ownership middleware) het account toe aan de context.
 func addTestContexts(req *http.Request, userID uuid.UUID, accountID string) *http.Request {
 	// Voeg user ID toe aan context
 	ctx := context.WithValue(req.Context(), common.UserContextKey, userID)
+	if id, err := uuid.Parse(accountID); err == nil {
+		ctx = common.WithAccount(ctx, domain.ConnectedAccount{ID: id, UserID: userID})
+	}
 
 	// Voeg URL parameters toe
 	rctx := chi.NewRouteContext()
@@ -86,21 +90,21 @@ func TestHandleCreateEvent(t *testing.T) {
 		mockStore.AssertExpectations(t)
 	})
 
-	t.Run("Error - Invalid Account ID", func(t *testing.T) {
+	t.Run("Error - No Account In Context", func(t *testing.T) {
 		// Arrange
 		mockStore, testLogger := setupTestHandlers(t)
 		handler := HandleCreateEvent(mockStore, testLogger)
 		rr := httptest.NewRecorder()
 
 		req, _ := http.NewRequest("POST", "/test", http.NoBody)
-		req = addTestContexts(req, userID, "invalid-id") // Geen UUID
+		req = addTestContexts(req, userID, "invalid-id") // Geen UUID, dus geen account
 
 		// Act
 		handler.ServeHTTP(rr, req)
 
 		// Assert
-		assert.Equal(t, http.StatusBadRequest, rr.Code)
-		assert.Contains(t, rr.Body.String(), "Ongeldig account ID")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Account niet gevonden")
 	})
 
 	t.Run("Error - Invalid JSON Body", func(t *testing.T) {
@@ -144,7 +148,7 @@ func TestHandleGetCalendarEvents(t *testing.T) {
 		mockStore.AssertExpectations(t)
 	})
 
-	t.Run("Error - Invalid Account ID", func(t *testing.T) {
+	t.Run("Error - No Account In Context", func(t *testing.T) {
 		// Arrange
 		mockStore, testLogger := setupTestHandlers(t)
 		handler := HandleGetCalendarEvents(mockStore, testLogger)
@@ -157,8 +161,8 @@ func TestHandleGetCalendarEvents(t *testing.T) {
 		handler.ServeHTTP(rr, req)
 
 		// Assert
-		assert.Equal(t, http.StatusBadRequest, rr.Code)
-		assert.Contains(t, rr.Body.String(), "Ongeldig account ID")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Account niet gevonden")
 	})
 }
 
@@ -237,3 +241,88 @@ func TestHandleGetAggregatedEvents(t *testing.T) {
 		assert.Contains(t, rr.Body.String(), "missing or invalid user ID in context")
 	})
 }
+
+// fakeSyncQueue onthoudt welke syncs zijn ingepland.
+type fakeSyncQueue struct {
+	requests []uuid.UUID
+}
+
+func (q *fakeSyncQueue) EnqueueCalendarSync(accountID uuid.UUID, _ string) bool {
+	q.requests = append(q.requests, accountID)
+	return true
+}
+
+// newNotificationRequest bouwt een push notificatie zoals Google die stuurt.
+func newNotificationRequest(channelID, token, resourceID, state string) *http.Request {
+	req := httptest.NewRequest("POST", "/api/v1/webhooks/google/calendar", http.NoBody)
+	req.Header.Set("X-Goog-Channel-ID", channelID)
+	req.Header.Set("X-Goog-Channel-Token", token)
+	req.Header.Set("X-Goog-Resource-ID", resourceID)
+	req.Header.Set("X-Goog-Resource-State", state)
+	return req
+}
+
+func TestHandleCalendarNotification(t *testing.T) {
+	accountID := uuid.New()
+	channel := domain.CalendarWatchChannel{
+		ConnectedAccountID: accountID,
+		CalendarID:         "primary",
+		ChannelID:          "channel-1",
+		ResourceID:         "resource-1",
+		Token:              "secret",
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockStore, testLogger := setupTestHandlers(t)
+		queue := &fakeSyncQueue{}
+		mockStore.On("GetCalendarWatchChannelByChannelID", mock.Anything, "channel-1").Return(channel, nil).Once()
+
+		rr := httptest.NewRecorder()
+		HandleCalendarNotification(mockStore, queue, testLogger).
+			ServeHTTP(rr, newNotificationRequest("channel-1", "secret", "resource-1", "exists"))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, []uuid.UUID{accountID}, queue.requests)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("Sync handshake is not enqueued", func(t *testing.T) {
+		mockStore, testLogger := setupTestHandlers(t)
+		queue := &fakeSyncQueue{}
+		mockStore.On("GetCalendarWatchChannelByChannelID", mock.Anything, "channel-1").Return(channel, nil).Once()
+
+		rr := httptest.NewRecorder()
+		HandleCalendarNotification(mockStore, queue, testLogger).
+			ServeHTTP(rr, newNotificationRequest("channel-1", "secret", "resource-1", "sync"))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, queue.requests)
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		mockStore, testLogger := setupTestHandlers(t)
+		queue := &fakeSyncQueue{}
+		mockStore.On("GetCalendarWatchChannelByChannelID", mock.Anything, "channel-1").Return(channel, nil).Once()
+
+		rr := httptest.NewRecorder()
+		HandleCalendarNotification(mockStore, queue, testLogger).
+			ServeHTTP(rr, newNotificationRequest("channel-1", "wrong", "resource-1", "exists"))
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Empty(t, queue.requests)
+	})
+
+	t.Run("Unknown channel", func(t *testing.T) {
+		mockStore, testLogger := setupTestHandlers(t)
+		queue := &fakeSyncQueue{}
+		mockStore.On("GetCalendarWatchChannelByChannelID", mock.Anything, "unknown").
+			Return(domain.CalendarWatchChannel{}, assert.AnError).Once()
+
+		rr := httptest.NewRecorder()
+		HandleCalendarNotification(mockStore, queue, testLogger).
+			ServeHTTP(rr, newNotificationRequest("unknown", "secret", "resource-1", "exists"))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Empty(t, queue.requests)
+	})
+}
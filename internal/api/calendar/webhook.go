@@ -0,0 +1,64 @@
+package calendar
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// SyncEnqueuer plant een directe agenda-sync in. Wordt geïmplementeerd door de worker.
+type SyncEnqueuer interface {
+	EnqueueCalendarSync(accountID uuid.UUID, calendarID string) bool
+}
+
+// HandleCalendarNotification ontvangt push notificaties van Google Calendar (events.watch).
+// Google stuurt geen body mee; alle informatie zit in de X-Goog-* headers.
+func HandleCalendarNotification(store store.Storer, queue SyncEnqueuer, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		channelID := r.Header.Get("X-Goog-Channel-ID")
+		if channelID == "" {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ontbrekend channel ID", logger)
+			return
+		}
+
+		channel, err := store.GetCalendarWatchChannelByChannelID(r.Context(), channelID)
+		if err != nil {
+			common.WriteJSONError(w, http.StatusNotFound, "Channel niet gevonden", logger)
+			return
+		}
+
+		token := r.Header.Get("X-Goog-Channel-Token")
+		resourceID := r.Header.Get("X-Goog-Resource-ID")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(channel.Token)) != 1 ||
+			resourceID != channel.ResourceID {
+			logger.Warn(
+				"rejected calendar notification with invalid token",
+				zap.String("channel_id", channelID),
+				zap.String("component", "api"),
+			)
+			common.WriteJSONError(w, http.StatusForbidden, "Ongeldig channel token", logger)
+			return
+		}
+
+		// De eerste notificatie bevestigt alleen dat het channel open staat
+		if r.Header.Get("X-Goog-Resource-State") == "sync" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		if !queue.EnqueueCalendarSync(channel.ConnectedAccountID, channel.CalendarID) {
+			logger.Warn(
+				"calendar sync queue full, change will be picked up by the next cycle",
+				zap.String("account_id", channel.ConnectedAccountID.String()),
+				zap.String("component", "api"),
+			)
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
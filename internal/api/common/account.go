@@ -0,0 +1,65 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// AccountContextKey is de context key voor het account uit de /accounts/{accountId} route
+var AccountContextKey contextKey = "account"
+
+// WithAccount zet een (gecontroleerd) account in de context
+func WithAccount(ctx context.Context, account domain.ConnectedAccount) context.Context {
+	return context.WithValue(ctx, AccountContextKey, account)
+}
+
+// GetAccountFromContext haalt het account op dat door AccountOwnershipMiddleware in de context is gezet
+func GetAccountFromContext(ctx context.Context) (domain.ConnectedAccount, error) {
+	account, ok := ctx.Value(AccountContextKey).(domain.ConnectedAccount)
+	if !ok {
+		return domain.ConnectedAccount{}, fmt.Errorf("missing account in context")
+	}
+	return account, nil
+}
+
+// AccountOwnershipMiddleware controleert voor de hele /accounts/{accountId} subtree of het account
+// bij de ingelogde gebruiker hoort, en zet het daarna in de context. Een account van een andere
+// gebruiker geeft dezelfde 404 als een account dat niet bestaat.
+func AccountOwnershipMiddleware(storer store.Storer, log *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			accountID, err := uuid.Parse(chi.URLParam(r, "accountId"))
+			if err != nil {
+				WriteJSONError(w, http.StatusBadRequest, "Ongeldig account ID", log)
+				return
+			}
+
+			userID, err := GetUserIDFromContext(r.Context())
+			if err != nil {
+				WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
+				return
+			}
+
+			if err := storer.VerifyAccountOwnership(r.Context(), accountID, userID); err != nil {
+				WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
+				return
+			}
+
+			account, err := storer.GetConnectedAccountByID(r.Context(), accountID)
+			if err != nil {
+				WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithAccount(r.Context(), account)))
+		})
+	}
+}
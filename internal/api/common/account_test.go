@@ -0,0 +1,101 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// serveAccountRoute stuurt een request door een router met de middleware, zoals in de server
+func serveAccountRoute(mockStore *store.MockStore, userID *uuid.UUID, accountID string) (*httptest.ResponseRecorder, *domain.ConnectedAccount) {
+	var seen *domain.ConnectedAccount
+	r := chi.NewRouter()
+	r.Route("/accounts/{accountId}", func(r chi.Router) {
+		r.Use(AccountOwnershipMiddleware(mockStore, zap.NewNop()))
+		r.Get("/rules", func(w http.ResponseWriter, r *http.Request) {
+			account, err := GetAccountFromContext(r.Context())
+			if err == nil {
+				seen = &account
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+	})
+
+	req := httptest.NewRequest("GET", "/accounts/"+accountID+"/rules", http.NoBody)
+	if userID != nil {
+		req = req.WithContext(context.WithValue(req.Context(), UserContextKey, *userID))
+	}
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr, seen
+}
+
+func TestAccountOwnershipMiddleware(t *testing.T) {
+	userID := uuid.New()
+	accountID := uuid.New()
+
+	t.Run("Owner krijgt het account in de context", func(t *testing.T) {
+		mockStore := new(store.MockStore)
+		account := domain.ConnectedAccount{ID: accountID, UserID: userID, Email: "jeffrey@example.com"}
+		mockStore.On("VerifyAccountOwnership", mock.Anything, accountID, userID).Return(nil)
+		mockStore.On("GetConnectedAccountByID", mock.Anything, accountID).Return(account, nil)
+
+		rr, seen := serveAccountRoute(mockStore, &userID, accountID.String())
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, &account, seen)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("Account van een andere gebruiker geeft 404", func(t *testing.T) {
+		mockStore := new(store.MockStore)
+		mockStore.On("VerifyAccountOwnership", mock.Anything, accountID, userID).
+			Return(errors.New("forbidden: account not found or does not belong to user"))
+
+		rr, seen := serveAccountRoute(mockStore, &userID, accountID.String())
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Account niet gevonden")
+		assert.Nil(t, seen)
+		mockStore.AssertNotCalled(t, "GetConnectedAccountByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Ongeldig account ID", func(t *testing.T) {
+		mockStore := new(store.MockStore)
+
+		rr, seen := serveAccountRoute(mockStore, &userID, "invalid-id")
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Nil(t, seen)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("Zonder gebruiker", func(t *testing.T) {
+		mockStore := new(store.MockStore)
+
+		rr, _ := serveAccountRoute(mockStore, nil, accountID.String())
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+func TestGetAccountFromContext(t *testing.T) {
+	_, err := GetAccountFromContext(context.Background())
+	assert.Error(t, err)
+
+	account := domain.ConnectedAccount{ID: uuid.New()}
+	got, err := GetAccountFromContext(WithAccount(context.Background(), account))
+	assert.NoError(t, err)
+	assert.Equal(t, account, got)
+}
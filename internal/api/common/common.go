@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"strings"
 
+	"agenda-automator-api/internal/rules"
 	"agenda-automator-api/internal/store" // logger package was hier niet nodig
 
 	"github.com/google/uuid"
@@ -51,6 +52,17 @@ func WriteJSONError(w http.ResponseWriter, status int, message string, logger *z
 	WriteJSON(w, status, map[string]string{"error": message}, logger)
 }
 
+// ValidationErrorResponse is de body van een 422: alle ongeldige velden met hun pad in de request body.
+type ValidationErrorResponse struct {
+	Error  string            `json:"error"`
+	Fields rules.FieldErrors `json:"fields"`
+}
+
+// WriteValidationErrors schrijft een 422 response met elk ongeldig veld.
+func WriteValidationErrors(w http.ResponseWriter, fields rules.FieldErrors, logger *zap.Logger) {
+	WriteJSON(w, http.StatusUnprocessableEntity, ValidationErrorResponse{Error: "Validatie mislukt", Fields: fields}, logger)
+}
+
 // getOAuthClient is a helper to get an OAuth2 HTTP client for Google APIs
 func getOAuthClient(
 	ctx context.Context,
@@ -110,6 +122,39 @@ func GetGmailClient(
 	return gmail.NewService(cleanCtx, option.WithHTTPClient(client))
 }
 
+// StopCalendarWatchChannels stopt alle Calendar push channels van een account bij Google.
+// Dit is best-effort: de rijen verdwijnen via ON DELETE CASCADE, en een channel dat
+// niet gestopt kan worden verloopt vanzelf.
+func StopCalendarWatchChannels(
+	ctx context.Context,
+	store store.Storer,
+	accountID uuid.UUID,
+	logger *zap.Logger,
+) {
+	channels, err := store.GetCalendarWatchChannelsForAccount(ctx, accountID)
+	if err != nil || len(channels) == 0 {
+		return
+	}
+
+	client, err := GetCalendarClient(ctx, store, accountID, logger)
+	if err != nil {
+		return
+	}
+
+	for _, ch := range channels {
+		stopErr := client.Channels.Stop(&calendar.Channel{Id: ch.ChannelID, ResourceId: ch.ResourceID}).Do()
+		if stopErr != nil {
+			logger.Warn(
+				"failed to stop calendar watch channel",
+				zap.Error(stopErr),
+				zap.String("channel_id", ch.ChannelID),
+				zap.String("account_id", accountID.String()),
+				zap.String("component", "api"),
+			)
+		}
+	}
+}
+
 // ParseEmailAddresses parses a comma-separated string of email addresses.
 func ParseEmailAddresses(emailString string) []string {
 	if emailString == "" {
@@ -7,6 +7,8 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"agenda-automator-api/internal/rules"
+
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
@@ -92,6 +94,21 @@ func TestWriteJSONError(t *testing.T) {
 	})
 }
 
+func TestWriteValidationErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	WriteValidationErrors(w, rules.FieldErrors{
+		{Field: "name", Message: "is required"},
+		{Field: "action_params.offset_minutes", Message: "must be between -10080 and 10080"},
+	}, zap.NewNop())
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.JSONEq(t, `{"error":"Validatie mislukt","fields":[
+		{"field":"name","message":"is required"},
+		{"field":"action_params.offset_minutes","message":"must be between -10080 and 10080"}
+	]}`, w.Body.String())
+}
+
 func TestParseEmailAddresses(t *testing.T) {
 	t.Run("Single email", func(t *testing.T) {
 		result := ParseEmailAddresses("test@example.com")
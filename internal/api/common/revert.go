@@ -0,0 +1,82 @@
+package common
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// revertRequest is de body van de revert endpoints: de periode waarin de uitvoeringen vielen.
+type revertRequest struct {
+	TimeMin *time.Time `json:"time_min"`
+	TimeMax *time.Time `json:"time_max,omitempty"`
+}
+
+// RevertResponse is het resultaat van een revert endpoint.
+type RevertResponse struct {
+	TimeMin time.Time `json:"time_min"`
+	TimeMax time.Time `json:"time_max"`
+	rules.RevertReport
+}
+
+// GroupLogsByAccount verdeelt logs over de accounts waarop de rule draaide, in de volgorde
+// waarin de accounts voorkomen. Een user-level rule heeft logs van meerdere accounts, die elk
+// met hun eigen client teruggedraaid worden.
+func GroupLogsByAccount(logs []domain.AutomationLog) ([]uuid.UUID, map[uuid.UUID][]domain.AutomationLog) {
+	var accountIDs []uuid.UUID
+	byAccount := make(map[uuid.UUID][]domain.AutomationLog)
+	for _, l := range logs {
+		if _, seen := byAccount[l.ConnectedAccountID]; !seen {
+			accountIDs = append(accountIDs, l.ConnectedAccountID)
+		}
+		byAccount[l.ConnectedAccountID] = append(byAccount[l.ConnectedAccountID], l)
+	}
+	return accountIDs, byAccount
+}
+
+// DecodeRevertRequest leest de periode van een revert. time_min is verplicht, time_max is standaard nu.
+func DecodeRevertRequest(w http.ResponseWriter, r *http.Request, logger *zap.Logger) (time.Time, time.Time, bool) {
+	var req revertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", logger)
+		return time.Time{}, time.Time{}, false
+	}
+	if req.TimeMin == nil {
+		WriteJSONError(w, http.StatusBadRequest, "time_min is verplicht", logger)
+		return time.Time{}, time.Time{}, false
+	}
+	if req.TimeMax == nil {
+		now := time.Now()
+		req.TimeMax = &now
+	}
+	if !req.TimeMax.After(*req.TimeMin) {
+		WriteJSONError(w, http.StatusBadRequest, "time_max moet na time_min liggen", logger)
+		return time.Time{}, time.Time{}, false
+	}
+	return *req.TimeMin, *req.TimeMax, true
+}
+
+// WriteRevertReport markeert de teruggedraaide logs en schrijft het rapport.
+func WriteRevertReport(
+	w http.ResponseWriter,
+	r *http.Request,
+	storer store.Storer,
+	timeMin, timeMax time.Time,
+	report rules.RevertReport,
+	logger *zap.Logger,
+) {
+	if ids := report.RevertedLogIDs(); len(ids) > 0 {
+		if err := storer.MarkLogsReverted(r.Context(), ids); err != nil {
+			// De acties zijn al teruggedraaid; een volgende revert probeert ze hooguit opnieuw
+			logger.Error("HANDLER ERROR [MarkLogsReverted]", zap.Error(err))
+		}
+	}
+	WriteJSON(w, http.StatusOK, RevertResponse{TimeMin: timeMin, TimeMax: timeMax, RevertReport: report}, logger)
+}
@@ -0,0 +1,58 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+)
+
+// ValidateAccountSelector geeft de ongeldige velden van de selector van een user-level rule, onder
+// "account_selector". Accounts in een lijst moeten van de gebruiker zijn; een fout betekent dat
+// de accounts niet opgehaald konden worden.
+func ValidateAccountSelector(
+	ctx context.Context,
+	storer store.Storer,
+	userID uuid.UUID,
+	selector *domain.AccountSelector,
+) (rules.FieldErrors, error) {
+	var errs rules.FieldErrors
+	if selector == nil {
+		errs.Add("account_selector", "is required")
+		return errs, nil
+	}
+
+	switch selector.Mode {
+	case domain.AccountSelectorAll:
+	case domain.AccountSelectorAccounts:
+		if len(selector.AccountIDs) == 0 {
+			errs.Add("account_selector.account_ids", "is required")
+			break
+		}
+		accounts, err := storer.GetAccountsForUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		owned := make(map[uuid.UUID]bool, len(accounts))
+		for _, acc := range accounts {
+			owned[acc.ID] = true
+		}
+		for i, id := range selector.AccountIDs {
+			if !owned[id] {
+				errs.Add(fmt.Sprintf("account_selector.account_ids[%d]", i), "unknown account %s", id)
+			}
+		}
+	case domain.AccountSelectorProvider:
+		if selector.Provider != domain.ProviderGoogle && selector.Provider != domain.ProviderMicrosoft {
+			errs.Add("account_selector.provider", "must be one of %s, %s", domain.ProviderGoogle, domain.ProviderMicrosoft)
+		}
+	default:
+		errs.Add("account_selector.mode", "must be one of %s, %s, %s",
+			domain.AccountSelectorAll, domain.AccountSelectorAccounts, domain.AccountSelectorProvider)
+	}
+	return errs, nil
+}
@@ -0,0 +1,262 @@
+package gmail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/api/gmail/v1"
+)
+
+// batchModifyChunkSize is het maximum aantal berichten per BatchModify call van de Gmail API
+const batchModifyChunkSize = 1000
+
+// maxQuerySelection is het maximum aantal berichten dat een zoekopdracht selecteert; de rest
+// blijft onaangeroerd en kan met een volgende aanroep bewerkt worden. Een lijst messageIds mag
+// niet langer zijn.
+const maxQuerySelection = 10000
+
+// BatchOperation is een bewerking van het bulk endpoint
+type BatchOperation string
+
+const (
+	BatchAddLabels    BatchOperation = "add_labels"
+	BatchRemoveLabels BatchOperation = "remove_labels"
+	BatchMarkRead     BatchOperation = "mark_read"
+	BatchMarkUnread   BatchOperation = "mark_unread"
+	BatchArchive      BatchOperation = "archive"
+	BatchTrash        BatchOperation = "trash"
+	BatchStar         BatchOperation = "star"
+	BatchUnstar       BatchOperation = "unstar"
+)
+
+// batchRequest is de body van het bulk endpoint. Precies één van MessageIDs en Query is gezet.
+type batchRequest struct {
+	MessageIDs []string       `json:"messageIds,omitempty"`
+	Query      string         `json:"query,omitempty"`
+	Operation  BatchOperation `json:"operation"`
+	LabelIDs   []string       `json:"labelIds,omitempty"`
+}
+
+// BatchChunkResult is het resultaat van één BatchModify call
+type BatchChunkResult struct {
+	Index    int    `json:"index"`
+	Messages int    `json:"messages"`
+	Success  bool   `json:"success"`
+	Error    string `json:"error,omitempty"`
+}
+
+// BatchResult is het antwoord van het bulk endpoint. Bij een zoekopdracht is Limit het maximum aantal
+// geselecteerde berichten en Truncated of er meer berichten op de zoekopdracht passen.
+type BatchResult struct {
+	Operation BatchOperation     `json:"operation"`
+	Total     int                `json:"total"`
+	Limit     int                `json:"limit,omitempty"`
+	Truncated bool               `json:"truncated"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	Chunks    []BatchChunkResult `json:"chunks"`
+}
+
+// batchModification is de vertaling van een bewerking naar Gmail labels en de wijziging in de cache
+type batchModification struct {
+	add     []string
+	remove  []string
+	status  domain.GmailMessageStatus // leeg als de status in de cache niet verandert
+	starred *bool                     // nil als de ster in de cache niet verandert
+}
+
+// HandleBatchGmailMessages voert één bewerking uit op een lijst berichten of op de eerste
+// maxQuerySelection berichten die op een zoekopdracht passen, in chunks van 1000 via BatchModify.
+func HandleBatchGmailMessages(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, err := common.GetAccountFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
+			return
+		}
+
+		var req batchRequest
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
+			return
+		}
+		hasQuery := strings.TrimSpace(req.Query) != ""
+		if (len(req.MessageIDs) > 0) == hasQuery {
+			common.WriteJSONError(w, http.StatusBadRequest, "Geef messageIds of query op, niet allebei", log)
+			return
+		}
+		if len(req.MessageIDs) > maxQuerySelection {
+			var fieldErrs rules.FieldErrors
+			fieldErrs.Add("messageIds", "must contain at most %d message IDs", maxQuerySelection)
+			common.WriteValidationErrors(w, fieldErrs, log)
+			return
+		}
+		modification, err := modificationFor(req.Operation, req.LabelIDs)
+		if err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, err.Error(), log)
+			return
+		}
+
+		ctx := r.Context()
+		client, err := common.GetGmailClient(ctx, storer, account.ID, log)
+		if err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail client niet initialiseren", log)
+			return
+		}
+
+		result := BatchResult{Operation: req.Operation, Chunks: []BatchChunkResult{}}
+		messageIDs := uniqueIDs(req.MessageIDs)
+		if hasQuery {
+			messageIDs, result.Truncated, err = searchMessageIDs(ctx, client, req.Query, maxQuerySelection)
+			if err != nil {
+				common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon berichten niet zoeken: %v", err), log)
+				return
+			}
+			result.Limit = maxQuerySelection
+		}
+		result.Total = len(messageIDs)
+
+		for i, chunk := range chunkIDs(messageIDs, batchModifyChunkSize) {
+			chunkResult := BatchChunkResult{Index: i, Messages: len(chunk)}
+			err := client.Users.Messages.BatchModify("me", &gmail.BatchModifyMessagesRequest{
+				Ids:            chunk,
+				AddLabelIds:    modification.add,
+				RemoveLabelIds: modification.remove,
+			}).Context(ctx).Do()
+			if err != nil {
+				log.Warn("gmail batch modify failed",
+					zap.String("account_id", account.ID.String()), zap.Int("chunk", i), zap.Error(err))
+				chunkResult.Error = err.Error()
+				result.Failed += len(chunk)
+			} else {
+				chunkResult.Success = true
+				result.Succeeded += len(chunk)
+				updateCache(ctx, storer, account.ID, chunk, modification, log)
+			}
+			result.Chunks = append(result.Chunks, chunkResult)
+		}
+
+		common.WriteJSON(w, http.StatusOK, result, log)
+	}
+}
+
+// modificationFor vertaalt een bewerking naar de labels die BatchModify toevoegt of verwijdert.
+func modificationFor(operation BatchOperation, labelIDs []string) (batchModification, error) {
+	switch operation {
+	case BatchAddLabels, BatchRemoveLabels:
+		if len(labelIDs) == 0 {
+			return batchModification{}, fmt.Errorf("labelIds is verplicht voor %s", operation)
+		}
+		if operation == BatchAddLabels {
+			return batchModification{add: labelIDs}, nil
+		}
+		return batchModification{remove: labelIDs}, nil
+	case BatchMarkRead:
+		return batchModification{remove: []string{"UNREAD"}, status: domain.GmailRead}, nil
+	case BatchMarkUnread:
+		return batchModification{add: []string{"UNREAD"}, status: domain.GmailUnread}, nil
+	case BatchArchive:
+		return batchModification{remove: []string{"INBOX"}, status: domain.GmailArchived}, nil
+	case BatchTrash:
+		return batchModification{add: []string{"TRASH"}, status: domain.GmailTrashed}, nil
+	case BatchStar:
+		starred := true
+		return batchModification{add: []string{"STARRED"}, starred: &starred}, nil
+	case BatchUnstar:
+		starred := false
+		return batchModification{remove: []string{"STARRED"}, starred: &starred}, nil
+	default:
+		return batchModification{}, fmt.Errorf("ongeldige bewerking: %q", operation)
+	}
+}
+
+// errSelectionFull stopt het pagineren zodra de selectie het maximum bereikt
+var errSelectionFull = errors.New("selection full")
+
+// searchMessageIDs haalt de IDs op van maximaal limit berichten die op de zoekopdracht passen.
+// truncated geeft aan of er meer berichten passen dan er geselecteerd zijn.
+func searchMessageIDs(ctx context.Context, client *gmail.Service, query string, limit int) (ids []string, truncated bool, err error) {
+	err = client.Users.Messages.List("me").Q(query).MaxResults(500).Pages(ctx, func(page *gmail.ListMessagesResponse) error {
+		for _, msg := range page.Messages {
+			if len(ids) == limit {
+				truncated = true
+				return errSelectionFull
+			}
+			ids = append(ids, msg.Id)
+		}
+		if len(ids) == limit && page.NextPageToken != "" {
+			truncated = true
+			return errSelectionFull
+		}
+		return nil
+	})
+	if errors.Is(err, errSelectionFull) {
+		err = nil
+	}
+	return ids, truncated, err
+}
+
+// updateCache werkt gmail_messages in één statement bij na een geslaagde chunk. Berichten die
+// (nog) niet gesynchroniseerd zijn staan niet in de cache; dat is geen fout.
+func updateCache(
+	ctx context.Context,
+	storer store.Storer,
+	accountID uuid.UUID,
+	messageIDs []string,
+	modification batchModification,
+	log *zap.Logger,
+) {
+	if modification.status == "" && modification.starred == nil {
+		return
+	}
+	params := store.UpdateGmailMessagesCacheParams{
+		ConnectedAccountID: accountID,
+		GmailMessageIDs:    messageIDs,
+		IsStarred:          modification.starred,
+	}
+	if modification.status != "" {
+		params.Status = &modification.status
+	}
+	if _, err := storer.UpdateGmailMessagesCache(ctx, params); err != nil {
+		log.Warn("gmail messages not updated in cache",
+			zap.String("account_id", accountID.String()), zap.Int("messages", len(messageIDs)), zap.Error(err))
+	}
+}
+
+// chunkIDs verdeelt ids in opeenvolgende stukken van maximaal size.
+func chunkIDs(ids []string, size int) [][]string {
+	var chunks [][]string
+	for len(ids) > size {
+		chunks = append(chunks, ids[:size])
+		ids = ids[size:]
+	}
+	if len(ids) > 0 {
+		chunks = append(chunks, ids)
+	}
+	return chunks
+}
+
+// uniqueIDs verwijdert lege en dubbele IDs met behoud van volgorde.
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	return unique
+}
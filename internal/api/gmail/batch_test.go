@@ -0,0 +1,192 @@
+package gmail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+)
+
+func TestModificationFor(t *testing.T) {
+	tests := []struct {
+		operation  BatchOperation
+		labelIDs   []string
+		wantAdd    []string
+		wantRemove []string
+		wantStatus domain.GmailMessageStatus
+		wantStar   *bool
+	}{
+		{operation: BatchAddLabels, labelIDs: []string{"Label_1"}, wantAdd: []string{"Label_1"}},
+		{operation: BatchRemoveLabels, labelIDs: []string{"Label_1"}, wantRemove: []string{"Label_1"}},
+		{operation: BatchMarkRead, wantRemove: []string{"UNREAD"}, wantStatus: domain.GmailRead},
+		{operation: BatchMarkUnread, wantAdd: []string{"UNREAD"}, wantStatus: domain.GmailUnread},
+		{operation: BatchArchive, wantRemove: []string{"INBOX"}, wantStatus: domain.GmailArchived},
+		{operation: BatchTrash, wantAdd: []string{"TRASH"}, wantStatus: domain.GmailTrashed},
+		{operation: BatchStar, wantAdd: []string{"STARRED"}, wantStar: boolPtr(true)},
+		{operation: BatchUnstar, wantRemove: []string{"STARRED"}, wantStar: boolPtr(false)},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.operation), func(t *testing.T) {
+			m, err := modificationFor(tt.operation, tt.labelIDs)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAdd, m.add)
+			assert.Equal(t, tt.wantRemove, m.remove)
+			assert.Equal(t, tt.wantStatus, m.status)
+			assert.Equal(t, tt.wantStar, m.starred)
+		})
+	}
+
+	_, err := modificationFor(BatchAddLabels, nil)
+	assert.Error(t, err)
+	_, err = modificationFor("delete", nil)
+	assert.Error(t, err)
+}
+
+func TestChunkIDs(t *testing.T) {
+	ids := make([]string, 2500)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("msg-%d", i)
+	}
+
+	chunks := chunkIDs(ids, batchModifyChunkSize)
+	require.Len(t, chunks, 3)
+	assert.Len(t, chunks[0], 1000)
+	assert.Len(t, chunks[1], 1000)
+	assert.Len(t, chunks[2], 500)
+	assert.Equal(t, "msg-2499", chunks[2][499])
+
+	assert.Nil(t, chunkIDs(nil, batchModifyChunkSize))
+}
+
+func TestUniqueIDs(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, uniqueIDs([]string{"a", "", "b", "a"}))
+}
+
+func TestUpdateCache(t *testing.T) {
+	mockStore := &store.MockStore{}
+	accountID := uuid.New()
+	archived := domain.GmailArchived
+
+	// Eén statement voor de hele chunk; niet-gecachte berichten zijn geen fout
+	mockStore.On("UpdateGmailMessagesCache", mock.Anything, store.UpdateGmailMessagesCacheParams{
+		ConnectedAccountID: accountID,
+		GmailMessageIDs:    []string{"msg-1", "msg-2"},
+		Status:             &archived,
+	}).Return(int64(1), nil).Once()
+	mockStore.On("UpdateGmailMessagesCache", mock.Anything, store.UpdateGmailMessagesCacheParams{
+		ConnectedAccountID: accountID,
+		GmailMessageIDs:    []string{"msg-3"},
+		IsStarred:          boolPtr(false),
+	}).Return(int64(0), errors.New("db down")).Once()
+
+	archive, _ := modificationFor(BatchArchive, nil)
+	updateCache(context.Background(), mockStore, accountID, []string{"msg-1", "msg-2"}, archive, zap.NewNop())
+	unstar, _ := modificationFor(BatchUnstar, nil)
+	updateCache(context.Background(), mockStore, accountID, []string{"msg-3"}, unstar, zap.NewNop())
+
+	// Labels veranderen niets in de cache
+	labels, _ := modificationFor(BatchAddLabels, []string{"Label_1"})
+	updateCache(context.Background(), mockStore, accountID, []string{"msg-4"}, labels, zap.NewNop())
+
+	mockStore.AssertExpectations(t)
+	mockStore.AssertNumberOfCalls(t, "UpdateGmailMessagesCache", 2)
+}
+
+func TestSearchMessageIDs_Limit(t *testing.T) {
+	// Drie pagina's van twee berichten
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("pageToken")
+		next := map[string]string{"": "p2", "p2": "p3", "p3": ""}[page]
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"messages":[{"id":"%[1]s-a"},{"id":"%[1]s-b"}],"nextPageToken":%[2]q}`, page, next)
+	}))
+	defer server.Close()
+	client, err := gmail.NewService(context.Background(), option.WithHTTPClient(server.Client()), option.WithEndpoint(server.URL))
+	require.NoError(t, err)
+
+	tests := []struct {
+		limit         int
+		wantIDs       int
+		wantTruncated bool
+	}{
+		{limit: 3, wantIDs: 3, wantTruncated: true},
+		{limit: 4, wantIDs: 4, wantTruncated: true},
+		{limit: 6, wantIDs: 6, wantTruncated: false},
+		{limit: 10, wantIDs: 6, wantTruncated: false},
+	}
+	for _, tt := range tests {
+		ids, truncated, err := searchMessageIDs(context.Background(), client, "from:x", tt.limit)
+		require.NoError(t, err)
+		assert.Len(t, ids, tt.wantIDs, "limit %d", tt.limit)
+		assert.Equal(t, tt.wantTruncated, truncated, "limit %d", tt.limit)
+	}
+}
+
+func TestHandleBatchGmailMessages_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "neither ids nor query", body: `{"operation":"archive"}`, want: "Geef messageIds of query op"},
+		{name: "both ids and query", body: `{"messageIds":["a"],"query":"from:x","operation":"archive"}`, want: "Geef messageIds of query op"},
+		{name: "unknown operation", body: `{"messageIds":["a"],"operation":"delete"}`, want: "ongeldige bewerking"},
+		{name: "labels missing", body: `{"messageIds":["a"],"operation":"add_labels"}`, want: "labelIds is verplicht"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &store.MockStore{}
+			req := newAccountRequest("POST", tt.body, uuid.New(), map[string]string{"accountId": uuid.New().String()})
+			rr := httptest.NewRecorder()
+
+			HandleBatchGmailMessages(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Contains(t, rr.Body.String(), tt.want)
+			mockStore.AssertNotCalled(t, "GetValidTokenForAccount", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestHandleBatchGmailMessages_TooManyIDs(t *testing.T) {
+	ids := make([]string, maxQuerySelection+1)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("msg-%d", i)
+	}
+	body, err := json.Marshal(batchRequest{MessageIDs: ids, Operation: BatchArchive})
+	require.NoError(t, err)
+
+	mockStore := &store.MockStore{}
+	req := newAccountRequest("POST", string(body), uuid.New(), map[string]string{"accountId": uuid.New().String()})
+	rr := httptest.NewRecorder()
+
+	HandleBatchGmailMessages(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	var response common.ValidationErrorResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	require.Len(t, response.Fields, 1)
+	assert.Equal(t, "messageIds", response.Fields[0].Field)
+	mockStore.AssertNotCalled(t, "GetValidTokenForAccount", mock.Anything, mock.Anything)
+}
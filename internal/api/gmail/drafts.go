@@ -0,0 +1,485 @@
+package gmail
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"slices"
+	"strings"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/email"
+	"agenda-automator-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// HandleCreateGmailDraft maakt een draft aan in Gmail en bewaart een kopie in gmail_drafts.
+func HandleCreateGmailDraft(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, err := common.GetAccountFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
+			return
+		}
+
+		var req domain.GmailDraftInput
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
+			return
+		}
+		if len(req.KeepAttachmentIDs) > 0 {
+			common.WriteJSONError(w, http.StatusBadRequest, "keepAttachmentIds kan alleen bij het bijwerken van een draft", log)
+			return
+		}
+		if _, err = email.FromOutgoing(req.GmailOutgoingMessage).Build(); err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, fmt.Sprintf("Ongeldig bericht: %v", err), log)
+			return
+		}
+
+		ctx := r.Context()
+		client, err := common.GetGmailClient(ctx, storer, account.ID, log)
+		if err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail client niet initialiseren", log)
+			return
+		}
+
+		message, err := email.ComposeGmail(client, req.GmailOutgoingMessage)
+		if err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon draft niet opstellen: %v", err), log)
+			return
+		}
+
+		created, err := client.Users.Drafts.Create("me", &gmail.Draft{Message: message}).Do()
+		if err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon draft niet creren: %v", err), log)
+			return
+		}
+
+		draft, err := cacheDraft(ctx, client, storer, account.ID, created.Id)
+		if err != nil {
+			log.Error("HANDLER ERROR [cacheDraft]", zap.Error(err), zap.String("gmail_draft_id", created.Id))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Draft aangemaakt, maar kon niet opslaan", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusCreated, draft, log)
+	}
+}
+
+// HandleGetGmailDrafts geeft de drafts uit de lokale cache. Met ?sync=true wordt de
+// cache eerst gelijkgetrokken met Gmail, zodat ook drafts uit de Gmail UI verschijnen.
+func HandleGetGmailDrafts(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, err := common.GetAccountFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
+			return
+		}
+
+		ctx := r.Context()
+		if r.URL.Query().Get("sync") == "true" {
+			client, err := common.GetGmailClient(ctx, storer, account.ID, log)
+			if err != nil {
+				log.Error("HANDLER ERROR [getGmailClient]", zap.Error(err))
+				common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail client niet initialiseren", log)
+				return
+			}
+			if err := syncDrafts(ctx, client, storer, account.ID); err != nil {
+				log.Error("HANDLER ERROR [syncDrafts]", zap.Error(err))
+				common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon drafts niet ophalen: %v", err), log)
+				return
+			}
+		}
+
+		drafts, err := storer.GetGmailDraftsForAccount(ctx, account.ID)
+		if err != nil {
+			log.Error("HANDLER ERROR [GetGmailDraftsForAccount]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon drafts niet ophalen", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"drafts": drafts,
+		}, log)
+	}
+}
+
+// HandleGetGmailDraft haalt een enkele draft uit de cache op.
+func HandleGetGmailDraft(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		draft, ok := getOwnedDraft(w, r, storer, log)
+		if !ok {
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, draft, log)
+	}
+}
+
+// HandleUpdateGmailDraft vervangt de inhoud van een draft. Bijlagen van de huidige
+// versie blijven alleen bewaard als hun ID in keepAttachmentIds staat.
+func HandleUpdateGmailDraft(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		draft, ok := getOwnedDraft(w, r, storer, log)
+		if !ok {
+			return
+		}
+
+		var req domain.GmailDraftInput
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
+			return
+		}
+		for _, id := range req.KeepAttachmentIDs {
+			if !slices.Contains(draft.AttachmentIds, id) {
+				common.WriteJSONError(w, http.StatusBadRequest, fmt.Sprintf("Onbekende bijlage: %s", id), log)
+				return
+			}
+		}
+
+		ctx := r.Context()
+		client, err := common.GetGmailClient(ctx, storer, draft.ConnectedAccountID, log)
+		if err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail client niet initialiseren", log)
+			return
+		}
+
+		outgoing := req.GmailOutgoingMessage
+		if len(req.KeepAttachmentIDs) > 0 {
+			kept, err := fetchDraftAttachments(client, draft.GmailDraftID, req.KeepAttachmentIDs)
+			if err != nil {
+				log.Error("HANDLER ERROR [fetchDraftAttachments]", zap.Error(err))
+				common.WriteJSONError(w, http.StatusInternalServerError, "Kon bijlagen van de draft niet ophalen", log)
+				return
+			}
+			outgoing.Attachments = append(kept, outgoing.Attachments...)
+		}
+		if _, err = email.FromOutgoing(outgoing).Build(); err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, fmt.Sprintf("Ongeldig bericht: %v", err), log)
+			return
+		}
+
+		message, err := email.ComposeGmail(client, outgoing)
+		if err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon draft niet opstellen: %v", err), log)
+			return
+		}
+
+		_, err = client.Users.Drafts.Update("me", draft.GmailDraftID, &gmail.Draft{
+			Id:      draft.GmailDraftID,
+			Message: message,
+		}).Do()
+		if err != nil {
+			if isGmailNotFound(err) {
+				// De draft is in Gmail al verstuurd of weggegooid
+				_ = storer.DeleteGmailDraft(ctx, draft.ID)
+				common.WriteJSONError(w, http.StatusNotFound, "Draft niet gevonden", log)
+				return
+			}
+			common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon draft niet bijwerken: %v", err), log)
+			return
+		}
+
+		updated, err := cacheDraft(ctx, client, storer, draft.ConnectedAccountID, draft.GmailDraftID)
+		if err != nil {
+			log.Error("HANDLER ERROR [cacheDraft]", zap.Error(err), zap.String("gmail_draft_id", draft.GmailDraftID))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Draft bijgewerkt, maar kon niet opslaan", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, updated, log)
+	}
+}
+
+// HandleDeleteGmailDraft gooit een draft weg in Gmail en in de cache.
+func HandleDeleteGmailDraft(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		draft, ok := getOwnedDraft(w, r, storer, log)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+		client, err := common.GetGmailClient(ctx, storer, draft.ConnectedAccountID, log)
+		if err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail client niet initialiseren", log)
+			return
+		}
+
+		// Een draft die in Gmail al weg is ruimen we alleen lokaal op
+		if err = client.Users.Drafts.Delete("me", draft.GmailDraftID).Do(); err != nil && !isGmailNotFound(err) {
+			common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon draft niet verwijderen: %v", err), log)
+			return
+		}
+
+		if err = storer.DeleteGmailDraft(ctx, draft.ID); err != nil {
+			log.Error("HANDLER ERROR [DeleteGmailDraft]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon draft niet verwijderen", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusNoContent, nil, log)
+	}
+}
+
+// HandleSendGmailDraft verstuurt een draft. Gmail verwijdert de draft daarna zelf,
+// dus ook de cache-rij verdwijnt.
+func HandleSendGmailDraft(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		draft, ok := getOwnedDraft(w, r, storer, log)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+		client, err := common.GetGmailClient(ctx, storer, draft.ConnectedAccountID, log)
+		if err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail client niet initialiseren", log)
+			return
+		}
+
+		sent, err := client.Users.Drafts.Send("me", &gmail.Draft{Id: draft.GmailDraftID}).Do()
+		if err != nil {
+			if isGmailNotFound(err) {
+				_ = storer.DeleteGmailDraft(ctx, draft.ID)
+				common.WriteJSONError(w, http.StatusNotFound, "Draft niet gevonden", log)
+				return
+			}
+			common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon draft niet versturen: %v", err), log)
+			return
+		}
+
+		if err = storer.DeleteGmailDraft(ctx, draft.ID); err != nil {
+			// Het bericht is verstuurd; de volgende sync ruimt de rij alsnog op
+			log.Warn("could not remove sent draft from cache", zap.Error(err), zap.String("draft_id", draft.ID.String()))
+		}
+
+		common.WriteJSON(w, http.StatusOK, sent, log)
+	}
+}
+
+// getOwnedDraft haalt de draft uit de URL op en controleert of die bij het account uit de context hoort.
+func getOwnedDraft(
+	w http.ResponseWriter,
+	r *http.Request,
+	storer store.Storer,
+	log *zap.Logger,
+) (domain.GmailDraft, bool) {
+	account, err := common.GetAccountFromContext(r.Context())
+	if err != nil {
+		common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
+		return domain.GmailDraft{}, false
+	}
+
+	draftID, err := uuid.Parse(chi.URLParam(r, "draftId"))
+	if err != nil {
+		common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig draft ID", log)
+		return domain.GmailDraft{}, false
+	}
+
+	draft, err := storer.GetGmailDraftByID(r.Context(), draftID)
+	if err != nil || draft.ConnectedAccountID != account.ID {
+		common.WriteJSONError(w, http.StatusNotFound, "Draft niet gevonden", log)
+		return domain.GmailDraft{}, false
+	}
+
+	return draft, true
+}
+
+// cacheDraft haalt de volledige draft op bij Gmail en schrijft die naar gmail_drafts.
+func cacheDraft(
+	ctx context.Context,
+	client *gmail.Service,
+	storer store.Storer,
+	accountID uuid.UUID,
+	gmailDraftID string,
+) (domain.GmailDraft, error) {
+	full, err := client.Users.Drafts.Get("me", gmailDraftID).Format("full").Do()
+	if err != nil {
+		return domain.GmailDraft{}, err
+	}
+	return storer.UpsertGmailDraft(ctx, draftParamsFromGmail(accountID, full))
+}
+
+// syncDrafts trekt de cache gelijk met Gmail: nieuwe drafts worden opgehaald en
+// drafts die in Gmail niet meer bestaan verdwijnen uit de cache.
+func syncDrafts(ctx context.Context, client *gmail.Service, storer store.Storer, accountID uuid.UUID) error {
+	cached, err := storer.GetGmailDraftsForAccount(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(cached))
+	for _, d := range cached {
+		known[d.GmailDraftID] = true
+	}
+
+	var ids []string
+	err = client.Users.Drafts.List("me").Pages(ctx, func(page *gmail.ListDraftsResponse) error {
+		for _, d := range page.Drafts {
+			ids = append(ids, d.Id)
+			if known[d.Id] {
+				continue
+			}
+			if _, err := cacheDraft(ctx, client, storer, accountID, d.Id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return storer.PruneGmailDrafts(ctx, accountID, ids)
+}
+
+// fetchDraftAttachments downloadt bijlagen van de huidige draft zodat ze in de nieuwe versie meegaan.
+func fetchDraftAttachments(
+	client *gmail.Service,
+	gmailDraftID string,
+	attachmentIDs []string,
+) ([]domain.GmailAttachmentInput, error) {
+	current, err := client.Users.Drafts.Get("me", gmailDraftID).Format("full").Do()
+	if err != nil {
+		return nil, err
+	}
+	if current.Message == nil {
+		return nil, errors.New("draft has no message")
+	}
+
+	parts := make(map[string]*gmail.MessagePart)
+	walkParts(current.Message.Payload, func(p *gmail.MessagePart) {
+		if p.Body != nil && p.Body.AttachmentId != "" {
+			parts[p.Body.AttachmentId] = p
+		}
+	})
+
+	attachments := make([]domain.GmailAttachmentInput, 0, len(attachmentIDs))
+	for _, id := range attachmentIDs {
+		part, ok := parts[id]
+		if !ok {
+			return nil, fmt.Errorf("attachment %s not found in draft", id)
+		}
+		body, err := client.Users.Messages.Attachments.Get("me", current.Message.Id, id).Do()
+		if err != nil {
+			return nil, err
+		}
+		data, err := decodePartData(body.Data)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, domain.GmailAttachmentInput{
+			Filename:    part.Filename,
+			ContentType: part.MimeType,
+			Data:        data,
+			ContentID:   strings.Trim(partHeader(part.Headers, "Content-ID"), "<>"),
+		})
+	}
+	return attachments, nil
+}
+
+// draftParamsFromGmail zet een draft in "full" formaat om naar de velden van gmail_drafts.
+func draftParamsFromGmail(accountID uuid.UUID, d *gmail.Draft) store.UpsertGmailDraftParams {
+	params := store.UpsertGmailDraftParams{
+		ConnectedAccountID: accountID,
+		GmailDraftID:       d.Id,
+	}
+	if d.Message == nil || d.Message.Payload == nil {
+		return params
+	}
+
+	headers := d.Message.Payload.Headers
+	if subject := partHeader(headers, "Subject"); subject != "" {
+		params.Subject = &subject
+	}
+	params.ToRecipients = parseAddresses(partHeader(headers, "To"))
+	params.CcRecipients = parseAddresses(partHeader(headers, "Cc"))
+	params.BccRecipients = parseAddresses(partHeader(headers, "Bcc"))
+
+	walkParts(d.Message.Payload, func(p *gmail.MessagePart) {
+		if p.Body == nil {
+			return
+		}
+		if p.Body.AttachmentId != "" {
+			params.AttachmentIDs = append(params.AttachmentIDs, p.Body.AttachmentId)
+			return
+		}
+		if p.Filename != "" || p.Body.Data == "" {
+			return
+		}
+		data, err := decodePartData(p.Body.Data)
+		if err != nil {
+			return
+		}
+		body := string(data)
+		switch {
+		case p.MimeType == "text/plain" && params.BodyPlain == nil:
+			params.BodyPlain = &body
+		case p.MimeType == "text/html" && params.BodyHTML == nil:
+			params.BodyHTML = &body
+		}
+	})
+	return params
+}
+
+// walkParts roept fn aan voor part en al zijn (geneste) subparts.
+func walkParts(part *gmail.MessagePart, fn func(*gmail.MessagePart)) {
+	if part == nil {
+		return
+	}
+	fn(part)
+	for _, child := range part.Parts {
+		walkParts(child, fn)
+	}
+}
+
+func partHeader(headers []*gmail.MessagePartHeader, name string) string {
+	for _, h := range headers {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+// parseAddresses geeft de kale adressen uit een adresheader; onleesbare headers worden per komma gesplitst.
+func parseAddresses(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	list, err := mail.ParseAddressList(value)
+	if err != nil {
+		var addresses []string
+		for _, a := range strings.Split(value, ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				addresses = append(addresses, a)
+			}
+		}
+		return addresses
+	}
+	addresses := make([]string, 0, len(list))
+	for _, a := range list {
+		addresses = append(addresses, a.Address)
+	}
+	return addresses
+}
+
+// decodePartData decodeert Gmail body data (base64url, met of zonder padding).
+func decodePartData(data string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(data, "="))
+}
+
+func isGmailNotFound(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound
+}
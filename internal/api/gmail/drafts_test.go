@@ -0,0 +1,152 @@
+package gmail
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+func TestDraftParamsFromGmail(t *testing.T) {
+	accountID := uuid.New()
+	encode := func(s string) string { return base64.URLEncoding.EncodeToString([]byte(s)) }
+
+	draft := &gmail.Draft{
+		Id: "r-42",
+		Message: &gmail.Message{
+			Id: "msg-42",
+			Payload: &gmail.MessagePart{
+				MimeType: "multipart/mixed",
+				Headers: []*gmail.MessagePartHeader{
+					{Name: "Subject", Value: "Offerte"},
+					{Name: "To", Value: `"Klant" <klant@example.com>, collega@example.com`},
+					{Name: "Cc", Value: "cc@example.com"},
+				},
+				Parts: []*gmail.MessagePart{
+					{
+						MimeType: "multipart/alternative",
+						Parts: []*gmail.MessagePart{
+							{MimeType: "text/plain", Body: &gmail.MessagePartBody{Data: encode("Zie bijlage")}},
+							{MimeType: "text/html", Body: &gmail.MessagePartBody{Data: encode("<p>Zie bijlage</p>")}},
+						},
+					},
+					{
+						MimeType: "application/pdf",
+						Filename: "offerte.pdf",
+						Body:     &gmail.MessagePartBody{AttachmentId: "att-1", Size: 1024},
+					},
+				},
+			},
+		},
+	}
+
+	params := draftParamsFromGmail(accountID, draft)
+
+	assert.Equal(t, accountID, params.ConnectedAccountID)
+	assert.Equal(t, "r-42", params.GmailDraftID)
+	require.NotNil(t, params.Subject)
+	assert.Equal(t, "Offerte", *params.Subject)
+	assert.Equal(t, []string{"klant@example.com", "collega@example.com"}, params.ToRecipients)
+	assert.Equal(t, []string{"cc@example.com"}, params.CcRecipients)
+	assert.Nil(t, params.BccRecipients)
+	require.NotNil(t, params.BodyPlain)
+	assert.Equal(t, "Zie bijlage", *params.BodyPlain)
+	require.NotNil(t, params.BodyHTML)
+	assert.Equal(t, "<p>Zie bijlage</p>", *params.BodyHTML)
+	assert.Equal(t, []string{"att-1"}, params.AttachmentIDs)
+}
+
+func TestDraftParamsFromGmail_WithoutMessage(t *testing.T) {
+	params := draftParamsFromGmail(uuid.New(), &gmail.Draft{Id: "r-1"})
+	assert.Equal(t, "r-1", params.GmailDraftID)
+	assert.Nil(t, params.Subject)
+	assert.Empty(t, params.AttachmentIDs)
+}
+
+func TestHandleGetGmailDraft_OtherAccount(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	accountID := uuid.New()
+	draftID := uuid.New()
+
+	mockStore.On("GetGmailDraftByID", mock.Anything, draftID).Return(domain.GmailDraft{
+		AccountEntity: domain.AccountEntity{
+			BaseEntity:         domain.BaseEntity{ID: draftID},
+			ConnectedAccountID: uuid.New(),
+		},
+	}, nil)
+
+	req := newAccountRequest("GET", "", userID, map[string]string{
+		"accountId": accountID.String(),
+		"draftId":   draftID.String(),
+	})
+	rr := httptest.NewRecorder()
+
+	HandleGetGmailDraft(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Draft niet gevonden")
+}
+
+func TestHandleUpdateGmailDraft_UnknownKeepAttachment(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	accountID := uuid.New()
+	draftID := uuid.New()
+
+	mockStore.On("GetGmailDraftByID", mock.Anything, draftID).Return(domain.GmailDraft{
+		AccountEntity: domain.AccountEntity{
+			BaseEntity:         domain.BaseEntity{ID: draftID},
+			ConnectedAccountID: accountID,
+		},
+		GmailDraftID:   "r-1",
+		HasAttachments: true,
+		AttachmentIds:  []string{"att-1"},
+	}, nil)
+
+	body := `{"to":["klant@example.com"],"subject":"Offerte","body":"v2","keepAttachmentIds":["att-9"]}`
+	req := newAccountRequest("PUT", body, userID, map[string]string{
+		"accountId": accountID.String(),
+		"draftId":   draftID.String(),
+	})
+	rr := httptest.NewRecorder()
+
+	HandleUpdateGmailDraft(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Onbekende bijlage: att-9")
+	mockStore.AssertNotCalled(t, "GetValidTokenForAccount", mock.Anything, mock.Anything)
+}
+
+func TestHandleCreateGmailDraft_RejectsKeepAttachmentIDs(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	accountID := uuid.New()
+
+	body := `{"to":["klant@example.com"],"subject":"Offerte","body":"v1","keepAttachmentIds":["att-1"]}`
+	req := newAccountRequest("POST", body, userID, map[string]string{"accountId": accountID.String()})
+	rr := httptest.NewRecorder()
+
+	HandleCreateGmailDraft(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockStore.AssertNotCalled(t, "GetValidTokenForAccount", mock.Anything, mock.Anything)
+}
+
+func TestIsGmailNotFound(t *testing.T) {
+	assert.True(t, isGmailNotFound(&googleapi.Error{Code: http.StatusNotFound}))
+	assert.False(t, isGmailNotFound(&googleapi.Error{Code: http.StatusForbidden}))
+	assert.False(t, isGmailNotFound(errors.New("boom")))
+}
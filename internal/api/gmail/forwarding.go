@@ -0,0 +1,286 @@
+package gmail
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/email"
+	"agenda-automator-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// verificationCodeAlphabet laat verwarrende tekens (0/O, 1/I) weg
+	verificationCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	verificationCodeLength   = 8
+	verificationCodeTTL      = time.Hour
+	// maxVerificationAttempts is het aantal codes dat per verzonden code geprobeerd mag worden
+	maxVerificationAttempts = 5
+	// verificationResendCooldown is de tijd tussen twee verificatiemails naar hetzelfde adres
+	verificationResendCooldown = 5 * time.Minute
+	// maxPendingForwardingAddresses begrenst de onbevestigde adressen per gebruiker, zodat de
+	// systeemmailer niet naar willekeurig veel adressen van derden te sturen is
+	maxPendingForwardingAddresses = 5
+)
+
+// HandleGetGmailForwardingAddresses haalt de doorstuuradressen van de gebruiker op.
+func HandleGetGmailForwardingAddresses(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := common.GetUserIDFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
+			return
+		}
+
+		addresses, err := storer.GetGmailForwardingAddressesForUser(r.Context(), userID)
+		if err != nil {
+			log.Error("HANDLER ERROR [GetGmailForwardingAddressesForUser]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon doorstuuradressen niet ophalen", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, addresses, log)
+	}
+}
+
+// HandleAddGmailForwardingAddress voegt een doorstuuradres van de gebruiker toe en stuurt via de
+// systeemmailer een verificatiecode naar dat adres. Pas na verificatie mogen forward regels ernaar
+// sturen. De code gaat niet via een gekoppeld account, anders stond hij leesbaar in de verzonden items.
+// Een nieuwe code voor hetzelfde adres kan pas na verificationResendCooldown, en een gebruiker heeft
+// hooguit maxPendingForwardingAddresses onbevestigde adressen.
+func HandleAddGmailForwardingAddress(storer store.Storer, mailer email.Mailer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := common.GetUserIDFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
+			return
+		}
+
+		var req struct {
+			Email string `json:"email"`
+		}
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
+			return
+		}
+		address, err := email.NormalizeAddress(req.Email)
+		if err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig e-mailadres", log)
+			return
+		}
+
+		ctx := r.Context()
+		existing, err := storer.GetGmailForwardingAddressesForUser(ctx, userID)
+		if err != nil {
+			log.Error("HANDLER ERROR [GetGmailForwardingAddressesForUser]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon doorstuuradressen niet ophalen", log)
+			return
+		}
+		pending := 0
+		for _, e := range existing {
+			if e.Email == address && e.VerifiedAt != nil {
+				common.WriteJSONError(w, http.StatusConflict, "Doorstuuradres is al geverifieerd", log)
+				return
+			}
+			if e.Email != address && e.VerifiedAt == nil {
+				pending++
+			}
+		}
+		if pending >= maxPendingForwardingAddresses {
+			common.WriteJSONError(w, http.StatusConflict, fmt.Sprintf(
+				"Maximaal %d onbevestigde doorstuuradressen; verifieer of verwijder er eerst een",
+				maxPendingForwardingAddresses,
+			), log)
+			return
+		}
+
+		user, err := storer.GetUserByID(ctx, userID)
+		if err != nil {
+			log.Error("HANDLER ERROR [GetUserByID]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon gebruiker niet ophalen", log)
+			return
+		}
+
+		code, err := generateVerificationCode()
+		if err != nil {
+			log.Error("HANDLER ERROR [generateVerificationCode]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon verificatiecode niet aanmaken", log)
+			return
+		}
+
+		forwardingAddress, err := storer.UpsertGmailForwardingAddress(ctx, store.UpsertGmailForwardingAddressParams{
+			UserID:               userID,
+			Email:                address,
+			VerificationCodeHash: hashVerificationCode(code),
+			ResendCooldown:       verificationResendCooldown,
+		})
+		if errors.Is(err, store.ErrForwardingVerificationCooldown) {
+			common.WriteJSONError(w, http.StatusTooManyRequests, "Er is net een verificatiecode verstuurd, probeer het later opnieuw", log)
+			return
+		}
+		if err != nil {
+			log.Error("HANDLER ERROR [UpsertGmailForwardingAddress]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon doorstuuradres niet opslaan", log)
+			return
+		}
+
+		if err := sendVerificationCode(ctx, mailer, user.Email, address, code); err != nil {
+			log.Error("HANDLER ERROR [sendVerificationCode]", zap.Error(err))
+			if errors.Is(err, email.ErrMailerNotConfigured) {
+				common.WriteJSONError(w, http.StatusServiceUnavailable, "Verificatiemail is niet beschikbaar", log)
+				return
+			}
+			common.WriteJSONError(w, http.StatusBadGateway, "Kon verificatiemail niet versturen", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusCreated, forwardingAddress, log)
+	}
+}
+
+// HandleVerifyGmailForwardingAddress bevestigt een doorstuuradres met de gemailde code.
+func HandleVerifyGmailForwardingAddress(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		address, ok := getOwnedForwardingAddress(w, r, storer, log)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Code string `json:"code"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
+			return
+		}
+
+		if address.VerifiedAt != nil {
+			common.WriteJSON(w, http.StatusOK, address, log)
+			return
+		}
+		if address.VerificationCodeHash == nil || address.VerificationSentAt == nil ||
+			time.Since(*address.VerificationSentAt) > verificationCodeTTL {
+			common.WriteJSONError(w, http.StatusBadRequest, "Verificatiecode is verlopen, vraag een nieuwe aan", log)
+			return
+		}
+
+		// Elke poging telt, ook een goede: zo kan niemand de code raden door snel veel codes te proberen
+		err := storer.UseGmailForwardingVerificationAttempt(r.Context(), address.ID, maxVerificationAttempts)
+		if errors.Is(err, store.ErrForwardingVerificationLocked) {
+			common.WriteJSONError(w, http.StatusTooManyRequests, "Te veel pogingen, vraag een nieuwe verificatiecode aan", log)
+			return
+		}
+		if err != nil {
+			log.Error("HANDLER ERROR [UseGmailForwardingVerificationAttempt]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon doorstuuradres niet verifiëren", log)
+			return
+		}
+
+		given := hashVerificationCode(strings.ToUpper(strings.TrimSpace(req.Code)))
+		if subtle.ConstantTimeCompare([]byte(given), []byte(*address.VerificationCodeHash)) != 1 {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige verificatiecode", log)
+			return
+		}
+
+		verified, err := storer.MarkGmailForwardingAddressVerified(r.Context(), address.ID)
+		if err != nil {
+			log.Error("HANDLER ERROR [MarkGmailForwardingAddressVerified]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon doorstuuradres niet verifiëren", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, verified, log)
+	}
+}
+
+// HandleDeleteGmailForwardingAddress verwijdert een doorstuuradres; forward regels naar dit adres falen daarna.
+func HandleDeleteGmailForwardingAddress(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		address, ok := getOwnedForwardingAddress(w, r, storer, log)
+		if !ok {
+			return
+		}
+
+		if err := storer.DeleteGmailForwardingAddress(r.Context(), address.ID); err != nil {
+			log.Error("HANDLER ERROR [DeleteGmailForwardingAddress]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon doorstuuradres niet verwijderen", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusNoContent, nil, log)
+	}
+}
+
+// getOwnedForwardingAddress haalt het doorstuuradres uit de URL op en controleert of het bij de gebruiker hoort.
+func getOwnedForwardingAddress(
+	w http.ResponseWriter,
+	r *http.Request,
+	storer store.Storer,
+	log *zap.Logger,
+) (domain.GmailForwardingAddress, bool) {
+	addressID, err := uuid.Parse(chi.URLParam(r, "addressId"))
+	if err != nil {
+		common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig adres ID", log)
+		return domain.GmailForwardingAddress{}, false
+	}
+
+	userID, err := common.GetUserIDFromContext(r.Context())
+	if err != nil {
+		common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
+		return domain.GmailForwardingAddress{}, false
+	}
+
+	address, err := storer.GetGmailForwardingAddressByID(r.Context(), addressID)
+	if err != nil || address.UserID != userID {
+		common.WriteJSONError(w, http.StatusNotFound, "Doorstuuradres niet gevonden", log)
+		return domain.GmailForwardingAddress{}, false
+	}
+
+	return address, true
+}
+
+func generateVerificationCode() (string, error) {
+	random := make([]byte, verificationCodeLength)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	code := make([]byte, verificationCodeLength)
+	for i, b := range random {
+		// 256 is een veelvoud van 32, dus elke letter is even waarschijnlijk
+		code[i] = verificationCodeAlphabet[int(b)%len(verificationCodeAlphabet)]
+	}
+	return string(code), nil
+}
+
+func hashVerificationCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// sendVerificationCode mailt de code via de systeemmailer naar het nieuwe doorstuuradres.
+func sendVerificationCode(ctx context.Context, mailer email.Mailer, requestedBy, to, code string) error {
+	return mailer.Send(ctx, &email.Message{
+		To:      []string{to},
+		Subject: "Bevestig je doorstuuradres",
+		Text: fmt.Sprintf(
+			"%s wil e-mail automatisch doorsturen naar dit adres.\n\n"+
+				"Je verificatiecode is: %s\n\n"+
+				"De code is 1 uur geldig. Heb je dit niet aangevraagd, dan kun je deze e-mail negeren.",
+			requestedBy, code,
+		),
+	})
+}
@@ -0,0 +1,275 @@
+package gmail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/email"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func pendingForwardingAddress(userID uuid.UUID, code string, sentAt time.Time) domain.GmailForwardingAddress {
+	hash := hashVerificationCode(code)
+	return domain.GmailForwardingAddress{
+		BaseEntity:           domain.BaseEntity{ID: uuid.New()},
+		UserID:               userID,
+		Email:                "boekhouding@example.com",
+		VerificationCodeHash: &hash,
+		VerificationSentAt:   &sentAt,
+	}
+}
+
+// recordingMailer onthoudt de verstuurde systeemmail
+type recordingMailer struct {
+	sent []*email.Message
+	err  error
+}
+
+func (m *recordingMailer) Send(_ context.Context, msg *email.Message) error {
+	m.sent = append(m.sent, msg)
+	return m.err
+}
+
+func TestHandleAddGmailForwardingAddress_Success(t *testing.T) {
+	mockStore := &store.MockStore{}
+	mailer := &recordingMailer{}
+	userID := uuid.New()
+	created := pendingForwardingAddress(userID, "ABCD2345", time.Now())
+
+	mockStore.On("GetGmailForwardingAddressesForUser", mock.Anything, userID).Return([]domain.GmailForwardingAddress{}, nil)
+	mockStore.On("GetUserByID", mock.Anything, userID).Return(domain.User{Email: "jeffrey@gmail.com"}, nil)
+	mockStore.On("UpsertGmailForwardingAddress", mock.Anything, mock.MatchedBy(func(p store.UpsertGmailForwardingAddressParams) bool {
+		return p.UserID == userID && p.Email == "boekhouding@example.com" && len(p.VerificationCodeHash) == 64 &&
+			p.ResendCooldown == verificationResendCooldown
+	})).Return(created, nil)
+
+	req := newAccountRequest("POST", `{"email": "Boekhouding@example.com"}`, userID, nil)
+	rr := httptest.NewRecorder()
+
+	HandleAddGmailForwardingAddress(mockStore, mailer, zap.NewNop())(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	// De code gaat via de systeemmailer en niet via een gekoppeld account
+	require.Len(t, mailer.sent, 1)
+	assert.Empty(t, mailer.sent[0].From)
+	assert.Equal(t, []string{"boekhouding@example.com"}, mailer.sent[0].To)
+	assert.Contains(t, mailer.sent[0].Text, "jeffrey@gmail.com")
+	mockStore.AssertNotCalled(t, "GetValidTokenForAccount", mock.Anything, mock.Anything)
+	mockStore.AssertExpectations(t)
+}
+
+func TestHandleAddGmailForwardingAddress_MailerNotConfigured(t *testing.T) {
+	mockStore := &store.MockStore{}
+	mailer := &recordingMailer{err: email.ErrMailerNotConfigured}
+	userID := uuid.New()
+
+	mockStore.On("GetGmailForwardingAddressesForUser", mock.Anything, userID).Return([]domain.GmailForwardingAddress{}, nil)
+	mockStore.On("GetUserByID", mock.Anything, userID).Return(domain.User{Email: "jeffrey@gmail.com"}, nil)
+	mockStore.On("UpsertGmailForwardingAddress", mock.Anything, mock.Anything).
+		Return(pendingForwardingAddress(userID, "ABCD2345", time.Now()), nil)
+
+	req := newAccountRequest("POST", `{"email": "boekhouding@example.com"}`, userID, nil)
+	rr := httptest.NewRecorder()
+
+	HandleAddGmailForwardingAddress(mockStore, mailer, zap.NewNop())(rr, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
+
+func TestHandleAddGmailForwardingAddress_InvalidEmail(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+
+	req := newAccountRequest("POST", `{"email": "geen adres"}`, userID, nil)
+	rr := httptest.NewRecorder()
+
+	HandleAddGmailForwardingAddress(mockStore, &recordingMailer{}, zap.NewNop())(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Ongeldig e-mailadres")
+	mockStore.AssertNotCalled(t, "UpsertGmailForwardingAddress", mock.Anything, mock.Anything)
+}
+
+func TestHandleAddGmailForwardingAddress_AlreadyVerified(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	verifiedAt := time.Now()
+
+	mockStore.On("GetGmailForwardingAddressesForUser", mock.Anything, userID).
+		Return([]domain.GmailForwardingAddress{{Email: "boekhouding@example.com", VerifiedAt: &verifiedAt}}, nil)
+
+	req := newAccountRequest("POST", `{"email": "Boekhouding <BOEKHOUDING@example.com>"}`, userID, nil)
+	rr := httptest.NewRecorder()
+
+	HandleAddGmailForwardingAddress(mockStore, &recordingMailer{}, zap.NewNop())(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	mockStore.AssertExpectations(t)
+}
+
+func TestHandleAddGmailForwardingAddress_ResendCooldown(t *testing.T) {
+	mockStore := &store.MockStore{}
+	mailer := &recordingMailer{}
+	userID := uuid.New()
+
+	mockStore.On("GetGmailForwardingAddressesForUser", mock.Anything, userID).
+		Return([]domain.GmailForwardingAddress{pendingForwardingAddress(userID, "ABCD2345", time.Now())}, nil)
+	mockStore.On("GetUserByID", mock.Anything, userID).Return(domain.User{Email: "jeffrey@gmail.com"}, nil)
+	mockStore.On("UpsertGmailForwardingAddress", mock.Anything, mock.Anything).
+		Return(domain.GmailForwardingAddress{}, store.ErrForwardingVerificationCooldown)
+
+	req := newAccountRequest("POST", `{"email": "boekhouding@example.com"}`, userID, nil)
+	rr := httptest.NewRecorder()
+
+	HandleAddGmailForwardingAddress(mockStore, mailer, zap.NewNop())(rr, req)
+
+	// De vorige code is nog recent: er gaat geen nieuwe mail uit
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Empty(t, mailer.sent)
+}
+
+func TestHandleAddGmailForwardingAddress_TooManyPending(t *testing.T) {
+	mockStore := &store.MockStore{}
+	mailer := &recordingMailer{}
+	userID := uuid.New()
+
+	existing := make([]domain.GmailForwardingAddress, maxPendingForwardingAddresses)
+	for i := range existing {
+		existing[i] = pendingForwardingAddress(userID, "ABCD2345", time.Now())
+		existing[i].Email = fmt.Sprintf("adres%d@example.com", i)
+	}
+	mockStore.On("GetGmailForwardingAddressesForUser", mock.Anything, userID).Return(existing, nil)
+
+	req := newAccountRequest("POST", `{"email": "boekhouding@example.com"}`, userID, nil)
+	rr := httptest.NewRecorder()
+
+	HandleAddGmailForwardingAddress(mockStore, mailer, zap.NewNop())(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Empty(t, mailer.sent)
+	mockStore.AssertNotCalled(t, "UpsertGmailForwardingAddress", mock.Anything, mock.Anything)
+}
+
+func TestHandleVerifyGmailForwardingAddress_Success(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	address := pendingForwardingAddress(userID, "ABCD2345", time.Now().Add(-30*time.Minute))
+	verifiedAt := time.Now()
+	verified := address
+	verified.VerifiedAt = &verifiedAt
+
+	mockStore.On("GetGmailForwardingAddressByID", mock.Anything, address.ID).Return(address, nil)
+	mockStore.On("UseGmailForwardingVerificationAttempt", mock.Anything, address.ID, maxVerificationAttempts).Return(nil)
+	mockStore.On("MarkGmailForwardingAddressVerified", mock.Anything, address.ID).Return(verified, nil)
+
+	req := newAccountRequest("POST", `{"code": " abcd2345 "}`, userID, map[string]string{
+		"addressId": address.ID.String(),
+	})
+	rr := httptest.NewRecorder()
+
+	HandleVerifyGmailForwardingAddress(mockStore, zap.NewNop())(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.NotNil(t, body["verified_at"])
+	assert.NotContains(t, rr.Body.String(), "verification_code_hash")
+	mockStore.AssertExpectations(t)
+}
+
+func TestHandleVerifyGmailForwardingAddress_WrongCode(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	address := pendingForwardingAddress(userID, "ABCD2345", time.Now())
+
+	mockStore.On("GetGmailForwardingAddressByID", mock.Anything, address.ID).Return(address, nil)
+	mockStore.On("UseGmailForwardingVerificationAttempt", mock.Anything, address.ID, maxVerificationAttempts).Return(nil)
+
+	req := newAccountRequest("POST", `{"code": "ZZZZ9999"}`, userID, map[string]string{
+		"addressId": address.ID.String(),
+	})
+	rr := httptest.NewRecorder()
+
+	HandleVerifyGmailForwardingAddress(mockStore, zap.NewNop())(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Ongeldige verificatiecode")
+	mockStore.AssertNotCalled(t, "MarkGmailForwardingAddressVerified", mock.Anything, mock.Anything)
+}
+
+func TestHandleVerifyGmailForwardingAddress_TooManyAttempts(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	address := pendingForwardingAddress(userID, "ABCD2345", time.Now())
+
+	mockStore.On("GetGmailForwardingAddressByID", mock.Anything, address.ID).Return(address, nil)
+	mockStore.On("UseGmailForwardingVerificationAttempt", mock.Anything, address.ID, maxVerificationAttempts).
+		Return(store.ErrForwardingVerificationLocked)
+
+	// Ook de goede code helpt niet meer als alle pogingen op zijn
+	req := newAccountRequest("POST", `{"code": "ABCD2345"}`, userID, map[string]string{
+		"addressId": address.ID.String(),
+	})
+	rr := httptest.NewRecorder()
+
+	HandleVerifyGmailForwardingAddress(mockStore, zap.NewNop())(rr, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	mockStore.AssertNotCalled(t, "MarkGmailForwardingAddressVerified", mock.Anything, mock.Anything)
+}
+
+func TestHandleVerifyGmailForwardingAddress_Expired(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	address := pendingForwardingAddress(userID, "ABCD2345", time.Now().Add(-2*time.Hour))
+
+	mockStore.On("GetGmailForwardingAddressByID", mock.Anything, address.ID).Return(address, nil)
+
+	req := newAccountRequest("POST", `{"code": "ABCD2345"}`, userID, map[string]string{
+		"addressId": address.ID.String(),
+	})
+	rr := httptest.NewRecorder()
+
+	HandleVerifyGmailForwardingAddress(mockStore, zap.NewNop())(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "verlopen")
+}
+
+func TestHandleDeleteGmailForwardingAddress_OtherUser(t *testing.T) {
+	mockStore := &store.MockStore{}
+	address := pendingForwardingAddress(uuid.New(), "ABCD2345", time.Now())
+
+	mockStore.On("GetGmailForwardingAddressByID", mock.Anything, address.ID).Return(address, nil)
+
+	req := newAccountRequest("DELETE", "", uuid.New(), map[string]string{
+		"addressId": address.ID.String(),
+	})
+	rr := httptest.NewRecorder()
+
+	HandleDeleteGmailForwardingAddress(mockStore, zap.NewNop())(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	mockStore.AssertNotCalled(t, "DeleteGmailForwardingAddress", mock.Anything, mock.Anything)
+}
+
+func TestGenerateVerificationCode(t *testing.T) {
+	code, err := generateVerificationCode()
+	require.NoError(t, err)
+	assert.Len(t, code, verificationCodeLength)
+	for _, c := range code {
+		assert.Contains(t, verificationCodeAlphabet, string(c))
+	}
+}
@@ -1,22 +1,19 @@
 package gmail
 
 import (
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 
 	"agenda-automator-api/internal/api/common"
 	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/email"
 	"agenda-automator-api/internal/store"
 
 	// "log" // <-- VERWIJDERD
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
-	"github.com/go-chi/chi/v5"
-	"github.com/google/uuid"
 	"go.uber.org/zap" // <-- TOEGEVOEGD
 	"google.golang.org/api/gmail/v1"
 )
@@ -24,12 +21,12 @@ import (
 // HandleGetGmailMessages retrieves Gmail messages.
 func HandleGetGmailMessages(store store.Storer, log *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		accountIDStr := chi.URLParam(r, "accountId")
-		accountID, err := uuid.Parse(accountIDStr)
+		account, err := common.GetAccountFromContext(r.Context())
 		if err != nil {
-			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig account ID", log) // <-- log meegegeven
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
 			return
 		}
+		accountID := account.ID
 
 		// Query parameters
 		query := r.URL.Query().Get("q") // Gmail search query
@@ -208,77 +205,36 @@ func HandleGetGmailMessages(store store.Storer, log *zap.Logger) http.HandlerFun
 // HandleSendGmailMessage sends an email using Gmail.
 func HandleSendGmailMessage(store store.Storer, log *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		accountIDStr := chi.URLParam(r, "accountId")
-		accountID, err := uuid.Parse(accountIDStr)
+		account, err := common.GetAccountFromContext(r.Context())
 		if err != nil {
-			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig account ID", log)
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
 			return
 		}
+		accountID := account.ID
 
 		var req struct {
-			To      []string `json:"to"`
-			Cc      []string `json:"cc,omitempty"`
-			Bcc     []string `json:"bcc,omitempty"`
-			Subject string   `json:"subject"`
-			Body    string   `json:"body"`
-			IsHTML  bool     `json:"isHtml,omitempty"`
+			domain.GmailOutgoingMessage
+			// SendAt plant het bericht in in plaats van het direct te versturen
+			SendAt *time.Time `json:"send_at,omitempty"`
 		}
 		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
 			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
 			return
 		}
 
-		ctx := r.Context()
-		// AANGEPAST: log meegegeven
-		client, err := common.GetGmailClient(ctx, store, accountID, log)
-		if err != nil {
-			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail client niet initialiseren", log)
+		if len(req.To) == 0 && len(req.Cc) == 0 && len(req.Bcc) == 0 {
+			common.WriteJSONError(w, http.StatusBadRequest, "Minimaal één ontvanger is verplicht", log)
 			return
 		}
 
-		// Create the email message
-		var message gmail.Message
-
-		// For simplicity, we'll create a basic text message
-		// In a full implementation, you'd want to properly encode MIME messages
-		contentType := "text/plain"
-		if req.IsHTML {
-			contentType = "text/html"
-		}
-
-		// Build recipients string
-		toRecipients := strings.Join(req.To, ",")
-		var ccRecipients, bccRecipients string
-		if len(req.Cc) > 0 {
-			ccRecipients = "Cc: " + strings.Join(req.Cc, ",") + "\r\n"
-		}
-		if len(req.Bcc) > 0 {
-			bccRecipients = "Bcc: " + strings.Join(req.Bcc, ",") + "\r\n"
-		}
-
-		// Create raw email content
-		rawMessage := fmt.Sprintf("To: %s\r\n%s%sSubject: %s\r\nContent-Type: %s; charset=UTF-8\r\n\r\n%s",
-			toRecipients, ccRecipients, bccRecipients, req.Subject, contentType, req.Body)
-
-		message.Raw = base64.URLEncoding.EncodeToString([]byte(rawMessage))
-
-		sentMessage, err := client.Users.Messages.Send("me", &message).Do()
-		if err != nil {
-			common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon email niet versturen: %v", err), log)
+		// Een onjuist adres of bericht moet direct een 400 geven, ook als het ingepland wordt
+		if _, err = email.FromOutgoing(req.GmailOutgoingMessage).Build(); err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, fmt.Sprintf("Ongeldig bericht: %v", err), log)
 			return
 		}
 
-		common.WriteJSON(w, http.StatusOK, sentMessage, log)
-	}
-}
-
-// HandleGetGmailLabels retrieves Gmail labels.
-func HandleGetGmailLabels(store store.Storer, log *zap.Logger) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		accountIDStr := chi.URLParam(r, "accountId")
-		accountID, err := uuid.Parse(accountIDStr)
-		if err != nil {
-			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig account ID", log)
+		if req.SendAt != nil {
+			scheduleGmailMessage(w, r, store, log, accountID, req.GmailOutgoingMessage, *req.SendAt)
 			return
 		}
 
@@ -286,98 +242,35 @@ func HandleGetGmailLabels(store store.Storer, log *zap.Logger) http.HandlerFunc
 		// AANGEPAST: log meegegeven
 		client, err := common.GetGmailClient(ctx, store, accountID, log)
 		if err != nil {
-			log.Error("HANDLER ERROR [getGmailClient]", zap.Error(err)) // <-- AANGEPAST
 			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail client niet initialiseren", log)
 			return
 		}
 
-		labels, err := client.Users.Labels.List("me").Do()
-		if err != nil {
-			log.Error("HANDLER ERROR [client.Users.Labels.List]", zap.Error(err)) // <-- AANGEPAST
-			common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon labels niet ophalen: %v", err), log)
-			return
-		}
-
-		common.WriteJSON(w, http.StatusOK, map[string]interface{}{
-			"labels": labels.Labels,
-		}, log)
-	}
-}
-
-// HandleCreateGmailDraft creates a Gmail draft.
-func HandleCreateGmailDraft(store store.Storer, log *zap.Logger) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		accountIDStr := chi.URLParam(r, "accountId")
-		accountID, err := uuid.Parse(accountIDStr)
-		if err != nil {
-			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig account ID", log)
-			return
-		}
-
-		var req struct {
-			To      []string `json:"to"`
-			Cc      []string `json:"cc,omitempty"`
-			Bcc     []string `json:"bcc,omitempty"`
-			Subject string   `json:"subject"`
-			Body    string   `json:"body"`
-			IsHTML  bool     `json:"isHtml,omitempty"`
-		}
-		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
-			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
-			return
-		}
-
-		ctx := r.Context()
-		// AANGEPAST: log meegegeven
-		client, err := common.GetGmailClient(ctx, store, accountID, log)
+		message, err := email.ComposeGmail(client, req.GmailOutgoingMessage)
 		if err != nil {
-			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail client niet initialiseren", log)
+			common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon email niet opstellen: %v", err), log)
 			return
 		}
 
-		// Create draft message similar to send message
-		var draft gmail.Draft
-		var message gmail.Message
-
-		contentType := "text/plain"
-		if req.IsHTML {
-			contentType = "text/html"
-		}
-
-		toRecipients := strings.Join(req.To, ",")
-		var ccRecipients, bccRecipients string
-		if len(req.Cc) > 0 {
-			ccRecipients = "Cc: " + strings.Join(req.Cc, ",") + "\r\n"
-		}
-		if len(req.Bcc) > 0 {
-			bccRecipients = "Bcc: " + strings.Join(req.Bcc, ",") + "\r\n"
-		}
-
-		rawMessage := fmt.Sprintf("To: %s\r\n%s%sSubject: %s\r\nContent-Type: %s; charset=UTF-8\r\n\r\n%s",
-			toRecipients, ccRecipients, bccRecipients, req.Subject, contentType, req.Body)
-
-		message.Raw = base64.URLEncoding.EncodeToString([]byte(rawMessage))
-		draft.Message = &message
-
-		createdDraft, err := client.Users.Drafts.Create("me", &draft).Do()
+		sentMessage, err := client.Users.Messages.Send("me", message).Do()
 		if err != nil {
-			common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon draft niet creren: %v", err), log)
+			common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon email niet versturen: %v", err), log)
 			return
 		}
 
-		common.WriteJSON(w, http.StatusCreated, createdDraft, log)
+		common.WriteJSON(w, http.StatusOK, sentMessage, log)
 	}
 }
 
-// HandleGetGmailDrafts retrieves Gmail drafts.
-func HandleGetGmailDrafts(store store.Storer, log *zap.Logger) http.HandlerFunc {
+// HandleGetGmailLabels retrieves Gmail labels.
+func HandleGetGmailLabels(store store.Storer, log *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		accountIDStr := chi.URLParam(r, "accountId")
-		accountID, err := uuid.Parse(accountIDStr)
+		account, err := common.GetAccountFromContext(r.Context())
 		if err != nil {
-			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig account ID", log)
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
 			return
 		}
+		accountID := account.ID
 
 		ctx := r.Context()
 		// AANGEPAST: log meegegeven
@@ -388,15 +281,15 @@ func HandleGetGmailDrafts(store store.Storer, log *zap.Logger) http.HandlerFunc
 			return
 		}
 
-		drafts, err := client.Users.Drafts.List("me").Do()
+		labels, err := client.Users.Labels.List("me").Do()
 		if err != nil {
-			log.Error("HANDLER ERROR [client.Users.Drafts.List]", zap.Error(err)) // <-- AANGEPAST
-			common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon drafts niet ophalen: %v", err), log)
+			log.Error("HANDLER ERROR [client.Users.Labels.List]", zap.Error(err)) // <-- AANGEPAST
+			common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon labels niet ophalen: %v", err), log)
 			return
 		}
 
 		common.WriteJSON(w, http.StatusOK, map[string]interface{}{
-			"drafts": drafts.Drafts,
+			"labels": labels.Labels,
 		}, log)
 	}
 }
@@ -405,12 +298,12 @@ func HandleGetGmailDrafts(store store.Storer, log *zap.Logger) http.HandlerFunc
 // AANGEPAST: Accepteert nu log *zap.Logger
 func HandleCreateGmailRule(storer store.Storer, log *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		accountIDStr := chi.URLParam(r, "accountId")
-		accountID, err := uuid.Parse(accountIDStr)
+		account, err := common.GetAccountFromContext(r.Context())
 		if err != nil {
-			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig account ID", log)
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
 			return
 		}
+		accountID := account.ID
 
 		userID, err := common.GetUserIDFromContext(r.Context())
 		if err != nil {
@@ -418,17 +311,14 @@ func HandleCreateGmailRule(storer store.Storer, log *zap.Logger) http.HandlerFun
 			return
 		}
 
-		account, err := storer.GetConnectedAccountByID(r.Context(), accountID)
-		if err != nil || account.UserID != userID {
-			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
-			return
-		}
-
 		var req domain.GmailAutomationRule
 		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
 			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
 			return
 		}
+		if !checkGmailRule(w, r, storer, accountID, req, log) {
+			return
+		}
 
 		params := store.CreateGmailAutomationRuleParams{
 			ConnectedAccountID: accountID,
@@ -440,6 +330,8 @@ func HandleCreateGmailRule(storer store.Storer, log *zap.Logger) http.HandlerFun
 			ActionType:         req.ActionType,
 			ActionParams:       req.ActionParams,
 			Priority:           req.Priority,
+			Schedule:           req.Schedule,
+			CreatedBy:          userID,
 		}
 
 		rule, err := storer.CreateGmailAutomationRule(r.Context(), params)
@@ -456,24 +348,12 @@ func HandleCreateGmailRule(storer store.Storer, log *zap.Logger) http.HandlerFun
 // AANGEPAST: Accepteert nu log *zap.Logger
 func HandleGetGmailRules(store store.Storer, log *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		accountIDStr := chi.URLParam(r, "accountId")
-		accountID, err := uuid.Parse(accountIDStr)
+		account, err := common.GetAccountFromContext(r.Context())
 		if err != nil {
-			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig account ID", log)
-			return
-		}
-
-		userID, err := common.GetUserIDFromContext(r.Context())
-		if err != nil {
-			common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
-			return
-		}
-
-		account, err := store.GetConnectedAccountByID(r.Context(), accountID)
-		if err != nil || account.UserID != userID {
 			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
 			return
 		}
+		accountID := account.ID
 
 		rules, err := store.GetGmailRulesForAccount(r.Context(), accountID)
 		if err != nil {
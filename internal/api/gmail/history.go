@@ -0,0 +1,110 @@
+package gmail
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// HandleGetGmailRuleHistory geeft alle versies van een Gmail rule, nieuwste eerst.
+func HandleGetGmailRuleHistory(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rule, _, ok := getOwnedGmailRule(w, r, storer, log)
+		if !ok {
+			return
+		}
+
+		versions, err := storer.GetRuleVersions(r.Context(), domain.RuleTypeGmail, rule.ID)
+		if err != nil {
+			log.Error("HANDLER ERROR [GetRuleVersions]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail rule geschiedenis niet ophalen", log)
+			return
+		}
+		if versions == nil {
+			versions = []domain.RuleVersion{}
+		}
+
+		common.WriteJSON(w, http.StatusOK, versions, log)
+	}
+}
+
+// HandleRestoreGmailRuleVersion zet een Gmail rule terug naar een eerdere versie. Dat levert een
+// nieuwe versie op; de actieve status en de prioriteit blijven ongewijzigd.
+func HandleRestoreGmailRuleVersion(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		version, err := strconv.Atoi(chi.URLParam(r, "version"))
+		if err != nil || version < 1 {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige versie", log)
+			return
+		}
+
+		rule, userID, ok := getOwnedGmailRule(w, r, storer, log)
+		if !ok {
+			return
+		}
+
+		ruleVersion, err := storer.GetRuleVersion(r.Context(), domain.RuleTypeGmail, rule.ID, version)
+		if err != nil {
+			common.WriteJSONError(w, http.StatusNotFound, "Versie niet gevonden", log)
+			return
+		}
+
+		// Een nieuwe waarde, zodat Unmarshal de json.RawMessage velden van rule niet overschrijft
+		var restored domain.GmailAutomationRule
+		if err = json.Unmarshal(ruleVersion.Snapshot, &restored); err != nil {
+			log.Error("HANDLER ERROR [RestoreGmailRuleVersion Unmarshal]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon versie niet lezen", log)
+			return
+		}
+		// Een oude versie kan ongeldig zijn onder de huidige validatie of naar een verwijderd label wijzen
+		if !checkGmailRule(w, r, storer, rule.ConnectedAccountID, restored, log) {
+			return
+		}
+
+		// Een user-level rule krijgt ook de selector van de versie terug; versies van voor de
+		// selector in de snapshot laten de huidige staan
+		var selector *domain.AccountSelector
+		if rule.IsUserRule() && restored.AccountSelector != nil {
+			fieldErrs, err := common.ValidateAccountSelector(r.Context(), storer, userID, restored.AccountSelector)
+			if err != nil {
+				log.Error("HANDLER ERROR [ValidateAccountSelector]", zap.Error(err))
+				common.WriteJSONError(w, http.StatusInternalServerError, "Kon accounts niet ophalen", log)
+				return
+			}
+			if len(fieldErrs) > 0 {
+				common.WriteValidationErrors(w, fieldErrs, log)
+				return
+			}
+			selector = restored.AccountSelector
+		}
+
+		updated, err := storer.UpdateGmailRule(r.Context(), store.UpdateGmailRuleParams{
+			RuleID:            rule.ID,
+			Name:              restored.Name,
+			Description:       restored.Description,
+			TriggerType:       restored.TriggerType,
+			TriggerConditions: restored.TriggerConditions,
+			ActionType:        restored.ActionType,
+			ActionParams:      restored.ActionParams,
+			Priority:          rule.Priority,
+			Schedule:          restored.Schedule,
+			AccountSelector:   selector,
+			ChangedBy:         userID,
+			RestoredFrom:      &version,
+		})
+		if err != nil {
+			log.Error("HANDLER ERROR [RestoreGmailRuleVersion UpdateGmailRule]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail rule niet terugzetten", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, updated, log)
+	}
+}
@@ -0,0 +1,100 @@
+package gmail
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// ownedGmailRule zet de mocks klaar voor een Gmail rule van userID.
+func ownedGmailRule(mockStore *store.MockStore, userID uuid.UUID) domain.GmailAutomationRule {
+	rule := testGmailRule(uuid.New(), 7)
+	rule.Version = 3
+	mockStore.On("VerifyGmailRuleOwnership", mock.Anything, rule.ID, userID).Return(nil)
+	mockStore.On("GetGmailRuleByID", mock.Anything, rule.ID).Return(rule, nil)
+	return rule
+}
+
+func TestHandleGetGmailRuleHistory(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	rule := ownedGmailRule(mockStore, userID)
+	versions := []domain.RuleVersion{
+		{
+			RuleType: domain.RuleTypeGmail, RuleID: rule.ID, Version: 1, ChangeType: domain.RuleChangeCreate,
+			Snapshot: json.RawMessage(`{"name":"Nieuwsbrieven archiveren"}`),
+		},
+	}
+	mockStore.On("GetRuleVersions", mock.Anything, domain.RuleTypeGmail, rule.ID).Return(versions, nil)
+
+	req := newAccountRequest("GET", "", userID, map[string]string{"ruleId": rule.ID.String()})
+	rr := httptest.NewRecorder()
+	HandleGetGmailRuleHistory(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var got []domain.RuleVersion
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.Equal(t, versions, got)
+}
+
+func TestHandleRestoreGmailRuleVersion(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		userID := uuid.New()
+		rule := ownedGmailRule(mockStore, userID)
+		mockStore.On("GetRuleVersion", mock.Anything, domain.RuleTypeGmail, rule.ID, 1).
+			Return(domain.RuleVersion{Version: 1, Snapshot: json.RawMessage(`{
+				"name": "Oude naam",
+				"is_active": false,
+				"trigger_type": "subject_match",
+				"trigger_conditions": {"subject_pattern": "factuur"},
+				"action_type": "mark_read",
+				"action_params": {}
+			}`)}, nil)
+
+		restored := rule
+		restored.Name = "Oude naam"
+		restored.Version = 4
+		mockStore.On("UpdateGmailRule", mock.Anything, mock.MatchedBy(func(p store.UpdateGmailRuleParams) bool {
+			return p.RuleID == rule.ID && p.Name == "Oude naam" &&
+				p.TriggerType == domain.GmailTriggerSubjectMatch && p.ActionType == domain.GmailActionMarkRead &&
+				p.Priority == 7 && p.ChangedBy == userID && p.RestoredFrom != nil && *p.RestoredFrom == 1
+		})).Return(restored, nil)
+
+		req := newAccountRequest("POST", "", userID, map[string]string{"ruleId": rule.ID.String(), "version": "1"})
+		rr := httptest.NewRecorder()
+		HandleRestoreGmailRuleVersion(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var got domain.GmailAutomationRule
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		assert.Equal(t, 4, got.Version)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		userID := uuid.New()
+		rule := ownedGmailRule(mockStore, userID)
+		mockStore.On("GetRuleVersion", mock.Anything, domain.RuleTypeGmail, rule.ID, 9).
+			Return(domain.RuleVersion{}, errors.New("rule version not found"))
+
+		req := newAccountRequest("POST", "", userID, map[string]string{"ruleId": rule.ID.String(), "version": "9"})
+		rr := httptest.NewRecorder()
+		HandleRestoreGmailRuleVersion(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockStore.AssertNotCalled(t, "UpdateGmailRule", mock.Anything, mock.Anything)
+	})
+}
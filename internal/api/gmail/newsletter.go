@@ -0,0 +1,126 @@
+package gmail
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+	"agenda-automator-api/internal/unsubscribe"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// HandleGetGmailNewsletterReport geeft de afzenders met het meeste nieuwsbriefvolume terug.
+func HandleGetGmailNewsletterReport(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, err := common.GetAccountFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
+			return
+		}
+		accountID := account.ID
+
+		limit := 20 // default
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			if parsed, perr := strconv.Atoi(limitStr); perr == nil && parsed > 0 && parsed <= 100 {
+				limit = parsed
+			}
+		}
+
+		senders, err := storer.GetGmailNewsletterSenders(r.Context(), accountID, limit)
+		if err != nil {
+			log.Error("HANDLER ERROR [GetGmailNewsletterSenders]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon nieuwsbriefrapport niet ophalen", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"senders": senders,
+		}, log)
+	}
+}
+
+// HandleUnsubscribeGmailMessage schrijft uit van de mailinglijst waar een bericht vandaan komt.
+func HandleUnsubscribeGmailMessage(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	unsubscriber := unsubscribe.NewUnsubscriber()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, err := common.GetAccountFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
+			return
+		}
+		accountID := account.ID
+		messageID := chi.URLParam(r, "messageId")
+
+		ctx := r.Context()
+		client, err := common.GetGmailClient(ctx, storer, accountID, log)
+		if err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail client niet initialiseren", log)
+			return
+		}
+
+		message, err := client.Users.Messages.Get("me", messageID).Format("metadata").
+			MetadataHeaders("From", "List-Unsubscribe", "List-Unsubscribe-Post").Do()
+		if err != nil {
+			common.WriteJSONError(w, http.StatusNotFound, "Bericht niet gevonden", log)
+			return
+		}
+
+		var sender *string
+		var listUnsubscribe, listUnsubscribePost string
+		for _, header := range message.Payload.Headers {
+			switch header.Name {
+			case "From":
+				sender = &header.Value
+			case "List-Unsubscribe":
+				listUnsubscribe = header.Value
+			case "List-Unsubscribe-Post":
+				listUnsubscribePost = header.Value
+			}
+		}
+
+		target := unsubscribe.Parse(listUnsubscribe, listUnsubscribePost)
+		if !target.CanUnsubscribe() {
+			common.WriteJSONError(
+				w,
+				http.StatusUnprocessableEntity,
+				"Bericht heeft geen automatische uitschrijfoptie",
+				log,
+			)
+			return
+		}
+
+		method, used, unsubscribeErr := unsubscriber.Unsubscribe(ctx, client, target)
+
+		params := store.CreateGmailUnsubscribeParams{
+			ConnectedAccountID: accountID,
+			GmailMessageID:     messageID,
+			Sender:             sender,
+			Method:             string(method),
+			Target:             used,
+			Status:             domain.GmailUnsubscribeSuccess,
+		}
+		if unsubscribeErr != nil {
+			errorMessage := unsubscribeErr.Error()
+			params.Status = domain.GmailUnsubscribeFailed
+			params.ErrorMessage = &errorMessage
+		}
+
+		result, err := storer.CreateGmailUnsubscribe(ctx, params)
+		if err != nil {
+			log.Error("HANDLER ERROR [CreateGmailUnsubscribe]", zap.Error(err))
+		}
+
+		if unsubscribeErr != nil {
+			common.WriteJSONError(w, http.StatusBadGateway, fmt.Sprintf("Uitschrijven mislukt: %v", unsubscribeErr), log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, result, log)
+	}
+}
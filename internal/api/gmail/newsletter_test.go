@@ -0,0 +1,73 @@
+package gmail
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestHandleGetGmailNewsletterReport(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	accountID := uuid.New()
+
+	senders := []domain.GmailNewsletterSender{{Sender: "News <news@example.com>", MessageCount: 42, OneClick: true}}
+	mockStore.On("GetGmailNewsletterSenders", mock.Anything, accountID, 5).Return(senders, nil)
+
+	req := newAccountRequest("GET", "", userID, map[string]string{"accountId": accountID.String()})
+	q := req.URL.Query()
+	q.Set("limit", "5")
+	req.URL.RawQuery = q.Encode()
+	rr := httptest.NewRecorder()
+
+	HandleGetGmailNewsletterReport(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response struct {
+		Senders []domain.GmailNewsletterSender `json:"senders"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, senders, response.Senders)
+	mockStore.AssertExpectations(t)
+}
+
+func TestHandleGetGmailNewsletterReport_InvalidLimitUsesDefault(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	accountID := uuid.New()
+
+	mockStore.On("GetGmailNewsletterSenders", mock.Anything, accountID, 20).
+		Return([]domain.GmailNewsletterSender{}, nil)
+
+	req := newAccountRequest("GET", "", userID, map[string]string{"accountId": accountID.String()})
+	req.URL.RawQuery = "limit=5000"
+	rr := httptest.NewRecorder()
+
+	HandleGetGmailNewsletterReport(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockStore.AssertExpectations(t)
+}
+
+func TestHandleUnsubscribeGmailMessage_WithoutAccount(t *testing.T) {
+	mockStore := &store.MockStore{}
+
+	req := newAccountRequest("POST", "", uuid.New(), map[string]string{
+		"messageId": "msg-1",
+	})
+	rr := httptest.NewRecorder()
+
+	HandleUnsubscribeGmailMessage(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	mockStore.AssertNotCalled(t, "CreateGmailUnsubscribe", mock.Anything, mock.Anything)
+}
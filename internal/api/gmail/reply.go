@@ -0,0 +1,362 @@
+package gmail
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/email"
+	"agenda-automator-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"google.golang.org/api/gmail/v1"
+)
+
+// replyRequest is de body van reply en reply-all. Ontvangers, onderwerp en thread
+// worden uit het originele bericht afgeleid; Cc en Bcc komen er alleen bij.
+type replyRequest struct {
+	Body        string                        `json:"body"`
+	IsHTML      bool                          `json:"isHtml,omitempty"`
+	TextBody    string                        `json:"textBody,omitempty"`
+	FromName    string                        `json:"fromName,omitempty"`
+	Cc          []string                      `json:"cc,omitempty"`
+	Bcc         []string                      `json:"bcc,omitempty"`
+	Attachments []domain.GmailAttachmentInput `json:"attachments,omitempty"`
+	// Quote voegt het originele bericht als citaat onder het antwoord toe
+	Quote bool `json:"quote,omitempty"`
+}
+
+// forwardRequest is de body van forward.
+type forwardRequest struct {
+	To   []string `json:"to"`
+	Cc   []string `json:"cc,omitempty"`
+	Bcc  []string `json:"bcc,omitempty"`
+	Note string   `json:"note,omitempty"`
+	Mode string   `json:"mode,omitempty"`
+}
+
+// htmlBodyPattern herkent een body die ExtractMessageBody uit een text/html onderdeel haalde
+var htmlBodyPattern = regexp.MustCompile(`(?i)<(html|body|div|p|br|table|span)\b`)
+
+// HandleReplyGmailMessage beantwoordt een bericht aan de afzender, in dezelfde thread.
+func HandleReplyGmailMessage(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return handleReply(storer, log, false)
+}
+
+// HandleReplyAllGmailMessage beantwoordt een bericht aan de afzender en alle ontvangers,
+// behalve de eigen adressen van het account.
+func HandleReplyAllGmailMessage(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return handleReply(storer, log, true)
+}
+
+func handleReply(storer store.Storer, log *zap.Logger, all bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, err := common.GetAccountFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
+			return
+		}
+		messageID := chi.URLParam(r, "messageId")
+
+		var req replyRequest
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
+			return
+		}
+		if strings.TrimSpace(req.Body) == "" {
+			common.WriteJSONError(w, http.StatusBadRequest, "Body is verplicht", log)
+			return
+		}
+
+		ctx := r.Context()
+		client, err := common.GetGmailClient(ctx, storer, account.ID, log)
+		if err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail client niet initialiseren", log)
+			return
+		}
+
+		original, err := client.Users.Messages.Get("me", messageID).Format("full").Do()
+		if err != nil {
+			if isGmailNotFound(err) {
+				common.WriteJSONError(w, http.StatusNotFound, "Bericht niet gevonden", log)
+				return
+			}
+			common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon bericht niet ophalen: %v", err), log)
+			return
+		}
+		if original.Payload == nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, "Bericht heeft geen inhoud", log)
+			return
+		}
+		headers := original.Payload.Headers
+
+		to, cc := replyRecipients(headers, ownAddresses(client, account.Email, log), all)
+		if len(to) == 0 {
+			common.WriteJSONError(w, http.StatusBadRequest, "Geen ontvangers voor het antwoord", log)
+			return
+		}
+
+		outgoing := domain.GmailOutgoingMessage{
+			To:          to,
+			Cc:          mergeAddresses(cc, req.Cc),
+			Bcc:         req.Bcc,
+			FromName:    req.FromName,
+			Subject:     replySubject(partHeader(headers, "Subject")),
+			Body:        req.Body,
+			IsHTML:      req.IsHTML,
+			TextBody:    req.TextBody,
+			Attachments: req.Attachments,
+			ThreadID:    original.ThreadId,
+		}
+		outgoing.InReplyTo, outgoing.References = replyReferences(headers)
+		if req.Quote {
+			quoteOriginal(&outgoing, headers, common.ExtractMessageBody(original.Payload))
+		}
+
+		if _, err = email.FromOutgoing(outgoing).Build(); err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, fmt.Sprintf("Ongeldig bericht: %v", err), log)
+			return
+		}
+
+		message, err := email.ComposeGmail(client, outgoing)
+		if err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon email niet opstellen: %v", err), log)
+			return
+		}
+
+		sent, err := client.Users.Messages.Send("me", message).Do()
+		if err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon email niet versturen: %v", err), log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, sent, log)
+	}
+}
+
+// HandleForwardGmailMessage stuurt een bericht door, inline of als bijlage, in dezelfde thread.
+func HandleForwardGmailMessage(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, err := common.GetAccountFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
+			return
+		}
+		messageID := chi.URLParam(r, "messageId")
+
+		var req forwardRequest
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
+			return
+		}
+		if len(req.To) == 0 {
+			common.WriteJSONError(w, http.StatusBadRequest, "Minimaal één ontvanger is verplicht", log)
+			return
+		}
+		mode, err := email.ParseForwardMode(req.Mode)
+		if err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige mode, gebruik inline of attachment", log)
+			return
+		}
+
+		ctx := r.Context()
+		client, err := common.GetGmailClient(ctx, storer, account.ID, log)
+		if err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail client niet initialiseren", log)
+			return
+		}
+
+		original, err := client.Users.Messages.Get("me", messageID).Format("raw").Do()
+		if err != nil {
+			if isGmailNotFound(err) {
+				common.WriteJSONError(w, http.StatusNotFound, "Bericht niet gevonden", log)
+				return
+			}
+			common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon bericht niet ophalen: %v", err), log)
+			return
+		}
+		raw, err := email.DecodeRaw(original.Raw)
+		if err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon bericht niet lezen", log)
+			return
+		}
+
+		m, err := email.Forward(raw, mode, req.To, req.Note)
+		if err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon bericht niet doorsturen: %v", err), log)
+			return
+		}
+		m.Cc, m.Bcc = req.Cc, req.Bcc
+		if header, err := mail.ReadMessage(bytes.NewReader(raw)); err == nil {
+			m.InReplyTo, m.References = referencesFor(header.Header.Get("Message-ID"), header.Header.Get("References"))
+		}
+
+		encoded, err := m.Raw()
+		if err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, fmt.Sprintf("Ongeldig bericht: %v", err), log)
+			return
+		}
+
+		sent, err := client.Users.Messages.Send("me", &gmail.Message{Raw: encoded, ThreadId: original.ThreadId}).Do()
+		if err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon email niet versturen: %v", err), log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, sent, log)
+	}
+}
+
+// ownAddresses geeft de kleine-letter adressen van het account: het hoofdadres plus
+// eventuele send-as aliassen. Lukt het ophalen van aliassen niet, dan alleen het hoofdadres.
+func ownAddresses(client *gmail.Service, accountEmail string, log *zap.Logger) map[string]bool {
+	own := map[string]bool{strings.ToLower(accountEmail): true}
+
+	sendAs, err := client.Users.Settings.SendAs.List("me").Do()
+	if err != nil {
+		log.Warn("could not list send-as aliases", zap.Error(err))
+		return own
+	}
+	for _, alias := range sendAs.SendAs {
+		own[strings.ToLower(alias.SendAsEmail)] = true
+	}
+	return own
+}
+
+// replyRecipients bepaalt de ontvangers van een antwoord. Het antwoord gaat naar Reply-To,
+// anders naar From; kwam het origineel van het account zelf, dan naar de oorspronkelijke To.
+// Bij reply-all komen To en Cc van het origineel erbij. Eigen adressen en dubbelen vallen weg.
+func replyRecipients(headers []*gmail.MessagePartHeader, own map[string]bool, all bool) (to, cc []string) {
+	sender := partHeader(headers, "Reply-To")
+	if sender == "" {
+		sender = partHeader(headers, "From")
+	}
+	originalTo := parseAddressHeader(partHeader(headers, "To"))
+
+	fromSelf := false
+	if from := parseAddressHeader(partHeader(headers, "From")); len(from) == 1 {
+		fromSelf = own[strings.ToLower(from[0].Address)]
+	}
+
+	seen := make(map[string]bool)
+	add := func(list []string, addresses []*mail.Address) []string {
+		for _, a := range addresses {
+			key := strings.ToLower(a.Address)
+			if own[key] || seen[key] {
+				continue
+			}
+			seen[key] = true
+			list = append(list, a.String())
+		}
+		return list
+	}
+
+	if fromSelf {
+		to = add(to, originalTo)
+	} else {
+		to = add(to, parseAddressHeader(sender))
+		if all {
+			to = add(to, originalTo)
+		}
+	}
+	if all {
+		cc = add(cc, parseAddressHeader(partHeader(headers, "Cc")))
+	}
+	return to, cc
+}
+
+// mergeAddresses voegt extra adressen toe die nog niet (hoofdletterongevoelig) in base staan.
+func mergeAddresses(base, extra []string) []string {
+	seen := make(map[string]bool, len(base))
+	for _, a := range parseAddressHeader(strings.Join(base, ", ")) {
+		seen[strings.ToLower(a.Address)] = true
+	}
+	for _, value := range extra {
+		parsed, err := mail.ParseAddress(value)
+		if err == nil && seen[strings.ToLower(parsed.Address)] {
+			continue
+		}
+		base = append(base, value)
+	}
+	return base
+}
+
+func parseAddressHeader(value string) []*mail.Address {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	list, err := mail.ParseAddressList(value)
+	if err != nil {
+		return nil
+	}
+	return list
+}
+
+// replySubject geeft het onderwerp voor een antwoord, met precies één "Re:" ervoor.
+func replySubject(subject string) string {
+	if strings.HasPrefix(strings.ToLower(subject), "re:") {
+		return subject
+	}
+	return strings.TrimSpace("Re: " + subject)
+}
+
+// replyReferences geeft de In-Reply-To en References headers voor een antwoord (RFC 5322 sectie 3.6.4).
+func replyReferences(headers []*gmail.MessagePartHeader) (inReplyTo, references string) {
+	return referencesFor(partHeader(headers, "Message-ID"), partHeader(headers, "References"))
+}
+
+func referencesFor(messageID, previous string) (inReplyTo, references string) {
+	if messageID == "" {
+		return "", ""
+	}
+	if previous != "" {
+		return messageID, previous + " " + messageID
+	}
+	return messageID, messageID
+}
+
+// quoteOriginal zet het originele bericht als citaat onder de body, zoals Gmail dat doet.
+func quoteOriginal(outgoing *domain.GmailOutgoingMessage, headers []*gmail.MessagePartHeader, original string) {
+	if strings.TrimSpace(original) == "" {
+		return
+	}
+	attribution := fmt.Sprintf("On %s, %s wrote:", partHeader(headers, "Date"), partHeader(headers, "From"))
+	originalIsHTML := htmlBodyPattern.MatchString(original)
+
+	if !outgoing.IsHTML {
+		if originalIsHTML {
+			// Een HTML citaat in een tekstbericht is onleesbaar
+			return
+		}
+		outgoing.Body += "\n\n" + attribution + "\n" + quoteText(original)
+		return
+	}
+
+	quoted := original
+	if !originalIsHTML {
+		quoted = strings.ReplaceAll(html.EscapeString(original), "\n", "<br>")
+	}
+	outgoing.Body += `<br><div class="gmail_quote"><div>` + html.EscapeString(attribution) + `</div>` +
+		`<blockquote style="margin:0 0 0 .8ex;border-left:1px #ccc solid;padding-left:1ex">` +
+		quoted + `</blockquote></div>`
+	if outgoing.TextBody != "" && !originalIsHTML {
+		outgoing.TextBody += "\n\n" + attribution + "\n" + quoteText(original)
+	}
+}
+
+// quoteText zet "> " voor elke regel.
+func quoteText(text string) string {
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = "> " + line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,178 @@
+package gmail
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"google.golang.org/api/gmail/v1"
+)
+
+func testHeaders(pairs ...string) []*gmail.MessagePartHeader {
+	var headers []*gmail.MessagePartHeader
+	for i := 0; i+1 < len(pairs); i += 2 {
+		headers = append(headers, &gmail.MessagePartHeader{Name: pairs[i], Value: pairs[i+1]})
+	}
+	return headers
+}
+
+func TestReplyRecipients(t *testing.T) {
+	own := map[string]bool{"ik@example.com": true, "alias@example.com": true}
+
+	tests := []struct {
+		name    string
+		headers []*gmail.MessagePartHeader
+		all     bool
+		wantTo  []string
+		wantCc  []string
+	}{
+		{
+			name:    "reply goes to sender",
+			headers: testHeaders("From", `"Klant" <klant@example.com>`, "To", "ik@example.com, collega@example.com"),
+			wantTo:  []string{`"Klant" <klant@example.com>`},
+		},
+		{
+			name:    "reply prefers Reply-To",
+			headers: testHeaders("From", "klant@example.com", "Reply-To", "support@example.com"),
+			wantTo:  []string{"<support@example.com>"},
+		},
+		{
+			name: "reply-all drops own addresses and duplicates",
+			headers: testHeaders(
+				"From", "klant@example.com",
+				"To", "IK@example.com, collega@example.com, KLANT@example.com",
+				"Cc", "alias@example.com, manager@example.com, collega@example.com",
+			),
+			all:    true,
+			wantTo: []string{"<klant@example.com>", "<collega@example.com>"},
+			wantCc: []string{"<manager@example.com>"},
+		},
+		{
+			name:    "reply to own message goes to original recipients",
+			headers: testHeaders("From", "ik@example.com", "To", "klant@example.com"),
+			wantTo:  []string{"<klant@example.com>"},
+		},
+		{
+			name:    "no usable sender",
+			headers: testHeaders("Subject", "Leeg"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			to, cc := replyRecipients(tt.headers, own, tt.all)
+			assert.Equal(t, tt.wantTo, to)
+			assert.Equal(t, tt.wantCc, cc)
+		})
+	}
+}
+
+func TestMergeAddresses(t *testing.T) {
+	merged := mergeAddresses([]string{"<manager@example.com>"}, []string{"Manager@example.com", "nieuw@example.com"})
+	assert.Equal(t, []string{"<manager@example.com>", "nieuw@example.com"}, merged)
+}
+
+func TestReplySubject(t *testing.T) {
+	assert.Equal(t, "Re: Offerte", replySubject("Offerte"))
+	assert.Equal(t, "RE: Offerte", replySubject("RE: Offerte"))
+	assert.Equal(t, "Re:", replySubject(""))
+}
+
+func TestReplyReferences(t *testing.T) {
+	inReplyTo, references := replyReferences(testHeaders("Message-ID", "<b@example.com>", "References", "<a@example.com>"))
+	assert.Equal(t, "<b@example.com>", inReplyTo)
+	assert.Equal(t, "<a@example.com> <b@example.com>", references)
+
+	inReplyTo, references = replyReferences(testHeaders("Message-ID", "<a@example.com>"))
+	assert.Equal(t, "<a@example.com>", inReplyTo)
+	assert.Equal(t, "<a@example.com>", references)
+
+	inReplyTo, references = replyReferences(nil)
+	assert.Empty(t, inReplyTo)
+	assert.Empty(t, references)
+}
+
+func TestQuoteOriginal(t *testing.T) {
+	headers := testHeaders("From", "klant@example.com", "Date", "Mon, 5 Oct 2026 10:00:00 +0200")
+
+	t.Run("plain text", func(t *testing.T) {
+		outgoing := domain.GmailOutgoingMessage{Body: "Akkoord"}
+		quoteOriginal(&outgoing, headers, "Kan het morgen?\nGroet")
+		assert.Equal(t, "Akkoord\n\nOn Mon, 5 Oct 2026 10:00:00 +0200, klant@example.com wrote:\n> Kan het morgen?\n> Groet", outgoing.Body)
+	})
+
+	t.Run("html reply escapes plain original", func(t *testing.T) {
+		outgoing := domain.GmailOutgoingMessage{Body: "<p>Akkoord</p>", IsHTML: true, TextBody: "Akkoord"}
+		quoteOriginal(&outgoing, headers, "1 < 2\nGroet")
+		assert.Contains(t, outgoing.Body, "<blockquote")
+		assert.Contains(t, outgoing.Body, "1 &lt; 2<br>Groet")
+		assert.Contains(t, outgoing.TextBody, "> 1 < 2")
+	})
+
+	t.Run("html original is not quoted in plain reply", func(t *testing.T) {
+		outgoing := domain.GmailOutgoingMessage{Body: "Akkoord"}
+		quoteOriginal(&outgoing, headers, "<div>Kan het morgen?</div>")
+		assert.Equal(t, "Akkoord", outgoing.Body)
+	})
+}
+
+func TestHandleReplyGmailMessage_EmptyBody(t *testing.T) {
+	mockStore := &store.MockStore{}
+	accountID := uuid.New()
+
+	req := newAccountRequest("POST", `{"body":"  "}`, uuid.New(), map[string]string{
+		"accountId": accountID.String(),
+		"messageId": "msg-1",
+	})
+	rr := httptest.NewRecorder()
+
+	HandleReplyAllGmailMessage(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Body is verplicht")
+	mockStore.AssertNotCalled(t, "GetValidTokenForAccount", mock.Anything, mock.Anything)
+}
+
+func TestHandleForwardGmailMessage_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "missing recipients", body: `{"note":"FYI"}`, want: "Minimaal één ontvanger is verplicht"},
+		{name: "invalid mode", body: `{"to":["collega@example.com"],"mode":"zip"}`, want: "Ongeldige mode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &store.MockStore{}
+			req := newAccountRequest("POST", tt.body, uuid.New(), map[string]string{
+				"accountId": uuid.New().String(),
+				"messageId": "msg-1",
+			})
+			rr := httptest.NewRecorder()
+
+			HandleForwardGmailMessage(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Contains(t, rr.Body.String(), tt.want)
+			mockStore.AssertNotCalled(t, "GetValidTokenForAccount", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandleReplyGmailMessage_WithoutAccount(t *testing.T) {
+	req := newAccountRequest("POST", `{"body":"Akkoord"}`, uuid.New(), map[string]string{"messageId": "msg-1"})
+	rr := httptest.NewRecorder()
+
+	HandleReplyGmailMessage(&store.MockStore{}, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
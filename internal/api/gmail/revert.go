@@ -0,0 +1,50 @@
+package gmail
+
+import (
+	"net/http"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/store"
+	gmailworker "agenda-automator-api/internal/worker/gmail"
+
+	"go.uber.org/zap"
+)
+
+// HandleRevertGmailRule draait de geslaagde uitvoeringen van een Gmail rule in een periode terug:
+// labels, gelezen, archief en ster worden teruggezet en berichten komen uit de prullenbak.
+// Verstuurde berichten en afmeldingen worden gemeld als niet teruggedraaid.
+func HandleRevertGmailRule(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rule, _, ok := getOwnedGmailRule(w, r, storer, log)
+		if !ok {
+			return
+		}
+
+		timeMin, timeMax, ok := common.DecodeRevertRequest(w, r, log)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+		logs, err := storer.GetRevertibleLogs(ctx, rule.ID, timeMin, timeMax)
+		if err != nil {
+			log.Error("HANDLER ERROR [RevertGmailRule GetRevertibleLogs]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon automation logs niet ophalen", log)
+			return
+		}
+
+		report := rules.NewRevertReport()
+		accountIDs, logsByAccount := common.GroupLogsByAccount(logs)
+		for _, accountID := range accountIDs {
+			client, err := common.GetGmailClient(ctx, storer, accountID, log)
+			if err != nil {
+				common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail client niet initialiseren", log)
+				return
+			}
+			report.Merge(gmailworker.Revert(ctx, client, logsByAccount[accountID]))
+		}
+
+		common.WriteRevertReport(w, r, storer, timeMin, timeMax, report, log)
+	}
+}
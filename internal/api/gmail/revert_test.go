@@ -0,0 +1,74 @@
+package gmail
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandleRevertGmailRule(t *testing.T) {
+	timeMin := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	timeMax := timeMin.Add(2 * time.Hour)
+	body := `{"time_min":"2026-03-01T08:00:00Z","time_max":"2026-03-01T10:00:00Z"}`
+
+	t.Run("nothing to revert", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		userID := uuid.New()
+		rule := ownedGmailRule(mockStore, userID)
+		mockStore.On("GetRevertibleLogs", mock.Anything, rule.ID, timeMin, timeMax).Return([]domain.AutomationLog{}, nil)
+
+		rr := httptest.NewRecorder()
+		HandleRevertGmailRule(mockStore, zap.NewNop()).
+			ServeHTTP(rr, newAccountRequest("POST", body, userID, map[string]string{"ruleId": rule.ID.String()}))
+
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var resp common.RevertResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Empty(t, resp.Reverted)
+		assert.Empty(t, resp.NotReverted)
+		mockStore.AssertNotCalled(t, "GetValidTokenForAccount", mock.Anything, mock.Anything)
+		mockStore.AssertNotCalled(t, "MarkLogsReverted", mock.Anything, mock.Anything)
+	})
+
+	t.Run("token error", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		userID := uuid.New()
+		rule := ownedGmailRule(mockStore, userID)
+		mockStore.On("GetRevertibleLogs", mock.Anything, rule.ID, timeMin, timeMax).
+			Return([]domain.AutomationLog{{ID: 1, ConnectedAccountID: rule.ConnectedAccountID, Status: domain.LogSuccess}}, nil)
+		mockStore.On("GetValidTokenForAccount", mock.Anything, rule.ConnectedAccountID).Return(nil, errors.New("token revoked"))
+
+		rr := httptest.NewRecorder()
+		HandleRevertGmailRule(mockStore, zap.NewNop()).
+			ServeHTTP(rr, newAccountRequest("POST", body, userID, map[string]string{"ruleId": rule.ID.String()}))
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		mockStore.AssertNotCalled(t, "MarkLogsReverted", mock.Anything, mock.Anything)
+	})
+
+	t.Run("time_min required", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		userID := uuid.New()
+		rule := ownedGmailRule(mockStore, userID)
+
+		rr := httptest.NewRecorder()
+		HandleRevertGmailRule(mockStore, zap.NewNop()).
+			ServeHTTP(rr, newAccountRequest("POST", `{}`, userID, map[string]string{"ruleId": rule.ID.String()}))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "time_min")
+	})
+}
@@ -0,0 +1,330 @@
+package gmail
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/store"
+	gmailworker "agenda-automator-api/internal/worker/gmail"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/api/gmail/v1"
+)
+
+// HandleGetGmailRule haalt een enkele Gmail automation rule op.
+func HandleGetGmailRule(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rule, _, ok := getOwnedGmailRule(w, r, storer, log)
+		if !ok {
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, rule, log)
+	}
+}
+
+// HandleUpdateGmailRule werkt een Gmail automation rule bij. Velden die niet in de
+// body staan behouden hun huidige waarde; de actieve status wijzigt alleen via toggle.
+func HandleUpdateGmailRule(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rule, userID, ok := getOwnedGmailRule(w, r, storer, log)
+		if !ok {
+			return
+		}
+
+		req := rule
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
+			return
+		}
+		if !checkGmailRule(w, r, storer, rule.ConnectedAccountID, req, log) {
+			return
+		}
+
+		updated, err := storer.UpdateGmailRule(r.Context(), store.UpdateGmailRuleParams{
+			RuleID:            rule.ID,
+			Name:              req.Name,
+			Description:       req.Description,
+			TriggerType:       req.TriggerType,
+			TriggerConditions: req.TriggerConditions,
+			ActionType:        req.ActionType,
+			ActionParams:      req.ActionParams,
+			Priority:          req.Priority,
+			Schedule:          req.Schedule,
+			ChangedBy:         userID,
+		})
+		if err != nil {
+			log.Error("HANDLER ERROR [UpdateGmailRule]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail rule niet updaten", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, updated, log)
+	}
+}
+
+// HandleDeleteGmailRule verwijdert een Gmail automation rule.
+func HandleDeleteGmailRule(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rule, _, ok := getOwnedGmailRule(w, r, storer, log)
+		if !ok {
+			return
+		}
+
+		if err := storer.DeleteGmailRule(r.Context(), rule.ID); err != nil {
+			log.Error("HANDLER ERROR [DeleteGmailRule]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail rule niet verwijderen", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusNoContent, nil, log)
+	}
+}
+
+// HandleToggleGmailRule zet een Gmail automation rule aan of uit.
+func HandleToggleGmailRule(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rule, userID, ok := getOwnedGmailRule(w, r, storer, log)
+		if !ok {
+			return
+		}
+
+		updated, err := storer.ToggleGmailRuleStatus(r.Context(), rule.ID, userID)
+		if err != nil {
+			log.Error("HANDLER ERROR [ToggleGmailRuleStatus]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail rule status niet togglen", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, updated, log)
+	}
+}
+
+// HandleReorderGmailRules herschrijft de prioriteiten van alle Gmail rules van het account.
+// De body bevat elke rule precies één keer; de eerste rule wordt als eerste uitgevoerd.
+func HandleReorderGmailRules(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, err := common.GetAccountFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
+			return
+		}
+
+		var req struct {
+			RuleIDs []uuid.UUID `json:"rule_ids"`
+		}
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
+			return
+		}
+
+		rules, err := storer.GetGmailRulesForAccount(r.Context(), account.ID)
+		if err != nil {
+			log.Error("HANDLER ERROR [GetGmailRulesForAccount]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail rules niet ophalen", log)
+			return
+		}
+		if !isRulePermutation(rules, req.RuleIDs) {
+			common.WriteJSONError(w, http.StatusBadRequest,
+				"rule_ids moet elke Gmail rule van het account precies één keer bevatten", log)
+			return
+		}
+
+		if err = storer.ReorderGmailRules(r.Context(), account.ID, req.RuleIDs); err != nil {
+			log.Error("HANDLER ERROR [ReorderGmailRules]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon volgorde van Gmail rules niet opslaan", log)
+			return
+		}
+
+		reordered, err := storer.GetGmailRulesForAccount(r.Context(), account.ID)
+		if err != nil {
+			log.Error("HANDLER ERROR [GetGmailRulesForAccount]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail rules niet ophalen", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, reordered, log)
+	}
+}
+
+// getOwnedGmailRule haalt de Gmail rule uit de URL op en controleert of die bij de gebruiker hoort.
+// Een onbekende rule en een rule van een andere gebruiker geven dezelfde 404.
+func getOwnedGmailRule(
+	w http.ResponseWriter,
+	r *http.Request,
+	storer store.Storer,
+	log *zap.Logger,
+) (domain.GmailAutomationRule, uuid.UUID, bool) {
+	ruleID, err := uuid.Parse(chi.URLParam(r, "ruleId"))
+	if err != nil {
+		common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig rule ID", log)
+		return domain.GmailAutomationRule{}, uuid.Nil, false
+	}
+
+	userID, err := common.GetUserIDFromContext(r.Context())
+	if err != nil {
+		common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
+		return domain.GmailAutomationRule{}, uuid.Nil, false
+	}
+
+	if err = storer.VerifyGmailRuleOwnership(r.Context(), ruleID, userID); err != nil {
+		common.WriteJSONError(w, http.StatusNotFound, "Gmail rule niet gevonden", log)
+		return domain.GmailAutomationRule{}, uuid.Nil, false
+	}
+
+	rule, err := storer.GetGmailRuleByID(r.Context(), ruleID)
+	if err != nil {
+		common.WriteJSONError(w, http.StatusNotFound, "Gmail rule niet gevonden", log)
+		return domain.GmailAutomationRule{}, uuid.Nil, false
+	}
+
+	return rule, userID, true
+}
+
+// validateGmailRule geeft alle ongeldige velden van een Gmail rule terug. Types en
+// parameters worden gecontroleerd tegen de registry die de worker ook gebruikt.
+func validateGmailRule(rule domain.GmailAutomationRule) rules.FieldErrors {
+	var errs rules.FieldErrors
+	if strings.TrimSpace(rule.Name) == "" {
+		errs.Add("name", "is required")
+	}
+	errs = append(errs, rules.ValidateSchedule(rule.Schedule).Prefixed("schedule")...)
+	return append(errs, gmailworker.Rules.ValidateRule(rules.Rule{
+		TriggerType:   string(rule.TriggerType),
+		TriggerParams: rule.TriggerConditions,
+		ActionType:    string(rule.ActionType),
+		ActionParams:  rule.ActionParams,
+	})...)
+}
+
+// labelReference is een label waar een rule op rekent, met het veldpad waarin het staat.
+type labelReference struct {
+	field string
+	name  string
+}
+
+// labelReferences geeft de labels die al moeten bestaan. add_label staat er niet tussen:
+// die actie maakt een ontbrekend label zelf aan.
+func labelReferences(rule domain.GmailAutomationRule) []labelReference {
+	var refs []labelReference
+	var params struct {
+		LabelName string `json:"label_name"`
+	}
+	if rule.TriggerType == domain.GmailTriggerLabelAdded && json.Unmarshal(rule.TriggerConditions, &params) == nil {
+		refs = append(refs, labelReference{field: "trigger_conditions.label_name", name: params.LabelName})
+	}
+	params.LabelName = ""
+	if rule.ActionType == domain.GmailActionRemoveLabel && json.Unmarshal(rule.ActionParams, &params) == nil {
+		refs = append(refs, labelReference{field: "action_params.label_name", name: params.LabelName})
+	}
+	return refs
+}
+
+// missingLabels geeft een veldfout voor elke referentie die geen bestaande label naam of ID is.
+// Systeemlabels zoals IMPORTANT hebben dezelfde naam en ID.
+func missingLabels(refs []labelReference, labels []*gmail.Label) rules.FieldErrors {
+	known := make(map[string]bool, 2*len(labels))
+	for _, label := range labels {
+		known[label.Id] = true
+		known[label.Name] = true
+	}
+	var errs rules.FieldErrors
+	for _, ref := range refs {
+		if !known[ref.name] {
+			errs.Add(ref.field, "label %q does not exist", ref.name)
+		}
+	}
+	return errs
+}
+
+// validateGmailRuleLabels controleert bij Gmail of de labels waar de rule op rekent bestaan.
+// Rules zonder zulke labels kosten geen API call.
+func validateGmailRuleLabels(
+	ctx context.Context,
+	storer store.Storer,
+	accountID uuid.UUID,
+	rule domain.GmailAutomationRule,
+	log *zap.Logger,
+) (rules.FieldErrors, error) {
+	refs := labelReferences(rule)
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	client, err := common.GetGmailClient(ctx, storer, accountID, log)
+	if err != nil {
+		return nil, err
+	}
+	list, err := client.Users.Labels.List("me").Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+	return missingLabels(refs, list.Labels), nil
+}
+
+// ValidateGmailRule geeft alle ongeldige velden van een Gmail rule voor accountID, inclusief labels
+// die niet in Gmail bestaan. Een fout betekent dat de labels niet opgehaald konden worden.
+func ValidateGmailRule(
+	ctx context.Context,
+	storer store.Storer,
+	accountID uuid.UUID,
+	rule domain.GmailAutomationRule,
+	log *zap.Logger,
+) (rules.FieldErrors, error) {
+	if fieldErrs := validateGmailRule(rule); len(fieldErrs) > 0 {
+		return fieldErrs, nil
+	}
+	if accountID == uuid.Nil {
+		// Een user-level rule heeft geen account om labels tegen te controleren; elk account
+		// zoekt de labels zelf op bij het uitvoeren
+		return nil, nil
+	}
+	return validateGmailRuleLabels(ctx, storer, accountID, rule, log)
+}
+
+// checkGmailRule controleert een rule voor het opslaan en schrijft bij een fout een 422 (of 500)
+// en geeft dan false terug.
+func checkGmailRule(
+	w http.ResponseWriter,
+	r *http.Request,
+	storer store.Storer,
+	accountID uuid.UUID,
+	rule domain.GmailAutomationRule,
+	log *zap.Logger,
+) bool {
+	fieldErrs, err := ValidateGmailRule(r.Context(), storer, accountID, rule, log)
+	if err != nil {
+		log.Error("HANDLER ERROR [validateGmailRuleLabels]", zap.Error(err))
+		common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail labels niet ophalen", log)
+		return false
+	}
+	if len(fieldErrs) > 0 {
+		common.WriteValidationErrors(w, fieldErrs, log)
+		return false
+	}
+	return true
+}
+
+// isRulePermutation controleert of ids precies de rules van het account bevat, zonder dubbelen.
+func isRulePermutation(rules []domain.GmailAutomationRule, ids []uuid.UUID) bool {
+	if len(ids) != len(rules) {
+		return false
+	}
+	remaining := make(map[uuid.UUID]bool, len(rules))
+	for _, rule := range rules {
+		remaining[rule.ID] = true
+	}
+	for _, id := range ids {
+		if !remaining[id] {
+			return false
+		}
+		delete(remaining, id)
+	}
+	return true
+}
@@ -0,0 +1,307 @@
+package gmail
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/api/gmail/v1"
+)
+
+func testGmailRule(accountID uuid.UUID, priority int) domain.GmailAutomationRule {
+	return domain.GmailAutomationRule{
+		BaseAutomationRule: domain.BaseAutomationRule{
+			AccountEntity: domain.AccountEntity{
+				BaseEntity:         domain.BaseEntity{ID: uuid.New()},
+				ConnectedAccountID: accountID,
+			},
+			Name:              "Nieuwsbrieven archiveren",
+			IsActive:          true,
+			TriggerConditions: json.RawMessage(`{"sender_pattern":"news@example.com"}`),
+			ActionParams:      json.RawMessage(`{}`),
+		},
+		TriggerType: domain.GmailTriggerSenderMatch,
+		ActionType:  domain.GmailActionArchive,
+		Priority:    priority,
+	}
+}
+
+func TestHandleGetGmailRule_OtherUser(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	ruleID := uuid.New()
+
+	mockStore.On("VerifyGmailRuleOwnership", mock.Anything, ruleID, userID).
+		Return(errors.New("forbidden: gmail rule not found or does not belong to user"))
+
+	req := newAccountRequest("GET", "", userID, map[string]string{"ruleId": ruleID.String()})
+	rr := httptest.NewRecorder()
+
+	HandleGetGmailRule(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Gmail rule niet gevonden")
+	mockStore.AssertNotCalled(t, "GetGmailRuleByID", mock.Anything, mock.Anything)
+}
+
+func TestHandleUpdateGmailRule_KeepsOmittedFields(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	rule := testGmailRule(uuid.New(), 5)
+
+	mockStore.On("VerifyGmailRuleOwnership", mock.Anything, rule.ID, userID).Return(nil)
+	mockStore.On("GetGmailRuleByID", mock.Anything, rule.ID).Return(rule, nil)
+	mockStore.On("UpdateGmailRule", mock.Anything, mock.MatchedBy(func(p store.UpdateGmailRuleParams) bool {
+		return p.RuleID == rule.ID &&
+			p.Name == "Hernoemd" &&
+			p.ActionType == domain.GmailActionTrash &&
+			p.TriggerType == rule.TriggerType &&
+			p.Priority == 5 &&
+			string(p.TriggerConditions) == string(rule.TriggerConditions)
+	})).Return(rule, nil)
+
+	req := newAccountRequest("PUT", `{"name":"Hernoemd","action_type":"trash"}`, userID,
+		map[string]string{"ruleId": rule.ID.String()})
+	rr := httptest.NewRecorder()
+
+	HandleUpdateGmailRule(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockStore.AssertExpectations(t)
+}
+
+func TestHandleUpdateGmailRule_InvalidActionType(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	rule := testGmailRule(uuid.New(), 1)
+
+	mockStore.On("VerifyGmailRuleOwnership", mock.Anything, rule.ID, userID).Return(nil)
+	mockStore.On("GetGmailRuleByID", mock.Anything, rule.ID).Return(rule, nil)
+
+	req := newAccountRequest("PUT", `{"action_type":"explode"}`, userID,
+		map[string]string{"ruleId": rule.ID.String()})
+	rr := httptest.NewRecorder()
+
+	HandleUpdateGmailRule(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.JSONEq(t, `{"error":"Validatie mislukt","fields":[
+		{"field":"action_type","message":"unknown action type \"explode\""}
+	]}`, rr.Body.String())
+	mockStore.AssertNotCalled(t, "UpdateGmailRule", mock.Anything, mock.Anything)
+}
+
+func TestHandleUpdateGmailRule_LabelLookupFails(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	rule := testGmailRule(uuid.New(), 1)
+
+	mockStore.On("VerifyGmailRuleOwnership", mock.Anything, rule.ID, userID).Return(nil)
+	mockStore.On("GetGmailRuleByID", mock.Anything, rule.ID).Return(rule, nil)
+	mockStore.On("GetValidTokenForAccount", mock.Anything, rule.ConnectedAccountID).Return(nil, errors.New("token revoked"))
+
+	req := newAccountRequest("PUT", `{"action_type":"remove_label","action_params":{"label_name":"Klanten"}}`, userID,
+		map[string]string{"ruleId": rule.ID.String()})
+	rr := httptest.NewRecorder()
+
+	HandleUpdateGmailRule(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Kon Gmail labels niet ophalen")
+	mockStore.AssertNotCalled(t, "UpdateGmailRule", mock.Anything, mock.Anything)
+}
+
+func TestHandleToggleGmailRule(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	rule := testGmailRule(uuid.New(), 1)
+	toggled := rule
+	toggled.IsActive = false
+
+	mockStore.On("VerifyGmailRuleOwnership", mock.Anything, rule.ID, userID).Return(nil)
+	mockStore.On("GetGmailRuleByID", mock.Anything, rule.ID).Return(rule, nil)
+	mockStore.On("ToggleGmailRuleStatus", mock.Anything, rule.ID, userID).Return(toggled, nil)
+
+	req := newAccountRequest("PUT", "", userID, map[string]string{"ruleId": rule.ID.String()})
+	rr := httptest.NewRecorder()
+
+	HandleToggleGmailRule(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var got domain.GmailAutomationRule
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.False(t, got.IsActive)
+	mockStore.AssertExpectations(t)
+}
+
+func TestHandleDeleteGmailRule(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	rule := testGmailRule(uuid.New(), 1)
+
+	mockStore.On("VerifyGmailRuleOwnership", mock.Anything, rule.ID, userID).Return(nil)
+	mockStore.On("GetGmailRuleByID", mock.Anything, rule.ID).Return(rule, nil)
+	mockStore.On("DeleteGmailRule", mock.Anything, rule.ID).Return(nil)
+
+	req := newAccountRequest("DELETE", "", userID, map[string]string{"ruleId": rule.ID.String()})
+	rr := httptest.NewRecorder()
+
+	HandleDeleteGmailRule(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	mockStore.AssertExpectations(t)
+}
+
+func TestHandleReorderGmailRules(t *testing.T) {
+	userID := uuid.New()
+	accountID := uuid.New()
+	first, second := testGmailRule(accountID, 2), testGmailRule(accountID, 1)
+	rules := []domain.GmailAutomationRule{first, second}
+
+	t.Run("rewrites priorities", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		newOrder := []uuid.UUID{second.ID, first.ID}
+
+		mockStore.On("GetGmailRulesForAccount", mock.Anything, accountID).Return(rules, nil)
+		mockStore.On("ReorderGmailRules", mock.Anything, accountID, newOrder).Return(nil)
+
+		body, _ := json.Marshal(map[string]any{"rule_ids": newOrder})
+		req := newAccountRequest("PUT", string(body), userID, map[string]string{"accountId": accountID.String()})
+		rr := httptest.NewRecorder()
+
+		HandleReorderGmailRules(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("rejects incomplete or foreign lists", func(t *testing.T) {
+		for name, ids := range map[string][]uuid.UUID{
+			"missing rule":   {first.ID},
+			"duplicate rule": {first.ID, first.ID},
+			"foreign rule":   {first.ID, uuid.New()},
+		} {
+			t.Run(name, func(t *testing.T) {
+				mockStore := &store.MockStore{}
+				mockStore.On("GetGmailRulesForAccount", mock.Anything, accountID).Return(rules, nil)
+
+				body, _ := json.Marshal(map[string]any{"rule_ids": ids})
+				req := newAccountRequest("PUT", string(body), userID, map[string]string{"accountId": accountID.String()})
+				rr := httptest.NewRecorder()
+
+				HandleReorderGmailRules(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+				assert.Equal(t, http.StatusBadRequest, rr.Code)
+				mockStore.AssertNotCalled(t, "ReorderGmailRules", mock.Anything, mock.Anything, mock.Anything)
+			})
+		}
+	})
+}
+
+func TestValidateGmailRule(t *testing.T) {
+	valid := testGmailRule(uuid.New(), 0)
+	assert.Empty(t, validateGmailRule(valid))
+
+	tests := []struct {
+		name   string
+		modify func(r *domain.GmailAutomationRule)
+		want   rules.FieldErrors
+	}{
+		{
+			name:   "no name",
+			modify: func(r *domain.GmailAutomationRule) { r.Name = " " },
+			want:   rules.FieldErrors{{Field: "name", Message: "is required"}},
+		},
+		{
+			name:   "unknown trigger",
+			modify: func(r *domain.GmailAutomationRule) { r.TriggerType = "on_full_moon" },
+			want:   rules.FieldErrors{{Field: "trigger_type", Message: `unknown trigger type "on_full_moon"`}},
+		},
+		{
+			name:   "missing action",
+			modify: func(r *domain.GmailAutomationRule) { r.ActionType = "" },
+			want:   rules.FieldErrors{{Field: "action_type", Message: `unknown action type ""`}},
+		},
+		{
+			name: "empty pattern and non-positive duration",
+			modify: func(r *domain.GmailAutomationRule) {
+				r.TriggerConditions = json.RawMessage(`{"sender_pattern":""}`)
+				r.ActionType = domain.GmailActionSnooze
+				r.ActionParams = json.RawMessage(`{"duration_minutes":0}`)
+			},
+			want: rules.FieldErrors{
+				{Field: "trigger_conditions.sender_pattern", Message: "is required"},
+				{Field: "action_params.duration_minutes", Message: "must be positive"},
+			},
+		},
+		{
+			name: "wrong parameter type",
+			modify: func(r *domain.GmailAutomationRule) {
+				r.ActionType = domain.GmailActionSchedule
+				r.ActionParams = json.RawMessage(`{"delay_minutes":"morgen"}`)
+			},
+			want: rules.FieldErrors{{Field: "action_params.delay_minutes", Message: "must be of type int"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := valid
+			tt.modify(&rule)
+			assert.Equal(t, tt.want, validateGmailRule(rule))
+		})
+	}
+
+	regex := valid
+	regex.TriggerConditions = json.RawMessage(`{"sender_pattern":"[a-z+@example.com","regex":true}`)
+	errs := validateGmailRule(regex)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "trigger_conditions.sender_pattern", errs[0].Field)
+	assert.Contains(t, errs[0].Message, "invalid regular expression")
+}
+
+func TestLabelReferences(t *testing.T) {
+	rule := testGmailRule(uuid.New(), 0)
+	assert.Empty(t, labelReferences(rule))
+
+	// add_label maakt het label zelf aan en hoeft dus niet te bestaan
+	rule.ActionType = domain.GmailActionAddLabel
+	rule.ActionParams = json.RawMessage(`{"label_name":"Nieuw"}`)
+	assert.Empty(t, labelReferences(rule))
+
+	rule.TriggerType = domain.GmailTriggerLabelAdded
+	rule.TriggerConditions = json.RawMessage(`{"label_name":"IMPORTANT"}`)
+	rule.ActionType = domain.GmailActionRemoveLabel
+	rule.ActionParams = json.RawMessage(`{"label_name":"Klanten"}`)
+	assert.Equal(t, []labelReference{
+		{field: "trigger_conditions.label_name", name: "IMPORTANT"},
+		{field: "action_params.label_name", name: "Klanten"},
+	}, labelReferences(rule))
+}
+
+func TestMissingLabels(t *testing.T) {
+	labels := []*gmail.Label{
+		{Id: "IMPORTANT", Name: "IMPORTANT"},
+		{Id: "Label_7", Name: "Klanten"},
+	}
+	refs := []labelReference{
+		{field: "trigger_conditions.label_name", name: "Label_7"},
+		{field: "action_params.label_name", name: "Klanten"},
+		{field: "action_params.label_name", name: "Leveranciers"},
+	}
+
+	assert.Equal(t, rules.FieldErrors{
+		{Field: "action_params.label_name", Message: `label "Leveranciers" does not exist`},
+	}, missingLabels(refs, labels))
+}
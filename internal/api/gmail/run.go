@@ -0,0 +1,33 @@
+package gmail
+
+import (
+	"net/http"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/api/run"
+	"agenda-automator-api/internal/store"
+
+	"go.uber.org/zap"
+)
+
+// HandleRunGmailRule start direct een verwerking van één Gmail rule op de berichten van het
+// afgelopen etmaal. Een rule van een account draait op dat account, een user-level rule op elk
+// actief account met Gmail sync dat zijn selector kiest. Een uitgezette rule geeft 409.
+func HandleRunGmailRule(storer store.Storer, runs run.Enqueuer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rule, userID, ok := getOwnedGmailRule(w, r, storer, log)
+		if !ok {
+			return
+		}
+		if !rule.IsActive {
+			common.WriteJSONError(w, http.StatusConflict, "Gmail rule staat uit", log)
+			return
+		}
+
+		arg := store.CreateProcessingRunParams{UserID: userID, GmailRuleID: &rule.ID}
+		if !rule.IsUserRule() {
+			arg.ConnectedAccountID = &rule.ConnectedAccountID
+		}
+		run.Start(w, r, storer, runs, arg, log)
+	}
+}
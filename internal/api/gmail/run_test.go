@@ -0,0 +1,68 @@
+package gmail
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeEnqueuer houdt bij welke runs de handler klaarzet.
+type fakeEnqueuer struct {
+	runs []domain.ProcessingRun
+}
+
+func (f *fakeEnqueuer) EnqueueRun(run domain.ProcessingRun) bool {
+	f.runs = append(f.runs, run)
+	return true
+}
+
+func TestHandleRunGmailRule(t *testing.T) {
+	t.Run("account rule", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		userID := uuid.New()
+		rule := ownedGmailRule(mockStore, userID)
+		queued := domain.ProcessingRun{ID: uuid.New(), GmailRuleID: &rule.ID, Status: domain.RunQueued}
+
+		// De run hoort bij het account van de rule, en niet bij een calendar rule
+		mockStore.On("CreateProcessingRun", mock.Anything, store.CreateProcessingRunParams{
+			UserID:             userID,
+			ConnectedAccountID: &rule.ConnectedAccountID,
+			GmailRuleID:        &rule.ID,
+		}).Return(queued, nil)
+		runs := &fakeEnqueuer{}
+
+		rr := httptest.NewRecorder()
+		HandleRunGmailRule(mockStore, runs, zap.NewNop()).
+			ServeHTTP(rr, newAccountRequest("POST", "", userID, map[string]string{"ruleId": rule.ID.String()}))
+
+		assert.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		require.Len(t, runs.runs, 1)
+		assert.Equal(t, queued.ID, runs.runs[0].ID)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("inactive rule", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		userID := uuid.New()
+		rule := testGmailRule(uuid.New(), 1)
+		rule.IsActive = false
+		mockStore.On("VerifyGmailRuleOwnership", mock.Anything, rule.ID, userID).Return(nil)
+		mockStore.On("GetGmailRuleByID", mock.Anything, rule.ID).Return(rule, nil)
+
+		rr := httptest.NewRecorder()
+		HandleRunGmailRule(mockStore, &fakeEnqueuer{}, zap.NewNop()).
+			ServeHTTP(rr, newAccountRequest("POST", "", userID, map[string]string{"ruleId": rule.ID.String()}))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		mockStore.AssertNotCalled(t, "CreateProcessingRun", mock.Anything, mock.Anything)
+	})
+}
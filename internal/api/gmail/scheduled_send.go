@@ -0,0 +1,177 @@
+package gmail
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// scheduleGmailMessage zet een bericht in de wachtrij in plaats van het direct te versturen.
+func scheduleGmailMessage(
+	w http.ResponseWriter,
+	r *http.Request,
+	storer store.Storer,
+	log *zap.Logger,
+	accountID uuid.UUID,
+	message domain.GmailOutgoingMessage,
+	sendAt time.Time,
+) {
+	if !validateScheduledSend(w, log, message, sendAt) {
+		return
+	}
+
+	send, err := storer.CreateGmailScheduledSend(r.Context(), store.CreateGmailScheduledSendParams{
+		ConnectedAccountID: accountID,
+		Message:            message,
+		SendAt:             sendAt,
+	})
+	if err != nil {
+		log.Error("HANDLER ERROR [CreateGmailScheduledSend]", zap.Error(err))
+		common.WriteJSONError(w, http.StatusInternalServerError, "Kon bericht niet inplannen", log)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusCreated, send, log)
+}
+
+// validateScheduledSend schrijft een 400 response en geeft false terug als het bericht niet ingepland kan worden.
+func validateScheduledSend(
+	w http.ResponseWriter,
+	log *zap.Logger,
+	message domain.GmailOutgoingMessage,
+	sendAt time.Time,
+) bool {
+	if len(message.To) == 0 && len(message.Cc) == 0 && len(message.Bcc) == 0 {
+		common.WriteJSONError(w, http.StatusBadRequest, "Minimaal één ontvanger is verplicht", log)
+		return false
+	}
+	if !sendAt.After(time.Now()) {
+		common.WriteJSONError(w, http.StatusBadRequest, "send_at moet in de toekomst liggen", log)
+		return false
+	}
+	return true
+}
+
+// HandleGetGmailScheduledSends haalt alle nog te versturen berichten van een account op.
+func HandleGetGmailScheduledSends(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, err := common.GetAccountFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
+			return
+		}
+		accountID := account.ID
+
+		sends, err := storer.GetPendingGmailScheduledSendsForAccount(r.Context(), accountID)
+		if err != nil {
+			log.Error("HANDLER ERROR [GetPendingGmailScheduledSendsForAccount]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon ingeplande berichten niet ophalen", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, sends, log)
+	}
+}
+
+// HandleUpdateGmailScheduledSend past de inhoud of het verzendtijdstip van een ingepland bericht aan.
+func HandleUpdateGmailScheduledSend(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		send, ok := getOwnedScheduledSend(w, r, storer, log)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			To      []string  `json:"to"`
+			Cc      []string  `json:"cc,omitempty"`
+			Bcc     []string  `json:"bcc,omitempty"`
+			Subject string    `json:"subject"`
+			Body    string    `json:"body"`
+			IsHTML  bool      `json:"isHtml,omitempty"`
+			SendAt  time.Time `json:"send_at"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
+			return
+		}
+
+		// Thread-informatie van een follow-up blijft behouden
+		message := domain.GmailOutgoingMessage{
+			To:        req.To,
+			Cc:        req.Cc,
+			Bcc:       req.Bcc,
+			Subject:   req.Subject,
+			Body:      req.Body,
+			IsHTML:    req.IsHTML,
+			ThreadID:  send.Message.ThreadID,
+			InReplyTo: send.Message.InReplyTo,
+		}
+		if !validateScheduledSend(w, log, message, req.SendAt) {
+			return
+		}
+
+		updated, err := storer.UpdateGmailScheduledSend(r.Context(), send.ID, message, req.SendAt)
+		if err != nil {
+			log.Error("HANDLER ERROR [UpdateGmailScheduledSend]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusConflict, "Bericht is al verstuurd of geannuleerd", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, updated, log)
+	}
+}
+
+// HandleCancelGmailScheduledSend annuleert een ingepland bericht.
+func HandleCancelGmailScheduledSend(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		send, ok := getOwnedScheduledSend(w, r, storer, log)
+		if !ok {
+			return
+		}
+
+		err := storer.UpdateGmailScheduledSendStatus(r.Context(), send.ID, domain.GmailScheduledSendCancelled, nil, nil)
+		if err != nil {
+			log.Error("HANDLER ERROR [UpdateGmailScheduledSendStatus]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusConflict, "Bericht is al verstuurd of geannuleerd", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusNoContent, nil, log)
+	}
+}
+
+// getOwnedScheduledSend haalt het ingeplande bericht uit de URL op en controleert of het bij het account hoort.
+func getOwnedScheduledSend(
+	w http.ResponseWriter,
+	r *http.Request,
+	storer store.Storer,
+	log *zap.Logger,
+) (domain.GmailScheduledSend, bool) {
+	account, err := common.GetAccountFromContext(r.Context())
+	if err != nil {
+		common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
+		return domain.GmailScheduledSend{}, false
+	}
+
+	sendID, err := uuid.Parse(chi.URLParam(r, "scheduledId"))
+	if err != nil {
+		common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig bericht ID", log)
+		return domain.GmailScheduledSend{}, false
+	}
+
+	send, err := storer.GetGmailScheduledSendByID(r.Context(), sendID)
+	if err != nil || send.ConnectedAccountID != account.ID || send.Status != domain.GmailScheduledSendPending {
+		common.WriteJSONError(w, http.StatusNotFound, "Ingepland bericht niet gevonden", log)
+		return domain.GmailScheduledSend{}, false
+	}
+
+	return send, true
+}
@@ -0,0 +1,110 @@
+package gmail
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestHandleSendGmailMessage_WithSendAtSchedules(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	accountID := uuid.New()
+	sendAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	mockStore.On("CreateGmailScheduledSend", mock.Anything, mock.MatchedBy(func(p store.CreateGmailScheduledSendParams) bool {
+		return p.ConnectedAccountID == accountID && p.Message.Subject == "Later" && p.SendAt.Equal(sendAt)
+	})).Return(domain.GmailScheduledSend{Status: domain.GmailScheduledSendPending, SendAt: sendAt}, nil)
+
+	body := `{"to": ["a@example.com"], "subject": "Later", "body": "Hoi", "send_at": "` + sendAt.Format(time.RFC3339) + `"}`
+	req := newAccountRequest("POST", body, userID, map[string]string{"accountId": accountID.String()})
+	rr := httptest.NewRecorder()
+
+	HandleSendGmailMessage(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	mockStore.AssertExpectations(t)
+	// Een ingepland bericht heeft nog geen Gmail client nodig
+	mockStore.AssertNotCalled(t, "GetValidTokenForAccount", mock.Anything, mock.Anything)
+}
+
+func TestHandleSendGmailMessage_SendAtInPast(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	accountID := uuid.New()
+
+	body := `{"to": ["a@example.com"], "send_at": "` + time.Now().Add(-time.Hour).Format(time.RFC3339) + `"}`
+	req := newAccountRequest("POST", body, userID, map[string]string{"accountId": accountID.String()})
+	rr := httptest.NewRecorder()
+
+	HandleSendGmailMessage(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockStore.AssertNotCalled(t, "CreateGmailScheduledSend", mock.Anything, mock.Anything)
+}
+
+func TestHandleUpdateGmailScheduledSend_KeepsThread(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	accountID := uuid.New()
+	sendID := uuid.New()
+	sendAt := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+
+	existing := domain.GmailScheduledSend{
+		AccountEntity: domain.AccountEntity{BaseEntity: domain.BaseEntity{ID: sendID}, ConnectedAccountID: accountID},
+		Message:       domain.GmailOutgoingMessage{ThreadID: "thread-1", InReplyTo: "<abc@example.com>"},
+		Status:        domain.GmailScheduledSendPending,
+	}
+	mockStore.On("GetGmailScheduledSendByID", mock.Anything, sendID).Return(existing, nil)
+	mockStore.On("UpdateGmailScheduledSend", mock.Anything, sendID, mock.MatchedBy(func(m domain.GmailOutgoingMessage) bool {
+		return m.Body == "Aangepast" && m.ThreadID == "thread-1" && m.InReplyTo == "<abc@example.com>"
+	}), mock.MatchedBy(sendAt.Equal)).Return(existing, nil)
+
+	body := `{"to": ["a@example.com"], "body": "Aangepast", "send_at": "` + sendAt.Format(time.RFC3339) + `"}`
+	req := newAccountRequest("PUT", body, userID, map[string]string{
+		"accountId":   accountID.String(),
+		"scheduledId": sendID.String(),
+	})
+	rr := httptest.NewRecorder()
+
+	HandleUpdateGmailScheduledSend(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	mockStore.AssertExpectations(t)
+}
+
+func TestHandleCancelGmailScheduledSend_AlreadySent(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	accountID := uuid.New()
+	sendID := uuid.New()
+
+	mockStore.On("GetGmailScheduledSendByID", mock.Anything, sendID).Return(domain.GmailScheduledSend{
+		AccountEntity: domain.AccountEntity{ConnectedAccountID: accountID},
+		Status:        domain.GmailScheduledSendSent,
+	}, nil)
+
+	req := newAccountRequest("DELETE", "", userID, map[string]string{
+		"accountId":   accountID.String(),
+		"scheduledId": sendID.String(),
+	})
+	rr := httptest.NewRecorder()
+
+	HandleCancelGmailScheduledSend(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	var response map[string]string
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "Ingepland bericht niet gevonden", response["error"])
+	mockStore.AssertNotCalled(t, "UpdateGmailScheduledSendStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,135 @@
+package gmail
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+	gmailworker "agenda-automator-api/internal/worker/gmail"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultSimulationLimit is het aantal recente berichten uit gmail_messages dat standaard getest wordt
+	defaultSimulationLimit = 500
+	maxSimulationLimit     = 5000
+)
+
+// simulateRequest is de body van de Gmail simulate endpoints. Rule is alleen nodig voor het ad-hoc endpoint.
+type simulateRequest struct {
+	Limit int                         `json:"limit,omitempty"`
+	Rule  *domain.GmailAutomationRule `json:"rule,omitempty"`
+}
+
+// SimulationResponse is het resultaat van een dry-run van een Gmail rule.
+type SimulationResponse struct {
+	MessagesChecked int                           `json:"messages_checked"`
+	Matches         []gmailworker.SimulationMatch `json:"matches"`
+}
+
+// HandleSimulateGmailRule laat zien wat een opgeslagen Gmail rule met de recente berichten in
+// gmail_messages zou doen. Er gaat niets naar Gmail en er worden geen logs geschreven.
+func HandleSimulateGmailRule(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, err := common.GetAccountFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
+			return
+		}
+
+		rule, _, ok := getOwnedGmailRule(w, r, storer, log)
+		if !ok {
+			return
+		}
+		if !rule.AppliesTo(&account) {
+			common.WriteJSONError(w, http.StatusNotFound, "Gmail rule niet gevonden", log)
+			return
+		}
+		// Een user-level rule wordt gesimuleerd zoals de worker hem voor dit account draait
+		rule.ConnectedAccountID = account.ID
+
+		req, ok := decodeSimulateRequest(w, r, log)
+		if !ok {
+			return
+		}
+
+		simulateGmailRule(w, r, storer, account, rule, req.Limit, log)
+	}
+}
+
+// HandleSimulateAdHocGmailRule doet hetzelfde voor een rule die nog niet opgeslagen is.
+// De rule wordt gevalideerd zoals bij aanmaken, behalve het bestaan van labels.
+func HandleSimulateAdHocGmailRule(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, err := common.GetAccountFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
+			return
+		}
+
+		req, ok := decodeSimulateRequest(w, r, log)
+		if !ok {
+			return
+		}
+		if req.Rule == nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "rule is verplicht", log)
+			return
+		}
+		if fieldErrs := validateGmailRule(*req.Rule); len(fieldErrs) > 0 {
+			common.WriteValidationErrors(w, fieldErrs.Prefixed("rule"), log)
+			return
+		}
+
+		rule := *req.Rule
+		rule.ConnectedAccountID = account.ID
+		simulateGmailRule(w, r, storer, account, rule, req.Limit, log)
+	}
+}
+
+// decodeSimulateRequest leest de body en vult de limiet aan. Een lege body is toegestaan.
+func decodeSimulateRequest(w http.ResponseWriter, r *http.Request, log *zap.Logger) (simulateRequest, bool) {
+	var req simulateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
+		return req, false
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultSimulationLimit
+	}
+	if req.Limit < 0 || req.Limit > maxSimulationLimit {
+		common.WriteJSONError(w, http.StatusBadRequest, "limit moet tussen 1 en 5000 liggen", log)
+		return req, false
+	}
+	return req, true
+}
+
+func simulateGmailRule(
+	w http.ResponseWriter,
+	r *http.Request,
+	storer store.Storer,
+	account domain.ConnectedAccount,
+	rule domain.GmailAutomationRule,
+	limit int,
+	log *zap.Logger,
+) {
+	messages, err := storer.GetGmailMessagesForAccount(r.Context(), account.ID, limit)
+	if err != nil {
+		log.Error("HANDLER ERROR [SimulateGmailRule GetGmailMessagesForAccount]", zap.Error(err))
+		common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail berichten niet ophalen", log)
+		return
+	}
+
+	matches, err := gmailworker.Simulate(r.Context(), rule, messages)
+	if err != nil {
+		// Opgeslagen rules van vóór de validatie kunnen ongeldig zijn
+		common.WriteJSONError(w, http.StatusUnprocessableEntity, "Rule is ongeldig: "+err.Error(), log)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, SimulationResponse{MessagesChecked: len(messages), Matches: matches}, log)
+}
@@ -0,0 +1,132 @@
+package gmail
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandleSimulateGmailRule(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	accountID := uuid.New()
+	rule := testGmailRule(accountID, 1)
+	rule.IsActive = false
+
+	sender, subject := "news@example.com", "Weekoverzicht"
+	other := "jan@example.org"
+	messages := []domain.GmailMessage{
+		{GmailMessageID: "msg-1", Sender: &sender, Subject: &subject, Labels: []string{"INBOX"}},
+		{GmailMessageID: "msg-2", Sender: &other, Subject: &subject, Labels: []string{"INBOX"}},
+	}
+
+	mockStore.On("VerifyGmailRuleOwnership", mock.Anything, rule.ID, userID).Return(nil)
+	mockStore.On("GetGmailRuleByID", mock.Anything, rule.ID).Return(rule, nil)
+	mockStore.On("GetGmailMessagesForAccount", mock.Anything, accountID, 100).Return(messages, nil)
+
+	req := newAccountRequest("POST", `{"limit": 100}`, userID, map[string]string{
+		"accountId": accountID.String(),
+		"ruleId":    rule.ID.String(),
+	})
+	rr := httptest.NewRecorder()
+
+	HandleSimulateGmailRule(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var response SimulationResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, 2, response.MessagesChecked)
+	require.Len(t, response.Matches, 1)
+	assert.Equal(t, "msg-1", response.Matches[0].GmailMessageID)
+	assert.Equal(t, []string{"INBOX"}, response.Matches[0].Action.RemoveLabels)
+
+	// Een simulatie schrijft niets weg
+	mockStore.AssertNotCalled(t, "CreateAutomationLog", mock.Anything, mock.Anything)
+	mockStore.AssertNotCalled(t, "UpdateGmailMessageStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleSimulateGmailRule_OtherAccount(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	rule := testGmailRule(uuid.New(), 1)
+
+	mockStore.On("VerifyGmailRuleOwnership", mock.Anything, rule.ID, userID).Return(nil)
+	mockStore.On("GetGmailRuleByID", mock.Anything, rule.ID).Return(rule, nil)
+
+	req := newAccountRequest("POST", "", userID, map[string]string{
+		"accountId": uuid.New().String(),
+		"ruleId":    rule.ID.String(),
+	})
+	rr := httptest.NewRecorder()
+
+	HandleSimulateGmailRule(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	mockStore.AssertNotCalled(t, "GetGmailMessagesForAccount", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleSimulateAdHocGmailRule(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	accountID := uuid.New()
+	subject := "Factuur 42"
+	messages := []domain.GmailMessage{{GmailMessageID: "msg-1", Subject: &subject}}
+
+	mockStore.On("GetGmailMessagesForAccount", mock.Anything, accountID, defaultSimulationLimit).Return(messages, nil)
+
+	body := `{"rule": {"name": "Facturen", "trigger_type": "subject_match",
+		"trigger_conditions": {"subject_pattern": "factuur \\d+", "regex": true},
+		"action_type": "add_label", "action_params": {"label_name": "Boekhouding"}}}`
+	req := newAccountRequest("POST", body, userID, map[string]string{"accountId": accountID.String()})
+	rr := httptest.NewRecorder()
+
+	HandleSimulateAdHocGmailRule(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var response SimulationResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	require.Len(t, response.Matches, 1)
+	assert.Equal(t, []string{"Boekhouding"}, response.Matches[0].Action.AddLabels)
+}
+
+func TestHandleSimulateAdHocGmailRule_Validation(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+		want     string
+	}{
+		{name: "missing rule", body: `{}`, wantCode: http.StatusBadRequest, want: "rule is verplicht"},
+		{name: "limit too high", body: `{"limit": 100000}`, wantCode: http.StatusBadRequest, want: "limit"},
+		{
+			name:     "invalid rule",
+			body:     `{"rule": {"name": "x", "trigger_type": "sender_match", "action_type": "archive"}}`,
+			wantCode: http.StatusUnprocessableEntity,
+			want:     "rule.trigger_conditions.sender_pattern",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &store.MockStore{}
+			req := newAccountRequest("POST", tt.body, uuid.New(), map[string]string{"accountId": uuid.New().String()})
+			rr := httptest.NewRecorder()
+
+			HandleSimulateAdHocGmailRule(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.Contains(t, rr.Body.String(), tt.want)
+			mockStore.AssertNotCalled(t, "GetGmailMessagesForAccount", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package gmail
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/api/gmail/v1"
+)
+
+// HandleSnoozeGmailMessage haalt een bericht uit de inbox tot het opgegeven tijdstip.
+func HandleSnoozeGmailMessage(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, err := common.GetAccountFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
+			return
+		}
+		accountID := account.ID
+		messageID := chi.URLParam(r, "messageId")
+
+		var req struct {
+			WakeAt time.Time `json:"wake_at"`
+		}
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
+			return
+		}
+		if !req.WakeAt.After(time.Now()) {
+			common.WriteJSONError(w, http.StatusBadRequest, "wake_at moet in de toekomst liggen", log)
+			return
+		}
+
+		ctx := r.Context()
+		client, err := common.GetGmailClient(ctx, storer, accountID, log)
+		if err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail client niet initialiseren", log)
+			return
+		}
+
+		message, err := client.Users.Messages.Get("me", messageID).Format("minimal").Do()
+		if err != nil {
+			common.WriteJSONError(w, http.StatusNotFound, "Bericht niet gevonden", log)
+			return
+		}
+
+		labelID, err := getOrCreateLabelID(client, domain.GmailSnoozedLabel)
+		if err != nil {
+			log.Error("HANDLER ERROR [getOrCreateLabelID]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Snoozed label niet aanmaken", log)
+			return
+		}
+
+		_, err = client.Users.Messages.Modify("me", messageID, &gmail.ModifyMessageRequest{
+			AddLabelIds:    []string{labelID},
+			RemoveLabelIds: []string{"INBOX"},
+		}).Do()
+		if err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon bericht niet snoozen: %v", err), log)
+			return
+		}
+
+		snooze, err := storer.CreateGmailSnooze(ctx, store.CreateGmailSnoozeParams{
+			ConnectedAccountID: accountID,
+			GmailMessageID:     message.Id,
+			GmailThreadID:      message.ThreadId,
+			WakeAt:             req.WakeAt,
+		})
+		if err != nil {
+			log.Error("HANDLER ERROR [CreateGmailSnooze]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon snooze niet opslaan", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusCreated, snooze, log)
+	}
+}
+
+// HandleGetGmailSnoozes haalt alle nog gesnoozede berichten van een account op.
+func HandleGetGmailSnoozes(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, err := common.GetAccountFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
+			return
+		}
+		accountID := account.ID
+
+		snoozes, err := storer.GetPendingGmailSnoozesForAccount(r.Context(), accountID)
+		if err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon snoozes niet ophalen", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, snoozes, log)
+	}
+}
+
+// HandleCancelGmailSnooze annuleert een snooze en zet het bericht direct terug in de inbox.
+func HandleCancelGmailSnooze(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, err := common.GetAccountFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
+			return
+		}
+		accountID := account.ID
+
+		snoozeID, err := uuid.Parse(chi.URLParam(r, "snoozeId"))
+		if err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig snooze ID", log)
+			return
+		}
+
+		snooze, err := storer.GetGmailSnoozeByID(r.Context(), snoozeID)
+		if err != nil || snooze.ConnectedAccountID != accountID || snooze.Status != domain.GmailSnoozePending {
+			common.WriteJSONError(w, http.StatusNotFound, "Snooze niet gevonden", log)
+			return
+		}
+
+		ctx := r.Context()
+		client, err := common.GetGmailClient(ctx, storer, accountID, log)
+		if err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail client niet initialiseren", log)
+			return
+		}
+
+		modifyRequest := &gmail.ModifyMessageRequest{AddLabelIds: []string{"INBOX"}}
+		if labelID, labelErr := findLabelID(client, domain.GmailSnoozedLabel); labelErr == nil && labelID != "" {
+			modifyRequest.RemoveLabelIds = []string{labelID}
+		}
+		if _, err = client.Users.Messages.Modify("me", snooze.GmailMessageID, modifyRequest).Do(); err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, fmt.Sprintf("Kon bericht niet terugzetten: %v", err), log)
+			return
+		}
+
+		if err = storer.UpdateGmailSnoozeStatus(ctx, snoozeID, domain.GmailSnoozeCancelled, nil); err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon snooze niet annuleren", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusNoContent, nil, log)
+	}
+}
+
+// findLabelID zoekt een label op naam. Een lege ID betekent dat het label niet bestaat.
+func findLabelID(client *gmail.Service, name string) (string, error) {
+	labels, err := client.Users.Labels.List("me").Do()
+	if err != nil {
+		return "", err
+	}
+
+	for _, label := range labels.Labels {
+		if label.Name == name {
+			return label.Id, nil
+		}
+	}
+	return "", nil
+}
+
+// getOrCreateLabelID zoekt een label op naam en maakt het aan als het nog niet bestaat.
+func getOrCreateLabelID(client *gmail.Service, name string) (string, error) {
+	labelID, err := findLabelID(client, name)
+	if err != nil || labelID != "" {
+		return labelID, err
+	}
+
+	created, err := client.Users.Labels.Create("me", &gmail.Label{
+		Name:                  name,
+		LabelListVisibility:   "labelShow",
+		MessageListVisibility: "show",
+	}).Do()
+	if err != nil {
+		return "", err
+	}
+	return created.Id, nil
+}
@@ -0,0 +1,118 @@
+package gmail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// newAccountRequest bouwt een request met user ID en chi URL parameters. Met een accountId
+// staat het account in de context, zoals na de AccountOwnershipMiddleware.
+func newAccountRequest(method, body string, userID uuid.UUID, params map[string]string) *http.Request {
+	req := httptest.NewRequest(method, "/", bytes.NewBufferString(body))
+	ctx := context.WithValue(req.Context(), common.UserContextKey, userID)
+	if accountID, err := uuid.Parse(params["accountId"]); err == nil {
+		ctx = common.WithAccount(ctx, domain.ConnectedAccount{ID: accountID, UserID: userID})
+	}
+
+	rctx := chi.NewRouteContext()
+	for key, value := range params {
+		rctx.URLParams.Add(key, value)
+	}
+	return req.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx))
+}
+
+func TestHandleSnoozeGmailMessage_WakeAtInPast(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	accountID := uuid.New()
+
+	body := `{"wake_at": "` + time.Now().Add(-time.Hour).Format(time.RFC3339) + `"}`
+	req := newAccountRequest("POST", body, userID, map[string]string{
+		"accountId": accountID.String(),
+		"messageId": "msg-1",
+	})
+	rr := httptest.NewRecorder()
+
+	HandleSnoozeGmailMessage(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "CreateGmailSnooze", mock.Anything, mock.Anything)
+}
+
+func TestHandleSnoozeGmailMessage_WithoutAccount(t *testing.T) {
+	mockStore := &store.MockStore{}
+
+	body := `{"wake_at": "` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`
+	req := newAccountRequest("POST", body, uuid.New(), map[string]string{
+		"messageId": "msg-1",
+	})
+	rr := httptest.NewRecorder()
+
+	HandleSnoozeGmailMessage(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestHandleGetGmailSnoozes(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	accountID := uuid.New()
+
+	snoozes := []domain.GmailSnooze{
+		{GmailMessageID: "msg-1", Status: domain.GmailSnoozePending, WakeAt: time.Now().Add(time.Hour)},
+	}
+	mockStore.On("GetPendingGmailSnoozesForAccount", mock.Anything, accountID).Return(snoozes, nil)
+
+	req := newAccountRequest("GET", "", userID, map[string]string{"accountId": accountID.String()})
+	rr := httptest.NewRecorder()
+
+	HandleGetGmailSnoozes(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response []domain.GmailSnooze
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Len(t, response, 1)
+	assert.Equal(t, "msg-1", response[0].GmailMessageID)
+	mockStore.AssertExpectations(t)
+}
+
+func TestHandleCancelGmailSnooze_OtherAccount(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	accountID := uuid.New()
+	snoozeID := uuid.New()
+
+	// De snooze hoort bij een ander account
+	mockStore.On("GetGmailSnoozeByID", mock.Anything, snoozeID).Return(domain.GmailSnooze{
+		AccountEntity: domain.AccountEntity{ConnectedAccountID: uuid.New()},
+		Status:        domain.GmailSnoozePending,
+	}, nil)
+
+	req := newAccountRequest("DELETE", "", userID, map[string]string{
+		"accountId": accountID.String(),
+		"snoozeId":  snoozeID.String(),
+	})
+	rr := httptest.NewRecorder()
+
+	HandleCancelGmailSnooze(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	mockStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "UpdateGmailSnoozeStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
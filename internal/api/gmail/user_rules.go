@@ -0,0 +1,126 @@
+package gmail
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"go.uber.org/zap"
+)
+
+// HandleCreateUserGmailRule maakt een Gmail rule van de gebruiker zelf. account_selector kiest op
+// welke van zijn accounts de worker de rule draait. Labels worden niet tegen een account
+// gecontroleerd, omdat elk account ze zelf opzoekt.
+func HandleCreateUserGmailRule(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := common.GetUserIDFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
+			return
+		}
+
+		var req domain.GmailAutomationRule
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
+			return
+		}
+		fieldErrs := validateGmailRule(req)
+		selectorErrs, err := common.ValidateAccountSelector(r.Context(), storer, userID, req.AccountSelector)
+		if err != nil {
+			log.Error("HANDLER ERROR [ValidateAccountSelector]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon accounts niet ophalen", log)
+			return
+		}
+		if fieldErrs = append(fieldErrs, selectorErrs...); len(fieldErrs) > 0 {
+			common.WriteValidationErrors(w, fieldErrs, log)
+			return
+		}
+
+		rule, err := storer.CreateUserGmailRule(r.Context(), store.CreateUserGmailRuleParams{
+			UserID:            userID,
+			AccountSelector:   *req.AccountSelector,
+			Name:              req.Name,
+			Description:       req.Description,
+			IsActive:          req.IsActive,
+			TriggerType:       req.TriggerType,
+			TriggerConditions: req.TriggerConditions,
+			ActionType:        req.ActionType,
+			ActionParams:      req.ActionParams,
+			Priority:          req.Priority,
+			Schedule:          req.Schedule,
+		})
+		if err != nil {
+			log.Error("HANDLER ERROR [CreateUserGmailRule]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail rule niet creren", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusCreated, rule, log)
+	}
+}
+
+// HandleGetUserGmailRules geeft de Gmail rules van de gebruiker zelf, hoogste priority eerst.
+func HandleGetUserGmailRules(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := common.GetUserIDFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
+			return
+		}
+
+		rules, err := storer.GetUserGmailRules(r.Context(), userID)
+		if err != nil {
+			log.Error("HANDLER ERROR [GetUserGmailRules]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail rules niet ophalen", log)
+			return
+		}
+		if rules == nil {
+			rules = []domain.GmailAutomationRule{}
+		}
+
+		common.WriteJSON(w, http.StatusOK, rules, log)
+	}
+}
+
+// HandleUpdateUserGmailRuleAccounts vervangt de account_selector van een user-level Gmail rule.
+// De body is de selector zelf.
+func HandleUpdateUserGmailRuleAccounts(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rule, userID, ok := getOwnedGmailRule(w, r, storer, log)
+		if !ok {
+			return
+		}
+		if !rule.IsUserRule() {
+			common.WriteJSONError(w, http.StatusNotFound, "Gmail rule niet gevonden", log)
+			return
+		}
+
+		var selector domain.AccountSelector
+		if err := json.NewDecoder(r.Body).Decode(&selector); err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
+			return
+		}
+		fieldErrs, err := common.ValidateAccountSelector(r.Context(), storer, userID, &selector)
+		if err != nil {
+			log.Error("HANDLER ERROR [ValidateAccountSelector]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon accounts niet ophalen", log)
+			return
+		}
+		if len(fieldErrs) > 0 {
+			common.WriteValidationErrors(w, fieldErrs, log)
+			return
+		}
+
+		updated, err := storer.UpdateGmailRuleAccountSelector(r.Context(), rule.ID, selector, userID)
+		if err != nil {
+			log.Error("HANDLER ERROR [UpdateGmailRuleAccountSelector]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail rule niet updaten", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, updated, log)
+	}
+}
@@ -0,0 +1,115 @@
+package gmail
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandleCreateUserGmailRule(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	created := testGmailRule(uuid.Nil, 5)
+	created.UserID = &userID
+	created.AccountSelector = &domain.AccountSelector{Mode: domain.AccountSelectorProvider, Provider: domain.ProviderGoogle}
+
+	// Labels worden per account opgezocht, dus er is geen GetGmailLabelsForAccount verwachting
+	mockStore.On("CreateUserGmailRule", mock.Anything, mock.MatchedBy(func(p store.CreateUserGmailRuleParams) bool {
+		return p.UserID == userID && p.TriggerType == domain.GmailTriggerLabelAdded &&
+			p.AccountSelector.Provider == domain.ProviderGoogle
+	})).Return(created, nil)
+
+	body := `{"name": "Facturen doorsturen", "trigger_type": "label_added",
+		"trigger_conditions": {"label_name": "Facturen"}, "action_type": "archive", "priority": 5,
+		"account_selector": {"mode": "provider", "provider": "google"}}`
+	req := newAccountRequest("POST", body, userID, nil)
+	rr := httptest.NewRecorder()
+
+	HandleCreateUserGmailRule(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	mockStore.AssertExpectations(t)
+}
+
+func TestHandleCreateUserGmailRule_InvalidSelector(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+
+	body := `{"name": "Nieuwsbrieven", "trigger_type": "sender_match",
+		"trigger_conditions": {"sender_pattern": "news@example.com"}, "action_type": "archive",
+		"account_selector": {"mode": "provider", "provider": "yahoo"}}`
+	req := newAccountRequest("POST", body, userID, nil)
+	rr := httptest.NewRecorder()
+
+	HandleCreateUserGmailRule(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"account_selector.provider"`)
+	mockStore.AssertNotCalled(t, "CreateUserGmailRule", mock.Anything, mock.Anything)
+}
+
+func TestHandleGetUserGmailRules(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	rule := testGmailRule(uuid.Nil, 1)
+	rule.UserID = &userID
+	mockStore.On("GetUserGmailRules", mock.Anything, userID).Return([]domain.GmailAutomationRule{rule}, nil)
+
+	rr := httptest.NewRecorder()
+	HandleGetUserGmailRules(mockStore, zap.NewNop()).ServeHTTP(rr, newAccountRequest("GET", "", userID, nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var got []domain.GmailAutomationRule
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	require.Len(t, got, 1)
+	assert.Equal(t, rule.ID, got[0].ID)
+}
+
+func TestHandleUpdateUserGmailRuleAccounts(t *testing.T) {
+	userID := uuid.New()
+	accountID := uuid.New()
+	body := `{"mode": "accounts", "account_ids": ["` + accountID.String() + `"]}`
+
+	t.Run("updated", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		rule := testGmailRule(uuid.Nil, 1)
+		rule.UserID = &userID
+		rule.AccountSelector = &domain.AccountSelector{Mode: domain.AccountSelectorAll}
+		want := domain.AccountSelector{Mode: domain.AccountSelectorAccounts, AccountIDs: []uuid.UUID{accountID}}
+
+		mockStore.On("VerifyGmailRuleOwnership", mock.Anything, rule.ID, userID).Return(nil)
+		mockStore.On("GetGmailRuleByID", mock.Anything, rule.ID).Return(rule, nil)
+		mockStore.On("GetAccountsForUser", mock.Anything, userID).
+			Return([]domain.ConnectedAccount{{ID: accountID, UserID: userID}}, nil)
+		mockStore.On("UpdateGmailRuleAccountSelector", mock.Anything, rule.ID, want, userID).Return(rule, nil)
+
+		req := newAccountRequest("PUT", body, userID, map[string]string{"ruleId": rule.ID.String()})
+		rr := httptest.NewRecorder()
+		HandleUpdateUserGmailRuleAccounts(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("account rule", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		rule := ownedGmailRule(mockStore, userID)
+
+		req := newAccountRequest("PUT", body, userID, map[string]string{"ruleId": rule.ID.String()})
+		rr := httptest.NewRecorder()
+		HandleUpdateUserGmailRuleAccounts(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockStore.AssertNotCalled(t, "UpdateGmailRuleAccountSelector", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
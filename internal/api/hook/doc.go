@@ -0,0 +1,2 @@
+// Package hook handles the inbound hook API endpoints: managing the hooks of a user and queueing deliveries for the rules bound to them.
+package hook
@@ -0,0 +1,253 @@
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/store"
+	"agenda-automator-api/internal/webhook"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Grenzen voor hooks en leveringen
+const (
+	maxHookNameLength = 100
+	maxBodyBytes      = 64 << 10
+)
+
+// Enqueuer zet een levering aan een hook klaar. Wordt geïmplementeerd door de worker.
+type Enqueuer interface {
+	EnqueueHookDelivery(delivery domain.HookDelivery) bool
+}
+
+// createHookRequest is de body van HandleCreateHook. Wat een levering doet staat in de rules die
+// aan de hook gebonden zijn.
+type createHookRequest struct {
+	Name string `json:"name"`
+}
+
+func (req createHookRequest) validate() rules.FieldErrors {
+	var errs rules.FieldErrors
+	if strings.TrimSpace(req.Name) == "" {
+		errs.Add("name", "is required")
+	} else if len(req.Name) > maxHookNameLength {
+		errs.Add("name", "must be at most %d characters", maxHookNameLength)
+	}
+	return errs
+}
+
+// HandleCreateHook maakt een inbound hook voor de gebruiker. Het token staat alleen in deze response.
+func HandleCreateHook(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := common.GetUserIDFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
+			return
+		}
+
+		var req createHookRequest
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
+			return
+		}
+		if fieldErrs := req.validate(); len(fieldErrs) > 0 {
+			common.WriteValidationErrors(w, fieldErrs, log)
+			return
+		}
+
+		token, err := webhook.GenerateToken()
+		if err != nil {
+			log.Error("HANDLER ERROR [GenerateToken]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon hook niet aanmaken", log)
+			return
+		}
+		hook, err := storer.CreateInboundHook(r.Context(), store.CreateInboundHookParams{
+			UserID:    userID,
+			Name:      strings.TrimSpace(req.Name),
+			TokenHash: webhook.HashToken(token),
+		})
+		if err != nil {
+			log.Error("HANDLER ERROR [CreateInboundHook]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon hook niet aanmaken", log)
+			return
+		}
+
+		hook.Token = token
+		common.WriteJSON(w, http.StatusCreated, hook, log)
+	}
+}
+
+// HandleGetHooks haalt de hooks van de gebruiker op, ook ingetrokken hooks.
+func HandleGetHooks(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := common.GetUserIDFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
+			return
+		}
+
+		hooks, err := storer.GetInboundHooksForUser(r.Context(), userID)
+		if err != nil {
+			log.Error("HANDLER ERROR [GetInboundHooksForUser]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon hooks niet ophalen", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, hooks, log)
+	}
+}
+
+// HandleRevokeHook trekt een hook in; het token werkt daarna niet meer. De leveringen en de
+// gebonden rules blijven bewaard.
+func HandleRevokeHook(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, hookID, ok := userAndHookID(w, r, log)
+		if !ok {
+			return
+		}
+
+		hook, err := storer.RevokeInboundHook(r.Context(), hookID, userID)
+		if err != nil {
+			writeHookError(w, err, "RevokeInboundHook", "Kon hook niet intrekken", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, hook, log)
+	}
+}
+
+// HandleGetHookDeliveries haalt de laatste leveringen van een hook op, nieuwste eerst; ?limit is maximaal 100.
+func HandleGetHookDeliveries(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, hookID, ok := userAndHookID(w, r, log)
+		if !ok {
+			return
+		}
+
+		limit := 50 // default
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			if parsed, perr := strconv.Atoi(limitStr); perr == nil && parsed > 0 && parsed <= 100 {
+				limit = parsed
+			}
+		}
+
+		if _, err := storer.GetInboundHook(r.Context(), hookID, userID); err != nil {
+			writeHookError(w, err, "GetInboundHook", "Kon hook niet ophalen", log)
+			return
+		}
+		deliveries, err := storer.GetHookDeliveries(r.Context(), hookID, limit)
+		if err != nil {
+			log.Error("HANDLER ERROR [GetHookDeliveries]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon leveringen niet ophalen", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, deliveries, log)
+	}
+}
+
+// HandleReceiveHook ontvangt een levering van een extern systeem. Het token in de URL is de
+// authenticatie; een onbekend of ingetrokken token geeft 404. De levering wordt in de status queued
+// opgeslagen en klaargezet bij de worker, die de gebonden rules draait; de response is 202 met de
+// levering. Is de queue vol, dan wordt de levering meteen als failure afgesloten en krijgt de
+// afzender 503.
+func HandleReceiveHook(storer store.Storer, deliveries Enqueuer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hook, err := storer.GetInboundHookByTokenHash(r.Context(), webhook.HashToken(chi.URLParam(r, "token")))
+		if err != nil {
+			writeHookError(w, err, "GetInboundHookByTokenHash", "Kon hook niet ophalen", log)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if !errors.As(err, &tooLarge) {
+				common.WriteJSONError(w, http.StatusBadRequest, "Kon request body niet lezen", log)
+				return
+			}
+			logFailedDelivery(r.Context(), storer, hook.ID, fmt.Sprintf("body exceeds %d bytes", maxBodyBytes), log)
+			common.WriteJSONError(w, http.StatusRequestEntityTooLarge, "Request body is te groot", log)
+			return
+		}
+		if _, err = webhook.TemplateValues(body); err != nil {
+			logFailedDelivery(r.Context(), storer, hook.ID, err.Error(), log)
+			common.WriteJSONError(w, http.StatusBadRequest, "Body moet een JSON object zijn", log)
+			return
+		}
+
+		delivery, err := storer.CreateHookDelivery(r.Context(), store.CreateHookDeliveryParams{
+			HookID:  hook.ID,
+			Status:  domain.HookDeliveryQueued,
+			Payload: body,
+		})
+		if err != nil {
+			log.Error("HANDLER ERROR [CreateHookDelivery]", zap.Error(err), zap.String("hook_id", hook.ID.String()))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon levering niet verwerken", log)
+			return
+		}
+
+		if !deliveries.EnqueueHookDelivery(delivery) {
+			message := "hook queue is full"
+			if _, err = storer.FinishHookDelivery(r.Context(), store.FinishHookDeliveryParams{
+				DeliveryID:   delivery.ID,
+				Status:       domain.HookDeliveryFailure,
+				ErrorMessage: &message,
+			}); err != nil {
+				log.Error("HANDLER ERROR [FinishHookDelivery]", zap.Error(err))
+			}
+			common.WriteJSONError(w, http.StatusServiceUnavailable, "Worker is bezig, probeer het later opnieuw", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusAccepted, delivery, log)
+	}
+}
+
+// logFailedDelivery slaat een levering op die niet klaargezet werd, zodat de eigenaar hem terugziet.
+func logFailedDelivery(ctx context.Context, storer store.Storer, hookID uuid.UUID, message string, log *zap.Logger) {
+	if _, err := storer.CreateHookDelivery(ctx, store.CreateHookDeliveryParams{
+		HookID:       hookID,
+		Status:       domain.HookDeliveryFailure,
+		ErrorMessage: &message,
+	}); err != nil {
+		log.Error("HANDLER ERROR [CreateHookDelivery]", zap.Error(err))
+	}
+}
+
+// userAndHookID leest de gebruiker uit de context en het hook ID uit de URL.
+func userAndHookID(w http.ResponseWriter, r *http.Request, log *zap.Logger) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := common.GetUserIDFromContext(r.Context())
+	if err != nil {
+		common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
+		return uuid.Nil, uuid.Nil, false
+	}
+	hookID, err := uuid.Parse(chi.URLParam(r, "hookId"))
+	if err != nil {
+		common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig hook ID", log)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, hookID, true
+}
+
+// writeHookError schrijft 404 voor een onbekende hook en 500 voor andere fouten.
+func writeHookError(w http.ResponseWriter, err error, operation, message string, log *zap.Logger) {
+	if errors.Is(err, store.ErrInboundHookNotFound) {
+		common.WriteJSONError(w, http.StatusNotFound, "Hook niet gevonden", log)
+		return
+	}
+	log.Error("HANDLER ERROR ["+operation+"]", zap.Error(err))
+	common.WriteJSONError(w, http.StatusInternalServerError, message, log)
+}
@@ -0,0 +1,208 @@
+package hook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+	"agenda-automator-api/internal/webhook"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeEnqueuer houdt bij welke leveringen de handler klaarzet.
+type fakeEnqueuer struct {
+	deliveries []domain.HookDelivery
+	full       bool
+}
+
+func (f *fakeEnqueuer) EnqueueHookDelivery(delivery domain.HookDelivery) bool {
+	if f.full {
+		return false
+	}
+	f.deliveries = append(f.deliveries, delivery)
+	return true
+}
+
+func newUserRequest(method, body string, userID uuid.UUID, params map[string]string) *http.Request {
+	req := httptest.NewRequest(method, "/", strings.NewReader(body))
+	rctx := chi.NewRouteContext()
+	for key, value := range params {
+		rctx.URLParams.Add(key, value)
+	}
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	return req.WithContext(context.WithValue(ctx, common.UserContextKey, userID))
+}
+
+func newTokenRequest(token string, body []byte) *http.Request {
+	req := httptest.NewRequest("POST", "/api/v1/hooks/"+token, bytes.NewReader(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("token", token)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestHandleCreateHook(t *testing.T) {
+	userID := uuid.New()
+	mockStore := &store.MockStore{}
+	var created store.CreateInboundHookParams
+	mockStore.On("CreateInboundHook", mock.Anything, mock.AnythingOfType("webhook.CreateInboundHookParams")).
+		Run(func(args mock.Arguments) { created = args.Get(1).(store.CreateInboundHookParams) }).
+		Return(domain.InboundHook{ID: uuid.New(), UserID: userID, Name: "CI releases"}, nil).Once()
+
+	rr := httptest.NewRecorder()
+	HandleCreateHook(mockStore, zap.NewNop()).ServeHTTP(rr, newUserRequest("POST", `{"name": " CI releases "}`, userID, nil))
+
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var response domain.InboundHook
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.True(t, strings.HasPrefix(response.Token, "hook_"))
+	// Alleen de hash van het token wordt opgeslagen
+	assert.Equal(t, webhook.HashToken(response.Token), created.TokenHash)
+	assert.Equal(t, "CI releases", created.Name)
+	assert.Equal(t, userID, created.UserID)
+	mockStore.AssertExpectations(t)
+}
+
+func TestHandleCreateHook_Validation(t *testing.T) {
+	mockStore := &store.MockStore{}
+
+	rr := httptest.NewRecorder()
+	HandleCreateHook(mockStore, zap.NewNop()).ServeHTTP(rr, newUserRequest("POST", `{"name": " "}`, uuid.New(), nil))
+
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"name"`)
+	mockStore.AssertNotCalled(t, "CreateInboundHook", mock.Anything, mock.Anything)
+}
+
+func TestHandleRevokeHook_NotFound(t *testing.T) {
+	userID := uuid.New()
+	hookID := uuid.New()
+	mockStore := &store.MockStore{}
+	mockStore.On("RevokeInboundHook", mock.Anything, hookID, userID).
+		Return(domain.InboundHook{}, store.ErrInboundHookNotFound).Once()
+
+	rr := httptest.NewRecorder()
+	HandleRevokeHook(mockStore, zap.NewNop()).
+		ServeHTTP(rr, newUserRequest("DELETE", "", userID, map[string]string{"hookId": hookID.String()}))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	mockStore.AssertExpectations(t)
+}
+
+func TestHandleGetHookDeliveries(t *testing.T) {
+	userID := uuid.New()
+	hookID := uuid.New()
+	mockStore := &store.MockStore{}
+	mockStore.On("GetInboundHook", mock.Anything, hookID, userID).Return(domain.InboundHook{ID: hookID}, nil).Once()
+	mockStore.On("GetHookDeliveries", mock.Anything, hookID, 10).
+		Return([]domain.HookDelivery{{ID: 1, HookID: hookID, Status: domain.HookDeliverySuccess}}, nil).Once()
+
+	req := newUserRequest("GET", "", userID, map[string]string{"hookId": hookID.String()})
+	req.URL.RawQuery = "limit=10"
+	rr := httptest.NewRecorder()
+	HandleGetHookDeliveries(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var got []domain.HookDelivery
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.Len(t, got, 1)
+	mockStore.AssertExpectations(t)
+}
+
+func TestHandleReceiveHook(t *testing.T) {
+	hook := domain.InboundHook{ID: uuid.New(), UserID: uuid.New()}
+	body := []byte(`{"version": "2.4.0"}`)
+	failedDelivery := mock.MatchedBy(func(arg store.CreateHookDeliveryParams) bool {
+		return arg.HookID == hook.ID && arg.Status == domain.HookDeliveryFailure && arg.ErrorMessage != nil
+	})
+
+	t.Run("queued", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		mockStore.On("GetInboundHookByTokenHash", mock.Anything, webhook.HashToken("hook_abc")).Return(hook, nil).Once()
+		queued := domain.HookDelivery{ID: 3, HookID: hook.ID, Status: domain.HookDeliveryQueued, Payload: body}
+		mockStore.On("CreateHookDelivery", mock.Anything, store.CreateHookDeliveryParams{
+			HookID:  hook.ID,
+			Status:  domain.HookDeliveryQueued,
+			Payload: body,
+		}).Return(queued, nil).Once()
+		deliveries := &fakeEnqueuer{}
+
+		rr := httptest.NewRecorder()
+		HandleReceiveHook(mockStore, deliveries, zap.NewNop()).ServeHTTP(rr, newTokenRequest("hook_abc", body))
+
+		require.Equal(t, http.StatusAccepted, rr.Code)
+		assert.Equal(t, []domain.HookDelivery{queued}, deliveries.deliveries)
+		assert.Contains(t, rr.Body.String(), `"status":"queued"`)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("unknown or revoked token", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		mockStore.On("GetInboundHookByTokenHash", mock.Anything, mock.Anything).
+			Return(domain.InboundHook{}, store.ErrInboundHookNotFound).Once()
+		deliveries := &fakeEnqueuer{}
+
+		rr := httptest.NewRecorder()
+		HandleReceiveHook(mockStore, deliveries, zap.NewNop()).ServeHTTP(rr, newTokenRequest("hook_oud", body))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Empty(t, deliveries.deliveries)
+	})
+
+	t.Run("invalid body is logged", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		mockStore.On("GetInboundHookByTokenHash", mock.Anything, mock.Anything).Return(hook, nil).Once()
+		mockStore.On("CreateHookDelivery", mock.Anything, failedDelivery).Return(domain.HookDelivery{}, nil).Once()
+		deliveries := &fakeEnqueuer{}
+
+		rr := httptest.NewRecorder()
+		HandleReceiveHook(mockStore, deliveries, zap.NewNop()).ServeHTTP(rr, newTokenRequest("hook_abc", []byte(`nee`)))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Empty(t, deliveries.deliveries)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("body too large is logged", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		mockStore.On("GetInboundHookByTokenHash", mock.Anything, mock.Anything).Return(hook, nil).Once()
+		mockStore.On("CreateHookDelivery", mock.Anything, failedDelivery).Return(domain.HookDelivery{}, nil).Once()
+		deliveries := &fakeEnqueuer{}
+
+		rr := httptest.NewRecorder()
+		large := append([]byte(`{"data": "`), bytes.Repeat([]byte("x"), maxBodyBytes)...)
+		HandleReceiveHook(mockStore, deliveries, zap.NewNop()).ServeHTTP(rr, newTokenRequest("hook_abc", large))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+		assert.Empty(t, deliveries.deliveries)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("queue full", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		mockStore.On("GetInboundHookByTokenHash", mock.Anything, mock.Anything).Return(hook, nil).Once()
+		mockStore.On("CreateHookDelivery", mock.Anything, mock.AnythingOfType("webhook.CreateHookDeliveryParams")).
+			Return(domain.HookDelivery{ID: 4, HookID: hook.ID, Status: domain.HookDeliveryQueued}, nil).Once()
+		mockStore.On("FinishHookDelivery", mock.Anything, mock.MatchedBy(func(arg store.FinishHookDeliveryParams) bool {
+			return arg.DeliveryID == 4 && arg.Status == domain.HookDeliveryFailure && arg.ErrorMessage != nil
+		})).Return(domain.HookDelivery{}, nil).Once()
+
+		rr := httptest.NewRecorder()
+		HandleReceiveHook(mockStore, &fakeEnqueuer{full: true}, zap.NewNop()).ServeHTTP(rr, newTokenRequest("hook_abc", body))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		mockStore.AssertExpectations(t)
+	})
+}
@@ -49,13 +49,14 @@ func TestHandleGetAutomationLogs(t *testing.T) {
 	}
 
 	// Set up the mocks
-	mockStore.On("GetConnectedAccountByID", mock.Anything, accountID).Return(account, nil)
 	mockStore.On("GetLogsForAccount", mock.Anything, accountID, 50).Return(logs, nil)
 
 	req, err := http.NewRequest("GET", "/api/v1/accounts/"+accountID.String()+"/logs", http.NoBody)
 	assert.NoError(t, err)
 
+	// De ownership middleware zet het account in de context
 	ctx := context.WithValue(req.Context(), common.UserContextKey, userID)
+	ctx = common.WithAccount(ctx, account)
 	req = req.WithContext(ctx)
 
 	// Set up chi router context for URL params
@@ -86,97 +87,16 @@ func TestHandleGetAutomationLogs(t *testing.T) {
 	mockStore.AssertExpectations(t)
 }
 
-func TestHandleGetAutomationLogs_InvalidAccountID(t *testing.T) {
-	// AANGEPAST: Maak een test-logger
-	testLogger := zap.NewNop()
-
-	// Create a mock store
-	mockStore := &store.MockStore{}
-
-	userID := uuid.New()
-
-	req, err := http.NewRequest("GET", "/api/v1/accounts/invalid-id/logs", http.NoBody)
-	assert.NoError(t, err)
-
-	ctx := context.WithValue(req.Context(), common.UserContextKey, userID)
-	req = req.WithContext(ctx)
-
-	// Set up chi router context for URL params
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("accountId", "invalid-id")
-	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
-
-	// Create a ResponseRecorder
-	rr := httptest.NewRecorder()
-
-	// Call the handler
-	// AANGEPAST: testLogger meegegeven
-	handler := HandleGetAutomationLogs(mockStore, testLogger)
-	handler.ServeHTTP(rr, req)
-
-	// Check the status code
-	assert.Equal(t, http.StatusBadRequest, rr.Code)
-}
-
-func TestHandleGetAutomationLogs_AccountNotFound(t *testing.T) {
-	// AANGEPAST: Maak een test-logger
-	testLogger := zap.NewNop()
-
-	// Create a mock store
-	mockStore := &store.MockStore{}
-
-	userID := uuid.New()
-	accountID := uuid.New()
-
-	// Set up the mock to return an error (account not found)
-	mockStore.On("GetConnectedAccountByID", mock.Anything, accountID).Return(domain.ConnectedAccount{}, assert.AnError)
-
-	req, err := http.NewRequest("GET", "/api/v1/accounts/"+accountID.String()+"/logs", http.NoBody)
-	assert.NoError(t, err)
-
-	ctx := context.WithValue(req.Context(), common.UserContextKey, userID)
-	req = req.WithContext(ctx)
-
-	// Set up chi router context for URL params
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("accountId", accountID.String())
-	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
-
-	// Create a ResponseRecorder
-	rr := httptest.NewRecorder()
-
-	// Call the handler
-	// AANGEPAST: testLogger meegegeven
-	handler := HandleGetAutomationLogs(mockStore, testLogger)
-	handler.ServeHTTP(rr, req)
-
-	// Check the status code
-	assert.Equal(t, http.StatusNotFound, rr.Code)
-
-	// Verify that the mock was called
-	mockStore.AssertExpectations(t)
-}
-
-func TestHandleGetAutomationLogs_Unauthorized(t *testing.T) {
-	// AANGEPAST: Maak een test-logger
+func TestHandleGetAutomationLogs_WithoutAccount(t *testing.T) {
 	testLogger := zap.NewNop()
 
 	// Create a mock store
 	mockStore := &store.MockStore{}
 
 	userID := uuid.New()
-	otherUserID := uuid.New()
 	accountID := uuid.New()
 
-	// Account belongs to different user
-	account := domain.ConnectedAccount{
-		ID:     accountID,
-		UserID: otherUserID, // Different user
-	}
-
-	// Set up the mock
-	mockStore.On("GetConnectedAccountByID", mock.Anything, accountID).Return(account, nil)
-
+	// Geen account in de context: de ownership middleware heeft het verzoek niet doorgelaten
 	req, err := http.NewRequest("GET", "/api/v1/accounts/"+accountID.String()+"/logs", http.NoBody)
 	assert.NoError(t, err)
 
@@ -192,15 +112,12 @@ func TestHandleGetAutomationLogs_Unauthorized(t *testing.T) {
 	rr := httptest.NewRecorder()
 
 	// Call the handler
-	// AANGEPAST: testLogger meegegeven
 	handler := HandleGetAutomationLogs(mockStore, testLogger)
 	handler.ServeHTTP(rr, req)
 
 	// Check the status code
 	assert.Equal(t, http.StatusNotFound, rr.Code)
-
-	// Verify that the mock was called
-	mockStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "GetLogsForAccount", mock.Anything, mock.Anything, mock.Anything)
 }
 
 // Helper function to create string pointer
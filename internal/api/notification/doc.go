@@ -0,0 +1,2 @@
+// Package notification handles the notification API endpoints of the logged-in user.
+package notification
@@ -0,0 +1,73 @@
+package notification
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// HandleGetNotifications haalt de meldingen van de ingelogde gebruiker op, nieuwste eerst.
+// Met ?unread=true alleen ongelezen meldingen; ?limit is maximaal 100.
+func HandleGetNotifications(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := common.GetUserIDFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
+			return
+		}
+
+		limit := 50 // default
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			if parsed, perr := strconv.Atoi(limitStr); perr == nil && parsed > 0 && parsed <= 100 {
+				limit = parsed
+			}
+		}
+		unreadOnly := r.URL.Query().Get("unread") == "true"
+
+		notifications, err := storer.GetNotificationsForUser(r.Context(), userID, unreadOnly, limit)
+		if err != nil {
+			log.Error("HANDLER ERROR [GetNotificationsForUser]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon meldingen niet ophalen", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, notifications, log)
+	}
+}
+
+// HandleMarkNotificationRead markeert een melding van de ingelogde gebruiker als gelezen.
+func HandleMarkNotificationRead(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		notificationID, err := uuid.Parse(chi.URLParam(r, "notificationId"))
+		if err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig melding ID", log)
+			return
+		}
+
+		userID, err := common.GetUserIDFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
+			return
+		}
+
+		notification, err := storer.MarkNotificationRead(r.Context(), notificationID, userID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotificationNotFound) {
+				common.WriteJSONError(w, http.StatusNotFound, "Melding niet gevonden", log)
+				return
+			}
+			log.Error("HANDLER ERROR [MarkNotificationRead]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon melding niet bijwerken", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, notification, log)
+	}
+}
@@ -0,0 +1,125 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newUserRequest(method, target string, userID uuid.UUID, params map[string]string) *http.Request {
+	req := httptest.NewRequest(method, target, http.NoBody)
+	rctx := chi.NewRouteContext()
+	for key, value := range params {
+		rctx.URLParams.Add(key, value)
+	}
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, common.UserContextKey, userID)
+	return req.WithContext(ctx)
+}
+
+func TestHandleGetNotifications(t *testing.T) {
+	userID := uuid.New()
+	notifications := []domain.Notification{{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Type:      domain.NotificationRulePaused,
+		Message:   "Rule 'Alles beantwoorden' is gepauzeerd: meer dan 100 uitvoeringen binnen een uur.",
+		Details:   json.RawMessage(`{"rule_type":"gmail","rule_id":"abc","rule_name":"Alles beantwoorden","reason":"..."}`),
+		CreatedAt: time.Now(),
+	}}
+
+	t.Run("unread with limit", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		mockStore.On("GetNotificationsForUser", mock.Anything, userID, true, 10).Return(notifications, nil)
+
+		rr := httptest.NewRecorder()
+		HandleGetNotifications(mockStore, zap.NewNop()).
+			ServeHTTP(rr, newUserRequest("GET", "/?unread=true&limit=10", userID, nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var got []domain.Notification
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		require.Len(t, got, 1)
+		assert.Equal(t, domain.NotificationRulePaused, got[0].Type)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		mockStore.On("GetNotificationsForUser", mock.Anything, userID, false, 50).Return([]domain.Notification{}, nil)
+
+		rr := httptest.NewRecorder()
+		HandleGetNotifications(mockStore, zap.NewNop()).
+			ServeHTTP(rr, newUserRequest("GET", "/?limit=5000", userID, nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[]`, rr.Body.String())
+		mockStore.AssertExpectations(t)
+	})
+}
+
+func TestHandleMarkNotificationRead(t *testing.T) {
+	userID := uuid.New()
+	notificationID := uuid.New()
+
+	t.Run("success", func(t *testing.T) {
+		readAt := time.Now()
+		mockStore := &store.MockStore{}
+		mockStore.On("MarkNotificationRead", mock.Anything, notificationID, userID).
+			Return(domain.Notification{ID: notificationID, UserID: userID, ReadAt: &readAt}, nil)
+
+		rr := httptest.NewRecorder()
+		HandleMarkNotificationRead(mockStore, zap.NewNop()).ServeHTTP(rr,
+			newUserRequest("PUT", "/", userID, map[string]string{"notificationId": notificationID.String()}))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"read_at"`)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		mockStore.On("MarkNotificationRead", mock.Anything, notificationID, userID).
+			Return(domain.Notification{}, store.ErrNotificationNotFound)
+
+		rr := httptest.NewRecorder()
+		HandleMarkNotificationRead(mockStore, zap.NewNop()).ServeHTTP(rr,
+			newUserRequest("PUT", "/", userID, map[string]string{"notificationId": notificationID.String()}))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		mockStore.On("MarkNotificationRead", mock.Anything, notificationID, userID).
+			Return(domain.Notification{}, errors.New("db down"))
+
+		rr := httptest.NewRecorder()
+		HandleMarkNotificationRead(mockStore, zap.NewNop()).ServeHTTP(rr,
+			newUserRequest("PUT", "/", userID, map[string]string{"notificationId": notificationID.String()}))
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		HandleMarkNotificationRead(&store.MockStore{}, zap.NewNop()).ServeHTTP(rr,
+			newUserRequest("PUT", "/", userID, map[string]string{"notificationId": "geen-uuid"}))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -0,0 +1,148 @@
+package rule
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// HandleGetRuleHistory geeft alle versies van een calendar rule, nieuwste eerst.
+func HandleGetRuleHistory(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rule, _, ok := getOwnedRule(w, r, storer, log)
+		if !ok {
+			return
+		}
+
+		versions, err := storer.GetRuleVersions(r.Context(), domain.RuleTypeCalendar, rule.ID)
+		if err != nil {
+			log.Error("HANDLER ERROR [GetRuleVersions]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon rule geschiedenis niet ophalen", log)
+			return
+		}
+		if versions == nil {
+			versions = []domain.RuleVersion{}
+		}
+
+		common.WriteJSON(w, http.StatusOK, versions, log)
+	}
+}
+
+// HandleRestoreRuleVersion zet de naam, condities, parameters en selector van een calendar rule terug naar
+// een eerdere versie. Dat levert een nieuwe versie op; de actieve status blijft ongewijzigd.
+func HandleRestoreRuleVersion(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		version, err := strconv.Atoi(chi.URLParam(r, "version"))
+		if err != nil || version < 1 {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige versie", log)
+			return
+		}
+
+		rule, userID, ok := getOwnedRule(w, r, storer, log)
+		if !ok {
+			return
+		}
+
+		ruleVersion, err := storer.GetRuleVersion(r.Context(), domain.RuleTypeCalendar, rule.ID, version)
+		if err != nil {
+			common.WriteJSONError(w, http.StatusNotFound, "Versie niet gevonden", log)
+			return
+		}
+
+		// Een nieuwe waarde, zodat Unmarshal de json.RawMessage velden van rule niet overschrijft
+		var restored domain.AutomationRule
+		if err = json.Unmarshal(ruleVersion.Snapshot, &restored); err != nil {
+			log.Error("HANDLER ERROR [RestoreRuleVersion Unmarshal]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon versie niet lezen", log)
+			return
+		}
+		// Een oude versie kan ongeldig zijn onder de huidige validatie
+		fieldErrs, ok := validateRuleForUser(w, r, storer, userID, restored, log)
+		if !ok {
+			return
+		}
+		if len(fieldErrs) > 0 {
+			common.WriteValidationErrors(w, fieldErrs, log)
+			return
+		}
+
+		// Een user-level rule krijgt ook de selector van de versie terug; versies van voor de
+		// selector in de snapshot laten de huidige staan
+		var selector *domain.AccountSelector
+		if rule.IsUserRule() && restored.AccountSelector != nil {
+			fieldErrs, err := common.ValidateAccountSelector(r.Context(), storer, userID, restored.AccountSelector)
+			if err != nil {
+				log.Error("HANDLER ERROR [ValidateAccountSelector]", zap.Error(err))
+				common.WriteJSONError(w, http.StatusInternalServerError, "Kon accounts niet ophalen", log)
+				return
+			}
+			if len(fieldErrs) > 0 {
+				common.WriteValidationErrors(w, fieldErrs, log)
+				return
+			}
+			selector = restored.AccountSelector
+		}
+
+		updatedRule, err := storer.UpdateRule(r.Context(), store.UpdateRuleParams{
+			RuleID:            rule.ID,
+			Name:              restored.Name,
+			TriggerType:       restored.Trigger(),
+			TriggerConditions: restored.TriggerConditions,
+			ActionType:        restored.Action(),
+			ActionParams:      restored.ActionParams,
+			Schedule:          restored.Schedule,
+			AccountSelector:   selector,
+			ChangedBy:         userID,
+			RestoredFrom:      &version,
+		})
+		if err != nil {
+			log.Error("HANDLER ERROR [RestoreRuleVersion UpdateRule]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon rule niet terugzetten", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, updatedRule, log)
+	}
+}
+
+// getOwnedRule haalt de rule uit de URL op en controleert of die bij de gebruiker hoort,
+// met dezelfde statuscodes als update, delete en toggle.
+func getOwnedRule(
+	w http.ResponseWriter,
+	r *http.Request,
+	storer store.Storer,
+	log *zap.Logger,
+) (domain.AutomationRule, uuid.UUID, bool) {
+	ruleID, err := uuid.Parse(chi.URLParam(r, "ruleId"))
+	if err != nil {
+		common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig rule ID", log)
+		return domain.AutomationRule{}, uuid.Nil, false
+	}
+
+	userID, err := common.GetUserIDFromContext(r.Context())
+	if err != nil {
+		common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
+		return domain.AutomationRule{}, uuid.Nil, false
+	}
+
+	rule, err := storer.GetRuleByID(r.Context(), ruleID)
+	if err != nil {
+		common.WriteJSONError(w, http.StatusNotFound, "Rule niet gevonden", log)
+		return domain.AutomationRule{}, uuid.Nil, false
+	}
+
+	if !ownsRule(r.Context(), storer, rule, userID) {
+		common.WriteJSONError(w, http.StatusForbidden, "Geen toegang tot deze rule", log)
+		return domain.AutomationRule{}, uuid.Nil, false
+	}
+
+	return rule, userID, true
+}
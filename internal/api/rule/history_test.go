@@ -0,0 +1,208 @@
+package rule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newHistoryRequest(userID uuid.UUID, ruleID uuid.UUID, version string) *http.Request {
+	req := httptest.NewRequest("POST", "/", http.NoBody)
+	ctx := context.WithValue(req.Context(), common.UserContextKey, userID)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("ruleId", ruleID.String())
+	if version != "" {
+		rctx.URLParams.Add("version", version)
+	}
+	return req.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx))
+}
+
+// ownedRule zet de mocks klaar voor een rule van userID.
+func ownedRule(mockStore *store.MockStore, userID, ruleID uuid.UUID) domain.AutomationRule {
+	accountID := uuid.New()
+	rule := domain.AutomationRule{BaseAutomationRule: domain.BaseAutomationRule{
+		AccountEntity: domain.AccountEntity{
+			BaseEntity:         domain.BaseEntity{ID: ruleID},
+			ConnectedAccountID: accountID,
+		},
+		Name:              "Dienst reminder",
+		IsActive:          false,
+		TriggerConditions: json.RawMessage(`{"summary_equals": "Dienst"}`),
+		ActionParams:      json.RawMessage(`{"new_event_title": "Vertrekken"}`),
+		Version:           3,
+	}}
+	mockStore.On("GetRuleByID", mock.Anything, ruleID).Return(rule, nil)
+	mockStore.On("GetConnectedAccountByID", mock.Anything, accountID).
+		Return(domain.ConnectedAccount{ID: accountID, UserID: userID}, nil)
+	return rule
+}
+
+func TestHandleGetRuleHistory(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		userID, ruleID := uuid.New(), uuid.New()
+		ownedRule(mockStore, userID, ruleID)
+		versions := []domain.RuleVersion{
+			{
+				RuleType: domain.RuleTypeCalendar, RuleID: ruleID, Version: 2, ChangeType: domain.RuleChangeToggle,
+				Snapshot: json.RawMessage(`{"is_active":false}`), Diff: json.RawMessage(`{"is_active":{"new":false,"old":true}}`),
+			},
+			{
+				RuleType: domain.RuleTypeCalendar, RuleID: ruleID, Version: 1, ChangeType: domain.RuleChangeCreate,
+				Snapshot: json.RawMessage(`{"is_active":true}`),
+			},
+		}
+		mockStore.On("GetRuleVersions", mock.Anything, domain.RuleTypeCalendar, ruleID).Return(versions, nil)
+
+		rr := httptest.NewRecorder()
+		HandleGetRuleHistory(mockStore, zap.NewNop()).ServeHTTP(rr, newHistoryRequest(userID, ruleID, ""))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var got []domain.RuleVersion
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		assert.Equal(t, versions, got)
+	})
+
+	t.Run("other user", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		ruleID := uuid.New()
+		ownedRule(mockStore, uuid.New(), ruleID)
+
+		rr := httptest.NewRecorder()
+		HandleGetRuleHistory(mockStore, zap.NewNop()).ServeHTTP(rr, newHistoryRequest(uuid.New(), ruleID, ""))
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		mockStore.AssertNotCalled(t, "GetRuleVersions", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("empty history", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		userID, ruleID := uuid.New(), uuid.New()
+		ownedRule(mockStore, userID, ruleID)
+		mockStore.On("GetRuleVersions", mock.Anything, domain.RuleTypeCalendar, ruleID).
+			Return([]domain.RuleVersion(nil), nil)
+
+		rr := httptest.NewRecorder()
+		HandleGetRuleHistory(mockStore, zap.NewNop()).ServeHTTP(rr, newHistoryRequest(userID, ruleID, ""))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[]`, rr.Body.String())
+	})
+}
+
+func TestHandleRestoreRuleVersion(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		userID, ruleID := uuid.New(), uuid.New()
+		rule := ownedRule(mockStore, userID, ruleID)
+		mockStore.On("GetRuleVersion", mock.Anything, domain.RuleTypeCalendar, ruleID, 1).
+			Return(domain.RuleVersion{Version: 1, Snapshot: json.RawMessage(`{
+				"name": "Oude naam",
+				"is_active": true,
+				"trigger_conditions": {"summary_contains": ["Dienst"]},
+				"action_params": {"new_event_title": "Eerder vertrekken", "offset_minutes": -30}
+			}`)}, nil)
+
+		restored := rule
+		restored.Name = "Oude naam"
+		restored.Version = 4
+		mockStore.On("UpdateRule", mock.Anything, mock.MatchedBy(func(params store.UpdateRuleParams) bool {
+			return params.RuleID == ruleID && params.Name == "Oude naam" &&
+				string(params.TriggerConditions) == `{"summary_contains": ["Dienst"]}` &&
+				params.ChangedBy == userID && params.RestoredFrom != nil && *params.RestoredFrom == 1
+		})).Return(restored, nil)
+
+		rr := httptest.NewRecorder()
+		HandleRestoreRuleVersion(mockStore, zap.NewNop()).ServeHTTP(rr, newHistoryRequest(userID, ruleID, "1"))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var got domain.AutomationRule
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		assert.Equal(t, 4, got.Version)
+		assert.False(t, got.IsActive)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("user rule gets its selector back", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		userID, accountID := uuid.New(), uuid.New()
+		rule := userRule(userID, domain.AccountSelector{Mode: domain.AccountSelectorAll})
+		mockStore.On("GetRuleByID", mock.Anything, rule.ID).Return(rule, nil)
+		mockStore.On("GetRuleVersion", mock.Anything, domain.RuleTypeCalendar, rule.ID, 2).
+			Return(domain.RuleVersion{Version: 2, Snapshot: json.RawMessage(`{
+				"name": "Standup reminder",
+				"trigger_conditions": {"summary_equals": "Standup"},
+				"action_params": {"new_event_title": "Standup", "offset_minutes": -5},
+				"account_selector": {"mode": "accounts", "account_ids": ["` + accountID.String() + `"]}
+			}`)}, nil)
+		mockStore.On("GetAccountsForUser", mock.Anything, userID).
+			Return([]domain.ConnectedAccount{{ID: accountID, UserID: userID}}, nil)
+		mockStore.On("UpdateRule", mock.Anything, mock.MatchedBy(func(params store.UpdateRuleParams) bool {
+			return params.AccountSelector != nil && params.AccountSelector.Mode == domain.AccountSelectorAccounts &&
+				len(params.AccountSelector.AccountIDs) == 1 && params.AccountSelector.AccountIDs[0] == accountID
+		})).Return(rule, nil)
+
+		rr := httptest.NewRecorder()
+		HandleRestoreRuleVersion(mockStore, zap.NewNop()).ServeHTTP(rr, newHistoryRequest(userID, rule.ID, "2"))
+
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("invalid version", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+
+		rr := httptest.NewRecorder()
+		HandleRestoreRuleVersion(mockStore, zap.NewNop()).ServeHTTP(rr, newHistoryRequest(uuid.New(), uuid.New(), "0"))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockStore.AssertNotCalled(t, "GetRuleByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		userID, ruleID := uuid.New(), uuid.New()
+		ownedRule(mockStore, userID, ruleID)
+		mockStore.On("GetRuleVersion", mock.Anything, domain.RuleTypeCalendar, ruleID, 9).
+			Return(domain.RuleVersion{}, errors.New("rule version not found"))
+
+		rr := httptest.NewRecorder()
+		HandleRestoreRuleVersion(mockStore, zap.NewNop()).ServeHTTP(rr, newHistoryRequest(userID, ruleID, "9"))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockStore.AssertNotCalled(t, "UpdateRule", mock.Anything, mock.Anything)
+	})
+
+	t.Run("snapshot no longer valid", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		userID, ruleID := uuid.New(), uuid.New()
+		ownedRule(mockStore, userID, ruleID)
+		mockStore.On("GetRuleVersion", mock.Anything, domain.RuleTypeCalendar, ruleID, 1).
+			Return(domain.RuleVersion{Version: 1, Snapshot: json.RawMessage(`{
+				"name": "Oud",
+				"trigger_conditions": {},
+				"action_params": {"new_event_title": "Vertrekken"}
+			}`)}, nil)
+
+		rr := httptest.NewRecorder()
+		HandleRestoreRuleVersion(mockStore, zap.NewNop()).ServeHTTP(rr, newHistoryRequest(userID, ruleID, "1"))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Contains(t, rr.Body.String(), "trigger_conditions")
+		mockStore.AssertNotCalled(t, "UpdateRule", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,49 @@
+package rule
+
+import (
+	"net/http"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/store"
+	calendarworker "agenda-automator-api/internal/worker/calendar"
+
+	"go.uber.org/zap"
+)
+
+// HandleRevertRule draait de geslaagde uitvoeringen van een calendar rule in een periode terug
+// door de gemaakte reminders te verwijderen. Het antwoord meldt wat niet teruggedraaid kon worden.
+func HandleRevertRule(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rule, _, ok := getOwnedRule(w, r, storer, log)
+		if !ok {
+			return
+		}
+
+		timeMin, timeMax, ok := common.DecodeRevertRequest(w, r, log)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+		logs, err := storer.GetRevertibleLogs(ctx, rule.ID, timeMin, timeMax)
+		if err != nil {
+			log.Error("HANDLER ERROR [RevertRule GetRevertibleLogs]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon automation logs niet ophalen", log)
+			return
+		}
+
+		report := rules.NewRevertReport()
+		accountIDs, logsByAccount := common.GroupLogsByAccount(logs)
+		for _, accountID := range accountIDs {
+			client, err := common.GetCalendarClient(ctx, storer, accountID, log)
+			if err != nil {
+				common.WriteJSONError(w, http.StatusInternalServerError, "Kon Calendar client niet initialiseren", log)
+				return
+			}
+			report.Merge(calendarworker.Revert(ctx, client, logsByAccount[accountID]))
+		}
+
+		common.WriteRevertReport(w, r, storer, timeMin, timeMax, report, log)
+	}
+}
@@ -0,0 +1,94 @@
+package rule
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newRevertRequest(userID, ruleID uuid.UUID, body string) *http.Request {
+	req := httptest.NewRequest("POST", "/", bytes.NewBufferString(body))
+	ctx := context.WithValue(req.Context(), common.UserContextKey, userID)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("ruleId", ruleID.String())
+	return req.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx))
+}
+
+func TestHandleRevertRule(t *testing.T) {
+	timeMin := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	body := `{"time_min":"2026-03-01T08:00:00Z"}`
+
+	t.Run("nothing to revert", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		userID, ruleID := uuid.New(), uuid.New()
+		ownedRule(mockStore, userID, ruleID)
+		// Zonder time_max loopt de periode tot nu
+		mockStore.On("GetRevertibleLogs", mock.Anything, ruleID, timeMin, mock.MatchedBy(func(to time.Time) bool {
+			return time.Since(to) < time.Minute
+		})).Return([]domain.AutomationLog{}, nil)
+
+		rr := httptest.NewRecorder()
+		HandleRevertRule(mockStore, zap.NewNop()).ServeHTTP(rr, newRevertRequest(userID, ruleID, body))
+
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var resp common.RevertResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, timeMin, resp.TimeMin)
+		assert.NotNil(t, resp.Reverted)
+		mockStore.AssertNotCalled(t, "GetValidTokenForAccount", mock.Anything, mock.Anything)
+	})
+
+	t.Run("token error", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		userID, ruleID := uuid.New(), uuid.New()
+		rule := ownedRule(mockStore, userID, ruleID)
+		mockStore.On("GetRevertibleLogs", mock.Anything, ruleID, timeMin, mock.Anything).
+			Return([]domain.AutomationLog{{ID: 1, ConnectedAccountID: rule.ConnectedAccountID, Status: domain.LogSuccess}}, nil)
+		mockStore.On("GetValidTokenForAccount", mock.Anything, rule.ConnectedAccountID).Return(nil, errors.New("token revoked"))
+
+		rr := httptest.NewRecorder()
+		HandleRevertRule(mockStore, zap.NewNop()).ServeHTTP(rr, newRevertRequest(userID, ruleID, body))
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		mockStore.AssertNotCalled(t, "MarkLogsReverted", mock.Anything, mock.Anything)
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		userID, ruleID := uuid.New(), uuid.New()
+		ownedRule(mockStore, userID, ruleID)
+
+		rr := httptest.NewRecorder()
+		HandleRevertRule(mockStore, zap.NewNop()).ServeHTTP(rr,
+			newRevertRequest(userID, ruleID, `{"time_min":"2026-03-01T08:00:00Z","time_max":"2026-03-01T07:00:00Z"}`))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("other user", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		ruleID := uuid.New()
+		ownedRule(mockStore, uuid.New(), ruleID)
+
+		rr := httptest.NewRecorder()
+		HandleRevertRule(mockStore, zap.NewNop()).ServeHTTP(rr, newRevertRequest(uuid.New(), ruleID, body))
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+}
@@ -1,12 +1,18 @@
 package rule
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
 
 	"agenda-automator-api/internal/api/common"
 	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
 	"agenda-automator-api/internal/store"
+	calendarworker "agenda-automator-api/internal/worker/calendar"
+	hookworker "agenda-automator-api/internal/worker/hook"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -17,22 +23,16 @@ import (
 // AANGEPAST: Accepteert nu log *zap.Logger
 func HandleCreateRule(storer store.Storer, log *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		accountIDStr := chi.URLParam(r, "accountId")
-		accountID, err := uuid.Parse(accountIDStr)
+		account, err := common.GetAccountFromContext(r.Context())
 		if err != nil {
-			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig account ID", log) // <-- AANGEPAST
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
 			return
 		}
+		accountID := account.ID
 
 		userID, err := common.GetUserIDFromContext(r.Context())
 		if err != nil {
-			common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log) // <-- AANGEPAST
-			return
-		}
-
-		account, err := storer.GetConnectedAccountByID(r.Context(), accountID)
-		if err != nil || account.UserID != userID {
-			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log) // <-- AANGEPAST
+			common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
 			return
 		}
 
@@ -41,12 +41,24 @@ func HandleCreateRule(storer store.Storer, log *zap.Logger) http.HandlerFunc {
 			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log) // <-- AANGEPAST
 			return
 		}
+		fieldErrs, ok := validateRuleForUser(w, r, storer, userID, req, log)
+		if !ok {
+			return
+		}
+		if len(fieldErrs) > 0 {
+			common.WriteValidationErrors(w, fieldErrs, log)
+			return
+		}
 
 		params := store.CreateAutomationRuleParams{
 			ConnectedAccountID: accountID,
 			Name:               req.Name,
+			TriggerType:        req.Trigger(),
 			TriggerConditions:  req.TriggerConditions,
+			ActionType:         req.Action(),
 			ActionParams:       req.ActionParams,
+			Schedule:           req.Schedule,
+			CreatedBy:          userID,
 		}
 
 		rule, err := storer.CreateAutomationRule(r.Context(), params)
@@ -63,24 +75,12 @@ func HandleCreateRule(storer store.Storer, log *zap.Logger) http.HandlerFunc {
 // AANGEPAST: Accepteert nu log *zap.Logger
 func HandleGetRules(storer store.Storer, log *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		accountIDStr := chi.URLParam(r, "accountId")
-		accountID, err := uuid.Parse(accountIDStr)
-		if err != nil {
-			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig account ID", log) // <-- AANGEPAST
-			return
-		}
-
-		userID, err := common.GetUserIDFromContext(r.Context())
+		account, err := common.GetAccountFromContext(r.Context())
 		if err != nil {
-			common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log) // <-- AANGEPAST
-			return
-		}
-
-		account, err := storer.GetConnectedAccountByID(r.Context(), accountID)
-		if err != nil || account.UserID != userID {
-			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log) // <-- AANGEPAST
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
 			return
 		}
+		accountID := account.ID
 
 		rules, err := storer.GetRulesForAccount(r.Context(), accountID)
 		if err != nil {
@@ -115,23 +115,35 @@ func HandleUpdateRule(storer store.Storer, log *zap.Logger) http.HandlerFunc {
 			return
 		}
 
-		account, err := storer.GetConnectedAccountByID(r.Context(), rule.ConnectedAccountID)
-		if err != nil || account.UserID != userID {
+		if !ownsRule(r.Context(), storer, rule, userID) {
 			common.WriteJSONError(w, http.StatusForbidden, "Geen toegang tot deze rule", log) // <-- AANGEPAST
 			return
 		}
 
-		var req domain.AutomationRule
+		// Velden die niet in de body staan behouden hun huidige waarde
+		req := rule
 		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
 			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log) // <-- AANGEPAST
 			return
 		}
+		fieldErrs, ok := validateRuleForUser(w, r, storer, userID, req, log)
+		if !ok {
+			return
+		}
+		if len(fieldErrs) > 0 {
+			common.WriteValidationErrors(w, fieldErrs, log)
+			return
+		}
 
 		params := store.UpdateRuleParams{
 			RuleID:            ruleID,
 			Name:              req.Name,
+			TriggerType:       req.Trigger(),
 			TriggerConditions: req.TriggerConditions,
+			ActionType:        req.Action(),
 			ActionParams:      req.ActionParams,
+			Schedule:          req.Schedule,
+			ChangedBy:         userID,
 		}
 
 		updatedRule, err := storer.UpdateRule(r.Context(), params)
@@ -167,8 +179,7 @@ func HandleDeleteRule(storer store.Storer, log *zap.Logger) http.HandlerFunc {
 			return
 		}
 
-		account, err := storer.GetConnectedAccountByID(r.Context(), rule.ConnectedAccountID)
-		if err != nil || account.UserID != userID {
+		if !ownsRule(r.Context(), storer, rule, userID) {
 			common.WriteJSONError(w, http.StatusForbidden, "Geen toegang tot deze rule", log) // <-- AANGEPAST
 			return
 		}
@@ -206,13 +217,12 @@ func HandleToggleRule(storer store.Storer, log *zap.Logger) http.HandlerFunc {
 			return
 		}
 
-		account, err := storer.GetConnectedAccountByID(r.Context(), rule.ConnectedAccountID)
-		if err != nil || account.UserID != userID {
+		if !ownsRule(r.Context(), storer, rule, userID) {
 			common.WriteJSONError(w, http.StatusForbidden, "Geen toegang tot deze rule", log) // <-- AANGEPAST
 			return
 		}
 
-		updatedRule, err := storer.ToggleRuleStatus(r.Context(), ruleID)
+		updatedRule, err := storer.ToggleRuleStatus(r.Context(), ruleID, userID)
 		if err != nil {
 			common.WriteJSONError(w, http.StatusInternalServerError, "Kon rule status niet togglen", log) // <-- AANGEPAST
 			return
@@ -221,3 +231,80 @@ func HandleToggleRule(storer store.Storer, log *zap.Logger) http.HandlerFunc {
 		common.WriteJSON(w, http.StatusOK, updatedRule, log) // <-- AANGEPAST
 	}
 }
+
+// ownsRule geeft aan of de rule van de gebruiker is: direct bij een user-level rule, anders via
+// het account van de rule.
+func ownsRule(ctx context.Context, storer store.Storer, rule domain.AutomationRule, userID uuid.UUID) bool {
+	if rule.IsUserRule() {
+		return *rule.UserID == userID
+	}
+	account, err := storer.GetConnectedAccountByID(ctx, rule.ConnectedAccountID)
+	return err == nil && account.UserID == userID
+}
+
+// ValidateRule geeft alle ongeldige velden van een calendar rule terug. Condities en parameters
+// worden gecontroleerd tegen de registry die de worker voor de trigger van de rule gebruikt. Of een
+// gebonden hook van de gebruiker is controleert ValidateHook.
+func ValidateRule(rule domain.AutomationRule) rules.FieldErrors {
+	var errs rules.FieldErrors
+	if strings.TrimSpace(rule.Name) == "" {
+		errs.Add("name", "is required")
+	}
+	errs = append(errs, rules.ValidateSchedule(rule.Schedule).Prefixed("schedule")...)
+
+	validate := calendarworker.Rules.ValidateRule
+	if rule.Trigger() == domain.CalendarTriggerHookReceived {
+		validate = hookworker.Rules.ValidateRule
+	}
+	return append(errs, validate(rules.Rule{
+		TriggerType:   rule.Trigger(),
+		TriggerParams: rule.TriggerConditions,
+		ActionType:    rule.Action(),
+		ActionParams:  rule.ActionParams,
+	})...)
+}
+
+// validateRuleForUser valideert een rule van userID, inclusief de hook waaraan hij gebonden is.
+// Bij een store fout is de response al geschreven en is ok false.
+func validateRuleForUser(
+	w http.ResponseWriter,
+	r *http.Request,
+	storer store.Storer,
+	userID uuid.UUID,
+	rule domain.AutomationRule,
+	log *zap.Logger,
+) (rules.FieldErrors, bool) {
+	fieldErrs := ValidateRule(rule)
+	hookErrs, err := ValidateHook(r.Context(), storer, userID, rule)
+	if err != nil {
+		log.Error("HANDLER ERROR [ValidateHook]", zap.Error(err))
+		common.WriteJSONError(w, http.StatusInternalServerError, "Kon hook niet ophalen", log)
+		return nil, false
+	}
+	return append(fieldErrs, hookErrs...), true
+}
+
+// ValidateHook controleert dat de hook van een hook_received rule van de gebruiker is. Een rule
+// met een andere trigger, of met condities die ValidateRule al afkeurt, geeft geen fouten.
+func ValidateHook(
+	ctx context.Context,
+	storer store.Storer,
+	userID uuid.UUID,
+	rule domain.AutomationRule,
+) (rules.FieldErrors, error) {
+	var errs rules.FieldErrors
+	if rule.Trigger() != domain.CalendarTriggerHookReceived {
+		return errs, nil
+	}
+	var conditions domain.HookTriggerConditions
+	if err := json.Unmarshal(rule.TriggerConditions, &conditions); err != nil || conditions.HookID == uuid.Nil {
+		return errs, nil
+	}
+
+	_, err := storer.GetInboundHook(ctx, conditions.HookID, userID)
+	if errors.Is(err, store.ErrInboundHookNotFound) {
+		errs.Add("trigger_conditions.hook_id", "unknown hook %s", conditions.HookID)
+		return errs, nil
+	}
+	return errs, err
+}
@@ -0,0 +1,38 @@
+package rule
+
+import (
+	"net/http"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/api/run"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"go.uber.org/zap"
+)
+
+// HandleRunRule start direct een verwerking van één rule. Een rule van een account draait op dat
+// account, een user-level rule op elk actief account dat zijn selector kiest. Een uitgezette rule
+// geeft 409: de worker zou hem overslaan. Een hook rule ook, die draait alleen bij een levering.
+func HandleRunRule(storer store.Storer, runs run.Enqueuer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rule, userID, ok := getOwnedRule(w, r, storer, log)
+		if !ok {
+			return
+		}
+		if !rule.IsActive {
+			common.WriteJSONError(w, http.StatusConflict, "Rule staat uit", log)
+			return
+		}
+		if rule.Trigger() == domain.CalendarTriggerHookReceived {
+			common.WriteJSONError(w, http.StatusConflict, "Een hook rule draait alleen bij een levering aan zijn hook", log)
+			return
+		}
+
+		arg := store.CreateProcessingRunParams{UserID: userID, RuleID: &rule.ID}
+		if !rule.IsUserRule() {
+			arg.ConnectedAccountID = &rule.ConnectedAccountID
+		}
+		run.Start(w, r, storer, runs, arg, log)
+	}
+}
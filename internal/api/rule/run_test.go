@@ -0,0 +1,101 @@
+package rule
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeEnqueuer houdt bij welke runs de handler klaarzet.
+type fakeEnqueuer struct {
+	runs []domain.ProcessingRun
+}
+
+func (f *fakeEnqueuer) EnqueueRun(run domain.ProcessingRun) bool {
+	f.runs = append(f.runs, run)
+	return true
+}
+
+func TestHandleRunRule(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("account rule", func(t *testing.T) {
+		mockStore := new(store.MockStore)
+		rule := userRule(userID, domain.AccountSelector{})
+		rule.UserID, rule.AccountSelector = nil, nil
+		rule.ConnectedAccountID = uuid.New()
+		mockStore.On("GetRuleByID", mock.Anything, rule.ID).Return(rule, nil)
+		mockStore.On("GetConnectedAccountByID", mock.Anything, rule.ConnectedAccountID).
+			Return(domain.ConnectedAccount{ID: rule.ConnectedAccountID, UserID: userID}, nil)
+		queued := domain.ProcessingRun{ID: uuid.New(), RuleID: &rule.ID, Status: domain.RunQueued}
+
+		// De run hoort bij het account van de rule
+		mockStore.On("CreateProcessingRun", mock.Anything, store.CreateProcessingRunParams{
+			UserID:             userID,
+			ConnectedAccountID: &rule.ConnectedAccountID,
+			RuleID:             &rule.ID,
+		}).Return(queued, nil)
+		runs := &fakeEnqueuer{}
+
+		rr := httptest.NewRecorder()
+		HandleRunRule(mockStore, runs, zap.NewNop()).
+			ServeHTTP(rr, newUserRuleRequest("POST", userID, rule.ID.String(), ""))
+
+		assert.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		require.Len(t, runs.runs, 1)
+		assert.Equal(t, queued.ID, runs.runs[0].ID)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("user rule", func(t *testing.T) {
+		mockStore := new(store.MockStore)
+		rule := userRule(userID, domain.AccountSelector{Mode: domain.AccountSelectorAll})
+		mockStore.On("GetRuleByID", mock.Anything, rule.ID).Return(rule, nil)
+		// Zonder account: de worker kiest de accounts met de selector
+		mockStore.On("CreateProcessingRun", mock.Anything, store.CreateProcessingRunParams{
+			UserID: userID,
+			RuleID: &rule.ID,
+		}).Return(domain.ProcessingRun{ID: uuid.New(), RuleID: &rule.ID}, nil)
+
+		rr := httptest.NewRecorder()
+		HandleRunRule(mockStore, &fakeEnqueuer{}, zap.NewNop()).
+			ServeHTTP(rr, newUserRuleRequest("POST", userID, rule.ID.String(), ""))
+
+		assert.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("inactive rule", func(t *testing.T) {
+		mockStore := new(store.MockStore)
+		rule := ownedRule(mockStore, userID, uuid.New()) // staat uit
+
+		rr := httptest.NewRecorder()
+		HandleRunRule(mockStore, &fakeEnqueuer{}, zap.NewNop()).
+			ServeHTTP(rr, newUserRuleRequest("POST", userID, rule.ID.String(), ""))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		mockStore.AssertNotCalled(t, "CreateProcessingRun", mock.Anything, mock.Anything)
+	})
+	t.Run("hook rule", func(t *testing.T) {
+		mockStore := new(store.MockStore)
+		rule := userRule(userID, domain.AccountSelector{Mode: domain.AccountSelectorAll})
+		rule.TriggerType = domain.CalendarTriggerHookReceived
+		mockStore.On("GetRuleByID", mock.Anything, rule.ID).Return(rule, nil)
+
+		rr := httptest.NewRecorder()
+		HandleRunRule(mockStore, &fakeEnqueuer{}, zap.NewNop()).
+			ServeHTTP(rr, newUserRuleRequest("POST", userID, rule.ID.String(), ""))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		mockStore.AssertNotCalled(t, "CreateProcessingRun", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,192 @@
+package rule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+	calendarworker "agenda-automator-api/internal/worker/calendar"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/api/calendar/v3"
+)
+
+const (
+	// defaultSimulationWindow is het tijdvenster vanaf nu als de request er geen opgeeft
+	defaultSimulationWindow = 30 * 24 * time.Hour
+	// maxSimulationWindow begrenst het aantal events dat bij Google opgehaald wordt
+	maxSimulationWindow = 366 * 24 * time.Hour
+)
+
+// simulateRequest is de body van de simulate endpoints. Rule is alleen nodig voor het
+// ad-hoc endpoint; time_min en time_max zijn optioneel.
+type simulateRequest struct {
+	TimeMin *time.Time             `json:"time_min,omitempty"`
+	TimeMax *time.Time             `json:"time_max,omitempty"`
+	Rule    *domain.AutomationRule `json:"rule,omitempty"`
+}
+
+// SimulationResponse is het resultaat van een dry-run van een calendar rule.
+type SimulationResponse struct {
+	TimeMin       time.Time                        `json:"time_min"`
+	TimeMax       time.Time                        `json:"time_max"`
+	EventsChecked int                              `json:"events_checked"`
+	Matches       []calendarworker.SimulationMatch `json:"matches"`
+}
+
+// HandleSimulateRule laat zien wat een opgeslagen calendar rule in een tijdvenster zou doen,
+// zonder reminders te maken of logs te schrijven. De rule hoeft niet actief te zijn.
+func HandleSimulateRule(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, err := common.GetAccountFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
+			return
+		}
+
+		ruleID, err := uuid.Parse(chi.URLParam(r, "ruleId"))
+		if err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig rule ID", log)
+			return
+		}
+
+		req, ok := decodeSimulateRequest(w, r, log)
+		if !ok {
+			return
+		}
+
+		rule, err := storer.GetRuleByID(r.Context(), ruleID)
+		if err != nil || !rule.AppliesTo(&account) {
+			common.WriteJSONError(w, http.StatusNotFound, "Rule niet gevonden", log)
+			return
+		}
+		// Een user-level rule wordt gesimuleerd zoals de worker hem voor dit account draait
+		rule.ConnectedAccountID = account.ID
+
+		simulateRule(w, r, storer, account, rule, req, log)
+	}
+}
+
+// HandleSimulateAdHocRule doet hetzelfde als HandleSimulateRule voor een rule die nog niet
+// opgeslagen is. De rule in de body wordt gevalideerd zoals bij aanmaken.
+func HandleSimulateAdHocRule(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, err := common.GetAccountFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
+			return
+		}
+
+		req, ok := decodeSimulateRequest(w, r, log)
+		if !ok {
+			return
+		}
+		if req.Rule == nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "rule is verplicht", log)
+			return
+		}
+		if fieldErrs := ValidateRule(*req.Rule); len(fieldErrs) > 0 {
+			common.WriteValidationErrors(w, fieldErrs.Prefixed("rule"), log)
+			return
+		}
+
+		rule := *req.Rule
+		rule.ConnectedAccountID = account.ID
+		simulateRule(w, r, storer, account, rule, req, log)
+	}
+}
+
+// decodeSimulateRequest leest de body en vult het tijdvenster aan. Een lege body is toegestaan.
+func decodeSimulateRequest(w http.ResponseWriter, r *http.Request, log *zap.Logger) (simulateRequest, bool) {
+	var req simulateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
+		return req, false
+	}
+
+	if req.TimeMin == nil {
+		now := time.Now()
+		req.TimeMin = &now
+	}
+	if req.TimeMax == nil {
+		timeMax := req.TimeMin.Add(defaultSimulationWindow)
+		req.TimeMax = &timeMax
+	}
+	if !req.TimeMax.After(*req.TimeMin) {
+		common.WriteJSONError(w, http.StatusBadRequest, "time_max moet na time_min liggen", log)
+		return req, false
+	}
+	if req.TimeMax.Sub(*req.TimeMin) > maxSimulationWindow {
+		common.WriteJSONError(w, http.StatusBadRequest, "Tijdvenster mag maximaal 366 dagen zijn", log)
+		return req, false
+	}
+	return req, true
+}
+
+func simulateRule(
+	w http.ResponseWriter,
+	r *http.Request,
+	storer store.Storer,
+	account domain.ConnectedAccount,
+	rule domain.AutomationRule,
+	req simulateRequest,
+	log *zap.Logger,
+) {
+	// Een hook rule draait op leveringen, niet op calendar events
+	if rule.Trigger() == domain.CalendarTriggerHookReceived {
+		common.WriteJSONError(w, http.StatusConflict, "Een hook rule kan niet gesimuleerd worden", log)
+		return
+	}
+
+	ctx := r.Context()
+	client, err := common.GetCalendarClient(ctx, storer, account.ID, log)
+	if err != nil {
+		common.WriteJSONError(w, http.StatusInternalServerError, "Kon Calendar client niet initialiseren", log)
+		return
+	}
+
+	events, err := listEvents(ctx, client, *req.TimeMin, *req.TimeMax)
+	if err != nil {
+		log.Error("HANDLER ERROR [SimulateRule listEvents]", zap.Error(err))
+		common.WriteJSONError(w, http.StatusInternalServerError, "Kon calendar events niet ophalen", log)
+		return
+	}
+
+	matches, err := calendarworker.Simulate(ctx, &account, rule, events)
+	if err != nil {
+		// Opgeslagen rules van vóór de validatie kunnen ongeldig zijn
+		common.WriteJSONError(w, http.StatusUnprocessableEntity, "Rule is ongeldig: "+err.Error(), log)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusOK, SimulationResponse{
+		TimeMin:       *req.TimeMin,
+		TimeMax:       *req.TimeMax,
+		EventsChecked: len(events),
+		Matches:       matches,
+	}, log)
+}
+
+// listEvents haalt alle events in het venster op uit de primaire agenda; alleen lezen.
+func listEvents(ctx context.Context, client *calendar.Service, timeMin, timeMax time.Time) ([]*calendar.Event, error) {
+	var events []*calendar.Event
+	err := client.Events.List("primary").
+		TimeMin(timeMin.Format(time.RFC3339)).
+		TimeMax(timeMax.Format(time.RFC3339)).
+		SingleEvents(true).
+		OrderBy("startTime").
+		MaxResults(2500).
+		Pages(ctx, func(page *calendar.Events) error {
+			events = append(events, page.Items...)
+			return nil
+		})
+	return events, err
+}
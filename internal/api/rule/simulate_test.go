@@ -0,0 +1,106 @@
+package rule
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func newSimulateRequest(body string, account domain.ConnectedAccount, ruleID string) *http.Request {
+	req := httptest.NewRequest("POST", "/", bytes.NewBufferString(body))
+	ctx := context.WithValue(req.Context(), common.UserContextKey, account.UserID)
+	ctx = common.WithAccount(ctx, account)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("accountId", account.ID.String())
+	if ruleID != "" {
+		rctx.URLParams.Add("ruleId", ruleID)
+	}
+	return req.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx))
+}
+
+func TestHandleSimulateRule_OtherAccount(t *testing.T) {
+	mockStore := &store.MockStore{}
+	account := domain.ConnectedAccount{ID: uuid.New(), UserID: uuid.New()}
+	ruleID := uuid.New()
+	rule := domain.AutomationRule{BaseAutomationRule: domain.BaseAutomationRule{
+		AccountEntity: domain.AccountEntity{ConnectedAccountID: uuid.New()},
+	}}
+
+	mockStore.On("GetRuleByID", mock.Anything, ruleID).Return(rule, nil)
+
+	rr := httptest.NewRecorder()
+	HandleSimulateRule(mockStore, zap.NewNop()).ServeHTTP(rr, newSimulateRequest("", account, ruleID.String()))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	mockStore.AssertNotCalled(t, "GetValidTokenForAccount", mock.Anything, mock.Anything)
+}
+
+func TestHandleSimulateRule_Window(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "max before min", body: `{"time_min": "2025-12-01T00:00:00Z", "time_max": "2025-11-01T00:00:00Z"}`, want: "time_max moet na time_min"},
+		{name: "window too large", body: `{"time_min": "2025-01-01T00:00:00Z", "time_max": "2027-01-01T00:00:00Z"}`, want: "maximaal 366 dagen"},
+		{name: "invalid json", body: `{"time_min": 5}`, want: "Ongeldige request body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &store.MockStore{}
+			account := domain.ConnectedAccount{ID: uuid.New(), UserID: uuid.New()}
+
+			rr := httptest.NewRecorder()
+			HandleSimulateRule(mockStore, zap.NewNop()).ServeHTTP(rr, newSimulateRequest(tt.body, account, uuid.New().String()))
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Contains(t, rr.Body.String(), tt.want)
+			mockStore.AssertNotCalled(t, "GetRuleByID", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandleSimulateAdHocRule(t *testing.T) {
+	t.Run("invalid rule", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		account := domain.ConnectedAccount{ID: uuid.New(), UserID: uuid.New()}
+		body := `{"rule": {"name": "Dienst", "trigger_conditions": {}, "action_params": {"new_event_title": "Vertrekken"}}}`
+
+		rr := httptest.NewRecorder()
+		HandleSimulateAdHocRule(mockStore, zap.NewNop()).ServeHTTP(rr, newSimulateRequest(body, account, ""))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		var response common.ValidationErrorResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "rule.trigger_conditions.summary_equals", response.Fields[0].Field)
+	})
+
+	t.Run("no calendar access", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		account := domain.ConnectedAccount{ID: uuid.New(), UserID: uuid.New()}
+		body := `{"rule": {"name": "Dienst", "trigger_conditions": {"summary_equals": "Dienst"}, "action_params": {"new_event_title": "Vertrekken"}}}`
+
+		mockStore.On("GetValidTokenForAccount", mock.Anything, account.ID).Return(nil, errors.New("token revoked"))
+
+		rr := httptest.NewRecorder()
+		HandleSimulateAdHocRule(mockStore, zap.NewNop()).ServeHTTP(rr, newSimulateRequest(body, account, ""))
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		mockStore.AssertNotCalled(t, "CreateAutomationRule", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,126 @@
+package rule
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"go.uber.org/zap"
+)
+
+// HandleCreateUserRule maakt een calendar rule van de gebruiker zelf. account_selector kiest op
+// welke van zijn accounts de worker de rule draait; de logs blijven per account.
+func HandleCreateUserRule(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := common.GetUserIDFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
+			return
+		}
+
+		var req domain.AutomationRule
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
+			return
+		}
+		fieldErrs, ok := validateRuleForUser(w, r, storer, userID, req, log)
+		if !ok {
+			return
+		}
+		selectorErrs, err := common.ValidateAccountSelector(r.Context(), storer, userID, req.AccountSelector)
+		if err != nil {
+			log.Error("HANDLER ERROR [ValidateAccountSelector]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon accounts niet ophalen", log)
+			return
+		}
+		if fieldErrs = append(fieldErrs, selectorErrs...); len(fieldErrs) > 0 {
+			common.WriteValidationErrors(w, fieldErrs, log)
+			return
+		}
+
+		rule, err := storer.CreateUserRule(r.Context(), store.CreateUserRuleParams{
+			UserID:            userID,
+			AccountSelector:   *req.AccountSelector,
+			Name:              req.Name,
+			TriggerType:       req.Trigger(),
+			TriggerConditions: req.TriggerConditions,
+			ActionType:        req.Action(),
+			ActionParams:      req.ActionParams,
+			Schedule:          req.Schedule,
+		})
+		if err != nil {
+			log.Error("HANDLER ERROR [CreateUserRule]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon rule niet creren", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusCreated, rule, log)
+	}
+}
+
+// HandleGetUserRules geeft de calendar rules van de gebruiker zelf. Rules van een account staan
+// onder /accounts/{accountId}/rules.
+func HandleGetUserRules(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := common.GetUserIDFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
+			return
+		}
+
+		rules, err := storer.GetUserRules(r.Context(), userID)
+		if err != nil {
+			log.Error("HANDLER ERROR [GetUserRules]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon rules niet ophalen", log)
+			return
+		}
+		if rules == nil {
+			rules = []domain.AutomationRule{}
+		}
+
+		common.WriteJSON(w, http.StatusOK, rules, log)
+	}
+}
+
+// HandleUpdateUserRuleAccounts vervangt de account_selector van een user-level calendar rule.
+// De body is de selector zelf.
+func HandleUpdateUserRuleAccounts(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rule, userID, ok := getOwnedRule(w, r, storer, log)
+		if !ok {
+			return
+		}
+		if !rule.IsUserRule() {
+			common.WriteJSONError(w, http.StatusNotFound, "Rule niet gevonden", log)
+			return
+		}
+
+		var selector domain.AccountSelector
+		if err := json.NewDecoder(r.Body).Decode(&selector); err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
+			return
+		}
+		fieldErrs, err := common.ValidateAccountSelector(r.Context(), storer, userID, &selector)
+		if err != nil {
+			log.Error("HANDLER ERROR [ValidateAccountSelector]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon accounts niet ophalen", log)
+			return
+		}
+		if len(fieldErrs) > 0 {
+			common.WriteValidationErrors(w, fieldErrs, log)
+			return
+		}
+
+		updated, err := storer.UpdateRuleAccountSelector(r.Context(), rule.ID, selector, userID)
+		if err != nil {
+			log.Error("HANDLER ERROR [UpdateRuleAccountSelector]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon rule niet updaten", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, updated, log)
+	}
+}
@@ -0,0 +1,168 @@
+package rule
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newUserRuleRequest(method string, userID uuid.UUID, ruleID string, body string) *http.Request {
+	req := httptest.NewRequest(method, "/", strings.NewReader(body))
+	ctx := context.WithValue(req.Context(), common.UserContextKey, userID)
+	rctx := chi.NewRouteContext()
+	if ruleID != "" {
+		rctx.URLParams.Add("ruleId", ruleID)
+	}
+	return req.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx))
+}
+
+// userRule maakt een user-level rule van userID met selector.
+func userRule(userID uuid.UUID, selector domain.AccountSelector) domain.AutomationRule {
+	return domain.AutomationRule{BaseAutomationRule: domain.BaseAutomationRule{
+		AccountEntity:     domain.AccountEntity{BaseEntity: domain.BaseEntity{ID: uuid.New()}},
+		Name:              "Standup reminder",
+		IsActive:          true,
+		TriggerConditions: json.RawMessage(`{"summary_equals": "Standup"}`),
+		ActionParams:      json.RawMessage(`{"offset_minutes": -5}`),
+		UserID:            &userID,
+		AccountSelector:   &selector,
+	}}
+}
+
+func TestHandleCreateUserRule(t *testing.T) {
+	userID := uuid.New()
+	accountID := uuid.New()
+
+	t.Run("created", func(t *testing.T) {
+		mockStore := new(store.MockStore)
+		selector := domain.AccountSelector{Mode: domain.AccountSelectorAccounts, AccountIDs: []uuid.UUID{accountID}}
+		created := userRule(userID, selector)
+
+		mockStore.On("GetAccountsForUser", mock.Anything, userID).
+			Return([]domain.ConnectedAccount{{ID: accountID, UserID: userID}}, nil)
+		mockStore.On("CreateUserRule", mock.Anything, mock.MatchedBy(func(p store.CreateUserRuleParams) bool {
+			return p.UserID == userID && p.Name == "Standup reminder" &&
+				p.ActionType == domain.CalendarActionCreateReminder &&
+				p.AccountSelector.Mode == domain.AccountSelectorAccounts
+		})).Return(created, nil)
+
+		body := `{"name": "Standup reminder", "trigger_conditions": {"summary_equals": "Standup"},
+			"action_params": {"new_event_title": "Standup prep", "offset_minutes": -5},
+			"account_selector": {"mode": "accounts", "account_ids": ["` + accountID.String() + `"]}}`
+		rr := httptest.NewRecorder()
+		HandleCreateUserRule(mockStore, zap.NewNop()).ServeHTTP(rr, newUserRuleRequest("POST", userID, "", body))
+
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var got domain.AutomationRule
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		assert.Equal(t, userID, *got.UserID)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		mockStore := new(store.MockStore)
+		mockStore.On("GetAccountsForUser", mock.Anything, userID).
+			Return([]domain.ConnectedAccount{{ID: accountID, UserID: userID}}, nil)
+
+		// Een account van een andere gebruiker mag niet in de selector staan
+		body := `{"name": "", "trigger_conditions": {"summary_equals": "Standup"},
+			"account_selector": {"mode": "accounts", "account_ids": ["` + uuid.NewString() + `"]}}`
+		rr := httptest.NewRecorder()
+		HandleCreateUserRule(mockStore, zap.NewNop()).ServeHTTP(rr, newUserRuleRequest("POST", userID, "", body))
+
+		require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		var resp common.ValidationErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		fields := map[string]bool{}
+		for _, f := range resp.Fields {
+			fields[f.Field] = true
+		}
+		assert.True(t, fields["name"])
+		assert.True(t, fields["account_selector.account_ids[0]"])
+		mockStore.AssertNotCalled(t, "CreateUserRule", mock.Anything, mock.Anything)
+	})
+
+	t.Run("selector required", func(t *testing.T) {
+		mockStore := new(store.MockStore)
+
+		body := `{"name": "Standup reminder", "trigger_conditions": {"summary_equals": "Standup"}}`
+		rr := httptest.NewRecorder()
+		HandleCreateUserRule(mockStore, zap.NewNop()).ServeHTTP(rr, newUserRuleRequest("POST", userID, "", body))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"account_selector"`)
+	})
+}
+
+func TestHandleGetUserRules(t *testing.T) {
+	userID := uuid.New()
+	mockStore := new(store.MockStore)
+	mockStore.On("GetUserRules", mock.Anything, userID).Return([]domain.AutomationRule(nil), nil)
+
+	rr := httptest.NewRecorder()
+	HandleGetUserRules(mockStore, zap.NewNop()).ServeHTTP(rr, newUserRuleRequest("GET", userID, "", ""))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[]`, rr.Body.String())
+}
+
+func TestHandleUpdateUserRuleAccounts(t *testing.T) {
+	userID := uuid.New()
+	body := `{"mode": "provider", "provider": "google"}`
+
+	t.Run("updated", func(t *testing.T) {
+		mockStore := new(store.MockStore)
+		rule := userRule(userID, domain.AccountSelector{Mode: domain.AccountSelectorAll})
+		updated := userRule(userID, domain.AccountSelector{Mode: domain.AccountSelectorProvider, Provider: domain.ProviderGoogle})
+
+		mockStore.On("GetRuleByID", mock.Anything, rule.ID).Return(rule, nil)
+		mockStore.On("UpdateRuleAccountSelector", mock.Anything, rule.ID,
+			domain.AccountSelector{Mode: domain.AccountSelectorProvider, Provider: domain.ProviderGoogle}, userID,
+		).Return(updated, nil)
+
+		rr := httptest.NewRecorder()
+		HandleUpdateUserRuleAccounts(mockStore, zap.NewNop()).
+			ServeHTTP(rr, newUserRuleRequest("PUT", userID, rule.ID.String(), body))
+
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("account rule", func(t *testing.T) {
+		mockStore := new(store.MockStore)
+		rule := ownedRule(mockStore, userID, uuid.New())
+
+		rr := httptest.NewRecorder()
+		HandleUpdateUserRuleAccounts(mockStore, zap.NewNop()).
+			ServeHTTP(rr, newUserRuleRequest("PUT", userID, rule.ID.String(), body))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		mockStore.AssertNotCalled(t, "UpdateRuleAccountSelector", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("other user", func(t *testing.T) {
+		mockStore := new(store.MockStore)
+		rule := userRule(uuid.New(), domain.AccountSelector{Mode: domain.AccountSelectorAll})
+		mockStore.On("GetRuleByID", mock.Anything, rule.ID).Return(rule, nil)
+
+		rr := httptest.NewRecorder()
+		HandleUpdateUserRuleAccounts(mockStore, zap.NewNop()).
+			ServeHTTP(rr, newUserRuleRequest("PUT", userID, rule.ID.String(), body))
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+}
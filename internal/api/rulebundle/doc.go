@@ -0,0 +1,2 @@
+// Package rulebundle handles export and import of rule sets as portable bundles.
+package rulebundle
@@ -0,0 +1,158 @@
+package rulebundle
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// HandleExportRules exporteert alle calendar en Gmail rules van de gebruiker als bundel.
+// Met ?format=yaml (of een Accept header met yaml) is de bundel YAML, anders JSON.
+func HandleExportRules(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := common.GetUserIDFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" && strings.Contains(r.Header.Get("Accept"), "yaml") {
+			format = "yaml"
+		}
+		if format != "" && format != "json" && format != "yaml" {
+			common.WriteJSONError(w, http.StatusBadRequest, "format moet json of yaml zijn", log)
+			return
+		}
+
+		accounts, err := storer.GetAccountsForUser(r.Context(), userID)
+		if err != nil {
+			log.Error("HANDLER ERROR [GetAccountsForUser]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon accounts niet ophalen", log)
+			return
+		}
+
+		bundle := domain.RuleBundle{
+			Format:     domain.RuleBundleFormat,
+			Version:    domain.RuleBundleVersion,
+			ExportedAt: time.Now().UTC(),
+			Accounts:   []domain.RuleBundleAccount{},
+		}
+		for _, account := range accounts {
+			bundleAccount, err := exportAccount(r, storer, account)
+			if err != nil {
+				log.Error("HANDLER ERROR [ExportRules]", zap.Error(err), zap.String("account_id", account.ID.String()))
+				common.WriteJSONError(w, http.StatusInternalServerError, "Kon rules niet ophalen", log)
+				return
+			}
+			bundle.Accounts = append(bundle.Accounts, bundleAccount)
+		}
+		sort.Slice(bundle.Accounts, func(i, j int) bool {
+			return bundle.Accounts[i].Email < bundle.Accounts[j].Email
+		})
+
+		if format != "yaml" {
+			common.WriteJSON(w, http.StatusOK, bundle, log)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/yaml")
+		w.WriteHeader(http.StatusOK)
+		if err = yaml.NewEncoder(w).Encode(bundle); err != nil {
+			log.Error("failed to write YAML response", zap.Error(err), zap.String("component", "api"))
+		}
+	}
+}
+
+// exportAccount zet de rules van één account om naar hun bundelvorm.
+func exportAccount(
+	r *http.Request,
+	storer store.Storer,
+	account domain.ConnectedAccount,
+) (domain.RuleBundleAccount, error) {
+	bundleAccount := domain.RuleBundleAccount{Email: account.Email}
+
+	calendarRules, err := storer.GetRulesForAccount(r.Context(), account.ID)
+	if err != nil {
+		return bundleAccount, err
+	}
+	for _, rule := range calendarRules {
+		// Een hook rule hoort bij een hook van deze gebruiker en is niet over te zetten
+		if rule.Trigger() == domain.CalendarTriggerHookReceived {
+			continue
+		}
+		conditions, err := paramsMap(rule.TriggerConditions)
+		if err != nil {
+			return bundleAccount, err
+		}
+		params, err := paramsMap(rule.ActionParams)
+		if err != nil {
+			return bundleAccount, err
+		}
+		bundleAccount.CalendarRules = append(bundleAccount.CalendarRules, domain.RuleBundleCalendar{
+			Name:              rule.Name,
+			IsActive:          rule.IsActive,
+			TriggerConditions: conditions,
+			ActionType:        rule.Action(),
+			ActionParams:      params,
+			Schedule:          rule.Schedule,
+		})
+	}
+
+	gmailRules, err := storer.GetGmailRulesForAccount(r.Context(), account.ID)
+	if err != nil {
+		return bundleAccount, err
+	}
+	for _, rule := range gmailRules {
+		conditions, err := paramsMap(rule.TriggerConditions)
+		if err != nil {
+			return bundleAccount, err
+		}
+		params, err := paramsMap(rule.ActionParams)
+		if err != nil {
+			return bundleAccount, err
+		}
+		bundleAccount.GmailRules = append(bundleAccount.GmailRules, domain.RuleBundleGmail{
+			Name:              rule.Name,
+			Description:       rule.Description,
+			IsActive:          rule.IsActive,
+			TriggerType:       rule.TriggerType,
+			TriggerConditions: conditions,
+			ActionType:        rule.ActionType,
+			ActionParams:      params,
+			Priority:          rule.Priority,
+			Schedule:          rule.Schedule,
+		})
+	}
+
+	return bundleAccount, nil
+}
+
+// paramsMap decodeert condities of parameters naar een map voor de bundel.
+func paramsMap(raw json.RawMessage) (map[string]any, error) {
+	params := map[string]any{}
+	if len(raw) == 0 || string(raw) == "null" {
+		return params, nil
+	}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// paramsJSON is het omgekeerde van paramsMap; een ontbrekende map wordt {}.
+func paramsJSON(params map[string]any) (json.RawMessage, error) {
+	if params == nil {
+		return json.RawMessage(`{}`), nil
+	}
+	return json.Marshal(params)
+}
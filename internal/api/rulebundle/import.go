@@ -0,0 +1,389 @@
+package rulebundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"agenda-automator-api/internal/api/common"
+	gmailapi "agenda-automator-api/internal/api/gmail"
+	ruleapi "agenda-automator-api/internal/api/rule"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// Wat een import doet met een rule waarvan de naam al bestaat in het doelaccount
+const (
+	OnConflictFail = "fail" // niets importeren en de conflicten melden (standaard)
+	OnConflictSkip = "skip" // de bestaande rule houden en de rule uit de bundel overslaan
+)
+
+// ImportRequest is de body van een import, als JSON of als YAML.
+type ImportRequest struct {
+	Bundle domain.RuleBundle `json:"bundle" yaml:"bundle"`
+	// AccountMap koppelt een e-mailadres uit de bundel aan het e-mailadres of ID van een eigen account.
+	// Accounts die er niet in staan worden gekoppeld aan het eigen account met hetzelfde e-mailadres.
+	AccountMap map[string]string `json:"account_map,omitempty" yaml:"account_map,omitempty"`
+	OnConflict string            `json:"on_conflict,omitempty" yaml:"on_conflict,omitempty"`
+	DryRun     bool              `json:"dry_run,omitempty"     yaml:"dry_run,omitempty"`
+}
+
+// ImportAccount is de koppeling van een account uit de bundel aan een eigen account.
+type ImportAccount struct {
+	Email        string    `json:"email"`
+	AccountID    uuid.UUID `json:"account_id"`
+	AccountEmail string    `json:"account_email"`
+}
+
+// ImportConflict is een rule uit de bundel met dezelfde naam als een rule in het doelaccount,
+// of als een andere rule uit de bundel voor hetzelfde account.
+type ImportConflict struct {
+	Field          string          `json:"field"`
+	RuleType       domain.RuleType `json:"rule_type"`
+	Name           string          `json:"name"`
+	AccountID      uuid.UUID       `json:"account_id"`
+	ExistingRuleID *uuid.UUID      `json:"existing_rule_id,omitempty"`
+}
+
+// ImportReport beschrijft het resultaat van een import. Bij een dry run of bij conflicten is er
+// niets opgeslagen en tellen Created wat aangemaakt zou worden.
+type ImportReport struct {
+	Error     string                  `json:"error,omitempty"`
+	DryRun    bool                    `json:"dry_run"`
+	Accounts  []ImportAccount         `json:"accounts"`
+	Conflicts []ImportConflict        `json:"conflicts"`
+	Created   store.ImportRulesResult `json:"created"`
+	Skipped   int                     `json:"skipped"` // conflicten die met on_conflict=skip overgeslagen zijn
+}
+
+// HandleImportRules valideert een rule bundel, koppelt de accounts uit de bundel aan eigen accounts
+// en maakt alle rules in één transactie aan. Bij conflicten wordt zonder on_conflict=skip niets opgeslagen.
+func HandleImportRules(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := common.GetUserIDFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
+			return
+		}
+
+		var req ImportRequest
+		if err = decodeImportRequest(r, &req); err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
+			return
+		}
+		if req.OnConflict == "" {
+			req.OnConflict = OnConflictFail
+		}
+
+		var fieldErrs rules.FieldErrors
+		if req.OnConflict != OnConflictFail && req.OnConflict != OnConflictSkip {
+			fieldErrs.Add("on_conflict", "must be %q or %q", OnConflictFail, OnConflictSkip)
+		}
+		if req.Bundle.Format != domain.RuleBundleFormat {
+			fieldErrs.Add("bundle.format", "must be %q", domain.RuleBundleFormat)
+		}
+		if req.Bundle.Version < 1 || req.Bundle.Version > domain.RuleBundleVersion {
+			fieldErrs.Add("bundle.version", "unsupported version %d", req.Bundle.Version)
+		}
+		if len(fieldErrs) > 0 {
+			common.WriteValidationErrors(w, fieldErrs, log)
+			return
+		}
+
+		accounts, err := storer.GetAccountsForUser(r.Context(), userID)
+		if err != nil {
+			log.Error("HANDLER ERROR [GetAccountsForUser]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon accounts niet ophalen", log)
+			return
+		}
+
+		plan := importPlan{
+			params: store.ImportRulesParams{CreatedBy: userID},
+			report: ImportReport{DryRun: req.DryRun, Accounts: []ImportAccount{}, Conflicts: []ImportConflict{}},
+			names:  map[ruleKey]*uuid.UUID{},
+			loaded: map[uuid.UUID]bool{},
+		}
+		for i, bundleAccount := range req.Bundle.Accounts {
+			field := fmt.Sprintf("bundle.accounts[%d]", i)
+			if len(bundleAccount.CalendarRules) == 0 && len(bundleAccount.GmailRules) == 0 {
+				continue
+			}
+
+			target, ok := mapAccount(bundleAccount.Email, req.AccountMap, accounts)
+			if !ok {
+				fieldErrs.Add(field+".email", "no connected account for %q; add it to account_map", bundleAccount.Email)
+				continue
+			}
+			plan.report.Accounts = append(plan.report.Accounts, ImportAccount{
+				Email:        bundleAccount.Email,
+				AccountID:    target.ID,
+				AccountEmail: target.Email,
+			})
+
+			errs, err := plan.addAccount(r, storer, field, bundleAccount, target, log)
+			if err != nil {
+				log.Error("HANDLER ERROR [ImportRules]", zap.Error(err), zap.String("account_id", target.ID.String()))
+				common.WriteJSONError(w, http.StatusInternalServerError, "Kon rules van het doelaccount niet controleren", log)
+				return
+			}
+			fieldErrs = append(fieldErrs, errs...)
+		}
+		if len(fieldErrs) > 0 {
+			common.WriteValidationErrors(w, fieldErrs, log)
+			return
+		}
+
+		report := plan.report
+		if len(report.Conflicts) > 0 && req.OnConflict == OnConflictFail {
+			report.Error = "Rules met dezelfde naam bestaan al; er is niets geïmporteerd"
+			common.WriteJSON(w, http.StatusConflict, report, log)
+			return
+		}
+		if req.OnConflict == OnConflictSkip {
+			report.Skipped = len(report.Conflicts)
+		}
+		if req.DryRun {
+			common.WriteJSON(w, http.StatusOK, report, log)
+			return
+		}
+
+		report.Created, err = storer.ImportRules(r.Context(), plan.params)
+		if err != nil {
+			log.Error("HANDLER ERROR [ImportRules]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon rules niet importeren", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusCreated, report, log)
+	}
+}
+
+// decodeImportRequest leest de body als YAML bij een YAML content type en anders als JSON.
+func decodeImportRequest(r *http.Request, req *ImportRequest) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if strings.HasSuffix(mediaType, "yaml") {
+		return yaml.NewDecoder(r.Body).Decode(req)
+	}
+	return json.NewDecoder(r.Body).Decode(req)
+}
+
+// mapAccount zoekt het eigen account voor een e-mailadres uit de bundel, via AccountMap als die
+// het adres bevat. Een waarde in AccountMap mag een e-mailadres of een account ID zijn.
+func mapAccount(
+	email string,
+	accountMap map[string]string,
+	accounts []domain.ConnectedAccount,
+) (domain.ConnectedAccount, bool) {
+	target := email
+	if mapped, ok := accountMap[email]; ok {
+		target = mapped
+	}
+	for _, account := range accounts {
+		if strings.EqualFold(account.Email, target) || account.ID.String() == target {
+			return account, true
+		}
+	}
+	return domain.ConnectedAccount{}, false
+}
+
+// ruleKey identificeert een rule naam binnen een account, per soort rule.
+type ruleKey struct {
+	accountID uuid.UUID
+	ruleType  domain.RuleType
+	name      string
+}
+
+func newRuleKey(accountID uuid.UUID, ruleType domain.RuleType, name string) ruleKey {
+	return ruleKey{accountID: accountID, ruleType: ruleType, name: strings.ToLower(strings.TrimSpace(name))}
+}
+
+// importPlan verzamelt de rules die aangemaakt worden en de conflicten die de import vond.
+type importPlan struct {
+	params store.ImportRulesParams
+	report ImportReport
+	// names bevat de namen die al bezet zijn, met het ID van de bestaande rule of nil voor een
+	// rule die eerder in dezelfde bundel staat
+	names  map[ruleKey]*uuid.UUID
+	loaded map[uuid.UUID]bool
+}
+
+// addAccount valideert de rules van één account uit de bundel tegen het doelaccount en voegt ze
+// toe aan het plan. Een fout betekent dat de bestaande rules of labels niet opgehaald konden worden.
+func (p *importPlan) addAccount(
+	r *http.Request,
+	storer store.Storer,
+	field string,
+	bundleAccount domain.RuleBundleAccount,
+	target domain.ConnectedAccount,
+	log *zap.Logger,
+) (rules.FieldErrors, error) {
+	var fieldErrs rules.FieldErrors
+
+	if err := p.loadExistingNames(r, storer, target.ID); err != nil {
+		return nil, err
+	}
+
+	for j, bundleRule := range bundleAccount.CalendarRules {
+		ruleField := fmt.Sprintf("%s.calendar_rules[%d]", field, j)
+		rule, err := calendarRule(target.ID, bundleRule)
+		if err != nil {
+			fieldErrs.Add(ruleField, "%v", err)
+			continue
+		}
+		if errs := ruleapi.ValidateRule(rule); len(errs) > 0 {
+			fieldErrs = append(fieldErrs, errs.Prefixed(ruleField)...)
+			continue
+		}
+		if p.conflict(ruleField, target.ID, domain.RuleTypeCalendar, rule.Name) {
+			continue
+		}
+		p.params.CalendarRules = append(p.params.CalendarRules, store.ImportCalendarRule{
+			ConnectedAccountID: target.ID,
+			Name:               rule.Name,
+			IsActive:           rule.IsActive,
+			TriggerConditions:  rule.TriggerConditions,
+			ActionType:         rule.Action(),
+			ActionParams:       rule.ActionParams,
+			Schedule:           rule.Schedule,
+		})
+		p.report.Created.CalendarRules++
+	}
+
+	for j, bundleRule := range bundleAccount.GmailRules {
+		ruleField := fmt.Sprintf("%s.gmail_rules[%d]", field, j)
+		rule, err := gmailRule(target.ID, bundleRule)
+		if err != nil {
+			fieldErrs.Add(ruleField, "%v", err)
+			continue
+		}
+		errs, err := gmailapi.ValidateGmailRule(r.Context(), storer, target.ID, rule, log)
+		if err != nil {
+			return nil, err
+		}
+		// Zelfde grenzen als chk_gmail_rules_priority_range, zodat één rule niet de hele import laat mislukken
+		if rule.Priority < 0 || rule.Priority > 100 {
+			errs.Add("priority", "must be between 0 and 100")
+		}
+		if len(errs) > 0 {
+			fieldErrs = append(fieldErrs, errs.Prefixed(ruleField)...)
+			continue
+		}
+		if p.conflict(ruleField, target.ID, domain.RuleTypeGmail, rule.Name) {
+			continue
+		}
+		p.params.GmailRules = append(p.params.GmailRules, store.ImportGmailRule{
+			ConnectedAccountID: target.ID,
+			Name:               rule.Name,
+			Description:        rule.Description,
+			IsActive:           rule.IsActive,
+			TriggerType:        rule.TriggerType,
+			TriggerConditions:  rule.TriggerConditions,
+			ActionType:         rule.ActionType,
+			ActionParams:       rule.ActionParams,
+			Priority:           rule.Priority,
+			Schedule:           rule.Schedule,
+		})
+		p.report.Created.GmailRules++
+	}
+
+	return fieldErrs, nil
+}
+
+// loadExistingNames voegt de namen van de bestaande rules van een account toe, eenmaal per account.
+func (p *importPlan) loadExistingNames(r *http.Request, storer store.Storer, accountID uuid.UUID) error {
+	if p.loaded[accountID] {
+		return nil
+	}
+	p.loaded[accountID] = true
+
+	calendarRules, err := storer.GetRulesForAccount(r.Context(), accountID)
+	if err != nil {
+		return err
+	}
+	for _, rule := range calendarRules {
+		id := rule.ID
+		p.names[newRuleKey(accountID, domain.RuleTypeCalendar, rule.Name)] = &id
+	}
+
+	gmailRules, err := storer.GetGmailRulesForAccount(r.Context(), accountID)
+	if err != nil {
+		return err
+	}
+	for _, rule := range gmailRules {
+		id := rule.ID
+		p.names[newRuleKey(accountID, domain.RuleTypeGmail, rule.Name)] = &id
+	}
+
+	return nil
+}
+
+// conflict meldt of de naam al bezet is en houdt anders de naam bij voor de volgende rules.
+func (p *importPlan) conflict(field string, accountID uuid.UUID, ruleType domain.RuleType, name string) bool {
+	key := newRuleKey(accountID, ruleType, name)
+	existing, taken := p.names[key]
+	if !taken {
+		p.names[key] = nil
+		return false
+	}
+	p.report.Conflicts = append(p.report.Conflicts, ImportConflict{
+		Field:          field,
+		RuleType:       ruleType,
+		Name:           name,
+		AccountID:      accountID,
+		ExistingRuleID: existing,
+	})
+	return true
+}
+
+// calendarRule zet een calendar rule uit de bundel om naar een rule voor accountID.
+func calendarRule(accountID uuid.UUID, bundleRule domain.RuleBundleCalendar) (domain.AutomationRule, error) {
+	conditions, err := paramsJSON(bundleRule.TriggerConditions)
+	if err != nil {
+		return domain.AutomationRule{}, err
+	}
+	params, err := paramsJSON(bundleRule.ActionParams)
+	if err != nil {
+		return domain.AutomationRule{}, err
+	}
+	return domain.AutomationRule{BaseAutomationRule: domain.BaseAutomationRule{
+		AccountEntity:     domain.AccountEntity{ConnectedAccountID: accountID},
+		Name:              bundleRule.Name,
+		IsActive:          bundleRule.IsActive,
+		TriggerConditions: conditions,
+		ActionParams:      params,
+		Schedule:          bundleRule.Schedule,
+	}, ActionType: bundleRule.ActionType}, nil
+}
+
+// gmailRule zet een Gmail rule uit de bundel om naar een rule voor accountID.
+func gmailRule(accountID uuid.UUID, bundleRule domain.RuleBundleGmail) (domain.GmailAutomationRule, error) {
+	conditions, err := paramsJSON(bundleRule.TriggerConditions)
+	if err != nil {
+		return domain.GmailAutomationRule{}, err
+	}
+	params, err := paramsJSON(bundleRule.ActionParams)
+	if err != nil {
+		return domain.GmailAutomationRule{}, err
+	}
+	return domain.GmailAutomationRule{
+		BaseAutomationRule: domain.BaseAutomationRule{
+			AccountEntity:     domain.AccountEntity{ConnectedAccountID: accountID},
+			Name:              bundleRule.Name,
+			IsActive:          bundleRule.IsActive,
+			TriggerConditions: conditions,
+			ActionParams:      params,
+			Schedule:          bundleRule.Schedule,
+		},
+		Description: bundleRule.Description,
+		TriggerType: bundleRule.TriggerType,
+		ActionType:  bundleRule.ActionType,
+		Priority:    bundleRule.Priority,
+	}, nil
+}
@@ -0,0 +1,296 @@
+package rulebundle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+func newUserRequest(method, target, body string, userID uuid.UUID) *http.Request {
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	return req.WithContext(context.WithValue(req.Context(), common.UserContextKey, userID))
+}
+
+func testCalendarRule(accountID uuid.UUID, name string) domain.AutomationRule {
+	return domain.AutomationRule{BaseAutomationRule: domain.BaseAutomationRule{
+		AccountEntity: domain.AccountEntity{
+			BaseEntity:         domain.BaseEntity{ID: uuid.New()},
+			ConnectedAccountID: accountID,
+		},
+		Name:              name,
+		IsActive:          true,
+		TriggerConditions: json.RawMessage(`{"summary_equals":"Dienst"}`),
+		ActionParams:      json.RawMessage(`{"new_event_title":"Vertrekken","offset_minutes":-45}`),
+	}}
+}
+
+func testGmailRule(accountID uuid.UUID, name string) domain.GmailAutomationRule {
+	return domain.GmailAutomationRule{
+		BaseAutomationRule: domain.BaseAutomationRule{
+			AccountEntity: domain.AccountEntity{
+				BaseEntity:         domain.BaseEntity{ID: uuid.New()},
+				ConnectedAccountID: accountID,
+			},
+			Name:              name,
+			IsActive:          false,
+			TriggerConditions: json.RawMessage(`{"sender_pattern":"news@example.com"}`),
+			ActionParams:      json.RawMessage(`{}`),
+		},
+		TriggerType: domain.GmailTriggerSenderMatch,
+		ActionType:  domain.GmailActionArchive,
+		Priority:    10,
+	}
+}
+
+func TestHandleExportRules(t *testing.T) {
+	userID := uuid.New()
+	account := domain.ConnectedAccount{ID: uuid.New(), UserID: userID, Email: "team@example.com"}
+
+	setup := func() *store.MockStore {
+		mockStore := &store.MockStore{}
+		mockStore.On("GetAccountsForUser", mock.Anything, userID).Return([]domain.ConnectedAccount{account}, nil)
+		mockStore.On("GetRulesForAccount", mock.Anything, account.ID).
+			Return([]domain.AutomationRule{testCalendarRule(account.ID, "Dienst reminder")}, nil)
+		mockStore.On("GetGmailRulesForAccount", mock.Anything, account.ID).
+			Return([]domain.GmailAutomationRule{testGmailRule(account.ID, "Nieuwsbrieven")}, nil)
+		return mockStore
+	}
+
+	t.Run("json", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		HandleExportRules(setup(), zap.NewNop()).ServeHTTP(rr, newUserRequest("GET", "/", "", userID))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var bundle domain.RuleBundle
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &bundle))
+		assert.Equal(t, domain.RuleBundleFormat, bundle.Format)
+		require.Len(t, bundle.Accounts, 1)
+		assert.Equal(t, "team@example.com", bundle.Accounts[0].Email)
+		require.Len(t, bundle.Accounts[0].CalendarRules, 1)
+		assert.Equal(t, "Dienst", bundle.Accounts[0].CalendarRules[0].TriggerConditions["summary_equals"])
+		require.Len(t, bundle.Accounts[0].GmailRules, 1)
+		assert.Equal(t, domain.GmailActionArchive, bundle.Accounts[0].GmailRules[0].ActionType)
+		assert.NotContains(t, rr.Body.String(), account.ID.String())
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		HandleExportRules(setup(), zap.NewNop()).ServeHTTP(rr, newUserRequest("GET", "/?format=yaml", "", userID))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/yaml", rr.Header().Get("Content-Type"))
+		var bundle domain.RuleBundle
+		require.NoError(t, yaml.Unmarshal(rr.Body.Bytes(), &bundle))
+		require.Len(t, bundle.Accounts, 1)
+		assert.Equal(t, 10, bundle.Accounts[0].GmailRules[0].Priority)
+		assert.Equal(t, -45, bundle.Accounts[0].CalendarRules[0].ActionParams["offset_minutes"])
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		HandleExportRules(&store.MockStore{}, zap.NewNop()).
+			ServeHTTP(rr, newUserRequest("GET", "/?format=xml", "", userID))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+const importBody = `{
+	"bundle": {
+		"format": "agenda-automator/rules",
+		"version": 1,
+		"accounts": [{
+			"email": "team@example.com",
+			"calendar_rules": [{
+				"name": "Dienst reminder",
+				"is_active": true,
+				"trigger_conditions": {"summary_equals": "Dienst"},
+				"action_params": {"new_event_title": "Vertrekken"}
+			}],
+			"gmail_rules": [{
+				"name": "Nieuwsbrieven",
+				"trigger_type": "sender_match",
+				"trigger_conditions": {"sender_pattern": "news@example.com"},
+				"action_type": "archive",
+				"priority": 5
+			}]
+		}]
+	},
+	"account_map": {"team@example.com": "me@example.com"}
+	%s
+}`
+
+func importRequest(userID uuid.UUID, extra string) *http.Request {
+	body := bytes.Replace([]byte(importBody), []byte("%s"), []byte(extra), 1)
+	return newUserRequest("POST", "/", string(body), userID)
+}
+
+func TestHandleImportRules(t *testing.T) {
+	userID := uuid.New()
+	target := domain.ConnectedAccount{ID: uuid.New(), UserID: userID, Email: "me@example.com"}
+
+	setup := func(existing []domain.GmailAutomationRule) *store.MockStore {
+		mockStore := &store.MockStore{}
+		mockStore.On("GetAccountsForUser", mock.Anything, userID).Return([]domain.ConnectedAccount{target}, nil)
+		mockStore.On("GetRulesForAccount", mock.Anything, target.ID).Return([]domain.AutomationRule{}, nil)
+		mockStore.On("GetGmailRulesForAccount", mock.Anything, target.ID).Return(existing, nil)
+		return mockStore
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockStore := setup(nil)
+		mockStore.On("ImportRules", mock.Anything, mock.MatchedBy(func(p store.ImportRulesParams) bool {
+			return p.CreatedBy == userID &&
+				len(p.CalendarRules) == 1 && p.CalendarRules[0].ConnectedAccountID == target.ID &&
+				len(p.GmailRules) == 1 && string(p.GmailRules[0].ActionParams) == `{}` &&
+				p.GmailRules[0].Priority == 5
+		})).Return(store.ImportRulesResult{CalendarRules: 1, GmailRules: 1}, nil)
+
+		rr := httptest.NewRecorder()
+		HandleImportRules(mockStore, zap.NewNop()).ServeHTTP(rr, importRequest(userID, ""))
+
+		require.Equal(t, http.StatusCreated, rr.Code)
+		var report ImportReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, store.ImportRulesResult{CalendarRules: 1, GmailRules: 1}, report.Created)
+		require.Len(t, report.Accounts, 1)
+		assert.Equal(t, target.ID, report.Accounts[0].AccountID)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("conflict fails", func(t *testing.T) {
+		existing := testGmailRule(target.ID, "nieuwsbrieven")
+		mockStore := setup([]domain.GmailAutomationRule{existing})
+
+		rr := httptest.NewRecorder()
+		HandleImportRules(mockStore, zap.NewNop()).ServeHTTP(rr, importRequest(userID, ""))
+
+		require.Equal(t, http.StatusConflict, rr.Code)
+		var report ImportReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		require.Len(t, report.Conflicts, 1)
+		assert.Equal(t, "bundle.accounts[0].gmail_rules[0]", report.Conflicts[0].Field)
+		assert.Equal(t, &existing.ID, report.Conflicts[0].ExistingRuleID)
+		mockStore.AssertNotCalled(t, "ImportRules", mock.Anything, mock.Anything)
+	})
+
+	t.Run("conflict skipped", func(t *testing.T) {
+		mockStore := setup([]domain.GmailAutomationRule{testGmailRule(target.ID, "Nieuwsbrieven")})
+		mockStore.On("ImportRules", mock.Anything, mock.MatchedBy(func(p store.ImportRulesParams) bool {
+			return len(p.CalendarRules) == 1 && len(p.GmailRules) == 0
+		})).Return(store.ImportRulesResult{CalendarRules: 1}, nil)
+
+		rr := httptest.NewRecorder()
+		HandleImportRules(mockStore, zap.NewNop()).ServeHTTP(rr, importRequest(userID, `, "on_conflict": "skip"`))
+
+		require.Equal(t, http.StatusCreated, rr.Code)
+		var report ImportReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.Equal(t, 1, report.Skipped)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		mockStore := setup(nil)
+
+		rr := httptest.NewRecorder()
+		HandleImportRules(mockStore, zap.NewNop()).ServeHTTP(rr, importRequest(userID, `, "dry_run": true`))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var report ImportReport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.True(t, report.DryRun)
+		assert.Equal(t, store.ImportRulesResult{CalendarRules: 1, GmailRules: 1}, report.Created)
+		mockStore.AssertNotCalled(t, "ImportRules", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unmapped account", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		mockStore.On("GetAccountsForUser", mock.Anything, userID).Return([]domain.ConnectedAccount{}, nil)
+
+		rr := httptest.NewRecorder()
+		HandleImportRules(mockStore, zap.NewNop()).ServeHTTP(rr, importRequest(userID, ""))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Contains(t, rr.Body.String(), "bundle.accounts[0].email")
+	})
+
+	t.Run("invalid rule", func(t *testing.T) {
+		mockStore := setup(nil)
+		body := bytes.Replace([]byte(importBody), []byte(`"archive"`), []byte(`"explode"`), 1)
+		body = bytes.Replace(body, []byte("%s"), nil, 1)
+
+		rr := httptest.NewRecorder()
+		HandleImportRules(mockStore, zap.NewNop()).ServeHTTP(rr, newUserRequest("POST", "/", string(body), userID))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Contains(t, rr.Body.String(), "bundle.accounts[0].gmail_rules[0].action_type")
+		mockStore.AssertNotCalled(t, "ImportRules", mock.Anything, mock.Anything)
+	})
+
+	t.Run("yaml body", func(t *testing.T) {
+		mockStore := setup(nil)
+		mockStore.On("ImportRules", mock.Anything, mock.MatchedBy(func(p store.ImportRulesParams) bool {
+			if len(p.CalendarRules) != 1 || p.CalendarRules[0].Schedule == nil {
+				return false
+			}
+			schedule := p.CalendarRules[0].Schedule
+			return string(p.CalendarRules[0].ActionParams) == `{"new_event_title":"Vertrekken"}` &&
+				schedule.ValidUntil != nil && schedule.ValidUntil.Equal(time.Date(2026, 8, 15, 0, 0, 0, 0, time.UTC)) &&
+				len(schedule.Windows) == 1 && schedule.Windows[0].Start == "08:00"
+		})).Return(store.ImportRulesResult{CalendarRules: 1}, nil)
+
+		body := `
+bundle:
+  format: agenda-automator/rules
+  version: 1
+  accounts:
+    - email: me@example.com
+      calendar_rules:
+        - name: Dienst reminder
+          is_active: true
+          trigger_conditions:
+            summary_equals: Dienst
+          action_params:
+            new_event_title: Vertrekken
+          schedule:
+            valid_until: 2026-08-15T00:00:00Z
+            timezone: Europe/Amsterdam
+            weekdays: [mon, tue, wed, thu, fri]
+            windows:
+              - start: "08:00"
+                end: "18:00"
+`
+		req := newUserRequest("POST", "/", body, userID)
+		req.Header.Set("Content-Type", "application/yaml")
+		rr := httptest.NewRecorder()
+		HandleImportRules(mockStore, zap.NewNop()).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("unknown bundle format", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		HandleImportRules(&store.MockStore{}, zap.NewNop()).
+			ServeHTTP(rr, newUserRequest("POST", "/", `{"bundle": {"format": "other", "version": 1}}`, userID))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Contains(t, rr.Body.String(), "bundle.format")
+	})
+}
@@ -0,0 +1,2 @@
+// Package run handles the "run now" API endpoints: starting a processing pass for an account or a rule and polling its run.
+package run
@@ -0,0 +1,119 @@
+package run
+
+import (
+	"errors"
+	"net/http"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// runLogLimit is het maximale aantal logs in de response van HandleGetRun.
+const runLogLimit = 100
+
+// Enqueuer zet een handmatige run klaar. Wordt geïmplementeerd door de worker.
+type Enqueuer interface {
+	EnqueueRun(run domain.ProcessingRun) bool
+}
+
+// RunResponse is een run met de logs die hij tot nu toe schreef, oudste eerst.
+type RunResponse struct {
+	domain.ProcessingRun
+	Logs []domain.AutomationLog `json:"logs"`
+}
+
+// HandleSyncAccount start direct een verwerking van het account, langs dezelfde weg als de ticker
+// van de worker. De response is de run in de status queued; zijn voortgang staat onder GET /runs/{runId}.
+func HandleSyncAccount(storer store.Storer, runs Enqueuer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, err := common.GetAccountFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusInternalServerError, err.Error(), log)
+			return
+		}
+		if account.Status != domain.StatusActive {
+			common.WriteJSONError(w, http.StatusConflict, "Account is niet actief", log)
+			return
+		}
+
+		Start(w, r, storer, runs, store.CreateProcessingRunParams{
+			UserID:             account.UserID,
+			ConnectedAccountID: &account.ID,
+		}, log)
+	}
+}
+
+// Start slaat een nieuwe run op en zet hem klaar bij de worker. Is de queue vol, dan wordt de run
+// meteen als failed afgesloten en krijgt de client 503.
+func Start(
+	w http.ResponseWriter,
+	r *http.Request,
+	storer store.Storer,
+	runs Enqueuer,
+	arg store.CreateProcessingRunParams,
+	log *zap.Logger,
+) {
+	run, err := storer.CreateProcessingRun(r.Context(), arg)
+	if err != nil {
+		log.Error("HANDLER ERROR [CreateProcessingRun]", zap.Error(err))
+		common.WriteJSONError(w, http.StatusInternalServerError, "Kon run niet starten", log)
+		return
+	}
+
+	if !runs.EnqueueRun(run) {
+		message := "run queue is full"
+		if _, err = storer.FinishProcessingRun(r.Context(), run.ID, domain.RunFailed, &message); err != nil {
+			log.Error("HANDLER ERROR [FinishProcessingRun]", zap.Error(err))
+		}
+		common.WriteJSONError(w, http.StatusServiceUnavailable, "Worker is bezig, probeer het later opnieuw", log)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusAccepted, run, log)
+}
+
+// HandleGetRun geeft de status van een run van de gebruiker, met de resultaten zodra hij klaar is
+// en de logs die hij schreef.
+func HandleGetRun(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		runID, err := uuid.Parse(chi.URLParam(r, "runId"))
+		if err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldig run ID", log)
+			return
+		}
+
+		userID, err := common.GetUserIDFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
+			return
+		}
+
+		run, err := storer.GetProcessingRun(r.Context(), runID, userID)
+		if err != nil {
+			if errors.Is(err, store.ErrProcessingRunNotFound) {
+				common.WriteJSONError(w, http.StatusNotFound, "Run niet gevonden", log)
+				return
+			}
+			log.Error("HANDLER ERROR [GetProcessingRun]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon run niet ophalen", log)
+			return
+		}
+
+		logs, err := storer.GetLogsForRun(r.Context(), run.ID, runLogLimit)
+		if err != nil {
+			log.Error("HANDLER ERROR [GetLogsForRun]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon logs van de run niet ophalen", log)
+			return
+		}
+		if logs == nil {
+			logs = []domain.AutomationLog{}
+		}
+
+		common.WriteJSON(w, http.StatusOK, RunResponse{ProcessingRun: run, Logs: logs}, log)
+	}
+}
@@ -0,0 +1,150 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeEnqueuer houdt bij welke runs de handler klaarzet.
+type fakeEnqueuer struct {
+	runs []domain.ProcessingRun
+	full bool
+}
+
+func (f *fakeEnqueuer) EnqueueRun(run domain.ProcessingRun) bool {
+	if f.full {
+		return false
+	}
+	f.runs = append(f.runs, run)
+	return true
+}
+
+func newAccountRequest(account domain.ConnectedAccount) *http.Request {
+	req := httptest.NewRequest("POST", "/", http.NoBody)
+	ctx := context.WithValue(req.Context(), common.UserContextKey, account.UserID)
+	return req.WithContext(common.WithAccount(ctx, account))
+}
+
+func newRunRequest(userID uuid.UUID, runID string) *http.Request {
+	req := httptest.NewRequest("GET", "/", http.NoBody)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("runId", runID)
+	ctx := context.WithValue(req.Context(), common.UserContextKey, userID)
+	return req.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx))
+}
+
+func TestHandleSyncAccount(t *testing.T) {
+	account := domain.ConnectedAccount{ID: uuid.New(), UserID: uuid.New(), Status: domain.StatusActive}
+	queued := domain.ProcessingRun{ID: uuid.New(), UserID: account.UserID, ConnectedAccountID: &account.ID, Status: domain.RunQueued}
+
+	mockStore := &store.MockStore{}
+	mockStore.On("CreateProcessingRun", mock.Anything, store.CreateProcessingRunParams{
+		UserID:             account.UserID,
+		ConnectedAccountID: &account.ID,
+	}).Return(queued, nil)
+	runs := &fakeEnqueuer{}
+
+	rr := httptest.NewRecorder()
+	HandleSyncAccount(mockStore, runs, zap.NewNop()).ServeHTTP(rr, newAccountRequest(account))
+
+	require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+	var got domain.ProcessingRun
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.Equal(t, queued.ID, got.ID)
+	assert.Equal(t, domain.RunQueued, got.Status)
+	require.Len(t, runs.runs, 1)
+	assert.Equal(t, queued.ID, runs.runs[0].ID)
+	mockStore.AssertExpectations(t)
+}
+
+func TestHandleSyncAccount_InactiveAccount(t *testing.T) {
+	account := domain.ConnectedAccount{ID: uuid.New(), UserID: uuid.New(), Status: domain.StatusRevoked}
+	mockStore := &store.MockStore{}
+
+	rr := httptest.NewRecorder()
+	HandleSyncAccount(mockStore, &fakeEnqueuer{}, zap.NewNop()).ServeHTTP(rr, newAccountRequest(account))
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	mockStore.AssertNotCalled(t, "CreateProcessingRun", mock.Anything, mock.Anything)
+}
+
+func TestHandleSyncAccount_QueueFull(t *testing.T) {
+	account := domain.ConnectedAccount{ID: uuid.New(), UserID: uuid.New(), Status: domain.StatusActive}
+	queued := domain.ProcessingRun{ID: uuid.New(), UserID: account.UserID, ConnectedAccountID: &account.ID, Status: domain.RunQueued}
+
+	mockStore := &store.MockStore{}
+	mockStore.On("CreateProcessingRun", mock.Anything, mock.Anything).Return(queued, nil)
+	// Een run die nooit opgepakt wordt, mag niet op queued blijven staan
+	mockStore.On("FinishProcessingRun", mock.Anything, queued.ID, domain.RunFailed, mock.AnythingOfType("*string")).
+		Return(domain.ProcessingRun{}, nil).Once()
+
+	rr := httptest.NewRecorder()
+	HandleSyncAccount(mockStore, &fakeEnqueuer{full: true}, zap.NewNop()).ServeHTTP(rr, newAccountRequest(account))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	mockStore.AssertExpectations(t)
+}
+
+func TestHandleGetRun(t *testing.T) {
+	userID := uuid.New()
+	accountID := uuid.New()
+	run := domain.ProcessingRun{
+		ID:                 uuid.New(),
+		UserID:             userID,
+		ConnectedAccountID: &accountID,
+		Status:             domain.RunCompleted,
+		Results:            &domain.RunResults{Success: 1},
+	}
+	logs := []domain.AutomationLog{{ID: 9, ConnectedAccountID: accountID, Status: domain.LogSuccess}}
+
+	mockStore := &store.MockStore{}
+	mockStore.On("GetProcessingRun", mock.Anything, run.ID, userID).Return(run, nil)
+	mockStore.On("GetLogsForRun", mock.Anything, run.ID, runLogLimit).Return(logs, nil)
+
+	rr := httptest.NewRecorder()
+	HandleGetRun(mockStore, zap.NewNop()).ServeHTTP(rr, newRunRequest(userID, run.ID.String()))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var got RunResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.Equal(t, domain.RunCompleted, got.Status)
+	assert.Equal(t, 1, got.Results.Success)
+	require.Len(t, got.Logs, 1)
+	assert.Equal(t, int64(9), got.Logs[0].ID)
+}
+
+func TestHandleGetRun_NotFound(t *testing.T) {
+	userID := uuid.New()
+	runID := uuid.New()
+	mockStore := &store.MockStore{}
+	// Ook een run van een andere gebruiker is niet gevonden
+	mockStore.On("GetProcessingRun", mock.Anything, runID, userID).
+		Return(domain.ProcessingRun{}, store.ErrProcessingRunNotFound)
+
+	rr := httptest.NewRecorder()
+	HandleGetRun(mockStore, zap.NewNop()).ServeHTTP(rr, newRunRequest(userID, runID.String()))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	mockStore.AssertNotCalled(t, "GetLogsForRun", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleGetRun_InvalidID(t *testing.T) {
+	rr := httptest.NewRecorder()
+	HandleGetRun(&store.MockStore{}, zap.NewNop()).ServeHTTP(rr, newRunRequest(uuid.New(), "not-a-uuid"))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
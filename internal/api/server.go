@@ -13,9 +13,15 @@ import (
 	"agenda-automator-api/internal/api/common"
 	"agenda-automator-api/internal/api/gmail"
 	"agenda-automator-api/internal/api/health"
+	"agenda-automator-api/internal/api/hook"
 	"agenda-automator-api/internal/api/log"
+	"agenda-automator-api/internal/api/notification"
 	"agenda-automator-api/internal/api/rule"
+	"agenda-automator-api/internal/api/rulebundle"
+	"agenda-automator-api/internal/api/run"
+	"agenda-automator-api/internal/api/template"
 	"agenda-automator-api/internal/api/user"
+	"agenda-automator-api/internal/email"
 	"agenda-automator-api/internal/store"
 
 	"github.com/go-chi/chi/v5" // <-- HIER ZAT DE TYPO
@@ -29,19 +35,40 @@ import (
 // VERWIJDERD: De helper GetUserIDFromContext stond al in common.go
 // (Je kunt deze laten staan als je wilt, maar het is dubbel)
 
+// Worker is het deel van de achtergrond-worker dat de API direct aanroept.
+type Worker interface {
+	calendar.SyncEnqueuer
+	hook.Enqueuer
+	run.Enqueuer
+}
+
 type Server struct {
 	Router            *chi.Mux
 	Store             store.Storer
 	Logger            *zap.Logger
 	GoogleOAuthConfig *oauth2.Config
+	CalendarSync      calendar.SyncEnqueuer
+	Hooks             hook.Enqueuer
+	Runs              run.Enqueuer
+	// Mailer verstuurt systeemmail, zoals verificatiecodes voor doorstuuradressen
+	Mailer email.Mailer
 }
 
-func NewServer(s store.Storer, logger *zap.Logger, oauthConfig *oauth2.Config) *Server {
+func NewServer(
+	s store.Storer,
+	logger *zap.Logger,
+	oauthConfig *oauth2.Config,
+	worker Worker,
+) *Server {
 	server := &Server{
 		Router:            chi.NewRouter(),
 		Store:             s,
 		Logger:            logger,
 		GoogleOAuthConfig: oauthConfig,
+		CalendarSync:      worker,
+		Hooks:             worker,
+		Runs:              worker,
+		Mailer:            email.NewMailerFromEnv(),
 	}
 
 	server.setupMiddleware()
@@ -73,6 +100,12 @@ func (s *Server) setupRoutes() {
 		r.Get("/auth/google/login", auth.HandleGoogleLogin(s.GoogleOAuthConfig, s.Logger))
 		r.Get("/auth/google/callback", auth.HandleGoogleCallback(s.Store, s.GoogleOAuthConfig, s.Logger))
 
+		// Google push notificaties (unprotected, gevalideerd via het channel token)
+		r.Post("/webhooks/google/calendar", calendar.HandleCalendarNotification(s.Store, s.CalendarSync, s.Logger))
+
+		// Inbound hooks (unprotected, het token in de URL is de authenticatie)
+		r.Post("/hooks/{token}", hook.HandleReceiveHook(s.Store, s.Hooks, s.Logger))
+
 		// Protected routes
 		r.Group(func(r chi.Router) {
 			r.Use(s.authMiddleware)
@@ -81,44 +114,125 @@ func (s *Server) setupRoutes() {
 			// AANGEPAST: Logger wordt nu correct doorgegeven
 			r.Get("/me", user.HandleGetMe(s.Store, s.Logger))
 			r.Get("/users/me", user.HandleGetMe(s.Store, s.Logger))
+			r.Post("/users/me/rules", rule.HandleCreateUserRule(s.Store, s.Logger))
+			r.Get("/users/me/rules", rule.HandleGetUserRules(s.Store, s.Logger))
+			r.Put("/users/me/rules/{ruleId}/accounts", rule.HandleUpdateUserRuleAccounts(s.Store, s.Logger))
+			r.Post("/users/me/gmail/rules", gmail.HandleCreateUserGmailRule(s.Store, s.Logger))
+			r.Get("/users/me/gmail/rules", gmail.HandleGetUserGmailRules(s.Store, s.Logger))
+			r.Put(
+				"/users/me/gmail/rules/{ruleId}/accounts",
+				gmail.HandleUpdateUserGmailRuleAccounts(s.Store, s.Logger),
+			)
+			r.Get("/users/me/rules/export", rulebundle.HandleExportRules(s.Store, s.Logger))
+			r.Post("/users/me/rules/import", rulebundle.HandleImportRules(s.Store, s.Logger))
+			r.Get("/users/me/notifications", notification.HandleGetNotifications(s.Store, s.Logger))
+			r.Put(
+				"/users/me/notifications/{notificationId}/read",
+				notification.HandleMarkNotificationRead(s.Store, s.Logger),
+			)
+			r.Post("/users/me/hooks", hook.HandleCreateHook(s.Store, s.Logger))
+			r.Get("/users/me/hooks", hook.HandleGetHooks(s.Store, s.Logger))
+			r.Delete("/users/me/hooks/{hookId}", hook.HandleRevokeHook(s.Store, s.Logger))
+			r.Get("/users/me/hooks/{hookId}/deliveries", hook.HandleGetHookDeliveries(s.Store, s.Logger))
+			r.Get("/users/me/webhook-secret", user.HandleGetWebhookSecret(s.Store, s.Logger))
+			r.Post("/users/me/webhook-secret/rotate", user.HandleRotateWebhookSecret(s.Store, s.Logger))
+			r.Get("/rule-templates", template.HandleGetRuleTemplates(s.Logger))
 
 			// Account routes
 			// AANGEPAST: Doorgeven s.Logger
 			r.Get("/accounts", account.HandleGetConnectedAccounts(s.Store, s.Logger))
-			r.Delete("/accounts/{accountId}", account.HandleDeleteConnectedAccount(s.Store, s.Logger))
 
-			// Rule routes
-			// AANGEPAST: Doorgeven s.Logger
-			r.Post("/accounts/{accountId}/rules", rule.HandleCreateRule(s.Store, s.Logger))
-			r.Get("/accounts/{accountId}/rules", rule.HandleGetRules(s.Store, s.Logger))
+			// Alle routes onder /accounts/{accountId} controleren eerst of het account van de gebruiker is;
+			// handlers lezen het account daarna uit de context.
+			r.Route("/accounts/{accountId}", func(r chi.Router) {
+				r.Use(common.AccountOwnershipMiddleware(s.Store, s.Logger))
+
+				r.Delete("/", account.HandleDeleteConnectedAccount(s.Store, s.Logger))
+				r.Post("/sync", run.HandleSyncAccount(s.Store, s.Runs, s.Logger))
+
+				// Rule routes
+				r.Post("/rules", rule.HandleCreateRule(s.Store, s.Logger))
+				r.Get("/rules", rule.HandleGetRules(s.Store, s.Logger))
+				r.Post("/rules/simulate", rule.HandleSimulateAdHocRule(s.Store, s.Logger))
+				r.Post("/rules/from-template", template.HandleCreateRuleFromTemplate(s.Store, s.Logger))
+				r.Post("/rules/{ruleId}/simulate", rule.HandleSimulateRule(s.Store, s.Logger))
+
+				// Log routes
+				r.Get("/logs", log.HandleGetAutomationLogs(s.Store, s.Logger))
+
+				// Calendar routes
+				r.Get("/calendars", calendar.HandleListCalendars(s.Store, s.Logger))
+				r.Get("/calendar/events", calendar.HandleGetCalendarEvents(s.Store, s.Logger))
+				r.Post("/calendar/events", calendar.HandleCreateEvent(s.Store, s.Logger))
+				r.Put("/calendar/events/{eventId}", calendar.HandleUpdateEvent(s.Store, s.Logger))
+				r.Delete("/calendar/events/{eventId}", calendar.HandleDeleteEvent(s.Store, s.Logger))
+
+				// Gmail routes
+				r.Get("/gmail/messages", gmail.HandleGetGmailMessages(s.Store, s.Logger))
+				r.Post("/gmail/messages/batch", gmail.HandleBatchGmailMessages(s.Store, s.Logger))
+				r.Post("/gmail/send", gmail.HandleSendGmailMessage(s.Store, s.Logger))
+				r.Post("/gmail/messages/{messageId}/reply", gmail.HandleReplyGmailMessage(s.Store, s.Logger))
+				r.Post("/gmail/messages/{messageId}/reply-all", gmail.HandleReplyAllGmailMessage(s.Store, s.Logger))
+				r.Post("/gmail/messages/{messageId}/forward", gmail.HandleForwardGmailMessage(s.Store, s.Logger))
+				r.Get("/gmail/labels", gmail.HandleGetGmailLabels(s.Store, s.Logger))
+				r.Post("/gmail/drafts", gmail.HandleCreateGmailDraft(s.Store, s.Logger))
+				r.Get("/gmail/drafts", gmail.HandleGetGmailDrafts(s.Store, s.Logger))
+				r.Get("/gmail/drafts/{draftId}", gmail.HandleGetGmailDraft(s.Store, s.Logger))
+				r.Put("/gmail/drafts/{draftId}", gmail.HandleUpdateGmailDraft(s.Store, s.Logger))
+				r.Delete("/gmail/drafts/{draftId}", gmail.HandleDeleteGmailDraft(s.Store, s.Logger))
+				r.Post("/gmail/drafts/{draftId}/send", gmail.HandleSendGmailDraft(s.Store, s.Logger))
+				r.Post("/gmail/messages/{messageId}/snooze", gmail.HandleSnoozeGmailMessage(s.Store, s.Logger))
+				r.Get("/gmail/snoozes", gmail.HandleGetGmailSnoozes(s.Store, s.Logger))
+				r.Delete("/gmail/snoozes/{snoozeId}", gmail.HandleCancelGmailSnooze(s.Store, s.Logger))
+				r.Get("/gmail/scheduled", gmail.HandleGetGmailScheduledSends(s.Store, s.Logger))
+				r.Put("/gmail/scheduled/{scheduledId}", gmail.HandleUpdateGmailScheduledSend(s.Store, s.Logger))
+				r.Delete("/gmail/scheduled/{scheduledId}", gmail.HandleCancelGmailScheduledSend(s.Store, s.Logger))
+				r.Get("/gmail/newsletters", gmail.HandleGetGmailNewsletterReport(s.Store, s.Logger))
+				r.Post("/gmail/messages/{messageId}/unsubscribe", gmail.HandleUnsubscribeGmailMessage(s.Store, s.Logger))
+
+				// Gmail automation rules
+				r.Post("/gmail/rules", gmail.HandleCreateGmailRule(s.Store, s.Logger))
+				r.Get("/gmail/rules", gmail.HandleGetGmailRules(s.Store, s.Logger))
+				r.Put("/gmail/rules/order", gmail.HandleReorderGmailRules(s.Store, s.Logger))
+				r.Post("/gmail/rules/simulate", gmail.HandleSimulateAdHocGmailRule(s.Store, s.Logger))
+				r.Post("/gmail/rules/{ruleId}/simulate", gmail.HandleSimulateGmailRule(s.Store, s.Logger))
+			})
+
+			// Rule routes (ownership via de rule)
 			r.Put("/rules/{ruleId}", rule.HandleUpdateRule(s.Store, s.Logger))
 			r.Delete("/rules/{ruleId}", rule.HandleDeleteRule(s.Store, s.Logger))
 			r.Put("/rules/{ruleId}/toggle", rule.HandleToggleRule(s.Store, s.Logger))
+			r.Get("/rules/{ruleId}/history", rule.HandleGetRuleHistory(s.Store, s.Logger))
+			r.Post("/rules/{ruleId}/history/{version}/restore", rule.HandleRestoreRuleVersion(s.Store, s.Logger))
+			r.Post("/rules/{ruleId}/revert", rule.HandleRevertRule(s.Store, s.Logger))
+			r.Post("/rules/{ruleId}/run", rule.HandleRunRule(s.Store, s.Runs, s.Logger))
 
-			// Log routes
-			// AANGEPAST: Doorgeven s.Logger
-			r.Get("/accounts/{accountId}/logs", log.HandleGetAutomationLogs(s.Store, s.Logger))
-
-			// Calendar routes (waren al correct)
-			r.Get("/accounts/{accountId}/calendars", calendar.HandleListCalendars(s.Store, s.Logger))
-			r.Get("/accounts/{accountId}/calendar/events", calendar.HandleGetCalendarEvents(s.Store, s.Logger))
-			r.Post("/accounts/{accountId}/calendar/events", calendar.HandleCreateEvent(s.Store, s.Logger))
-			r.Put("/accounts/{accountId}/calendar/events/{eventId}", calendar.HandleUpdateEvent(s.Store, s.Logger))
-			r.Delete("/accounts/{accountId}/calendar/events/{eventId}", calendar.HandleDeleteEvent(s.Store, s.Logger))
-			r.Post("/calendar/aggregated-events", calendar.HandleGetAggregatedEvents(s.Store, s.Logger))
+			// Handmatige runs (ownership via de run)
+			r.Get("/runs/{runId}", run.HandleGetRun(s.Store, s.Logger))
 
-			// Gmail routes
-			// AANGEPAST: Doorgeven s.Logger
-			r.Get("/accounts/{accountId}/gmail/messages", gmail.HandleGetGmailMessages(s.Store, s.Logger))
-			r.Post("/accounts/{accountId}/gmail/send", gmail.HandleSendGmailMessage(s.Store, s.Logger))
-			r.Get("/accounts/{accountId}/gmail/labels", gmail.HandleGetGmailLabels(s.Store, s.Logger))
-			r.Post("/accounts/{accountId}/gmail/drafts", gmail.HandleCreateGmailDraft(s.Store, s.Logger))
-			r.Get("/accounts/{accountId}/gmail/drafts", gmail.HandleGetGmailDrafts(s.Store, s.Logger))
+			// Gmail rule routes (ownership via de rule)
+			r.Get("/gmail/rules/{ruleId}", gmail.HandleGetGmailRule(s.Store, s.Logger))
+			r.Put("/gmail/rules/{ruleId}", gmail.HandleUpdateGmailRule(s.Store, s.Logger))
+			r.Delete("/gmail/rules/{ruleId}", gmail.HandleDeleteGmailRule(s.Store, s.Logger))
+			r.Put("/gmail/rules/{ruleId}/toggle", gmail.HandleToggleGmailRule(s.Store, s.Logger))
+			r.Get("/gmail/rules/{ruleId}/history", gmail.HandleGetGmailRuleHistory(s.Store, s.Logger))
+			r.Post(
+				"/gmail/rules/{ruleId}/history/{version}/restore",
+				gmail.HandleRestoreGmailRuleVersion(s.Store, s.Logger),
+			)
+			r.Post("/gmail/rules/{ruleId}/revert", gmail.HandleRevertGmailRule(s.Store, s.Logger))
+			r.Post("/gmail/rules/{ruleId}/run", gmail.HandleRunGmailRule(s.Store, s.Runs, s.Logger))
 
-			// Gmail automation rules
-			// AANGEPAST: Doorgeven s.Logger
-			r.Post("/accounts/{accountId}/gmail/rules", gmail.HandleCreateGmailRule(s.Store, s.Logger))
-			r.Get("/accounts/{accountId}/gmail/rules", gmail.HandleGetGmailRules(s.Store, s.Logger))
+			r.Post("/calendar/aggregated-events", calendar.HandleGetAggregatedEvents(s.Store, s.Logger))
+
+			// Doorstuuradressen (allowlist voor forward regels) horen bij de gebruiker, niet bij een account
+			r.Get("/gmail/forwarding-addresses", gmail.HandleGetGmailForwardingAddresses(s.Store, s.Logger))
+			r.Post("/gmail/forwarding-addresses", gmail.HandleAddGmailForwardingAddress(s.Store, s.Mailer, s.Logger))
+			r.Post(
+				"/gmail/forwarding-addresses/{addressId}/verify",
+				gmail.HandleVerifyGmailForwardingAddress(s.Store, s.Logger),
+			)
+			r.Delete("/gmail/forwarding-addresses/{addressId}", gmail.HandleDeleteGmailForwardingAddress(s.Store, s.Logger))
 		})
 	})
 }
@@ -2,17 +2,21 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
 
 	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/store"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
+	"golang.org/x/oauth2"
 )
 
 func TestAuthMiddleware(t *testing.T) {
@@ -150,6 +154,45 @@ func TestAuthMiddleware(t *testing.T) {
 	})
 }
 
+func TestAccountRoutes_RequireOwnership(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", "test-secret-key")
+
+	userID := uuid.New()
+	accountID := uuid.New()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": userID.String()})
+	tokenString, _ := token.SignedString([]byte("test-secret-key"))
+
+	mockStore := new(store.MockStore)
+	mockStore.On("VerifyAccountOwnership", mock.Anything, accountID, userID).
+		Return(fmt.Errorf("forbidden: account not found or does not belong to user"))
+	server := NewServer(mockStore, zap.NewNop(), &oauth2.Config{}, nil)
+
+	// Ook routes die direct een Google client maken (en vroeger geen check hadden) zijn beschermd
+	for _, route := range []struct{ method, path string }{
+		{"DELETE", "/api/v1/accounts/" + accountID.String()},
+		{"GET", "/api/v1/accounts/" + accountID.String() + "/rules"},
+		{"GET", "/api/v1/accounts/" + accountID.String() + "/calendar/events"},
+		{"DELETE", "/api/v1/accounts/" + accountID.String() + "/calendar/events/event-1"},
+		{"GET", "/api/v1/accounts/" + accountID.String() + "/gmail/messages"},
+		{"POST", "/api/v1/accounts/" + accountID.String() + "/gmail/send"},
+		{"GET", "/api/v1/accounts/" + accountID.String() + "/gmail/drafts"},
+		{"POST", "/api/v1/accounts/" + accountID.String() + "/hooks"},
+		{"POST", "/api/v1/accounts/" + accountID.String() + "/sync"},
+	} {
+		req := httptest.NewRequest(route.method, route.path, http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+tokenString)
+		w := httptest.NewRecorder()
+
+		server.Router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, route.method+" "+route.path)
+		assert.Contains(t, w.Body.String(), "Account niet gevonden", route.method+" "+route.path)
+	}
+
+	mockStore.AssertNotCalled(t, "GetValidTokenForAccount", mock.Anything, mock.Anything)
+	mockStore.AssertNotCalled(t, "DeleteConnectedAccount", mock.Anything, mock.Anything)
+}
+
 // Helper function to extract user ID from context for testing
 func getUserIDFromContextForTest(ctx context.Context) (uuid.UUID, error) {
 	userID, ok := ctx.Value(common.UserContextKey).(uuid.UUID)
@@ -0,0 +1,2 @@
+// Package template handles the rule template catalogue API endpoints.
+package template
@@ -0,0 +1,213 @@
+package template
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"agenda-automator-api/internal/api/common"
+	gmailapi "agenda-automator-api/internal/api/gmail"
+	ruleapi "agenda-automator-api/internal/api/rule"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/ruletemplate"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// FromTemplateRequest is de body van POST /accounts/{accountId}/rules/from-template.
+type FromTemplateRequest struct {
+	TemplateID string         `json:"template_id"`
+	Values     map[string]any `json:"values"`
+	Name       string         `json:"name,omitempty"`      // vervangt de naam uit de template
+	IsActive   *bool          `json:"is_active,omitempty"` // vervangt de actieve status uit de template
+	// Schedule beperkt wanneer de rule draait, bijv. een auto-reply alleen tijdens een vakantie
+	Schedule *domain.RuleSchedule `json:"schedule,omitempty"`
+}
+
+// FromTemplateResponse bevat de aangemaakte rule; Rule is een calendar of Gmail rule, afhankelijk van RuleType.
+type FromTemplateResponse struct {
+	TemplateID string          `json:"template_id"`
+	RuleType   domain.RuleType `json:"rule_type"`
+	Rule       any             `json:"rule"`
+}
+
+// HandleGetRuleTemplates geeft de catalogus met rule templates en hun variabelen.
+func HandleGetRuleTemplates(log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		common.WriteJSON(w, http.StatusOK, ruletemplate.Catalogue(), log)
+	}
+}
+
+// HandleCreateRuleFromTemplate vult een template met de waarden van de gebruiker en slaat het
+// resultaat op als calendar of Gmail rule, met dezelfde validatie als de gewone create endpoints.
+func HandleCreateRuleFromTemplate(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account, err := common.GetAccountFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusNotFound, "Account niet gevonden", log)
+			return
+		}
+
+		userID, err := common.GetUserIDFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
+			return
+		}
+
+		var req FromTemplateRequest
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.WriteJSONError(w, http.StatusBadRequest, "Ongeldige request body", log)
+			return
+		}
+
+		tmpl, ok := ruletemplate.Get(req.TemplateID)
+		if !ok {
+			common.WriteJSONError(w, http.StatusNotFound, "Template niet gevonden", log)
+			return
+		}
+
+		instance, err := tmpl.Instantiate(req.Values)
+		if err != nil {
+			var fieldErrs rules.FieldErrors
+			if errors.As(err, &fieldErrs) {
+				common.WriteValidationErrors(w, fieldErrs, log)
+				return
+			}
+			log.Error("HANDLER ERROR [Instantiate]", zap.Error(err), zap.String("template_id", tmpl.ID))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon template niet invullen", log)
+			return
+		}
+		if req.Name != "" {
+			instance.Name = req.Name
+		}
+		if req.IsActive != nil {
+			instance.IsActive = *req.IsActive
+		}
+
+		switch tmpl.RuleType {
+		case domain.RuleTypeCalendar:
+			createCalendarRule(w, r, storer, account.ID, userID, tmpl, instance, req.Schedule, log)
+		case domain.RuleTypeGmail:
+			createGmailRule(w, r, storer, account.ID, userID, tmpl, instance, req.Schedule, log)
+		}
+	}
+}
+
+// createCalendarRule valideert en bewaart een ingevulde calendar template.
+func createCalendarRule(
+	w http.ResponseWriter,
+	r *http.Request,
+	storer store.Storer,
+	accountID, userID uuid.UUID,
+	tmpl ruletemplate.Template,
+	instance ruletemplate.Rule,
+	schedule *domain.RuleSchedule,
+	log *zap.Logger,
+) {
+	rule := domain.AutomationRule{BaseAutomationRule: domain.BaseAutomationRule{
+		AccountEntity:     domain.AccountEntity{ConnectedAccountID: accountID},
+		Name:              instance.Name,
+		IsActive:          instance.IsActive,
+		TriggerConditions: instance.TriggerConditions,
+		ActionParams:      instance.ActionParams,
+		Schedule:          schedule,
+	}}
+	if fieldErrs := ruleapi.ValidateRule(rule); len(fieldErrs) > 0 {
+		common.WriteValidationErrors(w, fieldErrs.Prefixed("rule"), log)
+		return
+	}
+
+	created, err := storer.CreateAutomationRule(r.Context(), store.CreateAutomationRuleParams{
+		ConnectedAccountID: accountID,
+		Name:               rule.Name,
+		TriggerType:        rule.Trigger(),
+		TriggerConditions:  rule.TriggerConditions,
+		ActionType:         rule.Action(),
+		ActionParams:       rule.ActionParams,
+		Schedule:           rule.Schedule,
+		CreatedBy:          userID,
+	})
+	if err != nil {
+		log.Error("HANDLER ERROR [CreateAutomationRule]", zap.Error(err), zap.String("template_id", tmpl.ID))
+		common.WriteJSONError(w, http.StatusInternalServerError, "Kon regel niet aanmaken", log)
+		return
+	}
+	// Een nieuwe calendar rule is altijd actief; zet hem uit als de aanvraag dat vraagt
+	if !rule.IsActive && created.IsActive {
+		created, err = storer.ToggleRuleStatus(r.Context(), created.ID, userID)
+		if err != nil {
+			log.Error("HANDLER ERROR [ToggleRuleStatus]", zap.Error(err), zap.String("template_id", tmpl.ID))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon regel niet deactiveren", log)
+			return
+		}
+	}
+
+	common.WriteJSON(w, http.StatusCreated, FromTemplateResponse{
+		TemplateID: tmpl.ID,
+		RuleType:   domain.RuleTypeCalendar,
+		Rule:       created,
+	}, log)
+}
+
+// createGmailRule valideert (inclusief labels) en bewaart een ingevulde Gmail template.
+func createGmailRule(
+	w http.ResponseWriter,
+	r *http.Request,
+	storer store.Storer,
+	accountID, userID uuid.UUID,
+	tmpl ruletemplate.Template,
+	instance ruletemplate.Rule,
+	schedule *domain.RuleSchedule,
+	log *zap.Logger,
+) {
+	rule := domain.GmailAutomationRule{
+		BaseAutomationRule: domain.BaseAutomationRule{
+			AccountEntity:     domain.AccountEntity{ConnectedAccountID: accountID},
+			Name:              instance.Name,
+			IsActive:          instance.IsActive,
+			TriggerConditions: instance.TriggerConditions,
+			ActionParams:      instance.ActionParams,
+			Schedule:          schedule,
+		},
+		TriggerType: domain.GmailRuleTriggerType(instance.TriggerType),
+		ActionType:  domain.GmailRuleActionType(instance.ActionType),
+		Priority:    instance.Priority,
+	}
+	fieldErrs, err := gmailapi.ValidateGmailRule(r.Context(), storer, accountID, rule, log)
+	if err != nil {
+		log.Error("HANDLER ERROR [ValidateGmailRule]", zap.Error(err), zap.String("template_id", tmpl.ID))
+		common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail labels niet ophalen", log)
+		return
+	}
+	if len(fieldErrs) > 0 {
+		common.WriteValidationErrors(w, fieldErrs.Prefixed("rule"), log)
+		return
+	}
+
+	created, err := storer.CreateGmailAutomationRule(r.Context(), store.CreateGmailAutomationRuleParams{
+		ConnectedAccountID: accountID,
+		Name:               rule.Name,
+		IsActive:           rule.IsActive,
+		TriggerType:        rule.TriggerType,
+		TriggerConditions:  rule.TriggerConditions,
+		ActionType:         rule.ActionType,
+		ActionParams:       rule.ActionParams,
+		Priority:           rule.Priority,
+		Schedule:           rule.Schedule,
+		CreatedBy:          userID,
+	})
+	if err != nil {
+		log.Error("HANDLER ERROR [CreateGmailAutomationRule]", zap.Error(err), zap.String("template_id", tmpl.ID))
+		common.WriteJSONError(w, http.StatusInternalServerError, "Kon Gmail rule niet creren", log)
+		return
+	}
+
+	common.WriteJSON(w, http.StatusCreated, FromTemplateResponse{
+		TemplateID: tmpl.ID,
+		RuleType:   domain.RuleTypeGmail,
+		Rule:       created,
+	}, log)
+}
@@ -0,0 +1,149 @@
+package template
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/ruletemplate"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newAccountRequest(body string, accountID, userID uuid.UUID) *http.Request {
+	req := httptest.NewRequest("POST", "/", bytes.NewBufferString(body))
+	ctx := context.WithValue(req.Context(), common.UserContextKey, userID)
+	ctx = common.WithAccount(ctx, domain.ConnectedAccount{ID: accountID, UserID: userID})
+	return req.WithContext(ctx)
+}
+
+func TestHandleGetRuleTemplates(t *testing.T) {
+	rr := httptest.NewRecorder()
+	HandleGetRuleTemplates(zap.NewNop()).ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var templates []ruletemplate.Template
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &templates))
+	assert.Len(t, templates, len(ruletemplate.Catalogue()))
+}
+
+func TestHandleCreateRuleFromTemplate(t *testing.T) {
+	accountID := uuid.New()
+	userID := uuid.New()
+
+	t.Run("calendar", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		mockStore.On("CreateAutomationRule", mock.Anything, mock.MatchedBy(func(p store.CreateAutomationRuleParams) bool {
+			return p.ConnectedAccountID == accountID && p.CreatedBy == userID && p.Name == "Tandarts" &&
+				string(p.TriggerConditions) == `{"summary_contains":["Tandarts"]}`
+		})).Return(domain.AutomationRule{BaseAutomationRule: domain.BaseAutomationRule{Name: "Tandarts", IsActive: true}}, nil)
+
+		body := `{"template_id":"calendar-reminder-day-before","values":{"keywords":["Tandarts"]},"name":"Tandarts"}`
+		rr := httptest.NewRecorder()
+		HandleCreateRuleFromTemplate(mockStore, zap.NewNop()).ServeHTTP(rr, newAccountRequest(body, accountID, userID))
+
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var resp struct {
+			TemplateID string                `json:"template_id"`
+			RuleType   domain.RuleType       `json:"rule_type"`
+			Rule       domain.AutomationRule `json:"rule"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, domain.RuleTypeCalendar, resp.RuleType)
+		assert.Equal(t, "Tandarts", resp.Rule.Name)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("calendar inactive", func(t *testing.T) {
+		created := domain.AutomationRule{BaseAutomationRule: domain.BaseAutomationRule{
+			AccountEntity: domain.AccountEntity{BaseEntity: domain.BaseEntity{ID: uuid.New()}},
+			IsActive:      true,
+		}}
+		toggled := created
+		toggled.IsActive = false
+
+		mockStore := &store.MockStore{}
+		mockStore.On("CreateAutomationRule", mock.Anything, mock.Anything).Return(created, nil)
+		mockStore.On("ToggleRuleStatus", mock.Anything, created.ID, userID).Return(toggled, nil)
+
+		body := `{"template_id":"calendar-leave-reminder","values":{"summary":"Dienst"},"is_active":false}`
+		rr := httptest.NewRecorder()
+		HandleCreateRuleFromTemplate(mockStore, zap.NewNop()).ServeHTTP(rr, newAccountRequest(body, accountID, userID))
+
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("gmail", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		mockStore.On("CreateGmailAutomationRule", mock.Anything, mock.MatchedBy(func(p store.CreateGmailAutomationRuleParams) bool {
+			return p.ConnectedAccountID == accountID && p.IsActive &&
+				p.TriggerType == domain.GmailTriggerSenderMatch && p.ActionType == domain.GmailActionAddLabel &&
+				string(p.ActionParams) == `{"archive":true,"label_name":"Nieuwsbrieven"}`
+		})).Return(domain.GmailAutomationRule{}, nil)
+
+		body := `{"template_id":"gmail-newsletters-label-archive","values":{"sender_pattern":"newsletter@"}}`
+		rr := httptest.NewRecorder()
+		HandleCreateRuleFromTemplate(mockStore, zap.NewNop()).ServeHTTP(rr, newAccountRequest(body, accountID, userID))
+
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		assert.Contains(t, rr.Body.String(), `"rule_type":"gmail"`)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("gmail with schedule", func(t *testing.T) {
+		mockStore := &store.MockStore{}
+		mockStore.On("CreateGmailAutomationRule", mock.Anything, mock.MatchedBy(func(p store.CreateGmailAutomationRuleParams) bool {
+			return p.Schedule != nil && p.Schedule.ValidUntil != nil && p.Schedule.ValidUntil.Format("2006-01-02") == "2026-08-15"
+		})).Return(domain.GmailAutomationRule{}, nil)
+
+		body := `{"template_id":"gmail-newsletters-label-archive","values":{"sender_pattern":"newsletter@"},` +
+			`"schedule":{"valid_from":"2026-08-01T00:00:00Z","valid_until":"2026-08-15T00:00:00Z"}}`
+		rr := httptest.NewRecorder()
+		HandleCreateRuleFromTemplate(mockStore, zap.NewNop()).ServeHTTP(rr, newAccountRequest(body, accountID, userID))
+
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("invalid schedule", func(t *testing.T) {
+		body := `{"template_id":"calendar-leave-reminder","values":{"summary":"Dienst"},"schedule":{"max_executions":-1}}`
+		rr := httptest.NewRecorder()
+		HandleCreateRuleFromTemplate(&store.MockStore{}, zap.NewNop()).ServeHTTP(rr, newAccountRequest(body, accountID, userID))
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Contains(t, rr.Body.String(), "rule.schedule.max_executions")
+	})
+
+	t.Run("unknown template", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		HandleCreateRuleFromTemplate(&store.MockStore{}, zap.NewNop()).
+			ServeHTTP(rr, newAccountRequest(`{"template_id":"bestaat-niet"}`, accountID, userID))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("missing variable", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		HandleCreateRuleFromTemplate(&store.MockStore{}, zap.NewNop()).
+			ServeHTTP(rr, newAccountRequest(`{"template_id":"calendar-leave-reminder","values":{}}`, accountID, userID))
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Contains(t, rr.Body.String(), "values.summary")
+	})
+
+	t.Run("invalid rule", func(t *testing.T) {
+		body := `{"template_id":"calendar-leave-reminder","values":{"summary":"Dienst","offset_minutes":99999}}`
+		rr := httptest.NewRecorder()
+		HandleCreateRuleFromTemplate(&store.MockStore{}, zap.NewNop()).ServeHTTP(rr, newAccountRequest(body, accountID, userID))
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Contains(t, rr.Body.String(), "rule.action_params.offset_minutes")
+	})
+}
@@ -0,0 +1,65 @@
+package user
+
+import (
+	"net/http"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/store"
+	"agenda-automator-api/internal/webhook"
+
+	"go.uber.org/zap"
+)
+
+// HandleGetWebhookSecret geeft het geheim waarmee uitgaande webhooks van de gebruiker ondertekend
+// worden. Bestaat het nog niet, dan wordt het nu aangemaakt.
+func HandleGetWebhookSecret(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := common.GetUserIDFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
+			return
+		}
+
+		candidate, err := webhook.GenerateSecret()
+		if err != nil {
+			log.Error("HANDLER ERROR [GenerateSecret]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon webhook geheim niet aanmaken", log)
+			return
+		}
+		secret, err := storer.EnsureWebhookSecret(r.Context(), userID, candidate)
+		if err != nil {
+			log.Error("HANDLER ERROR [EnsureWebhookSecret]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon webhook geheim niet ophalen", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, secret, log)
+	}
+}
+
+// HandleRotateWebhookSecret vervangt het webhook geheim. Webhooks die daarna verstuurd worden,
+// zijn alleen met het nieuwe geheim te controleren.
+func HandleRotateWebhookSecret(storer store.Storer, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := common.GetUserIDFromContext(r.Context())
+		if err != nil {
+			common.WriteJSONError(w, http.StatusUnauthorized, err.Error(), log)
+			return
+		}
+
+		generated, err := webhook.GenerateSecret()
+		if err != nil {
+			log.Error("HANDLER ERROR [GenerateSecret]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon webhook geheim niet aanmaken", log)
+			return
+		}
+		secret, err := storer.RotateWebhookSecret(r.Context(), userID, generated)
+		if err != nil {
+			log.Error("HANDLER ERROR [RotateWebhookSecret]", zap.Error(err))
+			common.WriteJSONError(w, http.StatusInternalServerError, "Kon webhook geheim niet vervangen", log)
+			return
+		}
+
+		common.WriteJSON(w, http.StatusOK, secret, log)
+	}
+}
@@ -0,0 +1,73 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"agenda-automator-api/internal/api/common"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func webhookSecretRequest(method string, userID uuid.UUID) *http.Request {
+	req := httptest.NewRequest(method, "/api/v1/users/me/webhook-secret", http.NoBody)
+	return req.WithContext(context.WithValue(req.Context(), common.UserContextKey, userID))
+}
+
+func TestHandleGetWebhookSecret(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	candidate := mock.MatchedBy(func(secret string) bool { return strings.HasPrefix(secret, "whsec_") })
+	mockStore.On("EnsureWebhookSecret", mock.Anything, userID, candidate).
+		Return(domain.WebhookSecret{UserID: userID, Secret: "whsec_bestaand"}, nil).Once()
+
+	rr := httptest.NewRecorder()
+	HandleGetWebhookSecret(mockStore, zap.NewNop()).ServeHTTP(rr, webhookSecretRequest("GET", userID))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var response domain.WebhookSecret
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "whsec_bestaand", response.Secret)
+	mockStore.AssertExpectations(t)
+}
+
+func TestHandleRotateWebhookSecret(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	var rotated string
+	mockStore.On("RotateWebhookSecret", mock.Anything, userID, mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { rotated = args.String(2) }).
+		Return(domain.WebhookSecret{UserID: userID, Secret: "whsec_nieuw"}, nil).Once()
+
+	rr := httptest.NewRecorder()
+	HandleRotateWebhookSecret(mockStore, zap.NewNop()).ServeHTTP(rr, webhookSecretRequest("POST", userID))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var response domain.WebhookSecret
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.True(t, strings.HasPrefix(rotated, "whsec_"))
+	assert.Equal(t, "whsec_nieuw", response.Secret)
+	mockStore.AssertExpectations(t)
+}
+
+func TestHandleRotateWebhookSecret_StoreError(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	mockStore.On("RotateWebhookSecret", mock.Anything, userID, mock.AnythingOfType("string")).
+		Return(domain.WebhookSecret{}, assert.AnError).Once()
+
+	rr := httptest.NewRecorder()
+	HandleRotateWebhookSecret(mockStore, zap.NewNop()).ServeHTTP(rr, webhookSecretRequest("POST", userID))
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
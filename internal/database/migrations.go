@@ -30,6 +30,25 @@ func RunMigrations(ctx context.Context, db Querier, log *zap.Logger) error {
 		{"table optimizations", migrations.TableOptimizationsUp},
 		{"calendar optimizations", migrations.CalendarOptimizationsUp},
 		{"connected accounts optimization", migrations.ConnectedAccountsOptimizationUp},
+		{"calendar watch channels", migrations.CalendarWatchChannelsUp},
+		{"Gmail snoozes", migrations.GmailSnoozesUp},
+		{"Gmail scheduled sends", migrations.GmailScheduledSendsUp},
+		{"Gmail List-Unsubscribe", migrations.GmailListUnsubscribeUp},
+		{"Gmail forwarding addresses", migrations.GmailForwardingAddressesUp},
+		{"rule types as text", migrations.RuleTypesTextUp},
+		{"rule versions", migrations.RuleVersionsUp},
+		{"rule schedules", migrations.RuleSchedulesUp},
+		{"rule rate limits", migrations.RuleRateLimitsUp},
+		{"log reverts", migrations.LogRevertsUp},
+		{"calendar rule actions", migrations.CalendarRuleActionsUp},
+		{"webhook secrets", migrations.WebhookSecretsUp},
+		{"inbound hooks", migrations.InboundHooksUp},
+		{"user rules", migrations.UserRulesUp},
+		{"processing runs", migrations.ProcessingRunsUp},
+		{"rule account executions", migrations.RuleAccountExecutionsUp},
+		{"forwarding verification attempts", migrations.ForwardingVerificationAttemptsUp},
+		{"run logs", migrations.RunLogsUp},
+		{"hook rules", migrations.HookRulesUp},
 	}
 
 	for _, step := range migrationSteps {
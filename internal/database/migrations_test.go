@@ -48,6 +48,30 @@ func TestRunMigrations_Success(t *testing.T) {
 		migrations.ConnectedAccountsOptimizationUp,
 		mock.Anything,
 	).Return(pgconn.CommandTag{}, nil).Once()
+	mockDB.On("Exec", ctx, migrations.CalendarWatchChannelsUp, mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	mockDB.On("Exec", ctx, migrations.GmailSnoozesUp, mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	mockDB.On("Exec", ctx, migrations.GmailScheduledSendsUp, mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	mockDB.On("Exec", ctx, migrations.GmailListUnsubscribeUp, mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	mockDB.On(
+		"Exec",
+		ctx,
+		migrations.GmailForwardingAddressesUp,
+		mock.Anything,
+	).Return(pgconn.CommandTag{}, nil).Once()
+	mockDB.On("Exec", ctx, migrations.RuleTypesTextUp, mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	mockDB.On("Exec", ctx, migrations.RuleVersionsUp, mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	mockDB.On("Exec", ctx, migrations.RuleSchedulesUp, mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	mockDB.On("Exec", ctx, migrations.RuleRateLimitsUp, mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	mockDB.On("Exec", ctx, migrations.LogRevertsUp, mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	mockDB.On("Exec", ctx, migrations.CalendarRuleActionsUp, mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	mockDB.On("Exec", ctx, migrations.WebhookSecretsUp, mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	mockDB.On("Exec", ctx, migrations.InboundHooksUp, mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	mockDB.On("Exec", ctx, migrations.UserRulesUp, mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	mockDB.On("Exec", ctx, migrations.ProcessingRunsUp, mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	mockDB.On("Exec", ctx, migrations.RuleAccountExecutionsUp, mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	mockDB.On("Exec", ctx, migrations.ForwardingVerificationAttemptsUp, mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	mockDB.On("Exec", ctx, migrations.RunLogsUp, mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	mockDB.On("Exec", ctx, migrations.HookRulesUp, mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
 
 	// Act
 	err := RunMigrations(ctx, mockDB, log)
@@ -94,3 +118,17 @@ func TestRunMigrations_Fail(t *testing.T) {
 	assert.Equal(t, dbError, err)
 	mockDB.AssertExpectations(t)
 }
+
+// Alle migraties draaien bij elke start; 000012 zet de Gmail rule types om naar text en
+// verwijdert de enums, dus geen andere migratie mag ze opnieuw aanmaken of uitbreiden.
+func TestMigrations_RuleTypeEnumsNotReplayed(t *testing.T) {
+	for name, query := range map[string]string{
+		"Gmail schema":           migrations.GmailSchemaUp,
+		"Gmail snoozes":          migrations.GmailSnoozesUp,
+		"Gmail scheduled sends":  migrations.GmailScheduledSendsUp,
+		"Gmail List-Unsubscribe": migrations.GmailListUnsubscribeUp,
+	} {
+		assert.NotContains(t, query, "gmail_rule_trigger_type", name)
+		assert.NotContains(t, query, "gmail_rule_action_type", name)
+	}
+}
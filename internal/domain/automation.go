@@ -1 +1,104 @@
 package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RuleType geeft aan in welke tabel de rule van een RuleVersion staat.
+type RuleType string
+
+const (
+	RuleTypeCalendar RuleType = "calendar"
+	RuleTypeGmail    RuleType = "gmail"
+)
+
+// RuleChangeType beschrijft welke wijziging een RuleVersion opleverde.
+type RuleChangeType string
+
+const (
+	RuleChangeCreate  RuleChangeType = "create"
+	RuleChangeUpdate  RuleChangeType = "update"
+	RuleChangeToggle  RuleChangeType = "toggle"
+	RuleChangeRestore RuleChangeType = "restore"
+	RuleChangeExpire  RuleChangeType = "expire" // de worker zette een verlopen rule uit, zie RuleSchedule
+	RuleChangePause   RuleChangeType = "pause"  // de worker zette een rule uit die een uitvoeringslimiet overschreed
+)
+
+// RuleVersion is één versie uit de geschiedenis van een rule. Snapshot bevat de velden van de
+// rule na de wijziging, met dezelfde JSON namen als de rule zelf; Diff bevat per gewijzigd veld
+// de oude en nieuwe waarde.
+type RuleVersion struct {
+	ID           int64           `db:"id"            json:"id"`
+	RuleType     RuleType        `db:"rule_type"     json:"rule_type"`
+	RuleID       uuid.UUID       `db:"rule_id"       json:"rule_id"`
+	Version      int             `db:"version"       json:"version"`
+	ChangeType   RuleChangeType  `db:"change_type"   json:"change_type"`
+	ChangedBy    *uuid.UUID      `db:"changed_by"    json:"changed_by,omitempty"`
+	Snapshot     json.RawMessage `db:"snapshot"      json:"snapshot"`
+	Diff         json.RawMessage `db:"diff"          json:"diff,omitempty"`
+	RestoredFrom *int            `db:"restored_from" json:"restored_from,omitempty"`
+	CreatedAt    time.Time       `db:"created_at"    json:"created_at"`
+}
+
+// RuleSchedule beperkt wanneer een actieve rule draait. Alle velden zijn optioneel; een rule
+// zonder schedule draait altijd zolang hij actief is.
+type RuleSchedule struct {
+	// ValidFrom en ValidUntil begrenzen de periode; na ValidUntil wordt de rule uitgezet
+	ValidFrom  *time.Time `json:"valid_from,omitempty"  yaml:"valid_from,omitempty"`
+	ValidUntil *time.Time `json:"valid_until,omitempty" yaml:"valid_until,omitempty"`
+	// Timezone is de IANA naam waarin Weekdays en Windows gelden; verplicht zodra een van beide gezet is
+	Timezone string `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+	// Weekdays zijn de dagen waarop de rule draait, bijv. "mon"; leeg is elke dag
+	Weekdays []string `json:"weekdays,omitempty" yaml:"weekdays,omitempty"`
+	// Windows zijn de tijdvakken waarin de rule draait; leeg is de hele dag
+	Windows []TimeWindow `json:"windows,omitempty" yaml:"windows,omitempty"`
+	// MaxExecutions zet de rule uit na dit aantal geslaagde uitvoeringen; 0 is onbeperkt
+	MaxExecutions int `json:"max_executions,omitempty" yaml:"max_executions,omitempty"`
+	// MaxExecutionsPerHour vervangt voor deze rule RULE_MAX_EXECUTIONS_PER_HOUR; 0 gebruikt die standaard
+	MaxExecutionsPerHour int `json:"max_executions_per_hour,omitempty" yaml:"max_executions_per_hour,omitempty"`
+}
+
+// TimeWindow is een tijdvak binnen een dag, als "HH:MM". Een End voor Start loopt door na
+// middernacht, bijv. 18:00-08:00 voor buiten kantoortijd; de weekdag is die van het begin.
+type TimeWindow struct {
+	Start string `json:"start" yaml:"start"`
+	End   string `json:"end"   yaml:"end"`
+}
+
+// AccountSelectorMode bepaalt op welke accounts van de gebruiker een user-level rule draait.
+type AccountSelectorMode string
+
+const (
+	AccountSelectorAll      AccountSelectorMode = "all"
+	AccountSelectorAccounts AccountSelectorMode = "accounts" // alleen de accounts in AccountIDs
+	AccountSelectorProvider AccountSelectorMode = "provider" // alle accounts van Provider
+)
+
+// AccountSelector kiest de accounts waarvoor de worker een user-level rule uitvoert.
+type AccountSelector struct {
+	Mode       AccountSelectorMode `json:"mode"`
+	AccountIDs []uuid.UUID         `json:"account_ids,omitempty"`
+	Provider   ProviderType        `json:"provider,omitempty"`
+}
+
+// Matches geeft aan of acc door de selector gekozen wordt. Het account moet daarnaast van de
+// eigenaar van de rule zijn, zie BaseAutomationRule.AppliesTo.
+func (s AccountSelector) Matches(acc *ConnectedAccount) bool {
+	switch s.Mode {
+	case AccountSelectorAll:
+		return true
+	case AccountSelectorAccounts:
+		for _, id := range s.AccountIDs {
+			if id == acc.ID {
+				return true
+			}
+		}
+		return false
+	case AccountSelectorProvider:
+		return s.Provider == acc.Provider
+	}
+	return false
+}
@@ -1 +1,20 @@
 package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CalendarWatchChannel represents an active Google Calendar push notification channel
+type CalendarWatchChannel struct {
+	ID                 uuid.UUID `db:"id"                   json:"id"`
+	ConnectedAccountID uuid.UUID `db:"connected_account_id" json:"connected_account_id"`
+	CalendarID         string    `db:"calendar_id"          json:"calendar_id"`
+	ChannelID          string    `db:"channel_id"           json:"channel_id"`
+	ResourceID         string    `db:"resource_id"          json:"resource_id"`
+	Token              string    `db:"token"                json:"-"`
+	Expiration         time.Time `db:"expiration"           json:"expiration"`
+	CreatedAt          time.Time `db:"created_at"           json:"created_at"`
+	UpdatedAt          time.Time `db:"updated_at"           json:"updated_at"`
+}
@@ -52,6 +52,28 @@ type BaseAutomationRule struct {
 	IsActive          bool            `db:"is_active"         json:"is_active"`
 	TriggerConditions json.RawMessage `db:"trigger_conditions" json:"trigger_conditions"`
 	ActionParams      json.RawMessage `db:"action_params"     json:"action_params"`
+	Version           int             `db:"version"           json:"version"` // opgehoogd bij elke wijziging, zie RuleVersion
+	Schedule          *RuleSchedule   `db:"schedule"          json:"schedule,omitempty"`
+	ExecutionCount    int             `db:"execution_count"   json:"execution_count"` // geslaagde uitvoeringen sinds de rule aan staat
+	// UserID is gezet voor een user-level rule; die heeft geen connected_account_id maar draait
+	// op de accounts van de gebruiker die AccountSelector kiest
+	UserID          *uuid.UUID       `db:"user_id"          json:"user_id,omitempty"`
+	AccountSelector *AccountSelector `db:"account_selector" json:"account_selector,omitempty"`
+}
+
+// IsUserRule geeft aan of de rule van een gebruiker is in plaats van van één account.
+func (r BaseAutomationRule) IsUserRule() bool {
+	return r.UserID != nil
+}
+
+// AppliesTo geeft aan of de worker de rule voor acc moet uitvoeren: een account-rule alleen
+// voor zijn eigen account, een user-level rule voor de accounts van de gebruiker die de
+// selector kiest.
+func (r BaseAutomationRule) AppliesTo(acc *ConnectedAccount) bool {
+	if !r.IsUserRule() {
+		return r.ConnectedAccountID == acc.ID
+	}
+	return *r.UserID == acc.UserID && r.AccountSelector != nil && r.AccountSelector.Matches(acc)
 }
 
 type BaseAutomationLog struct {
@@ -1 +1,130 @@
 package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GmailSnoozedLabel is the Gmail label applied to snoozed messages
+const GmailSnoozedLabel = "Snoozed"
+
+// GmailSnoozeStatus represents the status of a snoozed message
+type GmailSnoozeStatus string
+
+const (
+	GmailSnoozePending   GmailSnoozeStatus = "snoozed"
+	GmailSnoozeWoken     GmailSnoozeStatus = "woken"
+	GmailSnoozeCancelled GmailSnoozeStatus = "cancelled"
+	GmailSnoozeFailed    GmailSnoozeStatus = "failed"
+)
+
+// GmailSnooze represents a message that is hidden from the inbox until WakeAt
+type GmailSnooze struct {
+	AccountEntity
+	GmailMessageID string            `db:"gmail_message_id"   json:"gmail_message_id"`
+	GmailThreadID  string            `db:"gmail_thread_id"    json:"gmail_thread_id"`
+	WakeAt         time.Time         `db:"wake_at"            json:"wake_at"`
+	Status         GmailSnoozeStatus `db:"status"             json:"status"`
+	ErrorMessage   *string           `db:"error_message"      json:"error_message,omitempty"`
+}
+
+// GmailOutgoingMessage is a composed message that has not been sent yet
+type GmailOutgoingMessage struct {
+	To       []string `json:"to"`
+	Cc       []string `json:"cc,omitempty"`
+	Bcc      []string `json:"bcc,omitempty"`
+	FromName string   `json:"fromName,omitempty"`
+	ReplyTo  []string `json:"replyTo,omitempty"`
+	Subject  string   `json:"subject"`
+	Body     string   `json:"body"`
+	IsHTML   bool     `json:"isHtml,omitempty"`
+	// TextBody is the plain text alternative of an HTML body
+	TextBody    string                 `json:"textBody,omitempty"`
+	Attachments []GmailAttachmentInput `json:"attachments,omitempty"`
+	ThreadID    string                 `json:"threadId,omitempty"`
+	InReplyTo   string                 `json:"inReplyTo,omitempty"`
+	References  string                 `json:"references,omitempty"`
+}
+
+// GmailDraftInput is the body of a draft create or update. KeepAttachmentIDs are
+// attachment IDs of the current draft (see GmailDraft.AttachmentIds) that carry over
+// to the new version, next to any new Attachments.
+type GmailDraftInput struct {
+	GmailOutgoingMessage
+	KeepAttachmentIDs []string `json:"keepAttachmentIds,omitempty"`
+}
+
+// GmailAttachmentInput is a file attached to an outgoing message. Data is base64 in JSON.
+// With a ContentID the attachment is an inline image, referenced in HTML as "cid:<ContentID>".
+type GmailAttachmentInput struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType,omitempty"`
+	Data        []byte `json:"data"`
+	ContentID   string `json:"contentId,omitempty"`
+}
+
+// GmailScheduledSendStatus represents the status of a scheduled send
+type GmailScheduledSendStatus string
+
+const (
+	GmailScheduledSendPending   GmailScheduledSendStatus = "scheduled"
+	GmailScheduledSendSent      GmailScheduledSendStatus = "sent"
+	GmailScheduledSendCancelled GmailScheduledSendStatus = "cancelled"
+	GmailScheduledSendFailed    GmailScheduledSendStatus = "failed"
+)
+
+// GmailScheduledSend represents an outgoing message queued until SendAt
+type GmailScheduledSend struct {
+	AccountEntity
+	RuleID         *uuid.UUID               `db:"rule_id"            json:"rule_id,omitempty"`
+	Message        GmailOutgoingMessage     `db:"payload"            json:"message"`
+	SendAt         time.Time                `db:"send_at"            json:"send_at"`
+	Status         GmailScheduledSendStatus `db:"status"             json:"status"`
+	Attempts       int                      `db:"attempts"           json:"attempts"`
+	LastError      *string                  `db:"last_error"         json:"last_error,omitempty"`
+	GmailMessageID *string                  `db:"gmail_message_id"   json:"gmail_message_id,omitempty"`
+}
+
+// GmailUnsubscribeStatus represents the result of an unsubscribe attempt
+type GmailUnsubscribeStatus string
+
+const (
+	GmailUnsubscribeSuccess GmailUnsubscribeStatus = "success"
+	GmailUnsubscribeFailed  GmailUnsubscribeStatus = "failed"
+)
+
+// GmailUnsubscribe records an attempt to unsubscribe from a mailing list
+type GmailUnsubscribe struct {
+	AccountEntity
+	GmailMessageID string                 `db:"gmail_message_id"   json:"gmail_message_id"`
+	Sender         *string                `db:"sender"             json:"sender,omitempty"`
+	Method         string                 `db:"method"             json:"method"`
+	Target         string                 `db:"target"             json:"target"`
+	Status         GmailUnsubscribeStatus `db:"status"             json:"status"`
+	ErrorMessage   *string                `db:"error_message"      json:"error_message,omitempty"`
+}
+
+// GmailNewsletterSender is a row of the newsletter report: a sender with mailing list volume
+type GmailNewsletterSender struct {
+	Sender          string    `json:"sender"`
+	MessageCount    int       `json:"message_count"`
+	UnreadCount     int       `json:"unread_count"`
+	LastReceivedAt  time.Time `json:"last_received_at"`
+	LatestMessageID string    `json:"latest_message_id"`
+	OneClick        bool      `json:"one_click"`
+	Unsubscribed    bool      `json:"unsubscribed"`
+}
+
+// GmailForwardingAddress is a destination a user allows forward rules to send to, once verified
+type GmailForwardingAddress struct {
+	BaseEntity
+	UserID             uuid.UUID  `db:"user_id"              json:"user_id"`
+	Email              string     `db:"email"                json:"email"`
+	VerificationSentAt *time.Time `db:"verification_sent_at" json:"verification_sent_at,omitempty"`
+	// VerificationAttempts counts the codes entered for the current verification code
+	VerificationAttempts int        `db:"verification_attempts" json:"verification_attempts"`
+	VerifiedAt           *time.Time `db:"verified_at"           json:"verified_at,omitempty"`
+	// VerificationCodeHash is the sha256 of the emailed code and never leaves the API
+	VerificationCodeHash *string `db:"verification_code_hash" json:"-"`
+}
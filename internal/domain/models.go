@@ -38,6 +38,24 @@ type ConnectedAccount struct {
 // AutomationRule represents an automation rule
 type AutomationRule struct {
 	BaseAutomationRule
+	TriggerType string `db:"trigger_type" json:"trigger_type"` // CalendarTriggerEventMatch if empty
+	ActionType  string `db:"action_type"  json:"action_type"`  // CalendarActionCreateReminder if empty
+}
+
+// Trigger returns the trigger type of the rule, CalendarTriggerEventMatch when none is set.
+func (r AutomationRule) Trigger() string {
+	if r.TriggerType == "" {
+		return CalendarTriggerEventMatch
+	}
+	return r.TriggerType
+}
+
+// Action returns the action type of the rule, CalendarActionCreateReminder when none is set.
+func (r AutomationRule) Action() string {
+	if r.ActionType == "" {
+		return CalendarActionCreateReminder
+	}
+	return r.ActionType
 }
 
 // AutomationLog represents a log entry for automation execution
@@ -45,18 +63,34 @@ type AutomationLog struct {
 	ID                 int64               `db:"id"                     json:"id"`
 	ConnectedAccountID uuid.UUID           `db:"connected_account_id"   json:"connected_account_id"`
 	RuleID             *uuid.UUID          `db:"rule_id"                json:"rule_id,omitempty"`
+	RuleVersion        *int                `db:"rule_version"           json:"rule_version,omitempty"`
 	Timestamp          time.Time           `db:"timestamp"              json:"timestamp"`
 	Status             AutomationLogStatus `db:"status"                 json:"status"`
 	TriggerDetails     json.RawMessage     `db:"trigger_details"        json:"trigger_details"`
 	ActionDetails      json.RawMessage     `db:"action_details"         json:"action_details"`
 	ErrorMessage       string              `db:"error_message"           json:"error_message"`
+	RevertedAt         *time.Time          `db:"reverted_at"            json:"reverted_at,omitempty"`
 }
 
+// Calendar rules hebben een trigger_type en een action_type kolom; de rules engine kent ze onder deze namen.
+// Rules met CalendarTriggerHookReceived draaien op leveringen aan een inbound hook in plaats van op events.
+const (
+	CalendarTriggerEventMatch    = "event_match"
+	CalendarTriggerHookReceived  = "hook_received"
+	CalendarActionCreateReminder = "create_reminder"
+	CalendarActionSendEmail      = "send_email" // stuurt via Gmail een e-mail over het event
+	CalendarActionWebhook        = "webhook"
+)
+
 // TriggerConditions represents conditions for triggering automation
 type TriggerConditions struct {
 	SummaryEquals    string   `json:"summary_equals,omitempty"`
 	SummaryContains  []string `json:"summary_contains,omitempty"`
 	LocationContains []string `json:"location_contains,omitempty"`
+	// StartsWithinHours only matches events that start within this many hours from now
+	StartsWithinHours int `json:"starts_within_hours,omitempty"`
+	// ExternalAttendees only matches events with an attendee outside the account's email domain
+	ExternalAttendees bool `json:"external_attendees,omitempty"`
 }
 
 // ActionParams represents parameters for automation actions
@@ -80,6 +114,23 @@ type ActionLogDetails struct {
 	ReminderTime        time.Time `json:"reminder_time"`
 }
 
+// GmailActionLogDetails represents details of a Gmail action execution. The label IDs are only
+// the labels the action actually changed, so a revert can put the message back as it was.
+type GmailActionLogDetails struct {
+	ActionType      GmailRuleActionType `json:"action_type"`
+	AddedLabelIDs   []string            `json:"added_label_ids,omitempty"`
+	RemovedLabelIDs []string            `json:"removed_label_ids,omitempty"`
+	CreatedEventID  string              `json:"created_event_id,omitempty"`
+	Reason          string              `json:"reason,omitempty"` // why a skipped action did nothing
+}
+
+// EmailActionLogDetails represents details of an email sent by a calendar rule.
+type EmailActionLogDetails struct {
+	ActionType    string   `json:"action_type"`
+	SentMessageID string   `json:"sent_message_id"`
+	To            []string `json:"to"`
+}
+
 // Event represents a calendar event
 type Event struct {
 	ID          string    `json:"id"`
@@ -126,6 +177,11 @@ const (
 	GmailActionTrash       GmailRuleActionType = "trash"
 	GmailActionStar        GmailRuleActionType = "star"
 	GmailActionUnstar      GmailRuleActionType = "unstar"
+	GmailActionSnooze      GmailRuleActionType = "snooze"
+	GmailActionSchedule    GmailRuleActionType = "schedule_send"
+	GmailActionUnsubscribe GmailRuleActionType = "unsubscribe"
+	GmailActionCreateEvent GmailRuleActionType = "create_calendar_event"
+	GmailActionWebhook     GmailRuleActionType = "webhook"
 )
 
 // GmailAutomationRule represents a Gmail automation rule
@@ -170,6 +226,10 @@ type GmailMessage struct {
 	ReceivedAt      time.Time          `db:"received_at"        json:"received_at"`
 	Labels          []string           `db:"labels"             json:"labels"`
 	LastSynced      time.Time          `db:"last_synced"        json:"last_synced"`
+	// List-Unsubscribe metadata (RFC 2369 / RFC 8058)
+	UnsubscribeURL      *string `db:"unsubscribe_url"       json:"unsubscribe_url,omitempty"`
+	UnsubscribeMailto   *string `db:"unsubscribe_mailto"    json:"unsubscribe_mailto,omitempty"`
+	UnsubscribeOneClick bool    `db:"unsubscribe_one_click" json:"unsubscribe_one_click"`
 }
 
 // GmailThread represents a Gmail thread
@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationType geeft aan waar een Notification over gaat.
+type NotificationType string
+
+const (
+	// NotificationRulePaused betekent dat de worker een rule uitzette na het overschrijden van een limiet
+	NotificationRulePaused NotificationType = "rule_paused"
+)
+
+// Notification is een melding voor de gebruiker die de API toont tot hij gelezen is.
+type Notification struct {
+	ID                 uuid.UUID        `db:"id"                   json:"id"`
+	UserID             uuid.UUID        `db:"user_id"              json:"user_id"`
+	ConnectedAccountID *uuid.UUID       `db:"connected_account_id" json:"connected_account_id,omitempty"`
+	Type               NotificationType `db:"type"                 json:"type"`
+	Message            string           `db:"message"              json:"message"`
+	Details            json.RawMessage  `db:"details"              json:"details,omitempty"`
+	ReadAt             *time.Time       `db:"read_at"              json:"read_at,omitempty"`
+	CreatedAt          time.Time        `db:"created_at"           json:"created_at"`
+}
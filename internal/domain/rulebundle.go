@@ -0,0 +1,51 @@
+package domain
+
+import "time"
+
+const (
+	// RuleBundleFormat staat in elke export, zodat een import andere JSON/YAML bestanden herkent.
+	RuleBundleFormat = "agenda-automator/rules"
+	// RuleBundleVersion wordt opgehoogd als de bundel niet meer compatibel is met oudere imports.
+	RuleBundleVersion = 1
+)
+
+// RuleBundle is een draagbare export van de calendar en Gmail rules van een gebruiker.
+// Accounts worden aangeduid met hun e-mailadres in plaats van hun ID, zodat een bundel
+// bij een andere gebruiker geïmporteerd kan worden.
+type RuleBundle struct {
+	Format     string              `json:"format"      yaml:"format"`
+	Version    int                 `json:"version"     yaml:"version"`
+	ExportedAt time.Time           `json:"exported_at" yaml:"exported_at"`
+	Accounts   []RuleBundleAccount `json:"accounts"    yaml:"accounts"`
+}
+
+// RuleBundleAccount bevat de rules van één account uit een RuleBundle.
+type RuleBundleAccount struct {
+	Email         string               `json:"email"                    yaml:"email"`
+	CalendarRules []RuleBundleCalendar `json:"calendar_rules,omitempty" yaml:"calendar_rules,omitempty"`
+	GmailRules    []RuleBundleGmail    `json:"gmail_rules,omitempty"    yaml:"gmail_rules,omitempty"`
+}
+
+// RuleBundleCalendar is een calendar rule in een RuleBundle. Condities en parameters zijn maps
+// in plaats van json.RawMessage, zodat ze in YAML als gewone velden verschijnen.
+type RuleBundleCalendar struct {
+	Name              string         `json:"name"               yaml:"name"`
+	IsActive          bool           `json:"is_active"          yaml:"is_active"`
+	TriggerConditions map[string]any `json:"trigger_conditions"    yaml:"trigger_conditions"`
+	ActionType        string         `json:"action_type,omitempty" yaml:"action_type,omitempty"`
+	ActionParams      map[string]any `json:"action_params"         yaml:"action_params"`
+	Schedule          *RuleSchedule  `json:"schedule,omitempty"    yaml:"schedule,omitempty"`
+}
+
+// RuleBundleGmail is een Gmail rule in een RuleBundle.
+type RuleBundleGmail struct {
+	Name              string               `json:"name"                  yaml:"name"`
+	Description       *string              `json:"description,omitempty" yaml:"description,omitempty"`
+	IsActive          bool                 `json:"is_active"             yaml:"is_active"`
+	TriggerType       GmailRuleTriggerType `json:"trigger_type"          yaml:"trigger_type"`
+	TriggerConditions map[string]any       `json:"trigger_conditions"    yaml:"trigger_conditions"`
+	ActionType        GmailRuleActionType  `json:"action_type"           yaml:"action_type"`
+	ActionParams      map[string]any       `json:"action_params"         yaml:"action_params"`
+	Priority          int                  `json:"priority"              yaml:"priority"`
+	Schedule          *RuleSchedule        `json:"schedule,omitempty"    yaml:"schedule,omitempty"`
+}
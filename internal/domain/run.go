@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProcessingRunStatus is de voortgang van een handmatige run.
+type ProcessingRunStatus string
+
+const (
+	RunQueued    ProcessingRunStatus = "queued"    // wacht in de queue van de worker
+	RunRunning   ProcessingRunStatus = "running"   // de worker verwerkt de run
+	RunCompleted ProcessingRunStatus = "completed" // klaar; de uitkomst per rule staat in de logs
+	RunFailed    ProcessingRunStatus = "failed"    // de run kon niet (volledig) draaien, zie ErrorMessage
+)
+
+// ProcessingRun is een verwerking die via de API is gestart in plaats van door de ticker van de
+// worker: een sync van een account, of één calendar rule (RuleID) of Gmail rule (GmailRuleID). Een
+// rule run zonder ConnectedAccountID hoort bij een user-level rule en draait op elk account waar die
+// rule op van toepassing is.
+type ProcessingRun struct {
+	ID                 uuid.UUID           `json:"id"`
+	UserID             uuid.UUID           `json:"user_id"`
+	ConnectedAccountID *uuid.UUID          `json:"connected_account_id,omitempty"`
+	RuleID             *uuid.UUID          `json:"rule_id,omitempty"`
+	GmailRuleID        *uuid.UUID          `json:"gmail_rule_id,omitempty"`
+	Status             ProcessingRunStatus `json:"status"`
+	ErrorMessage       *string             `json:"error_message,omitempty"`
+	Results            *RunResults         `json:"results,omitempty"`
+	CreatedAt          time.Time           `json:"created_at"`
+	StartedAt          *time.Time          `json:"started_at,omitempty"`
+	FinishedAt         *time.Time          `json:"finished_at,omitempty"`
+}
+
+// RunResults telt de automation logs die een run schreef, per status.
+type RunResults struct {
+	Success int `json:"success"`
+	Failure int `json:"failure"`
+	Skipped int `json:"skipped"`
+}
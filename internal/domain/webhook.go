@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookSecret is het geheim waarmee de webhooks van een gebruiker ondertekend worden.
+type WebhookSecret struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Secret    string    `json:"secret"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookActionLogDetails is de action_details van een webhook actie.
+type WebhookActionLogDetails struct {
+	ActionType string `json:"action_type"`
+	URL        string `json:"url"`
+	DeliveryID string `json:"delivery_id"`
+	StatusCode int    `json:"status_code"`
+	Attempts   int    `json:"attempts"`
+}
+
+// InboundHook is een webhook endpoint van een gebruiker. Een levering start de calendar rules met
+// trigger_type hook_received die aan de hook gebonden zijn. Het token zelf wordt niet bewaard;
+// alleen bij het aanmaken staat het in Token.
+type InboundHook struct {
+	ID             uuid.UUID  `json:"id"`
+	UserID         uuid.UUID  `json:"user_id"`
+	Name           string     `json:"name"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastDeliveryAt *time.Time `json:"last_delivery_at,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	Token          string     `json:"token,omitempty"`
+}
+
+// HookTriggerConditions zijn de trigger_conditions van een rule met trigger_type hook_received.
+type HookTriggerConditions struct {
+	HookID uuid.UUID `json:"hook_id"`
+}
+
+// Acties van een rule die op een inbound hook draait
+const (
+	HookActionCreateEvent = "create_calendar_event"
+	HookActionSendEmail   = "send_email"
+)
+
+// HookDeliveryStatus is de uitkomst van een levering aan een inbound hook.
+type HookDeliveryStatus string
+
+const (
+	HookDeliveryQueued  HookDeliveryStatus = "queued"  // de worker heeft de gebonden rules nog niet gedraaid
+	HookDeliverySuccess HookDeliveryStatus = "success" // alle rules zijn uitgevoerd of bewust overgeslagen
+	HookDeliveryPartial HookDeliveryStatus = "partial" // een deel van de rules is mislukt
+	HookDeliveryFailure HookDeliveryStatus = "failure" // er is geen rule gelukt, of de levering kon niet draaien
+)
+
+// HookDelivery is de log van één request aan een inbound hook.
+type HookDelivery struct {
+	ID           int64              `json:"id"`
+	HookID       uuid.UUID          `json:"hook_id"`
+	ReceivedAt   time.Time          `json:"received_at"`
+	Status       HookDeliveryStatus `json:"status"`
+	Payload      json.RawMessage    `json:"payload,omitempty"`
+	Results      []HookRuleResult   `json:"results"`
+	ErrorMessage *string            `json:"error_message,omitempty"`
+	FinishedAt   *time.Time         `json:"finished_at,omitempty"`
+}
+
+// HookTriggerLogDetails is de trigger_details van een automation log van een gebonden rule.
+type HookTriggerLogDetails struct {
+	HookID     uuid.UUID `json:"hook_id"`
+	DeliveryID int64     `json:"delivery_id"`
+}
+
+// HookRuleResult is de uitkomst van één gebonden rule op één account binnen een levering. Wat
+// de actie deed staat in de automation log van het account.
+type HookRuleResult struct {
+	RuleID             uuid.UUID  `json:"rule_id"`
+	ConnectedAccountID *uuid.UUID `json:"connected_account_id,omitempty"` // nil als de rule op geen account kon draaien
+	Outcome            string     `json:"outcome"`                        // een rules.Outcome
+	Error              string     `json:"error,omitempty"`
+}
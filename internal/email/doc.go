@@ -0,0 +1,3 @@
+// Package email composes and parses RFC 5322 messages with proper MIME structure for the Gmail API,
+// including forwards of existing messages.
+package email
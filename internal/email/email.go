@@ -0,0 +1,272 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// base64LineLength is de maximale regellengte voor base64 body's (RFC 2045 sectie 6.8)
+const base64LineLength = 76
+
+// Attachment is een bijlage of inline afbeelding.
+type Attachment struct {
+	Filename    string
+	ContentType string // Standaard application/octet-stream
+	Data        []byte
+	// ContentID maakt de bijlage inline; in HTML te gebruiken als <img src="cid:ContentID">
+	ContentID string
+}
+
+// Message is een op te stellen e-mailbericht.
+type Message struct {
+	From     string // Alleen het adres; Gmail vult dit zelf in als het leeg is
+	FromName string
+	To       []string
+	Cc       []string
+	Bcc      []string
+	ReplyTo  []string
+	Subject  string
+
+	// Text en/of HTML; met beide wordt het multipart/alternative
+	Text string
+	HTML string
+
+	InReplyTo  string
+	References string
+
+	Attachments []Attachment
+}
+
+// Build stelt het volledige bericht samen met CRLF regeleinden.
+func (m *Message) Build() ([]byte, error) {
+	var buf bytes.Buffer
+
+	if m.From != "" {
+		from := mail.Address{Name: m.FromName, Address: m.From}
+		writeHeader(&buf, "From", from.String())
+	}
+	for _, h := range []struct {
+		name  string
+		addrs []string
+	}{
+		{"To", m.To}, {"Cc", m.Cc}, {"Bcc", m.Bcc}, {"Reply-To", m.ReplyTo},
+	} {
+		if len(h.addrs) == 0 {
+			continue
+		}
+		value, err := formatAddressList(h.addrs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s address: %w", h.name, err)
+		}
+		writeHeader(&buf, h.name, value)
+	}
+
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("utf-8", sanitizeHeader(m.Subject)))
+	writeHeader(&buf, "Date", time.Now().Format(time.RFC1123Z))
+	if m.InReplyTo != "" {
+		writeHeader(&buf, "In-Reply-To", sanitizeHeader(m.InReplyTo))
+	}
+	if m.References != "" {
+		writeHeader(&buf, "References", sanitizeHeader(m.References))
+	}
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	if err := m.writeBody(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Raw geeft het bericht base64url-gecodeerd terug, zoals gmail.Message.Raw verwacht.
+func (m *Message) Raw() (string, error) {
+	built, err := m.Build()
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(built), nil
+}
+
+// writeBody kiest de MIME structuur:
+//
+//	multipart/mixed              (als er gewone bijlagen zijn)
+//	└─ multipart/related         (als er inline afbeeldingen zijn)
+//	   └─ multipart/alternative  (als er zowel tekst als HTML is)
+func (m *Message) writeBody(buf *bytes.Buffer) error {
+	var content part
+	switch {
+	case m.Text != "" && m.HTML != "":
+		content = multipartPart("alternative", textPart("text/plain", m.Text), textPart("text/html", m.HTML))
+	case m.HTML != "":
+		content = textPart("text/html", m.HTML)
+	default:
+		content = textPart("text/plain", m.Text)
+	}
+
+	related := []part{content}
+	mixed := []part{}
+	for _, a := range m.Attachments {
+		// Inline afbeeldingen hebben alleen zin naast HTML
+		if a.ContentID != "" && m.HTML != "" {
+			related = append(related, attachmentPart(a, true))
+		} else {
+			mixed = append(mixed, attachmentPart(a, false))
+		}
+	}
+
+	if len(related) > 1 {
+		content = multipartPart("related", related...)
+	}
+	if len(mixed) > 0 {
+		content = multipartPart("mixed", append([]part{content}, mixed...)...)
+	}
+
+	header, body, err := content.render()
+	if err != nil {
+		return err
+	}
+	for _, key := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+		if value := header.Get(key); value != "" {
+			writeHeader(buf, key, value)
+		}
+	}
+	buf.WriteString("\r\n")
+	_, err = buf.Write(body)
+	return err
+}
+
+// part is een MIME onderdeel: een blad met een gecodeerde body of een multipart met kinderen
+type part struct {
+	header   textproto.MIMEHeader
+	body     []byte
+	subtype  string
+	children []part
+}
+
+func multipartPart(subtype string, children ...part) part {
+	return part{subtype: subtype, children: children}
+}
+
+// render geeft de headers en body van het onderdeel terug; multiparts krijgen hier hun boundary.
+func (p part) render() (textproto.MIMEHeader, []byte, error) {
+	if p.children == nil {
+		return p.header, p.body, nil
+	}
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for _, child := range p.children {
+		header, body, err := child.render()
+		if err != nil {
+			return nil, nil, err
+		}
+		pw, err := w.CreatePart(header)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err := pw.Write(body); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", fmt.Sprintf("multipart/%s; boundary=%q", p.subtype, w.Boundary()))
+	return header, buf.Bytes(), nil
+}
+
+func textPart(contentType, text string) part {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType+"; charset=UTF-8")
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+	// Quoted-printable houdt regels onder de 76 tekens en codeert niet-ASCII tekens
+	var buf bytes.Buffer
+	qp := quotedprintable.NewWriter(&buf)
+	_, _ = qp.Write([]byte(normalizeNewlines(text)))
+	_ = qp.Close()
+
+	return part{header: header, body: buf.Bytes()}
+}
+
+func attachmentPart(a Attachment, inline bool) part {
+	contentType := a.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	header := textproto.MIMEHeader{}
+	filename := mime.QEncoding.Encode("utf-8", sanitizeHeader(a.Filename))
+	header.Set("Content-Type", fmt.Sprintf("%s; name=%q", contentType, filename))
+
+	disposition := "attachment"
+	if inline {
+		disposition = "inline"
+		header.Set("Content-ID", "<"+sanitizeHeader(a.ContentID)+">")
+	}
+	header.Set("Content-Disposition", fmt.Sprintf("%s; filename=%q", disposition, filename))
+
+	// message/rfc822 (bijv. een doorgestuurd bericht) mag niet base64-gecodeerd worden (RFC 2046 sectie 5.2.1)
+	if strings.HasPrefix(contentType, "message/") {
+		header.Set("Content-Transfer-Encoding", "8bit")
+		return part{header: header, body: a.Data}
+	}
+
+	header.Set("Content-Transfer-Encoding", "base64")
+	encoded := base64.StdEncoding.EncodeToString(a.Data)
+	var body strings.Builder
+	for len(encoded) > base64LineLength {
+		body.WriteString(encoded[:base64LineLength] + "\r\n")
+		encoded = encoded[base64LineLength:]
+	}
+	body.WriteString(encoded)
+
+	return part{header: header, body: []byte(body.String())}
+}
+
+// formatAddressList parseert adressen (met of zonder naam) en codeert namen volgens RFC 2047.
+func formatAddressList(addrs []string) (string, error) {
+	formatted := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		parsed, err := mail.ParseAddressList(addr)
+		if err != nil {
+			return "", fmt.Errorf("%q: %w", addr, err)
+		}
+		for _, p := range parsed {
+			formatted = append(formatted, p.String())
+		}
+	}
+	return strings.Join(formatted, ", "), nil
+}
+
+// NormalizeAddress haalt het kale adres uit "Naam <adres>" en maakt het lowercase, om adressen te vergelijken.
+func NormalizeAddress(value string) (string, error) {
+	parsed, err := mail.ParseAddress(value)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToLower(parsed.Address), nil
+}
+
+func writeHeader(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name + ": " + value + "\r\n")
+}
+
+// sanitizeHeader verwijdert regeleinden zodat een waarde geen extra headers kan injecteren
+func sanitizeHeader(value string) string {
+	return strings.NewReplacer("\r", "", "\n", " ").Replace(value)
+}
+
+func normalizeNewlines(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\n", "\r\n")
+}
@@ -0,0 +1,185 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parse leest een opgebouwd bericht terug met de standaardbibliotheek
+func parse(t *testing.T, m *Message) *mail.Message {
+	t.Helper()
+	built, err := m.Build()
+	require.NoError(t, err)
+	parsed, err := mail.ReadMessage(bytes.NewReader(built))
+	require.NoError(t, err)
+	return parsed
+}
+
+func TestBuild_PlainText(t *testing.T) {
+	m := &Message{
+		From:     "jeffrey@example.com",
+		FromName: "Jeffrey",
+		To:       []string{"Klant <klant@example.com>"},
+		Subject:  "Afspraak bevestigd",
+		Text:     "Tot morgen!",
+	}
+
+	parsed := parse(t, m)
+
+	assert.Equal(t, `"Jeffrey" <jeffrey@example.com>`, parsed.Header.Get("From"))
+	assert.Equal(t, `"Klant" <klant@example.com>`, parsed.Header.Get("To"))
+	assert.Equal(t, "text/plain; charset=UTF-8", parsed.Header.Get("Content-Type"))
+	assert.Equal(t, "quoted-printable", parsed.Header.Get("Content-Transfer-Encoding"))
+
+	body, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+	require.NoError(t, err)
+	assert.Equal(t, "Tot morgen!", string(body))
+}
+
+func TestBuild_EncodesNonASCIISubjectAndNames(t *testing.T) {
+	m := &Message{
+		To:      []string{"Zoë Müller <zoe@example.com>"},
+		Subject: "Één vraag over je café",
+		Text:    "Groetjes",
+	}
+
+	built, err := m.Build()
+	require.NoError(t, err)
+	// Headers moeten 7-bit blijven
+	header := string(built[:bytes.Index(built, []byte("\r\n\r\n"))])
+	for _, r := range header {
+		assert.Less(t, r, rune(128), "header bevat niet-ASCII teken %q", r)
+	}
+
+	parsed := parse(t, m)
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Één vraag over je café", subject)
+
+	to, err := parsed.Header.AddressList("To")
+	require.NoError(t, err)
+	assert.Equal(t, "Zoë Müller", to[0].Name)
+}
+
+func TestBuild_LongLinesAreWrapped(t *testing.T) {
+	m := &Message{To: []string{"a@example.com"}, Text: strings.Repeat("lang ", 500)}
+
+	built, err := m.Build()
+	require.NoError(t, err)
+	for _, line := range strings.Split(string(built), "\r\n") {
+		assert.LessOrEqual(t, len(line), 78)
+	}
+}
+
+func TestBuild_AlternativeWithInlineImageAndAttachment(t *testing.T) {
+	m := &Message{
+		To:      []string{"a@example.com"},
+		ReplyTo: []string{"support@example.com"},
+		Subject: "Nieuwsbrief",
+		Text:    "Zie de HTML versie",
+		HTML:    `<p>Hoi</p><img src="cid:logo">`,
+		Attachments: []Attachment{
+			{Filename: "logo.png", ContentType: "image/png", Data: []byte("png-data"), ContentID: "logo"},
+			{Filename: "factuur.pdf", ContentType: "application/pdf", Data: bytes.Repeat([]byte{0xff}, 200)},
+		},
+	}
+
+	parsed := parse(t, m)
+	assert.Equal(t, `<support@example.com>`, parsed.Header.Get("Reply-To"))
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+
+	mixed := multipart.NewReader(parsed.Body, params["boundary"])
+
+	// Eerste deel: related met daarin alternative + inline afbeelding
+	related, err := mixed.NextPart()
+	require.NoError(t, err)
+	mediaType, params, _ = mime.ParseMediaType(related.Header.Get("Content-Type"))
+	assert.Equal(t, "multipart/related", mediaType)
+
+	relatedReader := multipart.NewReader(related, params["boundary"])
+	alternative, err := relatedReader.NextPart()
+	require.NoError(t, err)
+	mediaType, _, _ = mime.ParseMediaType(alternative.Header.Get("Content-Type"))
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	logo, err := relatedReader.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "<logo>", logo.Header.Get("Content-Id"))
+	assert.True(t, strings.HasPrefix(logo.Header.Get("Content-Disposition"), "inline"))
+
+	// Tweede deel: de PDF als gewone bijlage
+	pdf, err := mixed.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "factuur.pdf", pdf.FileName())
+	encoded, err := io.ReadAll(pdf)
+	require.NoError(t, err)
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+	require.NoError(t, err)
+	assert.Equal(t, bytes.Repeat([]byte{0xff}, 200), decoded)
+}
+
+func TestBuild_ForwardedMessageIsNotEncoded(t *testing.T) {
+	original := "From: a@example.com\r\nSubject: Origineel\r\n\r\nInhoud"
+	m := &Message{
+		To:          []string{"b@example.com"},
+		Text:        "Zie bijlage",
+		Attachments: []Attachment{{Filename: "origineel.eml", ContentType: "message/rfc822", Data: []byte(original)}},
+	}
+
+	built, err := m.Build()
+	require.NoError(t, err)
+	assert.Contains(t, string(built), original)
+}
+
+func TestBuild_ReplyHeadersAndInjection(t *testing.T) {
+	m := &Message{
+		To:         []string{"a@example.com"},
+		Subject:    "Re: Vraag\r\nBcc: evil@example.com",
+		Text:       "Antwoord",
+		InReplyTo:  "<abc@mail.example.com>",
+		References: "<xyz@mail.example.com> <abc@mail.example.com>",
+	}
+
+	parsed := parse(t, m)
+
+	assert.Equal(t, "<abc@mail.example.com>", parsed.Header.Get("In-Reply-To"))
+	assert.Equal(t, "<xyz@mail.example.com> <abc@mail.example.com>", parsed.Header.Get("References"))
+	assert.Empty(t, parsed.Header.Get("Bcc"))
+}
+
+func TestBuild_Errors(t *testing.T) {
+	_, err := (&Message{To: []string{"geen adres"}}).Build()
+	assert.ErrorContains(t, err, "invalid To address")
+}
+
+func TestRaw_IsBase64URL(t *testing.T) {
+	m := &Message{To: []string{"a@example.com"}, Text: "Hoi"}
+
+	raw, err := m.Raw()
+	require.NoError(t, err)
+	decoded, err := base64.URLEncoding.DecodeString(raw)
+	require.NoError(t, err)
+	assert.Contains(t, string(decoded), "To: <a@example.com>\r\n")
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	addr, err := NormalizeAddress("Boekhouding <Boekhouding@Example.com>")
+	assert.NoError(t, err)
+	assert.Equal(t, "boekhouding@example.com", addr)
+
+	_, err = NormalizeAddress("geen adres")
+	assert.Error(t, err)
+}
@@ -0,0 +1,107 @@
+package email
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// ForwardMode bepaalt hoe het originele bericht wordt meegestuurd.
+type ForwardMode string
+
+const (
+	// ForwardInline neemt de tekst, HTML en bijlagen over onder een "Forwarded message" blok
+	ForwardInline ForwardMode = "inline"
+	// ForwardAttachment voegt het ongewijzigde origineel toe als message/rfc822 bijlage
+	ForwardAttachment ForwardMode = "attachment"
+)
+
+// ParseForwardMode valideert een modus; leeg betekent inline, zoals in Gmail zelf.
+func ParseForwardMode(value string) (ForwardMode, error) {
+	switch ForwardMode(value) {
+	case "", ForwardInline:
+		return ForwardInline, nil
+	case ForwardAttachment:
+		return ForwardAttachment, nil
+	default:
+		return "", fmt.Errorf("unknown forward mode %q", value)
+	}
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^\pL\pN ._-]+`)
+
+// Forward stelt een doorstuurbericht op uit het originele raw bericht, met een optionele notitie erboven.
+func Forward(raw []byte, mode ForwardMode, to []string, note string) (*Message, error) {
+	original, err := Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Message{
+		To:      to,
+		Subject: forwardSubject(original.Subject),
+	}
+
+	if mode == ForwardAttachment {
+		m.Text = note
+		m.Attachments = []Attachment{{
+			Filename:    attachmentFilename(original.Subject),
+			ContentType: "message/rfc822",
+			Data:        raw,
+		}}
+		return m, nil
+	}
+
+	intro := []string{"---------- Forwarded message ---------"}
+	for _, h := range []struct{ name, value string }{
+		{"From", original.From},
+		{"Date", original.Date},
+		{"Subject", original.Subject},
+		{"To", original.To},
+		{"Cc", original.Cc},
+	} {
+		if h.value != "" {
+			intro = append(intro, h.name+": "+h.value)
+		}
+	}
+
+	text := strings.Join(intro, "\n") + "\n\n" + original.Text
+	if note != "" {
+		text = note + "\n\n" + text
+	}
+	m.Text = text
+
+	if original.HTML != "" {
+		escaped := make([]string, len(intro))
+		for i, line := range intro {
+			escaped[i] = html.EscapeString(line)
+		}
+		block := "<div>" + strings.Join(escaped, "<br>") + "</div><br>" + original.HTML
+		if note != "" {
+			block = "<div>" + strings.ReplaceAll(html.EscapeString(note), "\n", "<br>") + "</div><br>" + block
+		}
+		m.HTML = block
+	}
+
+	m.Attachments = original.Attachments
+	return m, nil
+}
+
+func forwardSubject(subject string) string {
+	if strings.HasPrefix(strings.ToLower(subject), "fwd:") {
+		return subject
+	}
+	return "Fwd: " + subject
+}
+
+func attachmentFilename(subject string) string {
+	name := strings.TrimSpace(unsafeFilenameChars.ReplaceAllString(subject, ""))
+	if name == "" {
+		name = "message"
+	}
+	if runes := []rune(name); len(runes) > 100 {
+		name = string(runes[:100])
+	}
+	return name + ".eml"
+}
@@ -0,0 +1,74 @@
+package email
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func originalMessage(t *testing.T) []byte {
+	t.Helper()
+	m := &Message{
+		From:    "klant@example.com",
+		To:      []string{"jeffrey@example.com"},
+		Subject: "Factuur <maart>",
+		Text:    "Hierbij de factuur",
+		HTML:    "<p>Hierbij de factuur</p>",
+		Attachments: []Attachment{
+			{Filename: "factuur.pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.4")},
+		},
+	}
+	built, err := m.Build()
+	require.NoError(t, err)
+	return built
+}
+
+func TestForward_Inline(t *testing.T) {
+	m, err := Forward(originalMessage(t), ForwardInline, []string{"boekhouding@example.com"}, "Graag inboeken")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"boekhouding@example.com"}, m.To)
+	assert.Equal(t, "Fwd: Factuur <maart>", m.Subject)
+	assert.Contains(t, m.Text, "Graag inboeken\n\n---------- Forwarded message ---------\nFrom: ")
+	assert.Contains(t, m.Text, "Subject: Factuur <maart>")
+	assert.Contains(t, m.Text, "Hierbij de factuur")
+	assert.Contains(t, m.HTML, "Subject: Factuur &lt;maart&gt;")
+	assert.Contains(t, m.HTML, "<p>Hierbij de factuur</p>")
+	require.Len(t, m.Attachments, 1)
+	assert.Equal(t, "factuur.pdf", m.Attachments[0].Filename)
+
+	_, err = m.Build()
+	assert.NoError(t, err)
+}
+
+func TestForward_AsAttachment(t *testing.T) {
+	raw := originalMessage(t)
+	m, err := Forward(raw, ForwardAttachment, []string{"boekhouding@example.com"}, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Fwd: Factuur <maart>", m.Subject)
+	assert.Empty(t, m.HTML)
+	require.Len(t, m.Attachments, 1)
+	assert.Equal(t, "message/rfc822", m.Attachments[0].ContentType)
+	assert.Equal(t, "Factuur maart.eml", m.Attachments[0].Filename)
+	assert.Equal(t, raw, m.Attachments[0].Data)
+}
+
+func TestForwardSubject_DoesNotStack(t *testing.T) {
+	assert.Equal(t, "FWD: al doorgestuurd", forwardSubject("FWD: al doorgestuurd"))
+	assert.Equal(t, "Fwd: ", forwardSubject(""))
+}
+
+func TestParseForwardMode(t *testing.T) {
+	mode, err := ParseForwardMode("")
+	assert.NoError(t, err)
+	assert.Equal(t, ForwardInline, mode)
+
+	mode, err = ParseForwardMode("attachment")
+	assert.NoError(t, err)
+	assert.Equal(t, ForwardAttachment, mode)
+
+	_, err = ParseForwardMode("bcc")
+	assert.Error(t, err)
+}
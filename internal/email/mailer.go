@@ -0,0 +1,89 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/smtp"
+	"os"
+)
+
+// ErrMailerNotConfigured geeft Mailer.Send als SMTP_HOST of MAIL_FROM ontbreekt.
+var ErrMailerNotConfigured = errors.New("system mailer is not configured")
+
+// Mailer verstuurt systeemmail vanaf het adres van de applicatie, bijv. verificatiecodes. Anders
+// dan mail via een gekoppeld account komt die niet in de verzonden items van de gebruiker terecht.
+type Mailer interface {
+	Send(ctx context.Context, m *Message) error
+}
+
+// SMTPMailer verstuurt systeemmail via SMTP, met STARTTLS als de server dat aanbiedt.
+type SMTPMailer struct {
+	Addr     string // host:port
+	Username string // leeg is zonder authenticatie
+	Password string
+	From     string
+	FromName string
+
+	// sendMail is smtp.SendMail; vervangbaar in tests
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewMailerFromEnv maakt een SMTPMailer uit SMTP_HOST, SMTP_PORT (standaard 587), SMTP_USERNAME,
+// SMTP_PASSWORD, MAIL_FROM en MAIL_FROM_NAME.
+func NewMailerFromEnv() *SMTPMailer {
+	mailer := &SMTPMailer{
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("MAIL_FROM"),
+		FromName: os.Getenv("MAIL_FROM_NAME"),
+		sendMail: smtp.SendMail,
+	}
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		mailer.Addr = net.JoinHostPort(host, port)
+	}
+	return mailer
+}
+
+// Send verstuurt m met het systeemadres als afzender; m.From wordt genegeerd.
+func (s *SMTPMailer) Send(_ context.Context, m *Message) error {
+	if s.Addr == "" || s.From == "" {
+		return ErrMailerNotConfigured
+	}
+
+	msg := *m
+	msg.From, msg.FromName = s.From, s.FromName
+	built, err := msg.Build()
+	if err != nil {
+		return err
+	}
+
+	var recipients []string
+	for _, list := range [][]string{m.To, m.Cc, m.Bcc} {
+		for _, value := range list {
+			address, err := NormalizeAddress(value)
+			if err != nil {
+				return err
+			}
+			recipients = append(recipients, address)
+		}
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, err := net.SplitHostPort(s.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+	send := s.sendMail
+	if send == nil {
+		send = smtp.SendMail
+	}
+	return send(s.Addr, auth, s.From, recipients, built)
+}
@@ -0,0 +1,55 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"net/mail"
+	"net/smtp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSMTPMailer_Send(t *testing.T) {
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_PORT", "")
+	t.Setenv("SMTP_USERNAME", "mailer")
+	t.Setenv("SMTP_PASSWORD", "geheim")
+	t.Setenv("MAIL_FROM", "noreply@agenda.example.com")
+	t.Setenv("MAIL_FROM_NAME", "Agenda Automator")
+
+	mailer := NewMailerFromEnv()
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotMsg []byte
+	mailer.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotFrom, gotTo, gotMsg = addr, from, to, msg
+		assert.NotNil(t, a)
+		return nil
+	}
+
+	err := mailer.Send(context.Background(), &Message{
+		From:    "gebruiker@gmail.com",
+		To:      []string{"Doorstuur <Doorstuur@Example.com>"},
+		Subject: "Code",
+		Text:    "ABCD2345",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "smtp.example.com:587", gotAddr)
+	assert.Equal(t, "noreply@agenda.example.com", gotFrom)
+	assert.Equal(t, []string{"doorstuur@example.com"}, gotTo)
+	parsed, err := mail.ReadMessage(bytes.NewReader(gotMsg))
+	require.NoError(t, err)
+	// De afzender is altijd het systeemadres, nooit het account van de gebruiker
+	assert.Equal(t, `"Agenda Automator" <noreply@agenda.example.com>`, parsed.Header.Get("From"))
+}
+
+func TestSMTPMailer_NotConfigured(t *testing.T) {
+	t.Setenv("SMTP_HOST", "")
+	t.Setenv("MAIL_FROM", "")
+
+	err := NewMailerFromEnv().Send(context.Background(), &Message{To: []string{"a@example.com"}})
+	assert.ErrorIs(t, err, ErrMailerNotConfigured)
+}
@@ -0,0 +1,65 @@
+package email
+
+import (
+	"fmt"
+
+	"agenda-automator-api/internal/domain"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// FromOutgoing zet een via de API of een regel opgesteld bericht om naar een Message.
+func FromOutgoing(outgoing domain.GmailOutgoingMessage) *Message {
+	m := &Message{
+		FromName:   outgoing.FromName,
+		To:         outgoing.To,
+		Cc:         outgoing.Cc,
+		Bcc:        outgoing.Bcc,
+		ReplyTo:    outgoing.ReplyTo,
+		Subject:    outgoing.Subject,
+		InReplyTo:  outgoing.InReplyTo,
+		References: outgoing.References,
+	}
+
+	if outgoing.IsHTML {
+		m.HTML = outgoing.Body
+		m.Text = outgoing.TextBody
+	} else {
+		m.Text = outgoing.Body
+	}
+
+	for _, a := range outgoing.Attachments {
+		m.Attachments = append(m.Attachments, Attachment{
+			Filename:    a.Filename,
+			ContentType: a.ContentType,
+			Data:        a.Data,
+			ContentID:   a.ContentID,
+		})
+	}
+
+	// Zonder In-Reply-To verwijst References naar hetzelfde bericht
+	if m.References == "" {
+		m.References = m.InReplyTo
+	}
+	return m
+}
+
+// ComposeGmail bouwt een Gmail bericht uit outgoing. Met een weergavenaam moet het From adres
+// expliciet gezet worden; dat halen we dan uit het Gmail profiel van het account.
+func ComposeGmail(srv *gmail.Service, outgoing domain.GmailOutgoingMessage) (*gmail.Message, error) {
+	m := FromOutgoing(outgoing)
+
+	if outgoing.FromName != "" {
+		profile, err := srv.Users.GetProfile("me").Do()
+		if err != nil {
+			return nil, fmt.Errorf("could not get Gmail profile: %w", err)
+		}
+		m.From = profile.EmailAddress
+	}
+
+	raw, err := m.Raw()
+	if err != nil {
+		return nil, err
+	}
+	return &gmail.Message{ThreadId: outgoing.ThreadID, Raw: raw}, nil
+}
@@ -0,0 +1,161 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"unicode/utf8"
+)
+
+// Parsed is een ingelezen bericht met de kopregels die nodig zijn om het door te sturen.
+type Parsed struct {
+	From      string
+	To        string
+	Cc        string
+	Date      string
+	Subject   string
+	MessageID string
+
+	Text string
+	HTML string
+
+	// Attachments bevat gewone bijlagen en inline afbeeldingen (met ContentID)
+	Attachments []Attachment
+}
+
+var headerDecoder = &mime.WordDecoder{}
+
+// DecodeRaw decodeert gmail.Message.Raw, met of zonder padding.
+func DecodeRaw(raw string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(raw, "="))
+}
+
+// Parse leest een volledig RFC 5322 bericht en haalt de tekst, HTML en bijlagen uit de MIME structuur.
+func Parse(raw []byte) (*Parsed, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("could not read message: %w", err)
+	}
+
+	p := &Parsed{
+		From:      decodeHeader(msg.Header.Get("From")),
+		To:        decodeHeader(msg.Header.Get("To")),
+		Cc:        decodeHeader(msg.Header.Get("Cc")),
+		Date:      msg.Header.Get("Date"),
+		Subject:   decodeHeader(msg.Header.Get("Subject")),
+		MessageID: msg.Header.Get("Message-ID"),
+	}
+
+	if err := p.walk(textproto.MIMEHeader(msg.Header), msg.Body); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// walk loopt recursief door de onderdelen; het eerste tekst- en HTML-onderdeel wordt de body.
+func (p *Parsed) walk(header textproto.MIMEHeader, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("could not read multipart: %w", err)
+			}
+			if err := p.walk(part.Header, part); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := io.ReadAll(decodeTransfer(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return fmt.Errorf("could not decode %s part: %w", mediaType, err)
+	}
+
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := decodeHeader(dispositionParams["filename"])
+	if filename == "" {
+		filename = decodeHeader(params["name"])
+	}
+
+	isBody := disposition != "attachment" && filename == ""
+	switch {
+	case isBody && mediaType == "text/plain" && p.Text == "":
+		p.Text = toUTF8(data, params["charset"])
+	case isBody && mediaType == "text/html" && p.HTML == "":
+		p.HTML = toUTF8(data, params["charset"])
+	default:
+		if filename == "" {
+			filename = defaultFilename(mediaType)
+		}
+		p.Attachments = append(p.Attachments, Attachment{
+			Filename:    filename,
+			ContentType: mediaType,
+			Data:        data,
+			ContentID:   strings.Trim(header.Get("Content-ID"), "<>"),
+		})
+	}
+	return nil
+}
+
+func decodeTransfer(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		// De base64 decoder slaat regeleinden zelf over
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	default:
+		return body
+	}
+}
+
+func decodeHeader(value string) string {
+	decoded, err := headerDecoder.DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+// toUTF8 zet Latin-1 om naar UTF-8; andere tekensets worden ongewijzigd doorgegeven.
+func toUTF8(data []byte, charset string) string {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1":
+		if utf8.Valid(data) {
+			return string(data)
+		}
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	default:
+		return string(data)
+	}
+}
+
+func defaultFilename(mediaType string) string {
+	if mediaType == "message/rfc822" {
+		return "message.eml"
+	}
+	if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+		return "attachment" + exts[0]
+	}
+	return "attachment"
+}
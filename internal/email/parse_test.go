@@ -0,0 +1,74 @@
+package email
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_RoundTripsBuiltMessage(t *testing.T) {
+	m := &Message{
+		From:    "jeffrey@example.com",
+		To:      []string{"klant@example.com"},
+		Subject: "Offerte ✓",
+		Text:    "Zie bijlage, groet",
+		HTML:    `<p>Zie bijlage</p><img src="cid:logo">`,
+		Attachments: []Attachment{
+			{Filename: "logo.png", ContentType: "image/png", Data: []byte{0x89, 'P', 'N', 'G'}, ContentID: "logo"},
+			{Filename: "offerte.pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.4")},
+		},
+	}
+	built, err := m.Build()
+	require.NoError(t, err)
+
+	parsed, err := Parse(built)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Offerte ✓", parsed.Subject)
+	assert.Equal(t, "<jeffrey@example.com>", parsed.From)
+	assert.Equal(t, "Zie bijlage, groet", parsed.Text)
+	assert.Equal(t, m.HTML, parsed.HTML)
+	require.Len(t, parsed.Attachments, 2)
+	assert.Equal(t, "logo", parsed.Attachments[0].ContentID)
+	assert.Equal(t, []byte{0x89, 'P', 'N', 'G'}, parsed.Attachments[0].Data)
+	assert.Equal(t, "offerte.pdf", parsed.Attachments[1].Filename)
+	assert.Equal(t, "application/pdf", parsed.Attachments[1].ContentType)
+	assert.Equal(t, []byte("%PDF-1.4"), parsed.Attachments[1].Data)
+}
+
+func TestParse_Latin1QuotedPrintable(t *testing.T) {
+	raw := strings.Join([]string{
+		"From: =?iso-8859-1?q?Andr=E9?= <andre@example.com>",
+		"Subject: Caf=?iso-8859-1?q?=E9?=",
+		"Content-Type: text/plain; charset=iso-8859-1",
+		"Content-Transfer-Encoding: quoted-printable",
+		"",
+		"Tot straks in het caf=E9",
+	}, "\r\n")
+
+	parsed, err := Parse([]byte(raw))
+	require.NoError(t, err)
+
+	assert.Equal(t, "André <andre@example.com>", parsed.From)
+	assert.Equal(t, "Tot straks in het café", parsed.Text)
+	assert.Empty(t, parsed.Attachments)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := Parse([]byte("geen headers"))
+	assert.Error(t, err)
+}
+
+func TestDecodeRaw_AcceptsPaddedAndUnpadded(t *testing.T) {
+	m := &Message{To: []string{"a@example.com"}, Subject: "x", Text: "?>?"}
+	raw, err := m.Raw()
+	require.NoError(t, err)
+
+	padded, err := DecodeRaw(raw)
+	require.NoError(t, err)
+	unpadded, err := DecodeRaw(strings.TrimRight(raw, "="))
+	require.NoError(t, err)
+	assert.Equal(t, padded, unpadded)
+}
@@ -0,0 +1,4 @@
+// Package rules is the rules engine shared by the calendar and Gmail processors. Triggers and
+// actions register per type name in a Registry, validate their own JSON parameters and run
+// through one Engine that matches, deduplicates, executes and writes automation logs.
+package rules
@@ -0,0 +1,405 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+)
+
+// LogWriter slaat automation logs op; store.Storer voldoet hieraan.
+type LogWriter interface {
+	CreateAutomationLog(ctx context.Context, arg store.CreateLogParams) error
+}
+
+// Notifier slaat meldingen voor de gebruiker op; store.Storer voldoet hieraan.
+type Notifier interface {
+	CreateNotification(ctx context.Context, arg store.CreateNotificationParams) (domain.Notification, error)
+}
+
+// Outcome is de uitkomst van Engine.Run voor één rule en één subject.
+type Outcome string
+
+const (
+	OutcomeInvalid   Outcome = "invalid"   // de rule is verkeerd geconfigureerd, er is niets uitgevoerd
+	OutcomeNoMatch   Outcome = "no_match"  // de trigger matcht niet
+	OutcomeDuplicate Outcome = "duplicate" // de rule is al eerder voor dit subject uitgevoerd
+	OutcomeSuccess   Outcome = "success"
+	OutcomeSkipped   Outcome = "skipped"
+	OutcomeFailure   Outcome = "failure"
+	OutcomeDisabled  Outcome = "disabled" // de rule is in deze ronde uitgezet: schedule verlopen of limiet bereikt
+	OutcomeLimited   Outcome = "limited"  // een uitvoeringslimiet is bereikt, er is niets uitgevoerd
+)
+
+// runIDKey is de context key voor het ID van een handmatige run
+type runIDKey struct{}
+
+// WithRunID geeft een ctx voor een handmatige run: de automation logs die de engine met die ctx
+// schrijft krijgen het ID van de run, zodat de resultaten van de run precies die logs zijn.
+func WithRunID(ctx context.Context, runID uuid.UUID) context.Context {
+	return context.WithValue(ctx, runIDKey{}, runID)
+}
+
+// defaultActionDetails wordt gelogd als een geslaagde actie zelf geen details teruggeeft
+var defaultActionDetails = map[string]string{"details": "Action executed successfully"}
+
+// Config configureert een Engine voor één service.
+type Config[S any] struct {
+	// Name is het voorvoegsel van de logregels, bijv. "Gmail"
+	Name string
+	// RuleType komt in de details van meldingen aan de gebruiker
+	RuleType domain.RuleType
+	// Logs ontvangt de automation logs
+	Logs LogWriter
+	// Describe geeft de trigger_details van de automation log voor een subject
+	Describe func(subject S) any
+	// AlreadyHandled is optioneel en voorkomt dat een rule twee keer op hetzelfde subject draait
+	AlreadyHandled func(ctx context.Context, rule Rule, subject S) (bool, error)
+	// RecordExecution is optioneel en telt een geslaagde uitvoering van een rule met max_executions;
+	// het geeft het nieuwe aantal terug
+	RecordExecution func(ctx context.Context, rule Rule) (int, error)
+	// Expire is optioneel en zet een verlopen rule uit; zonder Expire worden verlopen rules alleen overgeslagen
+	Expire func(ctx context.Context, rule Rule) error
+	// Limits zijn de standaardlimieten; een rule vervangt RulePerHour met zijn
+	// RuleSchedule.MaxExecutionsPerHour. Ze werken alleen met ExecutionCounts en CountExecution.
+	Limits Limits
+	// ExecutionCounts is optioneel en geeft voor de actie het aantal uitgevoerde acties van de rule
+	// in dit uur en van het account op deze dag
+	ExecutionCounts func(ctx context.Context, rule Rule) (ruleHour, accountDay int, err error)
+	// CountExecution is optioneel en telt een uitgevoerde actie mee voor Limits. Het geeft het
+	// aantal uitgevoerde acties van het account op deze dag, inclusief deze.
+	CountExecution func(ctx context.Context, rule Rule) (accountDay int, err error)
+	// BusiestRule is optioneel en geeft de rule met de meeste uitgevoerde acties vandaag op het
+	// account, van welke service ook
+	BusiestRule func(ctx context.Context, accountID uuid.UUID) (domain.RuleType, uuid.UUID, error)
+	// Pause is optioneel en zet een rule uit die een limiet overschreed. Het type is niet altijd
+	// RuleType: de drukste rule van een account kan van een andere service zijn. Zie PauseWith.
+	Pause func(ctx context.Context, ruleType domain.RuleType, ruleID uuid.UUID) (Rule, error)
+	// Notifications is optioneel en ontvangt een melding als een rule gepauzeerd is
+	Notifications Notifier
+}
+
+// Engine is de gedeelde pipeline: valideren, trigger evalueren, dubbel werk voorkomen,
+// actie uitvoeren en het resultaat loggen.
+type Engine[S any] struct {
+	registry *Registry[S]
+	cfg      Config[S]
+}
+
+// NewEngine maakt een Engine op basis van een Registry.
+func NewEngine[S any](registry *Registry[S], cfg Config[S]) *Engine[S] {
+	return &Engine[S]{registry: registry, cfg: cfg}
+}
+
+// Due bepaalt of een rule volgens zijn schedule op now mag draaien. Een verlopen rule wordt via
+// Config.Expire uitgezet en krijgt een automation log. Roep Due één keer per rule per ronde aan,
+// voor de rule op de subjects van die ronde draait.
+func (e *Engine[S]) Due(ctx context.Context, rule Rule, now time.Time) bool {
+	state, reason := EvaluateSchedule(rule.Schedule, rule.Executions, now)
+	switch state {
+	case ScheduleOpen:
+		return true
+	case ScheduleExpired:
+		e.expire(ctx, rule, reason)
+	}
+	return false
+}
+
+// Run voert één rule uit op één subject. Fouten worden gelogd en in de Outcome uitgedrukt,
+// zodat de aanroeper gewoon door kan met de volgende rule. Een rule met ongeldige parameters
+// wordt overgeslagen zonder automation log: dat is een configuratiefout, geen mislukte uitvoering.
+// Run werkt rule.Executions bij; een rule die daardoor verloopt draait niet meer in deze ronde.
+func (e *Engine[S]) Run(ctx context.Context, rule *Rule, subject S) Outcome {
+	if rule.disabled {
+		return OutcomeDisabled
+	}
+
+	matched, err := e.Match(ctx, *rule, subject)
+	if err != nil {
+		log.Printf("[%s] Error checking rule match for rule %s: %v", e.cfg.Name, rule.ID, err)
+		return OutcomeInvalid
+	}
+	if !matched {
+		return OutcomeNoMatch
+	}
+
+	if e.cfg.AlreadyHandled != nil {
+		handled, err := e.cfg.AlreadyHandled(ctx, *rule, subject)
+		if err != nil {
+			log.Printf("[%s] ERROR checking logs for rule %s: %v", e.cfg.Name, rule.ID, err)
+		}
+		if handled {
+			return OutcomeDuplicate
+		}
+	}
+
+	if err := e.registry.ValidateAction(rule.ActionType, rule.ActionParams); err != nil {
+		log.Printf("[%s] Skipping rule %s with invalid %s params: %v", e.cfg.Name, rule.ID, rule.ActionType, err)
+		return OutcomeInvalid
+	}
+
+	log.Printf("[%s] MATCH: rule '%s' (%s)", e.cfg.Name, rule.Name, rule.ID)
+
+	if !e.withinLimits(ctx, rule, subject) {
+		return OutcomeLimited
+	}
+
+	result, err := e.Execute(ctx, *rule, subject)
+	switch {
+	case err != nil:
+		log.Printf("[%s] Error executing %s for rule %s: %v", e.cfg.Name, rule.ActionType, rule.ID, err)
+		e.writeLog(ctx, *rule, e.describe(subject), domain.LogFailure, nil, err.Error())
+		return OutcomeFailure
+	case result.Skipped:
+		e.writeLog(ctx, *rule, e.describe(subject), domain.LogSkipped, result.Details, "")
+		return OutcomeSkipped
+	default:
+		details := result.Details
+		if details == nil {
+			details = defaultActionDetails
+		}
+		e.writeLog(ctx, *rule, e.describe(subject), domain.LogSuccess, details, "")
+		e.recordExecution(ctx, rule)
+		e.countExecution(ctx, rule, subject)
+		return OutcomeSuccess
+	}
+}
+
+// Match evalueert alleen de trigger van een rule.
+func (e *Engine[S]) Match(ctx context.Context, rule Rule, subject S) (bool, error) {
+	trigger, err := e.registry.Trigger(rule.TriggerType)
+	if err != nil {
+		return false, err
+	}
+	return trigger.Match(ctx, subject, rule.TriggerParams)
+}
+
+// Execute voert alleen de actie van een rule uit, zonder te loggen.
+func (e *Engine[S]) Execute(ctx context.Context, rule Rule, subject S) (Result, error) {
+	action, err := e.registry.Action(rule.ActionType)
+	if err != nil {
+		return Result{}, err
+	}
+	return action.Execute(ctx, subject, rule)
+}
+
+// recordExecution telt een geslaagde uitvoering als de rule een max_executions heeft, en zet de
+// rule uit zodra dat maximum bereikt is.
+func (e *Engine[S]) recordExecution(ctx context.Context, rule *Rule) {
+	if rule.Schedule == nil || rule.Schedule.MaxExecutions == 0 || e.cfg.RecordExecution == nil {
+		return
+	}
+	count, err := e.cfg.RecordExecution(ctx, *rule)
+	if err != nil {
+		log.Printf("[%s] ERROR recording execution of rule %s: %v", e.cfg.Name, rule.ID, err)
+		return
+	}
+	rule.Executions = count
+	if state, reason := EvaluateSchedule(rule.Schedule, count, time.Now()); state == ScheduleExpired {
+		e.expire(ctx, *rule, reason)
+		rule.disabled = true
+	}
+}
+
+// expire zet een verlopen rule uit en logt dat met status skipped.
+func (e *Engine[S]) expire(ctx context.Context, rule Rule, reason string) {
+	if e.cfg.Expire == nil {
+		return
+	}
+	if err := e.cfg.Expire(ctx, rule); err != nil {
+		log.Printf("[%s] ERROR expiring rule %s: %v", e.cfg.Name, rule.ID, err)
+		return
+	}
+	log.Printf("[%s] Rule '%s' (%s) expired: %s", e.cfg.Name, rule.Name, rule.ID, reason)
+	e.writeLog(ctx, rule, nil, domain.LogSkipped, expiredDetails{RuleExpired: reason}, "")
+}
+
+// expiredDetails is de action_details van de log die een verlopen rule achterlaat
+type expiredDetails struct {
+	RuleExpired string `json:"rule_expired"`
+}
+
+// limits geeft de limieten voor een rule: Config.Limits met de uurlimiet van de rule zelf.
+func (e *Engine[S]) limits(rule Rule) Limits {
+	limits := e.cfg.Limits
+	if rule.Schedule != nil && rule.Schedule.MaxExecutionsPerHour > 0 {
+		limits.RulePerHour = rule.Schedule.MaxExecutionsPerHour
+	}
+	return limits
+}
+
+// withinLimits bepaalt of de rule nog een actie mag uitvoeren. Alleen uitgevoerde acties tellen
+// mee, zie countExecution. Een rule die zijn uurlimiet zou overschrijden wordt gepauzeerd; na de
+// daglimiet van het account slaan alle rules van het account de rest van de dag over.
+func (e *Engine[S]) withinLimits(ctx context.Context, rule *Rule, subject S) bool {
+	limits := e.limits(*rule)
+	if e.cfg.ExecutionCounts == nil || (limits.RulePerHour == 0 && limits.AccountPerDay == 0) {
+		return true
+	}
+	ruleHour, accountDay, err := e.cfg.ExecutionCounts(ctx, *rule)
+	if err != nil {
+		// Een storing in de tellers mag de automatisering niet stilleggen
+		log.Printf("[%s] ERROR reading execution counts of rule %s: %v", e.cfg.Name, rule.ID, err)
+		return true
+	}
+
+	switch {
+	case limits.RulePerHour > 0 && ruleHour >= limits.RulePerHour:
+		rule.disabled = true
+		e.pause(ctx, e.cfg.RuleType, rule.ID, rule.AccountID, subject,
+			fmt.Sprintf("more than %d executions of this rule in one hour", limits.RulePerHour),
+			fmt.Sprintf("meer dan %d uitvoeringen binnen een uur", limits.RulePerHour))
+		return false
+	case limits.AccountPerDay > 0 && accountDay >= limits.AccountPerDay:
+		log.Printf("[%s] Daily limit reached for account %s, skipping rule %s", e.cfg.Name, rule.AccountID, rule.ID)
+		return false
+	}
+	return true
+}
+
+// countExecution telt een uitgevoerde actie mee. Bereikt het account daarmee zijn daglimiet, dan
+// wordt de rule met de meeste uitgevoerde acties van vandaag gepauzeerd: die veroorzaakt het volume,
+// niet per se de rule die toevallig de laatste actie uitvoerde.
+func (e *Engine[S]) countExecution(ctx context.Context, rule *Rule, subject S) {
+	limits := e.limits(*rule)
+	if e.cfg.CountExecution == nil || (limits.RulePerHour == 0 && limits.AccountPerDay == 0) {
+		return
+	}
+	accountDay, err := e.cfg.CountExecution(ctx, *rule)
+	if err != nil {
+		log.Printf("[%s] ERROR counting execution of rule %s: %v", e.cfg.Name, rule.ID, err)
+		return
+	}
+	if limits.AccountPerDay == 0 || accountDay < limits.AccountPerDay || e.cfg.BusiestRule == nil {
+		return
+	}
+
+	ruleType, ruleID, err := e.cfg.BusiestRule(ctx, rule.AccountID)
+	if err != nil {
+		log.Printf("[%s] ERROR finding busiest rule of account %s: %v", e.cfg.Name, rule.AccountID, err)
+		return
+	}
+	if ruleType == e.cfg.RuleType && ruleID == rule.ID {
+		rule.disabled = true
+	}
+	e.pause(ctx, ruleType, ruleID, rule.AccountID, subject,
+		fmt.Sprintf("most executions on an account that reached its limit of %d executions today", limits.AccountPerDay),
+		fmt.Sprintf("de meeste uitvoeringen op een account dat vandaag zijn limiet van %d bereikte", limits.AccountPerDay))
+}
+
+// pause zet een rule uit die een limiet overschreed, logt dat met status skipped voor het subject
+// waarbij de limiet bereikt werd en meldt het aan de gebruiker. Een rule die al uit staat, bijv.
+// omdat een andere worker hem net pauzeerde, wordt overgeslagen.
+func (e *Engine[S]) pause(
+	ctx context.Context,
+	ruleType domain.RuleType,
+	ruleID, accountID uuid.UUID,
+	subject S,
+	reason, explanation string,
+) {
+	if e.cfg.Pause == nil {
+		return
+	}
+	rule, err := e.cfg.Pause(ctx, ruleType, ruleID)
+	if errors.Is(err, ErrNotActive) {
+		return
+	}
+	if err != nil {
+		log.Printf("[%s] ERROR pausing %s rule %s: %v", e.cfg.Name, ruleType, ruleID, err)
+		return
+	}
+	// Een user-level rule heeft zelf geen account; de pauze hoort bij het account van de limiet
+	rule.AccountID = accountID
+	log.Printf("[%s] Rule '%s' (%s) paused: %s", e.cfg.Name, rule.Name, rule.ID, reason)
+	e.writeLog(ctx, rule, e.describe(subject), domain.LogSkipped, pausedDetails{RulePaused: reason}, "")
+
+	if e.cfg.Notifications == nil {
+		return
+	}
+	_, err = e.cfg.Notifications.CreateNotification(ctx, store.CreateNotificationParams{
+		ConnectedAccountID: accountID,
+		Type:               domain.NotificationRulePaused,
+		Message:            fmt.Sprintf("Rule '%s' is gepauzeerd: %s.", rule.Name, explanation),
+		Details: marshalDetails(pausedNotification{
+			RuleType: ruleType,
+			RuleID:   rule.ID.String(),
+			RuleName: rule.Name,
+			Reason:   reason,
+		}),
+	})
+	if err != nil {
+		log.Printf("[%s] ERROR notifying about paused rule %s: %v", e.cfg.Name, rule.ID, err)
+	}
+}
+
+// pausedDetails is de action_details van de log die een gepauzeerde rule achterlaat
+type pausedDetails struct {
+	RulePaused string `json:"rule_paused"`
+}
+
+// pausedNotification zijn de details van een NotificationRulePaused melding
+type pausedNotification struct {
+	RuleType domain.RuleType `json:"rule_type,omitempty"`
+	RuleID   string          `json:"rule_id"`
+	RuleName string          `json:"rule_name"`
+	Reason   string          `json:"reason"`
+}
+
+// describe geeft de trigger_details van een subject, of nil zonder Config.Describe.
+func (e *Engine[S]) describe(subject S) any {
+	if e.cfg.Describe == nil {
+		return nil
+	}
+	return e.cfg.Describe(subject)
+}
+
+func (e *Engine[S]) writeLog(
+	ctx context.Context,
+	rule Rule,
+	triggerDetails any,
+	status domain.AutomationLogStatus,
+	actionDetails any,
+	errorMessage string,
+) {
+	params := store.CreateLogParams{
+		ConnectedAccountID: rule.AccountID,
+		RuleID:             &rule.ID,
+		Status:             status,
+		ErrorMessage:       errorMessage,
+	}
+	if rule.Version > 0 {
+		version := rule.Version
+		params.RuleVersion = &version
+	}
+	if runID, ok := ctx.Value(runIDKey{}).(uuid.UUID); ok {
+		params.RunID = &runID
+	}
+	if triggerDetails != nil {
+		params.TriggerDetails = marshalDetails(triggerDetails)
+	}
+	if actionDetails != nil {
+		params.ActionDetails = marshalDetails(actionDetails)
+	}
+
+	if err := e.cfg.Logs.CreateAutomationLog(ctx, params); err != nil {
+		log.Printf("[%s] ERROR saving %s log for rule %s: %v", e.cfg.Name, status, rule.ID, err)
+	}
+}
+
+func marshalDetails(details any) json.RawMessage {
+	if raw, ok := details.(json.RawMessage); ok {
+		return raw
+	}
+	data, err := json.Marshal(details)
+	if err != nil {
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,403 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingLogs struct {
+	logs []store.CreateLogParams
+}
+
+func (r *recordingLogs) CreateAutomationLog(_ context.Context, arg store.CreateLogParams) error {
+	r.logs = append(r.logs, arg)
+	return nil
+}
+
+func newTestEngine(t *testing.T, handled bool) (*Engine[string], *recordingLogs) {
+	t.Helper()
+	registry := newTestRegistry()
+	registry.RegisterAction(NewAction("fail", func(context.Context, string, Rule, NoParams) (Result, error) {
+		return Result{}, errors.New("google down")
+	}, nil))
+	registry.RegisterAction(NewAction("skip", func(context.Context, string, Rule, NoParams) (Result, error) {
+		return Result{Skipped: true, Details: map[string]string{"reason": "exists"}}, nil
+	}, nil))
+	registry.RegisterAction(NewAction("quiet", func(context.Context, string, Rule, NoParams) (Result, error) {
+		return Result{}, nil
+	}, nil))
+	registry.RegisterAction(NewAction("needs_pattern", func(context.Context, string, Rule, containsParams) (Result, error) {
+		return Result{}, nil
+	}, nil))
+
+	logs := &recordingLogs{}
+	engine := NewEngine(registry, Config[string]{
+		Name:     "Test",
+		Logs:     logs,
+		Describe: func(subject string) any { return map[string]string{"subject": subject} },
+		AlreadyHandled: func(context.Context, Rule, string) (bool, error) {
+			return handled, nil
+		},
+	})
+	return engine, logs
+}
+
+func testRule(triggerType, triggerParams, actionType string) Rule {
+	return Rule{
+		ID:            uuid.New(),
+		AccountID:     uuid.New(),
+		Name:          "Test rule",
+		Version:       4,
+		TriggerType:   triggerType,
+		TriggerParams: json.RawMessage(triggerParams),
+		ActionType:    actionType,
+	}
+}
+
+func TestEngine_Run(t *testing.T) {
+	tests := []struct {
+		name       string
+		rule       Rule
+		handled    bool
+		want       Outcome
+		wantStatus domain.AutomationLogStatus // leeg: geen automation log
+		wantAction string
+		wantError  string
+	}{
+		{
+			name:       "success",
+			rule:       testRule("contains", `{"pattern": "Dienst"}`, "echo"),
+			want:       OutcomeSuccess,
+			wantStatus: domain.LogSuccess,
+			wantAction: `{"subject":"Dienst"}`,
+		},
+		{
+			name:       "success without details",
+			rule:       testRule("always", ``, "quiet"),
+			want:       OutcomeSuccess,
+			wantStatus: domain.LogSuccess,
+			wantAction: `{"details":"Action executed successfully"}`,
+		},
+		{
+			name: "no match",
+			rule: testRule("contains", `{"pattern": "Vakantie"}`, "echo"),
+			want: OutcomeNoMatch,
+		},
+		{
+			name:    "already handled",
+			rule:    testRule("always", ``, "echo"),
+			handled: true,
+			want:    OutcomeDuplicate,
+		},
+		{
+			name: "invalid trigger params",
+			rule: testRule("contains", `{}`, "echo"),
+			want: OutcomeInvalid,
+		},
+		{
+			name: "unknown trigger",
+			rule: testRule("missing", ``, "echo"),
+			want: OutcomeInvalid,
+		},
+		{
+			name: "invalid action params",
+			rule: testRule("always", ``, "needs_pattern"),
+			want: OutcomeInvalid,
+		},
+		{
+			name:       "skipped",
+			rule:       testRule("always", ``, "skip"),
+			want:       OutcomeSkipped,
+			wantStatus: domain.LogSkipped,
+			wantAction: `{"reason":"exists"}`,
+		},
+		{
+			name:       "failure",
+			rule:       testRule("always", ``, "fail"),
+			want:       OutcomeFailure,
+			wantStatus: domain.LogFailure,
+			wantError:  "google down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, logs := newTestEngine(t, tt.handled)
+
+			outcome := engine.Run(context.Background(), &tt.rule, "Dienst")
+
+			assert.Equal(t, tt.want, outcome)
+			if tt.wantStatus == "" {
+				assert.Empty(t, logs.logs)
+				return
+			}
+			require.Len(t, logs.logs, 1)
+			entry := logs.logs[0]
+			assert.Equal(t, tt.wantStatus, entry.Status)
+			assert.Equal(t, tt.rule.AccountID, entry.ConnectedAccountID)
+			assert.Equal(t, tt.rule.ID, *entry.RuleID)
+			require.NotNil(t, entry.RuleVersion)
+			assert.Equal(t, 4, *entry.RuleVersion)
+			assert.JSONEq(t, `{"subject":"Dienst"}`, string(entry.TriggerDetails))
+			if tt.wantAction != "" {
+				assert.JSONEq(t, tt.wantAction, string(entry.ActionDetails))
+			} else {
+				assert.Nil(t, entry.ActionDetails)
+			}
+			assert.Equal(t, tt.wantError, entry.ErrorMessage)
+		})
+	}
+}
+
+func TestEngine_Run_UnknownVersion(t *testing.T) {
+	engine, logs := newTestEngine(t, false)
+	rule := testRule("always", ``, "echo")
+	rule.Version = 0
+
+	engine.Run(context.Background(), &rule, "Dienst")
+
+	require.Len(t, logs.logs, 1)
+	assert.Nil(t, logs.logs[0].RuleVersion)
+}
+
+func TestEngine_Run_RunID(t *testing.T) {
+	engine, logs := newTestEngine(t, false)
+	rule := testRule("always", ``, "echo")
+	runID := uuid.New()
+
+	// De ticker schrijft logs zonder run, een handmatige run met zijn eigen ID
+	engine.Run(context.Background(), &rule, "Dienst")
+	engine.Run(WithRunID(context.Background(), runID), &rule, "Dienst")
+
+	require.Len(t, logs.logs, 2)
+	assert.Nil(t, logs.logs[0].RunID)
+	assert.Equal(t, &runID, logs.logs[1].RunID)
+}
+
+func TestEngine_Due(t *testing.T) {
+	now := time.Date(2025, time.November, 17, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	engine, logs := newTestEngine(t, false)
+	var expired []uuid.UUID
+	engine.cfg.Expire = func(_ context.Context, rule Rule) error {
+		expired = append(expired, rule.ID)
+		return nil
+	}
+
+	open := testRule("always", ``, "echo")
+	assert.True(t, engine.Due(context.Background(), open, now))
+
+	notStarted := testRule("always", ``, "echo")
+	notStarted.Schedule = &domain.RuleSchedule{ValidFrom: &future}
+	assert.False(t, engine.Due(context.Background(), notStarted, now))
+	assert.Empty(t, expired)
+
+	ended := testRule("always", ``, "echo")
+	ended.Schedule = &domain.RuleSchedule{ValidUntil: &past}
+	assert.False(t, engine.Due(context.Background(), ended, now))
+	assert.Equal(t, []uuid.UUID{ended.ID}, expired)
+
+	require.Len(t, logs.logs, 1)
+	assert.Equal(t, domain.LogSkipped, logs.logs[0].Status)
+	assert.Equal(t, ended.ID, *logs.logs[0].RuleID)
+	assert.Nil(t, logs.logs[0].TriggerDetails)
+	assert.Contains(t, string(logs.logs[0].ActionDetails), "valid_until")
+}
+
+func TestEngine_Run_MaxExecutions(t *testing.T) {
+	engine, logs := newTestEngine(t, false)
+	count := 0
+	engine.cfg.RecordExecution = func(context.Context, Rule) (int, error) {
+		count++
+		return count, nil
+	}
+	expired := 0
+	engine.cfg.Expire = func(context.Context, Rule) error {
+		expired++
+		return nil
+	}
+
+	rule := testRule("always", ``, "echo")
+	rule.Schedule = &domain.RuleSchedule{MaxExecutions: 2}
+
+	assert.Equal(t, OutcomeSuccess, engine.Run(context.Background(), &rule, "Dienst"))
+	assert.Equal(t, OutcomeSuccess, engine.Run(context.Background(), &rule, "Dienst"))
+	assert.Equal(t, OutcomeDisabled, engine.Run(context.Background(), &rule, "Dienst"))
+
+	assert.Equal(t, 2, rule.Executions)
+	assert.Equal(t, 1, expired)
+	require.Len(t, logs.logs, 3) // twee uitvoeringen en de log van het uitzetten
+	assert.Contains(t, string(logs.logs[2].ActionDetails), "max_executions of 2 reached")
+}
+
+type recordingNotifications struct {
+	notifications []store.CreateNotificationParams
+}
+
+func (r *recordingNotifications) CreateNotification(
+	_ context.Context,
+	arg store.CreateNotificationParams,
+) (domain.Notification, error) {
+	r.notifications = append(r.notifications, arg)
+	return domain.Notification{}, nil
+}
+
+// counters houdt de tellers bij die de store voor de limieten bijhoudt: alleen uitgevoerde acties
+// tellen, per rule en per account.
+type counters struct {
+	perRule map[uuid.UUID]int
+	account int
+	paused  []uuid.UUID
+}
+
+// withCounters geeft de engine tellers die bij elke uitgevoerde actie oplopen, te beginnen bij accountDay.
+func withCounters(engine *Engine[string], limits Limits, accountDay int) (*counters, *recordingNotifications) {
+	c := &counters{perRule: map[uuid.UUID]int{}, account: accountDay}
+	notes := &recordingNotifications{}
+	engine.cfg.Limits = limits
+	engine.cfg.ExecutionCounts = func(_ context.Context, rule Rule) (int, int, error) {
+		return c.perRule[rule.ID], c.account, nil
+	}
+	engine.cfg.CountExecution = func(_ context.Context, rule Rule) (int, error) {
+		c.perRule[rule.ID]++
+		c.account++
+		return c.account, nil
+	}
+	engine.cfg.BusiestRule = func(context.Context, uuid.UUID) (domain.RuleType, uuid.UUID, error) {
+		var busiest uuid.UUID
+		for id, n := range c.perRule {
+			if n > c.perRule[busiest] {
+				busiest = id
+			}
+		}
+		return domain.RuleTypeCalendar, busiest, nil
+	}
+	engine.cfg.Pause = func(_ context.Context, _ domain.RuleType, ruleID uuid.UUID) (Rule, error) {
+		for _, id := range c.paused {
+			if id == ruleID {
+				return Rule{}, ErrNotActive
+			}
+		}
+		c.paused = append(c.paused, ruleID)
+		return Rule{ID: ruleID, Name: "Drukke rule", Version: 5}, nil
+	}
+	engine.cfg.Notifications = notes
+	return c, notes
+}
+
+func TestEngine_Run_RuleLimit(t *testing.T) {
+	engine, logs := newTestEngine(t, false)
+	c, notes := withCounters(engine, Limits{RulePerHour: 2}, 0)
+
+	rule := testRule("always", ``, "echo")
+	assert.Equal(t, OutcomeSuccess, engine.Run(context.Background(), &rule, "een"))
+	assert.Equal(t, OutcomeSuccess, engine.Run(context.Background(), &rule, "twee"))
+	assert.Equal(t, OutcomeLimited, engine.Run(context.Background(), &rule, "drie"))
+	assert.Equal(t, OutcomeDisabled, engine.Run(context.Background(), &rule, "vier"))
+
+	assert.Equal(t, []uuid.UUID{rule.ID}, c.paused)
+	require.Len(t, logs.logs, 3)
+	assert.Equal(t, domain.LogSkipped, logs.logs[2].Status)
+	assert.Equal(t, rule.AccountID, logs.logs[2].ConnectedAccountID)
+	assert.JSONEq(t, `{"subject":"drie"}`, string(logs.logs[2].TriggerDetails))
+	assert.JSONEq(t, `{"rule_paused":"more than 2 executions of this rule in one hour"}`, string(logs.logs[2].ActionDetails))
+	require.Len(t, notes.notifications, 1)
+	assert.Equal(t, domain.NotificationRulePaused, notes.notifications[0].Type)
+	assert.Equal(t, rule.AccountID, notes.notifications[0].ConnectedAccountID)
+	assert.Contains(t, string(notes.notifications[0].Details), rule.ID.String())
+}
+
+func TestEngine_Run_RuleLimitFromSchedule(t *testing.T) {
+	engine, _ := newTestEngine(t, false)
+	c, _ := withCounters(engine, Limits{RulePerHour: 100}, 0)
+
+	rule := testRule("always", ``, "echo")
+	rule.Schedule = &domain.RuleSchedule{MaxExecutionsPerHour: 1}
+	assert.Equal(t, OutcomeSuccess, engine.Run(context.Background(), &rule, "een"))
+	assert.Equal(t, OutcomeLimited, engine.Run(context.Background(), &rule, "twee"))
+	assert.Equal(t, []uuid.UUID{rule.ID}, c.paused)
+}
+
+func TestEngine_Run_OnlyExecutedActionsCount(t *testing.T) {
+	engine, _ := newTestEngine(t, false)
+	c, _ := withCounters(engine, Limits{RulePerHour: 1}, 0)
+
+	// Overgeslagen en mislukte acties tellen niet mee voor de limiet
+	failing := testRule("always", ``, "fail")
+	skipping := testRule("always", ``, "skip")
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, OutcomeFailure, engine.Run(context.Background(), &failing, "fout"))
+		assert.Equal(t, OutcomeSkipped, engine.Run(context.Background(), &skipping, "bestaat"))
+	}
+	assert.Empty(t, c.perRule)
+	assert.Empty(t, c.paused)
+}
+
+func TestEngine_Run_AccountLimit(t *testing.T) {
+	engine, logs := newTestEngine(t, false)
+	c, notes := withCounters(engine, Limits{AccountPerDay: 10}, 5)
+
+	busy := testRule("always", ``, "echo")
+	quiet := testRule("always", ``, "echo")
+	quiet.AccountID = busy.AccountID
+
+	assert.Equal(t, OutcomeSuccess, engine.Run(context.Background(), &busy, "een"))
+	assert.Equal(t, OutcomeSuccess, engine.Run(context.Background(), &busy, "twee"))
+	assert.Equal(t, OutcomeSuccess, engine.Run(context.Background(), &busy, "drie"))
+	assert.Equal(t, OutcomeSuccess, engine.Run(context.Background(), &quiet, "drie"))
+	// De tiende actie bereikt de daglimiet: niet de rule die hem uitvoert maar de drukste rule wordt gepauzeerd
+	assert.Equal(t, OutcomeSuccess, engine.Run(context.Background(), &quiet, "vier"))
+	assert.Equal(t, []uuid.UUID{busy.ID}, c.paused)
+	assert.False(t, quiet.disabled)
+
+	// Daarna slaan de overige rules van het account de rest van de dag over, zonder pauze
+	assert.Equal(t, OutcomeLimited, engine.Run(context.Background(), &quiet, "vijf"))
+	assert.Len(t, c.paused, 1)
+
+	require.Len(t, logs.logs, 6)
+	pauseLog := logs.logs[5]
+	assert.Equal(t, busy.ID, *pauseLog.RuleID)
+	assert.Equal(t, busy.AccountID, pauseLog.ConnectedAccountID)
+	assert.JSONEq(t, `{"subject":"vier"}`, string(pauseLog.TriggerDetails))
+	assert.Contains(t, string(pauseLog.ActionDetails), "limit of 10 executions today")
+	require.Len(t, notes.notifications, 1)
+	assert.Contains(t, notes.notifications[0].Message, "Drukke rule")
+}
+
+func TestEngine_Run_CounterError(t *testing.T) {
+	engine, logs := newTestEngine(t, false)
+	engine.cfg.Limits = Limits{RulePerHour: 1}
+	engine.cfg.ExecutionCounts = func(context.Context, Rule) (int, int, error) {
+		return 0, 0, errors.New("db down")
+	}
+	engine.cfg.CountExecution = func(context.Context, Rule) (int, error) {
+		return 0, errors.New("db down")
+	}
+
+	rule := testRule("always", ``, "echo")
+	assert.Equal(t, OutcomeSuccess, engine.Run(context.Background(), &rule, "Dienst"))
+	assert.Len(t, logs.logs, 1)
+}
+
+func TestLimitsFromEnv(t *testing.T) {
+	t.Setenv("RULE_MAX_EXECUTIONS_PER_HOUR", "25")
+	t.Setenv("ACCOUNT_MAX_EXECUTIONS_PER_DAY", "veel")
+
+	assert.Equal(t, Limits{RulePerHour: 25, AccountPerDay: DefaultAccountPerDay}, LimitsFromEnv())
+
+	t.Setenv("RULE_MAX_EXECUTIONS_PER_HOUR", "0")
+	t.Setenv("ACCOUNT_MAX_EXECUTIONS_PER_DAY", "")
+	assert.Equal(t, Limits{RulePerHour: 0, AccountPerDay: DefaultAccountPerDay}, LimitsFromEnv())
+}
@@ -0,0 +1,91 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Standaardlimieten tegen op hol geslagen rules, bijv. een auto_reply op elk nieuw bericht
+const (
+	DefaultRulePerHour   = 100
+	DefaultAccountPerDay = 1000
+)
+
+// Limits begrenst hoe vaak rules acties mogen uitvoeren; 0 is onbeperkt. Overgeslagen en mislukte
+// uitvoeringen tellen niet mee.
+type Limits struct {
+	// RulePerHour is het maximum aantal uitvoeringen van één rule per klokuur
+	RulePerHour int
+	// AccountPerDay is het maximum aantal uitvoeringen van alle rules van een account per UTC dag
+	AccountPerDay int
+}
+
+// LimitsFromEnv leest de limieten uit RULE_MAX_EXECUTIONS_PER_HOUR en
+// ACCOUNT_MAX_EXECUTIONS_PER_DAY, met de standaardwaarden als een variabele ontbreekt.
+func LimitsFromEnv() Limits {
+	return Limits{
+		RulePerHour:   envLimit("RULE_MAX_EXECUTIONS_PER_HOUR", DefaultRulePerHour),
+		AccountPerDay: envLimit("ACCOUNT_MAX_EXECUTIONS_PER_DAY", DefaultAccountPerDay),
+	}
+}
+
+func envLimit(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		log.Printf("[Rules] Invalid %s %q, using %d", name, value, fallback)
+		return fallback
+	}
+	return limit
+}
+
+// ErrNotActive geeft Config.Pause terug voor een rule die al uit staat.
+var ErrNotActive = errors.New("rule is not active")
+
+// RulePauser zet rules van beide services uit namens de worker; store.Storer voldoet hieraan.
+type RulePauser interface {
+	PauseRule(ctx context.Context, ruleID uuid.UUID) (domain.AutomationRule, error)
+	PauseGmailRule(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error)
+}
+
+// PauseWith geeft een Config.Pause die calendar- en Gmail rules via p uitzet.
+func PauseWith(p RulePauser) func(ctx context.Context, ruleType domain.RuleType, ruleID uuid.UUID) (Rule, error) {
+	return func(ctx context.Context, ruleType domain.RuleType, ruleID uuid.UUID) (Rule, error) {
+		var paused domain.BaseAutomationRule
+		var err error
+		switch ruleType {
+		case domain.RuleTypeGmail:
+			var rule domain.GmailAutomationRule
+			rule, err = p.PauseGmailRule(ctx, ruleID)
+			paused = rule.BaseAutomationRule
+		default:
+			var rule domain.AutomationRule
+			rule, err = p.PauseRule(ctx, ruleID)
+			paused = rule.BaseAutomationRule
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Rule{}, ErrNotActive
+		}
+		if err != nil {
+			return Rule{}, err
+		}
+		return Rule{
+			ID:        paused.ID,
+			AccountID: paused.ConnectedAccountID,
+			Name:      paused.Name,
+			Version:   paused.Version,
+			Schedule:  paused.Schedule,
+		}, nil
+	}
+}
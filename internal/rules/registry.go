@@ -0,0 +1,118 @@
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	// ErrUnknownTrigger wordt teruggegeven voor een trigger type dat niet geregistreerd is.
+	ErrUnknownTrigger = errors.New("unknown trigger type")
+	// ErrUnknownAction wordt teruggegeven voor een action type dat niet geregistreerd is.
+	ErrUnknownAction = errors.New("unknown action type")
+)
+
+// Registry bevat de triggers en acties van één service, op type naam.
+// Registreren gebeurt bij het opstarten; daarna is een Registry alleen-lezen en veilig voor gelijktijdig gebruik.
+type Registry[S any] struct {
+	triggers map[string]Trigger[S]
+	actions  map[string]Action[S]
+}
+
+// NewRegistry maakt een lege Registry.
+func NewRegistry[S any]() *Registry[S] {
+	return &Registry[S]{
+		triggers: make(map[string]Trigger[S]),
+		actions:  make(map[string]Action[S]),
+	}
+}
+
+// RegisterTrigger voegt een trigger toe. Een type twee keer registreren is een programmeerfout.
+func (r *Registry[S]) RegisterTrigger(t Trigger[S]) {
+	if _, exists := r.triggers[t.Type()]; exists {
+		panic(fmt.Sprintf("rules: trigger %q already registered", t.Type()))
+	}
+	r.triggers[t.Type()] = t
+}
+
+// RegisterAction voegt een actie toe. Een type twee keer registreren is een programmeerfout.
+func (r *Registry[S]) RegisterAction(a Action[S]) {
+	if _, exists := r.actions[a.Type()]; exists {
+		panic(fmt.Sprintf("rules: action %q already registered", a.Type()))
+	}
+	r.actions[a.Type()] = a
+}
+
+// Trigger geeft de trigger voor een type.
+func (r *Registry[S]) Trigger(name string) (Trigger[S], error) {
+	t, ok := r.triggers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTrigger, name)
+	}
+	return t, nil
+}
+
+// Action geeft de actie voor een type.
+func (r *Registry[S]) Action(name string) (Action[S], error) {
+	a, ok := r.actions[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAction, name)
+	}
+	return a, nil
+}
+
+// TriggerTypes geeft de geregistreerde trigger types, gesorteerd.
+func (r *Registry[S]) TriggerTypes() []string {
+	return sortedKeys(r.triggers)
+}
+
+// ActionTypes geeft de geregistreerde action types, gesorteerd.
+func (r *Registry[S]) ActionTypes() []string {
+	return sortedKeys(r.actions)
+}
+
+// ValidateTrigger controleert dat het type bestaat en de parameters geldig zijn.
+func (r *Registry[S]) ValidateTrigger(name string, params json.RawMessage) error {
+	t, err := r.Trigger(name)
+	if err != nil {
+		return err
+	}
+	return t.Validate(params)
+}
+
+// ValidateAction controleert dat het type bestaat en de parameters geldig zijn.
+func (r *Registry[S]) ValidateAction(name string, params json.RawMessage) error {
+	a, err := r.Action(name)
+	if err != nil {
+		return err
+	}
+	return a.Validate(params)
+}
+
+// ValidateRule controleert trigger en actie van een rule en geeft alle fouten terug, met
+// veldpaden zoals in de API: trigger_type, trigger_conditions.*, action_type en action_params.*.
+func (r *Registry[S]) ValidateRule(rule Rule) FieldErrors {
+	var errs FieldErrors
+	if t, err := r.Trigger(rule.TriggerType); err != nil {
+		errs.Add("trigger_type", "unknown trigger type %q", rule.TriggerType)
+	} else {
+		errs = append(errs, AsFieldErrors(t.Validate(rule.TriggerParams), "trigger_conditions")...)
+	}
+	if a, err := r.Action(rule.ActionType); err != nil {
+		errs.Add("action_type", "unknown action type %q", rule.ActionType)
+	} else {
+		errs = append(errs, AsFieldErrors(a.Validate(rule.ActionParams), "action_params")...)
+	}
+	return errs
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,101 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type containsParams struct {
+	Pattern string `json:"pattern"`
+}
+
+func (p containsParams) Validate() error {
+	var errs FieldErrors
+	if p.Pattern == "" {
+		errs.Add("pattern", "is required")
+	}
+	return errs.Err()
+}
+
+func newTestRegistry() *Registry[string] {
+	r := NewRegistry[string]()
+	r.RegisterTrigger(NewTrigger("contains", func(_ context.Context, subject string, p containsParams) (bool, error) {
+		return strings.Contains(subject, p.Pattern), nil
+	}))
+	r.RegisterTrigger(NewTrigger("always", func(context.Context, string, NoParams) (bool, error) {
+		return true, nil
+	}))
+	r.RegisterAction(NewAction("echo", func(_ context.Context, subject string, _ Rule, _ NoParams) (Result, error) {
+		return Result{Details: map[string]string{"subject": subject}}, nil
+	}, func(_ context.Context, subject string, _ Rule, _ NoParams) (any, error) {
+		return "echo " + subject, nil
+	}))
+	return r
+}
+
+func TestRegistry_Lookup(t *testing.T) {
+	r := newTestRegistry()
+
+	trigger, err := r.Trigger("contains")
+	require.NoError(t, err)
+	assert.Equal(t, "contains", trigger.Type())
+
+	_, err = r.Trigger("missing")
+	assert.ErrorIs(t, err, ErrUnknownTrigger)
+	_, err = r.Action("missing")
+	assert.ErrorIs(t, err, ErrUnknownAction)
+
+	assert.Equal(t, []string{"always", "contains"}, r.TriggerTypes())
+	assert.Equal(t, []string{"echo"}, r.ActionTypes())
+}
+
+func TestRegistry_DuplicateRegistrationPanics(t *testing.T) {
+	r := newTestRegistry()
+	assert.Panics(t, func() {
+		r.RegisterAction(NewAction("echo", func(context.Context, string, Rule, NoParams) (Result, error) {
+			return Result{}, nil
+		}, nil))
+	})
+}
+
+func TestRegistry_Validate(t *testing.T) {
+	r := newTestRegistry()
+
+	assert.NoError(t, r.ValidateTrigger("contains", json.RawMessage(`{"pattern": "x"}`)))
+	assert.EqualError(t, r.ValidateTrigger("contains", json.RawMessage(`{}`)), "pattern: is required")
+	assert.ErrorContains(t, r.ValidateTrigger("contains", json.RawMessage(`{"pattern": 1}`)), "invalid params")
+	assert.NoError(t, r.ValidateTrigger("always", nil))
+	assert.ErrorIs(t, r.ValidateAction("delete", nil), ErrUnknownAction)
+}
+
+func TestRegistry_ValidateRuleCollectsAllFields(t *testing.T) {
+	r := newTestRegistry()
+
+	errs := r.ValidateRule(Rule{TriggerType: "contains", TriggerParams: json.RawMessage(`{}`), ActionType: "delete"})
+	assert.Equal(t, FieldErrors{
+		{Field: "trigger_conditions.pattern", Message: "is required"},
+		{Field: "action_type", Message: `unknown action type "delete"`},
+	}, errs)
+
+	errs = r.ValidateRule(Rule{TriggerType: "contains", TriggerParams: json.RawMessage(`{"pattern": 1}`), ActionType: "echo"})
+	assert.Equal(t, FieldErrors{{Field: "trigger_conditions.pattern", Message: "must be of type string"}}, errs)
+
+	assert.Empty(t, r.ValidateRule(Rule{TriggerType: "always", ActionType: "echo"}))
+}
+
+func TestTrigger_MatchDecodesParams(t *testing.T) {
+	trigger, err := newTestRegistry().Trigger("contains")
+	require.NoError(t, err)
+
+	matched, err := trigger.Match(context.Background(), "Dienst Utrecht", json.RawMessage(`{"pattern": "Dienst"}`))
+	require.NoError(t, err)
+	assert.True(t, matched)
+
+	_, err = trigger.Match(context.Background(), "Dienst", json.RawMessage(`{}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,50 @@
+package rules
+
+import "time"
+
+// RevertItem is één gelogde uitvoering die teruggedraaid werd, of waarom dat niet lukte.
+type RevertItem struct {
+	LogID     int64     `json:"log_id"`
+	Timestamp time.Time `json:"timestamp"`
+	// Target is het event of bericht waarop de actie werkte
+	Target string `json:"target,omitempty"`
+	Action string `json:"action,omitempty"`
+	// Reason is alleen gezet als de actie niet teruggedraaid kon worden
+	Reason string `json:"reason,omitempty"`
+}
+
+// RevertReport is het resultaat van het terugdraaien van de uitvoeringen van een rule.
+type RevertReport struct {
+	Reverted    []RevertItem `json:"reverted"`
+	NotReverted []RevertItem `json:"not_reverted"`
+}
+
+// NewRevertReport maakt een leeg rapport, met lege lijsten in plaats van null in de JSON.
+func NewRevertReport() RevertReport {
+	return RevertReport{Reverted: []RevertItem{}, NotReverted: []RevertItem{}}
+}
+
+// Add voegt item toe als teruggedraaid, of als niet teruggedraaid met reason als die niet leeg is.
+func (r *RevertReport) Add(item RevertItem, reason string) {
+	if reason == "" {
+		r.Reverted = append(r.Reverted, item)
+		return
+	}
+	item.Reason = reason
+	r.NotReverted = append(r.NotReverted, item)
+}
+
+// Merge voegt de items van other aan het rapport toe, bijv. van een volgend account.
+func (r *RevertReport) Merge(other RevertReport) {
+	r.Reverted = append(r.Reverted, other.Reverted...)
+	r.NotReverted = append(r.NotReverted, other.NotReverted...)
+}
+
+// RevertedLogIDs geeft de IDs van de teruggedraaide logs.
+func (r RevertReport) RevertedLogIDs() []int64 {
+	ids := make([]int64, 0, len(r.Reverted))
+	for _, item := range r.Reverted {
+		ids = append(ids, item.LogID)
+	}
+	return ids
+}
@@ -0,0 +1,143 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// Rule is de service-onafhankelijke vorm van een automation rule.
+type Rule struct {
+	ID            uuid.UUID
+	AccountID     uuid.UUID
+	Name          string
+	Version       int // komt als rule_version in de automation log; 0 als onbekend
+	TriggerType   string
+	TriggerParams json.RawMessage
+	ActionType    string
+	ActionParams  json.RawMessage
+	Schedule      *domain.RuleSchedule // nil als de rule altijd mag draaien
+	Executions    int                  // geslaagde uitvoeringen, zie domain.RuleSchedule.MaxExecutions
+
+	// disabled is gezet als de engine de rule tijdens deze ronde heeft uitgezet
+	disabled bool
+}
+
+// Result beschrijft wat een actie gedaan heeft.
+type Result struct {
+	// Skipped betekent dat de actie bewust niets deed, bijv. omdat het resultaat al bestond
+	Skipped bool
+	// Details komt als action_details in de automation log
+	Details any
+}
+
+// Trigger bepaalt of een rule van toepassing is op een subject, zoals een event of bericht.
+type Trigger[S any] interface {
+	Type() string
+	Validate(params json.RawMessage) error
+	Match(ctx context.Context, subject S, params json.RawMessage) (bool, error)
+}
+
+// Action voert een rule uit op een subject.
+type Action[S any] interface {
+	Type() string
+	Validate(params json.RawMessage) error
+	Execute(ctx context.Context, subject S, rule Rule) (Result, error)
+	// Preview beschrijft wat Execute zou doen, zonder iets te wijzigen. Nil als de actie geen preview heeft.
+	Preview(ctx context.Context, subject S, rule Rule) (any, error)
+}
+
+// Validator is geïmplementeerd door parameter structs die meer controleren dan JSON decoding.
+type Validator interface {
+	Validate() error
+}
+
+// NoParams is het parametertype van triggers en acties zonder parameters.
+type NoParams struct{}
+
+// DecodeParams decodeert params in P en valideert het resultaat als P een Validator is.
+// Lege params geven de zero value van P. Een Validator geeft bij voorkeur FieldErrors terug,
+// zodat de API elk ongeldig veld kan melden.
+func DecodeParams[P any](params json.RawMessage) (P, error) {
+	var p P
+	if len(bytes.TrimSpace(params)) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return p, fmt.Errorf("invalid params: %w", decodeFieldErrors(err))
+		}
+	}
+	if v, ok := any(&p).(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return p, err
+		}
+	}
+	return p, nil
+}
+
+// NewTrigger maakt een Trigger van een match functie met getypeerde parameters.
+func NewTrigger[S, P any](name string, match func(ctx context.Context, subject S, params P) (bool, error)) Trigger[S] {
+	return &typedTrigger[S, P]{name: name, match: match}
+}
+
+type typedTrigger[S, P any] struct {
+	name  string
+	match func(ctx context.Context, subject S, params P) (bool, error)
+}
+
+func (t *typedTrigger[S, P]) Type() string { return t.name }
+
+func (t *typedTrigger[S, P]) Validate(params json.RawMessage) error {
+	_, err := DecodeParams[P](params)
+	return err
+}
+
+func (t *typedTrigger[S, P]) Match(ctx context.Context, subject S, params json.RawMessage) (bool, error) {
+	p, err := DecodeParams[P](params)
+	if err != nil {
+		return false, err
+	}
+	return t.match(ctx, subject, p)
+}
+
+// NewAction maakt een Action van een execute functie met getypeerde parameters. preview is
+// optioneel en mag geen Google API aanroepen die iets wijzigen of logs schrijven.
+func NewAction[S, P any](
+	name string,
+	execute func(ctx context.Context, subject S, rule Rule, params P) (Result, error),
+	preview func(ctx context.Context, subject S, rule Rule, params P) (any, error),
+) Action[S] {
+	return &typedAction[S, P]{name: name, execute: execute, preview: preview}
+}
+
+type typedAction[S, P any] struct {
+	name    string
+	execute func(ctx context.Context, subject S, rule Rule, params P) (Result, error)
+	preview func(ctx context.Context, subject S, rule Rule, params P) (any, error)
+}
+
+func (a *typedAction[S, P]) Type() string { return a.name }
+
+func (a *typedAction[S, P]) Validate(params json.RawMessage) error {
+	_, err := DecodeParams[P](params)
+	return err
+}
+
+func (a *typedAction[S, P]) Execute(ctx context.Context, subject S, rule Rule) (Result, error) {
+	p, err := DecodeParams[P](rule.ActionParams)
+	if err != nil {
+		return Result{}, err
+	}
+	return a.execute(ctx, subject, rule, p)
+}
+
+func (a *typedAction[S, P]) Preview(ctx context.Context, subject S, rule Rule) (any, error) {
+	p, err := DecodeParams[P](rule.ActionParams)
+	if err != nil || a.preview == nil {
+		return nil, err
+	}
+	return a.preview(ctx, subject, rule, p)
+}
@@ -0,0 +1,155 @@
+package rules
+
+import (
+	"fmt"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+)
+
+// ScheduleState is de toestand van een rule volgens zijn schedule op een bepaald moment.
+type ScheduleState string
+
+const (
+	ScheduleOpen    ScheduleState = "open"    // de rule mag nu draaien
+	ScheduleClosed  ScheduleState = "closed"  // nog niet begonnen, of buiten de weekdagen en tijdvakken
+	ScheduleExpired ScheduleState = "expired" // voorbij valid_until of max_executions bereikt; de rule moet uit
+)
+
+// weekdays koppelt de afkortingen uit RuleSchedule.Weekdays aan time.Weekday
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// clockLayout is het formaat van TimeWindow.Start en End
+const clockLayout = "15:04"
+
+// ValidateSchedule geeft alle ongeldige velden van een schedule. Een nil schedule is geldig.
+func ValidateSchedule(schedule *domain.RuleSchedule) FieldErrors {
+	var errs FieldErrors
+	if schedule == nil {
+		return errs
+	}
+	if schedule.ValidFrom != nil && schedule.ValidUntil != nil && !schedule.ValidUntil.After(*schedule.ValidFrom) {
+		errs.Add("valid_until", "must be after valid_from")
+	}
+	switch {
+	case schedule.Timezone != "":
+		if _, err := time.LoadLocation(schedule.Timezone); err != nil {
+			errs.Add("timezone", "unknown timezone %q", schedule.Timezone)
+		}
+	// Weekdagen en tijdvakken zijn lokale tijd; zonder zone zou stilzwijgend UTC gelden
+	case len(schedule.Weekdays) > 0 || len(schedule.Windows) > 0:
+		errs.Add("timezone", "is required with weekdays or windows")
+	}
+	seen := make(map[string]bool, len(schedule.Weekdays))
+	for i, day := range schedule.Weekdays {
+		field := fmt.Sprintf("weekdays[%d]", i)
+		switch _, ok := weekdays[day]; {
+		case !ok:
+			errs.Add(field, "must be one of mon, tue, wed, thu, fri, sat, sun")
+		case seen[day]:
+			errs.Add(field, "duplicate weekday %q", day)
+		}
+		seen[day] = true
+	}
+	for i, window := range schedule.Windows {
+		start, startErr := time.Parse(clockLayout, window.Start)
+		if startErr != nil {
+			errs.Add(fmt.Sprintf("windows[%d].start", i), "must be a time as HH:MM")
+		}
+		end, endErr := time.Parse(clockLayout, window.End)
+		if endErr != nil {
+			errs.Add(fmt.Sprintf("windows[%d].end", i), "must be a time as HH:MM")
+		}
+		if startErr == nil && endErr == nil && start.Equal(end) {
+			errs.Add(fmt.Sprintf("windows[%d].end", i), "must differ from start")
+		}
+	}
+	if schedule.MaxExecutions < 0 {
+		errs.Add("max_executions", "must not be negative")
+	}
+	if schedule.MaxExecutionsPerHour < 0 {
+		errs.Add("max_executions_per_hour", "must not be negative")
+	}
+	return errs
+}
+
+// EvaluateSchedule bepaalt of een rule met dit schedule en aantal geslaagde uitvoeringen op now
+// mag draaien. Bij ScheduleExpired beschrijft de reden waarom. Een ongeldig schedule telt als
+// ScheduleClosed, zodat een rule met een fout in zijn schedule niet onbedoeld draait.
+func EvaluateSchedule(schedule *domain.RuleSchedule, executions int, now time.Time) (ScheduleState, string) {
+	if schedule == nil {
+		return ScheduleOpen, ""
+	}
+	if schedule.MaxExecutions > 0 && executions >= schedule.MaxExecutions {
+		return ScheduleExpired, fmt.Sprintf("max_executions of %d reached", schedule.MaxExecutions)
+	}
+	if schedule.ValidUntil != nil && !now.Before(*schedule.ValidUntil) {
+		return ScheduleExpired, "valid_until " + schedule.ValidUntil.Format(time.RFC3339) + " passed"
+	}
+	if schedule.ValidFrom != nil && now.Before(*schedule.ValidFrom) {
+		return ScheduleClosed, ""
+	}
+
+	if len(schedule.Weekdays) == 0 && len(schedule.Windows) == 0 {
+		return ScheduleOpen, ""
+	}
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if schedule.Timezone == "" || err != nil {
+		return ScheduleClosed, ""
+	}
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+
+	if len(schedule.Windows) == 0 {
+		if dayAllowed(schedule.Weekdays, local.Weekday()) {
+			return ScheduleOpen, ""
+		}
+		return ScheduleClosed, ""
+	}
+	for _, window := range schedule.Windows {
+		start, err1 := time.Parse(clockLayout, window.Start)
+		end, err2 := time.Parse(clockLayout, window.End)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		startMin := start.Hour()*60 + start.Minute()
+		endMin := end.Hour()*60 + end.Minute()
+
+		switch {
+		case startMin < endMin:
+			if minute >= startMin && minute < endMin && dayAllowed(schedule.Weekdays, local.Weekday()) {
+				return ScheduleOpen, ""
+			}
+		// Een tijdvak over middernacht hoort bij de dag waarop het begint
+		case minute >= startMin:
+			if dayAllowed(schedule.Weekdays, local.Weekday()) {
+				return ScheduleOpen, ""
+			}
+		case minute < endMin:
+			if dayAllowed(schedule.Weekdays, local.AddDate(0, 0, -1).Weekday()) {
+				return ScheduleOpen, ""
+			}
+		}
+	}
+	return ScheduleClosed, ""
+}
+
+func dayAllowed(days []string, day time.Weekday) bool {
+	if len(days) == 0 {
+		return true
+	}
+	for _, d := range days {
+		if wd, ok := weekdays[d]; ok && wd == day {
+			return true
+		}
+	}
+	return false
+}
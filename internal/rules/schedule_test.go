@@ -0,0 +1,102 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSchedule(t *testing.T) {
+	from := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+	until := from.AddDate(0, 0, -1)
+
+	assert.Empty(t, ValidateSchedule(nil))
+	assert.Empty(t, ValidateSchedule(&domain.RuleSchedule{
+		Timezone: "Europe/Amsterdam",
+		Weekdays: []string{"mon", "fri"},
+		Windows:  []domain.TimeWindow{{Start: "18:00", End: "08:00"}},
+	}))
+
+	errs := ValidateSchedule(&domain.RuleSchedule{
+		ValidFrom:            &from,
+		ValidUntil:           &until,
+		Timezone:             "Mars/Olympus",
+		Weekdays:             []string{"mon", "maandag", "mon"},
+		Windows:              []domain.TimeWindow{{Start: "9:00am", End: "17:00"}, {Start: "10:00", End: "10:00"}},
+		MaxExecutions:        -1,
+		MaxExecutionsPerHour: -5,
+	})
+	fields := make([]string, len(errs))
+	for i, fe := range errs {
+		fields[i] = fe.Field
+	}
+	assert.Equal(t, []string{
+		"valid_until", "timezone", "weekdays[1]", "weekdays[2]",
+		"windows[0].start", "windows[1].end", "max_executions", "max_executions_per_hour",
+	}, fields)
+
+	errs = ValidateSchedule(&domain.RuleSchedule{Windows: []domain.TimeWindow{{Start: "09:00", End: "17:00"}}})
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, "timezone", errs[0].Field)
+	}
+	assert.Empty(t, ValidateSchedule(&domain.RuleSchedule{ValidUntil: &from}))
+}
+
+func TestEvaluateSchedule(t *testing.T) {
+	amsterdam, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Skip("tzdata not available")
+	}
+	// Maandag 17 november 2025
+	monday := func(hour, minute int) time.Time {
+		return time.Date(2025, time.November, 17, hour, minute, 0, 0, amsterdam)
+	}
+	holidayStart := time.Date(2025, time.July, 1, 0, 0, 0, 0, amsterdam)
+	holidayEnd := holidayStart.AddDate(0, 0, 14)
+
+	officeHours := &domain.RuleSchedule{
+		Timezone: "Europe/Amsterdam",
+		Weekdays: []string{"mon", "tue", "wed", "thu", "fri"},
+		Windows:  []domain.TimeWindow{{Start: "09:00", End: "17:00"}},
+	}
+	outsideOffice := &domain.RuleSchedule{
+		Timezone: "Europe/Amsterdam",
+		Weekdays: []string{"fri"},
+		Windows:  []domain.TimeWindow{{Start: "18:00", End: "08:00"}},
+	}
+	holiday := &domain.RuleSchedule{ValidFrom: &holidayStart, ValidUntil: &holidayEnd}
+
+	tests := []struct {
+		name       string
+		schedule   *domain.RuleSchedule
+		executions int
+		now        time.Time
+		want       ScheduleState
+	}{
+		{"no schedule", nil, 0, monday(3, 0), ScheduleOpen},
+		{"inside window", officeHours, 0, monday(9, 0), ScheduleOpen},
+		{"window end is exclusive", officeHours, 0, monday(17, 0), ScheduleClosed},
+		{"window in timezone", officeHours, 0, time.Date(2025, time.November, 17, 8, 30, 0, 0, time.UTC), ScheduleOpen},
+		{"weekend", officeHours, 0, monday(10, 0).AddDate(0, 0, -1), ScheduleClosed},
+		{"overnight on start day", outsideOffice, 0, monday(20, 0).AddDate(0, 0, -3), ScheduleOpen},
+		{"overnight after midnight", outsideOffice, 0, monday(7, 0).AddDate(0, 0, -2), ScheduleOpen},
+		{"overnight other day", outsideOffice, 0, monday(7, 0), ScheduleClosed},
+		{"before period", holiday, 0, holidayStart.Add(-time.Minute), ScheduleClosed},
+		{"in period", holiday, 0, holidayStart.AddDate(0, 0, 7), ScheduleOpen},
+		{"after period", holiday, 0, holidayEnd, ScheduleExpired},
+		{"max executions", &domain.RuleSchedule{MaxExecutions: 3}, 3, monday(12, 0), ScheduleExpired},
+		{"below max executions", &domain.RuleSchedule{MaxExecutions: 3}, 2, monday(12, 0), ScheduleOpen},
+		{"weekdays without timezone", &domain.RuleSchedule{Weekdays: []string{"mon"}}, 0, monday(12, 0), ScheduleClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, reason := EvaluateSchedule(tt.schedule, tt.executions, tt.now)
+			assert.Equal(t, tt.want, state)
+			assert.Equal(t, tt.want == ScheduleExpired, reason != "")
+		})
+	}
+}
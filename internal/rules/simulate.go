@@ -0,0 +1,30 @@
+package rules
+
+import "context"
+
+// Simulation is de uitkomst van een rule op één subject zonder uitvoering.
+type Simulation struct {
+	Matched bool
+	// Preview is wat de actie zou doen; alleen gezet bij een match
+	Preview any
+}
+
+// Simulate evalueert de trigger van een rule en geeft bij een match de preview van de actie.
+// Er wordt niets uitgevoerd, niets gededupliceerd en niets gelogd.
+func (r *Registry[S]) Simulate(ctx context.Context, rule Rule, subject S) (Simulation, error) {
+	trigger, err := r.Trigger(rule.TriggerType)
+	if err != nil {
+		return Simulation{}, err
+	}
+	action, err := r.Action(rule.ActionType)
+	if err != nil {
+		return Simulation{}, err
+	}
+
+	matched, err := trigger.Match(ctx, subject, rule.TriggerParams)
+	if err != nil || !matched {
+		return Simulation{}, err
+	}
+	preview, err := action.Preview(ctx, subject, rule)
+	return Simulation{Matched: true, Preview: preview}, err
+}
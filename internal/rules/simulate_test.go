@@ -0,0 +1,36 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Simulate(t *testing.T) {
+	r := newTestRegistry()
+	r.RegisterAction(NewAction("silent", func(context.Context, string, Rule, NoParams) (Result, error) {
+		t.Fatal("simulate must not execute actions")
+		return Result{}, nil
+	}, nil))
+	rule := Rule{TriggerType: "contains", TriggerParams: json.RawMessage(`{"pattern": "Dienst"}`), ActionType: "echo"}
+
+	sim, err := r.Simulate(context.Background(), rule, "Vrije dag")
+	require.NoError(t, err)
+	assert.Equal(t, Simulation{}, sim)
+
+	sim, err = r.Simulate(context.Background(), rule, "Dienst Utrecht")
+	require.NoError(t, err)
+	assert.Equal(t, Simulation{Matched: true, Preview: "echo Dienst Utrecht"}, sim)
+
+	rule.ActionType = "silent"
+	sim, err = r.Simulate(context.Background(), rule, "Dienst")
+	require.NoError(t, err)
+	assert.Equal(t, Simulation{Matched: true}, sim)
+
+	rule.ActionType = "delete"
+	_, err = r.Simulate(context.Background(), rule, "Dienst")
+	assert.ErrorIs(t, err, ErrUnknownAction)
+}
@@ -0,0 +1,24 @@
+package rules
+
+import (
+	"regexp"
+	"strings"
+)
+
+// templateVariable vindt {{naam}} in een tekst, met optionele spaties rond de naam
+var templateVariable = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.]+)\s*\}\}`)
+
+// ExpandTemplate vervangt {{naam}} in text door de waarde uit values. Onbekende namen blijven
+// staan, zodat een tikfout in de tekst zichtbaar is in plaats van stil te verdwijnen.
+func ExpandTemplate(text string, values map[string]string) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	return templateVariable.ReplaceAllStringFunc(text, func(match string) string {
+		name := templateVariable.FindStringSubmatch(match)[1]
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return match
+	})
+}
@@ -0,0 +1,16 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	values := map[string]string{"summary": "Intake", "start": "2025-11-30 09:00"}
+
+	assert.Equal(t, "Bevestiging: Intake op 2025-11-30 09:00",
+		ExpandTemplate("Bevestiging: {{summary}} op {{ start }}", values))
+	assert.Equal(t, "Locatie: {{location}}", ExpandTemplate("Locatie: {{location}}", values))
+	assert.Equal(t, "Geen variabelen", ExpandTemplate("Geen variabelen", values))
+}
@@ -0,0 +1,88 @@
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FieldError is één ongeldig veld. Field is het pad binnen de gevalideerde waarde,
+// bijv. "offset_minutes" of "to[1]"; leeg betekent de waarde als geheel.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// FieldErrors verzamelt alle ongeldige velden van een validatie, zodat de API ze in één keer
+// kan teruggeven in plaats van alleen de eerste fout.
+type FieldErrors []FieldError
+
+// Add voegt een fout voor field toe.
+func (e *FieldErrors) Add(field, format string, args ...any) {
+	*e = append(*e, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err geeft nil als er geen fouten zijn, zodat Validate methodes kunnen eindigen met return errs.Err().
+func (e FieldErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e FieldErrors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		if fe.Field == "" {
+			parts[i] = fe.Message
+		} else {
+			parts[i] = fe.Field + ": " + fe.Message
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Prefixed geeft de fouten met prefix voor elk veldpad, bijv. "action_params".
+func (e FieldErrors) Prefixed(prefix string) FieldErrors {
+	prefixed := make(FieldErrors, len(e))
+	for i, fe := range e {
+		prefixed[i] = FieldError{Field: joinPath(prefix, fe.Field), Message: fe.Message}
+	}
+	return prefixed
+}
+
+// AsFieldErrors zet een validatiefout om naar veldfouten onder prefix. Een fout die geen
+// FieldErrors bevat wordt één fout op prefix zelf.
+func AsFieldErrors(err error, prefix string) FieldErrors {
+	if err == nil {
+		return nil
+	}
+	var fieldErrs FieldErrors
+	if errors.As(err, &fieldErrs) {
+		return fieldErrs.Prefixed(prefix)
+	}
+	return FieldErrors{{Field: prefix, Message: err.Error()}}
+}
+
+// decodeFieldErrors vertaalt een json.Unmarshal fout naar het veld waar die optrad.
+func decodeFieldErrors(err error) FieldErrors {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return FieldErrors{{Field: typeErr.Field, Message: fmt.Sprintf("must be of type %s", typeErr.Type)}}
+	}
+	return FieldErrors{{Message: err.Error()}}
+}
+
+func joinPath(prefix, field string) string {
+	switch {
+	case prefix == "":
+		return field
+	case field == "":
+		return prefix
+	case strings.HasPrefix(field, "["):
+		return prefix + field
+	default:
+		return prefix + "." + field
+	}
+}
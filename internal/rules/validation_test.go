@@ -0,0 +1,32 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldErrors(t *testing.T) {
+	var errs FieldErrors
+	assert.NoError(t, errs.Err())
+
+	errs.Add("to[1]", "invalid address %q", "x")
+	errs.Add("", "at least one field is required")
+	assert.EqualError(t, errs.Err(), `to[1]: invalid address "x"; at least one field is required`)
+
+	assert.Equal(t, FieldErrors{
+		{Field: "action_params.to[1]", Message: `invalid address "x"`},
+		{Field: "action_params", Message: "at least one field is required"},
+	}, errs.Prefixed("action_params"))
+}
+
+func TestAsFieldErrors(t *testing.T) {
+	assert.Nil(t, AsFieldErrors(nil, "action_params"))
+
+	wrapped := fmt.Errorf("invalid params: %w", FieldErrors{{Field: "mode", Message: "unknown"}})
+	assert.Equal(t, FieldErrors{{Field: "action_params.mode", Message: "unknown"}}, AsFieldErrors(wrapped, "action_params"))
+
+	assert.Equal(t, FieldErrors{{Field: "action_params", Message: "boom"}}, AsFieldErrors(errors.New("boom"), "action_params"))
+}
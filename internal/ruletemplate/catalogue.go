@@ -0,0 +1,175 @@
+package ruletemplate
+
+import "agenda-automator-api/internal/domain"
+
+// catalogue is de ingebouwde lijst met templates. Elke template moet met de voorbeeldwaarden
+// van zijn variabelen een geldige rule opleveren; zie TestCatalogue_ExamplesAreValid.
+var catalogue = []Template{
+	{
+		ID:          "calendar-reminder-day-before",
+		Name:        "Herinnering een dag van tevoren",
+		Description: "Zet een dag voor elke afspraak met een bepaald woord in de titel een herinnering in de agenda.",
+		RuleType:    domain.RuleTypeCalendar,
+		Variables: []Variable{
+			{
+				Name:        "keywords",
+				Description: "Woorden in de titel van de afspraak",
+				Type:        VariableStringList,
+				Required:    true,
+				Example:     []string{"Tandarts"},
+			},
+			{
+				Name:        "title",
+				Description: "Titel van de herinnering",
+				Type:        VariableString,
+				Default:     "Morgen: afspraak",
+				Example:     "Morgen: tandarts",
+			},
+		},
+		Rule: RuleTemplate{
+			Name:              "Herinnering dag van tevoren: {{keywords}}",
+			IsActive:          true,
+			TriggerConditions: map[string]any{"summary_contains": "{{keywords}}"},
+			ActionParams: map[string]any{
+				"new_event_title": "{{title}}",
+				"offset_minutes":  -24 * 60,
+				"duration_min":    15,
+			},
+		},
+	},
+	{
+		ID:          "calendar-leave-reminder",
+		Name:        "Vertrekherinnering",
+		Description: "Zet voor elke afspraak met een vaste titel, zoals een dienst, een herinnering om op tijd te vertrekken.",
+		RuleType:    domain.RuleTypeCalendar,
+		Variables: []Variable{
+			{
+				Name:        "summary",
+				Description: "Exacte titel van de afspraak",
+				Type:        VariableString,
+				Required:    true,
+				Example:     "Dienst",
+			},
+			{
+				Name:        "offset_minutes",
+				Description: "Minuten ten opzichte van de start van de afspraak; negatief is ervoor",
+				Type:        VariableInteger,
+				Default:     -45,
+				Example:     -30,
+			},
+		},
+		Rule: RuleTemplate{
+			Name:              "Vertrekken voor {{summary}}",
+			IsActive:          true,
+			TriggerConditions: map[string]any{"summary_equals": "{{summary}}"},
+			ActionParams: map[string]any{
+				"new_event_title": "Vertrekken",
+				"offset_minutes":  "{{offset_minutes}}",
+			},
+		},
+	},
+	{
+		ID:          "gmail-newsletters-label-archive",
+		Name:        "Nieuwsbrieven labelen en archiveren",
+		Description: "Geeft mail van nieuwsbrieven een label en haalt ze uit de inbox.",
+		RuleType:    domain.RuleTypeGmail,
+		Variables: []Variable{
+			{
+				Name:        "sender_pattern",
+				Description: "Deel van het afzenderadres, bijvoorbeeld newsletter@ of het domein van de afzender",
+				Type:        VariableString,
+				Required:    true,
+				Example:     "newsletter@",
+			},
+			{
+				Name:        "label_name",
+				Description: "Label voor de nieuwsbrieven; wordt aangemaakt als het nog niet bestaat",
+				Type:        VariableString,
+				Default:     "Nieuwsbrieven",
+			},
+		},
+		Rule: RuleTemplate{
+			Name:              "Nieuwsbrieven van {{sender_pattern}}",
+			IsActive:          true,
+			TriggerType:       string(domain.GmailTriggerSenderMatch),
+			TriggerConditions: map[string]any{"sender_pattern": "{{sender_pattern}}"},
+			ActionType:        string(domain.GmailActionAddLabel),
+			ActionParams:      map[string]any{"label_name": "{{label_name}}", "archive": true},
+		},
+	},
+	{
+		ID:          "gmail-invoices-label",
+		Name:        "Facturen labelen",
+		Description: "Geeft mail met een factuur in het onderwerp een label, zodat facturen bij elkaar staan.",
+		RuleType:    domain.RuleTypeGmail,
+		Variables: []Variable{
+			{
+				Name:        "subject_pattern",
+				Description: "Woord in het onderwerp",
+				Type:        VariableString,
+				Default:     "factuur",
+			},
+			{
+				Name:        "label_name",
+				Description: "Label voor de facturen; wordt aangemaakt als het nog niet bestaat",
+				Type:        VariableString,
+				Default:     "Facturen",
+			},
+		},
+		Rule: RuleTemplate{
+			Name:              "Facturen labelen",
+			IsActive:          true,
+			TriggerType:       string(domain.GmailTriggerSubjectMatch),
+			TriggerConditions: map[string]any{"subject_pattern": "{{subject_pattern}}"},
+			ActionType:        string(domain.GmailActionAddLabel),
+			ActionParams:      map[string]any{"label_name": "{{label_name}}"},
+		},
+	},
+	{
+		ID:          "gmail-vip-star",
+		Name:        "Belangrijke afzender markeren",
+		Description: "Geeft elke mail van een belangrijke afzender een ster.",
+		RuleType:    domain.RuleTypeGmail,
+		Variables: []Variable{
+			{
+				Name:        "sender_pattern",
+				Description: "Afzenderadres of deel ervan",
+				Type:        VariableString,
+				Required:    true,
+				Example:     "directie@example.com",
+			},
+		},
+		Rule: RuleTemplate{
+			Name:              "Ster voor {{sender_pattern}}",
+			IsActive:          true,
+			TriggerType:       string(domain.GmailTriggerSenderMatch),
+			TriggerConditions: map[string]any{"sender_pattern": "{{sender_pattern}}"},
+			ActionType:        string(domain.GmailActionStar),
+			ActionParams:      map[string]any{},
+		},
+	},
+	{
+		ID:   "gmail-auto-reply-out-of-office",
+		Name: "Automatisch antwoord bij afwezigheid",
+		Description: "Beantwoordt elke nieuwe mail met een afwezigheidsbericht. De rule staat uit na het aanmaken; " +
+			"zet hem aan wanneer je afwezig bent.",
+		RuleType: domain.RuleTypeGmail,
+		Variables: []Variable{
+			{
+				Name:        "reply_text",
+				Description: "Tekst van het antwoord",
+				Type:        VariableString,
+				Required:    true,
+				Example:     "Ik ben afwezig en lees je mail na mijn terugkomst.",
+			},
+		},
+		Rule: RuleTemplate{
+			Name:              "Afwezigheidsbericht",
+			IsActive:          false,
+			TriggerType:       string(domain.GmailTriggerNewMessage),
+			TriggerConditions: map[string]any{},
+			ActionType:        string(domain.GmailActionAutoReply),
+			ActionParams:      map[string]any{"reply_text": "{{reply_text}}"},
+		},
+	},
+}
@@ -0,0 +1,3 @@
+// Package ruletemplate holds the built-in catalogue of rule templates. A template is a calendar
+// or Gmail rule with {{variable}} placeholders that Instantiate fills with the user's values.
+package ruletemplate
@@ -0,0 +1,221 @@
+package ruletemplate
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+)
+
+// VariableType bepaalt welke JSON waarde een variabele accepteert.
+type VariableType string
+
+const (
+	VariableString     VariableType = "string"
+	VariableInteger    VariableType = "integer"
+	VariableBoolean    VariableType = "boolean"
+	VariableStringList VariableType = "string_list"
+)
+
+// Variable is een invoerveld van een template. Een variabele zonder Default is verplicht.
+type Variable struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Type        VariableType `json:"type"`
+	Required    bool         `json:"required"`
+	Default     any          `json:"default,omitempty"`
+	Example     any          `json:"example,omitempty"`
+}
+
+// RuleTemplate is de rule die een template oplevert. Een string "{{naam}}" wordt vervangen door de
+// waarde van de variabele met het juiste type; in een langere string wordt de waarde als tekst ingevoegd.
+type RuleTemplate struct {
+	Name              string         `json:"name"`
+	IsActive          bool           `json:"is_active"`
+	TriggerType       string         `json:"trigger_type,omitempty"` // alleen Gmail
+	TriggerConditions map[string]any `json:"trigger_conditions"`
+	ActionType        string         `json:"action_type,omitempty"` // alleen Gmail
+	ActionParams      map[string]any `json:"action_params"`
+	Priority          int            `json:"priority,omitempty"` // alleen Gmail
+}
+
+// Template is een rule uit de catalogus met de variabelen die de gebruiker invult.
+type Template struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	RuleType    domain.RuleType `json:"rule_type"`
+	Variables   []Variable      `json:"variables"`
+	Rule        RuleTemplate    `json:"rule"`
+}
+
+// Rule is een ingevulde template, klaar om als calendar of Gmail rule opgeslagen te worden.
+type Rule struct {
+	Name              string
+	IsActive          bool
+	TriggerType       string
+	TriggerConditions json.RawMessage
+	ActionType        string
+	ActionParams      json.RawMessage
+	Priority          int
+}
+
+// placeholder vindt {{naam}} in een string uit een template
+var placeholder = regexp.MustCompile(`\{\{\s*([a-z_]+)\s*\}\}`)
+
+// Catalogue geeft alle ingebouwde templates, in de volgorde waarin ze getoond worden.
+func Catalogue() []Template {
+	return catalogue
+}
+
+// Get zoekt een template op ID.
+func Get(id string) (Template, bool) {
+	for _, t := range catalogue {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return Template{}, false
+}
+
+// Instantiate vult de template met values. Ontbrekende of ongeldige waarden en onbekende variabelen
+// komen terug als FieldErrors onder "values"; de rule zelf wordt door de aanroeper gevalideerd.
+func (t Template) Instantiate(values map[string]any) (Rule, error) {
+	var errs rules.FieldErrors
+	resolved := make(map[string]any, len(t.Variables))
+	for _, v := range t.Variables {
+		field := "values." + v.Name
+		raw, ok := values[v.Name]
+		if !ok || raw == nil {
+			if v.Required {
+				errs.Add(field, "is required")
+				continue
+			}
+			raw = v.Default
+		}
+		value, err := coerce(v.Type, raw)
+		if err != nil {
+			errs.Add(field, "%v", err)
+			continue
+		}
+		resolved[v.Name] = value
+	}
+	for name := range values {
+		if !t.hasVariable(name) {
+			errs.Add("values."+name, "unknown variable")
+		}
+	}
+	if len(errs) > 0 {
+		return Rule{}, errs
+	}
+
+	conditions, err := json.Marshal(fill(t.Rule.TriggerConditions, resolved))
+	if err != nil {
+		return Rule{}, err
+	}
+	params, err := json.Marshal(fill(t.Rule.ActionParams, resolved))
+	if err != nil {
+		return Rule{}, err
+	}
+	return Rule{
+		Name:              fmt.Sprint(fill(t.Rule.Name, resolved)),
+		IsActive:          t.Rule.IsActive,
+		TriggerType:       t.Rule.TriggerType,
+		TriggerConditions: conditions,
+		ActionType:        t.Rule.ActionType,
+		ActionParams:      params,
+		Priority:          t.Rule.Priority,
+	}, nil
+}
+
+func (t Template) hasVariable(name string) bool {
+	for _, v := range t.Variables {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// coerce zet een gedecodeerde JSON waarde om naar het type van de variabele.
+func coerce(typ VariableType, value any) (any, error) {
+	switch typ {
+	case VariableString:
+		s, ok := value.(string)
+		if !ok || strings.TrimSpace(s) == "" {
+			return nil, fmt.Errorf("must be a non-empty string")
+		}
+		return s, nil
+	case VariableInteger:
+		switch n := value.(type) {
+		case int:
+			return n, nil
+		case float64:
+			if n == math.Trunc(n) {
+				return int(n), nil
+			}
+		}
+		return nil, fmt.Errorf("must be a whole number")
+	case VariableBoolean:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("must be true or false")
+		}
+		return b, nil
+	case VariableStringList:
+		var list []string
+		switch items := value.(type) {
+		case []string:
+			list = items
+		case []any:
+			for _, item := range items {
+				s, ok := item.(string)
+				if !ok || strings.TrimSpace(s) == "" {
+					return nil, fmt.Errorf("must be a list of non-empty strings")
+				}
+				list = append(list, s)
+			}
+		default:
+			return nil, fmt.Errorf("must be a list of non-empty strings")
+		}
+		if len(list) == 0 {
+			return nil, fmt.Errorf("must contain at least one item")
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("unsupported variable type %q", typ)
+}
+
+// fill vervangt de placeholders in een waarde uit een template, ook in geneste maps en lijsten.
+func fill(value any, values map[string]any) any {
+	switch v := value.(type) {
+	case string:
+		if m := placeholder.FindStringSubmatch(v); m != nil && m[0] == v {
+			return values[m[1]]
+		}
+		return placeholder.ReplaceAllStringFunc(v, func(match string) string {
+			value := values[placeholder.FindStringSubmatch(match)[1]]
+			if list, ok := value.([]string); ok {
+				return strings.Join(list, ", ")
+			}
+			return fmt.Sprint(value)
+		})
+	case map[string]any:
+		filled := make(map[string]any, len(v))
+		for key, item := range v {
+			filled[key] = fill(item, values)
+		}
+		return filled
+	case []any:
+		filled := make([]any, len(v))
+		for i, item := range v {
+			filled[i] = fill(item, values)
+		}
+		return filled
+	}
+	return value
+}
@@ -0,0 +1,108 @@
+package ruletemplate
+
+import (
+	"encoding/json"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+	calendarworker "agenda-automator-api/internal/worker/calendar"
+	gmailworker "agenda-automator-api/internal/worker/gmail"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogue_ExamplesAreValid(t *testing.T) {
+	ids := map[string]bool{}
+	for _, tmpl := range Catalogue() {
+		t.Run(tmpl.ID, func(t *testing.T) {
+			assert.False(t, ids[tmpl.ID], "duplicate template ID")
+			ids[tmpl.ID] = true
+
+			values := map[string]any{}
+			for _, v := range tmpl.Variables {
+				assert.NotEqual(t, v.Required, v.Default != nil, "%s: a variable is either required or has a default", v.Name)
+				if v.Example != nil {
+					values[v.Name] = v.Example
+				}
+			}
+
+			rule, err := tmpl.Instantiate(values)
+			require.NoError(t, err)
+			assert.NotEmpty(t, rule.Name)
+
+			engineRule := rules.Rule{
+				TriggerType:   rule.TriggerType,
+				TriggerParams: rule.TriggerConditions,
+				ActionType:    rule.ActionType,
+				ActionParams:  rule.ActionParams,
+			}
+			switch tmpl.RuleType {
+			case domain.RuleTypeCalendar:
+				engineRule.TriggerType = domain.CalendarTriggerEventMatch
+				engineRule.ActionType = domain.CalendarActionCreateReminder
+				assert.Empty(t, calendarworker.Rules.ValidateRule(engineRule))
+			case domain.RuleTypeGmail:
+				assert.Empty(t, gmailworker.Rules.ValidateRule(engineRule))
+			default:
+				t.Fatalf("unknown rule type %q", tmpl.RuleType)
+			}
+		})
+	}
+}
+
+func TestTemplate_Instantiate(t *testing.T) {
+	tmpl, ok := Get("calendar-leave-reminder")
+	require.True(t, ok)
+
+	t.Run("typed placeholders", func(t *testing.T) {
+		// Waarden komen uit een JSON body, dus getallen zijn float64
+		rule, err := tmpl.Instantiate(map[string]any{"summary": "Dienst", "offset_minutes": float64(-30)})
+		require.NoError(t, err)
+
+		assert.Equal(t, "Vertrekken voor Dienst", rule.Name)
+		assert.JSONEq(t, `{"summary_equals": "Dienst"}`, string(rule.TriggerConditions))
+		assert.JSONEq(t, `{"new_event_title": "Vertrekken", "offset_minutes": -30}`, string(rule.ActionParams))
+	})
+
+	t.Run("default", func(t *testing.T) {
+		rule, err := tmpl.Instantiate(map[string]any{"summary": "Dienst"})
+		require.NoError(t, err)
+
+		var params domain.ActionParams
+		require.NoError(t, json.Unmarshal(rule.ActionParams, &params))
+		assert.Equal(t, -45, params.OffsetMinutes)
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		_, err := tmpl.Instantiate(map[string]any{"offset_minutes": 1.5, "colour": "blue"})
+
+		var fieldErrs rules.FieldErrors
+		require.ErrorAs(t, err, &fieldErrs)
+		assert.ElementsMatch(t, rules.FieldErrors{
+			{Field: "values.summary", Message: "is required"},
+			{Field: "values.offset_minutes", Message: "must be a whole number"},
+			{Field: "values.colour", Message: "unknown variable"},
+		}, fieldErrs)
+	})
+}
+
+func TestTemplate_InstantiateStringList(t *testing.T) {
+	tmpl, ok := Get("calendar-reminder-day-before")
+	require.True(t, ok)
+
+	rule, err := tmpl.Instantiate(map[string]any{"keywords": []any{"Tandarts", "Mondhygiënist"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Herinnering dag van tevoren: Tandarts, Mondhygiënist", rule.Name)
+	assert.JSONEq(t, `{"summary_contains": ["Tandarts", "Mondhygiënist"]}`, string(rule.TriggerConditions))
+
+	_, err = tmpl.Instantiate(map[string]any{"keywords": []any{}})
+	assert.ErrorContains(t, err, "values.keywords: must contain at least one item")
+}
+
+func TestGet_Unknown(t *testing.T) {
+	_, ok := Get("does-not-exist")
+	assert.False(t, ok)
+}
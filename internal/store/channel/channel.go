@@ -0,0 +1,186 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"agenda-automator-api/internal/database"
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ChannelStorer defines the interface for push channel store operations
+type ChannelStorer interface {
+	UpsertCalendarWatchChannel(
+		ctx context.Context,
+		arg UpsertCalendarWatchChannelParams,
+	) (domain.CalendarWatchChannel, error)
+	GetCalendarWatchChannelByChannelID(ctx context.Context, channelID string) (domain.CalendarWatchChannel, error)
+	GetCalendarWatchChannelsForAccount(ctx context.Context, accountID uuid.UUID) ([]domain.CalendarWatchChannel, error)
+	GetCalendarWatchChannelsForInactiveAccounts(ctx context.Context) ([]domain.CalendarWatchChannel, error)
+	DeleteCalendarWatchChannel(ctx context.Context, channelID string) error
+}
+
+// UpsertCalendarWatchChannelParams contains parameters for storing a watch channel.
+type UpsertCalendarWatchChannelParams struct {
+	ConnectedAccountID uuid.UUID
+	CalendarID         string
+	ChannelID          string
+	ResourceID         string
+	Token              string
+	Expiration         time.Time
+}
+
+// ChannelStore handles push channel database operations
+type ChannelStore struct {
+	db database.Querier
+}
+
+// NewChannelStore creates a new ChannelStore
+func NewChannelStore(db database.Querier) ChannelStorer {
+	return &ChannelStore{db: db}
+}
+
+const channelColumns = `id, connected_account_id, calendar_id, channel_id, resource_id,
+           token, expiration, created_at, updated_at`
+
+// scanChannel scans a database row into a CalendarWatchChannel
+func scanChannel(row pgx.Row) (domain.CalendarWatchChannel, error) {
+	var ch domain.CalendarWatchChannel
+	err := row.Scan(
+		&ch.ID,
+		&ch.ConnectedAccountID,
+		&ch.CalendarID,
+		&ch.ChannelID,
+		&ch.ResourceID,
+		&ch.Token,
+		&ch.Expiration,
+		&ch.CreatedAt,
+		&ch.UpdatedAt,
+	)
+	return ch, err
+}
+
+// UpsertCalendarWatchChannel slaat een (vernieuwd) channel op. Er is maximaal
+// één channel per account/agenda; een renewal overschrijft het vorige channel.
+func (s *ChannelStore) UpsertCalendarWatchChannel(
+	ctx context.Context,
+	arg UpsertCalendarWatchChannelParams,
+) (domain.CalendarWatchChannel, error) {
+	query := `
+    INSERT INTO calendar_watch_channels (
+        connected_account_id, calendar_id, channel_id, resource_id, token, expiration
+    ) VALUES (
+        $1, $2, $3, $4, $5, $6
+    )
+    ON CONFLICT (connected_account_id, calendar_id) DO UPDATE SET
+        channel_id = EXCLUDED.channel_id,
+        resource_id = EXCLUDED.resource_id,
+        token = EXCLUDED.token,
+        expiration = EXCLUDED.expiration,
+        updated_at = now()
+    RETURNING ` + channelColumns + `;
+    `
+
+	row := s.db.QueryRow(ctx, query,
+		arg.ConnectedAccountID,
+		arg.CalendarID,
+		arg.ChannelID,
+		arg.ResourceID,
+		arg.Token,
+		arg.Expiration,
+	)
+
+	return scanChannel(row)
+}
+
+// GetCalendarWatchChannelByChannelID haalt een channel op via het ID dat Google terugstuurt.
+func (s *ChannelStore) GetCalendarWatchChannelByChannelID(
+	ctx context.Context,
+	channelID string,
+) (domain.CalendarWatchChannel, error) {
+	query := `
+    SELECT ` + channelColumns + `
+    FROM calendar_watch_channels
+    WHERE channel_id = $1;
+    `
+
+	ch, err := scanChannel(s.db.QueryRow(ctx, query, channelID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.CalendarWatchChannel{}, errors.New("channel not found")
+		}
+		return domain.CalendarWatchChannel{}, err
+	}
+
+	return ch, nil
+}
+
+// GetCalendarWatchChannelsForAccount haalt alle channels van een account op.
+func (s *ChannelStore) GetCalendarWatchChannelsForAccount(
+	ctx context.Context,
+	accountID uuid.UUID,
+) ([]domain.CalendarWatchChannel, error) {
+	query := `
+    SELECT ` + channelColumns + `
+    FROM calendar_watch_channels
+    WHERE connected_account_id = $1
+    ORDER BY calendar_id;
+    `
+
+	rows, err := s.db.Query(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return collectChannels(rows)
+}
+
+// GetCalendarWatchChannelsForInactiveAccounts haalt de channels op van accounts
+// die niet (meer) actief zijn, bijv. gepauzeerd of ingetrokken. Deze moeten gestopt worden.
+func (s *ChannelStore) GetCalendarWatchChannelsForInactiveAccounts(
+	ctx context.Context,
+) ([]domain.CalendarWatchChannel, error) {
+	query := `
+    SELECT c.id, c.connected_account_id, c.calendar_id, c.channel_id, c.resource_id,
+           c.token, c.expiration, c.created_at, c.updated_at
+    FROM calendar_watch_channels c
+    JOIN connected_accounts ca ON c.connected_account_id = ca.id
+    WHERE ca.status <> 'active';
+    `
+
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return collectChannels(rows)
+}
+
+// DeleteCalendarWatchChannel verwijdert een channel. Een channel dat al weg is, is geen fout.
+func (s *ChannelStore) DeleteCalendarWatchChannel(ctx context.Context, channelID string) error {
+	query := `DELETE FROM calendar_watch_channels WHERE channel_id = $1`
+	_, err := s.db.Exec(ctx, query, channelID)
+	return err
+}
+
+// collectChannels scant alle rijen en sluit ze af
+func collectChannels(rows pgx.Rows) ([]domain.CalendarWatchChannel, error) {
+	defer rows.Close()
+
+	var channels []domain.CalendarWatchChannel
+	for rows.Next() {
+		ch, err := scanChannel(rows)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, ch)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return channels, nil
+}
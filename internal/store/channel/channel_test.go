@@ -0,0 +1,111 @@
+package channel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupChannelStore is een helper die een ChannelStore en een mock pool aanmaakt.
+func setupChannelStore(t *testing.T) (ChannelStorer, pgxmock.PgxPoolIface) {
+	t.Helper()
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	return NewChannelStore(mockPool), mockPool
+}
+
+var channelColumnNames = []string{
+	"id", "connected_account_id", "calendar_id", "channel_id", "resource_id",
+	"token", "expiration", "created_at", "updated_at",
+}
+
+func TestChannelStore_UpsertCalendarWatchChannel(t *testing.T) {
+	store, mockPool := setupChannelStore(t)
+	defer mockPool.Close()
+
+	ctx := context.Background()
+	params := UpsertCalendarWatchChannelParams{
+		ConnectedAccountID: uuid.New(),
+		CalendarID:         "primary",
+		ChannelID:          "channel-1",
+		ResourceID:         "resource-1",
+		Token:              "secret",
+		Expiration:         time.Now().Add(7 * 24 * time.Hour),
+	}
+
+	rows := pgxmock.NewRows(channelColumnNames).AddRow(
+		uuid.New(), params.ConnectedAccountID, params.CalendarID, params.ChannelID,
+		params.ResourceID, params.Token, params.Expiration, time.Now(), time.Now(),
+	)
+	mockPool.ExpectQuery("INSERT INTO calendar_watch_channels").
+		WithArgs(
+			params.ConnectedAccountID,
+			params.CalendarID,
+			params.ChannelID,
+			params.ResourceID,
+			params.Token,
+			params.Expiration,
+		).
+		WillReturnRows(rows)
+
+	ch, err := store.UpsertCalendarWatchChannel(ctx, params)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "channel-1", ch.ChannelID)
+	assert.Equal(t, "resource-1", ch.ResourceID)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestChannelStore_GetCalendarWatchChannelByChannelID_NotFound(t *testing.T) {
+	store, mockPool := setupChannelStore(t)
+	defer mockPool.Close()
+
+	mockPool.ExpectQuery("SELECT .* FROM calendar_watch_channels").
+		WithArgs("unknown").
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err := store.GetCalendarWatchChannelByChannelID(context.Background(), "unknown")
+
+	assert.EqualError(t, err, "channel not found")
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestChannelStore_GetCalendarWatchChannelsForInactiveAccounts(t *testing.T) {
+	store, mockPool := setupChannelStore(t)
+	defer mockPool.Close()
+
+	accountID := uuid.New()
+	rows := pgxmock.NewRows(channelColumnNames).
+		AddRow(uuid.New(), accountID, "primary", "channel-1", "resource-1", "secret", time.Now(), time.Now(), time.Now()).
+		AddRow(uuid.New(), accountID, "work", "channel-2", "resource-2", "secret", time.Now(), time.Now(), time.Now())
+	mockPool.ExpectQuery("SELECT .* FROM calendar_watch_channels c JOIN connected_accounts ca").
+		WillReturnRows(rows)
+
+	channels, err := store.GetCalendarWatchChannelsForInactiveAccounts(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, channels, 2)
+	assert.Equal(t, "channel-2", channels[1].ChannelID)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestChannelStore_DeleteCalendarWatchChannel(t *testing.T) {
+	store, mockPool := setupChannelStore(t)
+	defer mockPool.Close()
+
+	mockPool.ExpectExec("DELETE FROM calendar_watch_channels").
+		WithArgs("channel-1").
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+	err := store.DeleteCalendarWatchChannel(context.Background(), "channel-1")
+
+	assert.NoError(t, err)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
@@ -0,0 +1,2 @@
+// Package channel stores Google push notification channels.
+package channel
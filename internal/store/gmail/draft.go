@@ -0,0 +1,143 @@
+package gmail
+
+import (
+	"context"
+	"errors"
+
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// UpsertGmailDraftParams contains the cached copy of a Gmail draft.
+type UpsertGmailDraftParams struct {
+	ConnectedAccountID uuid.UUID
+	GmailDraftID       string
+	Subject            *string
+	ToRecipients       []string
+	CcRecipients       []string
+	BccRecipients      []string
+	BodyHTML           *string
+	BodyPlain          *string
+	// AttachmentIDs are the Gmail attachment IDs of the draft message
+	AttachmentIDs []string
+}
+
+const draftColumns = `id, connected_account_id, gmail_draft_id, subject, to_recipients, cc_recipients,
+		       bcc_recipients, body_html, body_plain, has_attachments, attachment_ids, created_at, updated_at`
+
+// scanDraft scans a database row into a GmailDraft
+func scanDraft(row pgx.Row) (domain.GmailDraft, error) {
+	var draft domain.GmailDraft
+	err := row.Scan(
+		&draft.ID, &draft.ConnectedAccountID, &draft.GmailDraftID, &draft.Subject, &draft.ToRecipients,
+		&draft.CcRecipients, &draft.BccRecipients, &draft.BodyHTML, &draft.BodyPlain, &draft.HasAttachments,
+		&draft.AttachmentIds, &draft.CreatedAt, &draft.UpdatedAt,
+	)
+	return draft, err
+}
+
+// UpsertGmailDraft stores or refreshes the cached copy of a draft. has_attachments is
+// derived from AttachmentIDs so chk_gmail_drafts_has_attachments_consistency always holds.
+func (s *GmailStore) UpsertGmailDraft(ctx context.Context, arg UpsertGmailDraftParams) (domain.GmailDraft, error) {
+	query := `
+		INSERT INTO gmail_drafts (
+			connected_account_id, gmail_draft_id, subject, to_recipients, cc_recipients,
+			bcc_recipients, body_html, body_plain, has_attachments, attachment_ids
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (connected_account_id, gmail_draft_id)
+		DO UPDATE SET
+			subject = EXCLUDED.subject,
+			to_recipients = EXCLUDED.to_recipients,
+			cc_recipients = EXCLUDED.cc_recipients,
+			bcc_recipients = EXCLUDED.bcc_recipients,
+			body_html = EXCLUDED.body_html,
+			body_plain = EXCLUDED.body_plain,
+			has_attachments = EXCLUDED.has_attachments,
+			attachment_ids = EXCLUDED.attachment_ids,
+			updated_at = now()
+		RETURNING ` + draftColumns + `;
+	`
+
+	// Een lege lijst slaan we op als NULL
+	var attachmentIDs []string
+	if len(arg.AttachmentIDs) > 0 {
+		attachmentIDs = arg.AttachmentIDs
+	}
+
+	row := s.db.QueryRow(ctx, query,
+		arg.ConnectedAccountID, arg.GmailDraftID, arg.Subject, arg.ToRecipients, arg.CcRecipients,
+		arg.BccRecipients, arg.BodyHTML, arg.BodyPlain, len(attachmentIDs) > 0, attachmentIDs,
+	)
+	return scanDraft(row)
+}
+
+// GetGmailDraftByID gets a single cached draft.
+func (s *GmailStore) GetGmailDraftByID(ctx context.Context, draftID uuid.UUID) (domain.GmailDraft, error) {
+	query := `
+		SELECT ` + draftColumns + `
+		FROM gmail_drafts
+		WHERE id = $1;
+	`
+
+	draft, err := scanDraft(s.db.QueryRow(ctx, query, draftID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.GmailDraft{}, errors.New("draft not found")
+		}
+		return domain.GmailDraft{}, err
+	}
+	return draft, nil
+}
+
+// GetGmailDraftsForAccount gets all cached drafts of an account, most recently changed first.
+func (s *GmailStore) GetGmailDraftsForAccount(ctx context.Context, accountID uuid.UUID) ([]domain.GmailDraft, error) {
+	query := `
+		SELECT ` + draftColumns + `
+		FROM gmail_drafts
+		WHERE connected_account_id = $1
+		ORDER BY updated_at DESC;
+	`
+
+	rows, err := s.db.Query(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var drafts []domain.GmailDraft
+	for rows.Next() {
+		draft, err := scanDraft(rows)
+		if err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, draft)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return drafts, nil
+}
+
+// DeleteGmailDraft removes a draft from the cache.
+func (s *GmailStore) DeleteGmailDraft(ctx context.Context, draftID uuid.UUID) error {
+	_, err := s.db.Exec(ctx, `DELETE FROM gmail_drafts WHERE id = $1;`, draftID)
+	return err
+}
+
+// PruneGmailDrafts removes cached drafts of an account that no longer exist in Gmail.
+func (s *GmailStore) PruneGmailDrafts(ctx context.Context, accountID uuid.UUID, keepGmailDraftIDs []string) error {
+	query := `
+		DELETE FROM gmail_drafts
+		WHERE connected_account_id = $1 AND NOT (gmail_draft_id = ANY($2::text[]));
+	`
+
+	if keepGmailDraftIDs == nil {
+		keepGmailDraftIDs = []string{}
+	}
+	_, err := s.db.Exec(ctx, query, accountID, keepGmailDraftIDs)
+	return err
+}
@@ -0,0 +1,130 @@
+package gmail
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var scanDraftHeaders = []string{
+	"id", "connected_account_id", "gmail_draft_id", "subject", "to_recipients", "cc_recipients",
+	"bcc_recipients", "body_html", "body_plain", "has_attachments", "attachment_ids", "created_at", "updated_at",
+}
+
+func TestGmailStore_UpsertGmailDraft(t *testing.T) {
+	tests := []struct {
+		name           string
+		attachmentIDs  []string
+		hasAttachments bool
+		storedIDs      []string
+	}{
+		{name: "without attachments", attachmentIDs: []string{}, hasAttachments: false, storedIDs: nil},
+		{name: "with attachments", attachmentIDs: []string{"att-1"}, hasAttachments: true, storedIDs: []string{"att-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mockDB.Close()
+
+			store := NewGmailStore(mockDB, dummyLog)
+			params := UpsertGmailDraftParams{
+				ConnectedAccountID: testAccountID,
+				GmailDraftID:       "r-123",
+				Subject:            stringPtr("Offerte"),
+				ToRecipients:       []string{"klant@example.com"},
+				BodyPlain:          stringPtr("Zie bijlage"),
+				AttachmentIDs:      tt.attachmentIDs,
+			}
+
+			// has_attachments en attachment_ids moeten samen de check constraint respecteren
+			mockDB.ExpectQuery(`INSERT INTO gmail_drafts .* ON CONFLICT \(connected_account_id, gmail_draft_id\)`).
+				WithArgs(
+					testAccountID, "r-123", params.Subject, params.ToRecipients, params.CcRecipients,
+					params.BccRecipients, params.BodyHTML, params.BodyPlain, tt.hasAttachments, tt.storedIDs,
+				).
+				WillReturnRows(pgxmock.NewRows(scanDraftHeaders).AddRow(
+					testUUID, testAccountID, "r-123", params.Subject, params.ToRecipients, []string(nil),
+					[]string(nil), nil, params.BodyPlain, tt.hasAttachments, tt.storedIDs, testTime, testTime,
+				))
+
+			draft, err := store.UpsertGmailDraft(context.Background(), params)
+			assert.NoError(t, err)
+			assert.Equal(t, testUUID, draft.ID)
+			assert.Equal(t, tt.hasAttachments, draft.HasAttachments)
+			assert.NoError(t, mockDB.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGmailStore_GetGmailDraftByID_NotFound(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+	mockDB.ExpectQuery(`SELECT .* FROM gmail_drafts WHERE id = \$1`).
+		WithArgs(testUUID).
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err = store.GetGmailDraftByID(context.Background(), testUUID)
+	assert.EqualError(t, err, "draft not found")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_GetGmailDraftsForAccount_Success(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+	mockDB.ExpectQuery(`SELECT .* FROM gmail_drafts WHERE connected_account_id = \$1 ORDER BY updated_at DESC`).
+		WithArgs(testAccountID).
+		WillReturnRows(pgxmock.NewRows(scanDraftHeaders).
+			AddRow(testUUID, testAccountID, "r-1", nil, []string{"a@example.com"}, nil, nil, nil, nil,
+				false, nil, testTime, testTime).
+			AddRow(testUUID, testAccountID, "r-2", nil, []string{"b@example.com"}, nil, nil, nil, nil,
+				true, []string{"att-1"}, testTime, testTime))
+
+	drafts, err := store.GetGmailDraftsForAccount(context.Background(), testAccountID)
+	assert.NoError(t, err)
+	require.Len(t, drafts, 2)
+	assert.Equal(t, "r-2", drafts[1].GmailDraftID)
+	assert.Equal(t, []string{"att-1"}, drafts[1].AttachmentIds)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_DeleteGmailDraft(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+	mockDB.ExpectExec(`DELETE FROM gmail_drafts WHERE id = \$1`).
+		WithArgs(testUUID).
+		WillReturnResult(pgconn.NewCommandTag("DELETE 1"))
+
+	assert.NoError(t, store.DeleteGmailDraft(context.Background(), testUUID))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_PruneGmailDrafts_NoDraftsLeft(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+	// Zonder drafts in Gmail moet de hele cache van het account leeg
+	mockDB.ExpectExec(`DELETE FROM gmail_drafts WHERE connected_account_id = \$1 AND NOT`).
+		WithArgs(testAccountID, []string{}).
+		WillReturnResult(pgconn.NewCommandTag("DELETE 3"))
+
+	assert.NoError(t, store.PruneGmailDrafts(context.Background(), testAccountID, nil))
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,187 @@
+package gmail
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// UpsertGmailForwardingAddressParams contains parameters for adding a forwarding address.
+type UpsertGmailForwardingAddressParams struct {
+	UserID               uuid.UUID
+	Email                string
+	VerificationCodeHash string
+	// ResendCooldown is the time that must have passed since the previous code was sent
+	ResendCooldown time.Duration
+}
+
+// ErrForwardingVerificationLocked is returned once all verification attempts for a code are used.
+var ErrForwardingVerificationLocked = errors.New("too many verification attempts")
+
+// ErrForwardingVerificationCooldown is returned when a new code is requested while the previous
+// one was sent less than the resend cooldown ago.
+var ErrForwardingVerificationCooldown = errors.New("verification code was sent recently")
+
+const forwardingAddressColumns = `id, user_id, email, verification_code_hash, verification_sent_at,
+		       verification_attempts, verified_at, created_at, updated_at`
+
+// scanForwardingAddress scans a database row into a GmailForwardingAddress
+func scanForwardingAddress(row pgx.Row) (domain.GmailForwardingAddress, error) {
+	var address domain.GmailForwardingAddress
+	err := row.Scan(
+		&address.ID, &address.UserID, &address.Email, &address.VerificationCodeHash, &address.VerificationSentAt,
+		&address.VerificationAttempts, &address.VerifiedAt, &address.CreatedAt, &address.UpdatedAt,
+	)
+	return address, err
+}
+
+// UpsertGmailForwardingAddress adds an unverified forwarding address, or replaces the
+// verification code of an address that was added before. A new code gets fresh attempts. Within
+// ResendCooldown of the previous code nothing changes and ErrForwardingVerificationCooldown is
+// returned, so the caller does not send another mail.
+func (s *GmailStore) UpsertGmailForwardingAddress(
+	ctx context.Context,
+	arg UpsertGmailForwardingAddressParams,
+) (domain.GmailForwardingAddress, error) {
+	query := `
+		INSERT INTO gmail_forwarding_addresses (user_id, email, verification_code_hash, verification_sent_at)
+		VALUES ($1, $2, $3, now())
+		ON CONFLICT (user_id, email)
+		DO UPDATE SET verification_code_hash = EXCLUDED.verification_code_hash,
+		              verification_sent_at = EXCLUDED.verification_sent_at,
+		              verification_attempts = 0,
+		              updated_at = now()
+		WHERE gmail_forwarding_addresses.verification_sent_at IS NULL
+		   OR gmail_forwarding_addresses.verification_sent_at <= now() - make_interval(secs => $4)
+		RETURNING ` + forwardingAddressColumns + `;
+	`
+
+	row := s.db.QueryRow(ctx, query, arg.UserID, arg.Email, arg.VerificationCodeHash, arg.ResendCooldown.Seconds())
+	address, err := scanForwardingAddress(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.GmailForwardingAddress{}, ErrForwardingVerificationCooldown
+	}
+	return address, err
+}
+
+// GetGmailForwardingAddressByID gets a single forwarding address.
+func (s *GmailStore) GetGmailForwardingAddressByID(
+	ctx context.Context,
+	addressID uuid.UUID,
+) (domain.GmailForwardingAddress, error) {
+	query := `
+		SELECT ` + forwardingAddressColumns + `
+		FROM gmail_forwarding_addresses
+		WHERE id = $1;
+	`
+
+	address, err := scanForwardingAddress(s.db.QueryRow(ctx, query, addressID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.GmailForwardingAddress{}, errors.New("forwarding address not found")
+		}
+		return domain.GmailForwardingAddress{}, err
+	}
+	return address, nil
+}
+
+// GetGmailForwardingAddressesForUser gets all forwarding addresses of a user, verified or not.
+func (s *GmailStore) GetGmailForwardingAddressesForUser(
+	ctx context.Context,
+	userID uuid.UUID,
+) ([]domain.GmailForwardingAddress, error) {
+	query := `
+		SELECT ` + forwardingAddressColumns + `
+		FROM gmail_forwarding_addresses
+		WHERE user_id = $1
+		ORDER BY email ASC;
+	`
+
+	rows, err := s.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var addresses []domain.GmailForwardingAddress
+	for rows.Next() {
+		address, err := scanForwardingAddress(rows)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return addresses, nil
+}
+
+// UseGmailForwardingVerificationAttempt counts one attempt to verify an address. It returns
+// ErrForwardingVerificationLocked when maxAttempts were already used for the current code; the
+// check and the increment are one statement, so parallel guesses cannot exceed the limit.
+func (s *GmailStore) UseGmailForwardingVerificationAttempt(
+	ctx context.Context,
+	addressID uuid.UUID,
+	maxAttempts int,
+) error {
+	query := `
+		UPDATE gmail_forwarding_addresses
+		SET verification_attempts = verification_attempts + 1, updated_at = now()
+		WHERE id = $1 AND verification_attempts < $2;
+	`
+
+	cmdTag, err := s.db.Exec(ctx, query, addressID, maxAttempts)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return ErrForwardingVerificationLocked
+	}
+	return nil
+}
+
+// MarkGmailForwardingAddressVerified marks an address as verified and clears its code.
+func (s *GmailStore) MarkGmailForwardingAddressVerified(
+	ctx context.Context,
+	addressID uuid.UUID,
+) (domain.GmailForwardingAddress, error) {
+	query := `
+		UPDATE gmail_forwarding_addresses
+		SET verified_at = now(), verification_code_hash = NULL, updated_at = now()
+		WHERE id = $1
+		RETURNING ` + forwardingAddressColumns + `;
+	`
+
+	address, err := scanForwardingAddress(s.db.QueryRow(ctx, query, addressID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.GmailForwardingAddress{}, errors.New("forwarding address not found")
+		}
+		return domain.GmailForwardingAddress{}, err
+	}
+	return address, nil
+}
+
+// DeleteGmailForwardingAddress removes an address from the allowlist.
+func (s *GmailStore) DeleteGmailForwardingAddress(ctx context.Context, addressID uuid.UUID) error {
+	query := `DELETE FROM gmail_forwarding_addresses WHERE id = $1`
+
+	cmdTag, err := s.db.Exec(ctx, query, addressID)
+	if err != nil {
+		return err
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return errors.New("no forwarding address found with ID " + addressID.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package gmail
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var scanForwardingAddressHeaders = []string{
+	"id", "user_id", "email", "verification_code_hash", "verification_sent_at",
+	"verification_attempts", "verified_at", "created_at", "updated_at",
+}
+
+func TestGmailStore_UpsertGmailForwardingAddress_Success(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+	params := UpsertGmailForwardingAddressParams{
+		UserID:               testAccountID,
+		Email:                "backup@example.com",
+		VerificationCodeHash: "hash",
+		ResendCooldown:       time.Minute,
+	}
+
+	mockDB.ExpectQuery(`INSERT INTO gmail_forwarding_addresses`).
+		WithArgs(params.UserID, params.Email, params.VerificationCodeHash, 60.0).
+		WillReturnRows(pgxmock.NewRows(scanForwardingAddressHeaders).AddRow(
+			testUUID, testAccountID, "backup@example.com", stringPtr("hash"), &testTime,
+			0, nil, testTime, testTime,
+		))
+
+	address, err := store.UpsertGmailForwardingAddress(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Equal(t, testUUID, address.ID)
+	assert.Nil(t, address.VerifiedAt)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_UpsertGmailForwardingAddress_Cooldown(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+	params := UpsertGmailForwardingAddressParams{
+		UserID:               testAccountID,
+		Email:                "backup@example.com",
+		VerificationCodeHash: "hash",
+		ResendCooldown:       time.Minute,
+	}
+
+	// De vorige code is nog te recent: de update slaat de rij over
+	mockDB.ExpectQuery(`INSERT INTO gmail_forwarding_addresses .*verification_sent_at <= now\(\) - make_interval`).
+		WithArgs(params.UserID, params.Email, params.VerificationCodeHash, 60.0).
+		WillReturnRows(pgxmock.NewRows(scanForwardingAddressHeaders))
+
+	_, err = store.UpsertGmailForwardingAddress(context.Background(), params)
+	assert.ErrorIs(t, err, ErrForwardingVerificationCooldown)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_GetGmailForwardingAddressByID_NotFound(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+	mockDB.ExpectQuery(`SELECT .* FROM gmail_forwarding_addresses`).
+		WithArgs(testUUID).
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err = store.GetGmailForwardingAddressByID(context.Background(), testUUID)
+	assert.EqualError(t, err, "forwarding address not found")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_GetGmailForwardingAddressesForUser_Success(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+	mockDB.ExpectQuery(`SELECT .* FROM gmail_forwarding_addresses WHERE user_id = \$1`).
+		WithArgs(testAccountID).
+		WillReturnRows(pgxmock.NewRows(scanForwardingAddressHeaders).
+			AddRow(testUUID, testAccountID, "a@example.com", nil, &testTime, 1, &testTime, testTime, testTime).
+			AddRow(testUUID, testAccountID, "b@example.com", stringPtr("hash"), &testTime, 0, nil, testTime, testTime))
+
+	addresses, err := store.GetGmailForwardingAddressesForUser(context.Background(), testAccountID)
+	assert.NoError(t, err)
+	assert.Len(t, addresses, 2)
+	assert.NotNil(t, addresses[0].VerifiedAt)
+	assert.Nil(t, addresses[1].VerifiedAt)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_UseGmailForwardingVerificationAttempt(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+
+	mockDB.ExpectExec(`UPDATE gmail_forwarding_addresses SET verification_attempts = verification_attempts \+ 1`).
+		WithArgs(testUUID, 5).
+		WillReturnResult(pgconn.NewCommandTag("UPDATE 1"))
+	assert.NoError(t, store.UseGmailForwardingVerificationAttempt(context.Background(), testUUID, 5))
+
+	// Alle pogingen voor deze code zijn op
+	mockDB.ExpectExec(`UPDATE gmail_forwarding_addresses SET verification_attempts = verification_attempts \+ 1`).
+		WithArgs(testUUID, 5).
+		WillReturnResult(pgconn.NewCommandTag("UPDATE 0"))
+	err = store.UseGmailForwardingVerificationAttempt(context.Background(), testUUID, 5)
+	assert.ErrorIs(t, err, ErrForwardingVerificationLocked)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_MarkGmailForwardingAddressVerified_Success(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+	mockDB.ExpectQuery(`UPDATE gmail_forwarding_addresses SET verified_at = now\(\), verification_code_hash = NULL`).
+		WithArgs(testUUID).
+		WillReturnRows(pgxmock.NewRows(scanForwardingAddressHeaders).AddRow(
+			testUUID, testAccountID, "backup@example.com", nil, &testTime, 2, &testTime, testTime, testTime,
+		))
+
+	address, err := store.MarkGmailForwardingAddressVerified(context.Background(), testUUID)
+	assert.NoError(t, err)
+	assert.NotNil(t, address.VerifiedAt)
+	assert.Nil(t, address.VerificationCodeHash)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_DeleteGmailForwardingAddress_NotFound(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+	mockDB.ExpectExec(`DELETE FROM gmail_forwarding_addresses WHERE id = \$1`).
+		WithArgs(testUUID).
+		WillReturnResult(pgconn.NewCommandTag("DELETE 0"))
+
+	err = store.DeleteGmailForwardingAddress(context.Background(), testUUID)
+	assert.Error(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -25,6 +25,8 @@ type CreateGmailAutomationRuleParams struct {
 	ActionType         domain.GmailRuleActionType
 	ActionParams       json.RawMessage
 	Priority           int
+	Schedule           *domain.RuleSchedule
+	CreatedBy          uuid.UUID // komt als changed_by in rule_versions
 }
 
 type UpdateGmailRuleParams struct {
@@ -36,8 +38,28 @@ type UpdateGmailRuleParams struct {
 	ActionType        domain.GmailRuleActionType
 	ActionParams      json.RawMessage
 	Priority          int
+	Schedule          *domain.RuleSchedule
+	// AccountSelector replaces the selector of a user-level rule; nil leaves it unchanged
+	AccountSelector *domain.AccountSelector
+	ChangedBy       uuid.UUID
+	// RestoredFrom is gezet als de update een oude versie terugzet; de versie krijgt dan change_type 'restore'
+	RestoredFrom *int
 }
 
+// RuleSnapshotSQL bouwt de snapshot voor rule_versions uit een rij van gmail_automation_rules.
+// Priority hoort er niet bij: de volgorde wordt per account beheerd via ReorderGmailRules.
+// account_selector staat er alleen in bij user-level rules.
+const RuleSnapshotSQL = `(jsonb_build_object(
+			'name', name,
+			'description', description,
+			'is_active', is_active,
+			'trigger_type', trigger_type,
+			'trigger_conditions', trigger_conditions,
+			'action_type', action_type,
+			'action_params', action_params,
+			'schedule', schedule
+		) || jsonb_strip_nulls(jsonb_build_object('account_selector', account_selector)))`
+
 type StoreGmailMessageParams struct {
 	ConnectedAccountID uuid.UUID
 	GmailMessageID     string
@@ -55,15 +77,33 @@ type StoreGmailMessageParams struct {
 	SizeEstimate       *int64
 	ReceivedAt         time.Time
 	Labels             []string
+	// Geparste List-Unsubscribe headers
+	UnsubscribeURL      *string
+	UnsubscribeMailto   *string
+	UnsubscribeOneClick bool
 }
 
 // GmailStorer defines the interface for Gmail-related storage operations.
 type GmailStorer interface {
 	CreateGmailAutomationRule(ctx context.Context, arg CreateGmailAutomationRuleParams) (domain.GmailAutomationRule, error)
 	GetGmailRulesForAccount(ctx context.Context, accountID uuid.UUID) ([]domain.GmailAutomationRule, error)
+	CreateUserGmailRule(ctx context.Context, arg CreateUserGmailRuleParams) (domain.GmailAutomationRule, error)
+	GetUserGmailRules(ctx context.Context, userID uuid.UUID) ([]domain.GmailAutomationRule, error)
+	UpdateGmailRuleAccountSelector(
+		ctx context.Context,
+		ruleID uuid.UUID,
+		selector domain.AccountSelector,
+		changedBy uuid.UUID,
+	) (domain.GmailAutomationRule, error)
 	UpdateGmailRule(ctx context.Context, arg UpdateGmailRuleParams) (domain.GmailAutomationRule, error)
 	DeleteGmailRule(ctx context.Context, ruleID uuid.UUID) error
-	ToggleGmailRuleStatus(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error)
+	ToggleGmailRuleStatus(ctx context.Context, ruleID uuid.UUID, changedBy uuid.UUID) (domain.GmailAutomationRule, error)
+	RecordGmailRuleExecution(ctx context.Context, ruleID uuid.UUID) (int, error)
+	ExpireGmailRule(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error)
+	PauseGmailRule(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error)
+	GetGmailRuleByID(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error)
+	VerifyGmailRuleOwnership(ctx context.Context, ruleID uuid.UUID, userID uuid.UUID) error
+	ReorderGmailRules(ctx context.Context, accountID uuid.UUID, ruleIDs []uuid.UUID) error
 	StoreGmailMessage(ctx context.Context, arg StoreGmailMessageParams) error
 	StoreGmailThread(ctx context.Context, arg StoreGmailThreadParams) error
 	UpdateGmailMessageStatus(
@@ -72,9 +112,53 @@ type GmailStorer interface {
 		messageID string,
 		status domain.GmailMessageStatus,
 	) error
+	UpdateGmailMessagesCache(ctx context.Context, arg UpdateGmailMessagesCacheParams) (int64, error)
 	GetGmailMessagesForAccount(ctx context.Context, accountID uuid.UUID, limit int) ([]domain.GmailMessage, error)
 	UpdateGmailSyncState(ctx context.Context, accountID uuid.UUID, historyID string, lastSync time.Time) error
 	GetGmailSyncState(ctx context.Context, accountID uuid.UUID) (historyID *string, lastSync *time.Time, err error)
+	CreateGmailSnooze(ctx context.Context, arg CreateGmailSnoozeParams) (domain.GmailSnooze, error)
+	GetGmailSnoozeByID(ctx context.Context, snoozeID uuid.UUID) (domain.GmailSnooze, error)
+	GetPendingGmailSnoozesForAccount(ctx context.Context, accountID uuid.UUID) ([]domain.GmailSnooze, error)
+	GetDueGmailSnoozes(ctx context.Context, now time.Time, limit int) ([]domain.GmailSnooze, error)
+	UpdateGmailSnoozeStatus(
+		ctx context.Context,
+		snoozeID uuid.UUID,
+		status domain.GmailSnoozeStatus,
+		errorMessage *string,
+	) error
+	CreateGmailScheduledSend(ctx context.Context, arg CreateGmailScheduledSendParams) (domain.GmailScheduledSend, error)
+	GetGmailScheduledSendByID(ctx context.Context, sendID uuid.UUID) (domain.GmailScheduledSend, error)
+	GetPendingGmailScheduledSendsForAccount(ctx context.Context, accountID uuid.UUID) ([]domain.GmailScheduledSend, error)
+	GetDueGmailScheduledSends(ctx context.Context, now time.Time, limit int) ([]domain.GmailScheduledSend, error)
+	UpdateGmailScheduledSend(
+		ctx context.Context,
+		sendID uuid.UUID,
+		message domain.GmailOutgoingMessage,
+		sendAt time.Time,
+	) (domain.GmailScheduledSend, error)
+	UpdateGmailScheduledSendStatus(
+		ctx context.Context,
+		sendID uuid.UUID,
+		status domain.GmailScheduledSendStatus,
+		gmailMessageID, lastError *string,
+	) error
+	RetryGmailScheduledSend(ctx context.Context, sendID uuid.UUID, lastError string, nextAttempt time.Time) error
+	CreateGmailUnsubscribe(ctx context.Context, arg CreateGmailUnsubscribeParams) (domain.GmailUnsubscribe, error)
+	GetGmailNewsletterSenders(ctx context.Context, accountID uuid.UUID, limit int) ([]domain.GmailNewsletterSender, error)
+	UpsertGmailForwardingAddress(
+		ctx context.Context,
+		arg UpsertGmailForwardingAddressParams,
+	) (domain.GmailForwardingAddress, error)
+	GetGmailForwardingAddressByID(ctx context.Context, addressID uuid.UUID) (domain.GmailForwardingAddress, error)
+	GetGmailForwardingAddressesForUser(ctx context.Context, userID uuid.UUID) ([]domain.GmailForwardingAddress, error)
+	UseGmailForwardingVerificationAttempt(ctx context.Context, addressID uuid.UUID, maxAttempts int) error
+	MarkGmailForwardingAddressVerified(ctx context.Context, addressID uuid.UUID) (domain.GmailForwardingAddress, error)
+	DeleteGmailForwardingAddress(ctx context.Context, addressID uuid.UUID) error
+	UpsertGmailDraft(ctx context.Context, arg UpsertGmailDraftParams) (domain.GmailDraft, error)
+	GetGmailDraftByID(ctx context.Context, draftID uuid.UUID) (domain.GmailDraft, error)
+	GetGmailDraftsForAccount(ctx context.Context, accountID uuid.UUID) ([]domain.GmailDraft, error)
+	DeleteGmailDraft(ctx context.Context, draftID uuid.UUID) error
+	PruneGmailDrafts(ctx context.Context, accountID uuid.UUID, keepGmailDraftIDs []string) error
 }
 
 type StoreGmailThreadParams struct {
@@ -88,6 +172,15 @@ type StoreGmailThreadParams struct {
 	Labels             []string
 }
 
+// UpdateGmailMessagesCacheParams contains the parameters for updating cached Gmail messages in bulk.
+// A nil Status or IsStarred leaves that column unchanged.
+type UpdateGmailMessagesCacheParams struct {
+	ConnectedAccountID uuid.UUID
+	GmailMessageIDs    []string
+	Status             *domain.GmailMessageStatus
+	IsStarred          *bool
+}
+
 // GmailStore implements the GmailStorer interface.
 // GmailStore handles Gmail-related database operations
 type GmailStore struct {
@@ -103,30 +196,42 @@ func NewGmailStore(db database.Querier, log *zap.Logger) GmailStorer {
 	}
 }
 
-// CreateGmailAutomationRule creates a new Gmail automation rule.
+// CreateGmailAutomationRule creates a new Gmail automation rule and stores it as version 1.
 func (s *GmailStore) CreateGmailAutomationRule(
 	ctx context.Context,
 	arg CreateGmailAutomationRuleParams,
 ) (domain.GmailAutomationRule, error) {
 	query := `
-		INSERT INTO gmail_automation_rules (
-			connected_account_id, name, description, is_active, trigger_type,
-			trigger_conditions, action_type, action_params, priority
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		RETURNING id, connected_account_id, name, description, is_active, trigger_type,
-		          trigger_conditions, action_type, action_params, priority, created_at, updated_at;
+		WITH created AS (
+			INSERT INTO gmail_automation_rules (
+				connected_account_id, name, description, is_active, trigger_type,
+				trigger_conditions, action_type, action_params, priority, schedule
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			RETURNING id, connected_account_id, name, description, is_active, trigger_type,
+			          trigger_conditions, action_type, action_params, priority, created_at, updated_at, version,
+			          schedule, execution_count, user_id, account_selector
+		), versioned AS (
+			INSERT INTO rule_versions (rule_type, rule_id, version, change_type, changed_by, snapshot)
+			SELECT 'gmail', id, version, 'create', $11::uuid, ` + RuleSnapshotSQL + `
+			FROM created
+		)
+		SELECT id, connected_account_id, name, description, is_active, trigger_type,
+		       trigger_conditions, action_type, action_params, priority, created_at, updated_at, version,
+		       schedule, execution_count, user_id, account_selector
+		FROM created;
 	`
 
 	row := s.db.QueryRow(ctx, query,
 		arg.ConnectedAccountID, arg.Name, arg.Description, arg.IsActive, arg.TriggerType,
-		arg.TriggerConditions, arg.ActionType, arg.ActionParams, arg.Priority,
+		arg.TriggerConditions, arg.ActionType, arg.ActionParams, arg.Priority, arg.Schedule, arg.CreatedBy,
 	)
 
 	var rule domain.GmailAutomationRule
 	err := row.Scan(
 		&rule.ID, &rule.ConnectedAccountID, &rule.Name, &rule.Description, &rule.IsActive,
 		&rule.TriggerType, &rule.TriggerConditions, &rule.ActionType, &rule.ActionParams,
-		&rule.Priority, &rule.CreatedAt, &rule.UpdatedAt,
+		&rule.Priority, &rule.CreatedAt, &rule.UpdatedAt, &rule.Version, &rule.Schedule, &rule.ExecutionCount,
+		&rule.UserID, &rule.AccountSelector,
 	)
 
 	if err != nil {
@@ -143,7 +248,8 @@ func (s *GmailStore) GetGmailRulesForAccount(
 ) ([]domain.GmailAutomationRule, error) {
 	query := `
 		SELECT id, connected_account_id, name, description, is_active, trigger_type,
-		       trigger_conditions, action_type, action_params, priority, created_at, updated_at
+		       trigger_conditions, action_type, action_params, priority, created_at, updated_at, version,
+		       schedule, execution_count, user_id, account_selector
 		FROM gmail_automation_rules
 		WHERE connected_account_id = $1
 		ORDER BY priority DESC, created_at DESC;
@@ -161,7 +267,8 @@ func (s *GmailStore) GetGmailRulesForAccount(
 		err := rows.Scan(
 			&rule.ID, &rule.ConnectedAccountID, &rule.Name, &rule.Description, &rule.IsActive,
 			&rule.TriggerType, &rule.TriggerConditions, &rule.ActionType, &rule.ActionParams,
-			&rule.Priority, &rule.CreatedAt, &rule.UpdatedAt,
+			&rule.Priority, &rule.CreatedAt, &rule.UpdatedAt, &rule.Version, &rule.Schedule, &rule.ExecutionCount,
+			&rule.UserID, &rule.AccountSelector,
 		)
 		if err != nil {
 			return nil, err
@@ -176,30 +283,57 @@ func (s *GmailStore) GetGmailRulesForAccount(
 	return rules, nil
 }
 
-// UpdateGmailRule updates an existing Gmail automation rule.
+// UpdateGmailRule updates an existing Gmail automation rule and writes the new version in the
+// same statement. The diff is taken against the latest stored version.
 func (s *GmailStore) UpdateGmailRule(
 	ctx context.Context,
 	arg UpdateGmailRuleParams,
 ) (domain.GmailAutomationRule, error) {
 	query := `
-		UPDATE gmail_automation_rules
-		SET name = $1, description = $2, trigger_type = $3, trigger_conditions = $4,
-		    action_type = $5, action_params = $6, priority = $7, updated_at = now()
-		WHERE id = $8
-		RETURNING id, connected_account_id, name, description, is_active, trigger_type,
-		          trigger_conditions, action_type, action_params, priority, created_at, updated_at;
+		WITH updated AS (
+			UPDATE gmail_automation_rules
+			SET name = $1, description = $2, trigger_type = $3, trigger_conditions = $4,
+			    action_type = $5, action_params = $6, priority = $7, schedule = $8,
+			    account_selector = COALESCE($12, account_selector),
+			    version = version + 1, updated_at = now()
+			WHERE id = $9
+			RETURNING id, connected_account_id, name, description, is_active, trigger_type,
+			          trigger_conditions, action_type, action_params, priority, created_at, updated_at, version,
+			          schedule, execution_count, user_id, account_selector
+		), versioned AS (
+			INSERT INTO rule_versions (
+				rule_type, rule_id, version, change_type, changed_by, snapshot, diff, restored_from
+			)
+			SELECT 'gmail', u.id, u.version,
+			       CASE WHEN $11::int IS NULL THEN 'update' ELSE 'restore' END,
+			       $10::uuid, u.snapshot,
+			       rule_snapshot_diff((
+			           SELECT v.snapshot FROM rule_versions v
+			           WHERE v.rule_type = 'gmail' AND v.rule_id = u.id
+			           ORDER BY v.version DESC
+			           LIMIT 1
+			       ), u.snapshot),
+			       $11::int
+			FROM (SELECT id, version, ` + RuleSnapshotSQL + ` AS snapshot FROM updated) u
+		)
+		SELECT id, connected_account_id, name, description, is_active, trigger_type,
+		       trigger_conditions, action_type, action_params, priority, created_at, updated_at, version,
+		       schedule, execution_count, user_id, account_selector
+		FROM updated;
 	`
 
 	row := s.db.QueryRow(ctx, query,
 		arg.Name, arg.Description, arg.TriggerType, arg.TriggerConditions,
-		arg.ActionType, arg.ActionParams, arg.Priority, arg.RuleID,
+		arg.ActionType, arg.ActionParams, arg.Priority, arg.Schedule, arg.RuleID,
+		arg.ChangedBy, arg.RestoredFrom, arg.AccountSelector,
 	)
 
 	var rule domain.GmailAutomationRule
 	err := row.Scan(
 		&rule.ID, &rule.ConnectedAccountID, &rule.Name, &rule.Description, &rule.IsActive,
 		&rule.TriggerType, &rule.TriggerConditions, &rule.ActionType, &rule.ActionParams,
-		&rule.Priority, &rule.CreatedAt, &rule.UpdatedAt,
+		&rule.Priority, &rule.CreatedAt, &rule.UpdatedAt, &rule.Version, &rule.Schedule, &rule.ExecutionCount,
+		&rule.UserID, &rule.AccountSelector,
 	)
 
 	if err != nil {
@@ -230,40 +364,216 @@ func (s *GmailStore) DeleteGmailRule(ctx context.Context, ruleID uuid.UUID) erro
 	return nil
 }
 
-// ToggleGmailRuleStatus toggles the active status of a Gmail automation rule
-func (s *GmailStore) ToggleGmailRuleStatus(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error) {
+// ToggleGmailRuleStatus toggles the active status of a Gmail automation rule and writes a new version.
+// Switching a rule on restarts its count for max_executions.
+func (s *GmailStore) ToggleGmailRuleStatus(
+	ctx context.Context,
+	ruleID uuid.UUID,
+	changedBy uuid.UUID,
+) (domain.GmailAutomationRule, error) {
+	query := `
+		WITH toggled AS (
+			UPDATE gmail_automation_rules
+			SET is_active = NOT is_active,
+			    execution_count = CASE WHEN is_active THEN execution_count ELSE 0 END,
+			    version = version + 1, updated_at = now()
+			WHERE id = $1
+			RETURNING id, connected_account_id, name, description, is_active, trigger_type,
+			          trigger_conditions, action_type, action_params, priority, created_at, updated_at, version,
+			          schedule, execution_count, user_id, account_selector
+		), versioned AS (
+			INSERT INTO rule_versions (rule_type, rule_id, version, change_type, changed_by, snapshot, diff)
+			SELECT 'gmail', t.id, t.version, 'toggle', $2::uuid, t.snapshot,
+			       rule_snapshot_diff((
+			           SELECT v.snapshot FROM rule_versions v
+			           WHERE v.rule_type = 'gmail' AND v.rule_id = t.id
+			           ORDER BY v.version DESC
+			           LIMIT 1
+			       ), t.snapshot)
+			FROM (SELECT id, version, ` + RuleSnapshotSQL + ` AS snapshot FROM toggled) t
+		), counters_reset AS (
+			-- A rule that is switched back on starts with a clean hourly limit
+			DELETE FROM rule_execution_counters
+			WHERE scope = 'rule' AND scope_id IN (SELECT id FROM toggled WHERE is_active)
+		)
+		SELECT id, connected_account_id, name, description, is_active, trigger_type,
+		       trigger_conditions, action_type, action_params, priority, created_at, updated_at, version,
+		       schedule, execution_count, user_id, account_selector
+		FROM toggled;
+	`
+
+	row := s.db.QueryRow(ctx, query, ruleID, changedBy)
+
+	var rule domain.GmailAutomationRule
+	err := row.Scan(
+		&rule.ID, &rule.ConnectedAccountID, &rule.Name, &rule.Description, &rule.IsActive,
+		&rule.TriggerType, &rule.TriggerConditions, &rule.ActionType, &rule.ActionParams,
+		&rule.Priority, &rule.CreatedAt, &rule.UpdatedAt, &rule.Version, &rule.Schedule, &rule.ExecutionCount,
+		&rule.UserID, &rule.AccountSelector,
+	)
+
+	if err != nil {
+		return domain.GmailAutomationRule{}, err
+	}
+
+	return rule, nil
+}
+
+// RecordGmailRuleExecution counts a successful execution and returns the new count.
+func (s *GmailStore) RecordGmailRuleExecution(ctx context.Context, ruleID uuid.UUID) (int, error) {
 	query := `
 		UPDATE gmail_automation_rules
-		SET is_active = NOT is_active, updated_at = now()
+		SET execution_count = execution_count + 1
 		WHERE id = $1
-		RETURNING id, connected_account_id, name, description, is_active, trigger_type,
-		          trigger_conditions, action_type, action_params, priority, created_at, updated_at;
+		RETURNING execution_count;
 	`
+	var count int
+	err := s.db.QueryRow(ctx, query, ruleID).Scan(&count)
+	return count, err
+}
 
-	row := s.db.QueryRow(ctx, query, ruleID)
+// ExpireGmailRule switches off a rule whose schedule has expired. The new version has
+// change_type 'expire' and no changed_by, since the worker makes the change.
+func (s *GmailStore) ExpireGmailRule(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error) {
+	return s.deactivateGmailRule(ctx, ruleID, domain.RuleChangeExpire)
+}
+
+// PauseGmailRule switches off a rule that exceeded an execution limit, with change_type 'pause'.
+func (s *GmailStore) PauseGmailRule(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error) {
+	return s.deactivateGmailRule(ctx, ruleID, domain.RuleChangePause)
+}
+
+// deactivateGmailRule switches off an active rule on behalf of the worker. A rule that is
+// already off gives pgx.ErrNoRows.
+func (s *GmailStore) deactivateGmailRule(
+	ctx context.Context,
+	ruleID uuid.UUID,
+	changeType domain.RuleChangeType,
+) (domain.GmailAutomationRule, error) {
+	query := `
+		WITH deactivated AS (
+			UPDATE gmail_automation_rules
+			SET is_active = false, version = version + 1, updated_at = now()
+			WHERE id = $1 AND is_active
+			RETURNING id, connected_account_id, name, description, is_active, trigger_type,
+			          trigger_conditions, action_type, action_params, priority, created_at, updated_at, version,
+			          schedule, execution_count, user_id, account_selector
+		), versioned AS (
+			INSERT INTO rule_versions (rule_type, rule_id, version, change_type, snapshot, diff)
+			SELECT 'gmail', d.id, d.version, $2, d.snapshot,
+			       rule_snapshot_diff((
+			           SELECT v.snapshot FROM rule_versions v
+			           WHERE v.rule_type = 'gmail' AND v.rule_id = d.id
+			           ORDER BY v.version DESC
+			           LIMIT 1
+			       ), d.snapshot)
+			FROM (SELECT id, version, ` + RuleSnapshotSQL + ` AS snapshot FROM deactivated) d
+		)
+		SELECT id, connected_account_id, name, description, is_active, trigger_type,
+		       trigger_conditions, action_type, action_params, priority, created_at, updated_at, version,
+		       schedule, execution_count, user_id, account_selector
+		FROM deactivated;
+	`
 
 	var rule domain.GmailAutomationRule
-	err := row.Scan(
+	err := s.db.QueryRow(ctx, query, ruleID, changeType).Scan(
 		&rule.ID, &rule.ConnectedAccountID, &rule.Name, &rule.Description, &rule.IsActive,
 		&rule.TriggerType, &rule.TriggerConditions, &rule.ActionType, &rule.ActionParams,
-		&rule.Priority, &rule.CreatedAt, &rule.UpdatedAt,
+		&rule.Priority, &rule.CreatedAt, &rule.UpdatedAt, &rule.Version, &rule.Schedule, &rule.ExecutionCount,
+		&rule.UserID, &rule.AccountSelector,
 	)
+	if err != nil {
+		return domain.GmailAutomationRule{}, err
+	}
 
+	return rule, nil
+}
+
+// GetGmailRuleByID gets a single Gmail automation rule.
+func (s *GmailStore) GetGmailRuleByID(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error) {
+	query := `
+		SELECT id, connected_account_id, name, description, is_active, trigger_type,
+		       trigger_conditions, action_type, action_params, priority, created_at, updated_at, version,
+		       schedule, execution_count, user_id, account_selector
+		FROM gmail_automation_rules
+		WHERE id = $1;
+	`
+
+	var rule domain.GmailAutomationRule
+	err := s.db.QueryRow(ctx, query, ruleID).Scan(
+		&rule.ID, &rule.ConnectedAccountID, &rule.Name, &rule.Description, &rule.IsActive,
+		&rule.TriggerType, &rule.TriggerConditions, &rule.ActionType, &rule.ActionParams,
+		&rule.Priority, &rule.CreatedAt, &rule.UpdatedAt, &rule.Version, &rule.Schedule, &rule.ExecutionCount,
+		&rule.UserID, &rule.AccountSelector,
+	)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.GmailAutomationRule{}, errors.New("gmail rule not found")
+		}
 		return domain.GmailAutomationRule{}, err
 	}
 
 	return rule, nil
 }
 
+// VerifyGmailRuleOwnership controleert of een gebruiker de eigenaar is van de Gmail regel (via het
+// account, of direct bij een user-level rule).
+func (s *GmailStore) VerifyGmailRuleOwnership(ctx context.Context, ruleID uuid.UUID, userID uuid.UUID) error {
+	query := `
+		SELECT 1
+		FROM gmail_automation_rules r
+		LEFT JOIN connected_accounts ca ON r.connected_account_id = ca.id
+		WHERE r.id = $1 AND (ca.user_id = $2 OR r.user_id = $2)
+		LIMIT 1;
+	`
+	var exists int
+	err := s.db.QueryRow(ctx, query, ruleID, userID).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errors.New("forbidden: gmail rule not found or does not belong to user")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ReorderGmailRules herschrijft de prioriteiten van de regels van een account in één statement.
+// De eerste regel in ruleIDs krijgt de hoogste prioriteit (regels worden op priority DESC uitgevoerd).
+func (s *GmailStore) ReorderGmailRules(ctx context.Context, accountID uuid.UUID, ruleIDs []uuid.UUID) error {
+	query := `
+		UPDATE gmail_automation_rules r
+		SET priority = cardinality($2::uuid[]) - o.position::int + 1, updated_at = now()
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS o(id, position)
+		WHERE r.id = o.id AND r.connected_account_id = $1;
+	`
+
+	cmdTag, err := s.db.Exec(ctx, query, accountID, ruleIDs)
+	if err != nil {
+		return err
+	}
+
+	if cmdTag.RowsAffected() != int64(len(ruleIDs)) {
+		s.log.Warn("Reorder touched an unexpected number of rules",
+			zap.String("account_id", accountID.String()),
+			zap.Int64("updated", cmdTag.RowsAffected()),
+			zap.Int("requested", len(ruleIDs)),
+		)
+		return errors.New("not all Gmail rules belong to the account")
+	}
+
+	return nil
+}
+
 // StoreGmailMessage stores or updates a Gmail message
 func (s *GmailStore) StoreGmailMessage(ctx context.Context, arg StoreGmailMessageParams) error {
 	query := `
 		INSERT INTO gmail_messages (
 			connected_account_id, gmail_message_id, gmail_thread_id, subject, sender,
 			recipients, cc_recipients, bcc_recipients, snippet, status, is_starred,
-			has_attachments, attachment_count, size_estimate, received_at, labels
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+			has_attachments, attachment_count, size_estimate, received_at, labels,
+			unsubscribe_url, unsubscribe_mailto, unsubscribe_one_click
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
 		ON CONFLICT (connected_account_id, gmail_message_id)
 		DO UPDATE SET
 			subject = EXCLUDED.subject,
@@ -278,6 +588,9 @@ func (s *GmailStore) StoreGmailMessage(ctx context.Context, arg StoreGmailMessag
 			attachment_count = EXCLUDED.attachment_count,
 			size_estimate = EXCLUDED.size_estimate,
 			labels = EXCLUDED.labels,
+			unsubscribe_url = EXCLUDED.unsubscribe_url,
+			unsubscribe_mailto = EXCLUDED.unsubscribe_mailto,
+			unsubscribe_one_click = EXCLUDED.unsubscribe_one_click,
 			last_synced = now(),
 			updated_at = now();
 	`
@@ -286,6 +599,7 @@ func (s *GmailStore) StoreGmailMessage(ctx context.Context, arg StoreGmailMessag
 		arg.ConnectedAccountID, arg.GmailMessageID, arg.GmailThreadID, arg.Subject, arg.Sender,
 		arg.Recipients, arg.CcRecipients, arg.BccRecipients, arg.Snippet, arg.Status, arg.IsStarred,
 		arg.HasAttachments, arg.AttachmentCount, arg.SizeEstimate, arg.ReceivedAt, arg.Labels,
+		arg.UnsubscribeURL, arg.UnsubscribeMailto, arg.UnsubscribeOneClick,
 	)
 
 	if err != nil {
@@ -351,6 +665,24 @@ func (s *GmailStore) UpdateGmailMessageStatus(
 	return nil
 }
 
+// UpdateGmailMessagesCache updates the status and/or star of cached Gmail messages in one statement
+// and returns the number of rows updated. Messages that are not cached are skipped.
+func (s *GmailStore) UpdateGmailMessagesCache(ctx context.Context, arg UpdateGmailMessagesCacheParams) (int64, error) {
+	query := `
+		UPDATE gmail_messages
+		SET status = COALESCE($3, status),
+			is_starred = COALESCE($4, is_starred),
+			updated_at = now()
+		WHERE connected_account_id = $1 AND gmail_message_id = ANY($2);
+	`
+
+	cmdTag, err := s.db.Exec(ctx, query, arg.ConnectedAccountID, arg.GmailMessageIDs, arg.Status, arg.IsStarred)
+	if err != nil {
+		return 0, err
+	}
+	return cmdTag.RowsAffected(), nil
+}
+
 // GetGmailMessagesForAccount gets recent Gmail messages for an account.
 func (s *GmailStore) GetGmailMessagesForAccount(
 	ctx context.Context,
@@ -361,7 +693,8 @@ func (s *GmailStore) GetGmailMessagesForAccount(
 		SELECT id, connected_account_id, gmail_message_id, gmail_thread_id, subject, sender,
 		       recipients, cc_recipients, bcc_recipients, snippet, status, is_starred,
 		       has_attachments, attachment_count, size_estimate, received_at, labels,
-		       last_synced, created_at, updated_at
+		       last_synced, created_at, updated_at,
+		       unsubscribe_url, unsubscribe_mailto, unsubscribe_one_click
 		FROM gmail_messages
 		WHERE connected_account_id = $1
 		ORDER BY received_at DESC
@@ -382,6 +715,7 @@ func (s *GmailStore) GetGmailMessagesForAccount(
 			&msg.Subject, &msg.Sender, &msg.Recipients, &msg.CcRecipients, &msg.BccRecipients,
 			&msg.Snippet, &msg.Status, &msg.IsStarred, &msg.HasAttachments, &msg.AttachmentCount,
 			&msg.SizeEstimate, &msg.ReceivedAt, &msg.Labels, &msg.LastSynced, &msg.CreatedAt, &msg.UpdatedAt,
+			&msg.UnsubscribeURL, &msg.UnsubscribeMailto, &msg.UnsubscribeOneClick,
 		)
 		if err != nil {
 			return nil, err
@@ -21,6 +21,7 @@ import (
 
 var testUUID = uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
 var testAccountID = uuid.MustParse("550e8400-e29b-41d4-a716-446655441111")
+var testUserID = uuid.MustParse("550e8400-e29b-41d4-a716-446655442222")
 var testTime = time.Date(2025, time.November, 16, 12, 0, 0, 0, time.UTC)
 var dummyLog = zap.NewNop()
 var dummyDesc = stringPtr("Test Description")
@@ -33,7 +34,8 @@ func stringPtr(s string) *string {
 // scanRuleHeaders definieert de kolomnamen en de volgorde voor Rules SELECTs
 var scanRuleHeaders = []string{
 	"id", "connected_account_id", "name", "description", "is_active", "trigger_type",
-	"trigger_conditions", "action_type", "action_params", "priority", "created_at", "updated_at",
+	"trigger_conditions", "action_type", "action_params", "priority", "created_at", "updated_at", "version",
+	"schedule", "execution_count", "user_id", "account_selector",
 }
 
 // createMockRuleRow maakt een enkele rij aan voor een GmailAutomationRule (nu dynamisch met params)
@@ -63,7 +65,8 @@ func createMockRuleRow(id uuid.UUID, isActive bool, priority int, name string, d
 		rule.BaseAutomationRule.ID, rule.BaseAutomationRule.ConnectedAccountID, rule.BaseAutomationRule.Name,
 		rule.Description, rule.BaseAutomationRule.IsActive,
 		rule.TriggerType, rule.BaseAutomationRule.TriggerConditions, rule.ActionType, rule.BaseAutomationRule.ActionParams,
-		rule.Priority, rule.BaseAutomationRule.CreatedAt, rule.BaseAutomationRule.UpdatedAt,
+		rule.Priority, rule.BaseAutomationRule.CreatedAt, rule.BaseAutomationRule.UpdatedAt, 1,
+		rule.Schedule, rule.ExecutionCount, rule.UserID, rule.AccountSelector,
 	)
 }
 
@@ -94,11 +97,13 @@ func TestGmailStore_CreateGmailAutomationRule_Success(t *testing.T) {
 		ActionType:         domain.GmailActionStar,
 		ActionParams:       json.RawMessage(`{}`),
 		Priority:           1,
+		CreatedBy:          testUserID,
 	}
 
-	mockDB.ExpectQuery(`INSERT INTO gmail_automation_rules`).
+	mockDB.ExpectQuery(`INSERT INTO gmail_automation_rules .* INSERT INTO rule_versions .* 'create'`).
 		WithArgs(params.ConnectedAccountID, params.Name, params.Description, params.IsActive,
-			params.TriggerType, params.TriggerConditions, params.ActionType, params.ActionParams, params.Priority).
+			params.TriggerType, params.TriggerConditions, params.ActionType, params.ActionParams, params.Priority,
+			params.Schedule, params.CreatedBy).
 		WillReturnRows(createMockRuleRow(testUUID, true, 1, params.Name, params.Description, params.TriggerType, params.ActionType))
 
 	rule, err := store.CreateGmailAutomationRule(context.Background(), params)
@@ -124,11 +129,13 @@ func TestGmailStore_CreateGmailAutomationRule_DBError(t *testing.T) {
 		ActionType:         domain.GmailActionStar,
 		ActionParams:       json.RawMessage(`{}`),
 		Priority:           1,
+		CreatedBy:          testUserID,
 	}
 
-	mockDB.ExpectQuery(`INSERT INTO gmail_automation_rules`).
+	mockDB.ExpectQuery(`INSERT INTO gmail_automation_rules .* INSERT INTO rule_versions .* 'create'`).
 		WithArgs(params.ConnectedAccountID, params.Name, params.Description, params.IsActive,
-			params.TriggerType, params.TriggerConditions, params.ActionType, params.ActionParams, params.Priority).
+			params.TriggerType, params.TriggerConditions, params.ActionType, params.ActionParams, params.Priority,
+			params.Schedule, params.CreatedBy).
 		WillReturnError(fmt.Errorf("database insert error"))
 
 	_, err = store.CreateGmailAutomationRule(context.Background(), params)
@@ -145,8 +152,8 @@ func TestGmailStore_GetGmailRulesForAccount_Success(t *testing.T) {
 	store := NewGmailStore(mockDB, dummyLog)
 
 	rows := pgxmock.NewRows(scanRuleHeaders).
-		AddRow(testUUID, testAccountID, "Rule 1", dummyDesc, true, domain.GmailTriggerNewMessage, json.RawMessage(`{}`), domain.GmailActionArchive, json.RawMessage(`{}`), 10, testTime, testTime).
-		AddRow(uuid.New(), testAccountID, "Rule 2", dummyDesc, false, domain.GmailTriggerStarred, json.RawMessage(`{}`), domain.GmailActionStar, json.RawMessage(`{}`), 5, testTime, testTime)
+		AddRow(testUUID, testAccountID, "Rule 1", dummyDesc, true, domain.GmailTriggerNewMessage, json.RawMessage(`{}`), domain.GmailActionArchive, json.RawMessage(`{}`), 10, testTime, testTime, 1, nil, 0, nil, nil).
+		AddRow(uuid.New(), testAccountID, "Rule 2", dummyDesc, false, domain.GmailTriggerStarred, json.RawMessage(`{}`), domain.GmailActionStar, json.RawMessage(`{}`), 5, testTime, testTime, 1, nil, 0, nil, nil)
 
 	mockDB.ExpectQuery(`SELECT .* FROM gmail_automation_rules WHERE connected_account_id = \$1`).
 		WithArgs(testAccountID).
@@ -184,7 +191,7 @@ func TestGmailStore_GetGmailRulesForAccount_ScanError(t *testing.T) {
 	store := NewGmailStore(mockDB, dummyLog)
 
 	rows := pgxmock.NewRows(scanRuleHeaders).
-		AddRow(testUUID, testAccountID, "Rule 1", dummyDesc, "NOT_BOOL", domain.GmailTriggerNewMessage, json.RawMessage(`{}`), domain.GmailActionArchive, json.RawMessage(`{}`), 10, testTime, testTime) // IsActive is verkeerd
+		AddRow(testUUID, testAccountID, "Rule 1", dummyDesc, "NOT_BOOL", domain.GmailTriggerNewMessage, json.RawMessage(`{}`), domain.GmailActionArchive, json.RawMessage(`{}`), 10, testTime, testTime, 1, nil, 0, nil, nil) // IsActive is verkeerd
 
 	mockDB.ExpectQuery(`SELECT .* FROM gmail_automation_rules WHERE connected_account_id = \$1`).
 		WithArgs(testAccountID).
@@ -212,13 +219,15 @@ func TestGmailStore_UpdateGmailRule_Success(t *testing.T) {
 		ActionType:        domain.GmailActionForward,
 		ActionParams:      json.RawMessage(`{"email": "forward@mail.com"}`),
 		Priority:          5,
+		ChangedBy:         testUserID,
 	}
 
 	expectedRule := createMockRuleRow(testUUID, true, params.Priority, params.Name, params.Description, params.TriggerType, params.ActionType)
 	// Mock ExpectExec omdat we nu de RETURNING gebruiken (QueryRow)
-	mockDB.ExpectQuery(`UPDATE gmail_automation_rules`).
+	mockDB.ExpectQuery(`UPDATE gmail_automation_rules .* INSERT INTO rule_versions .* rule_snapshot_diff`).
 		WithArgs(params.Name, params.Description, params.TriggerType, params.TriggerConditions,
-			params.ActionType, params.ActionParams, params.Priority, params.RuleID).
+			params.ActionType, params.ActionParams, params.Priority, params.Schedule, params.RuleID,
+			params.ChangedBy, params.RestoredFrom, params.AccountSelector).
 		WillReturnRows(expectedRule)
 
 	rule, err := store.UpdateGmailRule(context.Background(), params)
@@ -243,11 +252,13 @@ func TestGmailStore_UpdateGmailRule_NotFound(t *testing.T) {
 		ActionType:        domain.GmailActionForward,
 		ActionParams:      json.RawMessage(`{"email": "forward@mail.com"}`),
 		Priority:          5,
+		ChangedBy:         testUserID,
 	}
 
-	mockDB.ExpectQuery(`UPDATE gmail_automation_rules`).
+	mockDB.ExpectQuery(`UPDATE gmail_automation_rules .* INSERT INTO rule_versions .* rule_snapshot_diff`).
 		WithArgs(params.Name, params.Description, params.TriggerType, params.TriggerConditions,
-			params.ActionType, params.ActionParams, params.Priority, params.RuleID).
+			params.ActionType, params.ActionParams, params.Priority, params.Schedule, params.RuleID,
+			params.ChangedBy, params.RestoredFrom, params.AccountSelector).
 		WillReturnError(pgx.ErrNoRows)
 
 	_, err = store.UpdateGmailRule(context.Background(), params)
@@ -315,11 +326,11 @@ func TestGmailStore_ToggleGmailRuleStatus_Success(t *testing.T) {
 
 	expectedRule := createMockRuleRow(testUUID, false, 1, "Test Rule", dummyDesc, domain.GmailTriggerNewMessage, domain.GmailActionArchive)
 
-	mockDB.ExpectQuery(`UPDATE gmail_automation_rules SET is_active = NOT is_active`).
-		WithArgs(testUUID).
+	mockDB.ExpectQuery(`UPDATE gmail_automation_rules SET is_active = NOT is_active, execution_count = .* version = version \+ 1.* 'toggle'.* DELETE FROM rule_execution_counters`).
+		WithArgs(testUUID, testUserID).
 		WillReturnRows(expectedRule)
 
-	rule, err := store.ToggleGmailRuleStatus(context.Background(), testUUID)
+	rule, err := store.ToggleGmailRuleStatus(context.Background(), testUUID, testUserID)
 	assert.NoError(t, err)
 	assert.Equal(t, testUUID, rule.ID)
 	assert.False(t, rule.IsActive) // Controleer de status toggle
@@ -333,11 +344,11 @@ func TestGmailStore_ToggleGmailRuleStatus_NotFound(t *testing.T) {
 
 	store := NewGmailStore(mockDB, dummyLog)
 
-	mockDB.ExpectQuery(`UPDATE gmail_automation_rules SET is_active = NOT is_active`).
-		WithArgs(testUUID).
+	mockDB.ExpectQuery(`UPDATE gmail_automation_rules SET is_active = NOT is_active, execution_count = .* version = version \+ 1.* 'toggle'`).
+		WithArgs(testUUID, testUserID).
 		WillReturnError(pgx.ErrNoRows)
 
-	_, err = store.ToggleGmailRuleStatus(context.Background(), testUUID)
+	_, err = store.ToggleGmailRuleStatus(context.Background(), testUUID, testUserID)
 	assert.Error(t, err)
 	assert.ErrorIs(t, err, pgx.ErrNoRows)
 	assert.NoError(t, mockDB.ExpectationsWereMet())
@@ -345,6 +356,113 @@ func TestGmailStore_ToggleGmailRuleStatus_NotFound(t *testing.T) {
 
 // --- SYNC STATE TESTS ---
 
+func TestGmailStore_GetGmailRuleByID_Success(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+
+	expectedRule := createMockRuleRow(testUUID, true, 3, "Test Rule", dummyDesc, domain.GmailTriggerSenderMatch, domain.GmailActionStar)
+
+	mockDB.ExpectQuery(`SELECT .* FROM gmail_automation_rules WHERE id = \$1`).
+		WithArgs(testUUID).
+		WillReturnRows(expectedRule)
+
+	rule, err := store.GetGmailRuleByID(context.Background(), testUUID)
+	assert.NoError(t, err)
+	assert.Equal(t, testUUID, rule.ID)
+	assert.Equal(t, 3, rule.Priority)
+	assert.Equal(t, domain.GmailActionStar, rule.ActionType)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_GetGmailRuleByID_NotFound(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+
+	mockDB.ExpectQuery(`SELECT .* FROM gmail_automation_rules WHERE id = \$1`).
+		WithArgs(testUUID).
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err = store.GetGmailRuleByID(context.Background(), testUUID)
+	assert.ErrorContains(t, err, "gmail rule not found")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_VerifyGmailRuleOwnership(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("owner", func(t *testing.T) {
+		mockDB, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mockDB.Close()
+
+		store := NewGmailStore(mockDB, dummyLog)
+
+		mockDB.ExpectQuery(`SELECT 1 FROM gmail_automation_rules r LEFT JOIN connected_accounts ca`).
+			WithArgs(testUUID, userID).
+			WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(1))
+
+		assert.NoError(t, store.VerifyGmailRuleOwnership(context.Background(), testUUID, userID))
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("not owner", func(t *testing.T) {
+		mockDB, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mockDB.Close()
+
+		store := NewGmailStore(mockDB, dummyLog)
+
+		mockDB.ExpectQuery(`SELECT 1 FROM gmail_automation_rules r LEFT JOIN connected_accounts ca`).
+			WithArgs(testUUID, userID).
+			WillReturnError(pgx.ErrNoRows)
+
+		err = store.VerifyGmailRuleOwnership(context.Background(), testUUID, userID)
+		assert.ErrorContains(t, err, "forbidden")
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+}
+
+func TestGmailStore_ReorderGmailRules(t *testing.T) {
+	ruleIDs := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+
+	t.Run("success", func(t *testing.T) {
+		mockDB, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mockDB.Close()
+
+		store := NewGmailStore(mockDB, dummyLog)
+
+		mockDB.ExpectExec(`UPDATE gmail_automation_rules r SET priority = .* FROM unnest`).
+			WithArgs(testAccountID, ruleIDs).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 3))
+
+		assert.NoError(t, store.ReorderGmailRules(context.Background(), testAccountID, ruleIDs))
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("rule of other account", func(t *testing.T) {
+		mockDB, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mockDB.Close()
+
+		store := NewGmailStore(mockDB, dummyLog)
+
+		mockDB.ExpectExec(`UPDATE gmail_automation_rules r SET priority = .* FROM unnest`).
+			WithArgs(testAccountID, ruleIDs).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+
+		err = store.ReorderGmailRules(context.Background(), testAccountID, ruleIDs)
+		assert.ErrorContains(t, err, "not all Gmail rules belong to the account")
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+}
+
 func TestGmailStore_UpdateGmailSyncState_Success(t *testing.T) {
 	mockDB, err := pgxmock.NewPool()
 	require.NoError(t, err)
@@ -436,3 +554,75 @@ func TestGmailStore_GetGmailSyncState_DBError(t *testing.T) {
 	assert.ErrorContains(t, err, "db connection failed")
 	assert.NoError(t, mockDB.ExpectationsWereMet())
 }
+
+func TestGmailStore_RecordGmailRuleExecution(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	store := NewGmailStore(mockDB, dummyLog)
+
+	mockDB.ExpectQuery(`UPDATE gmail_automation_rules SET execution_count = execution_count \+ 1 WHERE id = \$1 RETURNING execution_count`).
+		WithArgs(testUUID).
+		WillReturnRows(pgxmock.NewRows([]string{"execution_count"}).AddRow(5))
+
+	count, err := store.RecordGmailRuleExecution(context.Background(), testUUID)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, count)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_ExpireGmailRule(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	store := NewGmailStore(mockDB, dummyLog)
+
+	// Alleen een actieve rule wordt uitgezet, met een versie zonder changed_by
+	mockDB.ExpectQuery(`WITH deactivated AS \( UPDATE gmail_automation_rules SET is_active = false.* AND is_active .* \$2`).
+		WithArgs(testUUID, domain.RuleChangeExpire).
+		WillReturnRows(createMockRuleRow(testUUID, false, 1, "Vakantie", nil, domain.GmailTriggerNewMessage, domain.GmailActionAutoReply))
+
+	rule, err := store.ExpireGmailRule(context.Background(), testUUID)
+	assert.NoError(t, err)
+	assert.False(t, rule.IsActive)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_PauseGmailRule(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	store := NewGmailStore(mockDB, dummyLog)
+
+	mockDB.ExpectQuery(`WITH deactivated AS \( UPDATE gmail_automation_rules SET is_active = false`).
+		WithArgs(testUUID, domain.RuleChangePause).
+		WillReturnRows(createMockRuleRow(testUUID, false, 1, "Alles beantwoorden", nil, domain.GmailTriggerNewMessage, domain.GmailActionAutoReply))
+
+	rule, err := store.PauseGmailRule(context.Background(), testUUID)
+	assert.NoError(t, err)
+	assert.False(t, rule.IsActive)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_UpdateGmailMessagesCache(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+	ids := []string{"msg-1", "msg-2"}
+	starred := true
+
+	mockDB.ExpectExec(`UPDATE gmail_messages SET status = COALESCE\(\$3, status\), is_starred = COALESCE\(\$4, is_starred\).* gmail_message_id = ANY\(\$2\)`).
+		WithArgs(testAccountID, ids, (*domain.GmailMessageStatus)(nil), &starred).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	updated, err := store.UpdateGmailMessagesCache(context.Background(), UpdateGmailMessagesCacheParams{
+		ConnectedAccountID: testAccountID,
+		GmailMessageIDs:    ids,
+		IsStarred:          &starred,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), updated)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
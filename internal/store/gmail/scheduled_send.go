@@ -0,0 +1,243 @@
+package gmail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"agenda-automator-api/internal/crypto"
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateGmailScheduledSendParams contains parameters for queueing an outgoing message.
+type CreateGmailScheduledSendParams struct {
+	ConnectedAccountID uuid.UUID
+	RuleID             *uuid.UUID
+	Message            domain.GmailOutgoingMessage
+	SendAt             time.Time
+}
+
+const scheduledSendColumns = `id, connected_account_id, rule_id, payload, send_at, status,
+		       attempts, last_error, gmail_message_id, created_at, updated_at`
+
+// encryptOutgoingMessage serialiseert en versleutelt een bericht voor opslag
+func encryptOutgoingMessage(message domain.GmailOutgoingMessage) ([]byte, error) {
+	plaintext, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal message: %w", err)
+	}
+
+	payload, err := crypto.Encrypt(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("could not encrypt message: %w", err)
+	}
+	return payload, nil
+}
+
+// scanScheduledSend scans a database row into a GmailScheduledSend and decrypts the message
+func scanScheduledSend(row pgx.Row) (domain.GmailScheduledSend, error) {
+	var send domain.GmailScheduledSend
+	var payload []byte
+	err := row.Scan(
+		&send.ID, &send.ConnectedAccountID, &send.RuleID, &payload, &send.SendAt, &send.Status,
+		&send.Attempts, &send.LastError, &send.GmailMessageID, &send.CreatedAt, &send.UpdatedAt,
+	)
+	if err != nil {
+		return domain.GmailScheduledSend{}, err
+	}
+
+	plaintext, err := crypto.Decrypt(payload)
+	if err != nil {
+		return domain.GmailScheduledSend{}, fmt.Errorf("could not decrypt message: %w", err)
+	}
+	if err := json.Unmarshal(plaintext, &send.Message); err != nil {
+		return domain.GmailScheduledSend{}, fmt.Errorf("could not unmarshal message: %w", err)
+	}
+
+	return send, nil
+}
+
+// CreateGmailScheduledSend queues a message to be sent at SendAt.
+func (s *GmailStore) CreateGmailScheduledSend(
+	ctx context.Context,
+	arg CreateGmailScheduledSendParams,
+) (domain.GmailScheduledSend, error) {
+	payload, err := encryptOutgoingMessage(arg.Message)
+	if err != nil {
+		return domain.GmailScheduledSend{}, err
+	}
+
+	query := `
+		INSERT INTO gmail_scheduled_sends (connected_account_id, rule_id, payload, send_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + scheduledSendColumns + `;
+	`
+
+	row := s.db.QueryRow(ctx, query, arg.ConnectedAccountID, arg.RuleID, payload, arg.SendAt)
+	return scanScheduledSend(row)
+}
+
+// GetGmailScheduledSendByID gets a single scheduled send.
+func (s *GmailStore) GetGmailScheduledSendByID(
+	ctx context.Context,
+	sendID uuid.UUID,
+) (domain.GmailScheduledSend, error) {
+	query := `
+		SELECT ` + scheduledSendColumns + `
+		FROM gmail_scheduled_sends
+		WHERE id = $1;
+	`
+
+	send, err := scanScheduledSend(s.db.QueryRow(ctx, query, sendID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.GmailScheduledSend{}, errors.New("scheduled send not found")
+		}
+		return domain.GmailScheduledSend{}, err
+	}
+	return send, nil
+}
+
+// GetPendingGmailScheduledSendsForAccount gets all messages of an account that still have to be sent.
+func (s *GmailStore) GetPendingGmailScheduledSendsForAccount(
+	ctx context.Context,
+	accountID uuid.UUID,
+) ([]domain.GmailScheduledSend, error) {
+	query := `
+		SELECT ` + scheduledSendColumns + `
+		FROM gmail_scheduled_sends
+		WHERE connected_account_id = $1 AND status = 'scheduled'
+		ORDER BY send_at ASC;
+	`
+
+	rows, err := s.db.Query(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return collectScheduledSends(rows)
+}
+
+// GetDueGmailScheduledSends gets scheduled sends whose send time has passed, oldest first.
+func (s *GmailStore) GetDueGmailScheduledSends(
+	ctx context.Context,
+	now time.Time,
+	limit int,
+) ([]domain.GmailScheduledSend, error) {
+	query := `
+		SELECT ` + scheduledSendColumns + `
+		FROM gmail_scheduled_sends
+		WHERE status = 'scheduled' AND send_at <= $1
+		ORDER BY send_at ASC
+		LIMIT $2;
+	`
+
+	rows, err := s.db.Query(ctx, query, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	return collectScheduledSends(rows)
+}
+
+// UpdateGmailScheduledSend replaces the message and send time of a pending scheduled send.
+func (s *GmailStore) UpdateGmailScheduledSend(
+	ctx context.Context,
+	sendID uuid.UUID,
+	message domain.GmailOutgoingMessage,
+	sendAt time.Time,
+) (domain.GmailScheduledSend, error) {
+	payload, err := encryptOutgoingMessage(message)
+	if err != nil {
+		return domain.GmailScheduledSend{}, err
+	}
+
+	query := `
+		UPDATE gmail_scheduled_sends
+		SET payload = $1, send_at = $2, attempts = 0, last_error = NULL, updated_at = now()
+		WHERE id = $3 AND status = 'scheduled'
+		RETURNING ` + scheduledSendColumns + `;
+	`
+
+	send, err := scanScheduledSend(s.db.QueryRow(ctx, query, payload, sendAt, sendID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.GmailScheduledSend{}, errors.New("scheduled send not found")
+		}
+		return domain.GmailScheduledSend{}, err
+	}
+	return send, nil
+}
+
+// UpdateGmailScheduledSendStatus moves a pending scheduled send to its final status.
+func (s *GmailStore) UpdateGmailScheduledSendStatus(
+	ctx context.Context,
+	sendID uuid.UUID,
+	status domain.GmailScheduledSendStatus,
+	gmailMessageID, lastError *string,
+) error {
+	query := `
+		UPDATE gmail_scheduled_sends
+		SET status = $1, gmail_message_id = $2, last_error = COALESCE($3, last_error), updated_at = now()
+		WHERE id = $4 AND status = 'scheduled';
+	`
+
+	cmdTag, err := s.db.Exec(ctx, query, status, gmailMessageID, lastError, sendID)
+	if err != nil {
+		return err
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return errors.New("no pending scheduled send found with ID " + sendID.String())
+	}
+
+	return nil
+}
+
+// RetryGmailScheduledSend records a failed attempt and moves the send time to the next retry.
+func (s *GmailStore) RetryGmailScheduledSend(
+	ctx context.Context,
+	sendID uuid.UUID,
+	lastError string,
+	nextAttempt time.Time,
+) error {
+	query := `
+		UPDATE gmail_scheduled_sends
+		SET attempts = attempts + 1, last_error = $1, send_at = $2, updated_at = now()
+		WHERE id = $3 AND status = 'scheduled';
+	`
+
+	cmdTag, err := s.db.Exec(ctx, query, lastError, nextAttempt, sendID)
+	if err != nil {
+		return err
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return errors.New("no pending scheduled send found with ID " + sendID.String())
+	}
+
+	return nil
+}
+
+// collectScheduledSends scans all rows and closes them
+func collectScheduledSends(rows pgx.Rows) ([]domain.GmailScheduledSend, error) {
+	defer rows.Close()
+
+	var sends []domain.GmailScheduledSend
+	for rows.Next() {
+		send, err := scanScheduledSend(rows)
+		if err != nil {
+			return nil, err
+		}
+		sends = append(sends, send)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sends, nil
+}
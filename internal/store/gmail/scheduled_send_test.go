@@ -0,0 +1,116 @@
+package gmail
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"agenda-automator-api/internal/crypto"
+	"agenda-automator-api/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var scanScheduledSendHeaders = []string{
+	"id", "connected_account_id", "rule_id", "payload", "send_at", "status",
+	"attempts", "last_error", "gmail_message_id", "created_at", "updated_at",
+}
+
+var testOutgoingMessage = domain.GmailOutgoingMessage{
+	To:      []string{"recipient@example.com"},
+	Subject: "Follow-up",
+	Body:    "Hoi, nog even ter herinnering.",
+}
+
+// encryptedTestPayload versleutelt een bericht zoals de store dat opslaat
+func encryptedTestPayload(t *testing.T, message domain.GmailOutgoingMessage) []byte {
+	t.Helper()
+	t.Setenv("ENCRYPTION_KEY", "12345678901234567890123456789012")
+
+	plaintext, err := json.Marshal(message)
+	require.NoError(t, err)
+	payload, err := crypto.Encrypt(plaintext)
+	require.NoError(t, err)
+	return payload
+}
+
+func TestGmailStore_CreateGmailScheduledSend_Success(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	payload := encryptedTestPayload(t, testOutgoingMessage)
+	store := NewGmailStore(mockDB, dummyLog)
+	params := CreateGmailScheduledSendParams{
+		ConnectedAccountID: testAccountID,
+		Message:            testOutgoingMessage,
+		SendAt:             testTime,
+	}
+
+	// De payload wordt met een willekeurige nonce versleuteld en is dus niet exact te matchen
+	mockDB.ExpectQuery(`INSERT INTO gmail_scheduled_sends`).
+		WithArgs(testAccountID, params.RuleID, pgxmock.AnyArg(), testTime).
+		WillReturnRows(pgxmock.NewRows(scanScheduledSendHeaders).AddRow(
+			testUUID, testAccountID, nil, payload, testTime, domain.GmailScheduledSendPending,
+			0, nil, nil, testTime, testTime,
+		))
+
+	send, err := store.CreateGmailScheduledSend(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Equal(t, testUUID, send.ID)
+	assert.Equal(t, testOutgoingMessage, send.Message)
+	assert.Equal(t, domain.GmailScheduledSendPending, send.Status)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_GetGmailScheduledSendByID_NotFound(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+	mockDB.ExpectQuery(`SELECT .* FROM gmail_scheduled_sends`).
+		WithArgs(testUUID).
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err = store.GetGmailScheduledSendByID(context.Background(), testUUID)
+	assert.EqualError(t, err, "scheduled send not found")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_GetDueGmailScheduledSends_DecryptFails(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	t.Setenv("ENCRYPTION_KEY", "12345678901234567890123456789012")
+	store := NewGmailStore(mockDB, dummyLog)
+	mockDB.ExpectQuery(`SELECT .* FROM gmail_scheduled_sends WHERE status = 'scheduled' AND send_at <= \$1`).
+		WithArgs(testTime, 50).
+		WillReturnRows(pgxmock.NewRows(scanScheduledSendHeaders).AddRow(
+			testUUID, testAccountID, nil, []byte("not encrypted"), testTime, domain.GmailScheduledSendPending,
+			0, nil, nil, testTime, testTime,
+		))
+
+	_, err = store.GetDueGmailScheduledSends(context.Background(), testTime, 50)
+	assert.ErrorContains(t, err, "could not decrypt message")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_RetryGmailScheduledSend_NotPending(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+	mockDB.ExpectExec(`UPDATE gmail_scheduled_sends SET attempts = attempts \+ 1`).
+		WithArgs("timeout", testTime, testUUID).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	err = store.RetryGmailScheduledSend(context.Background(), testUUID, "timeout", testTime)
+	assert.EqualError(t, err, "no pending scheduled send found with ID "+testUUID.String())
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,149 @@
+package gmail
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateGmailSnoozeParams contains parameters for snoozing a Gmail message.
+type CreateGmailSnoozeParams struct {
+	ConnectedAccountID uuid.UUID
+	GmailMessageID     string
+	GmailThreadID      string
+	WakeAt             time.Time
+}
+
+const snoozeColumns = `id, connected_account_id, gmail_message_id, gmail_thread_id,
+		       wake_at, status, error_message, created_at, updated_at`
+
+// scanSnooze scans a database row into a GmailSnooze
+func scanSnooze(row pgx.Row) (domain.GmailSnooze, error) {
+	var snooze domain.GmailSnooze
+	err := row.Scan(
+		&snooze.ID, &snooze.ConnectedAccountID, &snooze.GmailMessageID, &snooze.GmailThreadID,
+		&snooze.WakeAt, &snooze.Status, &snooze.ErrorMessage, &snooze.CreatedAt, &snooze.UpdatedAt,
+	)
+	return snooze, err
+}
+
+// CreateGmailSnooze stores a snooze. Snoozing an already snoozed message moves its wake-up time.
+func (s *GmailStore) CreateGmailSnooze(
+	ctx context.Context,
+	arg CreateGmailSnoozeParams,
+) (domain.GmailSnooze, error) {
+	query := `
+		INSERT INTO gmail_snoozes (connected_account_id, gmail_message_id, gmail_thread_id, wake_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (connected_account_id, gmail_message_id) WHERE status = 'snoozed'
+		DO UPDATE SET wake_at = EXCLUDED.wake_at, updated_at = now()
+		RETURNING ` + snoozeColumns + `;
+	`
+
+	row := s.db.QueryRow(ctx, query, arg.ConnectedAccountID, arg.GmailMessageID, arg.GmailThreadID, arg.WakeAt)
+	return scanSnooze(row)
+}
+
+// GetGmailSnoozeByID gets a single snooze.
+func (s *GmailStore) GetGmailSnoozeByID(ctx context.Context, snoozeID uuid.UUID) (domain.GmailSnooze, error) {
+	query := `
+		SELECT ` + snoozeColumns + `
+		FROM gmail_snoozes
+		WHERE id = $1;
+	`
+
+	snooze, err := scanSnooze(s.db.QueryRow(ctx, query, snoozeID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.GmailSnooze{}, errors.New("snooze not found")
+		}
+		return domain.GmailSnooze{}, err
+	}
+	return snooze, nil
+}
+
+// GetPendingGmailSnoozesForAccount gets all messages of an account that are still snoozed.
+func (s *GmailStore) GetPendingGmailSnoozesForAccount(
+	ctx context.Context,
+	accountID uuid.UUID,
+) ([]domain.GmailSnooze, error) {
+	query := `
+		SELECT ` + snoozeColumns + `
+		FROM gmail_snoozes
+		WHERE connected_account_id = $1 AND status = 'snoozed'
+		ORDER BY wake_at ASC;
+	`
+
+	rows, err := s.db.Query(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return collectSnoozes(rows)
+}
+
+// GetDueGmailSnoozes gets snoozes whose wake-up time has passed, oldest first.
+func (s *GmailStore) GetDueGmailSnoozes(ctx context.Context, now time.Time, limit int) ([]domain.GmailSnooze, error) {
+	query := `
+		SELECT ` + snoozeColumns + `
+		FROM gmail_snoozes
+		WHERE status = 'snoozed' AND wake_at <= $1
+		ORDER BY wake_at ASC
+		LIMIT $2;
+	`
+
+	rows, err := s.db.Query(ctx, query, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	return collectSnoozes(rows)
+}
+
+// UpdateGmailSnoozeStatus moves a pending snooze to its final status.
+func (s *GmailStore) UpdateGmailSnoozeStatus(
+	ctx context.Context,
+	snoozeID uuid.UUID,
+	status domain.GmailSnoozeStatus,
+	errorMessage *string,
+) error {
+	query := `
+		UPDATE gmail_snoozes
+		SET status = $1, error_message = $2, updated_at = now()
+		WHERE id = $3 AND status = 'snoozed';
+	`
+
+	cmdTag, err := s.db.Exec(ctx, query, status, errorMessage, snoozeID)
+	if err != nil {
+		return err
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return errors.New("no pending snooze found with ID " + snoozeID.String())
+	}
+
+	return nil
+}
+
+// collectSnoozes scans all rows and closes them
+func collectSnoozes(rows pgx.Rows) ([]domain.GmailSnooze, error) {
+	defer rows.Close()
+
+	var snoozes []domain.GmailSnooze
+	for rows.Next() {
+		snooze, err := scanSnooze(rows)
+		if err != nil {
+			return nil, err
+		}
+		snoozes = append(snoozes, snooze)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return snoozes, nil
+}
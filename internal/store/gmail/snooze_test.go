@@ -0,0 +1,95 @@
+package gmail
+
+import (
+	"context"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var scanSnoozeHeaders = []string{
+	"id", "connected_account_id", "gmail_message_id", "gmail_thread_id",
+	"wake_at", "status", "error_message", "created_at", "updated_at",
+}
+
+func TestGmailStore_CreateGmailSnooze_Success(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+	params := CreateGmailSnoozeParams{
+		ConnectedAccountID: testAccountID,
+		GmailMessageID:     "msg-1",
+		GmailThreadID:      "thread-1",
+		WakeAt:             testTime,
+	}
+
+	mockDB.ExpectQuery(`INSERT INTO gmail_snoozes`).
+		WithArgs(params.ConnectedAccountID, params.GmailMessageID, params.GmailThreadID, params.WakeAt).
+		WillReturnRows(pgxmock.NewRows(scanSnoozeHeaders).AddRow(
+			testUUID, testAccountID, "msg-1", "thread-1", testTime,
+			domain.GmailSnoozePending, nil, testTime, testTime,
+		))
+
+	snooze, err := store.CreateGmailSnooze(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Equal(t, testUUID, snooze.ID)
+	assert.Equal(t, domain.GmailSnoozePending, snooze.Status)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_GetGmailSnoozeByID_NotFound(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+	mockDB.ExpectQuery(`SELECT .* FROM gmail_snoozes`).
+		WithArgs(testUUID).
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err = store.GetGmailSnoozeByID(context.Background(), testUUID)
+	assert.EqualError(t, err, "snooze not found")
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_GetDueGmailSnoozes_Success(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+	mockDB.ExpectQuery(`SELECT .* FROM gmail_snoozes WHERE status = 'snoozed' AND wake_at <= \$1`).
+		WithArgs(testTime, 50).
+		WillReturnRows(pgxmock.NewRows(scanSnoozeHeaders).
+			AddRow(uuid.New(), testAccountID, "msg-1", "thread-1", testTime, domain.GmailSnoozePending, nil, testTime, testTime).
+			AddRow(uuid.New(), testAccountID, "msg-2", "thread-2", testTime, domain.GmailSnoozePending, nil, testTime, testTime))
+
+	snoozes, err := store.GetDueGmailSnoozes(context.Background(), testTime, 50)
+	assert.NoError(t, err)
+	assert.Len(t, snoozes, 2)
+	assert.Equal(t, "msg-2", snoozes[1].GmailMessageID)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_UpdateGmailSnoozeStatus_NotPending(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+	mockDB.ExpectExec(`UPDATE gmail_snoozes`).
+		WithArgs(domain.GmailSnoozeCancelled, (*string)(nil), testUUID).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	err = store.UpdateGmailSnoozeStatus(context.Background(), testUUID, domain.GmailSnoozeCancelled, nil)
+	assert.Error(t, err)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
@@ -0,0 +1,96 @@
+package gmail
+
+import (
+	"context"
+
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// CreateGmailUnsubscribeParams contains parameters for recording an unsubscribe attempt.
+type CreateGmailUnsubscribeParams struct {
+	ConnectedAccountID uuid.UUID
+	GmailMessageID     string
+	Sender             *string
+	Method             string
+	Target             string
+	Status             domain.GmailUnsubscribeStatus
+	ErrorMessage       *string
+}
+
+// CreateGmailUnsubscribe records the result of an unsubscribe attempt.
+func (s *GmailStore) CreateGmailUnsubscribe(
+	ctx context.Context,
+	arg CreateGmailUnsubscribeParams,
+) (domain.GmailUnsubscribe, error) {
+	query := `
+		INSERT INTO gmail_unsubscribes (
+			connected_account_id, gmail_message_id, sender, method, target, status, error_message
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, connected_account_id, gmail_message_id, sender, method, target,
+		          status, error_message, created_at, updated_at;
+	`
+
+	var u domain.GmailUnsubscribe
+	err := s.db.QueryRow(ctx, query,
+		arg.ConnectedAccountID, arg.GmailMessageID, arg.Sender, arg.Method, arg.Target, arg.Status, arg.ErrorMessage,
+	).Scan(
+		&u.ID, &u.ConnectedAccountID, &u.GmailMessageID, &u.Sender, &u.Method, &u.Target,
+		&u.Status, &u.ErrorMessage, &u.CreatedAt, &u.UpdatedAt,
+	)
+	return u, err
+}
+
+// GetGmailNewsletterSenders gets the senders with the most mailing list messages.
+func (s *GmailStore) GetGmailNewsletterSenders(
+	ctx context.Context,
+	accountID uuid.UUID,
+	limit int,
+) ([]domain.GmailNewsletterSender, error) {
+	query := `
+		SELECT m.sender,
+		       count(*) AS message_count,
+		       count(*) FILTER (WHERE m.status = 'unread') AS unread_count,
+		       max(m.received_at) AS last_received_at,
+		       (array_agg(m.gmail_message_id ORDER BY m.received_at DESC))[1] AS latest_message_id,
+		       bool_or(m.unsubscribe_one_click) AS one_click,
+		       EXISTS (
+		           SELECT 1 FROM gmail_unsubscribes u
+		           WHERE u.connected_account_id = m.connected_account_id
+		             AND u.sender = m.sender AND u.status = 'success'
+		       ) AS unsubscribed
+		FROM gmail_messages m
+		WHERE m.connected_account_id = $1
+		  AND m.sender IS NOT NULL
+		  AND (m.unsubscribe_url IS NOT NULL OR m.unsubscribe_mailto IS NOT NULL)
+		GROUP BY m.connected_account_id, m.sender
+		ORDER BY message_count DESC
+		LIMIT $2;
+	`
+
+	rows, err := s.db.Query(ctx, query, accountID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var senders []domain.GmailNewsletterSender
+	for rows.Next() {
+		var sender domain.GmailNewsletterSender
+		err := rows.Scan(
+			&sender.Sender, &sender.MessageCount, &sender.UnreadCount, &sender.LastReceivedAt,
+			&sender.LatestMessageID, &sender.OneClick, &sender.Unsubscribed,
+		)
+		if err != nil {
+			return nil, err
+		}
+		senders = append(senders, sender)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return senders, nil
+}
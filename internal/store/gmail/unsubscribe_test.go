@@ -0,0 +1,70 @@
+package gmail
+
+import (
+	"context"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGmailStore_CreateGmailUnsubscribe_Success(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+	sender := "News <news@example.com>"
+	params := CreateGmailUnsubscribeParams{
+		ConnectedAccountID: testAccountID,
+		GmailMessageID:     "msg-1",
+		Sender:             &sender,
+		Method:             "one_click",
+		Target:             "https://example.com/u/1",
+		Status:             domain.GmailUnsubscribeSuccess,
+	}
+
+	mockDB.ExpectQuery(`INSERT INTO gmail_unsubscribes`).
+		WithArgs(testAccountID, "msg-1", &sender, "one_click", "https://example.com/u/1",
+			domain.GmailUnsubscribeSuccess, params.ErrorMessage).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "connected_account_id", "gmail_message_id", "sender", "method", "target",
+			"status", "error_message", "created_at", "updated_at",
+		}).AddRow(
+			testUUID, testAccountID, "msg-1", &sender, "one_click", "https://example.com/u/1",
+			domain.GmailUnsubscribeSuccess, nil, testTime, testTime,
+		))
+
+	u, err := store.CreateGmailUnsubscribe(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Equal(t, testUUID, u.ID)
+	assert.Equal(t, domain.GmailUnsubscribeSuccess, u.Status)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_GetGmailNewsletterSenders_Success(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+	mockDB.ExpectQuery(`SELECT m.sender, .* FROM gmail_messages m .* GROUP BY m.connected_account_id, m.sender`).
+		WithArgs(testAccountID, 20).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"sender", "message_count", "unread_count", "last_received_at",
+			"latest_message_id", "one_click", "unsubscribed",
+		}).
+			AddRow("News <news@example.com>", 42, 40, testTime, "msg-9", true, false).
+			AddRow("Deals <deals@example.com>", 12, 3, testTime, "msg-4", false, true))
+
+	senders, err := store.GetGmailNewsletterSenders(context.Background(), testAccountID, 20)
+	assert.NoError(t, err)
+	require.Len(t, senders, 2)
+	assert.Equal(t, 42, senders[0].MessageCount)
+	assert.True(t, senders[0].OneClick)
+	assert.True(t, senders[1].Unsubscribed)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
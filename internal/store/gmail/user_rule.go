@@ -0,0 +1,134 @@
+package gmail
+
+import (
+	"context"
+	"encoding/json"
+
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateUserGmailRuleParams contains the parameters for a user-level Gmail rule. Instead of
+// an account the rule has an owner and a selector that picks the accounts it runs on.
+type CreateUserGmailRuleParams struct {
+	UserID            uuid.UUID
+	AccountSelector   domain.AccountSelector
+	Name              string
+	Description       *string
+	IsActive          bool
+	TriggerType       domain.GmailRuleTriggerType
+	TriggerConditions json.RawMessage
+	ActionType        domain.GmailRuleActionType
+	ActionParams      json.RawMessage
+	Priority          int
+	Schedule          *domain.RuleSchedule
+}
+
+const gmailRuleColumns = `id, connected_account_id, name, description, is_active, trigger_type,
+		       trigger_conditions, action_type, action_params, priority, created_at, updated_at, version,
+		       schedule, execution_count, user_id, account_selector`
+
+// scanGmailRule scans a database row into a GmailAutomationRule
+func scanGmailRule(row pgx.Row) (domain.GmailAutomationRule, error) {
+	var rule domain.GmailAutomationRule
+	err := row.Scan(
+		&rule.ID, &rule.ConnectedAccountID, &rule.Name, &rule.Description, &rule.IsActive,
+		&rule.TriggerType, &rule.TriggerConditions, &rule.ActionType, &rule.ActionParams,
+		&rule.Priority, &rule.CreatedAt, &rule.UpdatedAt, &rule.Version, &rule.Schedule, &rule.ExecutionCount,
+		&rule.UserID, &rule.AccountSelector,
+	)
+	return rule, err
+}
+
+// CreateUserGmailRule creates a user-level Gmail rule and stores it as version 1, with the
+// owner as changed_by.
+func (s *GmailStore) CreateUserGmailRule(
+	ctx context.Context,
+	arg CreateUserGmailRuleParams,
+) (domain.GmailAutomationRule, error) {
+	query := `
+		WITH created AS (
+			INSERT INTO gmail_automation_rules (
+				user_id, account_selector, name, description, is_active, trigger_type,
+				trigger_conditions, action_type, action_params, priority, schedule
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			RETURNING ` + gmailRuleColumns + `
+		), versioned AS (
+			INSERT INTO rule_versions (rule_type, rule_id, version, change_type, changed_by, snapshot)
+			SELECT 'gmail', id, version, 'create', user_id, ` + RuleSnapshotSQL + `
+			FROM created
+		)
+		SELECT ` + gmailRuleColumns + `
+		FROM created;
+	`
+
+	return scanGmailRule(s.db.QueryRow(ctx, query,
+		arg.UserID, arg.AccountSelector, arg.Name, arg.Description, arg.IsActive, arg.TriggerType,
+		arg.TriggerConditions, arg.ActionType, arg.ActionParams, arg.Priority, arg.Schedule,
+	))
+}
+
+// GetUserGmailRules gets the user-level Gmail rules of a user, highest priority first.
+// Whether a rule runs on an account is up to the caller, see AppliesTo.
+func (s *GmailStore) GetUserGmailRules(ctx context.Context, userID uuid.UUID) ([]domain.GmailAutomationRule, error) {
+	query := `
+		SELECT ` + gmailRuleColumns + `
+		FROM gmail_automation_rules
+		WHERE user_id = $1
+		ORDER BY priority DESC, created_at DESC;
+	`
+
+	rows, err := s.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []domain.GmailAutomationRule
+	for rows.Next() {
+		rule, err := scanGmailRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// UpdateGmailRuleAccountSelector replaces the selector of a user-level Gmail rule and writes
+// the new version in the same statement. An account rule returns pgx.ErrNoRows.
+func (s *GmailStore) UpdateGmailRuleAccountSelector(
+	ctx context.Context,
+	ruleID uuid.UUID,
+	selector domain.AccountSelector,
+	changedBy uuid.UUID,
+) (domain.GmailAutomationRule, error) {
+	query := `
+		WITH updated AS (
+			UPDATE gmail_automation_rules
+			SET account_selector = $2, version = version + 1, updated_at = now()
+			WHERE id = $1 AND user_id IS NOT NULL
+			RETURNING ` + gmailRuleColumns + `
+		), versioned AS (
+			INSERT INTO rule_versions (rule_type, rule_id, version, change_type, changed_by, snapshot, diff)
+			SELECT 'gmail', u.id, u.version, 'update', $3::uuid, u.snapshot,
+			       rule_snapshot_diff((
+			           SELECT v.snapshot FROM rule_versions v
+			           WHERE v.rule_type = 'gmail' AND v.rule_id = u.id
+			           ORDER BY v.version DESC
+			           LIMIT 1
+			       ), u.snapshot)
+			FROM (SELECT id, version, ` + RuleSnapshotSQL + ` AS snapshot FROM updated) u
+		)
+		SELECT ` + gmailRuleColumns + `
+		FROM updated;
+	`
+	return scanGmailRule(s.db.QueryRow(ctx, query, ruleID, selector, changedBy))
+}
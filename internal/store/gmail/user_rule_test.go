@@ -0,0 +1,114 @@
+package gmail
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func userRuleRow(selector *domain.AccountSelector, name string, priority int) []any {
+	return []any{
+		testUUID, nil, name, dummyDesc, true, domain.GmailTriggerNewMessage,
+		json.RawMessage(`{}`), domain.GmailActionArchive, json.RawMessage(`{}`), priority, testTime, testTime, 1,
+		nil, 0, &testUserID, selector,
+	}
+}
+
+func TestGmailStore_CreateUserGmailRule(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+	params := CreateUserGmailRuleParams{
+		UserID:          testUserID,
+		AccountSelector: domain.AccountSelector{Mode: domain.AccountSelectorProvider, Provider: domain.ProviderGoogle},
+		Name:            "Nieuwsbrieven archiveren",
+		Description:     dummyDesc,
+		IsActive:        true,
+		TriggerType:     domain.GmailTriggerNewMessage,
+		ActionType:      domain.GmailActionArchive,
+		Priority:        2,
+	}
+
+	// De eigenaar is changed_by van versie 1; er is geen connected_account_id
+	mockDB.ExpectQuery(`WITH created AS \( INSERT INTO gmail_automation_rules \( user_id, account_selector, .* 'create', user_id`).
+		WithArgs(
+			params.UserID, params.AccountSelector, params.Name, params.Description, params.IsActive, params.TriggerType,
+			params.TriggerConditions, params.ActionType, params.ActionParams, params.Priority, params.Schedule,
+		).
+		WillReturnRows(pgxmock.NewRows(scanRuleHeaders).AddRow(userRuleRow(&params.AccountSelector, params.Name, 2)...))
+
+	rule, err := store.CreateUserGmailRule(context.Background(), params)
+	require.NoError(t, err)
+	assert.True(t, rule.IsUserRule())
+	assert.Equal(t, testUserID, *rule.UserID)
+	assert.Equal(t, params.AccountSelector, *rule.AccountSelector)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_GetUserGmailRules(t *testing.T) {
+	mockDB, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockDB.Close()
+
+	store := NewGmailStore(mockDB, dummyLog)
+	selector := &domain.AccountSelector{Mode: domain.AccountSelectorAll}
+
+	mockDB.ExpectQuery(`SELECT .* FROM gmail_automation_rules WHERE user_id = \$1 ORDER BY priority DESC`).
+		WithArgs(testUserID).
+		WillReturnRows(pgxmock.NewRows(scanRuleHeaders).
+			AddRow(userRuleRow(selector, "Hoog", 5)...).
+			AddRow(userRuleRow(selector, "Laag", 1)...))
+
+	rules, err := store.GetUserGmailRules(context.Background(), testUserID)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, "Hoog", rules[0].Name)
+	assert.Equal(t, domain.AccountSelectorAll, rules[1].AccountSelector.Mode)
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGmailStore_UpdateGmailRuleAccountSelector(t *testing.T) {
+	selector := domain.AccountSelector{Mode: domain.AccountSelectorAccounts, AccountIDs: []uuid.UUID{testAccountID}}
+
+	t.Run("user rule", func(t *testing.T) {
+		mockDB, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mockDB.Close()
+
+		store := NewGmailStore(mockDB, dummyLog)
+		// De nieuwe selector wordt een versie, met de selector in de snapshot
+		mockDB.ExpectQuery(`WITH updated AS \( UPDATE gmail_automation_rules SET account_selector = \$2, version = version \+ 1.* WHERE id = \$1 AND user_id IS NOT NULL.* INSERT INTO rule_versions .* 'update', \$3::uuid.*'account_selector', account_selector`).
+			WithArgs(testUUID, selector, testUserID).
+			WillReturnRows(pgxmock.NewRows(scanRuleHeaders).AddRow(userRuleRow(&selector, "Rule", 1)...))
+
+		rule, err := store.UpdateGmailRuleAccountSelector(context.Background(), testUUID, selector, testUserID)
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{testAccountID}, rule.AccountSelector.AccountIDs)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+
+	t.Run("account rule", func(t *testing.T) {
+		mockDB, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mockDB.Close()
+
+		store := NewGmailStore(mockDB, dummyLog)
+		mockDB.ExpectQuery(`UPDATE gmail_automation_rules SET account_selector`).
+			WithArgs(testUUID, selector, testUserID).
+			WillReturnError(pgx.ErrNoRows)
+
+		_, err = store.UpdateGmailRuleAccountSelector(context.Background(), testUUID, selector, testUserID)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"time"
 
 	"agenda-automator-api/internal/domain"
 
@@ -22,18 +23,28 @@ type DBPool interface {
 // LogStorer defines the interface for log storage operations.
 type LogStorer interface {
 	CreateAutomationLog(ctx context.Context, arg CreateLogParams) error
-	HasLogForTrigger(ctx context.Context, ruleID uuid.UUID, triggerEventID string) (bool, error)
+	HasLogForTrigger(
+		ctx context.Context,
+		ruleID, accountID uuid.UUID,
+		triggerEventID string,
+	) (bool, error)
+	HasLogForMessage(ctx context.Context, ruleID, accountID uuid.UUID, gmailMessageID string) (bool, error)
 	GetLogsForAccount(ctx context.Context, accountID uuid.UUID, limit int) ([]domain.AutomationLog, error)
+	GetRevertibleLogs(ctx context.Context, ruleID uuid.UUID, from, to time.Time) ([]domain.AutomationLog, error)
+	MarkLogsReverted(ctx context.Context, logIDs []int64) error
+	GetLogsForRun(ctx context.Context, runID uuid.UUID, limit int) ([]domain.AutomationLog, error)
 }
 
 // CreateLogParams contains parameters for creating automation logs.
 type CreateLogParams struct {
 	ConnectedAccountID uuid.UUID
 	RuleID             *uuid.UUID // Optional, can be nil
+	RuleVersion        *int       // De versie van de rule die uitgevoerd werd, zie rule_versions
 	Status             domain.AutomationLogStatus
 	TriggerDetails     json.RawMessage // []byte
 	ActionDetails      json.RawMessage // []byte
 	ErrorMessage       string
+	RunID              *uuid.UUID // De handmatige run die de log schreef, nil voor de ticker
 }
 
 // LogStore implements the LogStorer interface.
@@ -51,16 +62,18 @@ func NewLogStore(pool DBPool) LogStorer {
 func (s *LogStore) CreateAutomationLog(ctx context.Context, arg CreateLogParams) error {
 	query := `
     INSERT INTO automation_logs (
-        connected_account_id, rule_id, status, trigger_details, action_details, error_message
-    ) VALUES ($1, $2, $3, $4, $5, $6);
+        connected_account_id, rule_id, rule_version, status, trigger_details, action_details, error_message, run_id
+    ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
     `
 	_, err := s.pool.Exec(ctx, query,
 		arg.ConnectedAccountID,
 		arg.RuleID, // This can be NULL if arg.RuleID is nil
+		arg.RuleVersion,
 		arg.Status,
 		arg.TriggerDetails,
 		arg.ActionDetails,
 		arg.ErrorMessage,
+		arg.RunID,
 	)
 	if err != nil {
 		return err
@@ -68,18 +81,24 @@ func (s *LogStore) CreateAutomationLog(ctx context.Context, arg CreateLogParams)
 	return nil
 }
 
-// HasLogForTrigger checks if a log exists for a trigger event.
-func (s *LogStore) HasLogForTrigger(ctx context.Context, ruleID uuid.UUID, triggerEventID string) (bool, error) {
+// HasLogForTrigger checks if a log exists for a trigger event. The account is part of the check:
+// a user-level rule runs on several accounts, and the same event can appear in each of them.
+func (s *LogStore) HasLogForTrigger(
+	ctx context.Context,
+	ruleID, accountID uuid.UUID,
+	triggerEventID string,
+) (bool, error) {
 	query := `
     SELECT 1
     FROM automation_logs
     WHERE rule_id = $1
+      AND connected_account_id = $2
       AND status = 'success'
-      AND trigger_details->>'google_event_id' = $2
+      AND trigger_details->>'google_event_id' = $3
     LIMIT 1;
     `
 	var exists int
-	err := s.pool.QueryRow(ctx, query, ruleID, triggerEventID).Scan(&exists)
+	err := s.pool.QueryRow(ctx, query, ruleID, accountID, triggerEventID).Scan(&exists)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -91,6 +110,37 @@ func (s *LogStore) HasLogForTrigger(ctx context.Context, ruleID uuid.UUID, trigg
 	return true, nil // Gevonden
 }
 
+// HasLogForMessage checks if a Gmail rule already ran successfully on a message of the account.
+// A full sync and a manual run look at the same messages again.
+func (s *LogStore) HasLogForMessage(
+	ctx context.Context,
+	ruleID, accountID uuid.UUID,
+	gmailMessageID string,
+) (bool, error) {
+	query := `
+    SELECT 1
+    FROM automation_logs
+    WHERE rule_id = $1
+      AND connected_account_id = $2
+      AND status = 'success'
+      AND trigger_details->>'gmail_message_id' = $3
+    LIMIT 1;
+    `
+	var exists int
+	err := s.pool.QueryRow(ctx, query, ruleID, accountID, gmailMessageID).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// logColumns zijn de kolommen die scanLogs verwacht, in die volgorde
+const logColumns = `id, connected_account_id, rule_id, rule_version, timestamp, status,
+	          trigger_details, action_details, error_message, reverted_at`
+
 // GetLogsForAccount haalt de meest recente logs op voor een account.
 func (s *LogStore) GetLogsForAccount(
 	ctx context.Context,
@@ -98,8 +148,7 @@ func (s *LogStore) GetLogsForAccount(
 	limit int,
 ) ([]domain.AutomationLog, error) {
 	query := `
-	   SELECT id, connected_account_id, rule_id, timestamp, status,
-	          trigger_details, action_details, error_message
+	   SELECT ` + logColumns + `
 	   FROM automation_logs
 	   WHERE connected_account_id = $1
 	   ORDER BY timestamp DESC
@@ -110,6 +159,72 @@ func (s *LogStore) GetLogsForAccount(
 	if err != nil {
 		return nil, err
 	}
+	return scanLogs(rows)
+}
+
+// GetRevertibleLogs haalt de geslaagde, nog niet teruggedraaide logs van een rule op met een
+// timestamp in [from, to), nieuwste eerst: in die volgorde moeten ze teruggedraaid worden.
+func (s *LogStore) GetRevertibleLogs(
+	ctx context.Context,
+	ruleID uuid.UUID,
+	from, to time.Time,
+) ([]domain.AutomationLog, error) {
+	query := `
+	   SELECT ` + logColumns + `
+	   FROM automation_logs
+	   WHERE rule_id = $1
+	     AND status = 'success'
+	     AND reverted_at IS NULL
+	     AND timestamp >= $2
+	     AND timestamp < $3
+	   ORDER BY timestamp DESC, id DESC;
+	   `
+
+	rows, err := s.pool.Query(ctx, query, ruleID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return scanLogs(rows)
+}
+
+// MarkLogsReverted zet reverted_at op logs waarvan de actie teruggedraaid is.
+func (s *LogStore) MarkLogsReverted(ctx context.Context, logIDs []int64) error {
+	if len(logIDs) == 0 {
+		return nil
+	}
+	query := `
+	   UPDATE automation_logs
+	   SET reverted_at = now()
+	   WHERE id = ANY($1) AND reverted_at IS NULL;
+	   `
+	_, err := s.pool.Exec(ctx, query, logIDs)
+	return err
+}
+
+// GetLogsForRun haalt de logs op die een handmatige run schreef, oudste eerst.
+func (s *LogStore) GetLogsForRun(
+	ctx context.Context,
+	runID uuid.UUID,
+	limit int,
+) ([]domain.AutomationLog, error) {
+	query := `
+	   SELECT l.id, l.connected_account_id, l.rule_id, l.rule_version, l.timestamp, l.status,
+	          l.trigger_details, l.action_details, l.error_message, l.reverted_at
+	   FROM automation_logs l
+	   JOIN processing_runs r ON r.id = l.run_id
+	   WHERE r.id = $1
+	   ORDER BY l.timestamp, l.id
+	   LIMIT $2;
+	   `
+
+	rows, err := s.pool.Query(ctx, query, runID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanLogs(rows)
+}
+
+func scanLogs(rows pgx.Rows) ([]domain.AutomationLog, error) {
 	defer rows.Close()
 
 	var logs []domain.AutomationLog
@@ -119,11 +234,13 @@ func (s *LogStore) GetLogsForAccount(
 			&log.ID,
 			&log.ConnectedAccountID,
 			&log.RuleID,
+			&log.RuleVersion,
 			&log.Timestamp,
 			&log.Status,
 			&log.TriggerDetails,
 			&log.ActionDetails,
 			&log.ErrorMessage,
+			&log.RevertedAt,
 		)
 		if err != nil {
 			return nil, err
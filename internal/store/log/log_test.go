@@ -33,9 +33,11 @@ func TestLogStore_CreateAutomationLog(t *testing.T) {
 
 	ctx := context.Background()
 	ruleID := uuid.New()
+	ruleVersion := 3
 	params := CreateLogParams{
 		ConnectedAccountID: uuid.New(),
 		RuleID:             &ruleID,
+		RuleVersion:        &ruleVersion,
 		Status:             domain.LogSuccess,
 		TriggerDetails:     json.RawMessage(`{}`),
 		ActionDetails:      json.RawMessage(`{}`),
@@ -47,10 +49,12 @@ func TestLogStore_CreateAutomationLog(t *testing.T) {
 		WithArgs(
 			params.ConnectedAccountID,
 			params.RuleID,
+			params.RuleVersion,
 			params.Status,
 			params.TriggerDetails,
 			params.ActionDetails,
 			params.ErrorMessage,
+			params.RunID,
 		).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
@@ -68,9 +72,11 @@ func TestLogStore_CreateAutomationLog_Error(t *testing.T) {
 
 	ctx := context.Background()
 	ruleID := uuid.New()
+	ruleVersion := 3
 	params := CreateLogParams{
 		ConnectedAccountID: uuid.New(),
 		RuleID:             &ruleID,
+		RuleVersion:        &ruleVersion,
 		Status:             domain.LogSuccess,
 		TriggerDetails:     json.RawMessage(`{}`),
 		ActionDetails:      json.RawMessage(`{}`),
@@ -83,10 +89,12 @@ func TestLogStore_CreateAutomationLog_Error(t *testing.T) {
 		WithArgs(
 			params.ConnectedAccountID,
 			params.RuleID,
+			params.RuleVersion,
 			params.Status,
 			params.TriggerDetails,
 			params.ActionDetails,
 			params.ErrorMessage,
+			params.RunID,
 		).
 		WillReturnError(dbError)
 
@@ -106,17 +114,18 @@ func TestLogStore_HasLogForTrigger(t *testing.T) {
 
 		ctx := context.Background()
 		ruleID := uuid.New()
+		accountID := uuid.New()
 		eventID := "google-event-id"
 
 		// Mock de data die de DB teruggeeft (1 rij met de waarde '1')
 		rows := pgxmock.NewRows([]string{"1"}).AddRow(1)
 
 		mockPool.ExpectQuery("SELECT 1").
-			WithArgs(ruleID, eventID).
+			WithArgs(ruleID, accountID, eventID).
 			WillReturnRows(rows)
 
 		// Act
-		exists, err := store.HasLogForTrigger(ctx, ruleID, eventID)
+		exists, err := store.HasLogForTrigger(ctx, ruleID, accountID, eventID)
 
 		// Assert
 		assert.NoError(t, err)
@@ -130,15 +139,16 @@ func TestLogStore_HasLogForTrigger(t *testing.T) {
 
 		ctx := context.Background()
 		ruleID := uuid.New()
+		accountID := uuid.New()
 		eventID := "google-event-id"
 
 		// Simuleer een "no rows" error
 		mockPool.ExpectQuery("SELECT 1").
-			WithArgs(ruleID, eventID).
+			WithArgs(ruleID, accountID, eventID).
 			WillReturnError(pgx.ErrNoRows)
 
 		// Act
-		exists, err := store.HasLogForTrigger(ctx, ruleID, eventID)
+		exists, err := store.HasLogForTrigger(ctx, ruleID, accountID, eventID)
 
 		// Assert
 		assert.NoError(t, err)  // De functie zelf hoort geen error te geven
@@ -152,16 +162,17 @@ func TestLogStore_HasLogForTrigger(t *testing.T) {
 
 		ctx := context.Background()
 		ruleID := uuid.New()
+		accountID := uuid.New()
 		eventID := "google-event-id"
 		dbError := errors.New("connection failed")
 
 		// Simuleer een willekeurige database error
 		mockPool.ExpectQuery("SELECT 1").
-			WithArgs(ruleID, eventID).
+			WithArgs(ruleID, accountID, eventID).
 			WillReturnError(dbError)
 
 		// Act
-		exists, err := store.HasLogForTrigger(ctx, ruleID, eventID)
+		exists, err := store.HasLogForTrigger(ctx, ruleID, accountID, eventID)
 
 		// Assert
 		assert.Error(t, err) // Nu verwachten we wel een error
@@ -171,6 +182,31 @@ func TestLogStore_HasLogForTrigger(t *testing.T) {
 	})
 }
 
+func TestLogStore_HasLogForMessage(t *testing.T) {
+	store, mockPool := setupLogStore(t)
+	defer mockPool.Close()
+
+	ctx := context.Background()
+	ruleID := uuid.New()
+	accountID := uuid.New()
+
+	mockPool.ExpectQuery(`SELECT 1 FROM automation_logs .* trigger_details->>'gmail_message_id' = \$3`).
+		WithArgs(ruleID, accountID, "msg-1").
+		WillReturnRows(pgxmock.NewRows([]string{"1"}).AddRow(1))
+	mockPool.ExpectQuery(`SELECT 1 FROM automation_logs`).
+		WithArgs(ruleID, accountID, "msg-2").
+		WillReturnError(pgx.ErrNoRows)
+
+	exists, err := store.HasLogForMessage(ctx, ruleID, accountID, "msg-1")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = store.HasLogForMessage(ctx, ruleID, accountID, "msg-2")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
 func TestLogStore_GetLogsForAccount(t *testing.T) {
 	store, mockPool := setupLogStore(t)
 	defer mockPool.Close()
@@ -178,18 +214,19 @@ func TestLogStore_GetLogsForAccount(t *testing.T) {
 	ctx := context.Background()
 	accountID := uuid.New()
 	ruleID := uuid.New()
+	ruleVersion := 2
 	limit := 10
 
 	// Definieer de kolommen
 	logColumns := []string{
-		"id", "connected_account_id", "rule_id", "timestamp", "status",
-		"trigger_details", "action_details", "error_message",
+		"id", "connected_account_id", "rule_id", "rule_version", "timestamp", "status",
+		"trigger_details", "action_details", "error_message", "reverted_at",
 	}
 
 	// Maak een mock rij
 	rows := pgxmock.NewRows(logColumns).AddRow(
-		int64(1), accountID, &ruleID, time.Now(), domain.LogSuccess,
-		json.RawMessage(`{}`), json.RawMessage(`{}`), "",
+		int64(1), accountID, &ruleID, &ruleVersion, time.Now(), domain.LogSuccess,
+		json.RawMessage(`{}`), json.RawMessage(`{}`), "", nil,
 	)
 
 	mockPool.ExpectQuery("SELECT id, connected_account_id").
@@ -204,6 +241,7 @@ func TestLogStore_GetLogsForAccount(t *testing.T) {
 	assert.Len(t, logs, 1)
 	assert.Equal(t, int64(1), logs[0].ID)
 	assert.Equal(t, domain.LogSuccess, logs[0].Status)
+	assert.Equal(t, &ruleVersion, logs[0].RuleVersion)
 	assert.NoError(t, mockPool.ExpectationsWereMet())
 }
 
@@ -241,12 +279,12 @@ func TestLogStore_GetLogsForAccount_ScanError(t *testing.T) {
 
 	// Definieer de kolommen
 	logColumns := []string{
-		"id", "connected_account_id", "rule_id", "timestamp", "status",
-		"trigger_details", "action_details", "error_message",
+		"id", "connected_account_id", "rule_id", "rule_version", "timestamp", "status",
+		"trigger_details", "action_details", "error_message", "reverted_at",
 	}
 
 	// Maak een mock rij met een probleem dat een scan error zou veroorzaken
-	rows := pgxmock.NewRows(logColumns).AddRow("invalid", uuid.New(), nil, time.Now(), domain.LogSuccess, json.RawMessage(`{}`), json.RawMessage(`{}`), "")
+	rows := pgxmock.NewRows(logColumns).AddRow("invalid", uuid.New(), nil, nil, time.Now(), domain.LogSuccess, json.RawMessage(`{}`), json.RawMessage(`{}`), "", nil)
 
 	mockPool.ExpectQuery("SELECT id, connected_account_id").
 		WithArgs(accountID, limit).
@@ -260,3 +298,79 @@ func TestLogStore_GetLogsForAccount_ScanError(t *testing.T) {
 	assert.Nil(t, logs)
 	assert.NoError(t, mockPool.ExpectationsWereMet())
 }
+
+func TestLogStore_GetRevertibleLogs(t *testing.T) {
+	store, mockPool := setupLogStore(t)
+	defer mockPool.Close()
+
+	ctx := context.Background()
+	ruleID := uuid.New()
+	to := time.Now()
+	from := to.Add(-time.Hour)
+
+	logColumns := []string{
+		"id", "connected_account_id", "rule_id", "rule_version", "timestamp", "status",
+		"trigger_details", "action_details", "error_message", "reverted_at",
+	}
+	rows := pgxmock.NewRows(logColumns).
+		AddRow(int64(2), uuid.New(), &ruleID, nil, to.Add(-time.Minute), domain.LogSuccess,
+			json.RawMessage(`{}`), json.RawMessage(`{"created_event_id":"evt-2"}`), "", nil).
+		AddRow(int64(1), uuid.New(), &ruleID, nil, from, domain.LogSuccess,
+			json.RawMessage(`{}`), json.RawMessage(`{"created_event_id":"evt-1"}`), "", nil)
+
+	mockPool.ExpectQuery(`(?s)FROM automation_logs.*reverted_at IS NULL.*ORDER BY timestamp DESC, id DESC`).
+		WithArgs(ruleID, from, to).
+		WillReturnRows(rows)
+
+	logs, err := store.GetRevertibleLogs(ctx, ruleID, from, to)
+
+	assert.NoError(t, err)
+	require.Len(t, logs, 2)
+	assert.Equal(t, int64(2), logs[0].ID)
+	assert.Nil(t, logs[0].RevertedAt)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestLogStore_MarkLogsReverted(t *testing.T) {
+	store, mockPool := setupLogStore(t)
+	defer mockPool.Close()
+
+	ctx := context.Background()
+	mockPool.ExpectExec("UPDATE automation_logs").
+		WithArgs([]int64{1, 2}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+
+	assert.NoError(t, store.MarkLogsReverted(ctx, []int64{1, 2}))
+	// Zonder IDs is er niets te doen
+	assert.NoError(t, store.MarkLogsReverted(ctx, nil))
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestLogStore_GetLogsForRun(t *testing.T) {
+	store, mockPool := setupLogStore(t)
+	defer mockPool.Close()
+
+	ctx := context.Background()
+	runID := uuid.New()
+	accountID := uuid.New()
+
+	logColumns := []string{
+		"id", "connected_account_id", "rule_id", "rule_version", "timestamp", "status",
+		"trigger_details", "action_details", "error_message", "reverted_at",
+	}
+	rows := pgxmock.NewRows(logColumns).
+		AddRow(int64(7), accountID, nil, nil, time.Now(), domain.LogSkipped,
+			json.RawMessage(`{}`), json.RawMessage(`{}`), "", nil)
+
+	// Alleen de logs die de worker met het ID van de run schreef
+	mockPool.ExpectQuery(`(?s)FROM automation_logs l\s+JOIN processing_runs r ON r.id = l.run_id\s+WHERE r.id = \$1.*LIMIT \$2`).
+		WithArgs(runID, 100).
+		WillReturnRows(rows)
+
+	logs, err := store.GetLogsForRun(ctx, runID, 100)
+
+	assert.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, domain.LogSkipped, logs[0].Status)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
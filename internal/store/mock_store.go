@@ -112,6 +112,35 @@ func (m *MockStore) GetRulesForAccount(ctx context.Context, accountID uuid.UUID)
 	return args.Get(0).([]domain.AutomationRule), args.Error(1)
 }
 
+// CreateUserRule mocks the CreateUserRule method.
+func (m *MockStore) CreateUserRule(ctx context.Context, arg CreateUserRuleParams) (domain.AutomationRule, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.AutomationRule), args.Error(1)
+}
+
+// GetUserRules mocks the GetUserRules method.
+func (m *MockStore) GetUserRules(ctx context.Context, userID uuid.UUID) ([]domain.AutomationRule, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]domain.AutomationRule), args.Error(1)
+}
+
+// GetRulesForHook mocks the GetRulesForHook method.
+func (m *MockStore) GetRulesForHook(ctx context.Context, hookID uuid.UUID) ([]domain.AutomationRule, error) {
+	args := m.Called(ctx, hookID)
+	return args.Get(0).([]domain.AutomationRule), args.Error(1)
+}
+
+// UpdateRuleAccountSelector mocks the UpdateRuleAccountSelector method.
+func (m *MockStore) UpdateRuleAccountSelector(
+	ctx context.Context,
+	ruleID uuid.UUID,
+	selector domain.AccountSelector,
+	changedBy uuid.UUID,
+) (domain.AutomationRule, error) {
+	args := m.Called(ctx, ruleID, selector, changedBy)
+	return args.Get(0).(domain.AutomationRule), args.Error(1)
+}
+
 // UpdateRule mocks the UpdateRule method
 func (m *MockStore) UpdateRule(ctx context.Context, arg UpdateRuleParams) (domain.AutomationRule, error) {
 	args := m.Called(ctx, arg)
@@ -119,11 +148,117 @@ func (m *MockStore) UpdateRule(ctx context.Context, arg UpdateRuleParams) (domai
 }
 
 // ToggleRuleStatus mocks the ToggleRuleStatus method
-func (m *MockStore) ToggleRuleStatus(ctx context.Context, ruleID uuid.UUID) (domain.AutomationRule, error) {
+func (m *MockStore) ToggleRuleStatus(
+	ctx context.Context,
+	ruleID uuid.UUID,
+	changedBy uuid.UUID,
+) (domain.AutomationRule, error) {
+	args := m.Called(ctx, ruleID, changedBy)
+	return args.Get(0).(domain.AutomationRule), args.Error(1)
+}
+
+// RecordRuleExecution mocks the RecordRuleExecution method
+func (m *MockStore) RecordRuleExecution(ctx context.Context, ruleID uuid.UUID) (int, error) {
+	args := m.Called(ctx, ruleID)
+	return args.Int(0), args.Error(1)
+}
+
+// ExpireRule mocks the ExpireRule method
+func (m *MockStore) ExpireRule(ctx context.Context, ruleID uuid.UUID) (domain.AutomationRule, error) {
+	args := m.Called(ctx, ruleID)
+	return args.Get(0).(domain.AutomationRule), args.Error(1)
+}
+
+// PauseRule mocks the PauseRule method
+func (m *MockStore) PauseRule(ctx context.Context, ruleID uuid.UUID) (domain.AutomationRule, error) {
 	args := m.Called(ctx, ruleID)
 	return args.Get(0).(domain.AutomationRule), args.Error(1)
 }
 
+// GetRuleVersions mocks the GetRuleVersions method
+func (m *MockStore) GetRuleVersions(
+	ctx context.Context,
+	ruleType domain.RuleType,
+	ruleID uuid.UUID,
+) ([]domain.RuleVersion, error) {
+	args := m.Called(ctx, ruleType, ruleID)
+	return args.Get(0).([]domain.RuleVersion), args.Error(1)
+}
+
+// GetRuleVersion mocks the GetRuleVersion method
+func (m *MockStore) GetRuleVersion(
+	ctx context.Context,
+	ruleType domain.RuleType,
+	ruleID uuid.UUID,
+	version int,
+) (domain.RuleVersion, error) {
+	args := m.Called(ctx, ruleType, ruleID, version)
+	return args.Get(0).(domain.RuleVersion), args.Error(1)
+}
+
+// ImportRules mocks the ImportRules method
+func (m *MockStore) ImportRules(ctx context.Context, arg ImportRulesParams) (ImportRulesResult, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(ImportRulesResult), args.Error(1)
+}
+
+// GetRuleExecutionCounts mocks the GetRuleExecutionCounts method
+func (m *MockStore) GetRuleExecutionCounts(ctx context.Context, ruleID, accountID uuid.UUID) (int, int, error) {
+	args := m.Called(ctx, ruleID, accountID)
+	return args.Int(0), args.Int(1), args.Error(2)
+}
+
+// CountRuleExecution mocks the CountRuleExecution method
+func (m *MockStore) CountRuleExecution(
+	ctx context.Context,
+	ruleType domain.RuleType,
+	ruleID, accountID uuid.UUID,
+) (int, int, error) {
+	args := m.Called(ctx, ruleType, ruleID, accountID)
+	return args.Int(0), args.Int(1), args.Error(2)
+}
+
+// GetBusiestRule mocks the GetBusiestRule method
+func (m *MockStore) GetBusiestRule(ctx context.Context, accountID uuid.UUID) (domain.RuleType, uuid.UUID, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).(domain.RuleType), args.Get(1).(uuid.UUID), args.Error(2)
+}
+
+// CreateNotification mocks the CreateNotification method
+func (m *MockStore) CreateNotification(ctx context.Context, arg CreateNotificationParams) (domain.Notification, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.Notification), args.Error(1)
+}
+
+// GetNotificationsForUser mocks the GetNotificationsForUser method
+func (m *MockStore) GetNotificationsForUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	unreadOnly bool,
+	limit int,
+) ([]domain.Notification, error) {
+	args := m.Called(ctx, userID, unreadOnly, limit)
+	return args.Get(0).([]domain.Notification), args.Error(1)
+}
+
+// MarkNotificationRead mocks the MarkNotificationRead method
+func (m *MockStore) MarkNotificationRead(ctx context.Context, notificationID, userID uuid.UUID) (domain.Notification, error) {
+	args := m.Called(ctx, notificationID, userID)
+	return args.Get(0).(domain.Notification), args.Error(1)
+}
+
+// EnsureWebhookSecret mocks the EnsureWebhookSecret method
+func (m *MockStore) EnsureWebhookSecret(ctx context.Context, userID uuid.UUID, candidate string) (domain.WebhookSecret, error) {
+	args := m.Called(ctx, userID, candidate)
+	return args.Get(0).(domain.WebhookSecret), args.Error(1)
+}
+
+// RotateWebhookSecret mocks the RotateWebhookSecret method
+func (m *MockStore) RotateWebhookSecret(ctx context.Context, userID uuid.UUID, secret string) (domain.WebhookSecret, error) {
+	args := m.Called(ctx, userID, secret)
+	return args.Get(0).(domain.WebhookSecret), args.Error(1)
+}
+
 // DeleteRule mocks the DeleteRule method
 func (m *MockStore) DeleteRule(ctx context.Context, ruleID uuid.UUID) error {
 	args := m.Called(ctx, ruleID)
@@ -143,8 +278,22 @@ func (m *MockStore) CreateAutomationLog(ctx context.Context, arg CreateLogParams
 }
 
 // HasLogForTrigger mocks the HasLogForTrigger method
-func (m *MockStore) HasLogForTrigger(ctx context.Context, ruleID uuid.UUID, triggerEventID string) (bool, error) {
-	args := m.Called(ctx, ruleID, triggerEventID)
+func (m *MockStore) HasLogForTrigger(
+	ctx context.Context,
+	ruleID, accountID uuid.UUID,
+	triggerEventID string,
+) (bool, error) {
+	args := m.Called(ctx, ruleID, accountID, triggerEventID)
+	return args.Bool(0), args.Error(1)
+}
+
+// HasLogForMessage mocks the HasLogForMessage method
+func (m *MockStore) HasLogForMessage(
+	ctx context.Context,
+	ruleID, accountID uuid.UUID,
+	gmailMessageID string,
+) (bool, error) {
+	args := m.Called(ctx, ruleID, accountID, gmailMessageID)
 	return args.Bool(0), args.Error(1)
 }
 
@@ -158,6 +307,28 @@ func (m *MockStore) GetLogsForAccount(
 	return args.Get(0).([]domain.AutomationLog), args.Error(1)
 }
 
+// GetRevertibleLogs mocks the GetRevertibleLogs method.
+func (m *MockStore) GetRevertibleLogs(
+	ctx context.Context,
+	ruleID uuid.UUID,
+	from, to time.Time,
+) ([]domain.AutomationLog, error) {
+	args := m.Called(ctx, ruleID, from, to)
+	return args.Get(0).([]domain.AutomationLog), args.Error(1)
+}
+
+// MarkLogsReverted mocks the MarkLogsReverted method.
+func (m *MockStore) MarkLogsReverted(ctx context.Context, logIDs []int64) error {
+	args := m.Called(ctx, logIDs)
+	return args.Error(0)
+}
+
+// GetLogsForRun mocks the GetLogsForRun method.
+func (m *MockStore) GetLogsForRun(ctx context.Context, runID uuid.UUID, limit int) ([]domain.AutomationLog, error) {
+	args := m.Called(ctx, runID, limit)
+	return args.Get(0).([]domain.AutomationLog), args.Error(1)
+}
+
 // GetValidTokenForAccount mocks the GetValidTokenForAccount method
 func (m *MockStore) GetValidTokenForAccount(ctx context.Context, accountID uuid.UUID) (*oauth2.Token, error) {
 	args := m.Called(ctx, accountID)
@@ -182,6 +353,32 @@ func (m *MockStore) CreateGmailAutomationRule(
 	return args.Get(0).(domain.GmailAutomationRule), args.Error(1)
 }
 
+// CreateUserGmailRule mocks the CreateUserGmailRule method.
+func (m *MockStore) CreateUserGmailRule(
+	ctx context.Context,
+	arg CreateUserGmailRuleParams,
+) (domain.GmailAutomationRule, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.GmailAutomationRule), args.Error(1)
+}
+
+// GetUserGmailRules mocks the GetUserGmailRules method.
+func (m *MockStore) GetUserGmailRules(ctx context.Context, userID uuid.UUID) ([]domain.GmailAutomationRule, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]domain.GmailAutomationRule), args.Error(1)
+}
+
+// UpdateGmailRuleAccountSelector mocks the UpdateGmailRuleAccountSelector method.
+func (m *MockStore) UpdateGmailRuleAccountSelector(
+	ctx context.Context,
+	ruleID uuid.UUID,
+	selector domain.AccountSelector,
+	changedBy uuid.UUID,
+) (domain.GmailAutomationRule, error) {
+	args := m.Called(ctx, ruleID, selector, changedBy)
+	return args.Get(0).(domain.GmailAutomationRule), args.Error(1)
+}
+
 // GetGmailRulesForAccount mocks the GetGmailRulesForAccount method.
 func (m *MockStore) GetGmailRulesForAccount(
 	ctx context.Context,
@@ -207,11 +404,51 @@ func (m *MockStore) DeleteGmailRule(ctx context.Context, ruleID uuid.UUID) error
 }
 
 // ToggleGmailRuleStatus mocks the ToggleGmailRuleStatus method
-func (m *MockStore) ToggleGmailRuleStatus(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error) {
+func (m *MockStore) ToggleGmailRuleStatus(
+	ctx context.Context,
+	ruleID uuid.UUID,
+	changedBy uuid.UUID,
+) (domain.GmailAutomationRule, error) {
+	args := m.Called(ctx, ruleID, changedBy)
+	return args.Get(0).(domain.GmailAutomationRule), args.Error(1)
+}
+
+// RecordGmailRuleExecution mocks the RecordGmailRuleExecution method
+func (m *MockStore) RecordGmailRuleExecution(ctx context.Context, ruleID uuid.UUID) (int, error) {
+	args := m.Called(ctx, ruleID)
+	return args.Int(0), args.Error(1)
+}
+
+// ExpireGmailRule mocks the ExpireGmailRule method
+func (m *MockStore) ExpireGmailRule(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error) {
 	args := m.Called(ctx, ruleID)
 	return args.Get(0).(domain.GmailAutomationRule), args.Error(1)
 }
 
+// PauseGmailRule mocks the PauseGmailRule method
+func (m *MockStore) PauseGmailRule(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error) {
+	args := m.Called(ctx, ruleID)
+	return args.Get(0).(domain.GmailAutomationRule), args.Error(1)
+}
+
+// GetGmailRuleByID mocks the GetGmailRuleByID method
+func (m *MockStore) GetGmailRuleByID(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error) {
+	args := m.Called(ctx, ruleID)
+	return args.Get(0).(domain.GmailAutomationRule), args.Error(1)
+}
+
+// VerifyGmailRuleOwnership mocks the VerifyGmailRuleOwnership method
+func (m *MockStore) VerifyGmailRuleOwnership(ctx context.Context, ruleID uuid.UUID, userID uuid.UUID) error {
+	args := m.Called(ctx, ruleID, userID)
+	return args.Error(0)
+}
+
+// ReorderGmailRules mocks the ReorderGmailRules method
+func (m *MockStore) ReorderGmailRules(ctx context.Context, accountID uuid.UUID, ruleIDs []uuid.UUID) error {
+	args := m.Called(ctx, accountID, ruleIDs)
+	return args.Error(0)
+}
+
 // StoreGmailMessage mocks the StoreGmailMessage method
 func (m *MockStore) StoreGmailMessage(ctx context.Context, arg StoreGmailMessageParams) error {
 	args := m.Called(ctx, arg)
@@ -235,6 +472,12 @@ func (m *MockStore) UpdateGmailMessageStatus(
 	return args.Error(0)
 }
 
+// UpdateGmailMessagesCache mocks the UpdateGmailMessagesCache method
+func (m *MockStore) UpdateGmailMessagesCache(ctx context.Context, arg UpdateGmailMessagesCacheParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 // GetGmailMessagesForAccount mocks the GetGmailMessagesForAccount method.
 func (m *MockStore) GetGmailMessagesForAccount(
 	ctx context.Context,
@@ -264,3 +507,339 @@ func (m *MockStore) GetGmailSyncState(
 	args := m.Called(ctx, accountID)
 	return args.Get(0).(*string), args.Get(1).(*time.Time), args.Error(2)
 }
+
+// CreateGmailSnooze mocks the CreateGmailSnooze method
+func (m *MockStore) CreateGmailSnooze(ctx context.Context, arg CreateGmailSnoozeParams) (domain.GmailSnooze, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.GmailSnooze), args.Error(1)
+}
+
+// GetGmailSnoozeByID mocks the GetGmailSnoozeByID method
+func (m *MockStore) GetGmailSnoozeByID(ctx context.Context, snoozeID uuid.UUID) (domain.GmailSnooze, error) {
+	args := m.Called(ctx, snoozeID)
+	return args.Get(0).(domain.GmailSnooze), args.Error(1)
+}
+
+// GetPendingGmailSnoozesForAccount mocks the GetPendingGmailSnoozesForAccount method.
+func (m *MockStore) GetPendingGmailSnoozesForAccount(
+	ctx context.Context,
+	accountID uuid.UUID,
+) ([]domain.GmailSnooze, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).([]domain.GmailSnooze), args.Error(1)
+}
+
+// GetDueGmailSnoozes mocks the GetDueGmailSnoozes method
+func (m *MockStore) GetDueGmailSnoozes(ctx context.Context, now time.Time, limit int) ([]domain.GmailSnooze, error) {
+	args := m.Called(ctx, now, limit)
+	return args.Get(0).([]domain.GmailSnooze), args.Error(1)
+}
+
+// UpdateGmailSnoozeStatus mocks the UpdateGmailSnoozeStatus method
+func (m *MockStore) UpdateGmailSnoozeStatus(
+	ctx context.Context,
+	snoozeID uuid.UUID,
+	status domain.GmailSnoozeStatus,
+	errorMessage *string,
+) error {
+	args := m.Called(ctx, snoozeID, status, errorMessage)
+	return args.Error(0)
+}
+
+// CreateGmailScheduledSend mocks the CreateGmailScheduledSend method.
+func (m *MockStore) CreateGmailScheduledSend(
+	ctx context.Context,
+	arg CreateGmailScheduledSendParams,
+) (domain.GmailScheduledSend, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.GmailScheduledSend), args.Error(1)
+}
+
+// GetGmailScheduledSendByID mocks the GetGmailScheduledSendByID method.
+func (m *MockStore) GetGmailScheduledSendByID(ctx context.Context, sendID uuid.UUID) (domain.GmailScheduledSend, error) {
+	args := m.Called(ctx, sendID)
+	return args.Get(0).(domain.GmailScheduledSend), args.Error(1)
+}
+
+// GetPendingGmailScheduledSendsForAccount mocks the GetPendingGmailScheduledSendsForAccount method.
+func (m *MockStore) GetPendingGmailScheduledSendsForAccount(
+	ctx context.Context,
+	accountID uuid.UUID,
+) ([]domain.GmailScheduledSend, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).([]domain.GmailScheduledSend), args.Error(1)
+}
+
+// GetDueGmailScheduledSends mocks the GetDueGmailScheduledSends method.
+func (m *MockStore) GetDueGmailScheduledSends(
+	ctx context.Context,
+	now time.Time,
+	limit int,
+) ([]domain.GmailScheduledSend, error) {
+	args := m.Called(ctx, now, limit)
+	return args.Get(0).([]domain.GmailScheduledSend), args.Error(1)
+}
+
+// UpdateGmailScheduledSend mocks the UpdateGmailScheduledSend method.
+func (m *MockStore) UpdateGmailScheduledSend(
+	ctx context.Context,
+	sendID uuid.UUID,
+	message domain.GmailOutgoingMessage,
+	sendAt time.Time,
+) (domain.GmailScheduledSend, error) {
+	args := m.Called(ctx, sendID, message, sendAt)
+	return args.Get(0).(domain.GmailScheduledSend), args.Error(1)
+}
+
+// UpdateGmailScheduledSendStatus mocks the UpdateGmailScheduledSendStatus method.
+func (m *MockStore) UpdateGmailScheduledSendStatus(
+	ctx context.Context,
+	sendID uuid.UUID,
+	status domain.GmailScheduledSendStatus,
+	gmailMessageID, lastError *string,
+) error {
+	args := m.Called(ctx, sendID, status, gmailMessageID, lastError)
+	return args.Error(0)
+}
+
+// RetryGmailScheduledSend mocks the RetryGmailScheduledSend method.
+func (m *MockStore) RetryGmailScheduledSend(
+	ctx context.Context,
+	sendID uuid.UUID,
+	lastError string,
+	nextAttempt time.Time,
+) error {
+	args := m.Called(ctx, sendID, lastError, nextAttempt)
+	return args.Error(0)
+}
+
+// CreateGmailUnsubscribe mocks the CreateGmailUnsubscribe method.
+func (m *MockStore) CreateGmailUnsubscribe(
+	ctx context.Context,
+	arg CreateGmailUnsubscribeParams,
+) (domain.GmailUnsubscribe, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.GmailUnsubscribe), args.Error(1)
+}
+
+// GetGmailNewsletterSenders mocks the GetGmailNewsletterSenders method.
+func (m *MockStore) GetGmailNewsletterSenders(
+	ctx context.Context,
+	accountID uuid.UUID,
+	limit int,
+) ([]domain.GmailNewsletterSender, error) {
+	args := m.Called(ctx, accountID, limit)
+	return args.Get(0).([]domain.GmailNewsletterSender), args.Error(1)
+}
+
+// UpsertGmailForwardingAddress mocks the UpsertGmailForwardingAddress method.
+func (m *MockStore) UpsertGmailForwardingAddress(
+	ctx context.Context,
+	arg UpsertGmailForwardingAddressParams,
+) (domain.GmailForwardingAddress, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.GmailForwardingAddress), args.Error(1)
+}
+
+// GetGmailForwardingAddressByID mocks the GetGmailForwardingAddressByID method.
+func (m *MockStore) GetGmailForwardingAddressByID(
+	ctx context.Context,
+	addressID uuid.UUID,
+) (domain.GmailForwardingAddress, error) {
+	args := m.Called(ctx, addressID)
+	return args.Get(0).(domain.GmailForwardingAddress), args.Error(1)
+}
+
+// GetGmailForwardingAddressesForUser mocks the GetGmailForwardingAddressesForUser method.
+func (m *MockStore) GetGmailForwardingAddressesForUser(
+	ctx context.Context,
+	userID uuid.UUID,
+) ([]domain.GmailForwardingAddress, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]domain.GmailForwardingAddress), args.Error(1)
+}
+
+// UseGmailForwardingVerificationAttempt mocks the UseGmailForwardingVerificationAttempt method.
+func (m *MockStore) UseGmailForwardingVerificationAttempt(
+	ctx context.Context,
+	addressID uuid.UUID,
+	maxAttempts int,
+) error {
+	args := m.Called(ctx, addressID, maxAttempts)
+	return args.Error(0)
+}
+
+// MarkGmailForwardingAddressVerified mocks the MarkGmailForwardingAddressVerified method.
+func (m *MockStore) MarkGmailForwardingAddressVerified(
+	ctx context.Context,
+	addressID uuid.UUID,
+) (domain.GmailForwardingAddress, error) {
+	args := m.Called(ctx, addressID)
+	return args.Get(0).(domain.GmailForwardingAddress), args.Error(1)
+}
+
+// DeleteGmailForwardingAddress mocks the DeleteGmailForwardingAddress method.
+func (m *MockStore) DeleteGmailForwardingAddress(ctx context.Context, addressID uuid.UUID) error {
+	args := m.Called(ctx, addressID)
+	return args.Error(0)
+}
+
+// UpsertGmailDraft mocks the UpsertGmailDraft method.
+func (m *MockStore) UpsertGmailDraft(ctx context.Context, arg UpsertGmailDraftParams) (domain.GmailDraft, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.GmailDraft), args.Error(1)
+}
+
+// GetGmailDraftByID mocks the GetGmailDraftByID method.
+func (m *MockStore) GetGmailDraftByID(ctx context.Context, draftID uuid.UUID) (domain.GmailDraft, error) {
+	args := m.Called(ctx, draftID)
+	return args.Get(0).(domain.GmailDraft), args.Error(1)
+}
+
+// GetGmailDraftsForAccount mocks the GetGmailDraftsForAccount method.
+func (m *MockStore) GetGmailDraftsForAccount(ctx context.Context, accountID uuid.UUID) ([]domain.GmailDraft, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).([]domain.GmailDraft), args.Error(1)
+}
+
+// DeleteGmailDraft mocks the DeleteGmailDraft method.
+func (m *MockStore) DeleteGmailDraft(ctx context.Context, draftID uuid.UUID) error {
+	args := m.Called(ctx, draftID)
+	return args.Error(0)
+}
+
+// PruneGmailDrafts mocks the PruneGmailDrafts method.
+func (m *MockStore) PruneGmailDrafts(ctx context.Context, accountID uuid.UUID, keepGmailDraftIDs []string) error {
+	args := m.Called(ctx, accountID, keepGmailDraftIDs)
+	return args.Error(0)
+}
+
+// UpsertCalendarWatchChannel mocks the UpsertCalendarWatchChannel method.
+func (m *MockStore) UpsertCalendarWatchChannel(
+	ctx context.Context,
+	arg UpsertCalendarWatchChannelParams,
+) (domain.CalendarWatchChannel, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.CalendarWatchChannel), args.Error(1)
+}
+
+// GetCalendarWatchChannelByChannelID mocks the GetCalendarWatchChannelByChannelID method.
+func (m *MockStore) GetCalendarWatchChannelByChannelID(
+	ctx context.Context,
+	channelID string,
+) (domain.CalendarWatchChannel, error) {
+	args := m.Called(ctx, channelID)
+	return args.Get(0).(domain.CalendarWatchChannel), args.Error(1)
+}
+
+// GetCalendarWatchChannelsForAccount mocks the GetCalendarWatchChannelsForAccount method.
+func (m *MockStore) GetCalendarWatchChannelsForAccount(
+	ctx context.Context,
+	accountID uuid.UUID,
+) ([]domain.CalendarWatchChannel, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).([]domain.CalendarWatchChannel), args.Error(1)
+}
+
+// GetCalendarWatchChannelsForInactiveAccounts mocks the GetCalendarWatchChannelsForInactiveAccounts method.
+func (m *MockStore) GetCalendarWatchChannelsForInactiveAccounts(
+	ctx context.Context,
+) ([]domain.CalendarWatchChannel, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.CalendarWatchChannel), args.Error(1)
+}
+
+// DeleteCalendarWatchChannel mocks the DeleteCalendarWatchChannel method
+func (m *MockStore) DeleteCalendarWatchChannel(ctx context.Context, channelID string) error {
+	args := m.Called(ctx, channelID)
+	return args.Error(0)
+}
+
+// CreateInboundHook mocks the CreateInboundHook method
+func (m *MockStore) CreateInboundHook(ctx context.Context, arg CreateInboundHookParams) (domain.InboundHook, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.InboundHook), args.Error(1)
+}
+
+// GetInboundHooksForUser mocks the GetInboundHooksForUser method
+func (m *MockStore) GetInboundHooksForUser(ctx context.Context, userID uuid.UUID) ([]domain.InboundHook, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]domain.InboundHook), args.Error(1)
+}
+
+// GetInboundHook mocks the GetInboundHook method
+func (m *MockStore) GetInboundHook(ctx context.Context, hookID, userID uuid.UUID) (domain.InboundHook, error) {
+	args := m.Called(ctx, hookID, userID)
+	return args.Get(0).(domain.InboundHook), args.Error(1)
+}
+
+// GetInboundHookByTokenHash mocks the GetInboundHookByTokenHash method
+func (m *MockStore) GetInboundHookByTokenHash(ctx context.Context, tokenHash []byte) (domain.InboundHook, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(domain.InboundHook), args.Error(1)
+}
+
+// RevokeInboundHook mocks the RevokeInboundHook method
+func (m *MockStore) RevokeInboundHook(ctx context.Context, hookID, userID uuid.UUID) (domain.InboundHook, error) {
+	args := m.Called(ctx, hookID, userID)
+	return args.Get(0).(domain.InboundHook), args.Error(1)
+}
+
+// CreateHookDelivery mocks the CreateHookDelivery method
+func (m *MockStore) CreateHookDelivery(ctx context.Context, arg CreateHookDeliveryParams) (domain.HookDelivery, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.HookDelivery), args.Error(1)
+}
+
+// FinishHookDelivery mocks the FinishHookDelivery method
+func (m *MockStore) FinishHookDelivery(ctx context.Context, arg FinishHookDeliveryParams) (domain.HookDelivery, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.HookDelivery), args.Error(1)
+}
+
+// FailQueuedHookDeliveries mocks the FailQueuedHookDeliveries method
+func (m *MockStore) FailQueuedHookDeliveries(ctx context.Context, errorMessage string) (int64, error) {
+	args := m.Called(ctx, errorMessage)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// GetHookDeliveries mocks the GetHookDeliveries method
+func (m *MockStore) GetHookDeliveries(ctx context.Context, hookID uuid.UUID, limit int) ([]domain.HookDelivery, error) {
+	args := m.Called(ctx, hookID, limit)
+	return args.Get(0).([]domain.HookDelivery), args.Error(1)
+}
+
+// CreateProcessingRun mocks the CreateProcessingRun method
+func (m *MockStore) CreateProcessingRun(ctx context.Context, arg CreateProcessingRunParams) (domain.ProcessingRun, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.ProcessingRun), args.Error(1)
+}
+
+// GetProcessingRun mocks the GetProcessingRun method
+func (m *MockStore) GetProcessingRun(ctx context.Context, runID, userID uuid.UUID) (domain.ProcessingRun, error) {
+	args := m.Called(ctx, runID, userID)
+	return args.Get(0).(domain.ProcessingRun), args.Error(1)
+}
+
+// StartProcessingRun mocks the StartProcessingRun method
+func (m *MockStore) StartProcessingRun(ctx context.Context, runID uuid.UUID) error {
+	args := m.Called(ctx, runID)
+	return args.Error(0)
+}
+
+// FinishProcessingRun mocks the FinishProcessingRun method
+func (m *MockStore) FinishProcessingRun(
+	ctx context.Context,
+	runID uuid.UUID,
+	status domain.ProcessingRunStatus,
+	errorMessage *string,
+) (domain.ProcessingRun, error) {
+	args := m.Called(ctx, runID, status, errorMessage)
+	return args.Get(0).(domain.ProcessingRun), args.Error(1)
+}
+
+// FailUnfinishedProcessingRuns mocks the FailUnfinishedProcessingRuns method
+func (m *MockStore) FailUnfinishedProcessingRuns(ctx context.Context, errorMessage string) (int64, error) {
+	args := m.Called(ctx, errorMessage)
+	return args.Get(0).(int64), args.Error(1)
+}
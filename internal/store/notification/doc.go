@@ -0,0 +1,2 @@
+// Package notification stores messages for users, such as a rule the worker paused.
+package notification
@@ -0,0 +1,141 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"agenda-automator-api/internal/database"
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNotificationNotFound is returned when a notification does not exist or belongs to another user.
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// NotificationStorer defines the interface for notification store operations
+type NotificationStorer interface {
+	CreateNotification(ctx context.Context, arg CreateNotificationParams) (domain.Notification, error)
+	GetNotificationsForUser(
+		ctx context.Context,
+		userID uuid.UUID,
+		unreadOnly bool,
+		limit int,
+	) ([]domain.Notification, error)
+	MarkNotificationRead(ctx context.Context, notificationID, userID uuid.UUID) (domain.Notification, error)
+}
+
+// CreateNotificationParams contains the parameters for a notification about a connected account.
+// De gebruiker is de eigenaar van het account.
+type CreateNotificationParams struct {
+	ConnectedAccountID uuid.UUID
+	Type               domain.NotificationType
+	Message            string
+	Details            json.RawMessage
+}
+
+// NotificationStore handles notification database operations
+type NotificationStore struct {
+	db database.Querier
+}
+
+// NewNotificationStore creates a new NotificationStore
+func NewNotificationStore(db database.Querier) NotificationStorer {
+	return &NotificationStore{db: db}
+}
+
+const notificationColumns = `id, user_id, connected_account_id, type, message, details, read_at, created_at`
+
+// scanNotification scans a database row into a Notification
+func scanNotification(row pgx.Row) (domain.Notification, error) {
+	var n domain.Notification
+	err := row.Scan(
+		&n.ID,
+		&n.UserID,
+		&n.ConnectedAccountID,
+		&n.Type,
+		&n.Message,
+		&n.Details,
+		&n.ReadAt,
+		&n.CreatedAt,
+	)
+	return n, err
+}
+
+// CreateNotification slaat een melding op voor de eigenaar van het account.
+func (s *NotificationStore) CreateNotification(
+	ctx context.Context,
+	arg CreateNotificationParams,
+) (domain.Notification, error) {
+	query := `
+    INSERT INTO notifications (user_id, connected_account_id, type, message, details)
+    SELECT user_id, id, $2, $3, $4
+    FROM connected_accounts
+    WHERE id = $1
+    RETURNING ` + notificationColumns + `;
+    `
+	return scanNotification(s.db.QueryRow(ctx, query, arg.ConnectedAccountID, arg.Type, arg.Message, arg.Details))
+}
+
+// GetNotificationsForUser haalt de meldingen van een gebruiker op, nieuwste eerst.
+func (s *NotificationStore) GetNotificationsForUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	unreadOnly bool,
+	limit int,
+) ([]domain.Notification, error) {
+	query := `
+    SELECT ` + notificationColumns + `
+    FROM notifications
+    WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+    ORDER BY created_at DESC
+    LIMIT $3;
+    `
+
+	rows, err := s.db.Query(ctx, query, userID, unreadOnly, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []domain.Notification{}
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// MarkNotificationRead markeert een melding van de gebruiker als gelezen. Een melding die al
+// gelezen was houdt zijn oorspronkelijke read_at.
+func (s *NotificationStore) MarkNotificationRead(
+	ctx context.Context,
+	notificationID, userID uuid.UUID,
+) (domain.Notification, error) {
+	query := `
+    UPDATE notifications
+    SET read_at = COALESCE(read_at, now())
+    WHERE id = $1 AND user_id = $2
+    RETURNING ` + notificationColumns + `;
+    `
+
+	n, err := scanNotification(s.db.QueryRow(ctx, query, notificationID, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Notification{}, ErrNotificationNotFound
+		}
+		return domain.Notification{}, err
+	}
+
+	return n, nil
+}
@@ -0,0 +1,111 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupNotificationStore is een helper die een NotificationStore en een mock pool aanmaakt.
+func setupNotificationStore(t *testing.T) (NotificationStorer, pgxmock.PgxPoolIface) {
+	t.Helper()
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	return NewNotificationStore(mockPool), mockPool
+}
+
+var notificationColumnNames = []string{
+	"id", "user_id", "connected_account_id", "type", "message", "details", "read_at", "created_at",
+}
+
+func TestNotificationStore_CreateNotification(t *testing.T) {
+	store, mockPool := setupNotificationStore(t)
+	defer mockPool.Close()
+
+	accountID := uuid.New()
+	userID := uuid.New()
+	params := CreateNotificationParams{
+		ConnectedAccountID: accountID,
+		Type:               domain.NotificationRulePaused,
+		Message:            "Rule 'Alles beantwoorden' is gepauzeerd",
+		Details:            json.RawMessage(`{"rule_type":"gmail"}`),
+	}
+
+	// De gebruiker komt uit het account
+	mockPool.ExpectQuery(`INSERT INTO notifications .* SELECT user_id, id, \$2, \$3, \$4 FROM connected_accounts WHERE id = \$1`).
+		WithArgs(accountID, params.Type, params.Message, params.Details).
+		WillReturnRows(pgxmock.NewRows(notificationColumnNames).AddRow(
+			uuid.New(), userID, &accountID, params.Type, params.Message, params.Details, nil, time.Now(),
+		))
+
+	n, err := store.CreateNotification(context.Background(), params)
+
+	require.NoError(t, err)
+	assert.Equal(t, userID, n.UserID)
+	assert.Nil(t, n.ReadAt)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestNotificationStore_GetNotificationsForUser(t *testing.T) {
+	store, mockPool := setupNotificationStore(t)
+	defer mockPool.Close()
+
+	userID := uuid.New()
+	readAt := time.Now()
+	mockPool.ExpectQuery(`SELECT .* FROM notifications WHERE user_id = \$1 AND \(NOT \$2 OR read_at IS NULL\) ORDER BY created_at DESC LIMIT \$3`).
+		WithArgs(userID, false, 50).
+		WillReturnRows(pgxmock.NewRows(notificationColumnNames).
+			AddRow(uuid.New(), userID, nil, domain.NotificationRulePaused, "Nieuw", nil, nil, time.Now()).
+			AddRow(uuid.New(), userID, nil, domain.NotificationRulePaused, "Oud", nil, &readAt, time.Now()))
+
+	notifications, err := store.GetNotificationsForUser(context.Background(), userID, false, 50)
+
+	require.NoError(t, err)
+	require.Len(t, notifications, 2)
+	assert.Equal(t, "Nieuw", notifications[0].Message)
+	assert.NotNil(t, notifications[1].ReadAt)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestNotificationStore_MarkNotificationRead(t *testing.T) {
+	store, mockPool := setupNotificationStore(t)
+	defer mockPool.Close()
+
+	notificationID := uuid.New()
+	userID := uuid.New()
+
+	t.Run("success", func(t *testing.T) {
+		readAt := time.Now()
+		mockPool.ExpectQuery(`UPDATE notifications SET read_at = COALESCE\(read_at, now\(\)\) WHERE id = \$1 AND user_id = \$2`).
+			WithArgs(notificationID, userID).
+			WillReturnRows(pgxmock.NewRows(notificationColumnNames).
+				AddRow(notificationID, userID, nil, domain.NotificationRulePaused, "Gelezen", nil, &readAt, time.Now()))
+
+		n, err := store.MarkNotificationRead(context.Background(), notificationID, userID)
+
+		require.NoError(t, err)
+		assert.NotNil(t, n.ReadAt)
+	})
+
+	t.Run("other user", func(t *testing.T) {
+		mockPool.ExpectQuery(`UPDATE notifications`).
+			WithArgs(notificationID, userID).
+			WillReturnError(pgx.ErrNoRows)
+
+		_, err := store.MarkNotificationRead(context.Background(), notificationID, userID)
+
+		assert.ErrorIs(t, err, ErrNotificationNotFound)
+	})
+
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
@@ -0,0 +1,2 @@
+// Package ratelimit counts rule executions per rule and per account for the worker's runaway protection.
+package ratelimit
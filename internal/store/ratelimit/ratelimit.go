@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+
+	"agenda-automator-api/internal/database"
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// RateLimitStorer defines the interface for execution counter store operations
+type RateLimitStorer interface {
+	GetRuleExecutionCounts(ctx context.Context, ruleID, accountID uuid.UUID) (ruleHour, accountDay int, err error)
+	CountRuleExecution(
+		ctx context.Context,
+		ruleType domain.RuleType,
+		ruleID, accountID uuid.UUID,
+	) (ruleHour, accountDay int, err error)
+	GetBusiestRule(ctx context.Context, accountID uuid.UUID) (domain.RuleType, uuid.UUID, error)
+}
+
+// RateLimitStore handles execution counter database operations
+type RateLimitStore struct {
+	db database.Querier
+}
+
+// NewRateLimitStore creates a new RateLimitStore
+func NewRateLimitStore(db database.Querier) RateLimitStorer {
+	return &RateLimitStore{db: db}
+}
+
+// GetRuleExecutionCounts geeft de uitvoeringen van de rule in dit uur en van het account op
+// deze UTC dag, zonder iets mee te tellen.
+func (s *RateLimitStore) GetRuleExecutionCounts(
+	ctx context.Context,
+	ruleID, accountID uuid.UUID,
+) (ruleHour, accountDay int, err error) {
+	query := `
+    SELECT
+        COALESCE((
+            SELECT executions FROM rule_execution_counters
+            WHERE scope_id = $1 AND scope = 'rule' AND window_start = date_trunc('hour', now())
+        ), 0),
+        COALESCE((
+            SELECT sum(executions) FROM rule_account_executions
+            WHERE connected_account_id = $2 AND day = (now() AT TIME ZONE 'UTC')::date
+        ), 0);
+    `
+	err = s.db.QueryRow(ctx, query, ruleID, accountID).Scan(&ruleHour, &accountDay)
+	return ruleHour, accountDay, err
+}
+
+// CountRuleExecution telt één uitgevoerde actie mee voor de rule (per uur) en voor de rule op het
+// account (per UTC dag), en geeft de nieuwe tellers terug: de rule in dit uur en het account
+// vandaag over al zijn rules. Tellers van eerdere vensters worden in hetzelfde statement opgeruimd.
+func (s *RateLimitStore) CountRuleExecution(
+	ctx context.Context,
+	ruleType domain.RuleType,
+	ruleID, accountID uuid.UUID,
+) (ruleHour, accountDay int, err error) {
+	query := `
+    WITH pruned_rule AS (
+        DELETE FROM rule_execution_counters
+        WHERE scope_id = $2 AND window_start < now() - interval '1 day'
+    ), pruned_account AS (
+        DELETE FROM rule_account_executions
+        WHERE connected_account_id = $3 AND day < (now() AT TIME ZONE 'UTC')::date
+    ), rule_counter AS (
+        INSERT INTO rule_execution_counters (scope_id, scope, window_start, executions)
+        VALUES ($2, 'rule', date_trunc('hour', now()), 1)
+        ON CONFLICT (scope_id, scope, window_start)
+        DO UPDATE SET executions = rule_execution_counters.executions + 1
+        RETURNING executions
+    ), account_counter AS (
+        INSERT INTO rule_account_executions (connected_account_id, rule_type, rule_id, day, executions)
+        VALUES ($3, $1, $2, (now() AT TIME ZONE 'UTC')::date, 1)
+        ON CONFLICT (connected_account_id, day, rule_id)
+        DO UPDATE SET executions = rule_account_executions.executions + 1
+        RETURNING executions
+    )
+    SELECT
+        (SELECT executions FROM rule_counter),
+        (SELECT executions FROM account_counter) + COALESCE((
+            SELECT sum(executions) FROM rule_account_executions
+            WHERE connected_account_id = $3 AND day = (now() AT TIME ZONE 'UTC')::date AND rule_id <> $2
+        ), 0);
+    `
+	err = s.db.QueryRow(ctx, query, ruleType, ruleID, accountID).Scan(&ruleHour, &accountDay)
+	return ruleHour, accountDay, err
+}
+
+// GetBusiestRule geeft de rule met de meeste uitgevoerde acties vandaag op het account, van
+// welke service ook. Zonder uitvoeringen vandaag geeft het pgx.ErrNoRows.
+func (s *RateLimitStore) GetBusiestRule(ctx context.Context, accountID uuid.UUID) (domain.RuleType, uuid.UUID, error) {
+	query := `
+    SELECT rule_type, rule_id
+    FROM rule_account_executions
+    WHERE connected_account_id = $1 AND day = (now() AT TIME ZONE 'UTC')::date
+    ORDER BY executions DESC, rule_id
+    LIMIT 1;
+    `
+	var ruleType domain.RuleType
+	var ruleID uuid.UUID
+	err := s.db.QueryRow(ctx, query, accountID).Scan(&ruleType, &ruleID)
+	return ruleType, ruleID, err
+}
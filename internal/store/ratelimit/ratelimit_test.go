@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitStore_GetRuleExecutionCounts(t *testing.T) {
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+	store := NewRateLimitStore(mockPool)
+
+	ruleID := uuid.New()
+	accountID := uuid.New()
+
+	// Alleen lezen: er wordt niets meegeteld
+	mockPool.ExpectQuery(`SELECT COALESCE\(\( SELECT executions FROM rule_execution_counters .* SELECT sum\(executions\) FROM rule_account_executions`).
+		WithArgs(ruleID, accountID).
+		WillReturnRows(pgxmock.NewRows([]string{"rule_hour", "account_day"}).AddRow(3, 42))
+
+	ruleHour, accountDay, err := store.GetRuleExecutionCounts(context.Background(), ruleID, accountID)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, ruleHour)
+	assert.Equal(t, 42, accountDay)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRateLimitStore_CountRuleExecution(t *testing.T) {
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+	store := NewRateLimitStore(mockPool)
+
+	ruleID := uuid.New()
+	accountID := uuid.New()
+
+	// Beide tellers worden in één statement opgehoogd, en oude vensters opgeruimd
+	mockPool.ExpectQuery(`WITH pruned_rule AS \( DELETE FROM rule_execution_counters .* 'rule', date_trunc\('hour', now\(\)\).* INSERT INTO rule_account_executions`).
+		WithArgs(domain.RuleTypeGmail, ruleID, accountID).
+		WillReturnRows(pgxmock.NewRows([]string{"rule_hour", "account_day"}).AddRow(3, 42))
+
+	ruleHour, accountDay, err := store.CountRuleExecution(context.Background(), domain.RuleTypeGmail, ruleID, accountID)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, ruleHour)
+	assert.Equal(t, 42, accountDay)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRateLimitStore_CountRuleExecution_Error(t *testing.T) {
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+	store := NewRateLimitStore(mockPool)
+
+	mockPool.ExpectQuery(`WITH pruned_rule AS`).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnError(errors.New("db down"))
+
+	_, _, err = store.CountRuleExecution(context.Background(), domain.RuleTypeCalendar, uuid.New(), uuid.New())
+
+	assert.Error(t, err)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRateLimitStore_GetBusiestRule(t *testing.T) {
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+	store := NewRateLimitStore(mockPool)
+
+	accountID := uuid.New()
+	ruleID := uuid.New()
+
+	mockPool.ExpectQuery(`SELECT rule_type, rule_id FROM rule_account_executions .* ORDER BY executions DESC`).
+		WithArgs(accountID).
+		WillReturnRows(pgxmock.NewRows([]string{"rule_type", "rule_id"}).AddRow(domain.RuleTypeGmail, ruleID))
+
+	ruleType, busiest, err := store.GetBusiestRule(context.Background(), accountID)
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.RuleTypeGmail, ruleType)
+	assert.Equal(t, ruleID, busiest)
+
+	mockPool.ExpectQuery(`SELECT rule_type, rule_id FROM rule_account_executions`).
+		WithArgs(accountID).
+		WillReturnError(pgx.ErrNoRows)
+
+	_, _, err = store.GetBusiestRule(context.Background(), accountID)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
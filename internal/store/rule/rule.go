@@ -17,8 +17,20 @@ type RuleStorer interface {
 	CreateAutomationRule(ctx context.Context, arg CreateAutomationRuleParams) (domain.AutomationRule, error)
 	GetRuleByID(ctx context.Context, ruleID uuid.UUID) (domain.AutomationRule, error)
 	GetRulesForAccount(ctx context.Context, accountID uuid.UUID) ([]domain.AutomationRule, error)
+	CreateUserRule(ctx context.Context, arg CreateUserRuleParams) (domain.AutomationRule, error)
+	GetUserRules(ctx context.Context, userID uuid.UUID) ([]domain.AutomationRule, error)
+	GetRulesForHook(ctx context.Context, hookID uuid.UUID) ([]domain.AutomationRule, error)
+	UpdateRuleAccountSelector(
+		ctx context.Context,
+		ruleID uuid.UUID,
+		selector domain.AccountSelector,
+		changedBy uuid.UUID,
+	) (domain.AutomationRule, error)
 	UpdateRule(ctx context.Context, arg UpdateRuleParams) (domain.AutomationRule, error)
-	ToggleRuleStatus(ctx context.Context, ruleID uuid.UUID) (domain.AutomationRule, error)
+	ToggleRuleStatus(ctx context.Context, ruleID uuid.UUID, changedBy uuid.UUID) (domain.AutomationRule, error)
+	RecordRuleExecution(ctx context.Context, ruleID uuid.UUID) (int, error)
+	ExpireRule(ctx context.Context, ruleID uuid.UUID) (domain.AutomationRule, error)
+	PauseRule(ctx context.Context, ruleID uuid.UUID) (domain.AutomationRule, error)
 	VerifyRuleOwnership(ctx context.Context, ruleID uuid.UUID, userID uuid.UUID) error
 	DeleteRule(ctx context.Context, ruleID uuid.UUID) error
 }
@@ -27,18 +39,56 @@ type RuleStorer interface {
 type CreateAutomationRuleParams struct {
 	ConnectedAccountID uuid.UUID
 	Name               string
+	TriggerType        string          // event_match of hook_received
 	TriggerConditions  json.RawMessage // []byte
+	ActionType         string          // een actie uit de calendar registry, bijv. create_reminder
 	ActionParams       json.RawMessage // []byte
+	Schedule           *domain.RuleSchedule
+	CreatedBy          uuid.UUID // komt als changed_by in rule_versions
+}
+
+// CreateUserRuleParams bevat de parameters voor een user-level rule. In plaats van een account
+// heeft de rule een eigenaar en een selector die de accounts kiest waarop hij draait.
+type CreateUserRuleParams struct {
+	UserID            uuid.UUID
+	AccountSelector   domain.AccountSelector
+	Name              string
+	TriggerType       string
+	TriggerConditions json.RawMessage
+	ActionType        string
+	ActionParams      json.RawMessage
+	Schedule          *domain.RuleSchedule
 }
 
 // UpdateRuleParams definieert de parameters voor het bijwerken van een regel.
 type UpdateRuleParams struct {
 	RuleID            uuid.UUID
 	Name              string
+	TriggerType       string
 	TriggerConditions json.RawMessage
+	ActionType        string
 	ActionParams      json.RawMessage
+	Schedule          *domain.RuleSchedule
+	// AccountSelector vervangt de selector van een user-level rule; nil laat hem ongewijzigd
+	AccountSelector *domain.AccountSelector
+	ChangedBy       uuid.UUID
+	// RestoredFrom is gezet als de update een oude versie terugzet; de versie krijgt dan change_type 'restore'
+	RestoredFrom *int
 }
 
+// SnapshotSQL bouwt de snapshot voor rule_versions uit een rij van automation_rules.
+// De sleutels zijn de JSON namen van domain.AutomationRule, zodat een snapshot terug te decoderen is.
+// account_selector staat er alleen in bij user-level rules.
+const SnapshotSQL = `(jsonb_build_object(
+        'name', name,
+        'is_active', is_active,
+        'trigger_type', trigger_type,
+        'trigger_conditions', trigger_conditions,
+        'action_type', action_type,
+        'action_params', action_params,
+        'schedule', schedule
+    ) || jsonb_strip_nulls(jsonb_build_object('account_selector', account_selector)))`
+
 // RuleStore handles rule-related database operations
 type RuleStore struct {
 	db database.Querier
@@ -61,22 +111,41 @@ func scanRule(row pgx.Row) (domain.AutomationRule, error) {
 		&rule.ActionParams,
 		&rule.CreatedAt,
 		&rule.UpdatedAt,
+		&rule.Version,
+		&rule.Schedule,
+		&rule.ExecutionCount,
+		&rule.ActionType,
+		&rule.UserID,
+		&rule.AccountSelector,
+		&rule.TriggerType,
 	)
 	return rule, err
 }
 
-// CreateAutomationRule creates a new automation rule.
+// CreateAutomationRule creates a new automation rule and stores it as version 1.
 func (s *RuleStore) CreateAutomationRule(
 	ctx context.Context,
 	arg CreateAutomationRuleParams,
 ) (domain.AutomationRule, error) {
 	query := `
-    INSERT INTO automation_rules (
-        connected_account_id, name, trigger_conditions, action_params
-    ) VALUES (
-        $1, $2, $3, $4
+    WITH created AS (
+        INSERT INTO automation_rules (
+            connected_account_id, name, trigger_conditions, action_params, schedule, action_type, trigger_type
+        ) VALUES (
+            $1, $2, $3, $4, $5, $7, $8
+        )
+        RETURNING id, connected_account_id, name, is_active, trigger_conditions, action_params,
+                  created_at, updated_at, version, schedule, execution_count, action_type,
+                  user_id, account_selector, trigger_type
+    ), versioned AS (
+        INSERT INTO rule_versions (rule_type, rule_id, version, change_type, changed_by, snapshot)
+        SELECT 'calendar', id, version, 'create', $6::uuid, ` + SnapshotSQL + `
+        FROM created
     )
-    RETURNING id, connected_account_id, name, is_active, trigger_conditions, action_params, created_at, updated_at;
+    SELECT id, connected_account_id, name, is_active, trigger_conditions, action_params,
+           created_at, updated_at, version, schedule, execution_count, action_type,
+           user_id, account_selector, trigger_type
+    FROM created;
     `
 
 	row := s.db.QueryRow(ctx, query,
@@ -84,6 +153,10 @@ func (s *RuleStore) CreateAutomationRule(
 		arg.Name,
 		arg.TriggerConditions,
 		arg.ActionParams,
+		arg.Schedule,
+		arg.CreatedBy,
+		arg.ActionType,
+		arg.TriggerType,
 	)
 
 	return scanRule(row)
@@ -93,24 +166,12 @@ func (s *RuleStore) CreateAutomationRule(
 func (s *RuleStore) GetRuleByID(ctx context.Context, ruleID uuid.UUID) (domain.AutomationRule, error) {
 	query := `
 	    SELECT id, connected_account_id, name, is_active,
-	           trigger_conditions, action_params, created_at, updated_at
+	           trigger_conditions, action_params, created_at, updated_at, version, schedule, execution_count, action_type,
+	           user_id, account_selector, trigger_type
 	    FROM automation_rules
 	    WHERE id = $1
 	    `
-	row := s.db.QueryRow(ctx, query, ruleID)
-
-	var rule domain.AutomationRule
-	err := row.Scan(
-		&rule.ID,
-		&rule.ConnectedAccountID,
-		&rule.Name,
-		&rule.IsActive,
-		&rule.TriggerConditions,
-		&rule.ActionParams,
-		&rule.CreatedAt,
-		&rule.UpdatedAt,
-	)
-
+	rule, err := scanRule(s.db.QueryRow(ctx, query, ruleID))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return domain.AutomationRule{}, errors.New("rule not found")
@@ -125,7 +186,8 @@ func (s *RuleStore) GetRuleByID(ctx context.Context, ruleID uuid.UUID) (domain.A
 func (s *RuleStore) GetRulesForAccount(ctx context.Context, accountID uuid.UUID) ([]domain.AutomationRule, error) {
 	query := `
     SELECT id, connected_account_id, name, is_active,
-           trigger_conditions, action_params, created_at, updated_at
+           trigger_conditions, action_params, created_at, updated_at, version, schedule, execution_count, action_type,
+           user_id, account_selector, trigger_type
     FROM automation_rules
     WHERE connected_account_id = $1
     ORDER BY created_at DESC;
@@ -139,17 +201,115 @@ func (s *RuleStore) GetRulesForAccount(ctx context.Context, accountID uuid.UUID)
 
 	var rules []domain.AutomationRule
 	for rows.Next() {
-		var rule domain.AutomationRule
-		err := rows.Scan(
-			&rule.ID,
-			&rule.ConnectedAccountID,
-			&rule.Name,
-			&rule.IsActive,
-			&rule.TriggerConditions,
-			&rule.ActionParams,
-			&rule.CreatedAt,
-			&rule.UpdatedAt,
-		)
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// CreateUserRule maakt een user-level rule aan en slaat die op als versie 1. De gebruiker is
+// ook changed_by van de eerste versie.
+func (s *RuleStore) CreateUserRule(ctx context.Context, arg CreateUserRuleParams) (domain.AutomationRule, error) {
+	query := `
+    WITH created AS (
+        INSERT INTO automation_rules (
+            user_id, account_selector, name, trigger_conditions, action_params, schedule, action_type, trigger_type
+        ) VALUES (
+            $1, $2, $3, $4, $5, $6, $7, $8
+        )
+        RETURNING id, connected_account_id, name, is_active, trigger_conditions, action_params,
+                  created_at, updated_at, version, schedule, execution_count, action_type,
+                  user_id, account_selector, trigger_type
+    ), versioned AS (
+        INSERT INTO rule_versions (rule_type, rule_id, version, change_type, changed_by, snapshot)
+        SELECT 'calendar', id, version, 'create', user_id, ` + SnapshotSQL + `
+        FROM created
+    )
+    SELECT id, connected_account_id, name, is_active, trigger_conditions, action_params,
+           created_at, updated_at, version, schedule, execution_count, action_type,
+           user_id, account_selector, trigger_type
+    FROM created;
+    `
+
+	return scanRule(s.db.QueryRow(ctx, query,
+		arg.UserID,
+		arg.AccountSelector,
+		arg.Name,
+		arg.TriggerConditions,
+		arg.ActionParams,
+		arg.Schedule,
+		arg.ActionType,
+		arg.TriggerType,
+	))
+}
+
+// GetUserRules haalt de user-level rules van een gebruiker op. Of een rule op een account
+// draait bepaalt de aanroeper met AppliesTo.
+func (s *RuleStore) GetUserRules(ctx context.Context, userID uuid.UUID) ([]domain.AutomationRule, error) {
+	query := `
+    SELECT id, connected_account_id, name, is_active,
+           trigger_conditions, action_params, created_at, updated_at, version, schedule, execution_count, action_type,
+           user_id, account_selector, trigger_type
+    FROM automation_rules
+    WHERE user_id = $1
+    ORDER BY created_at DESC;
+    `
+
+	rows, err := s.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []domain.AutomationRule
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// GetRulesForHook haalt de actieve rules op die aan een inbound hook gebonden zijn. Alleen rules
+// van de eigenaar van de hook tellen mee, direct of via een van zijn accounts; een rule die naar
+// de hook van een ander verwijst draait nooit.
+func (s *RuleStore) GetRulesForHook(ctx context.Context, hookID uuid.UUID) ([]domain.AutomationRule, error) {
+	query := `
+    SELECT r.id, r.connected_account_id, r.name, r.is_active,
+           r.trigger_conditions, r.action_params, r.created_at, r.updated_at, r.version, r.schedule,
+           r.execution_count, r.action_type, r.user_id, r.account_selector, r.trigger_type
+    FROM automation_rules r
+    JOIN inbound_hooks h ON h.id = $1
+    LEFT JOIN connected_accounts ca ON ca.id = r.connected_account_id
+    WHERE r.trigger_type = 'hook_received' AND r.trigger_conditions->>'hook_id' = $1::text
+      AND r.is_active AND COALESCE(r.user_id, ca.user_id) = h.user_id
+    ORDER BY r.created_at;
+    `
+
+	rows, err := s.db.Query(ctx, query, hookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []domain.AutomationRule
+	for rows.Next() {
+		rule, err := scanRule(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -163,60 +323,196 @@ func (s *RuleStore) GetRulesForAccount(ctx context.Context, accountID uuid.UUID)
 	return rules, nil
 }
 
-// UpdateRule werkt een bestaande regel bij.
+// UpdateRuleAccountSelector vervangt de selector van een user-level rule en schrijft de nieuwe
+// versie in hetzelfde statement. Een account-rule geeft pgx.ErrNoRows.
+func (s *RuleStore) UpdateRuleAccountSelector(
+	ctx context.Context,
+	ruleID uuid.UUID,
+	selector domain.AccountSelector,
+	changedBy uuid.UUID,
+) (domain.AutomationRule, error) {
+	query := `
+    WITH updated AS (
+        UPDATE automation_rules
+        SET account_selector = $2, version = version + 1, updated_at = now()
+        WHERE id = $1 AND user_id IS NOT NULL
+        RETURNING id, connected_account_id, name, is_active, trigger_conditions, action_params,
+                  created_at, updated_at, version, schedule, execution_count, action_type,
+                  user_id, account_selector, trigger_type
+    ), versioned AS (
+        INSERT INTO rule_versions (rule_type, rule_id, version, change_type, changed_by, snapshot, diff)
+        SELECT 'calendar', u.id, u.version, 'update', $3::uuid, u.snapshot,
+               rule_snapshot_diff((
+                   SELECT v.snapshot FROM rule_versions v
+                   WHERE v.rule_type = 'calendar' AND v.rule_id = u.id
+                   ORDER BY v.version DESC
+                   LIMIT 1
+               ), u.snapshot)
+        FROM (SELECT id, version, ` + SnapshotSQL + ` AS snapshot FROM updated) u
+    )
+    SELECT id, connected_account_id, name, is_active, trigger_conditions, action_params,
+           created_at, updated_at, version, schedule, execution_count, action_type,
+           user_id, account_selector, trigger_type
+    FROM updated;
+    `
+	return scanRule(s.db.QueryRow(ctx, query, ruleID, selector, changedBy))
+}
+
+// UpdateRule werkt een bestaande regel bij en schrijft de nieuwe versie in hetzelfde statement.
+// De diff wordt berekend ten opzichte van de laatst opgeslagen versie.
 func (s *RuleStore) UpdateRule(ctx context.Context, arg UpdateRuleParams) (domain.AutomationRule, error) {
 	query := `
-    UPDATE automation_rules
-    SET name = $1, trigger_conditions = $2, action_params = $3, updated_at = now()
-    WHERE id = $4
-    RETURNING id, connected_account_id, name, is_active, trigger_conditions, action_params, created_at, updated_at;
+    WITH updated AS (
+        UPDATE automation_rules
+        SET name = $1, trigger_conditions = $2, action_params = $3, schedule = $4, action_type = $8,
+            account_selector = COALESCE($9, account_selector), trigger_type = $10,
+            version = version + 1, updated_at = now()
+        WHERE id = $5
+        RETURNING id, connected_account_id, name, is_active, trigger_conditions, action_params,
+                  created_at, updated_at, version, schedule, execution_count, action_type,
+                  user_id, account_selector, trigger_type
+    ), versioned AS (
+        INSERT INTO rule_versions (
+            rule_type, rule_id, version, change_type, changed_by, snapshot, diff, restored_from
+        )
+        SELECT 'calendar', u.id, u.version,
+               CASE WHEN $7::int IS NULL THEN 'update' ELSE 'restore' END,
+               $6::uuid, u.snapshot,
+               rule_snapshot_diff((
+                   SELECT v.snapshot FROM rule_versions v
+                   WHERE v.rule_type = 'calendar' AND v.rule_id = u.id
+                   ORDER BY v.version DESC
+                   LIMIT 1
+               ), u.snapshot),
+               $7::int
+        FROM (SELECT id, version, ` + SnapshotSQL + ` AS snapshot FROM updated) u
+    )
+    SELECT id, connected_account_id, name, is_active, trigger_conditions, action_params,
+           created_at, updated_at, version, schedule, execution_count, action_type,
+           user_id, account_selector, trigger_type
+    FROM updated;
     `
 	row := s.db.QueryRow(ctx, query,
 		arg.Name,
 		arg.TriggerConditions,
 		arg.ActionParams,
+		arg.Schedule,
 		arg.RuleID,
+		arg.ChangedBy,
+		arg.RestoredFrom,
+		arg.ActionType,
+		arg.AccountSelector,
+		arg.TriggerType,
 	)
 
 	return scanRule(row)
 }
 
-// ToggleRuleStatus zet de 'is_active' boolean van een regel om.
-func (s *RuleStore) ToggleRuleStatus(ctx context.Context, ruleID uuid.UUID) (domain.AutomationRule, error) {
+// ToggleRuleStatus zet de 'is_active' boolean van een regel om en schrijft een nieuwe versie.
+// Bij het aanzetten begint de teller voor max_executions opnieuw.
+func (s *RuleStore) ToggleRuleStatus(
+	ctx context.Context,
+	ruleID uuid.UUID,
+	changedBy uuid.UUID,
+) (domain.AutomationRule, error) {
+	query := `
+    WITH toggled AS (
+        UPDATE automation_rules
+        SET is_active = NOT is_active,
+            execution_count = CASE WHEN is_active THEN execution_count ELSE 0 END,
+            version = version + 1, updated_at = now()
+        WHERE id = $1
+        RETURNING id, connected_account_id, name, is_active, trigger_conditions, action_params,
+                  created_at, updated_at, version, schedule, execution_count, action_type,
+                  user_id, account_selector, trigger_type
+    ), versioned AS (
+        INSERT INTO rule_versions (rule_type, rule_id, version, change_type, changed_by, snapshot, diff)
+        SELECT 'calendar', t.id, t.version, 'toggle', $2::uuid, t.snapshot,
+               rule_snapshot_diff((
+                   SELECT v.snapshot FROM rule_versions v
+                   WHERE v.rule_type = 'calendar' AND v.rule_id = t.id
+                   ORDER BY v.version DESC
+                   LIMIT 1
+               ), t.snapshot)
+        FROM (SELECT id, version, ` + SnapshotSQL + ` AS snapshot FROM toggled) t
+    ), counters_reset AS (
+        -- Een weer aangezette rule begint met een schone uurlimiet
+        DELETE FROM rule_execution_counters
+        WHERE scope = 'rule' AND scope_id IN (SELECT id FROM toggled WHERE is_active)
+    )
+    SELECT id, connected_account_id, name, is_active, trigger_conditions, action_params,
+           created_at, updated_at, version, schedule, execution_count, action_type,
+           user_id, account_selector, trigger_type
+    FROM toggled;
+    `
+	return scanRule(s.db.QueryRow(ctx, query, ruleID, changedBy))
+}
+
+// RecordRuleExecution telt een geslaagde uitvoering en geeft het nieuwe aantal terug.
+func (s *RuleStore) RecordRuleExecution(ctx context.Context, ruleID uuid.UUID) (int, error) {
 	query := `
     UPDATE automation_rules
-    SET is_active = NOT is_active, updated_at = now()
+    SET execution_count = execution_count + 1
     WHERE id = $1
-    RETURNING id, connected_account_id, name, is_active, trigger_conditions, action_params, created_at, updated_at;
+    RETURNING execution_count;
     `
-	row := s.db.QueryRow(ctx, query, ruleID)
+	var count int
+	err := s.db.QueryRow(ctx, query, ruleID).Scan(&count)
+	return count, err
+}
 
-	var rule domain.AutomationRule
-	err := row.Scan(
-		&rule.ID,
-		&rule.ConnectedAccountID,
-		&rule.Name,
-		&rule.IsActive,
-		&rule.TriggerConditions,
-		&rule.ActionParams,
-		&rule.CreatedAt,
-		&rule.UpdatedAt,
-	)
+// ExpireRule zet een regel uit waarvan het schedule verlopen is. De nieuwe versie heeft
+// change_type 'expire' en geen changed_by, omdat de worker de wijziging doet.
+func (s *RuleStore) ExpireRule(ctx context.Context, ruleID uuid.UUID) (domain.AutomationRule, error) {
+	return s.deactivateRule(ctx, ruleID, domain.RuleChangeExpire)
+}
 
-	if err != nil {
-		return domain.AutomationRule{}, err
-	}
+// PauseRule zet een regel uit die een uitvoeringslimiet overschreed, met change_type 'pause'.
+func (s *RuleStore) PauseRule(ctx context.Context, ruleID uuid.UUID) (domain.AutomationRule, error) {
+	return s.deactivateRule(ctx, ruleID, domain.RuleChangePause)
+}
 
-	return rule, nil
+// deactivateRule zet een actieve regel uit namens de worker. Een regel die al uit staat geeft pgx.ErrNoRows.
+func (s *RuleStore) deactivateRule(
+	ctx context.Context,
+	ruleID uuid.UUID,
+	changeType domain.RuleChangeType,
+) (domain.AutomationRule, error) {
+	query := `
+    WITH deactivated AS (
+        UPDATE automation_rules
+        SET is_active = false, version = version + 1, updated_at = now()
+        WHERE id = $1 AND is_active
+        RETURNING id, connected_account_id, name, is_active, trigger_conditions, action_params,
+                  created_at, updated_at, version, schedule, execution_count, action_type,
+                  user_id, account_selector, trigger_type
+    ), versioned AS (
+        INSERT INTO rule_versions (rule_type, rule_id, version, change_type, snapshot, diff)
+        SELECT 'calendar', d.id, d.version, $2, d.snapshot,
+               rule_snapshot_diff((
+                   SELECT v.snapshot FROM rule_versions v
+                   WHERE v.rule_type = 'calendar' AND v.rule_id = d.id
+                   ORDER BY v.version DESC
+                   LIMIT 1
+               ), d.snapshot)
+        FROM (SELECT id, version, ` + SnapshotSQL + ` AS snapshot FROM deactivated) d
+    )
+    SELECT id, connected_account_id, name, is_active, trigger_conditions, action_params,
+           created_at, updated_at, version, schedule, execution_count, action_type,
+           user_id, account_selector, trigger_type
+    FROM deactivated;
+    `
+	return scanRule(s.db.QueryRow(ctx, query, ruleID, changeType))
 }
 
-// VerifyRuleOwnership controleert of een gebruiker de eigenaar is van de regel (via het account).
+// VerifyRuleOwnership controleert of een gebruiker de eigenaar is van de regel (via het account,
+// of direct bij een user-level rule).
 func (s *RuleStore) VerifyRuleOwnership(ctx context.Context, ruleID uuid.UUID, userID uuid.UUID) error {
 	query := `
 	   SELECT 1
 	   FROM automation_rules r
-	   JOIN connected_accounts ca ON r.connected_account_id = ca.id
-	   WHERE r.id = $1 AND ca.user_id = $2
+	   LEFT JOIN connected_accounts ca ON r.connected_account_id = ca.id
+	   WHERE r.id = $1 AND (ca.user_id = $2 OR r.user_id = $2)
 	   LIMIT 1;
 	   `
 	var exists int
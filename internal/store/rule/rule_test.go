@@ -6,6 +6,8 @@ import (
 	"testing"
 	"time"
 
+	"agenda-automator-api/internal/domain"
+
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/pashagolub/pgxmock/v3"
@@ -27,14 +29,16 @@ func setupRuleStore(t *testing.T) (RuleStorer, pgxmock.PgxPoolIface) {
 // Definitie van de kolommen die door de queries worden geretourneerd
 var ruleColumns = []string{
 	"id", "connected_account_id", "name", "is_active",
-	"trigger_conditions", "action_params", "created_at", "updated_at",
+	"trigger_conditions", "action_params", "created_at", "updated_at", "version",
+	"schedule", "execution_count", "action_type", "user_id", "account_selector",
+	"trigger_type",
 }
 
 // Helper om een standaard mock-regel te maken
-func mockRuleData(ruleID, accountID uuid.UUID, name string, active bool) (uuid.UUID, uuid.UUID, string, bool, json.RawMessage, json.RawMessage, time.Time, time.Time) {
+func mockRuleData(ruleID, accountID uuid.UUID, name string, active bool) (uuid.UUID, uuid.UUID, string, bool, json.RawMessage, json.RawMessage, time.Time, time.Time, int, *domain.RuleSchedule, int, string, *uuid.UUID, *domain.AccountSelector, string) {
 	return ruleID, accountID, name, active,
 		json.RawMessage(`{}`), json.RawMessage(`{}`),
-		time.Now(), time.Now()
+		time.Now(), time.Now(), 1, nil, 0, domain.CalendarActionCreateReminder, nil, nil, domain.CalendarTriggerEventMatch
 }
 
 func TestRuleStore_CreateAutomationRule(t *testing.T) {
@@ -49,19 +53,25 @@ func TestRuleStore_CreateAutomationRule(t *testing.T) {
 		ConnectedAccountID: accountID,
 		Name:               "Test Rule",
 		TriggerConditions:  json.RawMessage(`{"key":"value"}`),
+		TriggerType:        domain.CalendarTriggerEventMatch,
+		ActionType:         domain.CalendarActionSendEmail,
 		ActionParams:       json.RawMessage(`{}`),
+		CreatedBy:          uuid.New(),
 	}
 
 	// Mock de data die de DB teruggeeft
 	rows := pgxmock.NewRows(ruleColumns).AddRow(
 		ruleID, params.ConnectedAccountID, params.Name, true, // is_active default op true
-		params.TriggerConditions, params.ActionParams, time.Now(), time.Now(),
+		params.TriggerConditions, params.ActionParams, time.Now(), time.Now(), 1, params.Schedule, 0, domain.CalendarActionCreateReminder, nil, nil,
+		domain.CalendarTriggerEventMatch,
 	)
 
-	mockPool.ExpectQuery("^INSERT INTO automation_rules").
+	// De rule en versie 1 worden in één statement geschreven
+	mockPool.ExpectQuery(`^WITH created AS \( INSERT INTO automation_rules .* INSERT INTO rule_versions .* 'create'`).
 		WithArgs(
 			params.ConnectedAccountID, params.Name,
-			params.TriggerConditions, params.ActionParams,
+			params.TriggerConditions, params.ActionParams, params.Schedule, params.CreatedBy, params.ActionType,
+			params.TriggerType,
 		).
 		WillReturnRows(rows)
 
@@ -73,6 +83,7 @@ func TestRuleStore_CreateAutomationRule(t *testing.T) {
 	assert.Equal(t, ruleID, rule.ID)
 	assert.Equal(t, "Test Rule", rule.Name)
 	assert.True(t, rule.IsActive)
+	assert.Equal(t, 1, rule.Version)
 	assert.NoError(t, mockPool.ExpectationsWereMet())
 }
 
@@ -159,17 +170,19 @@ func TestRuleStore_ToggleRuleStatus(t *testing.T) {
 	ruleID := uuid.New()
 	accountID := uuid.New()
 
+	userID := uuid.New()
+
 	// De teruggestuurde regel is nu 'false' (getoggled)
 	rows := pgxmock.NewRows(ruleColumns).AddRow(
 		mockRuleData(ruleID, accountID, "Toggled Rule", false),
 	)
 
-	mockPool.ExpectQuery("^UPDATE automation_rules SET is_active = NOT is_active").
-		WithArgs(ruleID).
+	mockPool.ExpectQuery(`^WITH toggled AS \( UPDATE automation_rules SET is_active = NOT is_active, execution_count = .* version = version \+ 1.* 'toggle'.* DELETE FROM rule_execution_counters WHERE scope = 'rule'`).
+		WithArgs(ruleID, userID).
 		WillReturnRows(rows)
 
 	// Act
-	rule, err := store.ToggleRuleStatus(ctx, ruleID)
+	rule, err := store.ToggleRuleStatus(ctx, ruleID, userID)
 
 	// Assert
 	assert.NoError(t, err)
@@ -281,17 +294,20 @@ func TestRuleStore_UpdateRule(t *testing.T) {
 		Name:              "Updated Rule",
 		TriggerConditions: json.RawMessage(`{"updated": true}`),
 		ActionParams:      json.RawMessage(`{"action": "updated"}`),
+		ChangedBy:         uuid.New(),
 	}
 
 	// Mock de data die de DB teruggeeft na update
 	rows := pgxmock.NewRows(ruleColumns).AddRow(
 		ruleID, accountID, params.Name, true, // is_active blijft hetzelfde
-		params.TriggerConditions, params.ActionParams, time.Now(), time.Now(),
+		params.TriggerConditions, params.ActionParams, time.Now(), time.Now(), 2, nil, 0, domain.CalendarActionCreateReminder, nil, nil,
+		domain.CalendarTriggerEventMatch,
 	)
 
-	mockPool.ExpectQuery("^UPDATE automation_rules").
+	mockPool.ExpectQuery(`^WITH updated AS \( UPDATE automation_rules .* INSERT INTO rule_versions .* rule_snapshot_diff`).
 		WithArgs(
-			params.Name, params.TriggerConditions, params.ActionParams, params.RuleID,
+			params.Name, params.TriggerConditions, params.ActionParams, params.Schedule, params.RuleID,
+			params.ChangedBy, params.RestoredFrom, params.ActionType, params.AccountSelector, params.TriggerType,
 		).
 		WillReturnRows(rows)
 
@@ -304,5 +320,180 @@ func TestRuleStore_UpdateRule(t *testing.T) {
 	assert.Equal(t, "Updated Rule", rule.Name)
 	assert.Equal(t, json.RawMessage(`{"updated": true}`), rule.TriggerConditions)
 	assert.Equal(t, json.RawMessage(`{"action": "updated"}`), rule.ActionParams)
+	assert.Equal(t, 2, rule.Version)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRuleStore_RecordRuleExecution(t *testing.T) {
+	store, mockPool := setupRuleStore(t)
+	defer mockPool.Close()
+
+	ruleID := uuid.New()
+	mockPool.ExpectQuery(`^UPDATE automation_rules SET execution_count = execution_count \+ 1 WHERE id = \$1 RETURNING execution_count`).
+		WithArgs(ruleID).
+		WillReturnRows(pgxmock.NewRows([]string{"execution_count"}).AddRow(3))
+
+	count, err := store.RecordRuleExecution(context.Background(), ruleID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRuleStore_ExpireRule(t *testing.T) {
+	store, mockPool := setupRuleStore(t)
+	defer mockPool.Close()
+
+	ruleID := uuid.New()
+	rows := pgxmock.NewRows(ruleColumns).AddRow(
+		mockRuleData(ruleID, uuid.New(), "Vakantie", false),
+	)
+
+	// Alleen een actieve rule wordt uitgezet, met een versie zonder changed_by
+	mockPool.ExpectQuery(`^WITH deactivated AS \( UPDATE automation_rules SET is_active = false.* AND is_active .* \$2`).
+		WithArgs(ruleID, domain.RuleChangeExpire).
+		WillReturnRows(rows)
+
+	rule, err := store.ExpireRule(context.Background(), ruleID)
+
+	assert.NoError(t, err)
+	assert.False(t, rule.IsActive)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRuleStore_PauseRule(t *testing.T) {
+	store, mockPool := setupRuleStore(t)
+	defer mockPool.Close()
+
+	ruleID := uuid.New()
+	mockPool.ExpectQuery(`^WITH deactivated AS \( UPDATE automation_rules SET is_active = false`).
+		WithArgs(ruleID, domain.RuleChangePause).
+		WillReturnError(pgx.ErrNoRows)
+
+	// Een rule die al uit staat wordt niet opnieuw gepauzeerd
+	_, err := store.PauseRule(context.Background(), ruleID)
+
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRuleStore_CreateUserRule(t *testing.T) {
+	store, mockPool := setupRuleStore(t)
+	defer mockPool.Close()
+
+	ctx := context.Background()
+	userID := uuid.New()
+	params := CreateUserRuleParams{
+		UserID:            userID,
+		AccountSelector:   domain.AccountSelector{Mode: domain.AccountSelectorAll},
+		Name:              "Voor alle agenda's",
+		TriggerType:       domain.CalendarTriggerEventMatch,
+		TriggerConditions: json.RawMessage(`{"summary_equals":"Standup"}`),
+		ActionType:        domain.CalendarActionCreateReminder,
+		ActionParams:      json.RawMessage(`{}`),
+	}
+
+	rows := pgxmock.NewRows(ruleColumns).AddRow(
+		uuid.New(), nil, params.Name, true,
+		params.TriggerConditions, params.ActionParams, time.Now(), time.Now(), 1, nil, 0, params.ActionType,
+		&userID, &params.AccountSelector, params.TriggerType,
+	)
+
+	// Geen connected_account_id; de eigenaar is changed_by van versie 1
+	mockPool.ExpectQuery(`^WITH created AS \( INSERT INTO automation_rules \( user_id, account_selector, .* 'create', user_id`).
+		WithArgs(
+			params.UserID, params.AccountSelector, params.Name,
+			params.TriggerConditions, params.ActionParams, params.Schedule, params.ActionType, params.TriggerType,
+		).
+		WillReturnRows(rows)
+
+	rule, err := store.CreateUserRule(ctx, params)
+
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Nil, rule.ConnectedAccountID)
+	assert.True(t, rule.IsUserRule())
+	assert.Equal(t, domain.AccountSelectorAll, rule.AccountSelector.Mode)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRuleStore_GetUserRules(t *testing.T) {
+	store, mockPool := setupRuleStore(t)
+	defer mockPool.Close()
+
+	ctx := context.Background()
+	userID := uuid.New()
+	selector := &domain.AccountSelector{Mode: domain.AccountSelectorProvider, Provider: domain.ProviderGoogle}
+
+	rows := pgxmock.NewRows(ruleColumns).AddRow(
+		uuid.New(), nil, "Google rule", true,
+		json.RawMessage(`{}`), json.RawMessage(`{}`), time.Now(), time.Now(), 1, nil, 0,
+		domain.CalendarActionCreateReminder, &userID, selector, domain.CalendarTriggerEventMatch,
+	)
+
+	mockPool.ExpectQuery(`^SELECT id, connected_account_id.* FROM automation_rules WHERE user_id = \$1`).
+		WithArgs(userID).
+		WillReturnRows(rows)
+
+	rules, err := store.GetUserRules(ctx, userID)
+
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, selector, rules[0].AccountSelector)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRuleStore_UpdateRuleAccountSelector(t *testing.T) {
+	store, mockPool := setupRuleStore(t)
+	defer mockPool.Close()
+
+	ctx := context.Background()
+	ruleID := uuid.New()
+	userID := uuid.New()
+	selector := domain.AccountSelector{Mode: domain.AccountSelectorAccounts, AccountIDs: []uuid.UUID{uuid.New()}}
+
+	rows := pgxmock.NewRows(ruleColumns).AddRow(
+		ruleID, nil, "Rule", true,
+		json.RawMessage(`{}`), json.RawMessage(`{}`), time.Now(), time.Now(), 1, nil, 0,
+		domain.CalendarActionCreateReminder, &userID, &selector, domain.CalendarTriggerEventMatch,
+	)
+
+	// Alleen user-level rules hebben een selector; een nieuwe selector wordt een versie
+	mockPool.ExpectQuery(`^WITH updated AS \( UPDATE automation_rules SET account_selector = \$2, version = version \+ 1.* WHERE id = \$1 AND user_id IS NOT NULL.* INSERT INTO rule_versions .* 'update', \$3::uuid.*'account_selector', account_selector`).
+		WithArgs(ruleID, selector, userID).
+		WillReturnRows(rows)
+
+	rule, err := store.UpdateRuleAccountSelector(ctx, ruleID, selector, userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, selector.AccountIDs, rule.AccountSelector.AccountIDs)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRuleStore_GetRulesForHook(t *testing.T) {
+	store, mockPool := setupRuleStore(t)
+	defer mockPool.Close()
+
+	ctx := context.Background()
+	hookID := uuid.New()
+	userID := uuid.New()
+	conditions := json.RawMessage(`{"hook_id":"` + hookID.String() + `"}`)
+
+	rows := pgxmock.NewRows(ruleColumns).AddRow(
+		uuid.New(), nil, "Release in agenda", true,
+		conditions, json.RawMessage(`{}`), time.Now(), time.Now(), 1, nil, 0,
+		domain.HookActionCreateEvent, &userID, &domain.AccountSelector{Mode: domain.AccountSelectorAll},
+		domain.CalendarTriggerHookReceived,
+	)
+
+	// Alleen actieve rules van de eigenaar van de hook
+	mockPool.ExpectQuery(`^SELECT r.id, .* FROM automation_rules r JOIN inbound_hooks h ON h.id = \$1 .* WHERE r.trigger_type = 'hook_received' AND r.trigger_conditions->>'hook_id' = \$1::text AND r.is_active AND COALESCE\(r.user_id, ca.user_id\) = h.user_id`).
+		WithArgs(hookID).
+		WillReturnRows(rows)
+
+	rules, err := store.GetRulesForHook(ctx, hookID)
+
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, domain.CalendarTriggerHookReceived, rules[0].Trigger())
 	assert.NoError(t, mockPool.ExpectationsWereMet())
 }
@@ -0,0 +1,2 @@
+// Package rulebundle imports sets of calendar and Gmail rules in a single statement.
+package rulebundle
@@ -0,0 +1,135 @@
+package rulebundle
+
+import (
+	"context"
+	"encoding/json"
+
+	"agenda-automator-api/internal/database"
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store/gmail"
+	"agenda-automator-api/internal/store/rule"
+
+	"github.com/google/uuid"
+)
+
+// RuleBundleStorer defines the interface for rule bundle store operations
+type RuleBundleStorer interface {
+	ImportRules(ctx context.Context, arg ImportRulesParams) (ImportRulesResult, error)
+}
+
+// ImportCalendarRule is één calendar rule voor ImportRules. De JSON namen zijn de kolommen
+// waar jsonb_to_recordset ze op leest.
+type ImportCalendarRule struct {
+	ConnectedAccountID uuid.UUID            `json:"connected_account_id"`
+	Name               string               `json:"name"`
+	IsActive           bool                 `json:"is_active"`
+	TriggerConditions  json.RawMessage      `json:"trigger_conditions"`
+	ActionType         string               `json:"action_type"`
+	ActionParams       json.RawMessage      `json:"action_params"`
+	Schedule           *domain.RuleSchedule `json:"schedule"`
+}
+
+// ImportGmailRule is één Gmail rule voor ImportRules.
+type ImportGmailRule struct {
+	ConnectedAccountID uuid.UUID                   `json:"connected_account_id"`
+	Name               string                      `json:"name"`
+	Description        *string                     `json:"description"`
+	IsActive           bool                        `json:"is_active"`
+	TriggerType        domain.GmailRuleTriggerType `json:"trigger_type"`
+	TriggerConditions  json.RawMessage             `json:"trigger_conditions"`
+	ActionType         domain.GmailRuleActionType  `json:"action_type"`
+	ActionParams       json.RawMessage             `json:"action_params"`
+	Priority           int                         `json:"priority"`
+	Schedule           *domain.RuleSchedule        `json:"schedule"`
+}
+
+// ImportRulesParams contains the rules to create in one import.
+type ImportRulesParams struct {
+	CalendarRules []ImportCalendarRule
+	GmailRules    []ImportGmailRule
+	CreatedBy     uuid.UUID // komt als changed_by in rule_versions
+}
+
+// ImportRulesResult telt de aangemaakte rules.
+type ImportRulesResult struct {
+	CalendarRules int `json:"calendar_rules"`
+	GmailRules    int `json:"gmail_rules"`
+}
+
+// RuleBundleStore handles rule bundle database operations
+type RuleBundleStore struct {
+	db database.Querier
+}
+
+// NewRuleBundleStore creates a new RuleBundleStore
+func NewRuleBundleStore(db database.Querier) RuleBundleStorer {
+	return &RuleBundleStore{db: db}
+}
+
+// ImportRules maakt alle rules en hun eerste versie aan in één statement, zodat een import
+// volledig slaagt of niets achterlaat.
+func (s *RuleBundleStore) ImportRules(ctx context.Context, arg ImportRulesParams) (ImportRulesResult, error) {
+	calendarRules, err := json.Marshal(nonNil(arg.CalendarRules))
+	if err != nil {
+		return ImportRulesResult{}, err
+	}
+	gmailRules, err := json.Marshal(nonNil(arg.GmailRules))
+	if err != nil {
+		return ImportRulesResult{}, err
+	}
+
+	query := `
+    WITH calendar_rules AS (
+        INSERT INTO automation_rules (
+            connected_account_id, name, is_active, trigger_conditions, action_type, action_params, schedule
+        )
+        SELECT r.connected_account_id, r.name, r.is_active, r.trigger_conditions,
+               COALESCE(NULLIF(r.action_type, ''), 'create_reminder'), r.action_params, r.schedule
+        FROM jsonb_to_recordset($1::jsonb) AS r(
+            connected_account_id uuid, name text, is_active boolean,
+            trigger_conditions jsonb, action_type text, action_params jsonb, schedule jsonb
+        )
+        RETURNING id, version, name, is_active, trigger_type, trigger_conditions, action_type, action_params,
+                  schedule, account_selector
+    ), calendar_versions AS (
+        INSERT INTO rule_versions (rule_type, rule_id, version, change_type, changed_by, snapshot)
+        SELECT 'calendar', id, version, 'create', $3::uuid, ` + rule.SnapshotSQL + `
+        FROM calendar_rules
+    ), gmail_rules AS (
+        INSERT INTO gmail_automation_rules (
+            connected_account_id, name, description, is_active, trigger_type,
+            trigger_conditions, action_type, action_params, priority, schedule
+        )
+        SELECT r.connected_account_id, r.name, r.description, r.is_active, r.trigger_type,
+               r.trigger_conditions, r.action_type, r.action_params, r.priority, r.schedule
+        FROM jsonb_to_recordset($2::jsonb) AS r(
+            connected_account_id uuid, name text, description text, is_active boolean, trigger_type text,
+            trigger_conditions jsonb, action_type text, action_params jsonb, priority integer, schedule jsonb
+        )
+        RETURNING id, version, name, description, is_active, trigger_type,
+                  trigger_conditions, action_type, action_params, schedule, account_selector
+    ), gmail_versions AS (
+        INSERT INTO rule_versions (rule_type, rule_id, version, change_type, changed_by, snapshot)
+        SELECT 'gmail', id, version, 'create', $3::uuid, ` + gmail.RuleSnapshotSQL + `
+        FROM gmail_rules
+    )
+    SELECT (SELECT count(*) FROM calendar_rules), (SELECT count(*) FROM gmail_rules);
+    `
+
+	var result ImportRulesResult
+	err = s.db.QueryRow(ctx, query, calendarRules, gmailRules, arg.CreatedBy).
+		Scan(&result.CalendarRules, &result.GmailRules)
+	if err != nil {
+		return ImportRulesResult{}, err
+	}
+
+	return result, nil
+}
+
+// nonNil zorgt dat een lege lijst als [] en niet als null naar jsonb_to_recordset gaat.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
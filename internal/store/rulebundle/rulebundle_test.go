@@ -0,0 +1,71 @@
+package rulebundle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupRuleBundleStore is een helper die een RuleBundleStore en een mock pool aanmaakt.
+func setupRuleBundleStore(t *testing.T) (RuleBundleStorer, pgxmock.PgxPoolIface) {
+	t.Helper()
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	return NewRuleBundleStore(mockPool), mockPool
+}
+
+func TestRuleBundleStore_ImportRules(t *testing.T) {
+	store, mockPool := setupRuleBundleStore(t)
+	defer mockPool.Close()
+
+	accountID := uuid.New()
+	userID := uuid.New()
+	arg := ImportRulesParams{
+		GmailRules: []ImportGmailRule{{
+			ConnectedAccountID: accountID,
+			Name:               "Nieuwsbrieven",
+			IsActive:           true,
+			TriggerType:        domain.GmailTriggerSenderMatch,
+			TriggerConditions:  json.RawMessage(`{"sender_pattern":"news@example.com"}`),
+			ActionType:         domain.GmailActionArchive,
+			ActionParams:       json.RawMessage(`{}`),
+			Priority:           10,
+		}},
+		CreatedBy: userID,
+	}
+	gmailRules, err := json.Marshal(arg.GmailRules)
+	require.NoError(t, err)
+
+	mockPool.ExpectQuery(`WITH calendar_rules AS .* jsonb_to_recordset\(\$1::jsonb\).* 'gmail', id, version, 'create'`).
+		WithArgs([]byte(`[]`), gmailRules, userID).
+		WillReturnRows(pgxmock.NewRows([]string{"calendar_rules", "gmail_rules"}).AddRow(0, 1))
+
+	result, err := store.ImportRules(context.Background(), arg)
+
+	require.NoError(t, err)
+	assert.Equal(t, ImportRulesResult{CalendarRules: 0, GmailRules: 1}, result)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRuleBundleStore_ImportRules_Error(t *testing.T) {
+	store, mockPool := setupRuleBundleStore(t)
+	defer mockPool.Close()
+
+	mockPool.ExpectQuery(`WITH calendar_rules AS`).
+		WithArgs([]byte(`[]`), []byte(`[]`), uuid.Nil).
+		WillReturnError(errors.New("check constraint violated"))
+
+	_, err := store.ImportRules(context.Background(), ImportRulesParams{})
+
+	assert.ErrorContains(t, err, "check constraint violated")
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
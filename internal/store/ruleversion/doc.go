@@ -0,0 +1,2 @@
+// Package ruleversion reads the version history of calendar and Gmail rules.
+package ruleversion
@@ -0,0 +1,114 @@
+package ruleversion
+
+import (
+	"context"
+	"errors"
+
+	"agenda-automator-api/internal/database"
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// RuleVersionStorer defines the interface for rule history store operations.
+// Versies worden geschreven door de rule en Gmail stores, in hetzelfde statement als de wijziging.
+type RuleVersionStorer interface {
+	GetRuleVersions(ctx context.Context, ruleType domain.RuleType, ruleID uuid.UUID) ([]domain.RuleVersion, error)
+	GetRuleVersion(
+		ctx context.Context,
+		ruleType domain.RuleType,
+		ruleID uuid.UUID,
+		version int,
+	) (domain.RuleVersion, error)
+}
+
+// RuleVersionStore handles rule history database operations
+type RuleVersionStore struct {
+	db database.Querier
+}
+
+// NewRuleVersionStore creates a new RuleVersionStore
+func NewRuleVersionStore(db database.Querier) RuleVersionStorer {
+	return &RuleVersionStore{db: db}
+}
+
+const ruleVersionColumns = `id, rule_type, rule_id, version, change_type, changed_by,
+           snapshot, diff, restored_from, created_at`
+
+// scanRuleVersion scans a database row into a RuleVersion
+func scanRuleVersion(row pgx.Row) (domain.RuleVersion, error) {
+	var v domain.RuleVersion
+	err := row.Scan(
+		&v.ID,
+		&v.RuleType,
+		&v.RuleID,
+		&v.Version,
+		&v.ChangeType,
+		&v.ChangedBy,
+		&v.Snapshot,
+		&v.Diff,
+		&v.RestoredFrom,
+		&v.CreatedAt,
+	)
+	return v, err
+}
+
+// GetRuleVersions haalt de geschiedenis van een rule op, nieuwste versie eerst.
+func (s *RuleVersionStore) GetRuleVersions(
+	ctx context.Context,
+	ruleType domain.RuleType,
+	ruleID uuid.UUID,
+) ([]domain.RuleVersion, error) {
+	query := `
+    SELECT ` + ruleVersionColumns + `
+    FROM rule_versions
+    WHERE rule_type = $1 AND rule_id = $2
+    ORDER BY version DESC;
+    `
+
+	rows, err := s.db.Query(ctx, query, ruleType, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []domain.RuleVersion
+	for rows.Next() {
+		v, err := scanRuleVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// GetRuleVersion haalt één versie van een rule op.
+func (s *RuleVersionStore) GetRuleVersion(
+	ctx context.Context,
+	ruleType domain.RuleType,
+	ruleID uuid.UUID,
+	version int,
+) (domain.RuleVersion, error) {
+	query := `
+    SELECT ` + ruleVersionColumns + `
+    FROM rule_versions
+    WHERE rule_type = $1 AND rule_id = $2 AND version = $3;
+    `
+
+	v, err := scanRuleVersion(s.db.QueryRow(ctx, query, ruleType, ruleID, version))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.RuleVersion{}, errors.New("rule version not found")
+		}
+		return domain.RuleVersion{}, err
+	}
+
+	return v, nil
+}
@@ -0,0 +1,119 @@
+package ruleversion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupRuleVersionStore is een helper die een RuleVersionStore en een mock pool aanmaakt.
+func setupRuleVersionStore(t *testing.T) (RuleVersionStorer, pgxmock.PgxPoolIface) {
+	t.Helper()
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	return NewRuleVersionStore(mockPool), mockPool
+}
+
+var ruleVersionColumnNames = []string{
+	"id", "rule_type", "rule_id", "version", "change_type", "changed_by",
+	"snapshot", "diff", "restored_from", "created_at",
+}
+
+func TestRuleVersionStore_GetRuleVersions(t *testing.T) {
+	store, mockPool := setupRuleVersionStore(t)
+	defer mockPool.Close()
+
+	ctx := context.Background()
+	ruleID := uuid.New()
+	userID := uuid.New()
+	restoredFrom := 1
+
+	rows := pgxmock.NewRows(ruleVersionColumnNames).
+		AddRow(int64(3), domain.RuleTypeCalendar, ruleID, 3, domain.RuleChangeRestore, &userID,
+			json.RawMessage(`{"name":"Old"}`), json.RawMessage(`{"name":{"old":"New","new":"Old"}}`),
+			&restoredFrom, time.Now()).
+		AddRow(int64(1), domain.RuleTypeCalendar, ruleID, 1, domain.RuleChangeCreate, nil,
+			json.RawMessage(`{"name":"Old"}`), nil, nil, time.Now())
+
+	mockPool.ExpectQuery(`SELECT .* FROM rule_versions WHERE rule_type = \$1 AND rule_id = \$2 ORDER BY version DESC`).
+		WithArgs(domain.RuleTypeCalendar, ruleID).
+		WillReturnRows(rows)
+
+	versions, err := store.GetRuleVersions(ctx, domain.RuleTypeCalendar, ruleID)
+
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, 3, versions[0].Version)
+	assert.Equal(t, domain.RuleChangeRestore, versions[0].ChangeType)
+	assert.Equal(t, &userID, versions[0].ChangedBy)
+	assert.Equal(t, 1, *versions[0].RestoredFrom)
+	assert.Nil(t, versions[1].ChangedBy)
+	assert.Nil(t, versions[1].Diff)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRuleVersionStore_GetRuleVersions_Error(t *testing.T) {
+	store, mockPool := setupRuleVersionStore(t)
+	defer mockPool.Close()
+
+	ruleID := uuid.New()
+	mockPool.ExpectQuery("SELECT .* FROM rule_versions").
+		WithArgs(domain.RuleTypeGmail, ruleID).
+		WillReturnError(errors.New("db down"))
+
+	_, err := store.GetRuleVersions(context.Background(), domain.RuleTypeGmail, ruleID)
+
+	assert.EqualError(t, err, "db down")
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRuleVersionStore_GetRuleVersion(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		store, mockPool := setupRuleVersionStore(t)
+		defer mockPool.Close()
+
+		ruleID := uuid.New()
+		rows := pgxmock.NewRows(ruleVersionColumnNames).AddRow(
+			int64(7), domain.RuleTypeGmail, ruleID, 2, domain.RuleChangeToggle, nil,
+			json.RawMessage(`{"is_active":false}`), json.RawMessage(`{"is_active":{"old":true,"new":false}}`),
+			nil, time.Now(),
+		)
+		mockPool.ExpectQuery(`SELECT .* FROM rule_versions WHERE rule_type = \$1 AND rule_id = \$2 AND version = \$3`).
+			WithArgs(domain.RuleTypeGmail, ruleID, 2).
+			WillReturnRows(rows)
+
+		v, err := store.GetRuleVersion(context.Background(), domain.RuleTypeGmail, ruleID, 2)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), v.ID)
+		assert.Equal(t, domain.RuleChangeToggle, v.ChangeType)
+		assert.JSONEq(t, `{"is_active":false}`, string(v.Snapshot))
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		store, mockPool := setupRuleVersionStore(t)
+		defer mockPool.Close()
+
+		ruleID := uuid.New()
+		mockPool.ExpectQuery("SELECT .* FROM rule_versions").
+			WithArgs(domain.RuleTypeCalendar, ruleID, 9).
+			WillReturnError(pgx.ErrNoRows)
+
+		_, err := store.GetRuleVersion(context.Background(), domain.RuleTypeCalendar, ruleID, 9)
+
+		assert.EqualError(t, err, "rule version not found")
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,2 @@
+// Package run stores manual processing runs started through the API, with their status and results.
+package run
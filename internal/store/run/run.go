@@ -0,0 +1,175 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"agenda-automator-api/internal/database"
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrProcessingRunNotFound is returned when a run does not exist or belongs to another user.
+var ErrProcessingRunNotFound = errors.New("processing run not found")
+
+// RunStorer defines the interface for processing run store operations
+type RunStorer interface {
+	CreateProcessingRun(ctx context.Context, arg CreateProcessingRunParams) (domain.ProcessingRun, error)
+	GetProcessingRun(ctx context.Context, runID, userID uuid.UUID) (domain.ProcessingRun, error)
+	StartProcessingRun(ctx context.Context, runID uuid.UUID) error
+	FinishProcessingRun(
+		ctx context.Context,
+		runID uuid.UUID,
+		status domain.ProcessingRunStatus,
+		errorMessage *string,
+	) (domain.ProcessingRun, error)
+	FailUnfinishedProcessingRuns(ctx context.Context, errorMessage string) (int64, error)
+}
+
+// CreateProcessingRunParams contains the target of a new run: an account, a rule, or both for a
+// rule of a single account. RuleID is a calendar rule, GmailRuleID a Gmail rule; at most one is set.
+type CreateProcessingRunParams struct {
+	UserID             uuid.UUID
+	ConnectedAccountID *uuid.UUID
+	RuleID             *uuid.UUID
+	GmailRuleID        *uuid.UUID
+}
+
+// RunStore handles processing run database operations
+type RunStore struct {
+	db database.Querier
+}
+
+// NewRunStore creates a new RunStore
+func NewRunStore(db database.Querier) RunStorer {
+	return &RunStore{db: db}
+}
+
+const runColumns = `id, user_id, connected_account_id, rule_id, gmail_rule_id, status, error_message,
+    results, created_at, started_at, finished_at`
+
+// scanRun scans a database row into a ProcessingRun
+func scanRun(row pgx.Row) (domain.ProcessingRun, error) {
+	var r domain.ProcessingRun
+	var results []byte
+	if err := row.Scan(
+		&r.ID,
+		&r.UserID,
+		&r.ConnectedAccountID,
+		&r.RuleID,
+		&r.GmailRuleID,
+		&r.Status,
+		&r.ErrorMessage,
+		&results,
+		&r.CreatedAt,
+		&r.StartedAt,
+		&r.FinishedAt,
+	); err != nil {
+		return r, err
+	}
+	if len(results) > 0 {
+		r.Results = &domain.RunResults{}
+		if err := json.Unmarshal(results, r.Results); err != nil {
+			return r, err
+		}
+	}
+	return r, nil
+}
+
+// CreateProcessingRun slaat een nieuwe run op in de status queued.
+func (s *RunStore) CreateProcessingRun(
+	ctx context.Context,
+	arg CreateProcessingRunParams,
+) (domain.ProcessingRun, error) {
+	query := `
+    INSERT INTO processing_runs (user_id, connected_account_id, rule_id, gmail_rule_id, status)
+    VALUES ($1, $2, $3, $4, 'queued')
+    RETURNING ` + runColumns + `;
+    `
+	return scanRun(s.db.QueryRow(ctx, query, arg.UserID, arg.ConnectedAccountID, arg.RuleID, arg.GmailRuleID))
+}
+
+// GetProcessingRun haalt een run van de gebruiker op.
+func (s *RunStore) GetProcessingRun(ctx context.Context, runID, userID uuid.UUID) (domain.ProcessingRun, error) {
+	query := `
+    SELECT ` + runColumns + `
+    FROM processing_runs
+    WHERE id = $1 AND user_id = $2;
+    `
+
+	r, err := scanRun(s.db.QueryRow(ctx, query, runID, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ProcessingRun{}, ErrProcessingRunNotFound
+		}
+		return domain.ProcessingRun{}, err
+	}
+
+	return r, nil
+}
+
+// StartProcessingRun zet een run op running. started_at blijft staan als de run al gestart was.
+func (s *RunStore) StartProcessingRun(ctx context.Context, runID uuid.UUID) error {
+	query := `
+    UPDATE processing_runs
+    SET status = 'running', started_at = COALESCE(started_at, now())
+    WHERE id = $1;
+    `
+	_, err := s.db.Exec(ctx, query, runID)
+	return err
+}
+
+// FinishProcessingRun sluit een run af. De resultaten zijn de automation logs die de worker met het
+// ID van de run schreef. Een run die nooit gestart is heeft geen resultaten.
+func (s *RunStore) FinishProcessingRun(
+	ctx context.Context,
+	runID uuid.UUID,
+	status domain.ProcessingRunStatus,
+	errorMessage *string,
+) (domain.ProcessingRun, error) {
+	query := `
+    UPDATE processing_runs r
+    SET status = $2,
+        error_message = $3,
+        finished_at = now(),
+        results = CASE WHEN r.started_at IS NULL THEN NULL ELSE (
+            SELECT jsonb_build_object(
+                'success', count(*) FILTER (WHERE l.status = 'success'),
+                'failure', count(*) FILTER (WHERE l.status = 'failure'),
+                'skipped', count(*) FILTER (WHERE l.status = 'skipped')
+            )
+            FROM automation_logs l
+            WHERE l.run_id = r.id
+        ) END
+    WHERE r.id = $1
+    RETURNING ` + runColumns + `;
+    `
+
+	r, err := scanRun(s.db.QueryRow(ctx, query, runID, status, errorMessage))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ProcessingRun{}, ErrProcessingRunNotFound
+		}
+		return domain.ProcessingRun{}, err
+	}
+
+	return r, nil
+}
+
+// FailUnfinishedProcessingRuns zet runs die nog queued of running zijn op failed. De queue van de
+// worker leeft in het geheugen, dus na een herstart worden die runs nooit meer afgemaakt.
+func (s *RunStore) FailUnfinishedProcessingRuns(ctx context.Context, errorMessage string) (int64, error) {
+	query := `
+    UPDATE processing_runs
+    SET status = 'failed', error_message = $1, finished_at = now()
+    WHERE status IN ('queued', 'running');
+    `
+	tag, err := s.db.Exec(ctx, query, errorMessage)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
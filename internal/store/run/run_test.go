@@ -0,0 +1,148 @@
+package run
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupRunStore is een helper die een RunStore en een mock pool aanmaakt.
+func setupRunStore(t *testing.T) (RunStorer, pgxmock.PgxPoolIface) {
+	t.Helper()
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	return NewRunStore(mockPool), mockPool
+}
+
+var runColumnNames = []string{
+	"id", "user_id", "connected_account_id", "rule_id", "gmail_rule_id", "status", "error_message",
+	"results", "created_at", "started_at", "finished_at",
+}
+
+func TestRunStore_CreateProcessingRun(t *testing.T) {
+	store, mockPool := setupRunStore(t)
+	defer mockPool.Close()
+
+	userID := uuid.New()
+	accountID := uuid.New()
+	params := CreateProcessingRunParams{UserID: userID, ConnectedAccountID: &accountID}
+
+	mockPool.ExpectQuery(`INSERT INTO processing_runs \(user_id, connected_account_id, rule_id, gmail_rule_id, status\) VALUES \(\$1, \$2, \$3, \$4, 'queued'\)`).
+		WithArgs(userID, &accountID, (*uuid.UUID)(nil), (*uuid.UUID)(nil)).
+		WillReturnRows(pgxmock.NewRows(runColumnNames).AddRow(
+			uuid.New(), userID, &accountID, nil, nil, domain.RunQueued, nil, nil, time.Now(), nil, nil,
+		))
+
+	run, err := store.CreateProcessingRun(context.Background(), params)
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.RunQueued, run.Status)
+	assert.Equal(t, accountID, *run.ConnectedAccountID)
+	assert.Nil(t, run.RuleID)
+	assert.Nil(t, run.Results)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRunStore_GetProcessingRun(t *testing.T) {
+	store, mockPool := setupRunStore(t)
+	defer mockPool.Close()
+
+	runID := uuid.New()
+	userID := uuid.New()
+	ruleID := uuid.New()
+	started := time.Now().Add(-time.Minute)
+	finished := time.Now()
+	mockPool.ExpectQuery(`SELECT .* FROM processing_runs WHERE id = \$1 AND user_id = \$2`).
+		WithArgs(runID, userID).
+		WillReturnRows(pgxmock.NewRows(runColumnNames).AddRow(
+			runID, userID, nil, &ruleID, nil, domain.RunCompleted, nil,
+			[]byte(`{"success": 2, "failure": 0, "skipped": 1}`), time.Now(), &started, &finished,
+		))
+
+	run, err := store.GetProcessingRun(context.Background(), runID, userID)
+
+	require.NoError(t, err)
+	require.NotNil(t, run.Results)
+	assert.Equal(t, domain.RunResults{Success: 2, Skipped: 1}, *run.Results)
+	assert.Equal(t, ruleID, *run.RuleID)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRunStore_GetProcessingRun_NotFound(t *testing.T) {
+	store, mockPool := setupRunStore(t)
+	defer mockPool.Close()
+
+	runID := uuid.New()
+	userID := uuid.New()
+	mockPool.ExpectQuery(`SELECT .* FROM processing_runs`).
+		WithArgs(runID, userID).
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err := store.GetProcessingRun(context.Background(), runID, userID)
+
+	assert.ErrorIs(t, err, ErrProcessingRunNotFound)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRunStore_StartProcessingRun(t *testing.T) {
+	store, mockPool := setupRunStore(t)
+	defer mockPool.Close()
+
+	runID := uuid.New()
+	// Een tweede start (volgend account van een user-level rule) houdt de oorspronkelijke started_at
+	mockPool.ExpectExec(`UPDATE processing_runs SET status = 'running', started_at = COALESCE\(started_at, now\(\)\) WHERE id = \$1`).
+		WithArgs(runID).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	assert.NoError(t, store.StartProcessingRun(context.Background(), runID))
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRunStore_FinishProcessingRun(t *testing.T) {
+	store, mockPool := setupRunStore(t)
+	defer mockPool.Close()
+
+	runID := uuid.New()
+	accountID := uuid.New()
+	message := "account is not active"
+	finished := time.Now()
+
+	// De resultaten tellen alleen de logs met het ID van de run
+	mockPool.ExpectQuery(`(?s)UPDATE processing_runs r SET status = \$2,\s+error_message = \$3.*FROM automation_logs l\s+WHERE l.run_id = r.id.*WHERE r.id = \$1 RETURNING`).
+		WithArgs(runID, domain.RunFailed, &message).
+		WillReturnRows(pgxmock.NewRows(runColumnNames).AddRow(
+			runID, uuid.New(), &accountID, nil, nil, domain.RunFailed, &message, nil, time.Now(), nil, &finished,
+		))
+
+	run, err := store.FinishProcessingRun(context.Background(), runID, domain.RunFailed, &message)
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.RunFailed, run.Status)
+	assert.Equal(t, message, *run.ErrorMessage)
+	assert.Nil(t, run.Results) // Nooit gestart
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRunStore_FailUnfinishedProcessingRuns(t *testing.T) {
+	store, mockPool := setupRunStore(t)
+	defer mockPool.Close()
+
+	mockPool.ExpectExec(`UPDATE processing_runs SET status = 'failed', error_message = \$1, finished_at = now\(\) WHERE status IN \('queued', 'running'\)`).
+		WithArgs("worker restarted").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 3))
+
+	n, err := store.FailUnfinishedProcessingRuns(context.Background(), "worker restarted")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
@@ -7,10 +7,17 @@ import (
 	"agenda-automator-api/internal/database"
 	"agenda-automator-api/internal/domain"
 	"agenda-automator-api/internal/store/account"
+	"agenda-automator-api/internal/store/channel"
 	"agenda-automator-api/internal/store/gmail"
 	"agenda-automator-api/internal/store/log"
+	"agenda-automator-api/internal/store/notification"
+	"agenda-automator-api/internal/store/ratelimit"
 	"agenda-automator-api/internal/store/rule"
+	"agenda-automator-api/internal/store/rulebundle"
+	"agenda-automator-api/internal/store/ruleversion"
+	"agenda-automator-api/internal/store/run"
 	"agenda-automator-api/internal/store/user"
+	"agenda-automator-api/internal/store/webhook"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -19,21 +26,54 @@ import (
 
 // Re-export parameter structs for backward compatibility
 type (
-	UpsertConnectedAccountParams      = account.UpsertConnectedAccountParams
-	UpdateAccountTokensParams         = account.UpdateAccountTokensParams
-	UpdateConnectedAccountTokenParams = account.UpdateConnectedAccountTokenParams
-	CreateAutomationRuleParams        = rule.CreateAutomationRuleParams
-	UpdateRuleParams                  = rule.UpdateRuleParams
-	CreateLogParams                   = log.CreateLogParams
-	CreateGmailAutomationRuleParams   = gmail.CreateGmailAutomationRuleParams
-	UpdateGmailRuleParams             = gmail.UpdateGmailRuleParams
-	StoreGmailMessageParams           = gmail.StoreGmailMessageParams
-	StoreGmailThreadParams            = gmail.StoreGmailThreadParams
+	UpsertConnectedAccountParams       = account.UpsertConnectedAccountParams
+	UpdateAccountTokensParams          = account.UpdateAccountTokensParams
+	UpdateConnectedAccountTokenParams  = account.UpdateConnectedAccountTokenParams
+	CreateAutomationRuleParams         = rule.CreateAutomationRuleParams
+	CreateUserRuleParams               = rule.CreateUserRuleParams
+	UpdateRuleParams                   = rule.UpdateRuleParams
+	CreateLogParams                    = log.CreateLogParams
+	CreateGmailAutomationRuleParams    = gmail.CreateGmailAutomationRuleParams
+	CreateUserGmailRuleParams          = gmail.CreateUserGmailRuleParams
+	UpdateGmailRuleParams              = gmail.UpdateGmailRuleParams
+	StoreGmailMessageParams            = gmail.StoreGmailMessageParams
+	StoreGmailThreadParams             = gmail.StoreGmailThreadParams
+	UpdateGmailMessagesCacheParams     = gmail.UpdateGmailMessagesCacheParams
+	UpsertCalendarWatchChannelParams   = channel.UpsertCalendarWatchChannelParams
+	CreateGmailSnoozeParams            = gmail.CreateGmailSnoozeParams
+	CreateGmailScheduledSendParams     = gmail.CreateGmailScheduledSendParams
+	CreateGmailUnsubscribeParams       = gmail.CreateGmailUnsubscribeParams
+	UpsertGmailForwardingAddressParams = gmail.UpsertGmailForwardingAddressParams
+	UpsertGmailDraftParams             = gmail.UpsertGmailDraftParams
+	ImportRulesParams                  = rulebundle.ImportRulesParams
+	ImportRulesResult                  = rulebundle.ImportRulesResult
+	ImportCalendarRule                 = rulebundle.ImportCalendarRule
+	ImportGmailRule                    = rulebundle.ImportGmailRule
+	CreateNotificationParams           = notification.CreateNotificationParams
+	CreateInboundHookParams            = webhook.CreateInboundHookParams
+	CreateHookDeliveryParams           = webhook.CreateHookDeliveryParams
+	FinishHookDeliveryParams           = webhook.FinishHookDeliveryParams
+	CreateProcessingRunParams          = run.CreateProcessingRunParams
 )
 
 // ErrTokenRevoked re-export error for backward compatibility
 var ErrTokenRevoked = account.ErrTokenRevoked
 
+// ErrNotificationNotFound re-export error
+var ErrNotificationNotFound = notification.ErrNotificationNotFound
+
+// ErrInboundHookNotFound re-export error
+var ErrInboundHookNotFound = webhook.ErrInboundHookNotFound
+
+// ErrProcessingRunNotFound re-export error
+var ErrProcessingRunNotFound = run.ErrProcessingRunNotFound
+
+// ErrForwardingVerificationLocked re-export error
+var ErrForwardingVerificationLocked = gmail.ErrForwardingVerificationLocked
+
+// ErrForwardingVerificationCooldown re-export error
+var ErrForwardingVerificationCooldown = gmail.ErrForwardingVerificationCooldown
+
 // Storer is de interface voor al onze database-interactions.
 type Storer interface {
 	CreateUser(ctx context.Context, email, name string) (domain.User, error)
@@ -53,14 +93,92 @@ type Storer interface {
 	CreateAutomationRule(ctx context.Context, arg CreateAutomationRuleParams) (domain.AutomationRule, error)
 	GetRuleByID(ctx context.Context, ruleID uuid.UUID) (domain.AutomationRule, error)
 	GetRulesForAccount(ctx context.Context, accountID uuid.UUID) ([]domain.AutomationRule, error)
+	CreateUserRule(ctx context.Context, arg CreateUserRuleParams) (domain.AutomationRule, error)
+	GetUserRules(ctx context.Context, userID uuid.UUID) ([]domain.AutomationRule, error)
+	GetRulesForHook(ctx context.Context, hookID uuid.UUID) ([]domain.AutomationRule, error)
+	UpdateRuleAccountSelector(
+		ctx context.Context,
+		ruleID uuid.UUID,
+		selector domain.AccountSelector,
+		changedBy uuid.UUID,
+	) (domain.AutomationRule, error)
 	UpdateRule(ctx context.Context, arg UpdateRuleParams) (domain.AutomationRule, error)
-	ToggleRuleStatus(ctx context.Context, ruleID uuid.UUID) (domain.AutomationRule, error)
+	ToggleRuleStatus(ctx context.Context, ruleID uuid.UUID, changedBy uuid.UUID) (domain.AutomationRule, error)
+	RecordRuleExecution(ctx context.Context, ruleID uuid.UUID) (int, error)
+	ExpireRule(ctx context.Context, ruleID uuid.UUID) (domain.AutomationRule, error)
+	PauseRule(ctx context.Context, ruleID uuid.UUID) (domain.AutomationRule, error)
 	DeleteRule(ctx context.Context, ruleID uuid.UUID) error
 	VerifyRuleOwnership(ctx context.Context, ruleID uuid.UUID, userID uuid.UUID) error
 
+	// Rule geschiedenis (calendar en Gmail)
+	GetRuleVersions(ctx context.Context, ruleType domain.RuleType, ruleID uuid.UUID) ([]domain.RuleVersion, error)
+	GetRuleVersion(
+		ctx context.Context,
+		ruleType domain.RuleType,
+		ruleID uuid.UUID,
+		version int,
+	) (domain.RuleVersion, error)
+
+	// Import van rule bundels (calendar en Gmail)
+	ImportRules(ctx context.Context, arg ImportRulesParams) (ImportRulesResult, error)
+
+	// Uitvoeringslimieten van de worker (calendar en Gmail)
+	GetRuleExecutionCounts(ctx context.Context, ruleID, accountID uuid.UUID) (ruleHour, accountDay int, err error)
+	CountRuleExecution(
+		ctx context.Context,
+		ruleType domain.RuleType,
+		ruleID, accountID uuid.UUID,
+	) (ruleHour, accountDay int, err error)
+	GetBusiestRule(ctx context.Context, accountID uuid.UUID) (domain.RuleType, uuid.UUID, error)
+
+	// Meldingen voor de gebruiker
+	CreateNotification(ctx context.Context, arg CreateNotificationParams) (domain.Notification, error)
+	GetNotificationsForUser(
+		ctx context.Context,
+		userID uuid.UUID,
+		unreadOnly bool,
+		limit int,
+	) ([]domain.Notification, error)
+	MarkNotificationRead(ctx context.Context, notificationID, userID uuid.UUID) (domain.Notification, error)
+
+	// Geheim voor uitgaande webhooks
+	EnsureWebhookSecret(ctx context.Context, userID uuid.UUID, candidate string) (domain.WebhookSecret, error)
+	RotateWebhookSecret(ctx context.Context, userID uuid.UUID, secret string) (domain.WebhookSecret, error)
+
+	// Inbound hooks en hun leveringen
+	CreateInboundHook(ctx context.Context, arg CreateInboundHookParams) (domain.InboundHook, error)
+	GetInboundHooksForUser(ctx context.Context, userID uuid.UUID) ([]domain.InboundHook, error)
+	GetInboundHook(ctx context.Context, hookID, userID uuid.UUID) (domain.InboundHook, error)
+	GetInboundHookByTokenHash(ctx context.Context, tokenHash []byte) (domain.InboundHook, error)
+	RevokeInboundHook(ctx context.Context, hookID, userID uuid.UUID) (domain.InboundHook, error)
+	CreateHookDelivery(ctx context.Context, arg CreateHookDeliveryParams) (domain.HookDelivery, error)
+	FinishHookDelivery(ctx context.Context, arg FinishHookDeliveryParams) (domain.HookDelivery, error)
+	FailQueuedHookDeliveries(ctx context.Context, errorMessage string) (int64, error)
+	GetHookDeliveries(ctx context.Context, hookID uuid.UUID, limit int) ([]domain.HookDelivery, error)
+
+	// Handmatige runs ("run now") en hun resultaten
+	CreateProcessingRun(ctx context.Context, arg CreateProcessingRunParams) (domain.ProcessingRun, error)
+	GetProcessingRun(ctx context.Context, runID, userID uuid.UUID) (domain.ProcessingRun, error)
+	StartProcessingRun(ctx context.Context, runID uuid.UUID) error
+	FinishProcessingRun(
+		ctx context.Context,
+		runID uuid.UUID,
+		status domain.ProcessingRunStatus,
+		errorMessage *string,
+	) (domain.ProcessingRun, error)
+	FailUnfinishedProcessingRuns(ctx context.Context, errorMessage string) (int64, error)
+	GetLogsForRun(ctx context.Context, runID uuid.UUID, limit int) ([]domain.AutomationLog, error)
+
 	CreateAutomationLog(ctx context.Context, arg CreateLogParams) error
-	HasLogForTrigger(ctx context.Context, ruleID uuid.UUID, triggerEventID string) (bool, error)
+	HasLogForTrigger(
+		ctx context.Context,
+		ruleID, accountID uuid.UUID,
+		triggerEventID string,
+	) (bool, error)
+	HasLogForMessage(ctx context.Context, ruleID, accountID uuid.UUID, gmailMessageID string) (bool, error)
 	GetLogsForAccount(ctx context.Context, accountID uuid.UUID, limit int) ([]domain.AutomationLog, error)
+	GetRevertibleLogs(ctx context.Context, ruleID uuid.UUID, from, to time.Time) ([]domain.AutomationLog, error)
+	MarkLogsReverted(ctx context.Context, logIDs []int64) error
 
 	// Gecentraliseerde Token Logica
 	GetValidTokenForAccount(ctx context.Context, accountID uuid.UUID) (*oauth2.Token, error)
@@ -71,9 +189,27 @@ type Storer interface {
 	// Gmail-specific methods
 	CreateGmailAutomationRule(ctx context.Context, arg CreateGmailAutomationRuleParams) (domain.GmailAutomationRule, error)
 	GetGmailRulesForAccount(ctx context.Context, accountID uuid.UUID) ([]domain.GmailAutomationRule, error)
+	CreateUserGmailRule(ctx context.Context, arg CreateUserGmailRuleParams) (domain.GmailAutomationRule, error)
+	GetUserGmailRules(ctx context.Context, userID uuid.UUID) ([]domain.GmailAutomationRule, error)
+	UpdateGmailRuleAccountSelector(
+		ctx context.Context,
+		ruleID uuid.UUID,
+		selector domain.AccountSelector,
+		changedBy uuid.UUID,
+	) (domain.GmailAutomationRule, error)
 	UpdateGmailRule(ctx context.Context, arg UpdateGmailRuleParams) (domain.GmailAutomationRule, error)
 	DeleteGmailRule(ctx context.Context, ruleID uuid.UUID) error
-	ToggleGmailRuleStatus(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error)
+	ToggleGmailRuleStatus(
+		ctx context.Context,
+		ruleID uuid.UUID,
+		changedBy uuid.UUID,
+	) (domain.GmailAutomationRule, error)
+	RecordGmailRuleExecution(ctx context.Context, ruleID uuid.UUID) (int, error)
+	ExpireGmailRule(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error)
+	PauseGmailRule(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error)
+	GetGmailRuleByID(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error)
+	VerifyGmailRuleOwnership(ctx context.Context, ruleID uuid.UUID, userID uuid.UUID) error
+	ReorderGmailRules(ctx context.Context, accountID uuid.UUID, ruleIDs []uuid.UUID) error
 
 	// Gmail message storage
 	StoreGmailMessage(ctx context.Context, arg StoreGmailMessageParams) error
@@ -84,11 +220,75 @@ type Storer interface {
 		messageID string,
 		status domain.GmailMessageStatus,
 	) error
+	UpdateGmailMessagesCache(ctx context.Context, arg UpdateGmailMessagesCacheParams) (int64, error)
 	GetGmailMessagesForAccount(ctx context.Context, accountID uuid.UUID, limit int) ([]domain.GmailMessage, error)
 
 	// Gmail sync tracking
 	UpdateGmailSyncState(ctx context.Context, accountID uuid.UUID, historyID string, lastSync time.Time) error
 	GetGmailSyncState(ctx context.Context, accountID uuid.UUID) (historyID *string, lastSync *time.Time, err error)
+
+	// Gmail snoozes
+	CreateGmailSnooze(ctx context.Context, arg CreateGmailSnoozeParams) (domain.GmailSnooze, error)
+	GetGmailSnoozeByID(ctx context.Context, snoozeID uuid.UUID) (domain.GmailSnooze, error)
+	GetPendingGmailSnoozesForAccount(ctx context.Context, accountID uuid.UUID) ([]domain.GmailSnooze, error)
+	GetDueGmailSnoozes(ctx context.Context, now time.Time, limit int) ([]domain.GmailSnooze, error)
+	UpdateGmailSnoozeStatus(
+		ctx context.Context,
+		snoozeID uuid.UUID,
+		status domain.GmailSnoozeStatus,
+		errorMessage *string,
+	) error
+
+	// Gmail scheduled sends
+	CreateGmailScheduledSend(ctx context.Context, arg CreateGmailScheduledSendParams) (domain.GmailScheduledSend, error)
+	GetGmailScheduledSendByID(ctx context.Context, sendID uuid.UUID) (domain.GmailScheduledSend, error)
+	GetPendingGmailScheduledSendsForAccount(ctx context.Context, accountID uuid.UUID) ([]domain.GmailScheduledSend, error)
+	GetDueGmailScheduledSends(ctx context.Context, now time.Time, limit int) ([]domain.GmailScheduledSend, error)
+	UpdateGmailScheduledSend(
+		ctx context.Context,
+		sendID uuid.UUID,
+		message domain.GmailOutgoingMessage,
+		sendAt time.Time,
+	) (domain.GmailScheduledSend, error)
+	UpdateGmailScheduledSendStatus(
+		ctx context.Context,
+		sendID uuid.UUID,
+		status domain.GmailScheduledSendStatus,
+		gmailMessageID, lastError *string,
+	) error
+	RetryGmailScheduledSend(ctx context.Context, sendID uuid.UUID, lastError string, nextAttempt time.Time) error
+
+	// Gmail List-Unsubscribe
+	CreateGmailUnsubscribe(ctx context.Context, arg CreateGmailUnsubscribeParams) (domain.GmailUnsubscribe, error)
+	GetGmailNewsletterSenders(ctx context.Context, accountID uuid.UUID, limit int) ([]domain.GmailNewsletterSender, error)
+
+	// Gmail forwarding addresses
+	UpsertGmailForwardingAddress(
+		ctx context.Context,
+		arg UpsertGmailForwardingAddressParams,
+	) (domain.GmailForwardingAddress, error)
+	GetGmailForwardingAddressByID(ctx context.Context, addressID uuid.UUID) (domain.GmailForwardingAddress, error)
+	GetGmailForwardingAddressesForUser(ctx context.Context, userID uuid.UUID) ([]domain.GmailForwardingAddress, error)
+	UseGmailForwardingVerificationAttempt(ctx context.Context, addressID uuid.UUID, maxAttempts int) error
+	MarkGmailForwardingAddressVerified(ctx context.Context, addressID uuid.UUID) (domain.GmailForwardingAddress, error)
+	DeleteGmailForwardingAddress(ctx context.Context, addressID uuid.UUID) error
+
+	// Gmail drafts cache
+	UpsertGmailDraft(ctx context.Context, arg UpsertGmailDraftParams) (domain.GmailDraft, error)
+	GetGmailDraftByID(ctx context.Context, draftID uuid.UUID) (domain.GmailDraft, error)
+	GetGmailDraftsForAccount(ctx context.Context, accountID uuid.UUID) ([]domain.GmailDraft, error)
+	DeleteGmailDraft(ctx context.Context, draftID uuid.UUID) error
+	PruneGmailDrafts(ctx context.Context, accountID uuid.UUID, keepGmailDraftIDs []string) error
+
+	// Calendar push notification channels
+	UpsertCalendarWatchChannel(
+		ctx context.Context,
+		arg UpsertCalendarWatchChannelParams,
+	) (domain.CalendarWatchChannel, error)
+	GetCalendarWatchChannelByChannelID(ctx context.Context, channelID string) (domain.CalendarWatchChannel, error)
+	GetCalendarWatchChannelsForAccount(ctx context.Context, accountID uuid.UUID) ([]domain.CalendarWatchChannel, error)
+	GetCalendarWatchChannelsForInactiveAccounts(ctx context.Context) ([]domain.CalendarWatchChannel, error)
+	DeleteCalendarWatchChannel(ctx context.Context, channelID string) error
 }
 
 // DBStore implementeert de Storer interface.
@@ -98,6 +298,13 @@ type DBStore struct {
 	ruleStore    rule.RuleStorer
 	logStore     log.LogStorer     // <-- GEWIJZIGD (naar interface)
 	gmailStore   gmail.GmailStorer // <-- GEWIJZIGD (naar interface)
+	channelStore channel.ChannelStorer
+	versionStore ruleversion.RuleVersionStorer
+	bundleStore  rulebundle.RuleBundleStorer
+	limitStore   ratelimit.RateLimitStorer
+	noticeStore  notification.NotificationStorer
+	hookStore    webhook.WebhookStorer
+	runStore     run.RunStorer
 }
 
 // NewStore maakt een nieuwe DBStore
@@ -108,6 +315,13 @@ func NewStore(db database.Querier, oauthCfg *oauth2.Config, logger *zap.Logger)
 		ruleStore:    rule.NewRuleStore(db),
 		logStore:     log.NewLogStore(db),
 		gmailStore:   gmail.NewGmailStore(db, logger),
+		channelStore: channel.NewChannelStore(db),
+		versionStore: ruleversion.NewRuleVersionStore(db),
+		bundleStore:  rulebundle.NewRuleBundleStore(db),
+		limitStore:   ratelimit.NewRateLimitStore(db),
+		noticeStore:  notification.NewNotificationStore(db),
+		hookStore:    webhook.NewWebhookStore(db),
+		runStore:     run.NewRunStore(db),
 	}
 }
 
@@ -193,14 +407,58 @@ func (s *DBStore) GetRulesForAccount(ctx context.Context, accountID uuid.UUID) (
 	return s.ruleStore.GetRulesForAccount(ctx, accountID)
 }
 
+// CreateUserRule maakt een user-level rule aan.
+func (s *DBStore) CreateUserRule(ctx context.Context, arg CreateUserRuleParams) (domain.AutomationRule, error) {
+	return s.ruleStore.CreateUserRule(ctx, arg)
+}
+
+// GetUserRules haalt de user-level rules van een gebruiker op.
+func (s *DBStore) GetUserRules(ctx context.Context, userID uuid.UUID) ([]domain.AutomationRule, error) {
+	return s.ruleStore.GetUserRules(ctx, userID)
+}
+
+// GetRulesForHook haalt de actieve rules op die aan een inbound hook gebonden zijn.
+func (s *DBStore) GetRulesForHook(ctx context.Context, hookID uuid.UUID) ([]domain.AutomationRule, error) {
+	return s.ruleStore.GetRulesForHook(ctx, hookID)
+}
+
+// UpdateRuleAccountSelector vervangt de selector van een user-level rule.
+func (s *DBStore) UpdateRuleAccountSelector(
+	ctx context.Context,
+	ruleID uuid.UUID,
+	selector domain.AccountSelector,
+	changedBy uuid.UUID,
+) (domain.AutomationRule, error) {
+	return s.ruleStore.UpdateRuleAccountSelector(ctx, ruleID, selector, changedBy)
+}
+
 // UpdateRule werkt een bestaande regel bij.
 func (s *DBStore) UpdateRule(ctx context.Context, arg UpdateRuleParams) (domain.AutomationRule, error) {
 	return s.ruleStore.UpdateRule(ctx, arg)
 }
 
 // ToggleRuleStatus zet de 'is_active' boolean van een regel om.
-func (s *DBStore) ToggleRuleStatus(ctx context.Context, ruleID uuid.UUID) (domain.AutomationRule, error) {
-	return s.ruleStore.ToggleRuleStatus(ctx, ruleID)
+func (s *DBStore) ToggleRuleStatus(
+	ctx context.Context,
+	ruleID uuid.UUID,
+	changedBy uuid.UUID,
+) (domain.AutomationRule, error) {
+	return s.ruleStore.ToggleRuleStatus(ctx, ruleID, changedBy)
+}
+
+// RecordRuleExecution telt een geslaagde uitvoering van een regel.
+func (s *DBStore) RecordRuleExecution(ctx context.Context, ruleID uuid.UUID) (int, error) {
+	return s.ruleStore.RecordRuleExecution(ctx, ruleID)
+}
+
+// ExpireRule zet een regel uit waarvan het schedule verlopen is.
+func (s *DBStore) ExpireRule(ctx context.Context, ruleID uuid.UUID) (domain.AutomationRule, error) {
+	return s.ruleStore.ExpireRule(ctx, ruleID)
+}
+
+// PauseRule zet een regel uit die een uitvoeringslimiet overschreed.
+func (s *DBStore) PauseRule(ctx context.Context, ruleID uuid.UUID) (domain.AutomationRule, error) {
+	return s.ruleStore.PauseRule(ctx, ruleID)
 }
 
 // VerifyRuleOwnership controleert of een gebruiker de eigenaar is van de regel (via het account).
@@ -213,6 +471,174 @@ func (s *DBStore) DeleteRule(ctx context.Context, ruleID uuid.UUID) error {
 	return s.ruleStore.DeleteRule(ctx, ruleID)
 }
 
+// --- RULE VERSION FUNCTIES ---
+
+// GetRuleVersions haalt de geschiedenis van een rule op, nieuwste versie eerst.
+func (s *DBStore) GetRuleVersions(
+	ctx context.Context,
+	ruleType domain.RuleType,
+	ruleID uuid.UUID,
+) ([]domain.RuleVersion, error) {
+	return s.versionStore.GetRuleVersions(ctx, ruleType, ruleID)
+}
+
+// GetRuleVersion haalt één versie van een rule op.
+func (s *DBStore) GetRuleVersion(
+	ctx context.Context,
+	ruleType domain.RuleType,
+	ruleID uuid.UUID,
+	version int,
+) (domain.RuleVersion, error) {
+	return s.versionStore.GetRuleVersion(ctx, ruleType, ruleID, version)
+}
+
+// --- RULE BUNDLE FUNCTIES ---
+
+// ImportRules maakt de rules van een import in één statement aan.
+func (s *DBStore) ImportRules(ctx context.Context, arg ImportRulesParams) (ImportRulesResult, error) {
+	return s.bundleStore.ImportRules(ctx, arg)
+}
+
+// --- RATE LIMIT FUNCTIES ---
+
+// GetRuleExecutionCounts geeft de tellers voor de limieten per rule en per account.
+func (s *DBStore) GetRuleExecutionCounts(ctx context.Context, ruleID, accountID uuid.UUID) (int, int, error) {
+	return s.limitStore.GetRuleExecutionCounts(ctx, ruleID, accountID)
+}
+
+// CountRuleExecution telt een uitgevoerde actie mee voor de limieten per rule en per account.
+func (s *DBStore) CountRuleExecution(
+	ctx context.Context,
+	ruleType domain.RuleType,
+	ruleID, accountID uuid.UUID,
+) (int, int, error) {
+	return s.limitStore.CountRuleExecution(ctx, ruleType, ruleID, accountID)
+}
+
+// GetBusiestRule geeft de rule met de meeste uitvoeringen vandaag op het account.
+func (s *DBStore) GetBusiestRule(ctx context.Context, accountID uuid.UUID) (domain.RuleType, uuid.UUID, error) {
+	return s.limitStore.GetBusiestRule(ctx, accountID)
+}
+
+// --- NOTIFICATIE FUNCTIES ---
+
+// CreateNotification slaat een melding op voor de eigenaar van een account.
+func (s *DBStore) CreateNotification(
+	ctx context.Context,
+	arg CreateNotificationParams,
+) (domain.Notification, error) {
+	return s.noticeStore.CreateNotification(ctx, arg)
+}
+
+// GetNotificationsForUser haalt de meldingen van een gebruiker op, nieuwste eerst.
+func (s *DBStore) GetNotificationsForUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	unreadOnly bool,
+	limit int,
+) ([]domain.Notification, error) {
+	return s.noticeStore.GetNotificationsForUser(ctx, userID, unreadOnly, limit)
+}
+
+// MarkNotificationRead markeert een melding van de gebruiker als gelezen.
+func (s *DBStore) MarkNotificationRead(
+	ctx context.Context,
+	notificationID, userID uuid.UUID,
+) (domain.Notification, error) {
+	return s.noticeStore.MarkNotificationRead(ctx, notificationID, userID)
+}
+
+// --- WEBHOOK FUNCTIES ---
+
+// EnsureWebhookSecret geeft het webhook geheim van de gebruiker en maakt het aan met candidate als het nog niet bestaat.
+func (s *DBStore) EnsureWebhookSecret(
+	ctx context.Context,
+	userID uuid.UUID,
+	candidate string,
+) (domain.WebhookSecret, error) {
+	return s.hookStore.EnsureWebhookSecret(ctx, userID, candidate)
+}
+
+// RotateWebhookSecret vervangt het webhook geheim van de gebruiker.
+func (s *DBStore) RotateWebhookSecret(ctx context.Context, userID uuid.UUID, secret string) (domain.WebhookSecret, error) {
+	return s.hookStore.RotateWebhookSecret(ctx, userID, secret)
+}
+
+// CreateInboundHook slaat een nieuwe inbound hook op.
+func (s *DBStore) CreateInboundHook(ctx context.Context, arg CreateInboundHookParams) (domain.InboundHook, error) {
+	return s.hookStore.CreateInboundHook(ctx, arg)
+}
+
+// GetInboundHooksForUser haalt de inbound hooks van een gebruiker op.
+func (s *DBStore) GetInboundHooksForUser(ctx context.Context, userID uuid.UUID) ([]domain.InboundHook, error) {
+	return s.hookStore.GetInboundHooksForUser(ctx, userID)
+}
+
+// GetInboundHook haalt een inbound hook van een gebruiker op.
+func (s *DBStore) GetInboundHook(ctx context.Context, hookID, userID uuid.UUID) (domain.InboundHook, error) {
+	return s.hookStore.GetInboundHook(ctx, hookID, userID)
+}
+
+// GetInboundHookByTokenHash zoekt een niet-ingetrokken inbound hook bij de hash van zijn token.
+func (s *DBStore) GetInboundHookByTokenHash(ctx context.Context, tokenHash []byte) (domain.InboundHook, error) {
+	return s.hookStore.GetInboundHookByTokenHash(ctx, tokenHash)
+}
+
+// RevokeInboundHook trekt een inbound hook in.
+func (s *DBStore) RevokeInboundHook(ctx context.Context, hookID, userID uuid.UUID) (domain.InboundHook, error) {
+	return s.hookStore.RevokeInboundHook(ctx, hookID, userID)
+}
+
+// CreateHookDelivery logt een levering aan een inbound hook.
+func (s *DBStore) CreateHookDelivery(ctx context.Context, arg CreateHookDeliveryParams) (domain.HookDelivery, error) {
+	return s.hookStore.CreateHookDelivery(ctx, arg)
+}
+
+// FinishHookDelivery slaat de uitkomst van een levering uit de queue op.
+func (s *DBStore) FinishHookDelivery(ctx context.Context, arg FinishHookDeliveryParams) (domain.HookDelivery, error) {
+	return s.hookStore.FinishHookDelivery(ctx, arg)
+}
+
+// FailQueuedHookDeliveries zet leveringen die nog in de queue staan op failure.
+func (s *DBStore) FailQueuedHookDeliveries(ctx context.Context, errorMessage string) (int64, error) {
+	return s.hookStore.FailQueuedHookDeliveries(ctx, errorMessage)
+}
+
+// GetHookDeliveries haalt de laatste leveringen van een inbound hook op.
+func (s *DBStore) GetHookDeliveries(ctx context.Context, hookID uuid.UUID, limit int) ([]domain.HookDelivery, error) {
+	return s.hookStore.GetHookDeliveries(ctx, hookID, limit)
+}
+
+// CreateProcessingRun slaat een nieuwe handmatige run op.
+func (s *DBStore) CreateProcessingRun(ctx context.Context, arg CreateProcessingRunParams) (domain.ProcessingRun, error) {
+	return s.runStore.CreateProcessingRun(ctx, arg)
+}
+
+// GetProcessingRun haalt een run van de gebruiker op.
+func (s *DBStore) GetProcessingRun(ctx context.Context, runID, userID uuid.UUID) (domain.ProcessingRun, error) {
+	return s.runStore.GetProcessingRun(ctx, runID, userID)
+}
+
+// StartProcessingRun zet een run op running.
+func (s *DBStore) StartProcessingRun(ctx context.Context, runID uuid.UUID) error {
+	return s.runStore.StartProcessingRun(ctx, runID)
+}
+
+// FinishProcessingRun sluit een run af en telt zijn resultaten.
+func (s *DBStore) FinishProcessingRun(
+	ctx context.Context,
+	runID uuid.UUID,
+	status domain.ProcessingRunStatus,
+	errorMessage *string,
+) (domain.ProcessingRun, error) {
+	return s.runStore.FinishProcessingRun(ctx, runID, status, errorMessage)
+}
+
+// FailUnfinishedProcessingRuns zet runs die nog queued of running zijn op failed.
+func (s *DBStore) FailUnfinishedProcessingRuns(ctx context.Context, errorMessage string) (int64, error) {
+	return s.runStore.FailUnfinishedProcessingRuns(ctx, errorMessage)
+}
+
 // --- LOG FUNCTIES ---
 
 // UpdateAccountStatus updates the status of an account.
@@ -226,8 +652,21 @@ func (s *DBStore) CreateAutomationLog(ctx context.Context, arg CreateLogParams)
 }
 
 // HasLogForTrigger checks if a log exists for a trigger event.
-func (s *DBStore) HasLogForTrigger(ctx context.Context, ruleID uuid.UUID, triggerEventID string) (bool, error) {
-	return s.logStore.HasLogForTrigger(ctx, ruleID, triggerEventID)
+func (s *DBStore) HasLogForTrigger(
+	ctx context.Context,
+	ruleID, accountID uuid.UUID,
+	triggerEventID string,
+) (bool, error) {
+	return s.logStore.HasLogForTrigger(ctx, ruleID, accountID, triggerEventID)
+}
+
+// HasLogForMessage checks if a Gmail rule already ran on a message.
+func (s *DBStore) HasLogForMessage(
+	ctx context.Context,
+	ruleID, accountID uuid.UUID,
+	gmailMessageID string,
+) (bool, error) {
+	return s.logStore.HasLogForMessage(ctx, ruleID, accountID, gmailMessageID)
 }
 
 // GetLogsForAccount haalt de meest recente logs op voor een account.
@@ -239,6 +678,25 @@ func (s *DBStore) GetLogsForAccount(
 	return s.logStore.GetLogsForAccount(ctx, accountID, limit)
 }
 
+// GetRevertibleLogs haalt de geslaagde, nog niet teruggedraaide logs van een rule in een periode op.
+func (s *DBStore) GetRevertibleLogs(
+	ctx context.Context,
+	ruleID uuid.UUID,
+	from, to time.Time,
+) ([]domain.AutomationLog, error) {
+	return s.logStore.GetRevertibleLogs(ctx, ruleID, from, to)
+}
+
+// MarkLogsReverted markeert logs waarvan de actie teruggedraaid is.
+func (s *DBStore) MarkLogsReverted(ctx context.Context, logIDs []int64) error {
+	return s.logStore.MarkLogsReverted(ctx, logIDs)
+}
+
+// GetLogsForRun haalt de logs op die een handmatige run schreef.
+func (s *DBStore) GetLogsForRun(ctx context.Context, runID uuid.UUID, limit int) ([]domain.AutomationLog, error) {
+	return s.logStore.GetLogsForRun(ctx, runID, limit)
+}
+
 // --- GECENTRALISEERDE TOKEN LOGICA ---
 
 // GetValidTokenForAccount is de centrale functie die een token ophaalt,
@@ -262,6 +720,29 @@ func (s *DBStore) CreateGmailAutomationRule(
 	return s.gmailStore.CreateGmailAutomationRule(ctx, arg)
 }
 
+// CreateUserGmailRule creates a user-level Gmail rule.
+func (s *DBStore) CreateUserGmailRule(
+	ctx context.Context,
+	arg CreateUserGmailRuleParams,
+) (domain.GmailAutomationRule, error) {
+	return s.gmailStore.CreateUserGmailRule(ctx, arg)
+}
+
+// GetUserGmailRules gets the user-level Gmail rules of a user.
+func (s *DBStore) GetUserGmailRules(ctx context.Context, userID uuid.UUID) ([]domain.GmailAutomationRule, error) {
+	return s.gmailStore.GetUserGmailRules(ctx, userID)
+}
+
+// UpdateGmailRuleAccountSelector replaces the selector of a user-level Gmail rule.
+func (s *DBStore) UpdateGmailRuleAccountSelector(
+	ctx context.Context,
+	ruleID uuid.UUID,
+	selector domain.AccountSelector,
+	changedBy uuid.UUID,
+) (domain.GmailAutomationRule, error) {
+	return s.gmailStore.UpdateGmailRuleAccountSelector(ctx, ruleID, selector, changedBy)
+}
+
 // GetGmailRulesForAccount gets all Gmail automation rules for an account.
 func (s *DBStore) GetGmailRulesForAccount(
 	ctx context.Context,
@@ -284,8 +765,42 @@ func (s *DBStore) DeleteGmailRule(ctx context.Context, ruleID uuid.UUID) error {
 }
 
 // ToggleGmailRuleStatus toggles the active status of a Gmail automation rule
-func (s *DBStore) ToggleGmailRuleStatus(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error) {
-	return s.gmailStore.ToggleGmailRuleStatus(ctx, ruleID)
+func (s *DBStore) ToggleGmailRuleStatus(
+	ctx context.Context,
+	ruleID uuid.UUID,
+	changedBy uuid.UUID,
+) (domain.GmailAutomationRule, error) {
+	return s.gmailStore.ToggleGmailRuleStatus(ctx, ruleID, changedBy)
+}
+
+// RecordGmailRuleExecution counts a successful execution of a Gmail automation rule
+func (s *DBStore) RecordGmailRuleExecution(ctx context.Context, ruleID uuid.UUID) (int, error) {
+	return s.gmailStore.RecordGmailRuleExecution(ctx, ruleID)
+}
+
+// ExpireGmailRule switches off a Gmail automation rule whose schedule has expired
+func (s *DBStore) ExpireGmailRule(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error) {
+	return s.gmailStore.ExpireGmailRule(ctx, ruleID)
+}
+
+// PauseGmailRule switches off a Gmail automation rule that exceeded an execution limit
+func (s *DBStore) PauseGmailRule(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error) {
+	return s.gmailStore.PauseGmailRule(ctx, ruleID)
+}
+
+// GetGmailRuleByID gets a single Gmail automation rule
+func (s *DBStore) GetGmailRuleByID(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error) {
+	return s.gmailStore.GetGmailRuleByID(ctx, ruleID)
+}
+
+// VerifyGmailRuleOwnership controleert of een gebruiker de eigenaar is van de Gmail regel
+func (s *DBStore) VerifyGmailRuleOwnership(ctx context.Context, ruleID uuid.UUID, userID uuid.UUID) error {
+	return s.gmailStore.VerifyGmailRuleOwnership(ctx, ruleID, userID)
+}
+
+// ReorderGmailRules rewrites the priorities of an account's Gmail rules in the given order
+func (s *DBStore) ReorderGmailRules(ctx context.Context, accountID uuid.UUID, ruleIDs []uuid.UUID) error {
+	return s.gmailStore.ReorderGmailRules(ctx, accountID, ruleIDs)
 }
 
 // --- GMAIL MESSAGE STORAGE METHODS ---
@@ -310,6 +825,11 @@ func (s *DBStore) UpdateGmailMessageStatus(
 	return s.gmailStore.UpdateGmailMessageStatus(ctx, accountID, messageID, status)
 }
 
+// UpdateGmailMessagesCache updates the status and/or star of cached Gmail messages in bulk.
+func (s *DBStore) UpdateGmailMessagesCache(ctx context.Context, arg UpdateGmailMessagesCacheParams) (int64, error) {
+	return s.gmailStore.UpdateGmailMessagesCache(ctx, arg)
+}
+
 // GetGmailMessagesForAccount gets recent Gmail messages for an account.
 func (s *DBStore) GetGmailMessagesForAccount(
 	ctx context.Context,
@@ -338,3 +858,232 @@ func (s *DBStore) GetGmailSyncState(
 ) (historyID *string, lastSync *time.Time, err error) {
 	return s.gmailStore.GetGmailSyncState(ctx, accountID)
 }
+
+// --- GMAIL SNOOZE METHODS ---
+
+// CreateGmailSnooze stores a snooze for a Gmail message.
+func (s *DBStore) CreateGmailSnooze(ctx context.Context, arg CreateGmailSnoozeParams) (domain.GmailSnooze, error) {
+	return s.gmailStore.CreateGmailSnooze(ctx, arg)
+}
+
+// GetGmailSnoozeByID gets a single snooze.
+func (s *DBStore) GetGmailSnoozeByID(ctx context.Context, snoozeID uuid.UUID) (domain.GmailSnooze, error) {
+	return s.gmailStore.GetGmailSnoozeByID(ctx, snoozeID)
+}
+
+// GetPendingGmailSnoozesForAccount gets all messages of an account that are still snoozed.
+func (s *DBStore) GetPendingGmailSnoozesForAccount(
+	ctx context.Context,
+	accountID uuid.UUID,
+) ([]domain.GmailSnooze, error) {
+	return s.gmailStore.GetPendingGmailSnoozesForAccount(ctx, accountID)
+}
+
+// GetDueGmailSnoozes gets snoozes whose wake-up time has passed.
+func (s *DBStore) GetDueGmailSnoozes(ctx context.Context, now time.Time, limit int) ([]domain.GmailSnooze, error) {
+	return s.gmailStore.GetDueGmailSnoozes(ctx, now, limit)
+}
+
+// UpdateGmailSnoozeStatus moves a pending snooze to its final status.
+func (s *DBStore) UpdateGmailSnoozeStatus(
+	ctx context.Context,
+	snoozeID uuid.UUID,
+	status domain.GmailSnoozeStatus,
+	errorMessage *string,
+) error {
+	return s.gmailStore.UpdateGmailSnoozeStatus(ctx, snoozeID, status, errorMessage)
+}
+
+// --- GMAIL SCHEDULED SEND METHODS ---
+
+// CreateGmailScheduledSend queues an outgoing message.
+func (s *DBStore) CreateGmailScheduledSend(
+	ctx context.Context,
+	arg CreateGmailScheduledSendParams,
+) (domain.GmailScheduledSend, error) {
+	return s.gmailStore.CreateGmailScheduledSend(ctx, arg)
+}
+
+// GetGmailScheduledSendByID gets a single scheduled send.
+func (s *DBStore) GetGmailScheduledSendByID(ctx context.Context, sendID uuid.UUID) (domain.GmailScheduledSend, error) {
+	return s.gmailStore.GetGmailScheduledSendByID(ctx, sendID)
+}
+
+// GetPendingGmailScheduledSendsForAccount gets all messages of an account that still have to be sent.
+func (s *DBStore) GetPendingGmailScheduledSendsForAccount(
+	ctx context.Context,
+	accountID uuid.UUID,
+) ([]domain.GmailScheduledSend, error) {
+	return s.gmailStore.GetPendingGmailScheduledSendsForAccount(ctx, accountID)
+}
+
+// GetDueGmailScheduledSends gets scheduled sends whose send time has passed.
+func (s *DBStore) GetDueGmailScheduledSends(
+	ctx context.Context,
+	now time.Time,
+	limit int,
+) ([]domain.GmailScheduledSend, error) {
+	return s.gmailStore.GetDueGmailScheduledSends(ctx, now, limit)
+}
+
+// UpdateGmailScheduledSend replaces the message and send time of a pending scheduled send.
+func (s *DBStore) UpdateGmailScheduledSend(
+	ctx context.Context,
+	sendID uuid.UUID,
+	message domain.GmailOutgoingMessage,
+	sendAt time.Time,
+) (domain.GmailScheduledSend, error) {
+	return s.gmailStore.UpdateGmailScheduledSend(ctx, sendID, message, sendAt)
+}
+
+// UpdateGmailScheduledSendStatus moves a pending scheduled send to its final status.
+func (s *DBStore) UpdateGmailScheduledSendStatus(
+	ctx context.Context,
+	sendID uuid.UUID,
+	status domain.GmailScheduledSendStatus,
+	gmailMessageID, lastError *string,
+) error {
+	return s.gmailStore.UpdateGmailScheduledSendStatus(ctx, sendID, status, gmailMessageID, lastError)
+}
+
+// RetryGmailScheduledSend records a failed attempt and moves the send time to the next retry.
+func (s *DBStore) RetryGmailScheduledSend(
+	ctx context.Context,
+	sendID uuid.UUID,
+	lastError string,
+	nextAttempt time.Time,
+) error {
+	return s.gmailStore.RetryGmailScheduledSend(ctx, sendID, lastError, nextAttempt)
+}
+
+// --- GMAIL UNSUBSCRIBE METHODS ---
+
+// CreateGmailUnsubscribe records the result of an unsubscribe attempt.
+func (s *DBStore) CreateGmailUnsubscribe(
+	ctx context.Context,
+	arg CreateGmailUnsubscribeParams,
+) (domain.GmailUnsubscribe, error) {
+	return s.gmailStore.CreateGmailUnsubscribe(ctx, arg)
+}
+
+// GetGmailNewsletterSenders gets the senders with the most mailing list messages.
+func (s *DBStore) GetGmailNewsletterSenders(
+	ctx context.Context,
+	accountID uuid.UUID,
+	limit int,
+) ([]domain.GmailNewsletterSender, error) {
+	return s.gmailStore.GetGmailNewsletterSenders(ctx, accountID, limit)
+}
+
+// --- GMAIL FORWARDING ADDRESS METHODS ---
+
+// UpsertGmailForwardingAddress adds an unverified forwarding address or replaces its verification code.
+func (s *DBStore) UpsertGmailForwardingAddress(
+	ctx context.Context,
+	arg UpsertGmailForwardingAddressParams,
+) (domain.GmailForwardingAddress, error) {
+	return s.gmailStore.UpsertGmailForwardingAddress(ctx, arg)
+}
+
+// GetGmailForwardingAddressByID gets a single forwarding address.
+func (s *DBStore) GetGmailForwardingAddressByID(
+	ctx context.Context,
+	addressID uuid.UUID,
+) (domain.GmailForwardingAddress, error) {
+	return s.gmailStore.GetGmailForwardingAddressByID(ctx, addressID)
+}
+
+// GetGmailForwardingAddressesForUser gets all forwarding addresses of a user.
+func (s *DBStore) GetGmailForwardingAddressesForUser(
+	ctx context.Context,
+	userID uuid.UUID,
+) ([]domain.GmailForwardingAddress, error) {
+	return s.gmailStore.GetGmailForwardingAddressesForUser(ctx, userID)
+}
+
+// UseGmailForwardingVerificationAttempt counts one attempt to verify an address.
+func (s *DBStore) UseGmailForwardingVerificationAttempt(
+	ctx context.Context,
+	addressID uuid.UUID,
+	maxAttempts int,
+) error {
+	return s.gmailStore.UseGmailForwardingVerificationAttempt(ctx, addressID, maxAttempts)
+}
+
+// MarkGmailForwardingAddressVerified marks an address as verified.
+func (s *DBStore) MarkGmailForwardingAddressVerified(
+	ctx context.Context,
+	addressID uuid.UUID,
+) (domain.GmailForwardingAddress, error) {
+	return s.gmailStore.MarkGmailForwardingAddressVerified(ctx, addressID)
+}
+
+// DeleteGmailForwardingAddress removes an address from the allowlist.
+func (s *DBStore) DeleteGmailForwardingAddress(ctx context.Context, addressID uuid.UUID) error {
+	return s.gmailStore.DeleteGmailForwardingAddress(ctx, addressID)
+}
+
+// --- GMAIL DRAFT METHODS ---
+
+// UpsertGmailDraft stores or refreshes the cached copy of a draft.
+func (s *DBStore) UpsertGmailDraft(ctx context.Context, arg UpsertGmailDraftParams) (domain.GmailDraft, error) {
+	return s.gmailStore.UpsertGmailDraft(ctx, arg)
+}
+
+// GetGmailDraftByID gets a single cached draft.
+func (s *DBStore) GetGmailDraftByID(ctx context.Context, draftID uuid.UUID) (domain.GmailDraft, error) {
+	return s.gmailStore.GetGmailDraftByID(ctx, draftID)
+}
+
+// GetGmailDraftsForAccount gets all cached drafts of an account.
+func (s *DBStore) GetGmailDraftsForAccount(ctx context.Context, accountID uuid.UUID) ([]domain.GmailDraft, error) {
+	return s.gmailStore.GetGmailDraftsForAccount(ctx, accountID)
+}
+
+// DeleteGmailDraft removes a draft from the cache.
+func (s *DBStore) DeleteGmailDraft(ctx context.Context, draftID uuid.UUID) error {
+	return s.gmailStore.DeleteGmailDraft(ctx, draftID)
+}
+
+// PruneGmailDrafts removes cached drafts that no longer exist in Gmail.
+func (s *DBStore) PruneGmailDrafts(ctx context.Context, accountID uuid.UUID, keepGmailDraftIDs []string) error {
+	return s.gmailStore.PruneGmailDrafts(ctx, accountID, keepGmailDraftIDs)
+}
+
+// --- CALENDAR WATCH CHANNEL METHODS ---
+
+// UpsertCalendarWatchChannel stores or renews a calendar push notification channel.
+func (s *DBStore) UpsertCalendarWatchChannel(
+	ctx context.Context,
+	arg UpsertCalendarWatchChannelParams,
+) (domain.CalendarWatchChannel, error) {
+	return s.channelStore.UpsertCalendarWatchChannel(ctx, arg)
+}
+
+// GetCalendarWatchChannelByChannelID gets a channel by the ID Google echoes in notifications.
+func (s *DBStore) GetCalendarWatchChannelByChannelID(
+	ctx context.Context,
+	channelID string,
+) (domain.CalendarWatchChannel, error) {
+	return s.channelStore.GetCalendarWatchChannelByChannelID(ctx, channelID)
+}
+
+// GetCalendarWatchChannelsForAccount gets all channels for an account.
+func (s *DBStore) GetCalendarWatchChannelsForAccount(
+	ctx context.Context,
+	accountID uuid.UUID,
+) ([]domain.CalendarWatchChannel, error) {
+	return s.channelStore.GetCalendarWatchChannelsForAccount(ctx, accountID)
+}
+
+// GetCalendarWatchChannelsForInactiveAccounts gets the channels of accounts that are no longer active.
+func (s *DBStore) GetCalendarWatchChannelsForInactiveAccounts(
+	ctx context.Context,
+) ([]domain.CalendarWatchChannel, error) {
+	return s.channelStore.GetCalendarWatchChannelsForInactiveAccounts(ctx)
+}
+
+// DeleteCalendarWatchChannel deletes a channel.
+func (s *DBStore) DeleteCalendarWatchChannel(ctx context.Context, channelID string) error {
+	return s.channelStore.DeleteCalendarWatchChannel(ctx, channelID)
+}
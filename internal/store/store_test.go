@@ -8,9 +8,12 @@ import (
 
 	"agenda-automator-api/internal/domain"
 	"agenda-automator-api/internal/store/account"
+	"agenda-automator-api/internal/store/channel"
 	"agenda-automator-api/internal/store/gmail"
 	"agenda-automator-api/internal/store/log"
 	"agenda-automator-api/internal/store/rule"
+	"agenda-automator-api/internal/store/run"
+	"agenda-automator-api/internal/store/webhook"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -130,6 +133,30 @@ func (m *MockRuleStore) GetRuleByID(ctx context.Context, ruleID uuid.UUID) (doma
 	}
 	return args.Get(0).(domain.AutomationRule), args.Error(1)
 }
+func (m *MockRuleStore) CreateUserRule(ctx context.Context, arg rule.CreateUserRuleParams) (domain.AutomationRule, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.AutomationRule), args.Error(1)
+}
+func (m *MockRuleStore) GetUserRules(ctx context.Context, userID uuid.UUID) ([]domain.AutomationRule, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.AutomationRule), args.Error(1)
+}
+func (m *MockRuleStore) GetRulesForHook(ctx context.Context, hookID uuid.UUID) ([]domain.AutomationRule, error) {
+	args := m.Called(ctx, hookID)
+	return args.Get(0).([]domain.AutomationRule), args.Error(1)
+}
+func (m *MockRuleStore) UpdateRuleAccountSelector(
+	ctx context.Context,
+	ruleID uuid.UUID,
+	selector domain.AccountSelector,
+	changedBy uuid.UUID,
+) (domain.AutomationRule, error) {
+	args := m.Called(ctx, ruleID, selector, changedBy)
+	return args.Get(0).(domain.AutomationRule), args.Error(1)
+}
 func (m *MockRuleStore) GetRulesForAccount(ctx context.Context, accountID uuid.UUID) ([]domain.AutomationRule, error) {
 	args := m.Called(ctx, accountID)
 	if args.Get(0) == nil {
@@ -144,13 +171,28 @@ func (m *MockRuleStore) UpdateRule(ctx context.Context, arg rule.UpdateRuleParam
 	}
 	return args.Get(0).(domain.AutomationRule), args.Error(1)
 }
-func (m *MockRuleStore) ToggleRuleStatus(ctx context.Context, ruleID uuid.UUID) (domain.AutomationRule, error) {
+func (m *MockRuleStore) ToggleRuleStatus(ctx context.Context, ruleID uuid.UUID, changedBy uuid.UUID) (domain.AutomationRule, error) {
+	args := m.Called(ctx, ruleID, changedBy)
+	if args.Get(0) == nil {
+		return domain.AutomationRule{}, args.Error(1)
+	}
+	return args.Get(0).(domain.AutomationRule), args.Error(1)
+}
+func (m *MockRuleStore) RecordRuleExecution(ctx context.Context, ruleID uuid.UUID) (int, error) {
+	args := m.Called(ctx, ruleID)
+	return args.Int(0), args.Error(1)
+}
+func (m *MockRuleStore) ExpireRule(ctx context.Context, ruleID uuid.UUID) (domain.AutomationRule, error) {
 	args := m.Called(ctx, ruleID)
 	if args.Get(0) == nil {
 		return domain.AutomationRule{}, args.Error(1)
 	}
 	return args.Get(0).(domain.AutomationRule), args.Error(1)
 }
+func (m *MockRuleStore) PauseRule(ctx context.Context, ruleID uuid.UUID) (domain.AutomationRule, error) {
+	args := m.Called(ctx, ruleID)
+	return args.Get(0).(domain.AutomationRule), args.Error(1)
+}
 func (m *MockRuleStore) DeleteRule(ctx context.Context, ruleID uuid.UUID) error {
 	args := m.Called(ctx, ruleID)
 	return args.Error(0)
@@ -169,8 +211,20 @@ func (m *MockLogStore) CreateAutomationLog(ctx context.Context, arg log.CreateLo
 	args := m.Called(ctx, arg)
 	return args.Error(0)
 }
-func (m *MockLogStore) HasLogForTrigger(ctx context.Context, ruleID uuid.UUID, triggerEventID string) (bool, error) {
-	args := m.Called(ctx, ruleID, triggerEventID)
+func (m *MockLogStore) HasLogForTrigger(
+	ctx context.Context,
+	ruleID, accountID uuid.UUID,
+	triggerEventID string,
+) (bool, error) {
+	args := m.Called(ctx, ruleID, accountID, triggerEventID)
+	return args.Bool(0), args.Error(1)
+}
+func (m *MockLogStore) HasLogForMessage(
+	ctx context.Context,
+	ruleID, accountID uuid.UUID,
+	gmailMessageID string,
+) (bool, error) {
+	args := m.Called(ctx, ruleID, accountID, gmailMessageID)
 	return args.Bool(0), args.Error(1)
 }
 func (m *MockLogStore) GetLogsForAccount(ctx context.Context, accountID uuid.UUID, limit int) ([]domain.AutomationLog, error) {
@@ -180,6 +234,21 @@ func (m *MockLogStore) GetLogsForAccount(ctx context.Context, accountID uuid.UUI
 	}
 	return args.Get(0).([]domain.AutomationLog), args.Error(1)
 }
+func (m *MockLogStore) GetRevertibleLogs(ctx context.Context, ruleID uuid.UUID, from, to time.Time) ([]domain.AutomationLog, error) {
+	args := m.Called(ctx, ruleID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.AutomationLog), args.Error(1)
+}
+func (m *MockLogStore) MarkLogsReverted(ctx context.Context, logIDs []int64) error {
+	args := m.Called(ctx, logIDs)
+	return args.Error(0)
+}
+func (m *MockLogStore) GetLogsForRun(ctx context.Context, runID uuid.UUID, limit int) ([]domain.AutomationLog, error) {
+	args := m.Called(ctx, runID, limit)
+	return args.Get(0).([]domain.AutomationLog), args.Error(1)
+}
 
 // MockGmailStore (Implementeert nu gmail.GmailStorer)
 type MockGmailStore struct {
@@ -193,6 +262,44 @@ func (m *MockGmailStore) CreateGmailAutomationRule(ctx context.Context, arg gmai
 	}
 	return args.Get(0).(domain.GmailAutomationRule), args.Error(1)
 }
+
+func (m *MockGmailStore) GetGmailRuleByID(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error) {
+	args := m.Called(ctx, ruleID)
+	return args.Get(0).(domain.GmailAutomationRule), args.Error(1)
+}
+
+func (m *MockGmailStore) VerifyGmailRuleOwnership(ctx context.Context, ruleID uuid.UUID, userID uuid.UUID) error {
+	args := m.Called(ctx, ruleID, userID)
+	return args.Error(0)
+}
+
+func (m *MockGmailStore) ReorderGmailRules(ctx context.Context, accountID uuid.UUID, ruleIDs []uuid.UUID) error {
+	args := m.Called(ctx, accountID, ruleIDs)
+	return args.Error(0)
+}
+func (m *MockGmailStore) CreateUserGmailRule(
+	ctx context.Context,
+	arg gmail.CreateUserGmailRuleParams,
+) (domain.GmailAutomationRule, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.GmailAutomationRule), args.Error(1)
+}
+func (m *MockGmailStore) GetUserGmailRules(ctx context.Context, userID uuid.UUID) ([]domain.GmailAutomationRule, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.GmailAutomationRule), args.Error(1)
+}
+func (m *MockGmailStore) UpdateGmailRuleAccountSelector(
+	ctx context.Context,
+	ruleID uuid.UUID,
+	selector domain.AccountSelector,
+	changedBy uuid.UUID,
+) (domain.GmailAutomationRule, error) {
+	args := m.Called(ctx, ruleID, selector, changedBy)
+	return args.Get(0).(domain.GmailAutomationRule), args.Error(1)
+}
 func (m *MockGmailStore) GetGmailRulesForAccount(ctx context.Context, accountID uuid.UUID) ([]domain.GmailAutomationRule, error) {
 	args := m.Called(ctx, accountID)
 	if args.Get(0) == nil {
@@ -211,7 +318,22 @@ func (m *MockGmailStore) DeleteGmailRule(ctx context.Context, ruleID uuid.UUID)
 	args := m.Called(ctx, ruleID)
 	return args.Error(0)
 }
-func (m *MockGmailStore) ToggleGmailRuleStatus(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error) {
+func (m *MockGmailStore) ToggleGmailRuleStatus(ctx context.Context, ruleID uuid.UUID, changedBy uuid.UUID) (domain.GmailAutomationRule, error) {
+	args := m.Called(ctx, ruleID, changedBy)
+	if args.Get(0) == nil {
+		return domain.GmailAutomationRule{}, args.Error(1)
+	}
+	return args.Get(0).(domain.GmailAutomationRule), args.Error(1)
+}
+func (m *MockGmailStore) RecordGmailRuleExecution(ctx context.Context, ruleID uuid.UUID) (int, error) {
+	args := m.Called(ctx, ruleID)
+	return args.Int(0), args.Error(1)
+}
+func (m *MockGmailStore) PauseGmailRule(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error) {
+	args := m.Called(ctx, ruleID)
+	return args.Get(0).(domain.GmailAutomationRule), args.Error(1)
+}
+func (m *MockGmailStore) ExpireGmailRule(ctx context.Context, ruleID uuid.UUID) (domain.GmailAutomationRule, error) {
 	args := m.Called(ctx, ruleID)
 	if args.Get(0) == nil {
 		return domain.GmailAutomationRule{}, args.Error(1)
@@ -230,6 +352,10 @@ func (m *MockGmailStore) UpdateGmailMessageStatus(ctx context.Context, accountID
 	args := m.Called(ctx, accountID, messageID, status)
 	return args.Error(0)
 }
+func (m *MockGmailStore) UpdateGmailMessagesCache(ctx context.Context, arg gmail.UpdateGmailMessagesCacheParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
 func (m *MockGmailStore) GetGmailMessagesForAccount(ctx context.Context, accountID uuid.UUID, limit int) ([]domain.GmailMessage, error) {
 	args := m.Called(ctx, accountID, limit)
 	if args.Get(0) == nil {
@@ -258,6 +384,279 @@ func (m *MockGmailStore) GetGmailSyncState(ctx context.Context, accountID uuid.U
 
 	return histID, t, args.Error(2)
 }
+func (m *MockGmailStore) CreateGmailSnooze(ctx context.Context, arg gmail.CreateGmailSnoozeParams) (domain.GmailSnooze, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.GmailSnooze), args.Error(1)
+}
+func (m *MockGmailStore) GetGmailSnoozeByID(ctx context.Context, snoozeID uuid.UUID) (domain.GmailSnooze, error) {
+	args := m.Called(ctx, snoozeID)
+	return args.Get(0).(domain.GmailSnooze), args.Error(1)
+}
+func (m *MockGmailStore) GetPendingGmailSnoozesForAccount(ctx context.Context, accountID uuid.UUID) ([]domain.GmailSnooze, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).([]domain.GmailSnooze), args.Error(1)
+}
+func (m *MockGmailStore) GetDueGmailSnoozes(ctx context.Context, now time.Time, limit int) ([]domain.GmailSnooze, error) {
+	args := m.Called(ctx, now, limit)
+	return args.Get(0).([]domain.GmailSnooze), args.Error(1)
+}
+func (m *MockGmailStore) UpdateGmailSnoozeStatus(ctx context.Context, snoozeID uuid.UUID, status domain.GmailSnoozeStatus, errorMessage *string) error {
+	args := m.Called(ctx, snoozeID, status, errorMessage)
+	return args.Error(0)
+}
+func (m *MockGmailStore) CreateGmailScheduledSend(ctx context.Context, arg gmail.CreateGmailScheduledSendParams) (domain.GmailScheduledSend, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.GmailScheduledSend), args.Error(1)
+}
+func (m *MockGmailStore) GetGmailScheduledSendByID(ctx context.Context, sendID uuid.UUID) (domain.GmailScheduledSend, error) {
+	args := m.Called(ctx, sendID)
+	return args.Get(0).(domain.GmailScheduledSend), args.Error(1)
+}
+func (m *MockGmailStore) GetPendingGmailScheduledSendsForAccount(ctx context.Context, accountID uuid.UUID) ([]domain.GmailScheduledSend, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).([]domain.GmailScheduledSend), args.Error(1)
+}
+func (m *MockGmailStore) GetDueGmailScheduledSends(ctx context.Context, now time.Time, limit int) ([]domain.GmailScheduledSend, error) {
+	args := m.Called(ctx, now, limit)
+	return args.Get(0).([]domain.GmailScheduledSend), args.Error(1)
+}
+func (m *MockGmailStore) UpdateGmailScheduledSend(ctx context.Context, sendID uuid.UUID, message domain.GmailOutgoingMessage, sendAt time.Time) (domain.GmailScheduledSend, error) {
+	args := m.Called(ctx, sendID, message, sendAt)
+	return args.Get(0).(domain.GmailScheduledSend), args.Error(1)
+}
+func (m *MockGmailStore) UpdateGmailScheduledSendStatus(ctx context.Context, sendID uuid.UUID, status domain.GmailScheduledSendStatus, gmailMessageID, lastError *string) error {
+	args := m.Called(ctx, sendID, status, gmailMessageID, lastError)
+	return args.Error(0)
+}
+func (m *MockGmailStore) RetryGmailScheduledSend(ctx context.Context, sendID uuid.UUID, lastError string, nextAttempt time.Time) error {
+	args := m.Called(ctx, sendID, lastError, nextAttempt)
+	return args.Error(0)
+}
+func (m *MockGmailStore) CreateGmailUnsubscribe(ctx context.Context, arg gmail.CreateGmailUnsubscribeParams) (domain.GmailUnsubscribe, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.GmailUnsubscribe), args.Error(1)
+}
+func (m *MockGmailStore) GetGmailNewsletterSenders(ctx context.Context, accountID uuid.UUID, limit int) ([]domain.GmailNewsletterSender, error) {
+	args := m.Called(ctx, accountID, limit)
+	return args.Get(0).([]domain.GmailNewsletterSender), args.Error(1)
+}
+func (m *MockGmailStore) UpsertGmailForwardingAddress(ctx context.Context, arg gmail.UpsertGmailForwardingAddressParams) (domain.GmailForwardingAddress, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.GmailForwardingAddress), args.Error(1)
+}
+func (m *MockGmailStore) GetGmailForwardingAddressByID(ctx context.Context, addressID uuid.UUID) (domain.GmailForwardingAddress, error) {
+	args := m.Called(ctx, addressID)
+	return args.Get(0).(domain.GmailForwardingAddress), args.Error(1)
+}
+func (m *MockGmailStore) GetGmailForwardingAddressesForUser(ctx context.Context, userID uuid.UUID) ([]domain.GmailForwardingAddress, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]domain.GmailForwardingAddress), args.Error(1)
+}
+func (m *MockGmailStore) UseGmailForwardingVerificationAttempt(ctx context.Context, addressID uuid.UUID, maxAttempts int) error {
+	args := m.Called(ctx, addressID, maxAttempts)
+	return args.Error(0)
+}
+func (m *MockGmailStore) MarkGmailForwardingAddressVerified(ctx context.Context, addressID uuid.UUID) (domain.GmailForwardingAddress, error) {
+	args := m.Called(ctx, addressID)
+	return args.Get(0).(domain.GmailForwardingAddress), args.Error(1)
+}
+func (m *MockGmailStore) DeleteGmailForwardingAddress(ctx context.Context, addressID uuid.UUID) error {
+	args := m.Called(ctx, addressID)
+	return args.Error(0)
+}
+
+func (m *MockGmailStore) UpsertGmailDraft(ctx context.Context, arg gmail.UpsertGmailDraftParams) (domain.GmailDraft, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.GmailDraft), args.Error(1)
+}
+
+func (m *MockGmailStore) GetGmailDraftByID(ctx context.Context, draftID uuid.UUID) (domain.GmailDraft, error) {
+	args := m.Called(ctx, draftID)
+	return args.Get(0).(domain.GmailDraft), args.Error(1)
+}
+
+func (m *MockGmailStore) GetGmailDraftsForAccount(ctx context.Context, accountID uuid.UUID) ([]domain.GmailDraft, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).([]domain.GmailDraft), args.Error(1)
+}
+
+func (m *MockGmailStore) DeleteGmailDraft(ctx context.Context, draftID uuid.UUID) error {
+	args := m.Called(ctx, draftID)
+	return args.Error(0)
+}
+
+func (m *MockGmailStore) PruneGmailDrafts(ctx context.Context, accountID uuid.UUID, keepGmailDraftIDs []string) error {
+	args := m.Called(ctx, accountID, keepGmailDraftIDs)
+	return args.Error(0)
+}
+
+// MockChannelStore (Implementeert channel.ChannelStorer)
+type MockChannelStore struct {
+	mock.Mock
+}
+
+func (m *MockChannelStore) UpsertCalendarWatchChannel(ctx context.Context, arg channel.UpsertCalendarWatchChannelParams) (domain.CalendarWatchChannel, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.CalendarWatchChannel), args.Error(1)
+}
+func (m *MockChannelStore) GetCalendarWatchChannelByChannelID(ctx context.Context, channelID string) (domain.CalendarWatchChannel, error) {
+	args := m.Called(ctx, channelID)
+	return args.Get(0).(domain.CalendarWatchChannel), args.Error(1)
+}
+func (m *MockChannelStore) GetCalendarWatchChannelsForAccount(ctx context.Context, accountID uuid.UUID) ([]domain.CalendarWatchChannel, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).([]domain.CalendarWatchChannel), args.Error(1)
+}
+func (m *MockChannelStore) GetCalendarWatchChannelsForInactiveAccounts(ctx context.Context) ([]domain.CalendarWatchChannel, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.CalendarWatchChannel), args.Error(1)
+}
+func (m *MockChannelStore) DeleteCalendarWatchChannel(ctx context.Context, channelID string) error {
+	args := m.Called(ctx, channelID)
+	return args.Error(0)
+}
+
+// MockRuleVersionStore (Implementeert ruleversion.RuleVersionStorer)
+type MockRuleVersionStore struct {
+	mock.Mock
+}
+
+func (m *MockRuleVersionStore) GetRuleVersions(ctx context.Context, ruleType domain.RuleType, ruleID uuid.UUID) ([]domain.RuleVersion, error) {
+	args := m.Called(ctx, ruleType, ruleID)
+	return args.Get(0).([]domain.RuleVersion), args.Error(1)
+}
+func (m *MockRuleVersionStore) GetRuleVersion(ctx context.Context, ruleType domain.RuleType, ruleID uuid.UUID, version int) (domain.RuleVersion, error) {
+	args := m.Called(ctx, ruleType, ruleID, version)
+	return args.Get(0).(domain.RuleVersion), args.Error(1)
+}
+
+// MockRuleBundleStore (Implementeert rulebundle.RuleBundleStorer)
+type MockRuleBundleStore struct {
+	mock.Mock
+}
+
+func (m *MockRuleBundleStore) ImportRules(ctx context.Context, arg ImportRulesParams) (ImportRulesResult, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(ImportRulesResult), args.Error(1)
+}
+
+// MockRateLimitStore (Implementeert ratelimit.RateLimitStorer)
+type MockRateLimitStore struct {
+	mock.Mock
+}
+
+func (m *MockRateLimitStore) GetRuleExecutionCounts(ctx context.Context, ruleID, accountID uuid.UUID) (int, int, error) {
+	args := m.Called(ctx, ruleID, accountID)
+	return args.Int(0), args.Int(1), args.Error(2)
+}
+
+func (m *MockRateLimitStore) CountRuleExecution(
+	ctx context.Context,
+	ruleType domain.RuleType,
+	ruleID, accountID uuid.UUID,
+) (int, int, error) {
+	args := m.Called(ctx, ruleType, ruleID, accountID)
+	return args.Int(0), args.Int(1), args.Error(2)
+}
+
+func (m *MockRateLimitStore) GetBusiestRule(ctx context.Context, accountID uuid.UUID) (domain.RuleType, uuid.UUID, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).(domain.RuleType), args.Get(1).(uuid.UUID), args.Error(2)
+}
+
+// MockNotificationStore (Implementeert notification.NotificationStorer)
+type MockNotificationStore struct {
+	mock.Mock
+}
+
+func (m *MockNotificationStore) CreateNotification(ctx context.Context, arg CreateNotificationParams) (domain.Notification, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.Notification), args.Error(1)
+}
+func (m *MockNotificationStore) GetNotificationsForUser(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit int) ([]domain.Notification, error) {
+	args := m.Called(ctx, userID, unreadOnly, limit)
+	return args.Get(0).([]domain.Notification), args.Error(1)
+}
+func (m *MockNotificationStore) MarkNotificationRead(ctx context.Context, notificationID, userID uuid.UUID) (domain.Notification, error) {
+	args := m.Called(ctx, notificationID, userID)
+	return args.Get(0).(domain.Notification), args.Error(1)
+}
+
+// MockRunStore (Implementeert run.RunStorer)
+type MockRunStore struct {
+	mock.Mock
+}
+
+func (m *MockRunStore) CreateProcessingRun(ctx context.Context, arg run.CreateProcessingRunParams) (domain.ProcessingRun, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.ProcessingRun), args.Error(1)
+}
+func (m *MockRunStore) GetProcessingRun(ctx context.Context, runID, userID uuid.UUID) (domain.ProcessingRun, error) {
+	args := m.Called(ctx, runID, userID)
+	return args.Get(0).(domain.ProcessingRun), args.Error(1)
+}
+func (m *MockRunStore) StartProcessingRun(ctx context.Context, runID uuid.UUID) error {
+	args := m.Called(ctx, runID)
+	return args.Error(0)
+}
+func (m *MockRunStore) FinishProcessingRun(ctx context.Context, runID uuid.UUID, status domain.ProcessingRunStatus, errorMessage *string) (domain.ProcessingRun, error) {
+	args := m.Called(ctx, runID, status, errorMessage)
+	return args.Get(0).(domain.ProcessingRun), args.Error(1)
+}
+func (m *MockRunStore) FailUnfinishedProcessingRuns(ctx context.Context, errorMessage string) (int64, error) {
+	args := m.Called(ctx, errorMessage)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockWebhookStore (Implementeert webhook.WebhookStorer)
+type MockWebhookStore struct {
+	mock.Mock
+}
+
+func (m *MockWebhookStore) EnsureWebhookSecret(ctx context.Context, userID uuid.UUID, candidate string) (domain.WebhookSecret, error) {
+	args := m.Called(ctx, userID, candidate)
+	return args.Get(0).(domain.WebhookSecret), args.Error(1)
+}
+func (m *MockWebhookStore) RotateWebhookSecret(ctx context.Context, userID uuid.UUID, secret string) (domain.WebhookSecret, error) {
+	args := m.Called(ctx, userID, secret)
+	return args.Get(0).(domain.WebhookSecret), args.Error(1)
+}
+func (m *MockWebhookStore) CreateInboundHook(ctx context.Context, arg webhook.CreateInboundHookParams) (domain.InboundHook, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.InboundHook), args.Error(1)
+}
+func (m *MockWebhookStore) GetInboundHooksForUser(ctx context.Context, userID uuid.UUID) ([]domain.InboundHook, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]domain.InboundHook), args.Error(1)
+}
+func (m *MockWebhookStore) GetInboundHook(ctx context.Context, hookID, userID uuid.UUID) (domain.InboundHook, error) {
+	args := m.Called(ctx, hookID, userID)
+	return args.Get(0).(domain.InboundHook), args.Error(1)
+}
+func (m *MockWebhookStore) GetInboundHookByTokenHash(ctx context.Context, tokenHash []byte) (domain.InboundHook, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(domain.InboundHook), args.Error(1)
+}
+func (m *MockWebhookStore) RevokeInboundHook(ctx context.Context, hookID, userID uuid.UUID) (domain.InboundHook, error) {
+	args := m.Called(ctx, hookID, userID)
+	return args.Get(0).(domain.InboundHook), args.Error(1)
+}
+func (m *MockWebhookStore) CreateHookDelivery(ctx context.Context, arg webhook.CreateHookDeliveryParams) (domain.HookDelivery, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.HookDelivery), args.Error(1)
+}
+func (m *MockWebhookStore) FinishHookDelivery(ctx context.Context, arg webhook.FinishHookDeliveryParams) (domain.HookDelivery, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(domain.HookDelivery), args.Error(1)
+}
+func (m *MockWebhookStore) FailQueuedHookDeliveries(ctx context.Context, errorMessage string) (int64, error) {
+	args := m.Called(ctx, errorMessage)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (m *MockWebhookStore) GetHookDeliveries(ctx context.Context, hookID uuid.UUID, limit int) ([]domain.HookDelivery, error) {
+	args := m.Called(ctx, hookID, limit)
+	return args.Get(0).([]domain.HookDelivery), args.Error(1)
+}
 
 // --- HULPSTRUCTUUR VOOR TESTS ---
 
@@ -268,6 +667,13 @@ type testStore struct {
 	ruleStore    *MockRuleStore
 	logStore     *MockLogStore
 	gmailStore   *MockGmailStore
+	channelStore *MockChannelStore
+	versionStore *MockRuleVersionStore
+	bundleStore  *MockRuleBundleStore
+	limitStore   *MockRateLimitStore
+	noticeStore  *MockNotificationStore
+	hookStore    *MockWebhookStore
+	runStore     *MockRunStore
 }
 
 func newTestStore(_ *testing.T) *testStore {
@@ -276,6 +682,13 @@ func newTestStore(_ *testing.T) *testStore {
 	mockRule := &MockRuleStore{}
 	mockLog := &MockLogStore{}
 	mockGmail := &MockGmailStore{}
+	mockChannel := &MockChannelStore{}
+	mockVersion := &MockRuleVersionStore{}
+	mockBundle := &MockRuleBundleStore{}
+	mockLimit := &MockRateLimitStore{}
+	mockNotice := &MockNotificationStore{}
+	mockHook := &MockWebhookStore{}
+	mockRun := &MockRunStore{}
 
 	dbStore := &DBStore{
 		userStore:    mockUser,
@@ -283,6 +696,13 @@ func newTestStore(_ *testing.T) *testStore {
 		ruleStore:    mockRule,
 		logStore:     mockLog,
 		gmailStore:   mockGmail, // <-- Dit zal nu correct werken
+		channelStore: mockChannel,
+		versionStore: mockVersion,
+		bundleStore:  mockBundle,
+		limitStore:   mockLimit,
+		noticeStore:  mockNotice,
+		hookStore:    mockHook,
+		runStore:     mockRun,
 	}
 
 	return &testStore{
@@ -292,6 +712,13 @@ func newTestStore(_ *testing.T) *testStore {
 		ruleStore:    mockRule,
 		logStore:     mockLog,
 		gmailStore:   mockGmail,
+		channelStore: mockChannel,
+		versionStore: mockVersion,
+		bundleStore:  mockBundle,
+		limitStore:   mockLimit,
+		noticeStore:  mockNotice,
+		hookStore:    mockHook,
+		runStore:     mockRun,
 	}
 }
 
@@ -451,6 +878,30 @@ func TestDBStore_RuleMethods(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expectedRules, rules)
 
+	// Test CreateUserRule, GetUserRules en UpdateRuleAccountSelector
+	userParams := CreateUserRuleParams{UserID: userID}
+	ts.ruleStore.On("CreateUserRule", ctx, userParams).Return(expectedRule, nil)
+	rule, err = ts.dbStore.CreateUserRule(ctx, userParams)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRule, rule)
+
+	ts.ruleStore.On("GetUserRules", ctx, userID).Return(expectedRules, nil)
+	rules, err = ts.dbStore.GetUserRules(ctx, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRules, rules)
+
+	hookID := uuid.New()
+	ts.ruleStore.On("GetRulesForHook", ctx, hookID).Return(expectedRules, nil)
+	rules, err = ts.dbStore.GetRulesForHook(ctx, hookID)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRules, rules)
+
+	selector := domain.AccountSelector{Mode: domain.AccountSelectorAll}
+	ts.ruleStore.On("UpdateRuleAccountSelector", ctx, ruleID, selector, userID).Return(expectedRule, nil)
+	rule, err = ts.dbStore.UpdateRuleAccountSelector(ctx, ruleID, selector, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRule, rule)
+
 	// Test UpdateRule
 	updateParams := UpdateRuleParams{}
 	ts.ruleStore.On("UpdateRule", ctx, updateParams).Return(expectedRule, nil)
@@ -459,8 +910,26 @@ func TestDBStore_RuleMethods(t *testing.T) {
 	assert.Equal(t, expectedRule, rule)
 
 	// Test ToggleRuleStatus
-	ts.ruleStore.On("ToggleRuleStatus", ctx, ruleID).Return(expectedRule, nil)
-	rule, err = ts.dbStore.ToggleRuleStatus(ctx, ruleID)
+	ts.ruleStore.On("ToggleRuleStatus", ctx, ruleID, userID).Return(expectedRule, nil)
+	rule, err = ts.dbStore.ToggleRuleStatus(ctx, ruleID, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRule, rule)
+
+	// Test RecordRuleExecution
+	ts.ruleStore.On("RecordRuleExecution", ctx, ruleID).Return(2, nil)
+	count, err := ts.dbStore.RecordRuleExecution(ctx, ruleID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// Test ExpireRule
+	ts.ruleStore.On("ExpireRule", ctx, ruleID).Return(expectedRule, nil)
+	rule, err = ts.dbStore.ExpireRule(ctx, ruleID)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRule, rule)
+
+	// Test PauseRule
+	ts.ruleStore.On("PauseRule", ctx, ruleID).Return(expectedRule, nil)
+	rule, err = ts.dbStore.PauseRule(ctx, ruleID)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedRule, rule)
 
@@ -491,11 +960,16 @@ func TestDBStore_LogMethods(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Test HasLogForTrigger
-	ts.logStore.On("HasLogForTrigger", ctx, ruleID, "trigger123").Return(true, nil)
-	has, err := ts.dbStore.HasLogForTrigger(ctx, ruleID, "trigger123")
+	ts.logStore.On("HasLogForTrigger", ctx, ruleID, accountID, "trigger123").Return(true, nil)
+	has, err := ts.dbStore.HasLogForTrigger(ctx, ruleID, accountID, "trigger123")
 	assert.NoError(t, err)
 	assert.True(t, has)
 
+	ts.logStore.On("HasLogForMessage", ctx, ruleID, accountID, "message123").Return(false, nil)
+	has, err = ts.dbStore.HasLogForMessage(ctx, ruleID, accountID, "message123")
+	assert.NoError(t, err)
+	assert.False(t, has)
+
 	// Test GetLogsForAccount
 	expectedLogs := []domain.AutomationLog{expectedLog}
 	ts.logStore.On("GetLogsForAccount", ctx, accountID, 50).Return(expectedLogs, nil)
@@ -503,12 +977,31 @@ func TestDBStore_LogMethods(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expectedLogs, logs)
 
+	// Test GetRevertibleLogs en MarkLogsReverted
+	to := time.Now()
+	from := to.Add(-time.Hour)
+	ts.logStore.On("GetRevertibleLogs", ctx, ruleID, from, to).Return(expectedLogs, nil)
+	logs, err = ts.dbStore.GetRevertibleLogs(ctx, ruleID, from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedLogs, logs)
+
+	ts.logStore.On("MarkLogsReverted", ctx, []int64{1}).Return(nil)
+	assert.NoError(t, ts.dbStore.MarkLogsReverted(ctx, []int64{1}))
+
+	// Test GetLogsForRun
+	runID := uuid.New()
+	ts.logStore.On("GetLogsForRun", ctx, runID, 100).Return(expectedLogs, nil)
+	logs, err = ts.dbStore.GetLogsForRun(ctx, runID, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedLogs, logs)
+
 	ts.logStore.AssertExpectations(t)
 }
 
 func TestDBStore_GmailMethods(t *testing.T) {
 	ctx := context.Background()
 	ruleID := uuid.New()
+	userID := uuid.New()
 	accountID := uuid.New()
 	expectedRule := domain.GmailAutomationRule{} // <-- GEWIJZIGD
 	ts := newTestStore(t)
@@ -527,6 +1020,24 @@ func TestDBStore_GmailMethods(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expectedRules, rules)
 
+	// Test CreateUserGmailRule, GetUserGmailRules en UpdateGmailRuleAccountSelector
+	userParams := CreateUserGmailRuleParams{UserID: userID}
+	ts.gmailStore.On("CreateUserGmailRule", ctx, userParams).Return(expectedRule, nil)
+	rule, err = ts.dbStore.CreateUserGmailRule(ctx, userParams)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRule, rule)
+
+	ts.gmailStore.On("GetUserGmailRules", ctx, userID).Return(expectedRules, nil)
+	rules, err = ts.dbStore.GetUserGmailRules(ctx, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRules, rules)
+
+	selector := domain.AccountSelector{Mode: domain.AccountSelectorProvider, Provider: domain.ProviderGoogle}
+	ts.gmailStore.On("UpdateGmailRuleAccountSelector", ctx, ruleID, selector, userID).Return(expectedRule, nil)
+	rule, err = ts.dbStore.UpdateGmailRuleAccountSelector(ctx, ruleID, selector, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRule, rule)
+
 	// Test UpdateGmailRule
 	updateParams := UpdateGmailRuleParams{}
 	ts.gmailStore.On("UpdateGmailRule", ctx, updateParams).Return(expectedRule, nil)
@@ -540,11 +1051,44 @@ func TestDBStore_GmailMethods(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Test ToggleGmailRuleStatus
-	ts.gmailStore.On("ToggleGmailRuleStatus", ctx, ruleID).Return(expectedRule, nil)
-	rule, err = ts.dbStore.ToggleGmailRuleStatus(ctx, ruleID)
+	ts.gmailStore.On("ToggleGmailRuleStatus", ctx, ruleID, userID).Return(expectedRule, nil)
+	rule, err = ts.dbStore.ToggleGmailRuleStatus(ctx, ruleID, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRule, rule)
+
+	// Test RecordGmailRuleExecution
+	ts.gmailStore.On("RecordGmailRuleExecution", ctx, ruleID).Return(1, nil)
+	count, err := ts.dbStore.RecordGmailRuleExecution(ctx, ruleID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// Test ExpireGmailRule
+	ts.gmailStore.On("ExpireGmailRule", ctx, ruleID).Return(expectedRule, nil)
+	rule, err = ts.dbStore.ExpireGmailRule(ctx, ruleID)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRule, rule)
+
+	// Test PauseGmailRule
+	ts.gmailStore.On("PauseGmailRule", ctx, ruleID).Return(expectedRule, nil)
+	rule, err = ts.dbStore.PauseGmailRule(ctx, ruleID)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedRule, rule)
 
+	// Test GetGmailRuleByID
+	ts.gmailStore.On("GetGmailRuleByID", ctx, ruleID).Return(expectedRule, nil)
+	rule, err = ts.dbStore.GetGmailRuleByID(ctx, ruleID)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRule, rule)
+
+	// Test VerifyGmailRuleOwnership
+	ts.gmailStore.On("VerifyGmailRuleOwnership", ctx, ruleID, userID).Return(nil)
+	assert.NoError(t, ts.dbStore.VerifyGmailRuleOwnership(ctx, ruleID, userID))
+
+	// Test ReorderGmailRules
+	ruleIDs := []uuid.UUID{ruleID}
+	ts.gmailStore.On("ReorderGmailRules", ctx, accountID, ruleIDs).Return(nil)
+	assert.NoError(t, ts.dbStore.ReorderGmailRules(ctx, accountID, ruleIDs))
+
 	// Test StoreGmailMessage
 	msgParams := StoreGmailMessageParams{}
 	ts.gmailStore.On("StoreGmailMessage", ctx, msgParams).Return(nil)
@@ -565,6 +1109,13 @@ func TestDBStore_GmailMethods(t *testing.T) {
 	err = ts.dbStore.UpdateGmailMessageStatus(ctx, accountID, msgID, status)
 	assert.NoError(t, err)
 
+	// Test UpdateGmailMessagesCache
+	cacheParams := UpdateGmailMessagesCacheParams{ConnectedAccountID: accountID, GmailMessageIDs: []string{msgID}, Status: &status}
+	ts.gmailStore.On("UpdateGmailMessagesCache", ctx, cacheParams).Return(int64(1), nil)
+	updated, err := ts.dbStore.UpdateGmailMessagesCache(ctx, cacheParams)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), updated)
+
 	// Test GetGmailMessagesForAccount
 	expectedMsgs := []domain.GmailMessage{} // <-- GEWIJZIGD
 	ts.gmailStore.On("GetGmailMessagesForAccount", ctx, accountID, 10).Return(expectedMsgs, nil)
@@ -595,3 +1146,447 @@ func TestDBStore_GmailMethods(t *testing.T) {
 
 	ts.gmailStore.AssertExpectations(t)
 }
+
+func TestDBStore_ChannelMethods(t *testing.T) {
+	ctx := context.Background()
+	accountID := uuid.New()
+	ts := newTestStore(t)
+
+	expectedChannel := domain.CalendarWatchChannel{ChannelID: "channel-1"}
+	expectedChannels := []domain.CalendarWatchChannel{expectedChannel}
+
+	// Test UpsertCalendarWatchChannel
+	params := UpsertCalendarWatchChannelParams{ConnectedAccountID: accountID, CalendarID: "primary"}
+	ts.channelStore.On("UpsertCalendarWatchChannel", ctx, params).Return(expectedChannel, nil)
+	ch, err := ts.dbStore.UpsertCalendarWatchChannel(ctx, params)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedChannel, ch)
+
+	// Test GetCalendarWatchChannelByChannelID
+	ts.channelStore.On("GetCalendarWatchChannelByChannelID", ctx, "channel-1").Return(expectedChannel, nil)
+	ch, err = ts.dbStore.GetCalendarWatchChannelByChannelID(ctx, "channel-1")
+	assert.NoError(t, err)
+	assert.Equal(t, expectedChannel, ch)
+
+	// Test GetCalendarWatchChannelsForAccount
+	ts.channelStore.On("GetCalendarWatchChannelsForAccount", ctx, accountID).Return(expectedChannels, nil)
+	chs, err := ts.dbStore.GetCalendarWatchChannelsForAccount(ctx, accountID)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedChannels, chs)
+
+	// Test GetCalendarWatchChannelsForInactiveAccounts
+	ts.channelStore.On("GetCalendarWatchChannelsForInactiveAccounts", ctx).Return(expectedChannels, nil)
+	chs, err = ts.dbStore.GetCalendarWatchChannelsForInactiveAccounts(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedChannels, chs)
+
+	// Test DeleteCalendarWatchChannel
+	ts.channelStore.On("DeleteCalendarWatchChannel", ctx, "channel-1").Return(nil)
+	err = ts.dbStore.DeleteCalendarWatchChannel(ctx, "channel-1")
+	assert.NoError(t, err)
+
+	ts.channelStore.AssertExpectations(t)
+}
+
+func TestDBStore_RuleVersionMethods(t *testing.T) {
+	ctx := context.Background()
+	ruleID := uuid.New()
+	ts := newTestStore(t)
+
+	expectedVersion := domain.RuleVersion{RuleType: domain.RuleTypeGmail, RuleID: ruleID, Version: 2}
+	expectedVersions := []domain.RuleVersion{expectedVersion}
+
+	// Test GetRuleVersions
+	ts.versionStore.On("GetRuleVersions", ctx, domain.RuleTypeGmail, ruleID).Return(expectedVersions, nil)
+	versions, err := ts.dbStore.GetRuleVersions(ctx, domain.RuleTypeGmail, ruleID)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedVersions, versions)
+
+	// Test GetRuleVersion
+	ts.versionStore.On("GetRuleVersion", ctx, domain.RuleTypeGmail, ruleID, 2).Return(expectedVersion, nil)
+	version, err := ts.dbStore.GetRuleVersion(ctx, domain.RuleTypeGmail, ruleID, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedVersion, version)
+
+	ts.versionStore.AssertExpectations(t)
+}
+
+func TestDBStore_ImportRules(t *testing.T) {
+	ctx := context.Background()
+	ts := newTestStore(t)
+
+	params := ImportRulesParams{CreatedBy: uuid.New()}
+	expected := ImportRulesResult{CalendarRules: 2, GmailRules: 1}
+
+	ts.bundleStore.On("ImportRules", ctx, params).Return(expected, nil)
+	result, err := ts.dbStore.ImportRules(ctx, params)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	ts.bundleStore.AssertExpectations(t)
+}
+
+func TestDBStore_RateLimitMethods(t *testing.T) {
+	ctx := context.Background()
+	ts := newTestStore(t)
+	ruleID := uuid.New()
+	accountID := uuid.New()
+
+	ts.limitStore.On("GetRuleExecutionCounts", ctx, ruleID, accountID).Return(3, 39, nil)
+	ruleHour, accountDay, err := ts.dbStore.GetRuleExecutionCounts(ctx, ruleID, accountID)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, ruleHour)
+	assert.Equal(t, 39, accountDay)
+
+	ts.limitStore.On("CountRuleExecution", ctx, domain.RuleTypeCalendar, ruleID, accountID).Return(4, 40, nil)
+	ruleHour, accountDay, err = ts.dbStore.CountRuleExecution(ctx, domain.RuleTypeCalendar, ruleID, accountID)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, ruleHour)
+	assert.Equal(t, 40, accountDay)
+
+	ts.limitStore.On("GetBusiestRule", ctx, accountID).Return(domain.RuleTypeCalendar, ruleID, nil)
+	ruleType, busiest, err := ts.dbStore.GetBusiestRule(ctx, accountID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.RuleTypeCalendar, ruleType)
+	assert.Equal(t, ruleID, busiest)
+
+	ts.limitStore.AssertExpectations(t)
+}
+
+func TestDBStore_NotificationMethods(t *testing.T) {
+	ctx := context.Background()
+	ts := newTestStore(t)
+	userID := uuid.New()
+	expected := domain.Notification{ID: uuid.New(), UserID: userID, Type: domain.NotificationRulePaused}
+
+	params := CreateNotificationParams{ConnectedAccountID: uuid.New(), Type: domain.NotificationRulePaused}
+	ts.noticeStore.On("CreateNotification", ctx, params).Return(expected, nil)
+	n, err := ts.dbStore.CreateNotification(ctx, params)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, n)
+
+	ts.noticeStore.On("GetNotificationsForUser", ctx, userID, true, 20).Return([]domain.Notification{expected}, nil)
+	notifications, err := ts.dbStore.GetNotificationsForUser(ctx, userID, true, 20)
+	assert.NoError(t, err)
+	assert.Len(t, notifications, 1)
+
+	ts.noticeStore.On("MarkNotificationRead", ctx, expected.ID, userID).Return(expected, nil)
+	n, err = ts.dbStore.MarkNotificationRead(ctx, expected.ID, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, n)
+
+	ts.noticeStore.AssertExpectations(t)
+}
+
+func TestDBStore_WebhookSecretMethods(t *testing.T) {
+	ctx := context.Background()
+	ts := newTestStore(t)
+	userID := uuid.New()
+	expected := domain.WebhookSecret{UserID: userID, Secret: "whsec_test"}
+
+	ts.hookStore.On("EnsureWebhookSecret", ctx, userID, "whsec_kandidaat").Return(expected, nil)
+	secret, err := ts.dbStore.EnsureWebhookSecret(ctx, userID, "whsec_kandidaat")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, secret)
+
+	ts.hookStore.On("RotateWebhookSecret", ctx, userID, "whsec_test").Return(expected, nil)
+	secret, err = ts.dbStore.RotateWebhookSecret(ctx, userID, "whsec_test")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, secret)
+
+	ts.hookStore.AssertExpectations(t)
+}
+
+func TestDBStore_ProcessingRunMethods(t *testing.T) {
+	ctx := context.Background()
+	ts := newTestStore(t)
+	userID := uuid.New()
+	accountID := uuid.New()
+	processingRun := domain.ProcessingRun{ID: uuid.New(), UserID: userID, ConnectedAccountID: &accountID, Status: domain.RunQueued}
+
+	arg := CreateProcessingRunParams{UserID: userID, ConnectedAccountID: &accountID}
+	ts.runStore.On("CreateProcessingRun", ctx, arg).Return(processingRun, nil)
+	created, err := ts.dbStore.CreateProcessingRun(ctx, arg)
+	assert.NoError(t, err)
+	assert.Equal(t, processingRun, created)
+
+	ts.runStore.On("GetProcessingRun", ctx, processingRun.ID, userID).Return(processingRun, nil)
+	_, err = ts.dbStore.GetProcessingRun(ctx, processingRun.ID, userID)
+	assert.NoError(t, err)
+
+	ts.runStore.On("StartProcessingRun", ctx, processingRun.ID).Return(nil)
+	assert.NoError(t, ts.dbStore.StartProcessingRun(ctx, processingRun.ID))
+
+	finished := processingRun
+	finished.Status = domain.RunCompleted
+	ts.runStore.On("FinishProcessingRun", ctx, processingRun.ID, domain.RunCompleted, (*string)(nil)).Return(finished, nil)
+	got, err := ts.dbStore.FinishProcessingRun(ctx, processingRun.ID, domain.RunCompleted, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.RunCompleted, got.Status)
+
+	ts.runStore.On("FailUnfinishedProcessingRuns", ctx, "worker restarted").Return(int64(2), nil)
+	n, err := ts.dbStore.FailUnfinishedProcessingRuns(ctx, "worker restarted")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	ts.runStore.AssertExpectations(t)
+}
+
+func TestDBStore_InboundHookMethods(t *testing.T) {
+	ctx := context.Background()
+	ts := newTestStore(t)
+	userID := uuid.New()
+	hook := domain.InboundHook{ID: uuid.New(), UserID: userID, Name: "CI releases"}
+
+	arg := CreateInboundHookParams{UserID: userID, Name: "CI releases", TokenHash: []byte("hash")}
+	ts.hookStore.On("CreateInboundHook", ctx, arg).Return(hook, nil)
+	created, err := ts.dbStore.CreateInboundHook(ctx, arg)
+	assert.NoError(t, err)
+	assert.Equal(t, hook, created)
+
+	ts.hookStore.On("GetInboundHooksForUser", ctx, userID).Return([]domain.InboundHook{hook}, nil)
+	hooks, err := ts.dbStore.GetInboundHooksForUser(ctx, userID)
+	assert.NoError(t, err)
+	assert.Len(t, hooks, 1)
+
+	ts.hookStore.On("GetInboundHook", ctx, hook.ID, userID).Return(hook, nil)
+	_, err = ts.dbStore.GetInboundHook(ctx, hook.ID, userID)
+	assert.NoError(t, err)
+
+	ts.hookStore.On("GetInboundHookByTokenHash", ctx, []byte("hash")).Return(hook, nil)
+	_, err = ts.dbStore.GetInboundHookByTokenHash(ctx, []byte("hash"))
+	assert.NoError(t, err)
+
+	ts.hookStore.On("RevokeInboundHook", ctx, hook.ID, userID).Return(hook, nil)
+	_, err = ts.dbStore.RevokeInboundHook(ctx, hook.ID, userID)
+	assert.NoError(t, err)
+
+	delivery := domain.HookDelivery{ID: 1, HookID: hook.ID, Status: domain.HookDeliverySuccess}
+	deliveryArg := CreateHookDeliveryParams{HookID: hook.ID, Status: domain.HookDeliverySuccess}
+	ts.hookStore.On("CreateHookDelivery", ctx, deliveryArg).Return(delivery, nil)
+	d, err := ts.dbStore.CreateHookDelivery(ctx, deliveryArg)
+	assert.NoError(t, err)
+	assert.Equal(t, delivery, d)
+
+	finishArg := FinishHookDeliveryParams{DeliveryID: 1, Status: domain.HookDeliverySuccess}
+	ts.hookStore.On("FinishHookDelivery", ctx, finishArg).Return(delivery, nil)
+	d, err = ts.dbStore.FinishHookDelivery(ctx, finishArg)
+	assert.NoError(t, err)
+	assert.Equal(t, delivery, d)
+
+	ts.hookStore.On("FailQueuedHookDeliveries", ctx, "restarted").Return(int64(2), nil)
+	n, err := ts.dbStore.FailQueuedHookDeliveries(ctx, "restarted")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	ts.hookStore.On("GetHookDeliveries", ctx, hook.ID, 50).Return([]domain.HookDelivery{delivery}, nil)
+	deliveries, err := ts.dbStore.GetHookDeliveries(ctx, hook.ID, 50)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+
+	ts.hookStore.AssertExpectations(t)
+}
+
+func TestDBStore_GmailSnoozeMethods(t *testing.T) {
+	ctx := context.Background()
+	accountID := uuid.New()
+	snoozeID := uuid.New()
+	now := time.Now()
+	ts := newTestStore(t)
+
+	expectedSnooze := domain.GmailSnooze{GmailMessageID: "msg-1"}
+	expectedSnoozes := []domain.GmailSnooze{expectedSnooze}
+
+	// Test CreateGmailSnooze
+	params := CreateGmailSnoozeParams{ConnectedAccountID: accountID, GmailMessageID: "msg-1"}
+	ts.gmailStore.On("CreateGmailSnooze", ctx, params).Return(expectedSnooze, nil)
+	snooze, err := ts.dbStore.CreateGmailSnooze(ctx, params)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedSnooze, snooze)
+
+	// Test GetGmailSnoozeByID
+	ts.gmailStore.On("GetGmailSnoozeByID", ctx, snoozeID).Return(expectedSnooze, nil)
+	snooze, err = ts.dbStore.GetGmailSnoozeByID(ctx, snoozeID)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedSnooze, snooze)
+
+	// Test GetPendingGmailSnoozesForAccount
+	ts.gmailStore.On("GetPendingGmailSnoozesForAccount", ctx, accountID).Return(expectedSnoozes, nil)
+	snoozes, err := ts.dbStore.GetPendingGmailSnoozesForAccount(ctx, accountID)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedSnoozes, snoozes)
+
+	// Test GetDueGmailSnoozes
+	ts.gmailStore.On("GetDueGmailSnoozes", ctx, now, 50).Return(expectedSnoozes, nil)
+	snoozes, err = ts.dbStore.GetDueGmailSnoozes(ctx, now, 50)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedSnoozes, snoozes)
+
+	// Test UpdateGmailSnoozeStatus
+	ts.gmailStore.On("UpdateGmailSnoozeStatus", ctx, snoozeID, domain.GmailSnoozeWoken, (*string)(nil)).Return(nil)
+	err = ts.dbStore.UpdateGmailSnoozeStatus(ctx, snoozeID, domain.GmailSnoozeWoken, nil)
+	assert.NoError(t, err)
+
+	ts.gmailStore.AssertExpectations(t)
+}
+
+func TestDBStore_GmailScheduledSendMethods(t *testing.T) {
+	ts := newTestStore(t)
+	ctx := context.Background()
+	accountID := uuid.New()
+	sendID := uuid.New()
+	now := time.Now()
+
+	message := domain.GmailOutgoingMessage{To: []string{"test@example.com"}, Subject: "Follow-up"}
+	expectedSend := domain.GmailScheduledSend{Message: message, SendAt: now}
+	expectedSends := []domain.GmailScheduledSend{expectedSend}
+
+	// Test CreateGmailScheduledSend
+	params := CreateGmailScheduledSendParams{ConnectedAccountID: accountID, Message: message, SendAt: now}
+	ts.gmailStore.On("CreateGmailScheduledSend", ctx, params).Return(expectedSend, nil)
+	send, err := ts.dbStore.CreateGmailScheduledSend(ctx, params)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedSend, send)
+
+	// Test GetGmailScheduledSendByID
+	ts.gmailStore.On("GetGmailScheduledSendByID", ctx, sendID).Return(expectedSend, nil)
+	send, err = ts.dbStore.GetGmailScheduledSendByID(ctx, sendID)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedSend, send)
+
+	// Test GetPendingGmailScheduledSendsForAccount
+	ts.gmailStore.On("GetPendingGmailScheduledSendsForAccount", ctx, accountID).Return(expectedSends, nil)
+	sends, err := ts.dbStore.GetPendingGmailScheduledSendsForAccount(ctx, accountID)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedSends, sends)
+
+	// Test GetDueGmailScheduledSends
+	ts.gmailStore.On("GetDueGmailScheduledSends", ctx, now, 50).Return(expectedSends, nil)
+	sends, err = ts.dbStore.GetDueGmailScheduledSends(ctx, now, 50)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedSends, sends)
+
+	// Test UpdateGmailScheduledSend
+	ts.gmailStore.On("UpdateGmailScheduledSend", ctx, sendID, message, now).Return(expectedSend, nil)
+	send, err = ts.dbStore.UpdateGmailScheduledSend(ctx, sendID, message, now)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedSend, send)
+
+	// Test UpdateGmailScheduledSendStatus
+	ts.gmailStore.On("UpdateGmailScheduledSendStatus", ctx, sendID, domain.GmailScheduledSendCancelled, (*string)(nil), (*string)(nil)).Return(nil)
+	err = ts.dbStore.UpdateGmailScheduledSendStatus(ctx, sendID, domain.GmailScheduledSendCancelled, nil, nil)
+	assert.NoError(t, err)
+
+	// Test RetryGmailScheduledSend
+	ts.gmailStore.On("RetryGmailScheduledSend", ctx, sendID, "timeout", now).Return(nil)
+	err = ts.dbStore.RetryGmailScheduledSend(ctx, sendID, "timeout", now)
+	assert.NoError(t, err)
+
+	ts.gmailStore.AssertExpectations(t)
+}
+
+func TestDBStore_GmailUnsubscribeMethods(t *testing.T) {
+	ts := newTestStore(t)
+	ctx := context.Background()
+	accountID := uuid.New()
+
+	// Test CreateGmailUnsubscribe
+	params := CreateGmailUnsubscribeParams{ConnectedAccountID: accountID, GmailMessageID: "msg-1"}
+	expected := domain.GmailUnsubscribe{GmailMessageID: "msg-1", Status: domain.GmailUnsubscribeSuccess}
+	ts.gmailStore.On("CreateGmailUnsubscribe", ctx, params).Return(expected, nil)
+	u, err := ts.dbStore.CreateGmailUnsubscribe(ctx, params)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, u)
+
+	// Test GetGmailNewsletterSenders
+	senders := []domain.GmailNewsletterSender{{Sender: "news@example.com", MessageCount: 3}}
+	ts.gmailStore.On("GetGmailNewsletterSenders", ctx, accountID, 20).Return(senders, nil)
+	result, err := ts.dbStore.GetGmailNewsletterSenders(ctx, accountID, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, senders, result)
+
+	ts.gmailStore.AssertExpectations(t)
+}
+
+func TestDBStore_GmailForwardingAddressMethods(t *testing.T) {
+	ts := newTestStore(t)
+	ctx := context.Background()
+	userID := uuid.New()
+	addressID := uuid.New()
+
+	// Test UpsertGmailForwardingAddress
+	params := UpsertGmailForwardingAddressParams{UserID: userID, Email: "backup@example.com", VerificationCodeHash: "hash"}
+	expected := domain.GmailForwardingAddress{UserID: userID, Email: "backup@example.com"}
+	ts.gmailStore.On("UpsertGmailForwardingAddress", ctx, params).Return(expected, nil)
+	address, err := ts.dbStore.UpsertGmailForwardingAddress(ctx, params)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, address)
+
+	// Test GetGmailForwardingAddressByID
+	ts.gmailStore.On("GetGmailForwardingAddressByID", ctx, addressID).Return(expected, nil)
+	address, err = ts.dbStore.GetGmailForwardingAddressByID(ctx, addressID)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, address)
+
+	// Test GetGmailForwardingAddressesForUser
+	addresses := []domain.GmailForwardingAddress{expected}
+	ts.gmailStore.On("GetGmailForwardingAddressesForUser", ctx, userID).Return(addresses, nil)
+	result, err := ts.dbStore.GetGmailForwardingAddressesForUser(ctx, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, addresses, result)
+
+	// Test UseGmailForwardingVerificationAttempt
+	ts.gmailStore.On("UseGmailForwardingVerificationAttempt", ctx, addressID, 5).Return(nil)
+	assert.NoError(t, ts.dbStore.UseGmailForwardingVerificationAttempt(ctx, addressID, 5))
+
+	// Test MarkGmailForwardingAddressVerified
+	ts.gmailStore.On("MarkGmailForwardingAddressVerified", ctx, addressID).Return(expected, nil)
+	address, err = ts.dbStore.MarkGmailForwardingAddressVerified(ctx, addressID)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, address)
+
+	// Test DeleteGmailForwardingAddress
+	ts.gmailStore.On("DeleteGmailForwardingAddress", ctx, addressID).Return(nil)
+	assert.NoError(t, ts.dbStore.DeleteGmailForwardingAddress(ctx, addressID))
+
+	ts.gmailStore.AssertExpectations(t)
+}
+
+func TestDBStore_GmailDraftMethods(t *testing.T) {
+	ctx := context.Background()
+	accountID := uuid.New()
+	draftID := uuid.New()
+	ts := newTestStore(t)
+
+	// Test UpsertGmailDraft
+	params := UpsertGmailDraftParams{ConnectedAccountID: accountID, GmailDraftID: "r-1"}
+	expected := domain.GmailDraft{GmailDraftID: "r-1"}
+	ts.gmailStore.On("UpsertGmailDraft", ctx, params).Return(expected, nil)
+	draft, err := ts.dbStore.UpsertGmailDraft(ctx, params)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, draft)
+
+	// Test GetGmailDraftByID
+	ts.gmailStore.On("GetGmailDraftByID", ctx, draftID).Return(expected, nil)
+	draft, err = ts.dbStore.GetGmailDraftByID(ctx, draftID)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, draft)
+
+	// Test GetGmailDraftsForAccount
+	drafts := []domain.GmailDraft{expected}
+	ts.gmailStore.On("GetGmailDraftsForAccount", ctx, accountID).Return(drafts, nil)
+	result, err := ts.dbStore.GetGmailDraftsForAccount(ctx, accountID)
+	assert.NoError(t, err)
+	assert.Equal(t, drafts, result)
+
+	// Test DeleteGmailDraft
+	ts.gmailStore.On("DeleteGmailDraft", ctx, draftID).Return(nil)
+	assert.NoError(t, ts.dbStore.DeleteGmailDraft(ctx, draftID))
+
+	// Test PruneGmailDrafts
+	keep := []string{"r-1"}
+	ts.gmailStore.On("PruneGmailDrafts", ctx, accountID, keep).Return(nil)
+	assert.NoError(t, ts.dbStore.PruneGmailDrafts(ctx, accountID, keep))
+
+	ts.gmailStore.AssertExpectations(t)
+}
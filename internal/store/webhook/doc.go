@@ -0,0 +1,3 @@
+// Package webhook stores the per-user secrets that sign outgoing webhooks, and the inbound hooks
+// through which external systems start actions, with their deliveries.
+package webhook
@@ -0,0 +1,264 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrInboundHookNotFound is returned when a hook does not exist, is revoked (for token lookups)
+// or belongs to another user.
+var ErrInboundHookNotFound = errors.New("inbound hook not found")
+
+// CreateInboundHookParams contains the parameters for a new inbound hook. TokenHash is the
+// SHA-256 of the token; the token itself is never stored.
+type CreateInboundHookParams struct {
+	UserID    uuid.UUID
+	Name      string
+	TokenHash []byte
+}
+
+// CreateHookDeliveryParams contains the log of one request to an inbound hook. A delivery with
+// status queued is finished later with FinishHookDelivery.
+type CreateHookDeliveryParams struct {
+	HookID       uuid.UUID
+	Status       domain.HookDeliveryStatus
+	Payload      json.RawMessage
+	Results      []domain.HookRuleResult
+	ErrorMessage *string
+}
+
+// FinishHookDeliveryParams contains the outcome of a queued delivery.
+type FinishHookDeliveryParams struct {
+	DeliveryID   int64
+	Status       domain.HookDeliveryStatus
+	Results      []domain.HookRuleResult
+	ErrorMessage *string
+}
+
+const hookColumns = `id, user_id, name, created_at, last_delivery_at, revoked_at`
+
+const deliveryColumns = `id, hook_id, received_at, status, payload, results, error_message, finished_at`
+
+// scanHook scans a database row into an InboundHook
+func scanHook(row pgx.Row) (domain.InboundHook, error) {
+	var h domain.InboundHook
+	err := row.Scan(
+		&h.ID,
+		&h.UserID,
+		&h.Name,
+		&h.CreatedAt,
+		&h.LastDeliveryAt,
+		&h.RevokedAt,
+	)
+	return h, err
+}
+
+// scanDelivery scans a database row into a HookDelivery
+func scanDelivery(row pgx.Row) (domain.HookDelivery, error) {
+	var d domain.HookDelivery
+	var results []byte
+	if err := row.Scan(
+		&d.ID,
+		&d.HookID,
+		&d.ReceivedAt,
+		&d.Status,
+		&d.Payload,
+		&results,
+		&d.ErrorMessage,
+		&d.FinishedAt,
+	); err != nil {
+		return d, err
+	}
+	d.Results = []domain.HookRuleResult{}
+	if len(results) > 0 {
+		if err := json.Unmarshal(results, &d.Results); err != nil {
+			return d, err
+		}
+	}
+	return d, nil
+}
+
+// notFound vertaalt een ontbrekende rij naar ErrInboundHookNotFound.
+func notFound(h domain.InboundHook, err error) (domain.InboundHook, error) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.InboundHook{}, ErrInboundHookNotFound
+	}
+	return h, err
+}
+
+// CreateInboundHook slaat een nieuwe hook op voor een gebruiker.
+func (s *WebhookStore) CreateInboundHook(
+	ctx context.Context,
+	arg CreateInboundHookParams,
+) (domain.InboundHook, error) {
+	query := `
+    INSERT INTO inbound_hooks (user_id, name, token_hash)
+    VALUES ($1, $2, $3)
+    RETURNING ` + hookColumns + `;
+    `
+	return scanHook(s.db.QueryRow(ctx, query, arg.UserID, arg.Name, arg.TokenHash))
+}
+
+// GetInboundHooksForUser haalt alle hooks van een gebruiker op, ook ingetrokken, nieuwste eerst.
+func (s *WebhookStore) GetInboundHooksForUser(ctx context.Context, userID uuid.UUID) ([]domain.InboundHook, error) {
+	query := `
+    SELECT ` + hookColumns + `
+    FROM inbound_hooks
+    WHERE user_id = $1
+    ORDER BY created_at DESC;
+    `
+
+	rows, err := s.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := []domain.InboundHook{}
+	for rows.Next() {
+		h, err := scanHook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, h)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return hooks, nil
+}
+
+// GetInboundHook haalt een hook van een gebruiker op.
+func (s *WebhookStore) GetInboundHook(ctx context.Context, hookID, userID uuid.UUID) (domain.InboundHook, error) {
+	query := `
+    SELECT ` + hookColumns + `
+    FROM inbound_hooks
+    WHERE id = $1 AND user_id = $2;
+    `
+	return notFound(scanHook(s.db.QueryRow(ctx, query, hookID, userID)))
+}
+
+// GetInboundHookByTokenHash zoekt de hook bij een token. Ingetrokken hooks worden niet gevonden.
+func (s *WebhookStore) GetInboundHookByTokenHash(ctx context.Context, tokenHash []byte) (domain.InboundHook, error) {
+	query := `
+    SELECT ` + hookColumns + `
+    FROM inbound_hooks
+    WHERE token_hash = $1 AND revoked_at IS NULL;
+    `
+	return notFound(scanHook(s.db.QueryRow(ctx, query, tokenHash)))
+}
+
+// RevokeInboundHook trekt een hook in. Een hook die al ingetrokken was houdt zijn oorspronkelijke
+// revoked_at.
+func (s *WebhookStore) RevokeInboundHook(ctx context.Context, hookID, userID uuid.UUID) (domain.InboundHook, error) {
+	query := `
+    UPDATE inbound_hooks
+    SET revoked_at = COALESCE(revoked_at, now())
+    WHERE id = $1 AND user_id = $2
+    RETURNING ` + hookColumns + `;
+    `
+	return notFound(scanHook(s.db.QueryRow(ctx, query, hookID, userID)))
+}
+
+// CreateHookDelivery logt een levering en zet last_delivery_at van de hook. Een levering met een
+// andere status dan queued is meteen afgerond.
+func (s *WebhookStore) CreateHookDelivery(
+	ctx context.Context,
+	arg CreateHookDeliveryParams,
+) (domain.HookDelivery, error) {
+	if arg.Results == nil {
+		arg.Results = []domain.HookRuleResult{}
+	}
+	results, err := json.Marshal(arg.Results)
+	if err != nil {
+		return domain.HookDelivery{}, err
+	}
+
+	query := `
+    WITH touched AS (
+        UPDATE inbound_hooks SET last_delivery_at = now() WHERE id = $1
+    )
+    INSERT INTO inbound_hook_deliveries (hook_id, status, payload, results, error_message, finished_at)
+    VALUES ($1, $2, $3, $4, $5, CASE WHEN $2 = 'queued' THEN NULL ELSE now() END)
+    RETURNING ` + deliveryColumns + `;
+    `
+	return scanDelivery(s.db.QueryRow(ctx, query, arg.HookID, arg.Status, arg.Payload, results, arg.ErrorMessage))
+}
+
+// FinishHookDelivery slaat de uitkomst van een levering uit de queue op. Een levering die al
+// afgerond is geeft pgx.ErrNoRows.
+func (s *WebhookStore) FinishHookDelivery(
+	ctx context.Context,
+	arg FinishHookDeliveryParams,
+) (domain.HookDelivery, error) {
+	if arg.Results == nil {
+		arg.Results = []domain.HookRuleResult{}
+	}
+	results, err := json.Marshal(arg.Results)
+	if err != nil {
+		return domain.HookDelivery{}, err
+	}
+
+	query := `
+    UPDATE inbound_hook_deliveries
+    SET status = $2, results = $3, error_message = $4, finished_at = now()
+    WHERE id = $1 AND status = 'queued'
+    RETURNING ` + deliveryColumns + `;
+    `
+	return scanDelivery(s.db.QueryRow(ctx, query, arg.DeliveryID, arg.Status, results, arg.ErrorMessage))
+}
+
+// FailQueuedHookDeliveries zet leveringen die nog in de queue staan op failure. De queue van de
+// worker leeft in het geheugen, dus na een herstart draaien die leveringen nooit meer.
+func (s *WebhookStore) FailQueuedHookDeliveries(ctx context.Context, errorMessage string) (int64, error) {
+	query := `
+    UPDATE inbound_hook_deliveries
+    SET status = 'failure', error_message = $1, finished_at = now()
+    WHERE status = 'queued';
+    `
+	tag, err := s.db.Exec(ctx, query, errorMessage)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// GetHookDeliveries haalt de laatste leveringen van een hook op, nieuwste eerst.
+func (s *WebhookStore) GetHookDeliveries(ctx context.Context, hookID uuid.UUID, limit int) ([]domain.HookDelivery, error) {
+	query := `
+    SELECT ` + deliveryColumns + `
+    FROM inbound_hook_deliveries
+    WHERE hook_id = $1
+    ORDER BY received_at DESC, id DESC
+    LIMIT $2;
+    `
+
+	rows, err := s.db.Query(ctx, query, hookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []domain.HookDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,186 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var hookRowColumns = []string{"id", "user_id", "name", "created_at", "last_delivery_at", "revoked_at"}
+
+var deliveryRowColumns = []string{
+	"id", "hook_id", "received_at", "status", "payload", "results", "error_message", "finished_at",
+}
+
+func TestWebhookStore_CreateInboundHook(t *testing.T) {
+	store, mockPool := setupWebhookStore(t)
+	defer mockPool.Close()
+
+	hookID, userID := uuid.New(), uuid.New()
+	now := time.Now()
+
+	mockPool.ExpectQuery(`INSERT INTO inbound_hooks \(user_id, name, token_hash\)`).
+		WithArgs(userID, "CI releases", []byte("hash")).
+		WillReturnRows(pgxmock.NewRows(hookRowColumns).
+			AddRow(hookID, userID, "CI releases", now, nil, nil))
+
+	hook, err := store.CreateInboundHook(context.Background(), CreateInboundHookParams{
+		UserID:    userID,
+		Name:      "CI releases",
+		TokenHash: []byte("hash"),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, hookID, hook.ID)
+	assert.Equal(t, userID, hook.UserID)
+	assert.Nil(t, hook.RevokedAt)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestWebhookStore_GetInboundHookByTokenHash_Revoked(t *testing.T) {
+	store, mockPool := setupWebhookStore(t)
+	defer mockPool.Close()
+
+	mockPool.ExpectQuery(`SELECT .* FROM inbound_hooks WHERE token_hash = \$1 AND revoked_at IS NULL`).
+		WithArgs([]byte("hash")).
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err := store.GetInboundHookByTokenHash(context.Background(), []byte("hash"))
+
+	assert.ErrorIs(t, err, ErrInboundHookNotFound)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestWebhookStore_RevokeInboundHook(t *testing.T) {
+	store, mockPool := setupWebhookStore(t)
+	defer mockPool.Close()
+
+	hookID, userID := uuid.New(), uuid.New()
+	revokedAt := time.Now()
+	mockPool.ExpectQuery(`UPDATE inbound_hooks SET revoked_at = COALESCE\(revoked_at, now\(\)\) WHERE id = \$1 AND user_id = \$2`).
+		WithArgs(hookID, userID).
+		WillReturnRows(pgxmock.NewRows(hookRowColumns).
+			AddRow(hookID, userID, "CI releases", revokedAt, nil, &revokedAt))
+
+	hook, err := store.RevokeInboundHook(context.Background(), hookID, userID)
+
+	require.NoError(t, err)
+	require.NotNil(t, hook.RevokedAt)
+	assert.Equal(t, revokedAt, *hook.RevokedAt)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestWebhookStore_RevokeInboundHook_OtherUser(t *testing.T) {
+	store, mockPool := setupWebhookStore(t)
+	defer mockPool.Close()
+
+	hookID, userID := uuid.New(), uuid.New()
+	mockPool.ExpectQuery(`UPDATE inbound_hooks`).
+		WithArgs(hookID, userID).
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err := store.RevokeInboundHook(context.Background(), hookID, userID)
+
+	assert.ErrorIs(t, err, ErrInboundHookNotFound)
+}
+
+func TestWebhookStore_CreateHookDelivery(t *testing.T) {
+	store, mockPool := setupWebhookStore(t)
+	defer mockPool.Close()
+
+	hookID := uuid.New()
+	now := time.Now()
+	payload := json.RawMessage(`{"version":"2.4.0"}`)
+
+	// Een levering in de queue heeft nog geen resultaten en geen finished_at
+	mockPool.ExpectQuery(`WITH touched AS \( UPDATE inbound_hooks SET last_delivery_at = now\(\) WHERE id = \$1 \) INSERT INTO inbound_hook_deliveries .* CASE WHEN \$2 = 'queued' THEN NULL ELSE now\(\) END`).
+		WithArgs(hookID, domain.HookDeliveryQueued, payload, []byte(`[]`), (*string)(nil)).
+		WillReturnRows(pgxmock.NewRows(deliveryRowColumns).
+			AddRow(int64(7), hookID, now, domain.HookDeliveryQueued, payload, []byte(`[]`), nil, nil))
+
+	delivery, err := store.CreateHookDelivery(context.Background(), CreateHookDeliveryParams{
+		HookID:  hookID,
+		Status:  domain.HookDeliveryQueued,
+		Payload: payload,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), delivery.ID)
+	assert.Equal(t, domain.HookDeliveryQueued, delivery.Status)
+	assert.Empty(t, delivery.Results)
+	assert.Nil(t, delivery.FinishedAt)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestWebhookStore_FinishHookDelivery(t *testing.T) {
+	store, mockPool := setupWebhookStore(t)
+	defer mockPool.Close()
+
+	hookID, ruleID, accountID := uuid.New(), uuid.New(), uuid.New()
+	now := time.Now()
+	results := []domain.HookRuleResult{{RuleID: ruleID, ConnectedAccountID: &accountID, Outcome: "success"}}
+	resultsJSON, err := json.Marshal(results)
+	require.NoError(t, err)
+
+	// Alleen een levering die nog in de queue staat wordt afgerond
+	mockPool.ExpectQuery(`UPDATE inbound_hook_deliveries SET status = \$2, results = \$3, error_message = \$4, finished_at = now\(\) WHERE id = \$1 AND status = 'queued'`).
+		WithArgs(int64(7), domain.HookDeliverySuccess, resultsJSON, (*string)(nil)).
+		WillReturnRows(pgxmock.NewRows(deliveryRowColumns).
+			AddRow(int64(7), hookID, now, domain.HookDeliverySuccess, nil, resultsJSON, nil, &now))
+
+	delivery, err := store.FinishHookDelivery(context.Background(), FinishHookDeliveryParams{
+		DeliveryID: 7,
+		Status:     domain.HookDeliverySuccess,
+		Results:    results,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, results, delivery.Results)
+	assert.NotNil(t, delivery.FinishedAt)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestWebhookStore_FailQueuedHookDeliveries(t *testing.T) {
+	store, mockPool := setupWebhookStore(t)
+	defer mockPool.Close()
+
+	mockPool.ExpectExec(`UPDATE inbound_hook_deliveries SET status = 'failure', error_message = \$1, finished_at = now\(\) WHERE status = 'queued'`).
+		WithArgs("worker restarted").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 3))
+
+	n, err := store.FailQueuedHookDeliveries(context.Background(), "worker restarted")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestWebhookStore_GetHookDeliveries(t *testing.T) {
+	store, mockPool := setupWebhookStore(t)
+	defer mockPool.Close()
+
+	hookID := uuid.New()
+	message := "body must be a JSON object"
+	mockPool.ExpectQuery(`SELECT .* FROM inbound_hook_deliveries WHERE hook_id = \$1 ORDER BY received_at DESC, id DESC LIMIT \$2`).
+		WithArgs(hookID, 20).
+		WillReturnRows(pgxmock.NewRows(deliveryRowColumns).
+			AddRow(int64(2), hookID, time.Now(), domain.HookDeliveryFailure, nil, []byte(`[]`), &message, nil))
+
+	deliveries, err := store.GetHookDeliveries(context.Background(), hookID, 20)
+
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, domain.HookDeliveryFailure, deliveries[0].Status)
+	assert.Equal(t, &message, deliveries[0].ErrorMessage)
+	assert.Empty(t, deliveries[0].Results)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
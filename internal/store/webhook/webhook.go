@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"agenda-automator-api/internal/crypto"
+	"agenda-automator-api/internal/database"
+	"agenda-automator-api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// WebhookStorer defines the interface for webhook store operations
+type WebhookStorer interface {
+	EnsureWebhookSecret(ctx context.Context, userID uuid.UUID, candidate string) (domain.WebhookSecret, error)
+	RotateWebhookSecret(ctx context.Context, userID uuid.UUID, secret string) (domain.WebhookSecret, error)
+
+	CreateInboundHook(ctx context.Context, arg CreateInboundHookParams) (domain.InboundHook, error)
+	GetInboundHooksForUser(ctx context.Context, userID uuid.UUID) ([]domain.InboundHook, error)
+	GetInboundHook(ctx context.Context, hookID, userID uuid.UUID) (domain.InboundHook, error)
+	GetInboundHookByTokenHash(ctx context.Context, tokenHash []byte) (domain.InboundHook, error)
+	RevokeInboundHook(ctx context.Context, hookID, userID uuid.UUID) (domain.InboundHook, error)
+	CreateHookDelivery(ctx context.Context, arg CreateHookDeliveryParams) (domain.HookDelivery, error)
+	FinishHookDelivery(ctx context.Context, arg FinishHookDeliveryParams) (domain.HookDelivery, error)
+	FailQueuedHookDeliveries(ctx context.Context, errorMessage string) (int64, error)
+	GetHookDeliveries(ctx context.Context, hookID uuid.UUID, limit int) ([]domain.HookDelivery, error)
+}
+
+// WebhookStore handles webhook database operations
+type WebhookStore struct {
+	db database.Querier
+}
+
+// NewWebhookStore creates a new WebhookStore
+func NewWebhookStore(db database.Querier) WebhookStorer {
+	return &WebhookStore{db: db}
+}
+
+// EnsureWebhookSecret geeft het geheim van de gebruiker, en slaat candidate op als die er nog geen
+// heeft. Twee gelijktijdige aanroepen krijgen hetzelfde geheim terug.
+func (s *WebhookStore) EnsureWebhookSecret(
+	ctx context.Context,
+	userID uuid.UUID,
+	candidate string,
+) (domain.WebhookSecret, error) {
+	encrypted, err := crypto.Encrypt([]byte(candidate))
+	if err != nil {
+		return domain.WebhookSecret{}, err
+	}
+
+	// De SELECT ziet de rij uit dezelfde statement niet, dus precies één van beide levert een rij
+	query := `
+    WITH inserted AS (
+        INSERT INTO webhook_secrets (user_id, secret)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id) DO NOTHING
+        RETURNING secret, created_at
+    )
+    SELECT secret, created_at FROM inserted
+    UNION ALL
+    SELECT secret, created_at FROM webhook_secrets WHERE user_id = $1
+    LIMIT 1;
+    `
+	return scanSecret(s.db.QueryRow(ctx, query, userID, encrypted), userID)
+}
+
+// RotateWebhookSecret vervangt het geheim van de gebruiker; webhooks daarna zijn met het nieuwe
+// geheim ondertekend.
+func (s *WebhookStore) RotateWebhookSecret(
+	ctx context.Context,
+	userID uuid.UUID,
+	secret string,
+) (domain.WebhookSecret, error) {
+	encrypted, err := crypto.Encrypt([]byte(secret))
+	if err != nil {
+		return domain.WebhookSecret{}, err
+	}
+
+	query := `
+    INSERT INTO webhook_secrets (user_id, secret)
+    VALUES ($1, $2)
+    ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, created_at = now()
+    RETURNING secret, created_at;
+    `
+	return scanSecret(s.db.QueryRow(ctx, query, userID, encrypted), userID)
+}
+
+// scanSecret leest een rij en ontsleutelt het geheim.
+func scanSecret(row pgx.Row, userID uuid.UUID) (domain.WebhookSecret, error) {
+	var encrypted []byte
+	var createdAt time.Time
+	if err := row.Scan(&encrypted, &createdAt); err != nil {
+		return domain.WebhookSecret{}, err
+	}
+	plaintext, err := crypto.Decrypt(encrypted)
+	if err != nil {
+		return domain.WebhookSecret{}, err
+	}
+	return domain.WebhookSecret{UserID: userID, Secret: string(plaintext), CreatedAt: createdAt}, nil
+}
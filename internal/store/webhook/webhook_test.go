@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/crypto"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupWebhookStore is een helper die een WebhookStore en een mock pool aanmaakt.
+func setupWebhookStore(t *testing.T) (WebhookStorer, pgxmock.PgxPoolIface) {
+	t.Helper()
+	t.Setenv("ENCRYPTION_KEY", "12345678901234567890123456789012")
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+
+	return NewWebhookStore(mockPool), mockPool
+}
+
+func encryptedSecret(t *testing.T, secret string) []byte {
+	t.Helper()
+	encrypted, err := crypto.Encrypt([]byte(secret))
+	require.NoError(t, err)
+	return encrypted
+}
+
+func TestWebhookStore_EnsureWebhookSecret_KeepsExisting(t *testing.T) {
+	store, mockPool := setupWebhookStore(t)
+	defer mockPool.Close()
+
+	userID := uuid.New()
+	createdAt := time.Now()
+	mockPool.ExpectQuery(`INSERT INTO webhook_secrets .* ON CONFLICT \(user_id\) DO NOTHING .* UNION ALL`).
+		WithArgs(userID, pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"secret", "created_at"}).
+			AddRow(encryptedSecret(t, "whsec_bestaand"), createdAt))
+
+	secret, err := store.EnsureWebhookSecret(context.Background(), userID, "whsec_nieuw")
+
+	require.NoError(t, err)
+	assert.Equal(t, "whsec_bestaand", secret.Secret)
+	assert.Equal(t, userID, secret.UserID)
+	assert.Equal(t, createdAt, secret.CreatedAt)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestWebhookStore_RotateWebhookSecret(t *testing.T) {
+	store, mockPool := setupWebhookStore(t)
+	defer mockPool.Close()
+
+	userID := uuid.New()
+	mockPool.ExpectQuery(`INSERT INTO webhook_secrets .* ON CONFLICT \(user_id\) DO UPDATE SET secret = EXCLUDED.secret`).
+		WithArgs(userID, pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"secret", "created_at"}).
+			AddRow(encryptedSecret(t, "whsec_nieuw"), time.Now()))
+
+	secret, err := store.RotateWebhookSecret(context.Background(), userID, "whsec_nieuw")
+
+	require.NoError(t, err)
+	assert.Equal(t, "whsec_nieuw", secret.Secret)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
@@ -0,0 +1,3 @@
+// Package unsubscribe parses List-Unsubscribe headers (RFC 2369) and performs
+// one-click (RFC 8058) or mailto unsubscribes.
+package unsubscribe
@@ -0,0 +1,153 @@
+package unsubscribe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"agenda-automator-api/internal/email"
+	"agenda-automator-api/internal/webhook"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// Method is de manier waarop is uitgeschreven
+type Method string
+
+const (
+	MethodOneClick Method = "one_click"
+	MethodMailto   Method = "mailto"
+)
+
+// oneClickBody is de vaste POST body uit RFC 8058
+const oneClickBody = "List-Unsubscribe=One-Click"
+
+// ErrNoUnsubscribe wordt teruggegeven als een bericht geen bruikbare List-Unsubscribe header heeft.
+var ErrNoUnsubscribe = errors.New("message has no usable List-Unsubscribe header")
+
+// Target bevat de uitschrijfopties van een bericht.
+type Target struct {
+	HTTPURL  string // Alleen https URLs
+	Mailto   string // Het volledige mailto: adres, inclusief eventuele subject/body
+	OneClick bool   // List-Unsubscribe-Post: List-Unsubscribe=One-Click aanwezig
+}
+
+// CanUnsubscribe geeft aan of automatisch uitschrijven mogelijk is. Een https URL zonder
+// one-click vereist een bezoek in de browser en telt daarom niet mee.
+func (t Target) CanUnsubscribe() bool {
+	return t.OneClick || t.Mailto != ""
+}
+
+// Parse leest de List-Unsubscribe en List-Unsubscribe-Post headers.
+// Voorbeeld: "<mailto:leave@example.com?subject=unsubscribe>, <https://example.com/u/123>".
+func Parse(listUnsubscribe, listUnsubscribePost string) Target {
+	var target Target
+
+	for _, part := range strings.Split(listUnsubscribe, ",") {
+		part = strings.TrimSpace(part)
+		if !strings.HasPrefix(part, "<") || !strings.HasSuffix(part, ">") {
+			continue
+		}
+		value := strings.TrimSpace(part[1 : len(part)-1])
+
+		lower := strings.ToLower(value)
+		switch {
+		case strings.HasPrefix(lower, "https://") && target.HTTPURL == "":
+			target.HTTPURL = value
+		case strings.HasPrefix(lower, "mailto:") && target.Mailto == "":
+			target.Mailto = value
+		}
+	}
+
+	// One-click vereist een https URL (RFC 8058 sectie 3.1)
+	target.OneClick = target.HTTPURL != "" &&
+		strings.EqualFold(strings.TrimSpace(listUnsubscribePost), oneClickBody)
+
+	return target
+}
+
+// Unsubscriber voert uitschrijvingen uit.
+type Unsubscriber struct {
+	httpClient *http.Client
+}
+
+// NewUnsubscriber maakt een Unsubscriber met dezelfde SSRF-veilige HTTP client als webhooks, die
+// geen interne adressen bereikt en geen redirects volgt.
+func NewUnsubscriber() *Unsubscriber {
+	return &Unsubscriber{httpClient: webhook.NewPublicClient(10*time.Second, 15*time.Second)}
+}
+
+// Unsubscribe schrijft uit via one-click als dat kan, en anders via een mailto bericht vanuit Gmail.
+// Het teruggegeven adres is de URL of het e-mailadres dat gebruikt is.
+func (u *Unsubscriber) Unsubscribe(
+	ctx context.Context,
+	srv *gmail.Service,
+	target Target,
+) (Method, string, error) {
+	switch {
+	case target.OneClick:
+		return MethodOneClick, target.HTTPURL, u.oneClick(ctx, target.HTTPURL)
+	case target.Mailto != "":
+		return MethodMailto, target.Mailto, sendMailto(srv, target.Mailto)
+	}
+	return "", "", ErrNoUnsubscribe
+}
+
+// oneClick doet de RFC 8058 POST.
+func (u *Unsubscriber) oneClick(ctx context.Context, rawURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, strings.NewReader(oneClickBody))
+	if err != nil {
+		return fmt.Errorf("invalid unsubscribe URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("one-click unsubscribe failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("one-click unsubscribe returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// sendMailto verstuurt het uitschrijfbericht uit een mailto URL via Gmail.
+func sendMailto(srv *gmail.Service, mailto string) error {
+	parsed, err := url.Parse(mailto)
+	if err != nil || parsed.Opaque == "" {
+		return fmt.Errorf("invalid mailto address: %s", mailto)
+	}
+
+	to, err := url.PathUnescape(parsed.Opaque)
+	if err != nil {
+		return fmt.Errorf("invalid mailto address: %s", mailto)
+	}
+
+	query := parsed.Query()
+	subject := query.Get("subject")
+	if subject == "" {
+		subject = "unsubscribe"
+	}
+
+	m := &email.Message{
+		To:      []string{to},
+		Subject: subject,
+		Text:    query.Get("body"),
+	}
+	raw, err := m.Raw()
+	if err != nil {
+		return fmt.Errorf("invalid mailto address: %w", err)
+	}
+
+	message := &gmail.Message{Raw: raw}
+	if _, err := srv.Users.Messages.Send("me", message).Do(); err != nil {
+		return fmt.Errorf("mailto unsubscribe failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package unsubscribe
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agenda-automator-api/internal/webhook"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		post     string
+		expected Target
+	}{
+		{
+			name:   "mailto and https with one-click",
+			header: "<mailto:leave@example.com?subject=unsubscribe>, <https://example.com/u/123>",
+			post:   "List-Unsubscribe=One-Click",
+			expected: Target{
+				HTTPURL:  "https://example.com/u/123",
+				Mailto:   "mailto:leave@example.com?subject=unsubscribe",
+				OneClick: true,
+			},
+		},
+		{
+			name:     "https without post header",
+			header:   "<https://example.com/u/123>",
+			expected: Target{HTTPURL: "https://example.com/u/123"},
+		},
+		{
+			name:     "plain http is ignored",
+			header:   "<http://example.com/u/123>",
+			post:     "List-Unsubscribe=One-Click",
+			expected: Target{},
+		},
+		{
+			name:     "malformed entries are ignored",
+			header:   "leave@example.com, <mailto:leave@example.com>",
+			expected: Target{Mailto: "mailto:leave@example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Parse(tt.header, tt.post))
+		})
+	}
+}
+
+func TestUnsubscriber_OneClick(t *testing.T) {
+	var body, contentType string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		body = string(raw)
+		contentType = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	u := &Unsubscriber{httpClient: server.Client()}
+	method, used, err := u.Unsubscribe(context.Background(), nil, Target{HTTPURL: server.URL, OneClick: true})
+
+	require.NoError(t, err)
+	assert.Equal(t, MethodOneClick, method)
+	assert.Equal(t, server.URL, used)
+	assert.Equal(t, "List-Unsubscribe=One-Click", body)
+	assert.Equal(t, "application/x-www-form-urlencoded", contentType)
+}
+
+func TestUnsubscriber_RejectsInternalAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		t.Error("internal server should not be reached")
+	}))
+	defer server.Close()
+
+	_, _, err := NewUnsubscriber().Unsubscribe(context.Background(), nil, Target{HTTPURL: server.URL, OneClick: true})
+
+	assert.ErrorIs(t, err, webhook.ErrInternalAddress)
+}
+
+func TestUnsubscriber_Mailto(t *testing.T) {
+	var sent gmail.Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/gmail/v1/users/me/messages/send", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+		json.NewEncoder(w).Encode(gmail.Message{Id: "sent-1"})
+	}))
+	defer server.Close()
+
+	srv, err := gmail.NewService(context.Background(), option.WithHTTPClient(server.Client()), option.WithEndpoint(server.URL))
+	require.NoError(t, err)
+
+	target := Target{Mailto: "mailto:leave@example.com?subject=stop%0D%0ABcc:%20x@example.com"}
+	method, _, err := NewUnsubscriber().Unsubscribe(context.Background(), srv, target)
+
+	require.NoError(t, err)
+	assert.Equal(t, MethodMailto, method)
+
+	raw, err := base64.URLEncoding.DecodeString(sent.Raw)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "To: <leave@example.com>\r\n")
+	// Regeleinden in het onderwerp mogen geen extra header opleveren
+	assert.Contains(t, string(raw), "Subject: stop Bcc: x@example.com\r\n")
+	assert.NotContains(t, string(raw), "\r\nBcc:")
+}
+
+func TestUnsubscriber_NoTarget(t *testing.T) {
+	_, _, err := NewUnsubscriber().Unsubscribe(context.Background(), nil, Target{})
+	assert.ErrorIs(t, err, ErrNoUnsubscribe)
+}
+
+func TestTarget_CanUnsubscribe(t *testing.T) {
+	assert.True(t, Target{HTTPURL: "https://example.com/u", OneClick: true}.CanUnsubscribe())
+	assert.True(t, Target{Mailto: "mailto:leave@example.com"}.CanUnsubscribe())
+	// Zonder one-click moet de gebruiker de pagina zelf openen
+	assert.False(t, Target{HTTPURL: "https://example.com/u"}.CanUnsubscribe())
+}
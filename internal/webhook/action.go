@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+
+	"github.com/google/uuid"
+)
+
+// ActionType is de naam van de webhook actie, gelijk voor calendar en Gmail rules.
+const ActionType = domain.CalendarActionWebhook
+
+// Params zijn de action_params van een webhook actie.
+type Params struct {
+	URL string `json:"url"`
+}
+
+func (p Params) Validate() error {
+	var errs rules.FieldErrors
+	if strings.TrimSpace(p.URL) == "" {
+		errs.Add("url", "is required")
+	} else if err := ValidateURL(p.URL); err != nil {
+		errs.Add("url", "%v", err)
+	}
+	return errs.Err()
+}
+
+// Payload is de JSON body van een webhook. Event of Message bevat de metadata van wat de rule
+// liet afgaan, afhankelijk van het type rule.
+type Payload struct {
+	DeliveredAt time.Time       `json:"delivered_at"`
+	RuleID      uuid.UUID       `json:"rule_id"`
+	RuleName    string          `json:"rule_name"`
+	RuleType    domain.RuleType `json:"rule_type"`
+	AccountID   uuid.UUID       `json:"account_id"`
+	Event       any             `json:"event,omitempty"`
+	Message     any             `json:"message,omitempty"`
+}
+
+// NewPayload vult de rule velden van een payload.
+func NewPayload(rule rules.Rule, ruleType domain.RuleType) Payload {
+	return Payload{
+		DeliveredAt: time.Now().UTC(),
+		RuleID:      rule.ID,
+		RuleName:    rule.Name,
+		RuleType:    ruleType,
+		AccountID:   rule.AccountID,
+	}
+}
+
+// Preview is wat een webhook actie zou versturen.
+type Preview struct {
+	URL     string  `json:"url"`
+	Payload Payload `json:"payload"`
+}
+
+// SendFunc verstuurt een webhook, zie Sender.Send.
+type SendFunc func(ctx context.Context, url, secret string, payload any) (Delivery, error)
+
+// SecretStore is het deel van de store dat Execute nodig heeft.
+type SecretStore interface {
+	EnsureWebhookSecret(ctx context.Context, userID uuid.UUID, candidate string) (domain.WebhookSecret, error)
+}
+
+// Execute verstuurt payload naar params.URL, ondertekend met het geheim van de gebruiker. Bij het
+// eerste gebruik wordt dat geheim aangemaakt. Een mislukte levering is een fout; de melding bevat de
+// laatste status en het aantal pogingen.
+func Execute(
+	ctx context.Context,
+	send SendFunc,
+	secrets SecretStore,
+	userID uuid.UUID,
+	params Params,
+	payload Payload,
+) (rules.Result, error) {
+	candidate, err := GenerateSecret()
+	if err != nil {
+		return rules.Result{}, fmt.Errorf("could not generate webhook secret: %w", err)
+	}
+	secret, err := secrets.EnsureWebhookSecret(ctx, userID, candidate)
+	if err != nil {
+		return rules.Result{}, fmt.Errorf("could not load webhook secret: %w", err)
+	}
+
+	delivery, err := send(ctx, params.URL, secret.Secret, payload)
+	if err != nil {
+		return rules.Result{}, err
+	}
+	return rules.Result{Details: domain.WebhookActionLogDetails{
+		ActionType: ActionType,
+		URL:        params.URL,
+		DeliveryID: delivery.DeliveryID,
+		StatusCode: delivery.StatusCode,
+		Attempts:   delivery.Attempts,
+	}}, nil
+}
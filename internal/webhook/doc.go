@@ -0,0 +1,3 @@
+// Package webhook sends signed JSON payloads to user-configured URLs, with retries and a policy
+// that keeps requests away from internal addresses.
+package webhook
@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tokenPrefix maakt inbound tokens herkenbaar, bijv. voor secret scanners
+const tokenPrefix = "hook_"
+
+// GenerateToken maakt het token van een inbound hook. Het token is de enige sleutel tot de hook,
+// dus het is lang genoeg om niet te raden.
+func GenerateToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return tokenPrefix + base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// HashToken is hoe een token opgeslagen en opgezocht wordt.
+func HashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// TemplateValues maakt template variabelen van een JSON object. Geneste velden krijgen een pad met
+// punten, bijv. {{release.version}}, en elementen van een lijst hun index, bijv. {{tags.0}}. Een
+// lijst met alleen tekst, getallen of booleans is ook als geheel beschikbaar, gescheiden door komma's.
+func TemplateValues(body []byte) (map[string]string, error) {
+	var object map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&object); err != nil || object == nil {
+		return nil, fmt.Errorf("body must be a JSON object")
+	}
+
+	values := map[string]string{}
+	flatten(values, "", object)
+	return values, nil
+}
+
+func flatten(values map[string]string, path string, value any) {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			flatten(values, join(key), child)
+		}
+	case []any:
+		scalars := make([]string, 0, len(v))
+		for i, child := range v {
+			flatten(values, join(strconv.Itoa(i)), child)
+			if s, ok := scalar(child); ok {
+				scalars = append(scalars, s)
+			}
+		}
+		if path != "" && len(scalars) == len(v) {
+			values[path] = strings.Join(scalars, ", ")
+		}
+	default:
+		if s, ok := scalar(v); ok && path != "" {
+			values[path] = s
+		}
+	}
+}
+
+// scalar geeft de tekst van een JSON waarde die geen object of lijst is; null wordt leeg.
+func scalar(value any) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", true
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	}
+	return "", false
+}
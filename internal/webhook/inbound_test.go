@@ -0,0 +1,54 @@
+package webhook
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateToken(t *testing.T) {
+	first, err := GenerateToken()
+	require.NoError(t, err)
+	second, err := GenerateToken()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(first, "hook_"))
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, HashToken(first), HashToken(first))
+	assert.NotEqual(t, HashToken(first), HashToken(second))
+}
+
+func TestTemplateValues(t *testing.T) {
+	values, err := TemplateValues([]byte(`{
+		"version": "2.4.0",
+		"build": 1234567890123,
+		"draft": false,
+		"notes": null,
+		"release": {"name": "Lente", "date": "2025-12-01T14:00:00+01:00"},
+		"stakeholders": ["pm@example.com", "cto@example.com"],
+		"commits": [{"sha": "abc123"}]
+	}`))
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"version":        "2.4.0",
+		"build":          "1234567890123",
+		"draft":          "false",
+		"notes":          "",
+		"release.name":   "Lente",
+		"release.date":   "2025-12-01T14:00:00+01:00",
+		"stakeholders":   "pm@example.com, cto@example.com",
+		"stakeholders.0": "pm@example.com",
+		"stakeholders.1": "cto@example.com",
+		"commits.0.sha":  "abc123",
+	}, values)
+}
+
+func TestTemplateValues_NotAnObject(t *testing.T) {
+	for _, body := range []string{``, `[]`, `"release"`, `null`, `{"a":`} {
+		_, err := TemplateValues([]byte(body))
+		assert.Error(t, err, body)
+	}
+}
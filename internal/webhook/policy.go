@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxURLLength houdt webhook URLs binnen een redelijke grootte voor rule parameters en logs
+const maxURLLength = 2048
+
+// blockedHostSuffixes zijn namen die naar interne services wijzen, los van waar ze naar resolven
+var blockedHostSuffixes = []string{"localhost", ".localhost", ".local", ".internal", ".home.arpa"}
+
+// ErrInternalAddress wordt teruggegeven als een request naar een intern adres zou gaan.
+var ErrInternalAddress = errors.New("request to internal address is not allowed")
+
+// ValidateURL controleert een webhook URL bij het opslaan van een rule: alleen https, geen
+// inloggegevens en geen host die al aan zijn naam of IP herkenbaar intern is. Waar een naam naar
+// resolvet wordt pas bij het verbinden gecontroleerd, zie rejectInternalAddress.
+func ValidateURL(raw string) error {
+	if len(raw) > maxURLLength {
+		return fmt.Errorf("must be at most %d characters", maxURLLength)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "https" {
+		return errors.New("must be an https URL")
+	}
+	if u.User != nil {
+		return errors.New("must not contain credentials")
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "" {
+		return errors.New("must have a host")
+	}
+	for _, suffix := range blockedHostSuffixes {
+		if host == strings.TrimPrefix(suffix, ".") || strings.HasSuffix(host, suffix) {
+			return ErrInternalAddress
+		}
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !isPublic(addr) {
+		return ErrInternalAddress
+	}
+	return nil
+}
+
+// sharedAddressSpace is 100.64.0.0/10 (RFC 6598), in gebruik voor carrier-grade NAT en sommige clouds
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// isPublic meldt of een adres op het publieke internet ligt.
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+// NewPublicClient maakt een HTTP client die alleen publieke adressen bereikt en geen redirects
+// volgt, voor requests naar URLs die van gebruikers of uit e-mail komen.
+func NewPublicClient(dialTimeout, timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: dialTimeout,
+		Control: rejectInternalAddress,
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+		// Een redirect zou het beleid van ValidateURL omzeilen
+		CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// rejectInternalAddress draait bij elke verbinding, dus ook na DNS resolutie en bij een naam die
+// later naar een intern adres wijst.
+func rejectInternalAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !isPublic(addr) {
+		return fmt.Errorf("%w: %s", ErrInternalAddress, host)
+	}
+	return nil
+}
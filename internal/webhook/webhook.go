@@ -0,0 +1,145 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Headers van een webhook request. De ontvanger controleert de handtekening met het geheim van de
+// gebruiker over "<timestamp>.<body>" en kan dubbele leveringen herkennen aan de delivery ID.
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderDelivery  = "X-Webhook-Delivery"
+)
+
+// Standaardwaarden voor verzenden: drie pogingen met 1s en 2s wachttijd ertussen.
+const (
+	defaultMaxAttempts = 3
+	defaultBackoff     = time.Second
+	requestTimeout     = 10 * time.Second
+)
+
+// Delivery beschrijft een verzonden webhook.
+type Delivery struct {
+	DeliveryID string `json:"delivery_id"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Attempts   int    `json:"attempts"`
+}
+
+// Sender verstuurt webhooks met een HTTP client die geen interne adressen bereikt.
+type Sender struct {
+	httpClient  *http.Client
+	maxAttempts int
+	backoff     time.Duration // wachttijd voor de tweede poging; daarna steeds het dubbele
+}
+
+// NewSender maakt een Sender met het SSRF-veilige beleid.
+func NewSender() *Sender {
+	return &Sender{
+		httpClient:  NewPublicClient(5*time.Second, requestTimeout),
+		maxAttempts: defaultMaxAttempts,
+		backoff:     defaultBackoff,
+	}
+}
+
+// GenerateSecret maakt een nieuw willekeurig geheim om webhooks mee te ondertekenen.
+func GenerateSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// Sign berekent de handtekening zoals in HeaderSignature: "sha256=" met de hex HMAC-SHA256.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send POST payload als JSON naar url. Netwerkfouten, 429 en 5xx worden opnieuw geprobeerd met
+// exponentiële backoff; elke poging wordt opnieuw ondertekend met dezelfde delivery ID. De Delivery
+// is ook bij een fout gevuld, zodat de laatste status gelogd kan worden.
+func (s *Sender) Send(ctx context.Context, url, secret string, payload any) (Delivery, error) {
+	delivery := Delivery{DeliveryID: uuid.NewString()}
+	if err := ValidateURL(url); err != nil {
+		return delivery, err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return delivery, fmt.Errorf("could not encode webhook payload: %w", err)
+	}
+
+	wait := s.backoff
+	for {
+		delivery.Attempts++
+		status, err := s.post(ctx, url, secret, delivery.DeliveryID, body)
+		delivery.StatusCode = status
+		if err == nil && status >= 200 && status < 300 {
+			return delivery, nil
+		}
+		if err == nil {
+			err = fmt.Errorf("webhook returned status %d", status)
+		}
+		if !retryable(status, err) || delivery.Attempts >= s.maxAttempts {
+			return delivery, fmt.Errorf("%w after %d attempt(s)", err, delivery.Attempts)
+		}
+
+		select {
+		case <-ctx.Done():
+			return delivery, ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// post doet één poging en geeft de status, of 0 als er geen antwoord kwam.
+func (s *Sender) post(ctx context.Context, url, secret, deliveryID string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "agenda-automator-webhook/1")
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderDelivery, deliveryID)
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	// Lees een beetje van de body zodat de verbinding hergebruikt kan worden
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// retryable meldt of een poging opnieuw mag: zonder antwoord, bij rate limiting of een serverfout.
+// Een geweigerd intern adres blijft geweigerd.
+func retryable(status int, err error) bool {
+	if errors.Is(err, ErrInternalAddress) {
+		return false
+	}
+	return status == 0 || status == http.StatusTooManyRequests || status >= 500
+}
@@ -2,35 +2,111 @@ package calendar
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"golang.org/x/oauth2"
 	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/gmail/v1"
 	"google.golang.org/api/option"
 
 	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
 	"agenda-automator-api/internal/store"
+	"agenda-automator-api/internal/webhook"
 )
 
 // CalendarProcessor handles calendar event processing
 type CalendarProcessor struct {
 	store      store.Storer
+	engine     *rules.Engine[*eventSubject]
 	newService func(ctx context.Context, client *http.Client) (*calendar.Service, error)
+	// newGmailService is voor acties die via Gmail mailen, zoals send_email
+	newGmailService func(ctx context.Context, client *http.Client) (*gmail.Service, error)
+	sendWebhook     webhook.SendFunc
 }
 
+// reminderDescriptionPrefix markeert events die we zelf hebben aangemaakt
+const reminderDescriptionPrefix = "Automatische reminder voor:"
+
 // NewCalendarProcessor creates a new calendar processor
 func NewCalendarProcessor(s store.Storer) *CalendarProcessor {
 	return &CalendarProcessor{
 		store: s,
+		engine: rules.NewEngine(Rules, rules.Config[*eventSubject]{
+			Name:     "Calendar",
+			RuleType: domain.RuleTypeCalendar,
+			Logs:     s,
+			Describe: describeEvent,
+			// Een reminder per event per rule: eerdere successen tellen als afgehandeld
+			AlreadyHandled: func(ctx context.Context, rule rules.Rule, subject *eventSubject) (bool, error) {
+				return s.HasLogForTrigger(ctx, rule.ID, rule.AccountID, subject.event.Id)
+			},
+			RecordExecution: func(ctx context.Context, rule rules.Rule) (int, error) {
+				return s.RecordRuleExecution(ctx, rule.ID)
+			},
+			Expire: func(ctx context.Context, rule rules.Rule) error {
+				_, err := s.ExpireRule(ctx, rule.ID)
+				return err
+			},
+			Limits: rules.LimitsFromEnv(),
+			ExecutionCounts: func(ctx context.Context, rule rules.Rule) (int, int, error) {
+				return s.GetRuleExecutionCounts(ctx, rule.ID, rule.AccountID)
+			},
+			CountExecution: func(ctx context.Context, rule rules.Rule) (int, error) {
+				_, accountDay, err := s.CountRuleExecution(ctx, domain.RuleTypeCalendar, rule.ID, rule.AccountID)
+				return accountDay, err
+			},
+			BusiestRule: func(ctx context.Context, accountID uuid.UUID) (domain.RuleType, uuid.UUID, error) {
+				return s.GetBusiestRule(ctx, accountID)
+			},
+			Pause:         rules.PauseWith(s),
+			Notifications: s,
+		}),
 		newService: func(ctx context.Context, client *http.Client) (*calendar.Service, error) {
 			return calendar.NewService(ctx, option.WithHTTPClient(client))
 		},
+		newGmailService: func(ctx context.Context, client *http.Client) (*gmail.Service, error) {
+			return gmail.NewService(ctx, option.WithHTTPClient(client))
+		},
+		sendWebhook: webhook.NewSender().Send,
+	}
+}
+
+// rulesForAccount geeft de event rules van het account plus de user-level event rules van de
+// eigenaar die op het account van toepassing zijn. Die laatste krijgen het account als
+// ConnectedAccountID, zodat logs, dedupe en limieten per account blijven. Rules die aan een inbound
+// hook gebonden zijn draaien op leveringen en niet hier.
+func (cp *CalendarProcessor) rulesForAccount(
+	ctx context.Context,
+	acc *domain.ConnectedAccount,
+) ([]domain.AutomationRule, error) {
+	accountRules, err := cp.store.GetRulesForAccount(ctx, acc.ID)
+	if err != nil {
+		return nil, err
+	}
+	userRules, err := cp.store.GetUserRules(ctx, acc.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var eventRules []domain.AutomationRule
+	for _, rule := range accountRules {
+		if rule.Trigger() == domain.CalendarTriggerEventMatch {
+			eventRules = append(eventRules, rule)
+		}
+	}
+	for _, rule := range userRules {
+		if rule.Trigger() == domain.CalendarTriggerEventMatch && rule.AppliesTo(acc) {
+			rule.ConnectedAccountID = acc.ID
+			eventRules = append(eventRules, rule)
+		}
 	}
+	return eventRules, nil
 }
 
 // ProcessEvents processes calendar events for automation rules.
@@ -39,16 +115,53 @@ func (cp *CalendarProcessor) ProcessEvents(
 	acc *domain.ConnectedAccount,
 	token *oauth2.Token,
 ) error {
-	rules, err := cp.store.GetRulesForAccount(ctx, acc.ID)
+	return cp.processEvents(ctx, acc, token, uuid.Nil)
+}
+
+// ProcessRule verwerkt de events van het account voor één rule, zoals ProcessEvents dat doet:
+// een uitgezette rule of een rule buiten zijn schedule draait ook hier niet.
+func (cp *CalendarProcessor) ProcessRule(
+	ctx context.Context,
+	acc *domain.ConnectedAccount,
+	token *oauth2.Token,
+	ruleID uuid.UUID,
+) error {
+	return cp.processEvents(ctx, acc, token, ruleID)
+}
+
+// processEvents verwerkt de events tegen de rules van het account; met een ruleID alleen tegen die rule.
+func (cp *CalendarProcessor) processEvents(
+	ctx context.Context,
+	acc *domain.ConnectedAccount,
+	token *oauth2.Token,
+	ruleID uuid.UUID,
+) error {
+	calendarRules, err := cp.rulesForAccount(ctx, acc)
 	if err != nil {
 		return fmt.Errorf("could not fetch automation rules: %w", err)
 	}
 
-	if len(rules) == 0 {
+	if len(calendarRules) == 0 {
 		log.Printf("[Calendar] No rules found for %s. Skipping.", acc.Email)
 		return nil
 	}
 
+	// Alleen actieve rules binnen hun schedule; verlopen rules worden hier uitgezet
+	now := time.Now()
+	dueRules := make([]rules.Rule, 0, len(calendarRules))
+	for _, rule := range calendarRules {
+		if !rule.IsActive || (ruleID != uuid.Nil && rule.ID != ruleID) {
+			continue
+		}
+		if r := engineRule(rule); cp.engine.Due(ctx, r, now) {
+			dueRules = append(dueRules, r)
+		}
+	}
+	if len(dueRules) == 0 {
+		log.Printf("[Calendar] No rules due for %s. Skipping.", acc.Email)
+		return nil
+	}
+
 	// Create calendar service
 	client := oauth2.NewClient(ctx, oauth2.StaticTokenSource(token))
 	srv, err := cp.newService(ctx, client)
@@ -78,199 +191,28 @@ func (cp *CalendarProcessor) ProcessEvents(
 		return nil
 	}
 
-	log.Printf("[Calendar] Checking %d events against %d rules for %s...", len(events.Items), len(rules), acc.Email)
+	log.Printf("[Calendar] Checking %d events against %d rules for %s...", len(events.Items), len(dueRules), acc.Email)
 
 	for _, event := range events.Items {
 		// Skip own created events
-		if strings.HasPrefix(event.Description, "Automatische reminder voor:") {
+		if isOwnReminder(event) {
 			continue
 		}
 
-		for _, rule := range rules {
-			if !rule.IsActive {
-				continue
-			}
-
-			var trigger domain.TriggerConditions
-			if err = json.Unmarshal(rule.TriggerConditions, &trigger); err != nil {
-				log.Printf("[Calendar] ERROR unmarshaling trigger for rule %s: %v", rule.ID, err)
-				continue
-			}
-
-			// Check triggers
-			summaryMatch := false
-			if trigger.SummaryEquals != "" && event.Summary == trigger.SummaryEquals {
-				summaryMatch = true
-			}
-			if !summaryMatch && len(trigger.SummaryContains) > 0 {
-				for _, contain := range trigger.SummaryContains {
-					if strings.Contains(event.Summary, contain) {
-						summaryMatch = true
-						break
-					}
-				}
-			}
-			if !summaryMatch {
-				continue
-			}
-
-			locationMatch := false
-			if len(trigger.LocationContains) == 0 {
-				locationMatch = true
-			} else {
-				eventLocationLower := strings.ToLower(event.Location)
-				for _, loc := range trigger.LocationContains {
-					if strings.Contains(eventLocationLower, strings.ToLower(loc)) {
-						locationMatch = true
-						break
-					}
-				}
-			}
-			if !locationMatch {
-				continue
-			}
-
-			// Check logs
-			hasLogged, err := cp.store.HasLogForTrigger(ctx, rule.ID, event.Id)
-			if err != nil {
-				log.Printf("[Calendar] ERROR checking logs for event %s / rule %s: %v", event.Id, rule.ID, err)
-			}
-			if hasLogged {
-				continue
-			}
-
-			log.Printf("[Calendar] MATCH: Event '%s' (ID: %s) matches rule '%s'.", event.Summary, event.Id, rule.Name)
-
-			var action domain.ActionParams
-			if err = json.Unmarshal(rule.ActionParams, &action); err != nil {
-				log.Printf("[Calendar] ERROR unmarshaling action for rule %s: %v", rule.ID, err)
-				continue
-			}
-
-			if action.NewEventTitle == "" {
-				log.Printf("[Calendar] ERROR: Rule %s heeft geen 'new_event_title'.", rule.ID)
-				continue
-			}
-
-			startTime, err := time.Parse(time.RFC3339, event.Start.DateTime)
-			if err != nil {
-				log.Printf("[Calendar] ERROR parsing start time: %v", err)
-				continue
-			}
-
-			offset := action.OffsetMinutes
-			if offset == 0 {
-				offset = -60
-			}
-			reminderTime := startTime.Add(time.Duration(offset) * time.Minute)
-
-			durMin := action.DurationMin
-			if durMin == 0 {
-				durMin = 5
-			}
-			endTime := reminderTime.Add(time.Duration(durMin) * time.Minute)
-
-			title := action.NewEventTitle
-
-			// Check for duplicates
-			if cp.eventExists(srv, reminderTime, endTime, title) {
-				log.Printf("[Calendar] SKIP: Reminder event '%s' at %s already exists.", title, reminderTime)
-
-				triggerDetailsJSON, _ := json.Marshal(domain.TriggerLogDetails{
-					GoogleEventID:  event.Id,
-					TriggerSummary: event.Summary,
-					TriggerTime:    startTime,
-				})
-				actionDetailsJSON, _ := json.Marshal(domain.ActionLogDetails{
-					CreatedEventID:      "unknown-pre-existing",
-					CreatedEventSummary: title,
-					ReminderTime:        reminderTime,
-				})
-				logParams := store.CreateLogParams{
-					ConnectedAccountID: acc.ID,
-					RuleID:             &rule.ID,
-					Status:             domain.LogSkipped,
-					TriggerDetails:     triggerDetailsJSON,
-					ActionDetails:      actionDetailsJSON,
-				}
-				if err = cp.store.CreateAutomationLog(ctx, logParams); err != nil {
-					log.Printf("[Calendar] ERROR saving skip log for rule %s: %v", rule.ID, err)
-				}
-				continue
-			}
-
-			// Create event
-			newEvent := &calendar.Event{
-				Summary: title,
-				Start: &calendar.EventDateTime{
-					DateTime: reminderTime.Format(time.RFC3339),
-					TimeZone: event.Start.TimeZone,
-				},
-				End: &calendar.EventDateTime{
-					DateTime: endTime.Format(time.RFC3339),
-					TimeZone: event.End.TimeZone,
-				},
-				Description: fmt.Sprintf("Automatische reminder voor: %s\nGemaakt door regel: %s", event.Summary, rule.Name),
-			}
-
-			createdEvent, err := srv.Events.Insert("primary", newEvent).Do()
-			if err != nil {
-				log.Printf("[Calendar] ERROR creating reminder event: %v", err)
-
-				triggerDetailsJSON, _ := json.Marshal(domain.TriggerLogDetails{
-					GoogleEventID:  event.Id,
-					TriggerSummary: event.Summary,
-					TriggerTime:    startTime,
-				})
-				logParams := store.CreateLogParams{
-					ConnectedAccountID: acc.ID,
-					RuleID:             &rule.ID,
-					Status:             domain.LogFailure,
-					TriggerDetails:     triggerDetailsJSON,
-					ErrorMessage:       err.Error(),
-				}
-				if err = cp.store.CreateAutomationLog(ctx, logParams); err != nil {
-					log.Printf("[Calendar] ERROR saving failure log for rule %s: %v", rule.ID, err)
-				}
-				continue
-			}
-
-			// Log success
-			triggerDetailsJSON, _ := json.Marshal(domain.TriggerLogDetails{
-				GoogleEventID:  event.Id,
-				TriggerSummary: event.Summary,
-				TriggerTime:    startTime,
-			})
-			actionDetailsJSON, _ := json.Marshal(domain.ActionLogDetails{
-				CreatedEventID:      createdEvent.Id,
-				CreatedEventSummary: createdEvent.Summary,
-				ReminderTime:        reminderTime,
-			})
-
-			logParams := store.CreateLogParams{
-				ConnectedAccountID: acc.ID,
-				RuleID:             &rule.ID,
-				Status:             domain.LogSuccess,
-				TriggerDetails:     triggerDetailsJSON,
-				ActionDetails:      actionDetailsJSON,
-			}
-			if err = cp.store.CreateAutomationLog(ctx, logParams); err != nil {
-				log.Printf("[Calendar] ERROR saving success log for rule %s: %v", rule.ID, err)
-			}
-
-			log.Printf(
-				"[Calendar] SUCCESS: Created reminder '%s' (ID: %s) for event '%s' (ID: %s)",
-				createdEvent.Summary,
-				createdEvent.Id,
-				event.Summary,
-				event.Id,
-			)
+		subject := &eventSubject{cp: cp, srv: srv, acc: acc, event: event, now: now}
+		for i := range dueRules {
+			cp.engine.Run(ctx, &dueRules[i], subject)
 		}
 	}
 
 	return nil
 }
 
+// isOwnReminder herkent reminder events die we zelf hebben aangemaakt.
+func isOwnReminder(event *calendar.Event) bool {
+	return strings.HasPrefix(event.Description, reminderDescriptionPrefix)
+}
+
 // eventExists checks if an event with the same title exists in the given time window
 func (cp *CalendarProcessor) eventExists(srv *calendar.Service, start, end time.Time, title string) bool {
 	timeMin := start.Add(-1 * time.Minute).Format(time.RFC3339)
@@ -9,6 +9,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -143,7 +144,10 @@ func TestCalendar_ProcessEvents_MatchAndCreate(t *testing.T) {
 
 	// 4. Stel de mock store verwachtingen in
 	mockStore.On("GetRulesForAccount", ctx, accountID).Return([]domain.AutomationRule{testRule}, nil).Once()
-	mockStore.On("HasLogForTrigger", ctx, ruleID, triggerEventID).Return(false, nil).Once() // Nog niet gelogd
+	mockStore.On("GetUserRules", ctx, uuid.Nil).Return([]domain.AutomationRule{}, nil).Once()
+	mockStore.On("HasLogForTrigger", ctx, ruleID, accountID, triggerEventID).Return(false, nil).Once() // Nog niet gelogd
+	mockStore.On("GetRuleExecutionCounts", ctx, ruleID, accountID).Return(1, 1, nil).Once()            // Ruim binnen de limieten
+	mockStore.On("CountRuleExecution", ctx, domain.RuleTypeCalendar, ruleID, accountID).Return(2, 2, nil).Once()
 
 	// Verwacht dat de SUCCES log wordt aangemaakt
 	mockStore.On("CreateAutomationLog", ctx, mock.MatchedBy(func(params store.CreateLogParams) bool {
@@ -209,9 +213,10 @@ func TestCalendar_ProcessEvents_AlreadyLogged(t *testing.T) {
 
 	// 3. Stel de mock store verwachtingen in
 	mockStore.On("GetRulesForAccount", ctx, accountID).Return([]domain.AutomationRule{testRule}, nil).Once()
+	mockStore.On("GetUserRules", ctx, uuid.Nil).Return([]domain.AutomationRule{}, nil).Once()
 
 	// BELANGRIJK: De log bestaat al!
-	mockStore.On("HasLogForTrigger", ctx, ruleID, triggerEventID).Return(true, nil).Once()
+	mockStore.On("HasLogForTrigger", ctx, ruleID, accountID, triggerEventID).Return(true, nil).Once()
 
 	// --- Act ---
 	err := processor.ProcessEvents(ctx, &domain.ConnectedAccount{ID: accountID}, testToken)
@@ -223,3 +228,259 @@ func TestCalendar_ProcessEvents_AlreadyLogged(t *testing.T) {
 	// Verifieer dat we NOOIT een log hebben proberen te maken (omdat we skipten)
 	mockStore.AssertNotCalled(t, "CreateAutomationLog")
 }
+
+// Test 2b: User-level rules draaien alleen op accounts die hun selector kiest, en de dedupe
+// gebeurt per account.
+func TestCalendar_ProcessEvents_UserRules(t *testing.T) {
+	// --- Arrange ---
+	userID := uuid.New()
+	acc := &domain.ConnectedAccount{ID: uuid.New(), UserID: userID, Provider: domain.ProviderGoogle}
+	triggerEventID := "trigger-event-id"
+
+	triggerCond, _ := json.Marshal(domain.TriggerConditions{SummaryEquals: "Dienst"})
+	userRule := func(selector domain.AccountSelector) domain.AutomationRule {
+		return domain.AutomationRule{
+			BaseAutomationRule: domain.BaseAutomationRule{
+				AccountEntity:     domain.AccountEntity{BaseEntity: domain.BaseEntity{ID: uuid.New()}},
+				IsActive:          true,
+				TriggerConditions: triggerCond,
+				ActionParams:      json.RawMessage(`{}`),
+				UserID:            &userID,
+				AccountSelector:   &selector,
+			},
+		}
+	}
+	googleRule := userRule(domain.AccountSelector{Mode: domain.AccountSelectorProvider, Provider: domain.ProviderGoogle})
+	otherRule := userRule(domain.AccountSelector{Mode: domain.AccountSelectorAccounts, AccountIDs: []uuid.UUID{uuid.New()}})
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && strings.Contains(r.URL.Path, "/events") {
+			listResp := calendar.Events{
+				Items: []*calendar.Event{
+					{Id: triggerEventID, Summary: "Dienst", Start: &calendar.EventDateTime{DateTime: "2025-11-30T09:00:00Z"}},
+				},
+			}
+			json.NewEncoder(w).Encode(listResp)
+			return
+		}
+		t.Errorf("Onverwacht request naar Fake Google API: %s %s", r.Method, r.URL.Path)
+	})
+
+	processor, mockStore, server := setupCalendarTest(t, handler)
+	defer server.Close()
+	processor.newService = func(ctx context.Context, client *http.Client) (*calendar.Service, error) {
+		return calendar.NewService(ctx, option.WithHTTPClient(client), option.WithEndpoint(server.URL))
+	}
+
+	ctx := context.Background()
+	mockStore.On("GetRulesForAccount", ctx, acc.ID).Return([]domain.AutomationRule{}, nil).Once()
+	mockStore.On("GetUserRules", ctx, userID).Return([]domain.AutomationRule{googleRule, otherRule}, nil).Once()
+
+	// Alleen de Google rule draait; de dedupe kijkt naar de logs van dit account
+	mockStore.On("HasLogForTrigger", ctx, googleRule.ID, acc.ID, triggerEventID).Return(true, nil).Once()
+
+	// --- Act ---
+	err := processor.ProcessEvents(ctx, acc, mockToken())
+
+	// --- Assert ---
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "HasLogForTrigger", ctx, otherRule.ID, mock.Anything, mock.Anything)
+	mockStore.AssertNotCalled(t, "CreateAutomationLog")
+}
+
+func TestCalendar_ProcessEvents_SkipsHookRules(t *testing.T) {
+	// --- Arrange ---
+	acc := &domain.ConnectedAccount{ID: uuid.New(), UserID: uuid.New(), Provider: domain.ProviderGoogle}
+	hookRule := domain.AutomationRule{
+		BaseAutomationRule: domain.BaseAutomationRule{
+			AccountEntity:     domain.AccountEntity{BaseEntity: domain.BaseEntity{ID: uuid.New()}, ConnectedAccountID: acc.ID},
+			IsActive:          true,
+			TriggerConditions: json.RawMessage(`{"hook_id": "` + uuid.NewString() + `"}`),
+			ActionParams:      json.RawMessage(`{"title": "Release", "start": "{{date}}"}`),
+		},
+		TriggerType: domain.CalendarTriggerHookReceived,
+		ActionType:  domain.HookActionCreateEvent,
+	}
+
+	// Zonder event rules worden de events niet eens opgehaald
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Onverwacht request naar Fake Google API: %s %s", r.Method, r.URL.Path)
+	})
+	processor, mockStore, server := setupCalendarTest(t, handler)
+	defer server.Close()
+
+	ctx := context.Background()
+	mockStore.On("GetRulesForAccount", ctx, acc.ID).Return([]domain.AutomationRule{hookRule}, nil).Once()
+	mockStore.On("GetUserRules", ctx, acc.UserID).Return([]domain.AutomationRule{}, nil).Once()
+
+	// --- Act ---
+	err := processor.ProcessEvents(ctx, acc, mockToken())
+
+	// --- Assert ---
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+}
+
+func TestCalendar_ProcessRule(t *testing.T) {
+	// --- Arrange ---
+	acc := &domain.ConnectedAccount{ID: uuid.New(), UserID: uuid.New()}
+	triggerEventID := "trigger-event-id"
+
+	triggerCond, _ := json.Marshal(domain.TriggerConditions{SummaryEquals: "Dienst"})
+	accountRule := func() domain.AutomationRule {
+		return domain.AutomationRule{
+			BaseAutomationRule: domain.BaseAutomationRule{
+				AccountEntity: domain.AccountEntity{
+					BaseEntity:         domain.BaseEntity{ID: uuid.New()},
+					ConnectedAccountID: acc.ID,
+				},
+				IsActive:          true,
+				TriggerConditions: triggerCond,
+				ActionParams:      json.RawMessage(`{}`),
+			},
+		}
+	}
+	target := accountRule()
+	other := accountRule()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && strings.Contains(r.URL.Path, "/events") {
+			listResp := calendar.Events{
+				Items: []*calendar.Event{
+					{Id: triggerEventID, Summary: "Dienst", Start: &calendar.EventDateTime{DateTime: "2025-11-30T09:00:00Z"}},
+				},
+			}
+			json.NewEncoder(w).Encode(listResp)
+			return
+		}
+		t.Errorf("Onverwacht request naar Fake Google API: %s %s", r.Method, r.URL.Path)
+	})
+
+	processor, mockStore, server := setupCalendarTest(t, handler)
+	defer server.Close()
+	processor.newService = func(ctx context.Context, client *http.Client) (*calendar.Service, error) {
+		return calendar.NewService(ctx, option.WithHTTPClient(client), option.WithEndpoint(server.URL))
+	}
+
+	ctx := context.Background()
+	mockStore.On("GetRulesForAccount", ctx, acc.ID).Return([]domain.AutomationRule{other, target}, nil).Once()
+	mockStore.On("GetUserRules", ctx, acc.UserID).Return([]domain.AutomationRule{}, nil).Once()
+	mockStore.On("HasLogForTrigger", ctx, target.ID, acc.ID, triggerEventID).Return(true, nil).Once()
+
+	// --- Act ---
+	err := processor.ProcessRule(ctx, acc, mockToken(), target.ID)
+
+	// --- Assert ---
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "HasLogForTrigger", ctx, other.ID, mock.Anything, mock.Anything)
+}
+
+// Test 3: Een verlopen regel wordt uitgezet en er worden geen events opgehaald.
+func TestCalendar_ProcessEvents_ExpiredRule(t *testing.T) {
+	// --- Arrange ---
+	accountID := uuid.New()
+	ruleID := uuid.New()
+	validUntil := time.Now().Add(-time.Hour)
+
+	testRule := domain.AutomationRule{
+		BaseAutomationRule: domain.BaseAutomationRule{
+			AccountEntity: domain.AccountEntity{
+				BaseEntity:         domain.BaseEntity{ID: ruleID},
+				ConnectedAccountID: accountID,
+			},
+			IsActive:          true,
+			TriggerConditions: json.RawMessage(`{"summary_equals":"Dienst"}`),
+			ActionParams:      json.RawMessage(`{}`),
+			Schedule:          &domain.RuleSchedule{ValidUntil: &validUntil},
+		},
+	}
+
+	// Fake Google API - mag niet aangeroepen worden
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected Google API call: %s %s", r.Method, r.URL.Path)
+	})
+
+	processor, mockStore, server := setupCalendarTest(t, handler)
+	defer server.Close()
+	processor.newService = func(ctx context.Context, client *http.Client) (*calendar.Service, error) {
+		return calendar.NewService(ctx, option.WithHTTPClient(client), option.WithEndpoint(server.URL))
+	}
+
+	ctx := context.Background()
+	mockStore.On("GetRulesForAccount", ctx, accountID).Return([]domain.AutomationRule{testRule}, nil).Once()
+	mockStore.On("GetUserRules", ctx, uuid.Nil).Return([]domain.AutomationRule{}, nil).Once()
+	mockStore.On("ExpireRule", ctx, ruleID).Return(domain.AutomationRule{}, nil).Once()
+	mockStore.On("CreateAutomationLog", ctx, mock.MatchedBy(func(p store.CreateLogParams) bool {
+		return p.RuleID != nil && *p.RuleID == ruleID && p.Status == domain.LogSkipped
+	})).Return(nil).Once()
+
+	// --- Act ---
+	err := processor.ProcessEvents(ctx, &domain.ConnectedAccount{ID: accountID}, mockToken())
+
+	// --- Assert ---
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+}
+
+// Test 4: Een regel boven de uurlimiet wordt gepauzeerd en de gebruiker krijgt een melding.
+func TestCalendar_ProcessEvents_RuleLimitPauses(t *testing.T) {
+	t.Setenv("RULE_MAX_EXECUTIONS_PER_HOUR", "5")
+
+	// --- Arrange ---
+	accountID := uuid.New()
+	ruleID := uuid.New()
+	triggerEventID := "trigger-event-id"
+
+	testRule := domain.AutomationRule{
+		BaseAutomationRule: domain.BaseAutomationRule{
+			AccountEntity: domain.AccountEntity{
+				BaseEntity:         domain.BaseEntity{ID: ruleID},
+				ConnectedAccountID: accountID,
+			},
+			Name:              "Dienst",
+			IsActive:          true,
+			TriggerConditions: json.RawMessage(`{"summary_equals":"Dienst"}`),
+			ActionParams:      json.RawMessage(`{"new_event_title":"Reminder"}`),
+		},
+	}
+
+	// Fake Google API - alleen de lijst; er mag geen reminder aangemaakt worden
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("unexpected Google API call: %s %s", r.Method, r.URL.Path)
+			return
+		}
+		json.NewEncoder(w).Encode(calendar.Events{Items: []*calendar.Event{
+			{Id: triggerEventID, Summary: "Dienst", Start: &calendar.EventDateTime{DateTime: "2025-11-30T09:00:00Z"}},
+		}})
+	})
+
+	processor, mockStore, server := setupCalendarTest(t, handler)
+	defer server.Close()
+	processor.newService = func(ctx context.Context, client *http.Client) (*calendar.Service, error) {
+		return calendar.NewService(ctx, option.WithHTTPClient(client), option.WithEndpoint(server.URL))
+	}
+
+	ctx := context.Background()
+	mockStore.On("GetRulesForAccount", ctx, accountID).Return([]domain.AutomationRule{testRule}, nil).Once()
+	mockStore.On("GetUserRules", ctx, uuid.Nil).Return([]domain.AutomationRule{}, nil).Once()
+	mockStore.On("HasLogForTrigger", ctx, ruleID, accountID, triggerEventID).Return(false, nil).Once()
+	mockStore.On("GetRuleExecutionCounts", ctx, ruleID, accountID).Return(5, 5, nil).Once()
+	mockStore.On("PauseRule", ctx, ruleID).Return(testRule, nil).Once()
+	mockStore.On("CreateAutomationLog", ctx, mock.MatchedBy(func(p store.CreateLogParams) bool {
+		return p.Status == domain.LogSkipped && strings.Contains(string(p.ActionDetails), "rule_paused")
+	})).Return(nil).Once()
+	mockStore.On("CreateNotification", ctx, mock.MatchedBy(func(p store.CreateNotificationParams) bool {
+		return p.ConnectedAccountID == accountID && p.Type == domain.NotificationRulePaused &&
+			strings.Contains(string(p.Details), `"rule_type":"calendar"`)
+	})).Return(domain.Notification{}, nil).Once()
+
+	// --- Act ---
+	err := processor.ProcessEvents(ctx, &domain.ConnectedAccount{ID: accountID}, mockToken())
+
+	// --- Assert ---
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+}
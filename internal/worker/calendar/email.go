@@ -0,0 +1,169 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/gmail/v1"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/email"
+	"agenda-automator-api/internal/rules"
+)
+
+// Ontvangers van send_email
+const (
+	recipientsExternal = "external" // deelnemers buiten het domein van het account (standaard)
+	recipientsAll      = "all"      // alle deelnemers behalve het account zelf
+)
+
+// sendEmailParams zijn de action_params van send_email. Subject en body mogen {{summary}}, {{start}},
+// {{end}} en {{location}} bevatten.
+type sendEmailParams struct {
+	Recipients string `json:"recipients"`
+	Subject    string `json:"subject"`
+	Body       string `json:"body"`
+}
+
+func (p sendEmailParams) Validate() error {
+	var errs rules.FieldErrors
+	switch p.Recipients {
+	case "", recipientsExternal, recipientsAll:
+	default:
+		errs.Add("recipients", "must be %q or %q", recipientsExternal, recipientsAll)
+	}
+	if strings.TrimSpace(p.Subject) == "" {
+		errs.Add("subject", "is required")
+	}
+	if strings.TrimSpace(p.Body) == "" {
+		errs.Add("body", "is required")
+	}
+	return errs.Err()
+}
+
+// EmailPreview is de e-mail die send_email voor een event zou sturen.
+type EmailPreview struct {
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	Body    string   `json:"body"`
+}
+
+// planEmail vult de templates in en bepaalt de ontvangers.
+func planEmail(s *eventSubject, action sendEmailParams) EmailPreview {
+	to := externalAttendees(s.event, s.acc)
+	if action.Recipients == recipientsAll {
+		to = attendees(s.event)
+	}
+	values := eventTemplateValues(s.event)
+	return EmailPreview{
+		To:      to,
+		Subject: rules.ExpandTemplate(action.Subject, values),
+		Body:    rules.ExpandTemplate(action.Body, values),
+	}
+}
+
+func previewEmail(_ context.Context, s *eventSubject, _ rules.Rule, action sendEmailParams) (any, error) {
+	return planEmail(s, action), nil
+}
+
+// sendEmail stuurt de deelnemers van het event een e-mail via de Gmail API van hetzelfde account.
+func sendEmail(ctx context.Context, s *eventSubject, _ rules.Rule, action sendEmailParams) (rules.Result, error) {
+	plan := planEmail(s, action)
+	details := domain.EmailActionLogDetails{ActionType: domain.CalendarActionSendEmail, To: plan.To}
+	if len(plan.To) == 0 {
+		return rules.Result{Skipped: true, Details: details}, nil
+	}
+
+	token, err := s.cp.store.GetValidTokenForAccount(ctx, s.acc.ID)
+	if err != nil {
+		return rules.Result{}, fmt.Errorf("could not get token for account: %w", err)
+	}
+	srv, err := s.cp.newGmailService(ctx, oauth2.NewClient(ctx, oauth2.StaticTokenSource(token)))
+	if err != nil {
+		return rules.Result{}, fmt.Errorf("could not create Gmail service: %w", err)
+	}
+
+	raw, err := (&email.Message{To: plan.To, Subject: plan.Subject, Text: plan.Body}).Raw()
+	if err != nil {
+		return rules.Result{}, fmt.Errorf("could not compose email: %w", err)
+	}
+	sent, err := srv.Users.Messages.Send("me", &gmail.Message{Raw: raw}).Context(ctx).Do()
+	if err != nil {
+		return rules.Result{}, fmt.Errorf("could not send email: %w", err)
+	}
+
+	log.Printf("[Calendar] SUCCESS: Sent email (ID: %s) for event '%s' (ID: %s) to %d recipients",
+		sent.Id, s.event.Summary, s.event.Id, len(plan.To))
+
+	details.SentMessageID = sent.Id
+	return rules.Result{Details: details}, nil
+}
+
+// attendees geeft de adressen van de deelnemers die de e-mail kunnen krijgen: niet het account
+// zelf, geen zalen of andere resources en niemand die heeft afgeslagen.
+func attendees(event *calendar.Event) []string {
+	var addresses []string
+	for _, attendee := range event.Attendees {
+		if attendee.Self || attendee.Resource || attendee.ResponseStatus == "declined" || attendee.Email == "" {
+			continue
+		}
+		addresses = append(addresses, attendee.Email)
+	}
+	return addresses
+}
+
+// externalAttendees geeft de deelnemers met een ander e-maildomein dan het account. Zonder account
+// (zoals in een simulatie zonder account) telt elke deelnemer als extern.
+func externalAttendees(event *calendar.Event, acc *domain.ConnectedAccount) []string {
+	accountDomain := ""
+	if acc != nil {
+		accountDomain = emailDomain(acc.Email)
+	}
+	var external []string
+	for _, address := range attendees(event) {
+		if accountDomain == "" || emailDomain(address) != accountDomain {
+			external = append(external, address)
+		}
+	}
+	return external
+}
+
+func emailDomain(address string) string {
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(address[at+1:])
+}
+
+// eventTemplateValues zijn de variabelen voor de templates van send_email.
+func eventTemplateValues(event *calendar.Event) map[string]string {
+	values := map[string]string{
+		"summary":  event.Summary,
+		"location": event.Location,
+	}
+	if event.Start != nil {
+		values["start"] = formatEventTime(event.Start)
+	}
+	if event.End != nil {
+		values["end"] = formatEventTime(event.End)
+	}
+	return values
+}
+
+// formatEventTime toont een event tijd in de tijdzone van het event, zoals de deelnemers hem kennen.
+func formatEventTime(eventTime *calendar.EventDateTime) string {
+	t, err := time.Parse(time.RFC3339, eventTime.DateTime)
+	if err != nil {
+		return eventTime.DateTime
+	}
+	if loc, err := time.LoadLocation(eventTime.TimeZone); err == nil && eventTime.TimeZone != "" {
+		t = t.In(loc)
+	}
+	return t.Format("2006-01-02 15:04")
+}
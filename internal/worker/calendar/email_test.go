@@ -0,0 +1,94 @@
+package calendar
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/email"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+)
+
+func TestSendEmail_ExternalAttendees(t *testing.T) {
+	var sent *email.Parsed
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || !strings.HasSuffix(r.URL.Path, "/gmail/v1/users/me/messages/send") {
+			t.Errorf("Onverwacht request naar Fake Google API: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var msg gmail.Message
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		raw, err := email.DecodeRaw(msg.Raw)
+		require.NoError(t, err)
+		sent, err = email.Parse(raw)
+		require.NoError(t, err)
+		json.NewEncoder(w).Encode(gmail.Message{Id: "sent-1"})
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	mockStore := new(store.MockStore)
+	processor := NewCalendarProcessor(mockStore)
+	processor.newGmailService = func(ctx context.Context, client *http.Client) (*gmail.Service, error) {
+		return gmail.NewService(ctx, option.WithHTTPClient(client), option.WithEndpoint(server.URL))
+	}
+	acc := &domain.ConnectedAccount{ID: uuid.New(), Email: "planning@bedrijf.nl"}
+	mockStore.On("GetValidTokenForAccount", context.Background(), acc.ID).Return(mockToken(), nil).Once()
+
+	event := &calendar.Event{
+		Id:       "event-1",
+		Summary:  "Intake",
+		Location: "Utrecht",
+		Start:    &calendar.EventDateTime{DateTime: "2025-11-30T09:00:00Z", TimeZone: "Europe/Amsterdam"},
+		Attendees: []*calendar.EventAttendee{
+			{Email: "planning@bedrijf.nl", Self: true},
+			{Email: "collega@bedrijf.nl"},
+			{Email: "klant@example.com"},
+			{Email: "zaal@resource.calendar.google.com", Resource: true},
+		},
+	}
+	params := sendEmailParams{Subject: "Bevestiging {{summary}}", Body: "Tot {{start}} in {{location}}."}
+
+	result, err := sendEmail(context.Background(), &eventSubject{cp: processor, acc: acc, event: event}, rules.Rule{Name: "Bevestiging"}, params)
+
+	require.NoError(t, err)
+	assert.False(t, result.Skipped)
+	assert.Equal(t, domain.EmailActionLogDetails{
+		ActionType:    domain.CalendarActionSendEmail,
+		SentMessageID: "sent-1",
+		To:            []string{"klant@example.com"},
+	}, result.Details)
+	require.NotNil(t, sent)
+	assert.Equal(t, "<klant@example.com>", sent.To)
+	assert.Equal(t, "Bevestiging Intake", sent.Subject)
+	assert.Contains(t, sent.Text, "Tot 2025-11-30 10:00 in Utrecht.")
+	mockStore.AssertExpectations(t)
+}
+
+func TestSendEmail_NoRecipients(t *testing.T) {
+	mockStore := new(store.MockStore)
+	processor := NewCalendarProcessor(mockStore)
+	acc := &domain.ConnectedAccount{Email: "planning@bedrijf.nl"}
+	event := &calendar.Event{Id: "event-1", Attendees: []*calendar.EventAttendee{{Email: "collega@bedrijf.nl"}}}
+
+	result, err := sendEmail(context.Background(), &eventSubject{cp: processor, acc: acc, event: event}, rules.Rule{Name: "Bevestiging"},
+		sendEmailParams{Subject: "Hoi", Body: "Hoi"})
+
+	require.NoError(t, err)
+	assert.True(t, result.Skipped)
+	// Zonder ontvangers wordt er geen token opgehaald
+	mockStore.AssertNotCalled(t, "GetValidTokenForAccount")
+}
@@ -0,0 +1,66 @@
+package calendar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+)
+
+// Revert verwijdert de reminders die een calendar rule volgens logs gemaakt heeft. Verstuurde
+// e-mails en webhooks kunnen niet teruggedraaid worden.
+func Revert(ctx context.Context, srv *calendar.Service, logs []domain.AutomationLog) rules.RevertReport {
+	report := rules.NewRevertReport()
+	for _, entry := range logs {
+		// Logs van vóór action_type hebben alleen de velden van ActionLogDetails
+		var action struct {
+			domain.ActionLogDetails
+			ActionType    string `json:"action_type"`
+			SentMessageID string `json:"sent_message_id"`
+			URL           string `json:"url"`
+		}
+		_ = json.Unmarshal(entry.ActionDetails, &action)
+
+		item := rules.RevertItem{
+			LogID:     entry.ID,
+			Timestamp: entry.Timestamp,
+			Target:    action.CreatedEventID,
+			Action:    domain.CalendarActionCreateReminder,
+		}
+		switch action.ActionType {
+		case domain.CalendarActionSendEmail:
+			item.Target, item.Action = action.SentMessageID, action.ActionType
+			report.Add(item, "message already sent")
+			continue
+		case domain.CalendarActionWebhook:
+			item.Target, item.Action = action.URL, action.ActionType
+			report.Add(item, "webhook already delivered")
+			continue
+		}
+		report.Add(item, deleteReminder(ctx, srv, action.CreatedEventID))
+	}
+	return report
+}
+
+// deleteReminder verwijdert een reminder event en geeft de reden als dat niet lukt.
+func deleteReminder(ctx context.Context, srv *calendar.Service, eventID string) string {
+	if eventID == "" {
+		return "log has no created event"
+	}
+	err := srv.Events.Delete("primary", eventID).Context(ctx).Do()
+	var apiErr *googleapi.Error
+	// Een reminder die de gebruiker zelf al verwijderd heeft, is ook teruggedraaid
+	if errors.As(err, &apiErr) && (apiErr.Code == http.StatusNotFound || apiErr.Code == http.StatusGone) {
+		return ""
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package calendar
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+)
+
+func TestCalendar_Revert(t *testing.T) {
+	var deleted []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" || !strings.Contains(r.URL.Path, "/calendars/primary/events/") {
+			t.Errorf("Onverwacht request naar Fake Google API: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		eventID := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		switch eventID {
+		case "gone":
+			w.WriteHeader(http.StatusGone)
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			deleted = append(deleted, eventID)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	srv, err := calendar.NewService(context.Background(), option.WithHTTPClient(server.Client()), option.WithEndpoint(server.URL))
+	require.NoError(t, err)
+
+	logWith := func(id int64, details string) domain.AutomationLog {
+		return domain.AutomationLog{ID: id, Timestamp: time.Now(), Status: domain.LogSuccess, ActionDetails: json.RawMessage(details)}
+	}
+	logs := []domain.AutomationLog{
+		logWith(4, `{"created_event_id":"reminder-1"}`),
+		logWith(3, `{"created_event_id":"gone"}`), // al door de gebruiker verwijderd
+		logWith(2, `{"created_event_id":"broken"}`),
+		logWith(1, `{"details":"Action executed successfully"}`),
+		logWith(0, `{"action_type":"send_email","sent_message_id":"sent-1","to":["klant@example.com"]}`),
+		logWith(-1, `{"action_type":"webhook","url":"https://example.com/hook","status_code":200}`),
+	}
+
+	report := Revert(context.Background(), srv, logs)
+
+	assert.Equal(t, []string{"reminder-1"}, deleted)
+	assert.Equal(t, []int64{4, 3}, report.RevertedLogIDs())
+	require.Len(t, report.NotReverted, 4)
+	assert.Equal(t, "broken", report.NotReverted[0].Target)
+	assert.NotEmpty(t, report.NotReverted[0].Reason)
+	assert.Equal(t, "log has no created event", report.NotReverted[1].Reason)
+	assert.Equal(t, "sent-1", report.NotReverted[2].Target)
+	assert.Equal(t, "message already sent", report.NotReverted[2].Reason)
+	assert.Equal(t, "https://example.com/hook", report.NotReverted[3].Target)
+	assert.Equal(t, "webhook already delivered", report.NotReverted[3].Reason)
+}
@@ -0,0 +1,263 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+)
+
+// eventSubject is het event waarop calendar regels draaien, met de service om reminders te maken.
+// now is het moment van de verwerking; in een simulatie is het leeg en telt starts_within_hours niet mee.
+type eventSubject struct {
+	cp    *CalendarProcessor
+	srv   *calendar.Service
+	acc   *domain.ConnectedAccount
+	event *calendar.Event
+	now   time.Time
+}
+
+// Rules bevat de calendar triggers en acties. Een nieuw type hoeft alleen hier geregistreerd te worden.
+var Rules = newRegistry()
+
+func newRegistry() *rules.Registry[*eventSubject] {
+	r := rules.NewRegistry[*eventSubject]()
+	r.RegisterTrigger(rules.NewTrigger(domain.CalendarTriggerEventMatch, matchEvent))
+	r.RegisterAction(rules.NewAction(domain.CalendarActionCreateReminder, createReminder, previewReminder))
+	r.RegisterAction(rules.NewAction(domain.CalendarActionSendEmail, sendEmail, previewEmail))
+	r.RegisterAction(rules.NewAction(domain.CalendarActionWebhook, sendWebhook, previewWebhook))
+	return r
+}
+
+// Grenzen voor de reminder parameters: maximaal een week voor of na het event en een reminder van maximaal een dag.
+const (
+	maxReminderOffsetMinutes   = 7 * 24 * 60
+	maxReminderDurationMinutes = 24 * 60
+	maxStartsWithinHours       = 30 * 24
+)
+
+// eventMatchParams zijn de trigger_conditions van een calendar rule.
+type eventMatchParams struct {
+	domain.TriggerConditions
+}
+
+func (p eventMatchParams) Validate() error {
+	var errs rules.FieldErrors
+	// Zonder titel moet er een andere beperking zijn, anders matcht de rule op elk event
+	if p.SummaryEquals == "" && len(p.SummaryContains) == 0 && !p.ExternalAttendees {
+		errs.Add("summary_equals", "summary_equals, summary_contains or external_attendees is required")
+	}
+	if p.StartsWithinHours < 0 || p.StartsWithinHours > maxStartsWithinHours {
+		errs.Add("starts_within_hours", "must be between 0 and %d", maxStartsWithinHours)
+	}
+	addEmptyItemErrors(&errs, "summary_contains", p.SummaryContains)
+	addEmptyItemErrors(&errs, "location_contains", p.LocationContains)
+	return errs.Err()
+}
+
+// reminderParams zijn de action_params van een calendar rule. Een offset of duur van 0 betekent de standaardwaarde.
+type reminderParams struct {
+	domain.ActionParams
+}
+
+func (p reminderParams) Validate() error {
+	var errs rules.FieldErrors
+	if strings.TrimSpace(p.NewEventTitle) == "" {
+		errs.Add("new_event_title", "is required")
+	}
+	if p.OffsetMinutes < -maxReminderOffsetMinutes || p.OffsetMinutes > maxReminderOffsetMinutes {
+		errs.Add("offset_minutes", "must be between %d and %d", -maxReminderOffsetMinutes, maxReminderOffsetMinutes)
+	}
+	if p.DurationMin < 0 || p.DurationMin > maxReminderDurationMinutes {
+		errs.Add("duration_min", "must be between 0 and %d", maxReminderDurationMinutes)
+	}
+	return errs.Err()
+}
+
+// addEmptyItemErrors meldt lege zoektermen; een lege term zou op elk event matchen.
+func addEmptyItemErrors(errs *rules.FieldErrors, field string, items []string) {
+	for i, item := range items {
+		if strings.TrimSpace(item) == "" {
+			errs.Add(fmt.Sprintf("%s[%d]", field, i), "must not be empty")
+		}
+	}
+}
+
+// engineRule zet een calendar rule om naar de vorm van de rules engine.
+func engineRule(rule domain.AutomationRule) rules.Rule {
+	return rules.Rule{
+		ID:            rule.ID,
+		AccountID:     rule.ConnectedAccountID,
+		Name:          rule.Name,
+		Version:       rule.Version,
+		TriggerType:   rule.Trigger(),
+		TriggerParams: rule.TriggerConditions,
+		ActionType:    rule.Action(),
+		ActionParams:  rule.ActionParams,
+		Schedule:      rule.Schedule,
+		Executions:    rule.ExecutionCount,
+	}
+}
+
+func describeEvent(s *eventSubject) any {
+	details := domain.TriggerLogDetails{
+		GoogleEventID:  s.event.Id,
+		TriggerSummary: s.event.Summary,
+	}
+	if s.event.Start != nil {
+		details.TriggerTime, _ = time.Parse(time.RFC3339, s.event.Start.DateTime)
+	}
+	return details
+}
+
+// matchEvent matcht op de titel (exact of een van de delen) en optioneel op de locatie, de
+// starttijd en externe deelnemers.
+func matchEvent(_ context.Context, s *eventSubject, trigger eventMatchParams) (bool, error) {
+	event := s.event
+	// Hele-dag events hebben geen starttijd om een reminder voor te plannen
+	if event.Start == nil || event.Start.DateTime == "" {
+		return false, nil
+	}
+
+	if trigger.StartsWithinHours > 0 && !s.now.IsZero() {
+		startTime, err := time.Parse(time.RFC3339, event.Start.DateTime)
+		if err != nil {
+			return false, fmt.Errorf("could not parse start time: %w", err)
+		}
+		if startTime.Before(s.now) || startTime.After(s.now.Add(time.Duration(trigger.StartsWithinHours)*time.Hour)) {
+			return false, nil
+		}
+	}
+	if trigger.ExternalAttendees && len(externalAttendees(event, s.acc)) == 0 {
+		return false, nil
+	}
+
+	hasSummary := trigger.SummaryEquals != "" || len(trigger.SummaryContains) > 0
+	summaryMatch := !hasSummary || (trigger.SummaryEquals != "" && event.Summary == trigger.SummaryEquals)
+	if !summaryMatch {
+		for _, contain := range trigger.SummaryContains {
+			if strings.Contains(event.Summary, contain) {
+				summaryMatch = true
+				break
+			}
+		}
+	}
+	if !summaryMatch {
+		return false, nil
+	}
+
+	if len(trigger.LocationContains) == 0 {
+		return true, nil
+	}
+	eventLocationLower := strings.ToLower(event.Location)
+	for _, loc := range trigger.LocationContains {
+		if strings.Contains(eventLocationLower, strings.ToLower(loc)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ReminderPreview is de reminder die create_reminder voor een event zou maken.
+type ReminderPreview struct {
+	Title string    `json:"title"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// planReminder berekent titel en tijden van de reminder voor een event.
+func planReminder(event *calendar.Event, action reminderParams) (ReminderPreview, error) {
+	startTime, err := time.Parse(time.RFC3339, event.Start.DateTime)
+	if err != nil {
+		return ReminderPreview{}, fmt.Errorf("could not parse start time: %w", err)
+	}
+
+	offset := action.OffsetMinutes
+	if offset == 0 {
+		offset = -60
+	}
+	reminderTime := startTime.Add(time.Duration(offset) * time.Minute)
+
+	durMin := action.DurationMin
+	if durMin == 0 {
+		durMin = 5
+	}
+
+	return ReminderPreview{
+		Title: action.NewEventTitle,
+		Start: reminderTime,
+		End:   reminderTime.Add(time.Duration(durMin) * time.Minute),
+	}, nil
+}
+
+func previewReminder(_ context.Context, s *eventSubject, _ rules.Rule, action reminderParams) (any, error) {
+	return planReminder(s.event, action)
+}
+
+// createReminder maakt een reminder event vóór het trigger event, tenzij die al bestaat.
+func createReminder(_ context.Context, s *eventSubject, rule rules.Rule, action reminderParams) (rules.Result, error) {
+	event := s.event
+	plan, err := planReminder(event, action)
+	if err != nil {
+		return rules.Result{}, err
+	}
+	reminderTime, endTime, title := plan.Start, plan.End, plan.Title
+
+	// Check for duplicates
+	if s.cp.eventExists(s.srv, reminderTime, endTime, title) {
+		log.Printf("[Calendar] SKIP: Reminder event '%s' at %s already exists.", title, reminderTime)
+		return rules.Result{
+			Skipped: true,
+			Details: domain.ActionLogDetails{
+				CreatedEventID:      "unknown-pre-existing",
+				CreatedEventSummary: title,
+				ReminderTime:        reminderTime,
+			},
+		}, nil
+	}
+
+	endTimeZone := event.Start.TimeZone
+	if event.End != nil {
+		endTimeZone = event.End.TimeZone
+	}
+
+	newEvent := &calendar.Event{
+		Summary: title,
+		Start: &calendar.EventDateTime{
+			DateTime: reminderTime.Format(time.RFC3339),
+			TimeZone: event.Start.TimeZone,
+		},
+		End: &calendar.EventDateTime{
+			DateTime: endTime.Format(time.RFC3339),
+			TimeZone: endTimeZone,
+		},
+		Description: fmt.Sprintf("%s %s\nGemaakt door regel: %s", reminderDescriptionPrefix, event.Summary, rule.Name),
+	}
+
+	createdEvent, err := s.srv.Events.Insert("primary", newEvent).Do()
+	if err != nil {
+		return rules.Result{}, fmt.Errorf("could not create reminder event: %w", err)
+	}
+
+	log.Printf(
+		"[Calendar] SUCCESS: Created reminder '%s' (ID: %s) for event '%s' (ID: %s)",
+		createdEvent.Summary,
+		createdEvent.Id,
+		event.Summary,
+		event.Id,
+	)
+
+	return rules.Result{
+		Details: domain.ActionLogDetails{
+			CreatedEventID:      createdEvent.Id,
+			CreatedEventSummary: createdEvent.Summary,
+			ReminderTime:        reminderTime,
+		},
+	}, nil
+}
@@ -0,0 +1,122 @@
+package calendar
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestMatchEvent(t *testing.T) {
+	timed := &calendar.EventDateTime{DateTime: "2025-11-30T09:00:00Z"}
+	now := time.Date(2025, 11, 29, 12, 0, 0, 0, time.UTC)
+	acc := &domain.ConnectedAccount{Email: "planning@bedrijf.nl"}
+	colleague := &calendar.EventAttendee{Email: "collega@bedrijf.nl"}
+	customer := &calendar.EventAttendee{Email: "klant@example.com"}
+
+	tests := []struct {
+		name    string
+		event   *calendar.Event
+		trigger domain.TriggerConditions
+		want    bool
+	}{
+		{
+			name:    "summary equals",
+			event:   &calendar.Event{Summary: "Dienst", Start: timed},
+			trigger: domain.TriggerConditions{SummaryEquals: "Dienst"},
+			want:    true,
+		},
+		{
+			name:    "summary contains and location matches case-insensitively",
+			event:   &calendar.Event{Summary: "Late Dienst", Location: "Ziekenhuis Utrecht", Start: timed},
+			trigger: domain.TriggerConditions{SummaryContains: []string{"Dienst"}, LocationContains: []string{"utrecht"}},
+			want:    true,
+		},
+		{
+			name:    "location does not match",
+			event:   &calendar.Event{Summary: "Dienst", Location: "Amsterdam", Start: timed},
+			trigger: domain.TriggerConditions{SummaryEquals: "Dienst", LocationContains: []string{"Utrecht"}},
+			want:    false,
+		},
+		{
+			name:    "starts within window",
+			event:   &calendar.Event{Summary: "Dienst", Start: timed},
+			trigger: domain.TriggerConditions{SummaryEquals: "Dienst", StartsWithinHours: 24},
+			want:    true,
+		},
+		{
+			name:    "starts after window",
+			event:   &calendar.Event{Summary: "Dienst", Start: timed},
+			trigger: domain.TriggerConditions{SummaryEquals: "Dienst", StartsWithinHours: 12},
+			want:    false,
+		},
+		{
+			name:    "external attendee without summary condition",
+			event:   &calendar.Event{Summary: "Intake", Start: timed, Attendees: []*calendar.EventAttendee{colleague, customer}},
+			trigger: domain.TriggerConditions{ExternalAttendees: true},
+			want:    true,
+		},
+		{
+			name:    "only internal attendees",
+			event:   &calendar.Event{Summary: "Overleg", Start: timed, Attendees: []*calendar.EventAttendee{colleague}},
+			trigger: domain.TriggerConditions{ExternalAttendees: true},
+			want:    false,
+		},
+		{
+			name: "external attendee declined",
+			event: &calendar.Event{Summary: "Intake", Start: timed, Attendees: []*calendar.EventAttendee{
+				{Email: "klant@example.com", ResponseStatus: "declined"},
+			}},
+			trigger: domain.TriggerConditions{ExternalAttendees: true},
+			want:    false,
+		},
+		{
+			name:    "all-day event",
+			event:   &calendar.Event{Summary: "Dienst", Start: &calendar.EventDateTime{Date: "2025-11-30"}},
+			trigger: domain.TriggerConditions{SummaryEquals: "Dienst"},
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject := &eventSubject{acc: acc, event: tt.event, now: now}
+			matched, err := matchEvent(context.Background(), subject, eventMatchParams{tt.trigger})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, matched)
+		})
+	}
+}
+
+func TestRules_Validate(t *testing.T) {
+	assert.NoError(t, Rules.ValidateTrigger(domain.CalendarTriggerEventMatch, []byte(`{"summary_equals": "Dienst"}`)))
+	assert.Error(t, Rules.ValidateTrigger(domain.CalendarTriggerEventMatch, []byte(`{"location_contains": ["Utrecht"]}`)))
+	assert.NoError(t, Rules.ValidateAction(domain.CalendarActionCreateReminder, []byte(`{"new_event_title": "Reminder"}`)))
+	assert.Error(t, Rules.ValidateAction(domain.CalendarActionCreateReminder, []byte(`{}`)))
+	assert.NoError(t, Rules.ValidateTrigger(domain.CalendarTriggerEventMatch, []byte(`{"external_attendees": true}`)))
+	assert.Error(t, Rules.ValidateTrigger(domain.CalendarTriggerEventMatch, []byte(`{"summary_equals": "Dienst", "starts_within_hours": -1}`)))
+	assert.NoError(t, Rules.ValidateAction(domain.CalendarActionSendEmail, []byte(`{"subject": "Tot morgen", "body": "Hoi"}`)))
+	assert.Error(t, Rules.ValidateAction(domain.CalendarActionSendEmail, []byte(`{"recipients": "iedereen", "subject": "Tot morgen", "body": "Hoi"}`)))
+}
+
+func TestRules_ValidateFieldPaths(t *testing.T) {
+	errs := Rules.ValidateRule(rules.Rule{
+		TriggerType:   domain.CalendarTriggerEventMatch,
+		TriggerParams: []byte(`{"summary_contains": ["Dienst", " "]}`),
+		ActionType:    domain.CalendarActionCreateReminder,
+		ActionParams:  []byte(`{"offset_minutes": -20000, "duration_min": -5}`),
+	})
+
+	assert.Equal(t, rules.FieldErrors{
+		{Field: "trigger_conditions.summary_contains[1]", Message: "must not be empty"},
+		{Field: "action_params.new_event_title", Message: "is required"},
+		{Field: "action_params.offset_minutes", Message: "must be between -10080 and 10080"},
+		{Field: "action_params.duration_min", Message: "must be between 0 and 1440"},
+	}, errs)
+}
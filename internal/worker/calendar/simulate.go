@@ -0,0 +1,65 @@
+package calendar
+
+import (
+	"context"
+
+	"google.golang.org/api/calendar/v3"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/webhook"
+)
+
+// SimulationMatch is een event waarop een calendar rule zou afgaan, met de reminder die gemaakt
+// of de e-mail die gestuurd zou worden.
+type SimulationMatch struct {
+	EventID      string           `json:"event_id"`
+	EventSummary string           `json:"event_summary"`
+	EventStart   string           `json:"event_start"`
+	Reminder     *ReminderPreview `json:"reminder,omitempty"`
+	Email        *EmailPreview    `json:"email,omitempty"`
+	Webhook      *webhook.Preview `json:"webhook,omitempty"`
+	Error        string           `json:"error,omitempty"`
+}
+
+// Simulate evalueert een calendar rule tegen events zonder reminders te maken of logs te schrijven.
+// Eigen reminder events tellen niet mee, net als in ProcessEvents. De rule hoeft niet actief te zijn.
+// starts_within_hours telt niet mee: het venster van de simulatie bepaalt welke events bekeken worden.
+func Simulate(
+	ctx context.Context,
+	acc *domain.ConnectedAccount,
+	rule domain.AutomationRule,
+	events []*calendar.Event,
+) ([]SimulationMatch, error) {
+	matches := []SimulationMatch{}
+	simRule := engineRule(rule)
+
+	for _, event := range events {
+		if isOwnReminder(event) {
+			continue
+		}
+		sim, err := Rules.Simulate(ctx, simRule, &eventSubject{acc: acc, event: event})
+		if !sim.Matched {
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		match := SimulationMatch{EventID: event.Id, EventSummary: event.Summary, EventStart: event.Start.DateTime}
+		if err != nil {
+			match.Error = err.Error()
+		} else {
+			switch preview := sim.Preview.(type) {
+			case ReminderPreview:
+				match.Reminder = &preview
+			case EmailPreview:
+				match.Email = &preview
+			case webhook.Preview:
+				match.Webhook = &preview
+			}
+		}
+		matches = append(matches, match)
+	}
+
+	return matches, nil
+}
@@ -0,0 +1,54 @@
+package calendar
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestSimulate(t *testing.T) {
+	rule := domain.AutomationRule{BaseAutomationRule: domain.BaseAutomationRule{
+		Name:              "Dienst reminder",
+		TriggerConditions: json.RawMessage(`{"summary_contains": ["Dienst"]}`),
+		ActionParams:      json.RawMessage(`{"new_event_title": "Vertrekken", "offset_minutes": -45}`),
+	}}
+	events := []*calendar.Event{
+		{Id: "ev-1", Summary: "Dienst", Start: &calendar.EventDateTime{DateTime: "2025-11-30T09:00:00+01:00"}},
+		{Id: "ev-2", Summary: "Tandarts", Start: &calendar.EventDateTime{DateTime: "2025-11-30T14:00:00+01:00"}},
+		{
+			Id:          "ev-3",
+			Summary:     "Vertrekken voor Dienst",
+			Description: reminderDescriptionPrefix + " Dienst",
+			Start:       &calendar.EventDateTime{DateTime: "2025-11-30T08:15:00+01:00"},
+		},
+	}
+
+	matches, err := Simulate(context.Background(), nil, rule, events)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+
+	match := matches[0]
+	assert.Equal(t, "ev-1", match.EventID)
+	require.NotNil(t, match.Reminder)
+	assert.Equal(t, "Vertrekken", match.Reminder.Title)
+	assert.True(t, match.Reminder.Start.Equal(time.Date(2025, 11, 30, 7, 15, 0, 0, time.UTC)))
+	assert.Equal(t, 5*time.Minute, match.Reminder.End.Sub(match.Reminder.Start))
+}
+
+func TestSimulate_InvalidRule(t *testing.T) {
+	rule := domain.AutomationRule{BaseAutomationRule: domain.BaseAutomationRule{
+		TriggerConditions: json.RawMessage(`{}`),
+		ActionParams:      json.RawMessage(`{"new_event_title": "Vertrekken"}`),
+	}}
+	events := []*calendar.Event{{Id: "ev-1", Summary: "Dienst", Start: &calendar.EventDateTime{DateTime: "2025-11-30T09:00:00Z"}}}
+
+	_, err := Simulate(context.Background(), nil, rule, events)
+	assert.Error(t, err)
+}
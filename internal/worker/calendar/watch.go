@@ -0,0 +1,147 @@
+package calendar
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+)
+
+// watchedCalendarID is de agenda waarvoor we push notificaties openen.
+// Dit is dezelfde agenda die ProcessEvents verwerkt.
+const watchedCalendarID = "primary"
+
+// watchRenewalMargin bepaalt hoe lang voor de expiratie we een channel vernieuwen.
+// Google geeft channels maximaal ~7 dagen, de worker draait elke 2 minuten.
+const watchRenewalMargin = 24 * time.Hour
+
+// EnsureWatch zorgt dat er een geldig events.watch channel open staat voor het account.
+// Bestaat er geen channel, of verloopt het binnenkort, dan wordt er een nieuw channel
+// geopend en het oude gestopt.
+func (cp *CalendarProcessor) EnsureWatch(
+	ctx context.Context,
+	acc *domain.ConnectedAccount,
+	token *oauth2.Token,
+	webhookURL string,
+) error {
+	channels, err := cp.store.GetCalendarWatchChannelsForAccount(ctx, acc.ID)
+	if err != nil {
+		return fmt.Errorf("could not fetch watch channels: %w", err)
+	}
+
+	var current *domain.CalendarWatchChannel
+	for i := range channels {
+		if channels[i].CalendarID == watchedCalendarID {
+			current = &channels[i]
+			break
+		}
+	}
+
+	if current != nil && time.Until(current.Expiration) > watchRenewalMargin {
+		return nil // Channel is nog lang genoeg geldig
+	}
+
+	client := oauth2.NewClient(ctx, oauth2.StaticTokenSource(token))
+	srv, err := cp.newService(ctx, client)
+	if err != nil {
+		return fmt.Errorf("could not create calendar service: %w", err)
+	}
+
+	channelToken, err := newChannelToken()
+	if err != nil {
+		return fmt.Errorf("could not generate channel token: %w", err)
+	}
+
+	resp, err := srv.Events.Watch(watchedCalendarID, &calendar.Channel{
+		Id:      uuid.NewString(),
+		Type:    "web_hook",
+		Address: webhookURL,
+		Token:   channelToken,
+	}).Do()
+	if err != nil {
+		return fmt.Errorf("could not open watch channel: %w", err)
+	}
+
+	// Het nieuwe channel overschrijft het oude in de database (uniek per account/agenda)
+	if _, err := cp.store.UpsertCalendarWatchChannel(ctx, store.UpsertCalendarWatchChannelParams{
+		ConnectedAccountID: acc.ID,
+		CalendarID:         watchedCalendarID,
+		ChannelID:          resp.Id,
+		ResourceID:         resp.ResourceId,
+		Token:              channelToken,
+		Expiration:         time.UnixMilli(resp.Expiration),
+	}); err != nil {
+		return fmt.Errorf("could not store watch channel: %w", err)
+	}
+
+	log.Printf("[Calendar] Opened watch channel %s for %s", resp.Id, acc.Email)
+
+	// Stop het oude channel pas nu, zodat we geen notificaties missen
+	if current != nil {
+		if err := stopChannel(srv, current); err != nil {
+			log.Printf("[Calendar] WARN: Could not stop old watch channel %s: %v", current.ChannelID, err)
+		}
+	}
+
+	return nil
+}
+
+// StopWatch stopt een channel bij Google en verwijdert het uit de database.
+// Een token van nil betekent dat het account geen toegang meer geeft; dan
+// verwijderen we alleen de rij en laten we het channel bij Google verlopen.
+func (cp *CalendarProcessor) StopWatch(
+	ctx context.Context,
+	ch domain.CalendarWatchChannel,
+	token *oauth2.Token,
+) error {
+	if token != nil {
+		client := oauth2.NewClient(ctx, oauth2.StaticTokenSource(token))
+		srv, err := cp.newService(ctx, client)
+		if err != nil {
+			return fmt.Errorf("could not create calendar service: %w", err)
+		}
+		if err := stopChannel(srv, &ch); err != nil {
+			return fmt.Errorf("could not stop watch channel: %w", err)
+		}
+	}
+
+	if err := cp.store.DeleteCalendarWatchChannel(ctx, ch.ChannelID); err != nil {
+		return fmt.Errorf("could not delete watch channel: %w", err)
+	}
+	return nil
+}
+
+// stopChannel stopt een channel bij Google. Een channel dat Google niet (meer) kent, telt als gestopt.
+func stopChannel(srv *calendar.Service, ch *domain.CalendarWatchChannel) error {
+	err := srv.Channels.Stop(&calendar.Channel{
+		Id:         ch.ChannelID,
+		ResourceId: ch.ResourceID,
+	}).Do()
+
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && (apiErr.Code == http.StatusNotFound || apiErr.Code == http.StatusGone) {
+		return nil
+	}
+	return err
+}
+
+// newChannelToken genereert het geheim dat Google terugstuurt als X-Goog-Channel-Token.
+func newChannelToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
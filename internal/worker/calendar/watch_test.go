@@ -0,0 +1,183 @@
+package calendar
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+)
+
+const testWebhookURL = "https://example.com/api/v1/webhooks/google/calendar"
+
+// useTestEndpoint laat de processor de fake Google API gebruiken.
+func useTestEndpoint(processor *CalendarProcessor, url string) {
+	processor.newService = func(ctx context.Context, client *http.Client) (*calendar.Service, error) {
+		return calendar.NewService(ctx, option.WithHTTPClient(client), option.WithEndpoint(url))
+	}
+}
+
+// Test: Geen channel aanwezig, dus er wordt een nieuw channel geopend en opgeslagen.
+func TestCalendar_EnsureWatch_OpensNewChannel(t *testing.T) {
+	accountID := uuid.New()
+	expiration := time.Now().Add(7 * 24 * time.Hour).Truncate(time.Millisecond)
+
+	var watchCalls int
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.Path == "/calendars/primary/events/watch" {
+			watchCalls++
+			var ch calendar.Channel
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&ch))
+			assert.Equal(t, "web_hook", ch.Type)
+			assert.Equal(t, testWebhookURL, ch.Address)
+			assert.NotEmpty(t, ch.Token)
+
+			ch.ResourceId = "resource-1"
+			ch.Expiration = expiration.UnixMilli()
+			json.NewEncoder(w).Encode(ch)
+			return
+		}
+		t.Errorf("Onverwacht request naar Fake Google API: %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	processor, mockStore, server := setupCalendarTest(t, handler)
+	defer server.Close()
+	useTestEndpoint(processor, server.URL)
+
+	ctx := context.Background()
+	mockStore.On("GetCalendarWatchChannelsForAccount", ctx, accountID).
+		Return([]domain.CalendarWatchChannel{}, nil).Once()
+	mockStore.On("UpsertCalendarWatchChannel", ctx, mock.MatchedBy(func(p store.UpsertCalendarWatchChannelParams) bool {
+		return p.ConnectedAccountID == accountID &&
+			p.CalendarID == "primary" &&
+			p.ResourceID == "resource-1" &&
+			p.Token != "" &&
+			p.Expiration.Equal(expiration)
+	})).Return(domain.CalendarWatchChannel{}, nil).Once()
+
+	err := processor.EnsureWatch(ctx, &domain.ConnectedAccount{ID: accountID}, mockToken(), testWebhookURL)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, watchCalls)
+	mockStore.AssertExpectations(t)
+}
+
+// Test: Het channel is nog lang geldig, er gebeurt niets bij Google.
+func TestCalendar_EnsureWatch_ChannelStillValid(t *testing.T) {
+	accountID := uuid.New()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Onverwacht request naar Fake Google API: %s %s", r.Method, r.URL.Path)
+	})
+
+	processor, mockStore, server := setupCalendarTest(t, handler)
+	defer server.Close()
+	useTestEndpoint(processor, server.URL)
+
+	ctx := context.Background()
+	mockStore.On("GetCalendarWatchChannelsForAccount", ctx, accountID).Return([]domain.CalendarWatchChannel{
+		{CalendarID: "primary", ChannelID: "channel-1", Expiration: time.Now().Add(5 * 24 * time.Hour)},
+	}, nil).Once()
+
+	err := processor.EnsureWatch(ctx, &domain.ConnectedAccount{ID: accountID}, mockToken(), testWebhookURL)
+
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "UpsertCalendarWatchChannel")
+}
+
+// Test: Het channel verloopt binnenkort, er wordt vernieuwd en het oude channel gestopt.
+func TestCalendar_EnsureWatch_RenewsExpiringChannel(t *testing.T) {
+	accountID := uuid.New()
+	oldChannel := domain.CalendarWatchChannel{
+		CalendarID: "primary",
+		ChannelID:  "old-channel",
+		ResourceID: "old-resource",
+		Expiration: time.Now().Add(time.Hour),
+	}
+
+	var stopped calendar.Channel
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/calendars/primary/events/watch":
+			var ch calendar.Channel
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&ch))
+			ch.ResourceId = "new-resource"
+			ch.Expiration = time.Now().Add(7 * 24 * time.Hour).UnixMilli()
+			json.NewEncoder(w).Encode(ch)
+		case r.Method == "POST" && r.URL.Path == "/channels/stop":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&stopped))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("Onverwacht request naar Fake Google API: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+
+	processor, mockStore, server := setupCalendarTest(t, handler)
+	defer server.Close()
+	useTestEndpoint(processor, server.URL)
+
+	ctx := context.Background()
+	mockStore.On("GetCalendarWatchChannelsForAccount", ctx, accountID).
+		Return([]domain.CalendarWatchChannel{oldChannel}, nil).Once()
+	mockStore.On("UpsertCalendarWatchChannel", ctx, mock.MatchedBy(func(p store.UpsertCalendarWatchChannelParams) bool {
+		return p.ResourceID == "new-resource" && p.ChannelID != "old-channel"
+	})).Return(domain.CalendarWatchChannel{}, nil).Once()
+
+	err := processor.EnsureWatch(ctx, &domain.ConnectedAccount{ID: accountID}, mockToken(), testWebhookURL)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "old-channel", stopped.Id)
+	assert.Equal(t, "old-resource", stopped.ResourceId)
+	mockStore.AssertExpectations(t)
+}
+
+// Test: Zonder token (ingetrokken account) wordt alleen de rij verwijderd.
+func TestCalendar_StopWatch_WithoutToken(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Onverwacht request naar Fake Google API: %s %s", r.Method, r.URL.Path)
+	})
+
+	processor, mockStore, server := setupCalendarTest(t, handler)
+	defer server.Close()
+	useTestEndpoint(processor, server.URL)
+
+	ctx := context.Background()
+	mockStore.On("DeleteCalendarWatchChannel", ctx, "channel-1").Return(nil).Once()
+
+	err := processor.StopWatch(ctx, domain.CalendarWatchChannel{ChannelID: "channel-1"}, nil)
+
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+}
+
+// Test: Een channel dat Google niet meer kent, wordt gewoon opgeruimd.
+func TestCalendar_StopWatch_UnknownChannel(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	processor, mockStore, server := setupCalendarTest(t, handler)
+	defer server.Close()
+	useTestEndpoint(processor, server.URL)
+
+	ctx := context.Background()
+	mockStore.On("DeleteCalendarWatchChannel", ctx, "channel-1").Return(nil).Once()
+
+	err := processor.StopWatch(ctx, domain.CalendarWatchChannel{ChannelID: "channel-1"}, mockToken())
+
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+}
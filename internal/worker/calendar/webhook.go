@@ -0,0 +1,64 @@
+package calendar
+
+import (
+	"context"
+
+	"google.golang.org/api/calendar/v3"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/webhook"
+)
+
+// eventPayload is de metadata van een event in een webhook payload; de beschrijving gaat niet mee.
+type eventPayload struct {
+	ID        string   `json:"id"`
+	Summary   string   `json:"summary"`
+	Location  string   `json:"location,omitempty"`
+	Start     string   `json:"start,omitempty"`
+	End       string   `json:"end,omitempty"`
+	Status    string   `json:"status,omitempty"`
+	Organizer string   `json:"organizer,omitempty"`
+	Attendees []string `json:"attendees,omitempty"`
+	HTMLLink  string   `json:"html_link,omitempty"`
+}
+
+func webhookPayload(s *eventSubject, rule rules.Rule) webhook.Payload {
+	event := s.event
+	payload := eventPayload{
+		ID:        event.Id,
+		Summary:   event.Summary,
+		Location:  event.Location,
+		Start:     eventDateTime(event.Start),
+		End:       eventDateTime(event.End),
+		Status:    event.Status,
+		Attendees: attendees(event),
+		HTMLLink:  event.HtmlLink,
+	}
+	if event.Organizer != nil {
+		payload.Organizer = event.Organizer.Email
+	}
+
+	p := webhook.NewPayload(rule, domain.RuleTypeCalendar)
+	p.Event = payload
+	return p
+}
+
+func eventDateTime(t *calendar.EventDateTime) string {
+	if t == nil {
+		return ""
+	}
+	if t.DateTime != "" {
+		return t.DateTime
+	}
+	return t.Date
+}
+
+func previewWebhook(_ context.Context, s *eventSubject, rule rules.Rule, params webhook.Params) (any, error) {
+	return webhook.Preview{URL: params.URL, Payload: webhookPayload(s, rule)}, nil
+}
+
+// sendWebhook stuurt het event naar de URL van de gebruiker, ondertekend met zijn webhook geheim.
+func sendWebhook(ctx context.Context, s *eventSubject, rule rules.Rule, params webhook.Params) (rules.Result, error) {
+	return webhook.Execute(ctx, s.cp.sendWebhook, s.cp.store, s.acc.UserID, params, webhookPayload(s, rule))
+}
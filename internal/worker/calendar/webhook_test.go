@@ -0,0 +1,91 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/store"
+	"agenda-automator-api/internal/webhook"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestSendWebhook(t *testing.T) {
+	mockStore := new(store.MockStore)
+	processor := NewCalendarProcessor(mockStore)
+	acc := &domain.ConnectedAccount{ID: uuid.New(), UserID: uuid.New()}
+	rule := rules.Rule{ID: uuid.New(), AccountID: acc.ID, Name: "Naar CRM"}
+
+	var sentTo, sentSecret string
+	var sent webhook.Payload
+	processor.sendWebhook = func(_ context.Context, url, secret string, payload any) (webhook.Delivery, error) {
+		sentTo, sentSecret, sent = url, secret, payload.(webhook.Payload)
+		return webhook.Delivery{DeliveryID: "delivery-1", StatusCode: 204, Attempts: 2}, nil
+	}
+	mockStore.On("EnsureWebhookSecret", context.Background(), acc.UserID, mock.AnythingOfType("string")).
+		Return(domain.WebhookSecret{UserID: acc.UserID, Secret: "whsec_test"}, nil).Once()
+
+	event := &calendar.Event{
+		Id:        "event-1",
+		Summary:   "Intake",
+		Start:     &calendar.EventDateTime{DateTime: "2025-11-30T09:00:00Z"},
+		End:       &calendar.EventDateTime{Date: "2025-11-30"},
+		Organizer: &calendar.EventOrganizer{Email: "planning@bedrijf.nl"},
+		Attendees: []*calendar.EventAttendee{{Email: "klant@example.com"}},
+	}
+	params := webhook.Params{URL: "https://example.com/hook"}
+
+	result, err := sendWebhook(context.Background(), &eventSubject{cp: processor, acc: acc, event: event}, rule, params)
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.WebhookActionLogDetails{
+		ActionType: domain.CalendarActionWebhook,
+		URL:        "https://example.com/hook",
+		DeliveryID: "delivery-1",
+		StatusCode: 204,
+		Attempts:   2,
+	}, result.Details)
+	assert.Equal(t, "https://example.com/hook", sentTo)
+	assert.Equal(t, "whsec_test", sentSecret)
+	assert.Equal(t, rule.ID, sent.RuleID)
+	assert.Equal(t, domain.RuleTypeCalendar, sent.RuleType)
+	assert.Equal(t, eventPayload{
+		ID:        "event-1",
+		Summary:   "Intake",
+		Start:     "2025-11-30T09:00:00Z",
+		End:       "2025-11-30",
+		Organizer: "planning@bedrijf.nl",
+		Attendees: []string{"klant@example.com"},
+	}, sent.Event)
+	mockStore.AssertExpectations(t)
+}
+
+func TestSendWebhook_DeliveryFailed(t *testing.T) {
+	mockStore := new(store.MockStore)
+	processor := NewCalendarProcessor(mockStore)
+	acc := &domain.ConnectedAccount{ID: uuid.New(), UserID: uuid.New()}
+	processor.sendWebhook = func(context.Context, string, string, any) (webhook.Delivery, error) {
+		return webhook.Delivery{StatusCode: 500, Attempts: 3}, errors.New("webhook returned status 500 after 3 attempt(s)")
+	}
+	mockStore.On("EnsureWebhookSecret", context.Background(), acc.UserID, mock.AnythingOfType("string")).
+		Return(domain.WebhookSecret{Secret: "whsec_test"}, nil).Once()
+
+	_, err := sendWebhook(context.Background(), &eventSubject{cp: processor, acc: acc, event: &calendar.Event{Id: "event-1"}},
+		rules.Rule{}, webhook.Params{URL: "https://example.com/hook"})
+
+	assert.EqualError(t, err, "webhook returned status 500 after 3 attempt(s)")
+}
+
+func TestRules_ValidateWebhookParams(t *testing.T) {
+	assert.NoError(t, Rules.ValidateAction(domain.CalendarActionWebhook, []byte(`{"url": "https://example.com/hook"}`)))
+	assert.Error(t, Rules.ValidateAction(domain.CalendarActionWebhook, []byte(`{"url": "http://example.com/hook"}`)))
+	assert.Error(t, Rules.ValidateAction(domain.CalendarActionWebhook, []byte(`{"url": "https://127.0.0.1/hook"}`)))
+	assert.Error(t, Rules.ValidateAction(domain.CalendarActionWebhook, []byte(`{}`)))
+}
@@ -0,0 +1,178 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+)
+
+// calendarSyncQueueSize is het maximale aantal openstaande sync-verzoeken.
+// Loopt de queue vol, dan pakt de volgende tick de wijziging alsnog op.
+const calendarSyncQueueSize = 100
+
+// calendarSyncRetryDelay is de wachttijd voor een sync van een account dat nog bezig was.
+const calendarSyncRetryDelay = 15 * time.Second
+
+// calendarSyncRequest is een verzoek om een agenda direct te synchroniseren.
+type calendarSyncRequest struct {
+	accountID  uuid.UUID
+	calendarID string
+}
+
+// calendarSyncQueue bundelt push notificaties: zolang er voor een account
+// al een sync openstaat, worden nieuwe notificaties genegeerd.
+type calendarSyncQueue struct {
+	requests   chan calendarSyncRequest
+	mu         sync.Mutex
+	pending    map[uuid.UUID]bool
+	retryDelay time.Duration
+}
+
+func newCalendarSyncQueue() *calendarSyncQueue {
+	return &calendarSyncQueue{
+		requests:   make(chan calendarSyncRequest, calendarSyncQueueSize),
+		pending:    make(map[uuid.UUID]bool),
+		retryDelay: calendarSyncRetryDelay,
+	}
+}
+
+// enqueue zet een sync klaar zonder te blokkeren.
+func (q *calendarSyncQueue) enqueue(req calendarSyncRequest) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.pending[req.accountID] {
+		return true // Er staat al een sync klaar voor dit account
+	}
+
+	select {
+	case q.requests <- req:
+		q.pending[req.accountID] = true
+		return true
+	default:
+		return false
+	}
+}
+
+// retry zet een sync na retryDelay opnieuw klaar. Is de queue dan vol, dan pakt de volgende tick
+// de wijziging op.
+func (q *calendarSyncQueue) retry(req calendarSyncRequest) {
+	time.AfterFunc(q.retryDelay, func() {
+		q.enqueue(req)
+	})
+}
+
+// done markeert dat een sync-verzoek is opgepakt.
+func (q *calendarSyncQueue) done(accountID uuid.UUID) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.pending, accountID)
+}
+
+// EnqueueCalendarSync plant een directe sync in voor een agenda, bijv. na een
+// push notificatie van Google. Geeft false terug als de queue vol is.
+func (w *Worker) EnqueueCalendarSync(accountID uuid.UUID, calendarID string) bool {
+	return w.syncQueue.enqueue(calendarSyncRequest{accountID: accountID, calendarID: calendarID})
+}
+
+// syncCalendar verwerkt de agenda van één account buiten de ticker om.
+func (w *Worker) syncCalendar(req calendarSyncRequest) {
+	ctx, cancel := context.WithTimeout(context.Background(), 110*time.Second)
+	defer cancel()
+
+	acc, err := w.store.GetConnectedAccountByID(ctx, req.accountID)
+	if err != nil {
+		w.logger.Error(
+			"failed to get account for calendar sync",
+			zap.Error(err),
+			zap.String("account_id", req.accountID.String()),
+			zap.String("component", "worker"),
+		)
+		return
+	}
+
+	if acc.Status != domain.StatusActive {
+		return // De sweep stopt de channels van niet-actieve accounts
+	}
+
+	// Een handmatige run kan het account vasthouden. Die run heeft de events misschien al opgehaald
+	// voor de notificatie kwam, dus de sync volgt zodra het account weer vrij is.
+	unlock, ok := w.accountLocks.tryLock(acc.ID)
+	if !ok {
+		w.logger.Info(
+			"account busy, retrying calendar sync",
+			zap.String("account_id", acc.ID.String()),
+			zap.String("component", "worker"),
+		)
+		w.syncQueue.retry(req)
+		return
+	}
+	defer unlock()
+
+	token, err := w.store.GetValidTokenForAccount(ctx, acc.ID)
+	if err != nil {
+		w.logger.Warn(
+			"failed to get valid token for calendar sync",
+			zap.Error(err),
+			zap.String("account_id", acc.ID.String()),
+			zap.String("component", "worker"),
+		)
+		return
+	}
+
+	w.logger.Info(
+		"processing calendar after push notification",
+		zap.String("account_id", acc.ID.String()),
+		zap.String("calendar_id", req.calendarID),
+		zap.String("component", "worker"),
+	)
+	if err := w.calendarProcessor.ProcessEvents(ctx, &acc, token); err != nil {
+		w.logger.Error(
+			"failed to process calendar events",
+			zap.Error(err),
+			zap.String("account_id", acc.ID.String()),
+			zap.String("component", "worker"),
+		)
+	}
+}
+
+// stopInactiveWatches stopt de watch channels van accounts die gepauzeerd,
+// ingetrokken of in error status zijn.
+func (w *Worker) stopInactiveWatches(ctx context.Context) {
+	channels, err := w.store.GetCalendarWatchChannelsForInactiveAccounts(ctx)
+	if err != nil {
+		w.logger.Error("failed to get watch channels of inactive accounts", zap.Error(err), zap.String("component", "worker"))
+		return
+	}
+
+	for _, ch := range channels {
+		var token *oauth2.Token
+		token, err = w.store.GetValidTokenForAccount(ctx, ch.ConnectedAccountID)
+		if err != nil && !errors.Is(err, store.ErrTokenRevoked) {
+			w.logger.Warn(
+				"failed to get token to stop watch channel, retrying next cycle",
+				zap.Error(err),
+				zap.String("channel_id", ch.ChannelID),
+				zap.String("component", "worker"),
+			)
+			continue
+		}
+
+		if err = w.calendarProcessor.StopWatch(ctx, ch, token); err != nil {
+			w.logger.Error(
+				"failed to stop watch channel",
+				zap.Error(err),
+				zap.String("channel_id", ch.ChannelID),
+				zap.String("component", "worker"),
+			)
+		}
+	}
+}
@@ -3,184 +3,109 @@ package gmail
 
 import (
 	"context"
-	"encoding/json"
-	"log"
+	"strings"
 
 	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
 
 	"google.golang.org/api/gmail/v1"
 )
 
-// Action implementations.
-func (gp *GmailProcessor) executeAutoReply(
-	_ context.Context,
-	srv *gmail.Service,
-	acc *domain.ConnectedAccount,
-	message *gmail.Message,
-	rule domain.GmailAutomationRule,
-) error {
-	var params struct {
-		ReplyText string `json:"reply_text"`
-	}
-	if err := json.Unmarshal(rule.ActionParams, &params); err != nil {
-		return err
-	}
-
-	reply := &gmail.Message{
-		ThreadId: message.ThreadId,
-		Raw:      gp.createReplyRaw(message, params.ReplyText, acc.Email),
-	}
-
-	_, err := srv.Users.Messages.Send("me", reply).Do()
-	return err
+type autoReplyParams struct {
+	ReplyText string `json:"reply_text"`
 }
 
-func (gp *GmailProcessor) executeAddLabel(
-	_ context.Context,
-	srv *gmail.Service,
-	_ *domain.ConnectedAccount,
-	message *gmail.Message,
-	rule domain.GmailAutomationRule,
-) error {
-	var params struct {
-		LabelName string `json:"label_name"`
+func (p autoReplyParams) Validate() error {
+	var errs rules.FieldErrors
+	if strings.TrimSpace(p.ReplyText) == "" {
+		errs.Add("reply_text", "is required")
 	}
-	if err := json.Unmarshal(rule.ActionParams, &params); err != nil {
-		return err
-	}
-
-	label, err := gp.getOrCreateLabel(srv, params.LabelName)
-	if err != nil {
-		return err
-	}
-
-	modifyRequest := &gmail.ModifyMessageRequest{
-		AddLabelIds: []string{label.Id},
-	}
-
-	_, err = srv.Users.Messages.Modify("me", message.Id, modifyRequest).Do()
-	return err
+	return errs.Err()
 }
 
-func (gp *GmailProcessor) executeRemoveLabel(
+// Action implementations.
+func (gp *GmailProcessor) executeAutoReply(
 	_ context.Context,
-	srv *gmail.Service,
-	_ *domain.ConnectedAccount,
-	message *gmail.Message,
-	rule domain.GmailAutomationRule,
+	s *messageSubject,
+	_ rules.Rule,
+	params autoReplyParams,
 ) error {
-	var params struct {
-		LabelName string `json:"label_name"`
-	}
-	if err := json.Unmarshal(rule.ActionParams, &params); err != nil {
-		return err
-	}
-
-	label, err := gp.getLabelByName(srv, params.LabelName)
+	raw, err := gp.createReplyRaw(s.message, params.ReplyText, s.acc.Email)
 	if err != nil {
 		return err
 	}
 
-	modifyRequest := &gmail.ModifyMessageRequest{
-		RemoveLabelIds: []string{label.Id},
+	reply := &gmail.Message{
+		ThreadId: s.message.ThreadId,
+		Raw:      raw,
 	}
 
-	_, err = srv.Users.Messages.Modify("me", message.Id, modifyRequest).Do()
+	_, err = s.srv.Users.Messages.Send("me", reply).Do()
 	return err
 }
 
-func (gp *GmailProcessor) executeMarkRead(
-	_ context.Context,
-	srv *gmail.Service,
-	_ *domain.ConnectedAccount,
-	message *gmail.Message,
-	_ domain.GmailAutomationRule,
-) error {
-	modifyRequest := &gmail.ModifyMessageRequest{
-		RemoveLabelIds: []string{"UNREAD"},
+// addLabelRequest voegt het label toe, en maakt het aan als het nog niet bestaat.
+func (gp *GmailProcessor) addLabelRequest(s *messageSubject, params addLabelParams) (*gmail.ModifyMessageRequest, error) {
+	label, err := gp.getOrCreateLabel(s.srv, params.LabelName)
+	if err != nil {
+		return nil, err
 	}
-	_, err := srv.Users.Messages.Modify("me", message.Id, modifyRequest).Do()
-	return err
-}
 
-func (gp *GmailProcessor) executeMarkUnread(
-	_ context.Context,
-	srv *gmail.Service,
-	_ *domain.ConnectedAccount,
-	message *gmail.Message,
-	_ domain.GmailAutomationRule,
-) error {
 	modifyRequest := &gmail.ModifyMessageRequest{
-		AddLabelIds: []string{"UNREAD"},
+		AddLabelIds: []string{label.Id},
 	}
-	_, err := srv.Users.Messages.Modify("me", message.Id, modifyRequest).Do()
-	return err
-}
-
-func (gp *GmailProcessor) executeArchive(
-	_ context.Context,
-	srv *gmail.Service,
-	_ *domain.ConnectedAccount,
-	message *gmail.Message,
-	_ domain.GmailAutomationRule,
-) error {
-	modifyRequest := &gmail.ModifyMessageRequest{
-		RemoveLabelIds: []string{"INBOX"},
+	if params.Archive {
+		modifyRequest.RemoveLabelIds = []string{"INBOX"}
 	}
-	_, err := srv.Users.Messages.Modify("me", message.Id, modifyRequest).Do()
-	return err
+	return modifyRequest, nil
 }
 
-func (gp *GmailProcessor) executeTrash(
-	_ context.Context,
-	srv *gmail.Service,
-	_ *domain.ConnectedAccount,
-	message *gmail.Message,
-	_ domain.GmailAutomationRule,
-) error {
-	modifyRequest := &gmail.ModifyMessageRequest{
-		AddLabelIds: []string{"TRASH"},
+func (gp *GmailProcessor) removeLabelRequest(s *messageSubject, params labelParams) (*gmail.ModifyMessageRequest, error) {
+	label, err := gp.getLabelByName(s.srv, params.LabelName)
+	if err != nil {
+		return nil, err
 	}
-	_, err := srv.Users.Messages.Modify("me", message.Id, modifyRequest).Do()
-	return err
-}
 
-func (gp *GmailProcessor) executeStar(
-	_ context.Context,
-	srv *gmail.Service,
-	_ *domain.ConnectedAccount,
-	message *gmail.Message,
-	_ domain.GmailAutomationRule,
-) error {
-	modifyRequest := &gmail.ModifyMessageRequest{
-		AddLabelIds: []string{"STARRED"},
-	}
-	_, err := srv.Users.Messages.Modify("me", message.Id, modifyRequest).Do()
-	return err
+	return &gmail.ModifyMessageRequest{
+		RemoveLabelIds: []string{label.Id},
+	}, nil
 }
 
-func (gp *GmailProcessor) executeUnstar(
-	_ context.Context,
-	srv *gmail.Service,
-	_ *domain.ConnectedAccount,
-	message *gmail.Message,
-	_ domain.GmailAutomationRule,
-) error {
-	modifyRequest := &gmail.ModifyMessageRequest{
-		RemoveLabelIds: []string{"STARRED"},
+// fixedLabels voegt vaste systeemlabels toe of verwijdert ze, zoals mark_read (UNREAD eraf)
+// of archive (INBOX eraf).
+func fixedLabels(
+	add, remove []string,
+) func(gp *GmailProcessor, s *messageSubject, params rules.NoParams) (*gmail.ModifyMessageRequest, error) {
+	return func(*GmailProcessor, *messageSubject, rules.NoParams) (*gmail.ModifyMessageRequest, error) {
+		return &gmail.ModifyMessageRequest{
+			AddLabelIds:    add,
+			RemoveLabelIds: remove,
+		}, nil
 	}
-	_, err := srv.Users.Messages.Modify("me", message.Id, modifyRequest).Do()
-	return err
 }
 
-// Simplified implementations.
-func (gp *GmailProcessor) executeForward(
-	_ context.Context,
-	_ *gmail.Service,
-	_ *domain.ConnectedAccount,
-	_ *gmail.Message,
-	_ domain.GmailAutomationRule,
-) error {
-	log.Printf("[Gmail] Forward action not yet implemented")
-	return nil
+// labelChanges geeft de labels uit modifyRequest die echt veranderen ten opzichte van labelIDs,
+// de labels die het bericht vóór de actie had.
+func labelChanges(
+	actionType domain.GmailRuleActionType,
+	labelIDs []string,
+	modifyRequest *gmail.ModifyMessageRequest,
+) domain.GmailActionLogDetails {
+	had := make(map[string]bool, len(labelIDs))
+	for _, id := range labelIDs {
+		had[id] = true
+	}
+
+	details := domain.GmailActionLogDetails{ActionType: actionType}
+	for _, id := range modifyRequest.AddLabelIds {
+		if !had[id] {
+			details.AddedLabelIDs = append(details.AddedLabelIDs, id)
+		}
+	}
+	for _, id := range modifyRequest.RemoveLabelIds {
+		if had[id] {
+			details.RemovedLabelIDs = append(details.RemovedLabelIDs, id)
+		}
+	}
+	return details
 }
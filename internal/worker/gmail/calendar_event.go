@@ -0,0 +1,252 @@
+package gmail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/email"
+	"agenda-automator-api/internal/rules"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/calendar/v3"
+)
+
+// Grenzen en standaardwaarden voor create_calendar_event
+const (
+	defaultEventDurationMinutes = 60
+	maxEventDurationMinutes     = 24 * 60
+)
+
+// eventTimeLayouts zijn de datumnotaties die create_calendar_event uit een bericht kan lezen,
+// van ISO tot de Nederlandse notatie met dag eerst.
+var eventTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"02-01-2006 15:04",
+	"2-1-2006 15:04",
+	"02/01/2006 15:04",
+	"2/1/2006 15:04",
+	"02-01-2006 15.04",
+	"2-1-2006 15.04",
+}
+
+// createEventParams zijn de action_params van create_calendar_event. Pattern is een reguliere expressie
+// over onderwerp en tekst van het bericht met een verplichte groep (?P<start>...) en optioneel
+// (?P<end>...) en (?P<location>...). Title mag {{subject}}, {{from}} en elke benoemde groep bevatten.
+type createEventParams struct {
+	Pattern     string `json:"pattern"`
+	Title       string `json:"title"`
+	DurationMin int    `json:"duration_min"` // 0 is 60 minuten; alleen gebruikt zonder end groep
+	TimeZone    string `json:"timezone"`     // IANA naam voor tijden zonder zone, standaard UTC
+}
+
+func (p createEventParams) Validate() error {
+	var errs rules.FieldErrors
+	if strings.TrimSpace(p.Pattern) == "" {
+		errs.Add("pattern", "is required")
+	} else if re, err := compilePattern(p.Pattern); err != nil {
+		errs.Add("pattern", "invalid regular expression: %v", err)
+	} else if re.SubexpIndex("start") < 0 {
+		errs.Add("pattern", "must contain a named group (?P<start>...)")
+	}
+	if strings.TrimSpace(p.Title) == "" {
+		errs.Add("title", "is required")
+	}
+	if p.DurationMin < 0 || p.DurationMin > maxEventDurationMinutes {
+		errs.Add("duration_min", "must be between 0 and %d", maxEventDurationMinutes)
+	}
+	if p.TimeZone != "" {
+		if _, err := time.LoadLocation(p.TimeZone); err != nil {
+			errs.Add("timezone", "unknown time zone %q", p.TimeZone)
+		}
+	}
+	return errs.Err()
+}
+
+// plannedEvent is het event dat create_calendar_event uit een bericht haalt.
+type plannedEvent struct {
+	Title    string
+	Start    time.Time
+	End      time.Time
+	Location string
+}
+
+// planEvent zoekt het patroon in text en bouwt het event. Nil zonder match.
+func planEvent(params createEventParams, subject, from, text string) (*plannedEvent, error) {
+	re, err := compilePattern(params.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	match := re.FindStringSubmatch(text)
+	if match == nil {
+		return nil, nil
+	}
+
+	values := map[string]string{"subject": subject, "from": from}
+	for i, name := range re.SubexpNames() {
+		if name != "" {
+			values[name] = strings.TrimSpace(match[i])
+		}
+	}
+
+	loc := time.UTC
+	if params.TimeZone != "" {
+		if loc, err = time.LoadLocation(params.TimeZone); err != nil {
+			return nil, err
+		}
+	}
+	start, err := parseEventTime(values["start"], loc)
+	if err != nil {
+		return nil, err
+	}
+
+	duration := params.DurationMin
+	if duration == 0 {
+		duration = defaultEventDurationMinutes
+	}
+	end := start.Add(time.Duration(duration) * time.Minute)
+	if values["end"] != "" {
+		if end, err = parseEventEnd(values["end"], start, loc); err != nil {
+			return nil, err
+		}
+	}
+
+	return &plannedEvent{
+		Title:    rules.ExpandTemplate(params.Title, values),
+		Start:    start,
+		End:      end,
+		Location: values["location"],
+	}, nil
+}
+
+// parseEventTime leest een datum met tijd in een van eventTimeLayouts.
+func parseEventTime(value string, loc *time.Location) (time.Time, error) {
+	for _, layout := range eventTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("could not parse event time %q", value)
+}
+
+// parseEventEnd leest de eindtijd; alleen een tijd (15:04) hoort bij de dag van de start.
+func parseEventEnd(value string, start time.Time, loc *time.Location) (time.Time, error) {
+	for _, layout := range []string{"15:04", "15.04"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			end := time.Date(start.Year(), start.Month(), start.Day(), t.Hour(), t.Minute(), 0, 0, start.Location())
+			if !end.After(start) {
+				return time.Time{}, fmt.Errorf("event end %q is not after the start", value)
+			}
+			return end, nil
+		}
+	}
+	end, err := parseEventTime(value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !end.After(start) {
+		return time.Time{}, fmt.Errorf("event end %q is not after the start", value)
+	}
+	return end, nil
+}
+
+// createCalendarEvent leest het volledige bericht, haalt het event eruit met het patroon en maakt
+// het aan in de primaire agenda van hetzelfde account.
+func createCalendarEvent(
+	ctx context.Context,
+	s *messageSubject,
+	rule rules.Rule,
+	params createEventParams,
+) (rules.Result, error) {
+	details := domain.GmailActionLogDetails{ActionType: domain.GmailActionCreateEvent}
+
+	original, err := s.srv.Users.Messages.Get("me", s.message.Id).Format("raw").Context(ctx).Do()
+	if err != nil {
+		return rules.Result{}, fmt.Errorf("could not fetch raw message: %w", err)
+	}
+	raw, err := email.DecodeRaw(original.Raw)
+	if err != nil {
+		return rules.Result{}, fmt.Errorf("could not decode raw message: %w", err)
+	}
+	parsed, err := email.Parse(raw)
+	if err != nil {
+		return rules.Result{}, err
+	}
+
+	text := parsed.Text
+	if text == "" {
+		text = stripTags(parsed.HTML)
+	}
+	planned, err := planEvent(params, parsed.Subject, parsed.From, parsed.Subject+"\n"+text)
+	if err != nil {
+		return rules.Result{}, err
+	}
+	if planned == nil {
+		details.Reason = "pattern did not match the message"
+		return rules.Result{Skipped: true, Details: details}, nil
+	}
+
+	token, err := s.gp.store.GetValidTokenForAccount(ctx, s.acc.ID)
+	if err != nil {
+		return rules.Result{}, fmt.Errorf("could not get token for account: %w", err)
+	}
+	srv, err := s.gp.newCalendarService(ctx, oauth2.NewClient(ctx, oauth2.StaticTokenSource(token)))
+	if err != nil {
+		return rules.Result{}, fmt.Errorf("could not create calendar service: %w", err)
+	}
+
+	created, err := srv.Events.Insert("primary", &calendar.Event{
+		Summary:     planned.Title,
+		Location:    planned.Location,
+		Start:       &calendar.EventDateTime{DateTime: planned.Start.Format(time.RFC3339), TimeZone: params.TimeZone},
+		End:         &calendar.EventDateTime{DateTime: planned.End.Format(time.RFC3339), TimeZone: params.TimeZone},
+		Description: fmt.Sprintf("Uit e-mail: %s\nGemaakt door regel: %s", parsed.Subject, rule.Name),
+	}).Context(ctx).Do()
+	if err != nil {
+		return rules.Result{}, fmt.Errorf("could not create calendar event: %w", err)
+	}
+
+	log.Printf("[Gmail] Created calendar event '%s' (ID: %s) from message %s", created.Summary, created.Id, s.message.Id)
+
+	details.CreatedEventID = created.Id
+	return rules.Result{Details: details}, nil
+}
+
+// previewCalendarEvent rekent met onderwerp en snippet, want de simulatie heeft geen volledig bericht.
+func previewCalendarEvent(_ context.Context, s *messageSubject, _ rules.Rule, params createEventParams) (any, error) {
+	subject, from, text := "", "", s.message.Snippet
+	if s.message.Payload != nil {
+		if value := s.gp.getHeaderValue(s.message.Payload.Headers, "Subject"); value != nil {
+			subject = *value
+		}
+		if value := s.gp.getHeaderValue(s.message.Payload.Headers, "From"); value != nil {
+			from = *value
+		}
+	}
+	planned, err := planEvent(params, subject, from, subject+"\n"+text)
+	if err != nil {
+		return ActionPreview{}, err
+	}
+	if planned == nil {
+		return ActionPreview{}, fmt.Errorf("pattern did not match the message")
+	}
+	return ActionPreview{
+		EventTitle:    planned.Title,
+		EventStart:    &planned.Start,
+		EventEnd:      &planned.End,
+		EventLocation: planned.Location,
+	}, nil
+}
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// stripTags maakt van een HTML body grove platte tekst, genoeg om een patroon op te laten matchen.
+func stripTags(html string) string {
+	return htmlTag.ReplaceAllString(html, " ")
+}
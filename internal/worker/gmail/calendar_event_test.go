@@ -0,0 +1,115 @@
+package gmail
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/email"
+	"agenda-automator-api/internal/rules"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+)
+
+// bookingPattern leest de boekingsbevestigingen uit de tests
+const bookingPattern = `op (?P<start>\d{2}-\d{2}-\d{4} \d{2}:\d{2}) tot (?P<end>\d{2}:\d{2}) in (?P<location>[^.\n]+)`
+
+func TestCreateCalendarEvent(t *testing.T) {
+	original, err := (&email.Message{
+		From:    "boekingen@example.com",
+		To:      []string{"jeffrey@example.com"},
+		Subject: "Boeking bevestigd",
+		Text:    "Uw afspraak staat op 30-11-2025 09:30 tot 10:15 in Utrecht.",
+	}).Build()
+	require.NoError(t, err)
+
+	var inserted calendar.Event
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/gmail/v1/users/me/messages/msg-1":
+			json.NewEncoder(w).Encode(gmail.Message{Id: "msg-1", Raw: base64.URLEncoding.EncodeToString(original)})
+		case r.Method == "POST" && r.URL.Path == "/calendars/primary/events":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&inserted))
+			inserted.Id = "event-1"
+			json.NewEncoder(w).Encode(inserted)
+		default:
+			t.Errorf("Onverwacht request naar Fake Google API: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	gp, mockStore := setupSnoozeTest(t, handler)
+	gp.newCalendarService = func(ctx context.Context, client *http.Client) (*calendar.Service, error) {
+		return calendar.NewService(ctx, option.WithHTTPClient(client), option.WithEndpoint(server.URL))
+	}
+	ctx := context.Background()
+	srv, err := gp.newService(ctx, http.DefaultClient)
+	require.NoError(t, err)
+
+	acc := &domain.ConnectedAccount{ID: uuid.New()}
+	mockStore.On("GetValidTokenForAccount", ctx, acc.ID).Return(&oauth2.Token{AccessToken: "fake-token"}, nil).Once()
+
+	subject := &messageSubject{gp: gp, srv: srv, acc: acc, message: &gmail.Message{Id: "msg-1"}}
+	params := createEventParams{Pattern: bookingPattern, Title: "{{subject}} ({{location}})", TimeZone: "Europe/Amsterdam"}
+	result, err := createCalendarEvent(ctx, subject, rules.Rule{Name: "Boekingen"}, params)
+
+	require.NoError(t, err)
+	assert.False(t, result.Skipped)
+	assert.Equal(t, domain.GmailActionLogDetails{ActionType: domain.GmailActionCreateEvent, CreatedEventID: "event-1"}, result.Details)
+	assert.Equal(t, "Boeking bevestigd (Utrecht)", inserted.Summary)
+	assert.Equal(t, "Utrecht", inserted.Location)
+	assert.Equal(t, "2025-11-30T09:30:00+01:00", inserted.Start.DateTime)
+	assert.Equal(t, "2025-11-30T10:15:00+01:00", inserted.End.DateTime)
+	assert.Equal(t, "Europe/Amsterdam", inserted.Start.TimeZone)
+	mockStore.AssertExpectations(t)
+}
+
+func TestCreateCalendarEvent_NoMatch(t *testing.T) {
+	original, err := (&email.Message{To: []string{"jeffrey@example.com"}, Subject: "Nieuwsbrief", Text: "Geen afspraak"}).Build()
+	require.NoError(t, err)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/gmail/v1/users/me/messages/msg-1" {
+			t.Errorf("Onverwacht request naar Fake Google API: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(gmail.Message{Id: "msg-1", Raw: base64.URLEncoding.EncodeToString(original)})
+	})
+	gp, mockStore := setupSnoozeTest(t, handler)
+	ctx := context.Background()
+	srv, err := gp.newService(ctx, http.DefaultClient)
+	require.NoError(t, err)
+
+	subject := &messageSubject{gp: gp, srv: srv, acc: &domain.ConnectedAccount{ID: uuid.New()}, message: &gmail.Message{Id: "msg-1"}}
+	result, err := createCalendarEvent(ctx, subject, rules.Rule{}, createEventParams{Pattern: bookingPattern, Title: "Afspraak"})
+
+	require.NoError(t, err)
+	assert.True(t, result.Skipped)
+	assert.Equal(t, "pattern did not match the message", result.Details.(domain.GmailActionLogDetails).Reason)
+	mockStore.AssertNotCalled(t, "GetValidTokenForAccount")
+}
+
+func TestPlanEvent_DefaultDuration(t *testing.T) {
+	planned, err := planEvent(createEventParams{Pattern: `om (?P<start>\S+ \S+)`, Title: "Bel {{from}}"},
+		"Terugbelverzoek", "klant@example.com", "Graag om 2025-11-30 14:00 bellen")
+
+	require.NoError(t, err)
+	require.NotNil(t, planned)
+	assert.Equal(t, "Bel klant@example.com", planned.Title)
+	assert.Equal(t, time.Date(2025, 11, 30, 14, 0, 0, 0, time.UTC), planned.Start)
+	assert.Equal(t, time.Date(2025, 11, 30, 15, 0, 0, 0, time.UTC), planned.End)
+}
@@ -0,0 +1,100 @@
+package gmail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"agenda-automator-api/internal/email"
+	"agenda-automator-api/internal/rules"
+
+	"github.com/google/uuid"
+	"google.golang.org/api/gmail/v1"
+)
+
+// ErrForwardAddressNotVerified wordt teruggegeven als een forward regel naar een niet-geverifieerd adres wil sturen.
+var ErrForwardAddressNotVerified = errors.New("forward address is not a verified forwarding address")
+
+type forwardParams struct {
+	To   []string `json:"to"`
+	Mode string   `json:"mode"`
+	Note string   `json:"note"`
+}
+
+func (p forwardParams) Validate() error {
+	var errs rules.FieldErrors
+	if len(p.To) == 0 {
+		errs.Add("to", "forward action requires at least one address")
+	}
+	addAddressErrors(&errs, "to", p.To)
+	if _, err := email.ParseForwardMode(p.Mode); err != nil {
+		errs.Add("mode", "%v", err)
+	}
+	return errs.Err()
+}
+
+// executeForward stuurt het originele bericht door, inline of als message/rfc822 bijlage.
+// Alleen adressen die de gebruiker zelf heeft geverifieerd zijn toegestaan, zodat een
+// gecompromitteerde regel geen mail naar willekeurige adressen kan doorsturen.
+func (gp *GmailProcessor) executeForward(
+	ctx context.Context,
+	s *messageSubject,
+	_ rules.Rule,
+	params forwardParams,
+) error {
+	mode, err := email.ParseForwardMode(params.Mode)
+	if err != nil {
+		return err
+	}
+
+	if err := gp.checkForwardTargets(ctx, s.acc.UserID, params.To); err != nil {
+		return err
+	}
+
+	original, err := s.srv.Users.Messages.Get("me", s.message.Id).Format("raw").Do()
+	if err != nil {
+		return fmt.Errorf("could not fetch raw message: %w", err)
+	}
+	raw, err := email.DecodeRaw(original.Raw)
+	if err != nil {
+		return fmt.Errorf("could not decode raw message: %w", err)
+	}
+
+	forward, err := email.Forward(raw, mode, params.To, params.Note)
+	if err != nil {
+		return err
+	}
+	encoded, err := forward.Raw()
+	if err != nil {
+		return fmt.Errorf("could not compose forward: %w", err)
+	}
+
+	_, err = s.srv.Users.Messages.Send("me", &gmail.Message{Raw: encoded}).Do()
+	return err
+}
+
+// checkForwardTargets controleert dat elk adres op de geverifieerde doorstuurlijst van de gebruiker staat.
+func (gp *GmailProcessor) checkForwardTargets(ctx context.Context, userID uuid.UUID, targets []string) error {
+	addresses, err := gp.store.GetGmailForwardingAddressesForUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("could not load forwarding addresses: %w", err)
+	}
+
+	verified := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		if address.VerifiedAt != nil {
+			verified[address.Email] = true
+		}
+	}
+
+	for _, target := range targets {
+		normalized, err := email.NormalizeAddress(target)
+		if err != nil {
+			return fmt.Errorf("invalid forward address %q: %w", target, err)
+		}
+		if !verified[normalized] {
+			return fmt.Errorf("%w: %s", ErrForwardAddressNotVerified, normalized)
+		}
+	}
+	return nil
+}
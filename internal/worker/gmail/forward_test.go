@@ -0,0 +1,113 @@
+package gmail
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/email"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/gmail/v1"
+)
+
+func forwardRule(params string) domain.GmailAutomationRule {
+	return domain.GmailAutomationRule{
+		BaseAutomationRule: domain.BaseAutomationRule{ActionParams: json.RawMessage(params)},
+		ActionType:         domain.GmailActionForward,
+	}
+}
+
+func TestGmail_executeForward_AsAttachmentToVerifiedAddress(t *testing.T) {
+	original, err := (&email.Message{
+		From:    "klant@example.com",
+		To:      []string{"jeffrey@example.com"},
+		Subject: "Factuur maart",
+		Text:    "Hierbij de factuur",
+	}).Build()
+	require.NoError(t, err)
+
+	var sent gmail.Message
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/gmail/v1/users/me/messages/msg-1":
+			assert.Equal(t, "raw", r.URL.Query().Get("format"))
+			json.NewEncoder(w).Encode(gmail.Message{Id: "msg-1", Raw: base64.URLEncoding.EncodeToString(original)})
+		case r.Method == "POST" && r.URL.Path == "/gmail/v1/users/me/messages/send":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+			json.NewEncoder(w).Encode(gmail.Message{Id: "sent-1"})
+		default:
+			t.Errorf("Onverwacht request naar Fake Gmail API: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	gp, mockStore := setupSnoozeTest(t, handler)
+
+	ctx := context.Background()
+	srv, err := gp.newService(ctx, http.DefaultClient)
+	require.NoError(t, err)
+
+	acc := &domain.ConnectedAccount{ID: uuid.New(), UserID: uuid.New()}
+	verifiedAt := time.Now()
+	mockStore.On("GetGmailForwardingAddressesForUser", ctx, acc.UserID).Return([]domain.GmailForwardingAddress{
+		{Email: "boekhouding@example.com", VerifiedAt: &verifiedAt},
+	}, nil).Once()
+
+	rule := forwardRule(`{"to": ["Boekhouding <Boekhouding@example.com>"], "mode": "attachment", "note": "Graag inboeken"}`)
+	err = executeRule(ctx, gp, srv, acc, &gmail.Message{Id: "msg-1"}, rule)
+
+	require.NoError(t, err)
+	raw, err := email.DecodeRaw(sent.Raw)
+	require.NoError(t, err)
+	forwarded, err := email.Parse(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "Fwd: Factuur maart", forwarded.Subject)
+	assert.Equal(t, "Graag inboeken", forwarded.Text)
+	require.Len(t, forwarded.Attachments, 1)
+	assert.Equal(t, "message/rfc822", forwarded.Attachments[0].ContentType)
+	assert.Contains(t, string(forwarded.Attachments[0].Data), "Hierbij de factuur")
+	mockStore.AssertExpectations(t)
+}
+
+func TestGmail_executeForward_RejectsUnverifiedAddress(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Onverwacht request naar Fake Gmail API: %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	gp, mockStore := setupSnoozeTest(t, handler)
+
+	ctx := context.Background()
+	srv, err := gp.newService(ctx, http.DefaultClient)
+	require.NoError(t, err)
+
+	acc := &domain.ConnectedAccount{ID: uuid.New(), UserID: uuid.New()}
+	mockStore.On("GetGmailForwardingAddressesForUser", ctx, acc.UserID).Return([]domain.GmailForwardingAddress{
+		{Email: "boekhouding@example.com"}, // nog niet geverifieerd
+	}, nil).Once()
+
+	rule := forwardRule(`{"to": ["boekhouding@example.com"]}`)
+	err = executeRule(ctx, gp, srv, acc, &gmail.Message{Id: "msg-1"}, rule)
+
+	assert.ErrorIs(t, err, ErrForwardAddressNotVerified)
+	mockStore.AssertExpectations(t)
+}
+
+func TestGmail_executeForward_InvalidParams(t *testing.T) {
+	gp := newTestProcessor()
+	acc := &domain.ConnectedAccount{}
+
+	err := executeRule(context.Background(), gp, nil, acc, &gmail.Message{Id: "msg-1"}, forwardRule(`{}`))
+	assert.Error(t, err)
+
+	err = executeRule(
+		context.Background(), gp, nil, acc, &gmail.Message{Id: "msg-1"},
+		forwardRule(`{"to": ["a@example.com"], "mode": "bcc"}`),
+	)
+	assert.Error(t, err)
+}
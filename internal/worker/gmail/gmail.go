@@ -6,31 +6,165 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 
 	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
 	"agenda-automator-api/internal/store"
+	"agenda-automator-api/internal/unsubscribe"
+	"agenda-automator-api/internal/webhook"
 
+	"github.com/google/uuid"
 	"golang.org/x/oauth2"
+	"google.golang.org/api/calendar/v3"
 	"google.golang.org/api/gmail/v1"
 	"google.golang.org/api/option"
 )
 
 // GmailProcessor handles Gmail message processing
 type GmailProcessor struct {
-	store      store.Storer
-	newService func(ctx context.Context, client *http.Client) (*gmail.Service, error)
+	store        store.Storer
+	unsubscriber *unsubscribe.Unsubscriber
+	engine       *rules.Engine[*messageSubject]
+	newService   func(ctx context.Context, client *http.Client) (*gmail.Service, error)
+	// newCalendarService is voor acties in de agenda van het account, zoals create_calendar_event
+	newCalendarService func(ctx context.Context, client *http.Client) (*calendar.Service, error)
+	sendWebhook        webhook.SendFunc
 }
 
 // NewGmailProcessor creates a new Gmail processor
 func NewGmailProcessor(s store.Storer) *GmailProcessor {
 	return &GmailProcessor{
-		store: s,
+		store:        s,
+		unsubscriber: unsubscribe.NewUnsubscriber(),
+		engine: rules.NewEngine(Rules, rules.Config[*messageSubject]{
+			Name:     "Gmail",
+			RuleType: domain.RuleTypeGmail,
+			Logs:     s,
+			Describe: describeMessage,
+			AlreadyHandled: func(ctx context.Context, rule rules.Rule, subject *messageSubject) (bool, error) {
+				return s.HasLogForMessage(ctx, rule.ID, rule.AccountID, subject.message.Id)
+			},
+			RecordExecution: func(ctx context.Context, rule rules.Rule) (int, error) {
+				return s.RecordGmailRuleExecution(ctx, rule.ID)
+			},
+			Expire: func(ctx context.Context, rule rules.Rule) error {
+				_, err := s.ExpireGmailRule(ctx, rule.ID)
+				return err
+			},
+			Limits: rules.LimitsFromEnv(),
+			ExecutionCounts: func(ctx context.Context, rule rules.Rule) (int, int, error) {
+				return s.GetRuleExecutionCounts(ctx, rule.ID, rule.AccountID)
+			},
+			CountExecution: func(ctx context.Context, rule rules.Rule) (int, error) {
+				_, accountDay, err := s.CountRuleExecution(ctx, domain.RuleTypeGmail, rule.ID, rule.AccountID)
+				return accountDay, err
+			},
+			BusiestRule: func(ctx context.Context, accountID uuid.UUID) (domain.RuleType, uuid.UUID, error) {
+				return s.GetBusiestRule(ctx, accountID)
+			},
+			Pause:         rules.PauseWith(s),
+			Notifications: s,
+		}),
 		newService: func(ctx context.Context, client *http.Client) (*gmail.Service, error) {
 			return gmail.NewService(ctx, option.WithHTTPClient(client))
 		},
+		newCalendarService: func(ctx context.Context, client *http.Client) (*calendar.Service, error) {
+			return calendar.NewService(ctx, option.WithHTTPClient(client))
+		},
+		sendWebhook: webhook.NewSender().Send,
+	}
+}
+
+// rulesForAccount geeft de rules van het account plus de user-level rules van de eigenaar die op
+// het account van toepassing zijn, samen op priority DESC. De user-level rules krijgen het account
+// als ConnectedAccountID, zodat logs en limieten per account blijven.
+func (gp *GmailProcessor) rulesForAccount(
+	ctx context.Context,
+	acc *domain.ConnectedAccount,
+) ([]domain.GmailAutomationRule, error) {
+	accountRules, err := gp.store.GetGmailRulesForAccount(ctx, acc.ID)
+	if err != nil {
+		return nil, err
+	}
+	userRules, err := gp.store.GetUserGmailRules(ctx, acc.UserID)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range userRules {
+		if rule.AppliesTo(acc) {
+			rule.ConnectedAccountID = acc.ID
+			accountRules = append(accountRules, rule)
+		}
+	}
+	// Bij gelijke priority gaan de rules van het account voor
+	slices.SortStableFunc(accountRules, func(a, b domain.GmailAutomationRule) int {
+		return b.Priority - a.Priority
+	})
+	return accountRules, nil
+}
+
+// dueRules geeft de actieve rules van het account binnen hun schedule, of met een ruleID alleen
+// die rule. Verlopen rules worden hier uitgezet.
+func (gp *GmailProcessor) dueRules(
+	ctx context.Context,
+	acc *domain.ConnectedAccount,
+	ruleID uuid.UUID,
+) ([]rules.Rule, error) {
+	gmailRules, err := gp.rulesForAccount(ctx, acc)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch Gmail rules: %w", err)
+	}
+
+	now := time.Now()
+	activeRules := make([]rules.Rule, 0, len(gmailRules))
+	for _, rule := range gmailRules {
+		if !rule.IsActive || (ruleID != uuid.Nil && rule.ID != ruleID) {
+			continue
+		}
+		if r := engineRule(rule); gp.engine.Due(ctx, r, now) {
+			activeRules = append(activeRules, r)
+		}
+	}
+	return activeRules, nil
+}
+
+// ProcessRule verwerkt de berichten van het afgelopen etmaal voor één rule, zoals een volledige sync
+// dat doet. De sync state blijft staan: de andere rules zien de nieuwe berichten bij de volgende
+// tick nog, en berichten waar de rule al op draaide worden overgeslagen.
+func (gp *GmailProcessor) ProcessRule(
+	ctx context.Context,
+	acc *domain.ConnectedAccount,
+	token *oauth2.Token,
+	ruleID uuid.UUID,
+) error {
+	activeRules, err := gp.dueRules(ctx, acc, ruleID)
+	if err != nil {
+		return err
+	}
+	if len(activeRules) == 0 {
+		log.Printf("[Gmail] Rule %s is not due for %s", ruleID, acc.Email)
+		return nil
+	}
+
+	client := oauth2.NewClient(ctx, oauth2.StaticTokenSource(token))
+	srv, err := gp.newService(ctx, client)
+	if err != nil {
+		return fmt.Errorf("could not create Gmail service: %w", err)
 	}
+
+	messages, err := gp.fetchRecentMessages(srv, acc)
+	if err != nil {
+		return fmt.Errorf("could not fetch recent messages: %w", err)
+	}
+	for _, message := range messages {
+		if err = gp.processMessageAgainstRules(ctx, srv, acc, message, activeRules); err != nil {
+			log.Printf("[Gmail] Error processing message %s: %v", message.Id, err)
+		}
+	}
+	return nil
 }
 
 // ProcessMessages processes Gmail messages for automation rules
@@ -48,17 +182,10 @@ func (gp *GmailProcessor) ProcessMessages(ctx context.Context, acc *domain.Conne
 		log.Printf("[Gmail] Could not get Gmail sync state for %s: %v", acc.Email, err)
 	}
 
-	// Fetch Gmail rules
-	rules, err := gp.store.GetGmailRulesForAccount(ctx, acc.ID)
+	// Alleen actieve rules binnen hun schedule
+	activeRules, err := gp.dueRules(ctx, acc, uuid.Nil)
 	if err != nil {
-		return fmt.Errorf("could not fetch Gmail rules: %w", err)
-	}
-
-	activeRules := make([]domain.GmailAutomationRule, 0)
-	for _, rule := range rules {
-		if rule.IsActive {
-			activeRules = append(activeRules, rule)
-		}
+		return err
 	}
 
 	if len(activeRules) == 0 {
@@ -3,17 +3,15 @@ package gmail
 
 import (
 	"context"
-	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"strings"
 	"time"
 
 	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/email"
 	"agenda-automator-api/internal/store"
 
-	"github.com/google/uuid"
 	"google.golang.org/api/gmail/v1"
 )
 
@@ -46,6 +44,7 @@ func (gp *GmailProcessor) storeMessageInDB(
 		(message.Payload.Body != nil && message.Payload.Body.Size > 0 && len(message.Payload.Parts) > 0)
 
 	receivedAt := time.Unix(message.InternalDate/1000, 0)
+	unsubscribeTarget := gp.parseUnsubscribeTarget(message)
 
 	params := store.StoreGmailMessageParams{
 		ConnectedAccountID: acc.ID,
@@ -64,6 +63,10 @@ func (gp *GmailProcessor) storeMessageInDB(
 		SizeEstimate:       &message.SizeEstimate,
 		ReceivedAt:         receivedAt,
 		Labels:             message.LabelIds,
+		// Lege strings worden NULL, zodat het nieuwsbriefrapport alleen echte mailinglijsten telt
+		UnsubscribeURL:      nilIfEmpty(unsubscribeTarget.HTTPURL),
+		UnsubscribeMailto:   nilIfEmpty(unsubscribeTarget.Mailto),
+		UnsubscribeOneClick: unsubscribeTarget.OneClick,
 	}
 
 	return gp.store.StoreGmailMessage(ctx, params)
@@ -154,80 +157,66 @@ func (gp *GmailProcessor) getLabelByName(srv *gmail.Service, name string) (*gmai
 	return nil, fmt.Errorf("label not found: %s", name)
 }
 
-func (gp *GmailProcessor) createReplyRaw(originalMessage *gmail.Message, replyText, fromEmail string) string {
-	subject := gp.getHeaderValue(originalMessage.Payload.Headers, "Subject")
-	if subject == nil {
-		subject = stringPtr("Re:")
-	} else if !strings.HasPrefix(*subject, "Re:") {
-		subject = stringPtr("Re: " + *subject)
-	}
+func (gp *GmailProcessor) createReplyRaw(originalMessage *gmail.Message, replyText, fromEmail string) (string, error) {
+	headers := originalMessage.Payload.Headers
 
-	to := gp.getHeaderValue(originalMessage.Payload.Headers, "From")
-	messageID := gp.getHeaderValue(originalMessage.Payload.Headers, "Message-ID")
-
-	rawMessage := fmt.Sprintf(
-		"To: %s\r\nFrom: %s\r\nSubject: %s\r\n",
-		*to, fromEmail, *subject,
-	)
+	to := gp.replyAddress(headers)
+	if to == nil {
+		return "", errors.New("original message has no sender to reply to")
+	}
 
-	if messageID != nil {
-		rawMessage += fmt.Sprintf("References: %s\r\nIn-Reply-To: %s\r\n", *messageID, *messageID)
+	reply := &email.Message{
+		From:    fromEmail,
+		To:      []string{*to},
+		Subject: gp.replySubject(headers),
+		Text:    replyText,
 	}
+	reply.InReplyTo, reply.References = gp.replyReferences(headers)
 
-	rawMessage += "Content-Type: text/plain; charset=UTF-8\r\n\r\n" + replyText
+	return reply.Raw()
+}
 
-	return base64.URLEncoding.EncodeToString([]byte(rawMessage))
+// replyAddress geeft het adres voor een antwoord: Reply-To van de afzender gaat voor From.
+func (gp *GmailProcessor) replyAddress(headers []*gmail.MessagePartHeader) *string {
+	if to := gp.getHeaderValue(headers, "Reply-To"); to != nil {
+		return to
+	}
+	return gp.getHeaderValue(headers, "From")
 }
 
-// Logging helpers
-func (gp *GmailProcessor) logGmailAutomationSuccess(
-	ctx context.Context,
-	accountID uuid.UUID,
-	ruleID *uuid.UUID,
-	messageID, threadID, details string,
-) {
-	params := store.CreateLogParams{
-		ConnectedAccountID: accountID,
-		RuleID:             ruleID,
-		Status:             domain.LogSuccess,
-		TriggerDetails: json.RawMessage(
-			fmt.Sprintf(
-				`{"gmail_message_id": %q, "gmail_thread_id": %q}`,
-				messageID,
-				threadID,
-			),
-		),
-		ActionDetails: json.RawMessage(fmt.Sprintf(`{"details": %q}`, details)),
-	}
-	if err := gp.store.CreateAutomationLog(ctx, params); err != nil {
-		log.Printf("Failed to create automation log: %v", err)
+// replySubject geeft het onderwerp voor een antwoord, met precies één "Re:" ervoor.
+func (gp *GmailProcessor) replySubject(headers []*gmail.MessagePartHeader) string {
+	subject := gp.getHeaderValue(headers, "Subject")
+	if subject == nil {
+		return "Re:"
 	}
+	if strings.HasPrefix(strings.ToLower(*subject), "re:") {
+		return *subject
+	}
+	return "Re: " + *subject
 }
 
-func (gp *GmailProcessor) logGmailAutomationFailure(
-	ctx context.Context,
-	accountID uuid.UUID,
-	ruleID *uuid.UUID,
-	messageID, threadID, errorMsg string,
-) {
-	params := store.CreateLogParams{
-		ConnectedAccountID: accountID,
-		RuleID:             ruleID,
-		Status:             domain.LogFailure,
-		TriggerDetails: json.RawMessage(
-			fmt.Sprintf(
-				`{"gmail_message_id": %q, "gmail_thread_id": %q}`,
-				messageID,
-				threadID,
-			),
-		),
-		ErrorMessage: errorMsg,
-	}
-	if err := gp.store.CreateAutomationLog(ctx, params); err != nil {
-		log.Printf("Failed to create automation log: %v", err)
+// replyReferences geeft de In-Reply-To en References headers voor een antwoord (RFC 5322 sectie 3.6.4).
+func (gp *GmailProcessor) replyReferences(headers []*gmail.MessagePartHeader) (inReplyTo, references string) {
+	messageID := gp.getHeaderValue(headers, "Message-ID")
+	if messageID == nil {
+		return "", ""
+	}
+
+	references = *messageID
+	if previous := gp.getHeaderValue(headers, "References"); previous != nil {
+		references = *previous + " " + *messageID
 	}
+	return *messageID, references
 }
 
 func stringPtr(s string) *string {
 	return &s
 }
+
+func nilIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,49 @@
+package gmail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"mime"
+	"net/mail"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/gmail/v1"
+)
+
+func TestGmail_createReplyRaw(t *testing.T) {
+	gp := newTestProcessor()
+	original := &gmail.Message{Payload: &gmail.MessagePart{Headers: []*gmail.MessagePartHeader{
+		{Name: "From", Value: "Zoë <zoe@example.com>"},
+		{Name: "Reply-To", Value: "support@example.com"},
+		{Name: "Subject", Value: "Vraag over één factuur"},
+		{Name: "Message-ID", Value: "<b@mail.example.com>"},
+		{Name: "References", Value: "<a@mail.example.com>"},
+	}}}
+
+	raw, err := gp.createReplyRaw(original, "Bedankt, we kijken ernaar.", "jeffrey@example.com")
+	require.NoError(t, err)
+
+	decoded, err := base64.URLEncoding.DecodeString(raw)
+	require.NoError(t, err)
+	parsed, err := mail.ReadMessage(bytes.NewReader(decoded))
+	require.NoError(t, err)
+
+	// Reply-To gaat voor From
+	assert.Equal(t, "<support@example.com>", parsed.Header.Get("To"))
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Re: Vraag over één factuur", subject)
+	assert.Equal(t, "<b@mail.example.com>", parsed.Header.Get("In-Reply-To"))
+	assert.Equal(t, "<a@mail.example.com> <b@mail.example.com>", parsed.Header.Get("References"))
+}
+
+func TestGmail_createReplyRaw_NoSender(t *testing.T) {
+	gp := newTestProcessor()
+	original := &gmail.Message{Payload: &gmail.MessagePart{}}
+
+	_, err := gp.createReplyRaw(original, "Hoi", "jeffrey@example.com")
+
+	assert.Error(t, err)
+}
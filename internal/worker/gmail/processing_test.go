@@ -2,20 +2,43 @@ package gmail
 
 import (
 	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/store"
+	"context"
 	"encoding/json"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"google.golang.org/api/gmail/v1"
 )
 
 // Helper om een processor te maken (geen mocks nodig voor deze tests)
 func newTestProcessor() *GmailProcessor {
-	return &GmailProcessor{store: nil} // Store is niet nodig voor checkRuleMatch
+	return NewGmailProcessor(nil) // Store is niet nodig voor alleen trigger of actie
+}
+
+// matchRule evalueert alleen de trigger van rule via de rules engine, zoals de processor dat doet.
+func matchRule(gp *GmailProcessor, message *gmail.Message, rule domain.GmailAutomationRule) (bool, error) {
+	return gp.engine.Match(context.Background(), engineRule(rule), &messageSubject{gp: gp, message: message})
+}
+
+// executeRule voert alleen de actie van rule uit via de rules engine, zonder te loggen.
+func executeRule(
+	ctx context.Context,
+	gp *GmailProcessor,
+	srv *gmail.Service,
+	acc *domain.ConnectedAccount,
+	message *gmail.Message,
+	rule domain.GmailAutomationRule,
+) error {
+	_, err := gp.engine.Execute(ctx, engineRule(rule), &messageSubject{gp: gp, srv: srv, acc: acc, message: message})
+	return err
 }
 
 // Test 1: Sender Match - Wel match
-func TestGmail_checkRuleMatch_SenderMatch(t *testing.T) {
+func TestGmail_RuleMatch_SenderMatch(t *testing.T) {
 	gp := newTestProcessor()
 
 	// Arrange
@@ -36,7 +59,7 @@ func TestGmail_checkRuleMatch_SenderMatch(t *testing.T) {
 	}
 
 	// Act
-	matches, err := gp.checkRuleMatch(msg, rule)
+	matches, err := matchRule(gp, msg, rule)
 
 	// Assert
 	assert.NoError(t, err)
@@ -44,7 +67,7 @@ func TestGmail_checkRuleMatch_SenderMatch(t *testing.T) {
 }
 
 // Test 2: Sender Match - Geen match
-func TestGmail_checkRuleMatch_SenderNoMatch(t *testing.T) {
+func TestGmail_RuleMatch_SenderNoMatch(t *testing.T) {
 	gp := newTestProcessor()
 
 	// Arrange
@@ -65,7 +88,7 @@ func TestGmail_checkRuleMatch_SenderNoMatch(t *testing.T) {
 	}
 
 	// Act
-	matches, err := gp.checkRuleMatch(msg, rule)
+	matches, err := matchRule(gp, msg, rule)
 
 	// Assert
 	assert.NoError(t, err)
@@ -73,7 +96,7 @@ func TestGmail_checkRuleMatch_SenderNoMatch(t *testing.T) {
 }
 
 // Test 3: Subject Match - Wel match (case-insensitive)
-func TestGmail_checkRuleMatch_SubjectMatch(t *testing.T) {
+func TestGmail_RuleMatch_SubjectMatch(t *testing.T) {
 	gp := newTestProcessor()
 
 	// Arrange
@@ -94,7 +117,7 @@ func TestGmail_checkRuleMatch_SubjectMatch(t *testing.T) {
 	}
 
 	// Act
-	matches, err := gp.checkRuleMatch(msg, rule)
+	matches, err := matchRule(gp, msg, rule)
 
 	// Assert
 	assert.NoError(t, err)
@@ -102,7 +125,7 @@ func TestGmail_checkRuleMatch_SubjectMatch(t *testing.T) {
 }
 
 // Test 4: Starred Match - Wel match
-func TestGmail_checkRuleMatch_Starred(t *testing.T) {
+func TestGmail_RuleMatch_Starred(t *testing.T) {
 	gp := newTestProcessor()
 
 	// Arrange
@@ -114,9 +137,41 @@ func TestGmail_checkRuleMatch_Starred(t *testing.T) {
 	}
 
 	// Act
-	matches, err := gp.checkRuleMatch(msg, rule)
+	matches, err := matchRule(gp, msg, rule)
 
 	// Assert
 	assert.NoError(t, err)
 	assert.True(t, matches)
 }
+
+// Een bericht waar de rule al op draaide wordt overgeslagen, bijv. bij een handmatige run
+func TestGmail_RuleRun_SkipsHandledMessage(t *testing.T) {
+	mockStore := &store.MockStore{}
+	gp := NewGmailProcessor(mockStore)
+
+	msg := &gmail.Message{
+		Id: "msg-1",
+		Payload: &gmail.MessagePart{
+			Headers: []*gmail.MessagePartHeader{{Name: "From", Value: "news@example.com"}},
+		},
+	}
+	cond, _ := json.Marshal(map[string]string{"sender_pattern": "example.com"})
+	rule := engineRule(domain.GmailAutomationRule{
+		BaseAutomationRule: domain.BaseAutomationRule{
+			AccountEntity: domain.AccountEntity{
+				BaseEntity:         domain.BaseEntity{ID: uuid.New()},
+				ConnectedAccountID: uuid.New(),
+			},
+			TriggerConditions: cond,
+		},
+		TriggerType: domain.GmailTriggerSenderMatch,
+		ActionType:  domain.GmailActionArchive,
+	})
+	mockStore.On("HasLogForMessage", mock.Anything, rule.ID, rule.AccountID, "msg-1").Return(true, nil)
+
+	outcome := gp.engine.Run(context.Background(), &rule, &messageSubject{gp: gp, message: msg})
+
+	assert.Equal(t, rules.OutcomeDuplicate, outcome)
+	mockStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "CreateAutomationLog", mock.Anything, mock.Anything)
+}
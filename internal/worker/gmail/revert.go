@@ -0,0 +1,91 @@
+package gmail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// Revert draait de gelogde acties van een Gmail rule terug, in de volgorde van logs (nieuwste
+// eerst). Alleen acties die labels wijzigden zijn terug te draaien; een bericht in de prullenbak
+// wordt eruit gehaald. Verstuurde berichten, snoozes en afmeldingen worden als niet teruggedraaid
+// gemeld, net als logs van vóór de action_type in action_details.
+func Revert(ctx context.Context, srv *gmail.Service, logs []domain.AutomationLog) rules.RevertReport {
+	report := rules.NewRevertReport()
+	for _, entry := range logs {
+		var trigger messageLogDetails
+		var action domain.GmailActionLogDetails
+		_ = json.Unmarshal(entry.TriggerDetails, &trigger)
+		_ = json.Unmarshal(entry.ActionDetails, &action)
+
+		item := rules.RevertItem{
+			LogID:     entry.ID,
+			Timestamp: entry.Timestamp,
+			Target:    trigger.GmailMessageID,
+			Action:    string(action.ActionType),
+		}
+		report.Add(item, revertMessage(ctx, srv, trigger.GmailMessageID, action))
+	}
+	return report
+}
+
+// revertMessage zet de labels van een bericht terug en geeft de reden als dat niet kan.
+func revertMessage(ctx context.Context, srv *gmail.Service, messageID string, action domain.GmailActionLogDetails) string {
+	switch action.ActionType {
+	case "":
+		return "log has no recorded prior state"
+	case domain.GmailActionAutoReply, domain.GmailActionForward:
+		return "message already sent"
+	case domain.GmailActionSchedule:
+		return "scheduled message cannot be reverted; cancel it under scheduled messages if it was not sent yet"
+	case domain.GmailActionSnooze:
+		return "snooze cannot be reverted; cancel the snooze instead"
+	case domain.GmailActionUnsubscribe:
+		return "unsubscribe cannot be reverted"
+	case domain.GmailActionCreateEvent:
+		return "calendar event cannot be reverted here; delete the created event instead"
+	case domain.GmailActionWebhook:
+		return "webhook already delivered"
+	}
+	if messageID == "" {
+		return "log has no message ID"
+	}
+
+	// TRASH gaat via untrash, zodat Gmail het bericht ook echt uit de prullenbak haalt
+	trashed := slices.Contains(action.AddedLabelIDs, "TRASH")
+	if trashed {
+		if _, err := srv.Users.Messages.Untrash("me", messageID).Context(ctx).Do(); err != nil {
+			return revertError(err)
+		}
+	}
+
+	modifyRequest := &gmail.ModifyMessageRequest{
+		AddLabelIds: action.RemovedLabelIDs,
+		RemoveLabelIds: slices.DeleteFunc(slices.Clone(action.AddedLabelIDs), func(id string) bool {
+			return id == "TRASH"
+		}),
+	}
+	if len(modifyRequest.AddLabelIds) == 0 && len(modifyRequest.RemoveLabelIds) == 0 {
+		return ""
+	}
+	if _, err := srv.Users.Messages.Modify("me", messageID, modifyRequest).Context(ctx).Do(); err != nil {
+		return revertError(err)
+	}
+	return ""
+}
+
+func revertError(err error) string {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+		return "message no longer exists"
+	}
+	return err.Error()
+}
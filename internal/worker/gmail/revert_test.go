@@ -0,0 +1,113 @@
+package gmail
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+)
+
+func revertTestLog(id int64, messageID, actionDetails string) domain.AutomationLog {
+	return domain.AutomationLog{
+		ID:             id,
+		Timestamp:      time.Now(),
+		Status:         domain.LogSuccess,
+		TriggerDetails: json.RawMessage(`{"gmail_message_id":"` + messageID + `","gmail_thread_id":"t"}`),
+		ActionDetails:  json.RawMessage(actionDetails),
+	}
+}
+
+func TestGmail_labelChangeAction_LogsOnlyChangedLabels(t *testing.T) {
+	var modify gmail.ModifyMessageRequest
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/gmail/v1/users/me/labels":
+			json.NewEncoder(w).Encode(gmail.ListLabelsResponse{Labels: []*gmail.Label{{Id: "Label_1", Name: "Facturen"}}})
+		case r.Method == "POST" && r.URL.Path == "/gmail/v1/users/me/messages/msg-1/modify":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&modify))
+			json.NewEncoder(w).Encode(gmail.Message{Id: "msg-1"})
+		default:
+			t.Errorf("Onverwacht request naar Fake Gmail API: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	gp, _ := setupSnoozeTest(t, handler)
+	ctx := context.Background()
+	srv, err := gp.newService(ctx, http.DefaultClient)
+	require.NoError(t, err)
+
+	// Het bericht heeft Label_1 al, maar staat nog in de inbox
+	subject := &messageSubject{gp: gp, srv: srv, message: &gmail.Message{Id: "msg-1", LabelIds: []string{"INBOX", "Label_1"}}}
+	rule := engineRule(simulateRule(domain.GmailTriggerNewMessage, `{}`, domain.GmailActionAddLabel,
+		`{"label_name":"Facturen","archive":true}`))
+
+	action, err := Rules.Action(rule.ActionType)
+	require.NoError(t, err)
+	result, err := action.Execute(ctx, subject, rule)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Label_1"}, modify.AddLabelIds)
+	assert.Equal(t, domain.GmailActionLogDetails{
+		ActionType:      domain.GmailActionAddLabel,
+		RemovedLabelIDs: []string{"INBOX"},
+	}, result.Details)
+}
+
+func TestGmail_Revert(t *testing.T) {
+	var modifies = map[string]gmail.ModifyMessageRequest{}
+	var untrashed []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /gmail/v1/users/me/messages/archived/modify", "POST /gmail/v1/users/me/messages/trashed/modify":
+			var modify gmail.ModifyMessageRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&modify))
+			modifies[r.URL.Path] = modify
+			json.NewEncoder(w).Encode(gmail.Message{})
+		case "POST /gmail/v1/users/me/messages/trashed/untrash":
+			untrashed = append(untrashed, "trashed")
+			json.NewEncoder(w).Encode(gmail.Message{})
+		case "POST /gmail/v1/users/me/messages/deleted/modify":
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"code": 404, "message": "Not Found"}})
+		default:
+			t.Errorf("Onverwacht request naar Fake Gmail API: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	srv, err := gmail.NewService(context.Background(), option.WithHTTPClient(server.Client()), option.WithEndpoint(server.URL))
+	require.NoError(t, err)
+
+	logs := []domain.AutomationLog{
+		revertTestLog(5, "archived", `{"action_type":"add_label","added_label_ids":["Label_1"],"removed_label_ids":["INBOX"]}`),
+		revertTestLog(4, "trashed", `{"action_type":"trash","added_label_ids":["TRASH","Label_2"]}`),
+		revertTestLog(3, "deleted", `{"action_type":"mark_read","removed_label_ids":["UNREAD"]}`),
+		revertTestLog(2, "replied", `{"action_type":"auto_reply"}`),
+		revertTestLog(1, "old", `{"details":"Action executed successfully"}`),
+		revertTestLog(0, "hooked", `{"action_type":"webhook","url":"https://example.com/hook","status_code":200}`),
+	}
+
+	report := Revert(context.Background(), srv, logs)
+
+	assert.Equal(t, []int64{5, 4}, report.RevertedLogIDs())
+	assert.Equal(t, []string{"INBOX"}, modifies["/gmail/v1/users/me/messages/archived/modify"].AddLabelIds)
+	assert.Equal(t, []string{"Label_1"}, modifies["/gmail/v1/users/me/messages/archived/modify"].RemoveLabelIds)
+	assert.Equal(t, []string{"trashed"}, untrashed)
+	assert.Equal(t, []string{"Label_2"}, modifies["/gmail/v1/users/me/messages/trashed/modify"].RemoveLabelIds)
+
+	require.Len(t, report.NotReverted, 4)
+	assert.Equal(t, "message no longer exists", report.NotReverted[0].Reason)
+	assert.Equal(t, "message already sent", report.NotReverted[1].Reason)
+	assert.Equal(t, "log has no recorded prior state", report.NotReverted[2].Reason)
+	assert.Equal(t, "webhook already delivered", report.NotReverted[3].Reason)
+}
@@ -0,0 +1,244 @@
+package gmail
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// messageSubject is het bericht waarop Gmail regels draaien, met alles wat acties nodig hebben.
+type messageSubject struct {
+	gp      *GmailProcessor
+	srv     *gmail.Service
+	acc     *domain.ConnectedAccount
+	message *gmail.Message
+}
+
+// messageLogDetails is de trigger_details van een Gmail automation log
+type messageLogDetails struct {
+	GmailMessageID string `json:"gmail_message_id"`
+	GmailThreadID  string `json:"gmail_thread_id"`
+}
+
+// Rules bevat alle Gmail triggers en acties. Een nieuw type hoeft alleen hier geregistreerd te worden;
+// de worker en de API validatie gebruiken deze registry. Alleen de rollback van migratie 000012
+// somt de types ook op, voor de enums die hij terugzet.
+var Rules = newRegistry()
+
+func newRegistry() *rules.Registry[*messageSubject] {
+	r := rules.NewRegistry[*messageSubject]()
+
+	r.RegisterTrigger(rules.NewTrigger(string(domain.GmailTriggerNewMessage), matchNewMessage))
+	r.RegisterTrigger(rules.NewTrigger(string(domain.GmailTriggerSenderMatch), matchSender))
+	r.RegisterTrigger(rules.NewTrigger(string(domain.GmailTriggerSubjectMatch), matchSubject))
+	r.RegisterTrigger(rules.NewTrigger(string(domain.GmailTriggerLabelAdded), matchLabel))
+	r.RegisterTrigger(rules.NewTrigger(string(domain.GmailTriggerStarred), matchStarred))
+
+	r.RegisterAction(gmailAction(domain.GmailActionAutoReply, (*GmailProcessor).executeAutoReply, previewAutoReply))
+	r.RegisterAction(gmailAction(domain.GmailActionForward, (*GmailProcessor).executeForward, previewForward))
+	r.RegisterAction(labelChangeAction(domain.GmailActionAddLabel, (*GmailProcessor).addLabelRequest, previewAddLabel))
+	r.RegisterAction(
+		labelChangeAction(domain.GmailActionRemoveLabel, (*GmailProcessor).removeLabelRequest, previewRemoveLabel),
+	)
+	r.RegisterAction(labelAction(domain.GmailActionMarkRead, nil, []string{"UNREAD"}))
+	r.RegisterAction(labelAction(domain.GmailActionMarkUnread, []string{"UNREAD"}, nil))
+	r.RegisterAction(labelAction(domain.GmailActionArchive, nil, []string{"INBOX"}))
+	r.RegisterAction(labelAction(domain.GmailActionTrash, []string{"TRASH"}, nil))
+	r.RegisterAction(labelAction(domain.GmailActionStar, []string{"STARRED"}, nil))
+	r.RegisterAction(labelAction(domain.GmailActionUnstar, nil, []string{"STARRED"}))
+	r.RegisterAction(gmailAction(domain.GmailActionSnooze, (*GmailProcessor).executeSnooze, previewSnooze))
+	r.RegisterAction(gmailAction(domain.GmailActionSchedule, (*GmailProcessor).executeScheduleSend, previewScheduleSend))
+	r.RegisterAction(gmailAction(domain.GmailActionUnsubscribe, (*GmailProcessor).executeUnsubscribe, previewUnsubscribe))
+	r.RegisterAction(rules.NewAction(string(domain.GmailActionCreateEvent), createCalendarEvent, previewCalendarEvent))
+	r.RegisterAction(rules.NewAction(string(domain.GmailActionWebhook), sendWebhook, previewWebhook))
+
+	return r
+}
+
+// gmailAction maakt een actie van een GmailProcessor methode met getypeerde parameters en een
+// preview die beschrijft wat de methode zou doen.
+func gmailAction[P any](
+	actionType domain.GmailRuleActionType,
+	execute func(gp *GmailProcessor, ctx context.Context, s *messageSubject, rule rules.Rule, params P) error,
+	preview func(s *messageSubject, params P) (ActionPreview, error),
+) rules.Action[*messageSubject] {
+	return rules.NewAction(string(actionType),
+		func(ctx context.Context, s *messageSubject, rule rules.Rule, params P) (rules.Result, error) {
+			if err := execute(s.gp, ctx, s, rule, params); err != nil {
+				return rules.Result{}, err
+			}
+			return rules.Result{Details: domain.GmailActionLogDetails{ActionType: actionType}}, nil
+		},
+		func(_ context.Context, s *messageSubject, _ rules.Rule, params P) (any, error) {
+			return preview(s, params)
+		})
+}
+
+// labelChangeAction maakt een actie die alleen labels van het bericht wijzigt. De log bevat welke
+// labels echt veranderden, zodat Revert het bericht kan terugzetten.
+func labelChangeAction[P any](
+	actionType domain.GmailRuleActionType,
+	change func(gp *GmailProcessor, s *messageSubject, params P) (*gmail.ModifyMessageRequest, error),
+	preview func(s *messageSubject, params P) (ActionPreview, error),
+) rules.Action[*messageSubject] {
+	return rules.NewAction(string(actionType),
+		func(_ context.Context, s *messageSubject, _ rules.Rule, params P) (rules.Result, error) {
+			modifyRequest, err := change(s.gp, s, params)
+			if err != nil {
+				return rules.Result{}, err
+			}
+			if _, err = s.srv.Users.Messages.Modify("me", s.message.Id, modifyRequest).Do(); err != nil {
+				return rules.Result{}, err
+			}
+			return rules.Result{Details: labelChanges(actionType, s.message.LabelIds, modifyRequest)}, nil
+		},
+		func(_ context.Context, s *messageSubject, _ rules.Rule, params P) (any, error) {
+			return preview(s, params)
+		})
+}
+
+// labelAction maakt een actie zonder parameters die vaste systeemlabels toevoegt of verwijdert.
+func labelAction(actionType domain.GmailRuleActionType, add, remove []string) rules.Action[*messageSubject] {
+	return labelChangeAction(actionType, fixedLabels(add, remove),
+		func(*messageSubject, rules.NoParams) (ActionPreview, error) {
+			return ActionPreview{AddLabels: add, RemoveLabels: remove}, nil
+		})
+}
+
+// engineRule zet een Gmail rule om naar de vorm van de rules engine.
+func engineRule(rule domain.GmailAutomationRule) rules.Rule {
+	return rules.Rule{
+		ID:            rule.ID,
+		AccountID:     rule.ConnectedAccountID,
+		Name:          rule.Name,
+		Version:       rule.Version,
+		TriggerType:   string(rule.TriggerType),
+		TriggerParams: rule.TriggerConditions,
+		ActionType:    string(rule.ActionType),
+		ActionParams:  rule.ActionParams,
+		Schedule:      rule.Schedule,
+		Executions:    rule.ExecutionCount,
+	}
+}
+
+func describeMessage(s *messageSubject) any {
+	return messageLogDetails{GmailMessageID: s.message.Id, GmailThreadID: s.message.ThreadId}
+}
+
+// Trigger parameters. Met regex=true is het patroon een reguliere expressie, anders een
+// hoofdletterongevoelige deelstring.
+type senderMatchParams struct {
+	SenderPattern string `json:"sender_pattern"`
+	Regex         bool   `json:"regex"`
+}
+
+func (p senderMatchParams) Validate() error {
+	var errs rules.FieldErrors
+	addPatternErrors(&errs, "sender_pattern", p.SenderPattern, p.Regex)
+	return errs.Err()
+}
+
+type subjectMatchParams struct {
+	SubjectPattern string `json:"subject_pattern"`
+	Regex          bool   `json:"regex"`
+}
+
+func (p subjectMatchParams) Validate() error {
+	var errs rules.FieldErrors
+	addPatternErrors(&errs, "subject_pattern", p.SubjectPattern, p.Regex)
+	return errs.Err()
+}
+
+// labelParams wordt gedeeld door de label_added trigger en de add_label/remove_label acties
+type labelParams struct {
+	LabelName string `json:"label_name"`
+}
+
+func (p labelParams) Validate() error {
+	var errs rules.FieldErrors
+	if strings.TrimSpace(p.LabelName) == "" {
+		errs.Add("label_name", "is required")
+	}
+	return errs.Err()
+}
+
+// addLabelParams zijn de parameters van add_label. Met archive=true verdwijnt het bericht ook uit de inbox,
+// zoals "Inbox overslaan" in een Gmail filter.
+type addLabelParams struct {
+	labelParams
+	Archive bool `json:"archive"`
+}
+
+func addPatternErrors(errs *rules.FieldErrors, field, pattern string, regex bool) {
+	if strings.TrimSpace(pattern) == "" {
+		errs.Add(field, "is required")
+		return
+	}
+	if regex {
+		if _, err := compilePattern(pattern); err != nil {
+			errs.Add(field, "invalid regular expression: %v", err)
+		}
+	}
+}
+
+// addAddressErrors meldt elk adres in addresses dat geen geldig e-mailadres is.
+func addAddressErrors(errs *rules.FieldErrors, field string, addresses []string) {
+	for i, address := range addresses {
+		if _, err := mail.ParseAddress(address); err != nil {
+			errs.Add(fmt.Sprintf("%s[%d]", field, i), "invalid email address %q", address)
+		}
+	}
+}
+
+// compilePattern compileert een regex patroon hoofdletterongevoelig, net als de deelstring match.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// Triggers
+func matchNewMessage(_ context.Context, _ *messageSubject, _ rules.NoParams) (bool, error) {
+	return true, nil
+}
+
+func matchSender(_ context.Context, s *messageSubject, params senderMatchParams) (bool, error) {
+	return s.headerMatches("From", params.SenderPattern, params.Regex)
+}
+
+func matchSubject(_ context.Context, s *messageSubject, params subjectMatchParams) (bool, error) {
+	return s.headerMatches("Subject", params.SubjectPattern, params.Regex)
+}
+
+func matchLabel(_ context.Context, s *messageSubject, params labelParams) (bool, error) {
+	return s.gp.hasLabel(s.message.LabelIds, params.LabelName), nil
+}
+
+func matchStarred(_ context.Context, s *messageSubject, _ rules.NoParams) (bool, error) {
+	return s.gp.hasLabel(s.message.LabelIds, "STARRED"), nil
+}
+
+// headerMatches zoekt hoofdletterongevoelig naar pattern in een header van het bericht,
+// als deelstring of als reguliere expressie.
+func (s *messageSubject) headerMatches(name, pattern string, regex bool) (bool, error) {
+	if s.message.Payload == nil {
+		return false, nil
+	}
+	value := s.gp.getHeaderValue(s.message.Payload.Headers, name)
+	if value == nil {
+		return false, nil
+	}
+	if !regex {
+		return strings.Contains(strings.ToLower(*value), strings.ToLower(pattern)), nil
+	}
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(*value), nil
+}
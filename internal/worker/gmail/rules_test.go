@@ -0,0 +1,162 @@
+package gmail
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/gmail/v1"
+)
+
+func TestRules_AllDomainTypesRegistered(t *testing.T) {
+	triggers := []domain.GmailRuleTriggerType{
+		domain.GmailTriggerNewMessage, domain.GmailTriggerSenderMatch, domain.GmailTriggerSubjectMatch,
+		domain.GmailTriggerLabelAdded, domain.GmailTriggerStarred,
+	}
+	for _, trigger := range triggers {
+		_, err := Rules.Trigger(string(trigger))
+		assert.NoError(t, err, trigger)
+	}
+
+	actions := []domain.GmailRuleActionType{
+		domain.GmailActionAutoReply, domain.GmailActionForward, domain.GmailActionAddLabel,
+		domain.GmailActionRemoveLabel, domain.GmailActionMarkRead, domain.GmailActionMarkUnread,
+		domain.GmailActionArchive, domain.GmailActionTrash, domain.GmailActionStar, domain.GmailActionUnstar,
+		domain.GmailActionSnooze, domain.GmailActionSchedule, domain.GmailActionUnsubscribe,
+		domain.GmailActionCreateEvent, domain.GmailActionWebhook,
+	}
+	for _, action := range actions {
+		_, err := Rules.Action(string(action))
+		assert.NoError(t, err, action)
+	}
+	assert.Len(t, Rules.ActionTypes(), len(actions))
+}
+
+func TestRules_ValidateParams(t *testing.T) {
+	tests := []struct {
+		actionType domain.GmailRuleActionType
+		params     string
+		wantErr    bool
+	}{
+		{domain.GmailActionAddLabel, `{"label_name": "Klanten"}`, false},
+		{domain.GmailActionCreateEvent, `{"pattern": "op (?P<start>\\d{2}-\\d{2}-\\d{4} \\d{2}:\\d{2})", "title": "Afspraak"}`, false},
+		{domain.GmailActionCreateEvent, `{"pattern": "op \\d{2}-\\d{2}-\\d{4}", "title": "Afspraak"}`, true},
+		{domain.GmailActionCreateEvent, `{"pattern": "op (?P<start>.+)", "title": "Afspraak", "timezone": "Mars/Olympus"}`, true},
+		{domain.GmailActionAddLabel, `{}`, true},
+		{domain.GmailActionAddLabel, `{"label_name": "Nieuwsbrieven", "archive": true}`, false},
+		{domain.GmailActionAutoReply, `{"reply_text": ""}`, true},
+		{domain.GmailActionForward, `{"to": ["a@example.com"], "mode": "attachment"}`, false},
+		{domain.GmailActionForward, `{"to": ["a@example.com"], "mode": "bcc"}`, true},
+		{domain.GmailActionSnooze, `{"duration_minutes": 30}`, false},
+		{domain.GmailActionSchedule, `{"delay_minutes": 60}`, true},
+		{domain.GmailActionArchive, ``, false},
+	}
+
+	for _, tt := range tests {
+		err := Rules.ValidateAction(string(tt.actionType), json.RawMessage(tt.params))
+		assert.Equal(t, tt.wantErr, err != nil, "%s %s: %v", tt.actionType, tt.params, err)
+	}
+}
+
+func TestRules_ValidateFieldPaths(t *testing.T) {
+	errs := Rules.ValidateRule(rules.Rule{
+		TriggerType:   string(domain.GmailTriggerSubjectMatch),
+		TriggerParams: json.RawMessage(`{"subject_pattern": "factuur (", "regex": true}`),
+		ActionType:    string(domain.GmailActionForward),
+		ActionParams:  json.RawMessage(`{"to": ["boekhouding@example.com", "geen adres"], "mode": "bcc"}`),
+	})
+
+	require.Len(t, errs, 3)
+	assert.Equal(t, "trigger_conditions.subject_pattern", errs[0].Field)
+	assert.Contains(t, errs[0].Message, "invalid regular expression")
+	assert.Equal(t, "action_params.to[1]", errs[1].Field)
+	assert.Equal(t, "action_params.mode", errs[2].Field)
+}
+
+func TestGmail_RuleMatch_Regex(t *testing.T) {
+	gp := newTestProcessor()
+	message := &gmail.Message{Payload: &gmail.MessagePart{Headers: []*gmail.MessagePartHeader{
+		{Name: "Subject", Value: "Factuur 2025-0042"},
+	}}}
+	rule := domain.GmailAutomationRule{
+		BaseAutomationRule: domain.BaseAutomationRule{
+			TriggerConditions: json.RawMessage(`{"subject_pattern": "^factuur \\d{4}-\\d+$", "regex": true}`),
+		},
+		TriggerType: domain.GmailTriggerSubjectMatch,
+	}
+
+	matches, err := matchRule(gp, message, rule)
+	require.NoError(t, err)
+	assert.True(t, matches)
+
+	// Zonder regex vlag is het patroon een letterlijke deelstring
+	rule.TriggerConditions = json.RawMessage(`{"subject_pattern": "^factuur"}`)
+	matches, err = matchRule(gp, message, rule)
+	require.NoError(t, err)
+	assert.False(t, matches)
+}
+
+func TestGmail_RuleMatch_LabelAdded(t *testing.T) {
+	gp := newTestProcessor()
+	rule := domain.GmailAutomationRule{
+		BaseAutomationRule: domain.BaseAutomationRule{TriggerConditions: json.RawMessage(`{"label_name": "Label_7"}`)},
+		TriggerType:        domain.GmailTriggerLabelAdded,
+	}
+
+	matches, err := matchRule(gp, &gmail.Message{LabelIds: []string{"INBOX", "Label_7"}}, rule)
+	require.NoError(t, err)
+	assert.True(t, matches)
+
+	rule.TriggerType = "on_full_moon"
+	_, err = matchRule(gp, &gmail.Message{}, rule)
+	assert.Error(t, err)
+}
+
+func TestGmail_rulesForAccount(t *testing.T) {
+	userID := uuid.New()
+	acc := &domain.ConnectedAccount{ID: uuid.New(), UserID: userID, Provider: domain.ProviderGoogle}
+	rule := func(name string, priority int, selector *domain.AccountSelector) domain.GmailAutomationRule {
+		r := domain.GmailAutomationRule{Priority: priority}
+		r.ID = uuid.New()
+		r.Name = name
+		if selector != nil {
+			r.UserID = &userID
+			r.AccountSelector = selector
+		} else {
+			r.ConnectedAccountID = acc.ID
+		}
+		return r
+	}
+
+	mockStore := new(store.MockStore)
+	mockStore.On("GetGmailRulesForAccount", mock.Anything, acc.ID).Return([]domain.GmailAutomationRule{
+		rule("account hoog", 5, nil),
+		rule("account laag", 1, nil),
+	}, nil)
+	mockStore.On("GetUserGmailRules", mock.Anything, userID).Return([]domain.GmailAutomationRule{
+		rule("user hoog", 5, &domain.AccountSelector{Mode: domain.AccountSelectorAll}),
+		rule("user midden", 3, &domain.AccountSelector{Mode: domain.AccountSelectorAccounts, AccountIDs: []uuid.UUID{acc.ID}}),
+		rule("ander account", 9, &domain.AccountSelector{Mode: domain.AccountSelectorAccounts, AccountIDs: []uuid.UUID{uuid.New()}}),
+		rule("microsoft", 9, &domain.AccountSelector{Mode: domain.AccountSelectorProvider, Provider: domain.ProviderMicrosoft}),
+	}, nil)
+
+	gp := &GmailProcessor{store: mockStore}
+	got, err := gp.rulesForAccount(context.Background(), acc)
+	require.NoError(t, err)
+
+	// Samen op priority, bij gelijke priority de rules van het account eerst
+	names := make([]string, len(got))
+	for i, r := range got {
+		names[i] = r.Name
+		assert.Equal(t, acc.ID, r.ConnectedAccountID, r.Name)
+	}
+	assert.Equal(t, []string{"account hoog", "user hoog", "user midden", "account laag"}, names)
+}
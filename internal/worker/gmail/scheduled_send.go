@@ -0,0 +1,99 @@
+package gmail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/email"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/store"
+
+	"golang.org/x/oauth2"
+)
+
+type scheduleSendParams struct {
+	DelayMinutes int      `json:"delay_minutes"`
+	To           []string `json:"to"`
+	Subject      string   `json:"subject"`
+	Body         string   `json:"body"`
+	IsHTML       bool     `json:"is_html"`
+}
+
+func (p scheduleSendParams) Validate() error {
+	var errs rules.FieldErrors
+	if p.DelayMinutes <= 0 {
+		errs.Add("delay_minutes", "must be positive")
+	}
+	if p.Body == "" {
+		errs.Add("body", "is required")
+	}
+	addAddressErrors(&errs, "to", p.To)
+	return errs.Err()
+}
+
+// executeScheduleSend zet een vertraagd bericht in de wachtrij, bijv. een follow-up na drie dagen.
+// Zonder ontvangers in de params wordt het een antwoord aan de afzender in dezelfde thread.
+func (gp *GmailProcessor) executeScheduleSend(
+	ctx context.Context,
+	s *messageSubject,
+	rule rules.Rule,
+	params scheduleSendParams,
+) error {
+	message := s.message
+
+	outgoing := domain.GmailOutgoingMessage{
+		To:      params.To,
+		Subject: params.Subject,
+		Body:    params.Body,
+		IsHTML:  params.IsHTML,
+	}
+
+	if len(outgoing.To) == 0 {
+		from := gp.getHeaderValue(message.Payload.Headers, "From")
+		if from == nil {
+			return errors.New("schedule_send action has no recipients and message has no sender")
+		}
+		outgoing.To = []string{*from}
+		outgoing.ThreadID = message.ThreadId
+		outgoing.InReplyTo, outgoing.References = gp.replyReferences(message.Payload.Headers)
+		if outgoing.Subject == "" {
+			outgoing.Subject = gp.replySubject(message.Payload.Headers)
+		}
+	}
+
+	ruleID := rule.ID
+	_, err := gp.store.CreateGmailScheduledSend(ctx, store.CreateGmailScheduledSendParams{
+		ConnectedAccountID: s.acc.ID,
+		RuleID:             &ruleID,
+		Message:            outgoing,
+		SendAt:             time.Now().Add(time.Duration(params.DelayMinutes) * time.Minute),
+	})
+	return err
+}
+
+// SendScheduledMessage verstuurt een bericht uit de wachtrij en geeft het Gmail message ID terug.
+func (gp *GmailProcessor) SendScheduledMessage(
+	ctx context.Context,
+	send domain.GmailScheduledSend,
+	token *oauth2.Token,
+) (string, error) {
+	client := oauth2.NewClient(ctx, oauth2.StaticTokenSource(token))
+	srv, err := gp.newService(ctx, client)
+	if err != nil {
+		return "", fmt.Errorf("could not create Gmail service: %w", err)
+	}
+
+	message, err := email.ComposeGmail(srv, send.Message)
+	if err != nil {
+		return "", fmt.Errorf("could not compose message: %w", err)
+	}
+
+	sent, err := srv.Users.Messages.Send("me", message).Do()
+	if err != nil {
+		return "", err
+	}
+	return sent.Id, nil
+}
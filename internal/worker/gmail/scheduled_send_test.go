@@ -0,0 +1,135 @@
+package gmail
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/gmail/v1"
+)
+
+func TestGmail_SendScheduledMessage_SendsInThread(t *testing.T) {
+	var sent gmail.Message
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.Path == "/gmail/v1/users/me/messages/send" {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+			json.NewEncoder(w).Encode(gmail.Message{Id: "sent-1"})
+			return
+		}
+		t.Errorf("Onverwacht request naar Fake Gmail API: %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	gp, _ := setupSnoozeTest(t, handler)
+
+	send := domain.GmailScheduledSend{Message: domain.GmailOutgoingMessage{
+		To:        []string{"klant@example.com"},
+		Subject:   "Re: Offerte",
+		Body:      "Heeft u de offerte al kunnen bekijken?",
+		ThreadID:  "thread-1",
+		InReplyTo: "<abc@mail.example.com>",
+	}}
+
+	messageID, err := gp.SendScheduledMessage(context.Background(), send, &oauth2.Token{AccessToken: "fake"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "sent-1", messageID)
+	assert.Equal(t, "thread-1", sent.ThreadId)
+
+	raw, err := base64.URLEncoding.DecodeString(sent.Raw)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "To: <klant@example.com>\r\n")
+	assert.Contains(t, string(raw), "In-Reply-To: <abc@mail.example.com>\r\n")
+	assert.Contains(t, string(raw), "Heeft u de offerte al kunnen bekijken?")
+}
+
+func TestGmail_executeScheduleSend_FollowUpToSender(t *testing.T) {
+	mockStore := new(store.MockStore)
+	gp := NewGmailProcessor(mockStore)
+
+	ctx := context.Background()
+	acc := &domain.ConnectedAccount{ID: uuid.New()}
+	ruleID := uuid.New()
+	rule := domain.GmailAutomationRule{
+		BaseAutomationRule: domain.BaseAutomationRule{
+			ActionParams: json.RawMessage(`{"delay_minutes": 4320, "body": "Nog even een herinnering."}`),
+		},
+		ActionType: domain.GmailActionSchedule,
+	}
+	rule.ID = ruleID
+
+	message := &gmail.Message{Id: "msg-1", ThreadId: "thread-1", Payload: &gmail.MessagePart{
+		Headers: []*gmail.MessagePartHeader{
+			{Name: "From", Value: "klant@example.com"},
+			{Name: "Subject", Value: "Offerte"},
+			{Name: "Message-ID", Value: "<abc@mail.example.com>"},
+		},
+	}}
+
+	mockStore.On("CreateGmailScheduledSend", ctx, mock.MatchedBy(func(p store.CreateGmailScheduledSendParams) bool {
+		return p.ConnectedAccountID == acc.ID &&
+			*p.RuleID == ruleID &&
+			p.Message.Subject == "Re: Offerte" &&
+			p.Message.ThreadID == "thread-1" &&
+			p.Message.InReplyTo == "<abc@mail.example.com>" &&
+			assert.ObjectsAreEqual([]string{"klant@example.com"}, p.Message.To)
+	})).Return(domain.GmailScheduledSend{}, nil).Once()
+
+	err := executeRule(ctx, gp, nil, acc, message, rule)
+
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+}
+
+func TestGmail_executeScheduleSend_InvalidDelay(t *testing.T) {
+	gp := newTestProcessor()
+	rule := domain.GmailAutomationRule{
+		BaseAutomationRule: domain.BaseAutomationRule{ActionParams: json.RawMessage(`{"body": "Hoi"}`)},
+		ActionType:         domain.GmailActionSchedule,
+	}
+
+	err := executeRule(context.Background(), gp, nil, &domain.ConnectedAccount{}, &gmail.Message{Id: "msg-1"}, rule)
+
+	assert.Error(t, err)
+}
+
+func TestGmail_SendScheduledMessage_FromName(t *testing.T) {
+	var sent gmail.Message
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/gmail/v1/users/me/profile":
+			json.NewEncoder(w).Encode(gmail.Profile{EmailAddress: "verkoop@example.com"})
+		case r.Method == "POST" && r.URL.Path == "/gmail/v1/users/me/messages/send":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+			json.NewEncoder(w).Encode(gmail.Message{Id: "sent-2"})
+		default:
+			t.Errorf("Onverwacht request naar Fake Gmail API: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	gp, _ := setupSnoozeTest(t, handler)
+
+	send := domain.GmailScheduledSend{Message: domain.GmailOutgoingMessage{
+		FromName: "Team Verkoop",
+		To:       []string{"klant@example.com"},
+		Subject:  "Offerte",
+		Body:     "In de bijlage de offerte.",
+	}}
+
+	_, err := gp.SendScheduledMessage(context.Background(), send, &oauth2.Token{AccessToken: "fake"})
+
+	require.NoError(t, err)
+	raw, err := base64.URLEncoding.DecodeString(sent.Raw)
+	require.NoError(t, err)
+	// De weergavenaam van het ingeplande bericht blijft bij het versturen behouden
+	assert.Contains(t, string(raw), "From: \"Team Verkoop\" <verkoop@example.com>\r\n")
+}
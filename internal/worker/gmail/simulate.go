@@ -0,0 +1,190 @@
+package gmail
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/email"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/unsubscribe"
+	"agenda-automator-api/internal/webhook"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// ActionPreview beschrijft wat een Gmail actie met een bericht zou doen.
+type ActionPreview struct {
+	AddLabels    []string   `json:"add_labels,omitempty"`
+	RemoveLabels []string   `json:"remove_labels,omitempty"`
+	To           []string   `json:"to,omitempty"`
+	Subject      string     `json:"subject,omitempty"`
+	Body         string     `json:"body,omitempty"`
+	Mode         string     `json:"mode,omitempty"`
+	WakeAt       *time.Time `json:"wake_at,omitempty"`
+	SendAt       *time.Time `json:"send_at,omitempty"`
+	Unsubscribe  string     `json:"unsubscribe,omitempty"`
+
+	// Het event dat create_calendar_event zou aanmaken
+	EventTitle    string     `json:"event_title,omitempty"`
+	EventStart    *time.Time `json:"event_start,omitempty"`
+	EventEnd      *time.Time `json:"event_end,omitempty"`
+	EventLocation string     `json:"event_location,omitempty"`
+
+	// De request die webhook zou versturen
+	Webhook *webhook.Preview `json:"webhook,omitempty"`
+}
+
+// SimulationMatch is een opgeslagen bericht waarop een Gmail rule zou afgaan.
+type SimulationMatch struct {
+	GmailMessageID string         `json:"gmail_message_id"`
+	GmailThreadID  string         `json:"gmail_thread_id"`
+	Subject        *string        `json:"subject,omitempty"`
+	Sender         *string        `json:"sender,omitempty"`
+	ReceivedAt     time.Time      `json:"received_at"`
+	Action         *ActionPreview `json:"action,omitempty"`
+	Error          string         `json:"error,omitempty"`
+}
+
+// Simulate evalueert een Gmail rule tegen berichten uit gmail_messages. Er wordt niets naar
+// Gmail gestuurd en niets gelogd. De rule hoeft niet actief te zijn.
+func Simulate(ctx context.Context, rule domain.GmailAutomationRule, messages []domain.GmailMessage) ([]SimulationMatch, error) {
+	matches := []SimulationMatch{}
+	simRule := engineRule(rule)
+	gp := &GmailProcessor{}
+
+	for _, stored := range messages {
+		subject := &messageSubject{gp: gp, message: storedMessage(stored)}
+		sim, err := Rules.Simulate(ctx, simRule, subject)
+		if !sim.Matched {
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		match := SimulationMatch{
+			GmailMessageID: stored.GmailMessageID,
+			GmailThreadID:  stored.GmailThreadID,
+			Subject:        stored.Subject,
+			Sender:         stored.Sender,
+			ReceivedAt:     stored.ReceivedAt,
+		}
+		if err != nil {
+			match.Error = err.Error()
+		} else if preview, ok := sim.Preview.(ActionPreview); ok {
+			match.Action = &preview
+		}
+		matches = append(matches, match)
+	}
+
+	return matches, nil
+}
+
+// storedMessage bouwt een Gmail bericht uit een rij van gmail_messages met de headers en
+// labels waar triggers en previews naar kijken.
+func storedMessage(stored domain.GmailMessage) *gmail.Message {
+	var headers []*gmail.MessagePartHeader
+	addHeader := func(name string, value *string) {
+		if value != nil {
+			headers = append(headers, &gmail.MessagePartHeader{Name: name, Value: *value})
+		}
+	}
+	addHeader("From", stored.Sender)
+	addHeader("Subject", stored.Subject)
+	if len(stored.Recipients) > 0 {
+		to := strings.Join(stored.Recipients, ", ")
+		addHeader("To", &to)
+	}
+
+	var listUnsubscribe []string
+	for _, target := range []*string{stored.UnsubscribeMailto, stored.UnsubscribeURL} {
+		if target != nil {
+			listUnsubscribe = append(listUnsubscribe, "<"+*target+">")
+		}
+	}
+	if len(listUnsubscribe) > 0 {
+		value := strings.Join(listUnsubscribe, ", ")
+		addHeader("List-Unsubscribe", &value)
+	}
+	if stored.UnsubscribeOneClick {
+		value := "List-Unsubscribe=One-Click"
+		addHeader("List-Unsubscribe-Post", &value)
+	}
+
+	message := &gmail.Message{
+		Id:       stored.GmailMessageID,
+		ThreadId: stored.GmailThreadID,
+		LabelIds: stored.Labels,
+		Payload:  &gmail.MessagePart{Headers: headers},
+	}
+	if stored.Snippet != nil {
+		message.Snippet = *stored.Snippet
+	}
+	return message
+}
+
+// Previews van de acties; ze rekenen alleen en roepen geen Gmail API aan.
+
+func previewAutoReply(s *messageSubject, params autoReplyParams) (ActionPreview, error) {
+	preview := ActionPreview{Body: params.ReplyText, Subject: s.gp.replySubject(s.message.Payload.Headers)}
+	if to := s.gp.replyAddress(s.message.Payload.Headers); to != nil {
+		preview.To = []string{*to}
+	}
+	return preview, nil
+}
+
+func previewForward(_ *messageSubject, params forwardParams) (ActionPreview, error) {
+	mode, err := email.ParseForwardMode(params.Mode)
+	if err != nil {
+		return ActionPreview{}, err
+	}
+	return ActionPreview{To: params.To, Mode: string(mode), Body: params.Note}, nil
+}
+
+func previewAddLabel(_ *messageSubject, params addLabelParams) (ActionPreview, error) {
+	preview := ActionPreview{AddLabels: []string{params.LabelName}}
+	if params.Archive {
+		preview.RemoveLabels = []string{"INBOX"}
+	}
+	return preview, nil
+}
+
+func previewRemoveLabel(_ *messageSubject, params labelParams) (ActionPreview, error) {
+	return ActionPreview{RemoveLabels: []string{params.LabelName}}, nil
+}
+
+func previewSnooze(_ *messageSubject, params snoozeParams) (ActionPreview, error) {
+	wakeAt := time.Now().Add(time.Duration(params.DurationMinutes) * time.Minute)
+	return ActionPreview{
+		AddLabels:    []string{domain.GmailSnoozedLabel},
+		RemoveLabels: []string{"INBOX"},
+		WakeAt:       &wakeAt,
+	}, nil
+}
+
+func previewScheduleSend(s *messageSubject, params scheduleSendParams) (ActionPreview, error) {
+	sendAt := time.Now().Add(time.Duration(params.DelayMinutes) * time.Minute)
+	preview := ActionPreview{To: params.To, Subject: params.Subject, Body: params.Body, SendAt: &sendAt}
+	if len(preview.To) == 0 {
+		if from := s.gp.getHeaderValue(s.message.Payload.Headers, "From"); from != nil {
+			preview.To = []string{*from}
+		}
+		if preview.Subject == "" {
+			preview.Subject = s.gp.replySubject(s.message.Payload.Headers)
+		}
+	}
+	return preview, nil
+}
+
+func previewUnsubscribe(s *messageSubject, _ rules.NoParams) (ActionPreview, error) {
+	target := s.gp.parseUnsubscribeTarget(s.message)
+	switch {
+	case target.OneClick:
+		return ActionPreview{Unsubscribe: string(unsubscribe.MethodOneClick), To: []string{target.HTTPURL}}, nil
+	case target.Mailto != "":
+		return ActionPreview{Unsubscribe: string(unsubscribe.MethodMailto), To: []string{target.Mailto}}, nil
+	}
+	return ActionPreview{}, unsubscribe.ErrNoUnsubscribe
+}
@@ -0,0 +1,139 @@
+package gmail
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/unsubscribe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func storedTestMessage(id, sender, subject string, labels ...string) domain.GmailMessage {
+	return domain.GmailMessage{
+		GmailMessageID: id,
+		GmailThreadID:  "thread-" + id,
+		Sender:         &sender,
+		Subject:        &subject,
+		Labels:         labels,
+	}
+}
+
+func simulateRule(triggerType domain.GmailRuleTriggerType, conditions string, actionType domain.GmailRuleActionType, params string) domain.GmailAutomationRule {
+	return domain.GmailAutomationRule{
+		BaseAutomationRule: domain.BaseAutomationRule{
+			TriggerConditions: json.RawMessage(conditions),
+			ActionParams:      json.RawMessage(params),
+		},
+		TriggerType: triggerType,
+		ActionType:  actionType,
+	}
+}
+
+func TestSimulate_LabelChanges(t *testing.T) {
+	messages := []domain.GmailMessage{
+		storedTestMessage("msg-1", "Nieuwsbrief <news@example.com>", "Weekoverzicht", "INBOX", "UNREAD"),
+		storedTestMessage("msg-2", "Collega <jan@example.org>", "Rooster", "INBOX"),
+	}
+	rule := simulateRule(domain.GmailTriggerSenderMatch, `{"sender_pattern": "news@"}`, domain.GmailActionArchive, `{}`)
+
+	matches, err := Simulate(context.Background(), rule, messages)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "msg-1", matches[0].GmailMessageID)
+	assert.Equal(t, &ActionPreview{RemoveLabels: []string{"INBOX"}}, matches[0].Action)
+}
+
+func TestSimulate_AddLabelAndArchive(t *testing.T) {
+	messages := []domain.GmailMessage{
+		storedTestMessage("msg-1", "Nieuwsbrief <news@example.com>", "Weekoverzicht", "INBOX"),
+	}
+	rule := simulateRule(domain.GmailTriggerSenderMatch, `{"sender_pattern": "news@"}`,
+		domain.GmailActionAddLabel, `{"label_name": "Nieuwsbrieven", "archive": true}`)
+
+	matches, err := Simulate(context.Background(), rule, messages)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, &ActionPreview{AddLabels: []string{"Nieuwsbrieven"}, RemoveLabels: []string{"INBOX"}}, matches[0].Action)
+}
+
+func TestSimulate_StarredAndSnooze(t *testing.T) {
+	messages := []domain.GmailMessage{
+		storedTestMessage("msg-1", "a@example.com", "Offerte", "INBOX", "STARRED"),
+		storedTestMessage("msg-2", "b@example.com", "Offerte", "INBOX"),
+	}
+	rule := simulateRule(domain.GmailTriggerStarred, `{}`, domain.GmailActionSnooze, `{"duration_minutes": 60}`)
+
+	matches, err := Simulate(context.Background(), rule, messages)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	action := matches[0].Action
+	require.NotNil(t, action)
+	assert.Equal(t, []string{domain.GmailSnoozedLabel}, action.AddLabels)
+	require.NotNil(t, action.WakeAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *action.WakeAt, time.Minute)
+}
+
+func TestSimulate_AutoReplyAndUnsubscribe(t *testing.T) {
+	newsletter := storedTestMessage("msg-1", "news@example.com", "Aanbieding")
+	mailto := "mailto:leave@example.com"
+	newsletter.UnsubscribeMailto = &mailto
+	plain := storedTestMessage("msg-2", "news@example.com", "Bevestiging")
+
+	matches, err := Simulate(context.Background(),
+		simulateRule(domain.GmailTriggerNewMessage, ``, domain.GmailActionUnsubscribe, ``),
+		[]domain.GmailMessage{newsletter, plain})
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	assert.Equal(t, &ActionPreview{Unsubscribe: string(unsubscribe.MethodMailto), To: []string{mailto}}, matches[0].Action)
+	assert.Nil(t, matches[1].Action)
+	assert.Equal(t, unsubscribe.ErrNoUnsubscribe.Error(), matches[1].Error)
+
+	matches, err = Simulate(context.Background(),
+		simulateRule(domain.GmailTriggerSubjectMatch, `{"subject_pattern": "aanbieding"}`, domain.GmailActionAutoReply, `{"reply_text": "Nee, dank je"}`),
+		[]domain.GmailMessage{newsletter, plain})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, &ActionPreview{To: []string{"news@example.com"}, Subject: "Re: Aanbieding", Body: "Nee, dank je"}, matches[0].Action)
+}
+
+func TestSimulate_InvalidRule(t *testing.T) {
+	rule := simulateRule(domain.GmailTriggerSenderMatch, `{}`, domain.GmailActionArchive, `{}`)
+	_, err := Simulate(context.Background(), rule, []domain.GmailMessage{storedTestMessage("msg-1", "a@example.com", "Hoi")})
+	assert.Error(t, err)
+}
+
+func TestSimulate_CreateCalendarEventFromSnippet(t *testing.T) {
+	message := storedTestMessage("msg-1", "boekingen@example.com", "Boeking bevestigd", "INBOX")
+	snippet := "Uw afspraak staat op 2025-11-30 09:30 in Utrecht."
+	message.Snippet = &snippet
+	rule := simulateRule(domain.GmailTriggerSenderMatch, `{"sender_pattern": "boekingen@"}`,
+		domain.GmailActionCreateEvent, `{"pattern": "op (?P<start>\\S+ \\S+) in (?P<location>[^.]+)", "title": "Afspraak"}`)
+
+	matches, err := Simulate(context.Background(), rule, []domain.GmailMessage{message})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	require.NotNil(t, matches[0].Action)
+	assert.Equal(t, "Afspraak", matches[0].Action.EventTitle)
+	assert.Equal(t, "Utrecht", matches[0].Action.EventLocation)
+	assert.Equal(t, time.Date(2025, 11, 30, 9, 30, 0, 0, time.UTC), *matches[0].Action.EventStart)
+}
+
+func TestSimulate_WebhookPreview(t *testing.T) {
+	rule := simulateRule(domain.GmailTriggerSenderMatch, `{"sender_pattern": "boekingen@"}`,
+		domain.GmailActionWebhook, `{"url": "https://example.com/hook"}`)
+
+	matches, err := Simulate(context.Background(), rule, []domain.GmailMessage{storedTestMessage("msg-1", "boekingen@example.com", "Boeking", "INBOX")})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	require.NotNil(t, matches[0].Action)
+	require.NotNil(t, matches[0].Action.Webhook)
+	assert.Equal(t, "https://example.com/hook", matches[0].Action.Webhook.URL)
+	assert.Equal(t, domain.RuleTypeGmail, matches[0].Action.Webhook.Payload.RuleType)
+	assert.Equal(t, messagePayload{ID: "msg-1", ThreadID: "thread-msg-1", From: "boekingen@example.com", Subject: "Boeking",
+		LabelIDs: []string{"INBOX"}}, matches[0].Action.Webhook.Payload.Message)
+}
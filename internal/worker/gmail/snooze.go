@@ -0,0 +1,79 @@
+// Package gmail handles Gmail-related background tasks.
+package gmail
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/store"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/gmail/v1"
+)
+
+type snoozeParams struct {
+	DurationMinutes int `json:"duration_minutes"`
+}
+
+func (p snoozeParams) Validate() error {
+	var errs rules.FieldErrors
+	if p.DurationMinutes <= 0 {
+		errs.Add("duration_minutes", "must be positive")
+	}
+	return errs.Err()
+}
+
+// executeSnooze haalt een bericht uit de inbox tot de ingestelde tijd verstreken is.
+func (gp *GmailProcessor) executeSnooze(
+	ctx context.Context,
+	s *messageSubject,
+	_ rules.Rule,
+	params snoozeParams,
+) error {
+	srv, message := s.srv, s.message
+
+	label, err := gp.getOrCreateLabel(srv, domain.GmailSnoozedLabel)
+	if err != nil {
+		return err
+	}
+
+	modifyRequest := &gmail.ModifyMessageRequest{
+		AddLabelIds:    []string{label.Id},
+		RemoveLabelIds: []string{"INBOX"},
+	}
+	if _, err = srv.Users.Messages.Modify("me", message.Id, modifyRequest).Do(); err != nil {
+		return err
+	}
+
+	_, err = gp.store.CreateGmailSnooze(ctx, store.CreateGmailSnoozeParams{
+		ConnectedAccountID: s.acc.ID,
+		GmailMessageID:     message.Id,
+		GmailThreadID:      message.ThreadId,
+		WakeAt:             time.Now().Add(time.Duration(params.DurationMinutes) * time.Minute),
+	})
+	return err
+}
+
+// WakeSnooze zet een gesnoozed bericht terug in de inbox en markeert het als ongelezen.
+func (gp *GmailProcessor) WakeSnooze(ctx context.Context, snooze domain.GmailSnooze, token *oauth2.Token) error {
+	client := oauth2.NewClient(ctx, oauth2.StaticTokenSource(token))
+	srv, err := gp.newService(ctx, client)
+	if err != nil {
+		return fmt.Errorf("could not create Gmail service: %w", err)
+	}
+
+	modifyRequest := &gmail.ModifyMessageRequest{
+		AddLabelIds: []string{"INBOX", "UNREAD"},
+	}
+
+	// Het label kan inmiddels door de gebruiker verwijderd zijn; dan is er niets weg te halen
+	if label, labelErr := gp.getLabelByName(srv, domain.GmailSnoozedLabel); labelErr == nil {
+		modifyRequest.RemoveLabelIds = []string{label.Id}
+	}
+
+	_, err = srv.Users.Messages.Modify("me", snooze.GmailMessageID, modifyRequest).Do()
+	return err
+}
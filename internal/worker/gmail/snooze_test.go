@@ -0,0 +1,112 @@
+package gmail
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+)
+
+// setupSnoozeTest maakt een processor die tegen een fake Gmail API praat.
+func setupSnoozeTest(t *testing.T, handler http.Handler) (*GmailProcessor, *store.MockStore) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	mockStore := new(store.MockStore)
+	gp := NewGmailProcessor(mockStore)
+	gp.newService = func(ctx context.Context, client *http.Client) (*gmail.Service, error) {
+		return gmail.NewService(ctx, option.WithHTTPClient(client), option.WithEndpoint(server.URL))
+	}
+	return gp, mockStore
+}
+
+func TestGmail_WakeSnooze_RestoresToInbox(t *testing.T) {
+	var modify gmail.ModifyMessageRequest
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/gmail/v1/users/me/labels":
+			json.NewEncoder(w).Encode(gmail.ListLabelsResponse{Labels: []*gmail.Label{
+				{Id: "Label_1", Name: "Work"},
+				{Id: "Label_2", Name: domain.GmailSnoozedLabel},
+			}})
+		case r.Method == "POST" && r.URL.Path == "/gmail/v1/users/me/messages/msg-1/modify":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&modify))
+			json.NewEncoder(w).Encode(gmail.Message{Id: "msg-1"})
+		default:
+			t.Errorf("Onverwacht request naar Fake Gmail API: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	gp, _ := setupSnoozeTest(t, handler)
+
+	err := gp.WakeSnooze(context.Background(), domain.GmailSnooze{GmailMessageID: "msg-1"}, &oauth2.Token{AccessToken: "fake"})
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"INBOX", "UNREAD"}, modify.AddLabelIds)
+	assert.Equal(t, []string{"Label_2"}, modify.RemoveLabelIds)
+}
+
+func TestGmail_executeSnooze_StoresWakeTime(t *testing.T) {
+	var modify gmail.ModifyMessageRequest
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/gmail/v1/users/me/labels":
+			json.NewEncoder(w).Encode(gmail.ListLabelsResponse{})
+		case r.Method == "POST" && r.URL.Path == "/gmail/v1/users/me/labels":
+			json.NewEncoder(w).Encode(gmail.Label{Id: "Label_9", Name: domain.GmailSnoozedLabel})
+		case r.Method == "POST" && r.URL.Path == "/gmail/v1/users/me/messages/msg-1/modify":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&modify))
+			json.NewEncoder(w).Encode(gmail.Message{Id: "msg-1"})
+		default:
+			t.Errorf("Onverwacht request naar Fake Gmail API: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	gp, mockStore := setupSnoozeTest(t, handler)
+
+	ctx := context.Background()
+	srv, err := gp.newService(ctx, http.DefaultClient)
+	require.NoError(t, err)
+
+	acc := &domain.ConnectedAccount{ID: uuid.New()}
+	rule := domain.GmailAutomationRule{
+		BaseAutomationRule: domain.BaseAutomationRule{ActionParams: json.RawMessage(`{"duration_minutes": 60}`)},
+		ActionType:         domain.GmailActionSnooze,
+	}
+
+	mockStore.On("CreateGmailSnooze", ctx, mock.MatchedBy(func(p store.CreateGmailSnoozeParams) bool {
+		return p.ConnectedAccountID == acc.ID && p.GmailMessageID == "msg-1" && p.GmailThreadID == "thread-1"
+	})).Return(domain.GmailSnooze{}, nil).Once()
+
+	err = executeRule(ctx, gp, srv, acc, &gmail.Message{Id: "msg-1", ThreadId: "thread-1"}, rule)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Label_9"}, modify.AddLabelIds)
+	assert.Equal(t, []string{"INBOX"}, modify.RemoveLabelIds)
+	mockStore.AssertExpectations(t)
+}
+
+func TestGmail_executeSnooze_InvalidDuration(t *testing.T) {
+	gp := newTestProcessor()
+	rule := domain.GmailAutomationRule{
+		BaseAutomationRule: domain.BaseAutomationRule{ActionParams: json.RawMessage(`{}`)},
+		ActionType:         domain.GmailActionSnooze,
+	}
+
+	err := executeRule(context.Background(), gp, nil, &domain.ConnectedAccount{}, &gmail.Message{Id: "msg-1"}, rule)
+
+	assert.Error(t, err)
+}
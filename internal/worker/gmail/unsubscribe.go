@@ -0,0 +1,64 @@
+package gmail
+
+import (
+	"context"
+	"fmt"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/store"
+	"agenda-automator-api/internal/unsubscribe"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// parseUnsubscribeTarget leest de List-Unsubscribe headers van een bericht.
+func (gp *GmailProcessor) parseUnsubscribeTarget(message *gmail.Message) unsubscribe.Target {
+	if message.Payload == nil {
+		return unsubscribe.Target{}
+	}
+
+	var listUnsubscribe, listUnsubscribePost string
+	if value := gp.getHeaderValue(message.Payload.Headers, "List-Unsubscribe"); value != nil {
+		listUnsubscribe = *value
+	}
+	if value := gp.getHeaderValue(message.Payload.Headers, "List-Unsubscribe-Post"); value != nil {
+		listUnsubscribePost = *value
+	}
+	return unsubscribe.Parse(listUnsubscribe, listUnsubscribePost)
+}
+
+// executeUnsubscribe schrijft de gebruiker uit van de mailinglijst van het bericht en legt het resultaat vast.
+func (gp *GmailProcessor) executeUnsubscribe(
+	ctx context.Context,
+	s *messageSubject,
+	_ rules.Rule,
+	_ rules.NoParams,
+) error {
+	srv, acc, message := s.srv, s.acc, s.message
+
+	target := gp.parseUnsubscribeTarget(message)
+	if !target.CanUnsubscribe() {
+		return unsubscribe.ErrNoUnsubscribe
+	}
+
+	method, used, unsubscribeErr := gp.unsubscriber.Unsubscribe(ctx, srv, target)
+
+	params := store.CreateGmailUnsubscribeParams{
+		ConnectedAccountID: acc.ID,
+		GmailMessageID:     message.Id,
+		Sender:             gp.getHeaderValue(message.Payload.Headers, "From"),
+		Method:             string(method),
+		Target:             used,
+		Status:             domain.GmailUnsubscribeSuccess,
+	}
+	if unsubscribeErr != nil {
+		params.Status = domain.GmailUnsubscribeFailed
+		params.ErrorMessage = stringPtr(unsubscribeErr.Error())
+	}
+
+	if _, err := gp.store.CreateGmailUnsubscribe(ctx, params); err != nil {
+		return fmt.Errorf("could not record unsubscribe: %w", err)
+	}
+	return unsubscribeErr
+}
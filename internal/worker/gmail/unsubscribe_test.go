@@ -0,0 +1,79 @@
+package gmail
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+	"agenda-automator-api/internal/unsubscribe"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/gmail/v1"
+)
+
+func newsletterMessage(headers ...*gmail.MessagePartHeader) *gmail.Message {
+	headers = append(headers, &gmail.MessagePartHeader{Name: "From", Value: "News <news@example.com>"})
+	return &gmail.Message{Id: "msg-1", Payload: &gmail.MessagePart{Headers: headers}}
+}
+
+func TestGmail_parseUnsubscribeTarget(t *testing.T) {
+	gp := newTestProcessor()
+	message := newsletterMessage(
+		&gmail.MessagePartHeader{Name: "List-Unsubscribe", Value: "<https://example.com/u/1>, <mailto:leave@example.com>"},
+		&gmail.MessagePartHeader{Name: "List-Unsubscribe-Post", Value: "List-Unsubscribe=One-Click"},
+	)
+
+	target := gp.parseUnsubscribeTarget(message)
+
+	assert.Equal(t, "https://example.com/u/1", target.HTTPURL)
+	assert.Equal(t, "mailto:leave@example.com", target.Mailto)
+	assert.True(t, target.OneClick)
+}
+
+func TestGmail_executeUnsubscribe_MailtoRecordsSuccess(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.Path == "/gmail/v1/users/me/messages/send" {
+			json.NewEncoder(w).Encode(gmail.Message{Id: "sent-1"})
+			return
+		}
+		t.Errorf("Onverwacht request naar Fake Gmail API: %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	gp, mockStore := setupSnoozeTest(t, handler)
+
+	ctx := context.Background()
+	srv, err := gp.newService(ctx, http.DefaultClient)
+	require.NoError(t, err)
+
+	acc := &domain.ConnectedAccount{ID: uuid.New()}
+	rule := domain.GmailAutomationRule{ActionType: domain.GmailActionUnsubscribe}
+	message := newsletterMessage(&gmail.MessagePartHeader{Name: "List-Unsubscribe", Value: "<mailto:leave@example.com>"})
+
+	mockStore.On("CreateGmailUnsubscribe", ctx, mock.MatchedBy(func(p store.CreateGmailUnsubscribeParams) bool {
+		return p.ConnectedAccountID == acc.ID &&
+			p.Method == string(unsubscribe.MethodMailto) &&
+			p.Target == "mailto:leave@example.com" &&
+			p.Status == domain.GmailUnsubscribeSuccess &&
+			*p.Sender == "News <news@example.com>"
+	})).Return(domain.GmailUnsubscribe{}, nil).Once()
+
+	err = executeRule(ctx, gp, srv, acc, message, rule)
+
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+}
+
+func TestGmail_executeUnsubscribe_NoHeader(t *testing.T) {
+	gp := newTestProcessor()
+	rule := domain.GmailAutomationRule{ActionType: domain.GmailActionUnsubscribe}
+
+	err := executeRule(context.Background(), gp, nil, &domain.ConnectedAccount{}, newsletterMessage(), rule)
+
+	assert.ErrorIs(t, err, unsubscribe.ErrNoUnsubscribe)
+}
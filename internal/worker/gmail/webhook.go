@@ -0,0 +1,57 @@
+package gmail
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/webhook"
+)
+
+// messagePayload is de metadata van een bericht in een webhook payload; de inhoud gaat niet mee.
+type messagePayload struct {
+	ID       string   `json:"id"`
+	ThreadID string   `json:"thread_id"`
+	From     string   `json:"from,omitempty"`
+	To       string   `json:"to,omitempty"`
+	Subject  string   `json:"subject,omitempty"`
+	Date     string   `json:"date,omitempty"`
+	Snippet  string   `json:"snippet,omitempty"`
+	LabelIDs []string `json:"label_ids,omitempty"`
+}
+
+func webhookPayload(s *messageSubject, rule rules.Rule) webhook.Payload {
+	message := messagePayload{
+		ID:       s.message.Id,
+		ThreadID: s.message.ThreadId,
+		Snippet:  s.message.Snippet,
+		LabelIDs: s.message.LabelIds,
+	}
+	if s.message.Payload != nil {
+		header := func(name string) string {
+			if value := s.gp.getHeaderValue(s.message.Payload.Headers, name); value != nil {
+				return strings.TrimSpace(*value)
+			}
+			return ""
+		}
+		message.From, message.To, message.Subject, message.Date = header("From"), header("To"), header("Subject"), header("Date")
+	}
+	if message.Date == "" && s.message.InternalDate > 0 {
+		message.Date = time.UnixMilli(s.message.InternalDate).UTC().Format(time.RFC3339)
+	}
+
+	p := webhook.NewPayload(rule, domain.RuleTypeGmail)
+	p.Message = message
+	return p
+}
+
+func previewWebhook(_ context.Context, s *messageSubject, rule rules.Rule, params webhook.Params) (any, error) {
+	return ActionPreview{Webhook: &webhook.Preview{URL: params.URL, Payload: webhookPayload(s, rule)}}, nil
+}
+
+// sendWebhook stuurt het bericht naar de URL van de gebruiker, ondertekend met zijn webhook geheim.
+func sendWebhook(ctx context.Context, s *messageSubject, rule rules.Rule, params webhook.Params) (rules.Result, error) {
+	return webhook.Execute(ctx, s.gp.sendWebhook, s.gp.store, s.acc.UserID, params, webhookPayload(s, rule))
+}
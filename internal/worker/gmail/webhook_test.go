@@ -0,0 +1,72 @@
+package gmail
+
+import (
+	"context"
+	"testing"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/store"
+	"agenda-automator-api/internal/webhook"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/gmail/v1"
+)
+
+func TestSendWebhook(t *testing.T) {
+	mockStore := new(store.MockStore)
+	processor := NewGmailProcessor(mockStore)
+	acc := &domain.ConnectedAccount{ID: uuid.New(), UserID: uuid.New()}
+	rule := rules.Rule{ID: uuid.New(), AccountID: acc.ID, Name: "Facturen naar boekhouding"}
+
+	var sentSecret string
+	var sent webhook.Payload
+	processor.sendWebhook = func(_ context.Context, _ string, secret string, payload any) (webhook.Delivery, error) {
+		sentSecret, sent = secret, payload.(webhook.Payload)
+		return webhook.Delivery{DeliveryID: "delivery-1", StatusCode: 200, Attempts: 1}, nil
+	}
+	mockStore.On("EnsureWebhookSecret", context.Background(), acc.UserID, mock.AnythingOfType("string")).
+		Return(domain.WebhookSecret{UserID: acc.UserID, Secret: "whsec_test"}, nil).Once()
+
+	message := &gmail.Message{
+		Id:           "msg-1",
+		ThreadId:     "thread-1",
+		Snippet:      "Factuur 2025-11",
+		LabelIds:     []string{"INBOX", "UNREAD"},
+		InternalDate: 1764495000000,
+		Payload: &gmail.MessagePart{Headers: []*gmail.MessagePartHeader{
+			{Name: "From", Value: "facturen@example.com"},
+			{Name: "To", Value: "ik@bedrijf.nl"},
+			{Name: "Subject", Value: "Factuur"},
+		}},
+	}
+
+	result, err := sendWebhook(context.Background(), &messageSubject{gp: processor, acc: acc, message: message}, rule,
+		webhook.Params{URL: "https://example.com/hook"})
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.WebhookActionLogDetails{
+		ActionType: "webhook",
+		URL:        "https://example.com/hook",
+		DeliveryID: "delivery-1",
+		StatusCode: 200,
+		Attempts:   1,
+	}, result.Details)
+	assert.Equal(t, "whsec_test", sentSecret)
+	assert.Equal(t, domain.RuleTypeGmail, sent.RuleType)
+	assert.Equal(t, "Facturen naar boekhouding", sent.RuleName)
+	assert.Equal(t, messagePayload{
+		ID:       "msg-1",
+		ThreadID: "thread-1",
+		From:     "facturen@example.com",
+		To:       "ik@bedrijf.nl",
+		Subject:  "Factuur",
+		Date:     "2025-11-30T09:30:00Z",
+		Snippet:  "Factuur 2025-11",
+		LabelIDs: []string{"INBOX", "UNREAD"},
+	}, sent.Message)
+	mockStore.AssertExpectations(t)
+}
@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// scheduledSendBatchSize is het maximale aantal berichten dat per run wordt verstuurd.
+	scheduledSendBatchSize = 50
+	// maxScheduledSendAttempts is het aantal pogingen voordat een bericht als mislukt geldt.
+	maxScheduledSendAttempts = 5
+)
+
+// sendScheduledMessages verstuurt berichten uit de wachtrij waarvan het verzendtijdstip verstreken is.
+func (w *Worker) sendScheduledMessages(ctx context.Context) error {
+	sends, err := w.store.GetDueGmailScheduledSends(ctx, time.Now(), scheduledSendBatchSize)
+	if err != nil {
+		return fmt.Errorf("could not get due scheduled sends: %w", err)
+	}
+
+	for _, send := range sends {
+		token, err := w.store.GetValidTokenForAccount(ctx, send.ConnectedAccountID)
+		var messageID string
+		if err == nil {
+			messageID, err = w.gmailProcessor.SendScheduledMessage(ctx, send, token)
+		}
+
+		if err == nil {
+			err = w.store.UpdateGmailScheduledSendStatus(ctx, send.ID, domain.GmailScheduledSendSent, &messageID, nil)
+		} else if isPermanentGmailError(err) || send.Attempts+1 >= maxScheduledSendAttempts {
+			msg := err.Error()
+			err = w.store.UpdateGmailScheduledSendStatus(ctx, send.ID, domain.GmailScheduledSendFailed, nil, &msg)
+		} else {
+			// Tijdelijke fout: opnieuw proberen met exponential backoff (1, 2, 4, 8 minuten)
+			w.logger.Warn(
+				"failed to send scheduled message, retrying",
+				zap.Error(err),
+				zap.String("scheduled_send_id", send.ID.String()),
+				zap.Int("attempt", send.Attempts+1),
+				zap.String("component", "worker"),
+			)
+			nextAttempt := time.Now().Add(time.Duration(1<<send.Attempts) * time.Minute)
+			err = w.store.RetryGmailScheduledSend(ctx, send.ID, err.Error(), nextAttempt)
+		}
+
+		if err != nil {
+			w.logger.Error(
+				"failed to update scheduled send",
+				zap.Error(err),
+				zap.String("scheduled_send_id", send.ID.String()),
+				zap.String("component", "worker"),
+			)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/store"
+
+	"go.uber.org/zap"
+	"google.golang.org/api/googleapi"
+)
+
+// snoozeBatchSize is het maximale aantal snoozes dat per run wordt gewekt.
+const snoozeBatchSize = 50
+
+// wakeSnoozedMessages zet berichten waarvan de snooze verlopen is terug in de inbox.
+func (w *Worker) wakeSnoozedMessages(ctx context.Context) error {
+	snoozes, err := w.store.GetDueGmailSnoozes(ctx, time.Now(), snoozeBatchSize)
+	if err != nil {
+		return fmt.Errorf("could not get due snoozes: %w", err)
+	}
+
+	for _, snooze := range snoozes {
+		token, err := w.store.GetValidTokenForAccount(ctx, snooze.ConnectedAccountID)
+		if err == nil {
+			err = w.gmailProcessor.WakeSnooze(ctx, snooze, token)
+		}
+
+		status := domain.GmailSnoozeWoken
+		var errorMessage *string
+		if err != nil {
+			if !isPermanentGmailError(err) {
+				// Tijdelijke fout: de volgende run probeert het opnieuw
+				w.logger.Warn(
+					"failed to wake snoozed message, retrying next run",
+					zap.Error(err),
+					zap.String("snooze_id", snooze.ID.String()),
+					zap.String("component", "worker"),
+				)
+				continue
+			}
+			status = domain.GmailSnoozeFailed
+			msg := err.Error()
+			errorMessage = &msg
+		}
+
+		if err := w.store.UpdateGmailSnoozeStatus(ctx, snooze.ID, status, errorMessage); err != nil {
+			w.logger.Error(
+				"failed to update snooze status",
+				zap.Error(err),
+				zap.String("snooze_id", snooze.ID.String()),
+				zap.String("component", "worker"),
+			)
+		}
+	}
+
+	return nil
+}
+
+// isPermanentGmailError geeft aan of opnieuw proberen zinloos is, bijv. omdat
+// het bericht verwijderd is of het account geen toegang meer geeft.
+func isPermanentGmailError(err error) bool {
+	if errors.Is(err, store.ErrTokenRevoked) {
+		return true
+	}
+
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusNotFound || apiErr.Code == http.StatusBadRequest
+	}
+	return false
+}
@@ -0,0 +1,2 @@
+// Package hook runs the calendar rules bound to an inbound hook, with the JSON body of a delivery as template values.
+package hook
@@ -0,0 +1,142 @@
+package hook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/store"
+	"agenda-automator-api/internal/webhook"
+)
+
+// OutcomeNotDue is de uitkomst van een gebonden rule die buiten zijn schedule valt of net verlopen is.
+const OutcomeNotDue rules.Outcome = "not_due"
+
+// deliverySubject is een levering aan een hook: het account waarop een gebonden rule draait en de
+// template variabelen uit de body.
+type deliverySubject struct {
+	hp       *HookProcessor
+	acc      *domain.ConnectedAccount
+	delivery domain.HookDelivery
+	values   map[string]string
+}
+
+// HookProcessor draait de rules die aan een inbound hook gebonden zijn.
+type HookProcessor struct {
+	store              store.Storer
+	logger             *zap.Logger
+	engine             *rules.Engine[*deliverySubject]
+	newCalendarService func(ctx context.Context, client *http.Client) (*calendar.Service, error)
+	newGmailService    func(ctx context.Context, client *http.Client) (*gmail.Service, error)
+}
+
+// NewHookProcessor maakt een HookProcessor. Gebonden rules zijn calendar rules, dus ze delen de
+// logs, limieten en uitvoeringstellers van de calendar rules.
+func NewHookProcessor(s store.Storer, logger *zap.Logger) *HookProcessor {
+	return &HookProcessor{
+		store:  s,
+		logger: logger,
+		engine: rules.NewEngine(Rules, rules.Config[*deliverySubject]{
+			Name:     "Hook",
+			RuleType: domain.RuleTypeCalendar,
+			Logs:     s,
+			Describe: describeDelivery,
+			RecordExecution: func(ctx context.Context, rule rules.Rule) (int, error) {
+				return s.RecordRuleExecution(ctx, rule.ID)
+			},
+			Expire: func(ctx context.Context, rule rules.Rule) error {
+				_, err := s.ExpireRule(ctx, rule.ID)
+				return err
+			},
+			Limits: rules.LimitsFromEnv(),
+			ExecutionCounts: func(ctx context.Context, rule rules.Rule) (int, int, error) {
+				return s.GetRuleExecutionCounts(ctx, rule.ID, rule.AccountID)
+			},
+			CountExecution: func(ctx context.Context, rule rules.Rule) (int, error) {
+				_, accountDay, err := s.CountRuleExecution(ctx, domain.RuleTypeCalendar, rule.ID, rule.AccountID)
+				return accountDay, err
+			},
+			BusiestRule: func(ctx context.Context, accountID uuid.UUID) (domain.RuleType, uuid.UUID, error) {
+				return s.GetBusiestRule(ctx, accountID)
+			},
+			Pause:         rules.PauseWith(s),
+			Notifications: s,
+		}),
+		newCalendarService: func(ctx context.Context, client *http.Client) (*calendar.Service, error) {
+			return calendar.NewService(ctx, option.WithHTTPClient(client))
+		},
+		newGmailService: func(ctx context.Context, client *http.Client) (*gmail.Service, error) {
+			return gmail.NewService(ctx, option.WithHTTPClient(client))
+		},
+	}
+}
+
+// Run draait een gebonden rule voor een levering op één account, via de rules engine: een rule
+// buiten zijn schedule draait niet, en de uitkomst komt in de automation log van het account.
+// Een user-level rule krijgt het account als ConnectedAccountID, net als bij de calendar sync.
+func (hp *HookProcessor) Run(
+	ctx context.Context,
+	acc *domain.ConnectedAccount,
+	rule domain.AutomationRule,
+	delivery domain.HookDelivery,
+) rules.Outcome {
+	values, err := webhook.TemplateValues(delivery.Payload)
+	if err != nil {
+		// De API neemt alleen JSON objecten aan; dit is een levering van voor die controle
+		hp.logger.Warn(
+			"hook delivery has an invalid body",
+			zap.Error(err),
+			zap.Int64("delivery_id", delivery.ID),
+			zap.String("component", "hook"),
+		)
+		return rules.OutcomeInvalid
+	}
+
+	rule.ConnectedAccountID = acc.ID
+	r := engineRule(rule)
+	if !hp.engine.Due(ctx, r, time.Now()) {
+		return OutcomeNotDue
+	}
+
+	subject := &deliverySubject{hp: hp, acc: acc, delivery: delivery, values: values}
+	return hp.engine.Run(ctx, &r, subject)
+}
+
+// engineRule zet een gebonden rule om naar de vorm van de rules engine.
+func engineRule(rule domain.AutomationRule) rules.Rule {
+	return rules.Rule{
+		ID:            rule.ID,
+		AccountID:     rule.ConnectedAccountID,
+		Name:          rule.Name,
+		Version:       rule.Version,
+		TriggerType:   rule.Trigger(),
+		TriggerParams: rule.TriggerConditions,
+		ActionType:    rule.Action(),
+		ActionParams:  rule.ActionParams,
+		Schedule:      rule.Schedule,
+		Executions:    rule.ExecutionCount,
+	}
+}
+
+func describeDelivery(s *deliverySubject) any {
+	return domain.HookTriggerLogDetails{HookID: s.delivery.HookID, DeliveryID: s.delivery.ID}
+}
+
+// client geeft een HTTP client met een geldig token van het account.
+func (s *deliverySubject) client(ctx context.Context) (*http.Client, error) {
+	token, err := s.hp.store.GetValidTokenForAccount(ctx, s.acc.ID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get token for account: %w", err)
+	}
+	return oauth2.NewClient(ctx, oauth2.StaticTokenSource(token)), nil
+}
@@ -0,0 +1,240 @@
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/email"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/store"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+)
+
+// releaseBody is een levering van een CI systeem zoals in de voorbeelden van de API docs
+const releaseBody = `{
+	"version": "2.4.0",
+	"release": {"date": "2025-12-01 14:00"},
+	"stakeholders": ["pm@example.com", "cto@example.com"]
+}`
+
+// fakeGoogle vangt de events en e-mails op die de acties aanmaken.
+type fakeGoogle struct {
+	events []calendar.Event
+	emails []*email.Parsed
+}
+
+func newTestProcessor(t *testing.T, fake *fakeGoogle) (*HookProcessor, *store.MockStore) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/calendars/primary/events":
+			var event calendar.Event
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+			fake.events = append(fake.events, event)
+			event.Id = "event-1"
+			json.NewEncoder(w).Encode(event)
+		case r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/gmail/v1/users/me/messages/send"):
+			var msg gmail.Message
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+			raw, err := email.DecodeRaw(msg.Raw)
+			require.NoError(t, err)
+			parsed, err := email.Parse(raw)
+			require.NoError(t, err)
+			fake.emails = append(fake.emails, parsed)
+			json.NewEncoder(w).Encode(gmail.Message{Id: "sent-1"})
+		default:
+			t.Errorf("Onverwacht request naar Fake Google API: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	mockStore := new(store.MockStore)
+	processor := NewHookProcessor(mockStore, zap.NewNop())
+	processor.newCalendarService = func(ctx context.Context, client *http.Client) (*calendar.Service, error) {
+		return calendar.NewService(ctx, option.WithHTTPClient(client), option.WithEndpoint(server.URL))
+	}
+	processor.newGmailService = func(ctx context.Context, client *http.Client) (*gmail.Service, error) {
+		return gmail.NewService(ctx, option.WithHTTPClient(client), option.WithEndpoint(server.URL))
+	}
+	return processor, mockStore
+}
+
+// hookRule maakt een rule van het account die aan de hook gebonden is.
+func hookRule(hookID, accountID uuid.UUID, actionType, params string) domain.AutomationRule {
+	return domain.AutomationRule{
+		BaseAutomationRule: domain.BaseAutomationRule{
+			AccountEntity: domain.AccountEntity{
+				BaseEntity:         domain.BaseEntity{ID: uuid.New()},
+				ConnectedAccountID: accountID,
+			},
+			Name:              "CI releases",
+			IsActive:          true,
+			TriggerConditions: json.RawMessage(`{"hook_id": "` + hookID.String() + `"}`),
+			ActionParams:      json.RawMessage(params),
+			Version:           1,
+		},
+		TriggerType: domain.CalendarTriggerHookReceived,
+		ActionType:  actionType,
+	}
+}
+
+// captureLogs laat de engine zijn automation logs schrijven en binnen de limieten blijven.
+func captureLogs(mockStore *store.MockStore) *[]store.CreateLogParams {
+	logs := new([]store.CreateLogParams)
+	mockStore.On("CreateAutomationLog", mock.Anything, mock.AnythingOfType("log.CreateLogParams")).
+		Run(func(args mock.Arguments) { *logs = append(*logs, args.Get(1).(store.CreateLogParams)) }).
+		Return(nil)
+	mockStore.On("GetRuleExecutionCounts", mock.Anything, mock.Anything, mock.Anything).Return(0, 0, nil)
+	mockStore.On("CountRuleExecution", mock.Anything, domain.RuleTypeCalendar, mock.Anything, mock.Anything).Return(1, 1, nil)
+	return logs
+}
+
+func TestRun_ExecutesRulesWithTemplateValues(t *testing.T) {
+	fake := &fakeGoogle{}
+	processor, mockStore := newTestProcessor(t, fake)
+	acc := &domain.ConnectedAccount{ID: uuid.New(), Status: domain.StatusActive}
+	delivery := domain.HookDelivery{ID: 7, HookID: uuid.New(), Payload: json.RawMessage(releaseBody)}
+	createEvent := hookRule(delivery.HookID, acc.ID, domain.HookActionCreateEvent,
+		`{"title": "Release {{version}}", "start": "{{release.date}}", "duration_min": 30, "timezone": "Europe/Amsterdam"}`)
+	sendEmail := hookRule(delivery.HookID, acc.ID, domain.HookActionSendEmail,
+		`{"to": ["{{stakeholders}}"], "subject": "Release {{version}} gepland", "body": "Op {{release.date}}."}`)
+
+	mockStore.On("GetValidTokenForAccount", mock.Anything, acc.ID).Return(&oauth2.Token{AccessToken: "fake-token"}, nil).Twice()
+	logs := captureLogs(mockStore)
+
+	assert.Equal(t, rules.OutcomeSuccess, processor.Run(context.Background(), acc, createEvent, delivery))
+	assert.Equal(t, rules.OutcomeSuccess, processor.Run(context.Background(), acc, sendEmail, delivery))
+
+	require.Len(t, fake.events, 1)
+	assert.Equal(t, "Release 2.4.0", fake.events[0].Summary)
+	assert.Equal(t, "2025-12-01T14:00:00+01:00", fake.events[0].Start.DateTime)
+	assert.Equal(t, "2025-12-01T14:30:00+01:00", fake.events[0].End.DateTime)
+	require.Len(t, fake.emails, 1)
+	assert.Equal(t, "Release 2.4.0 gepland", fake.emails[0].Subject)
+	assert.Contains(t, fake.emails[0].To, "pm@example.com")
+	assert.Contains(t, fake.emails[0].To, "cto@example.com")
+
+	// De uitkomst staat in de automation logs van het account, met de levering als trigger
+	require.Len(t, *logs, 2)
+	first := (*logs)[0]
+	assert.Equal(t, domain.LogSuccess, first.Status)
+	assert.Equal(t, acc.ID, first.ConnectedAccountID)
+	assert.Equal(t, createEvent.ID, *first.RuleID)
+	assert.JSONEq(t, `{"hook_id": "`+delivery.HookID.String()+`", "delivery_id": 7}`, string(first.TriggerDetails))
+	assert.JSONEq(t, `{"created_event_id": "event-1"}`, string(first.ActionDetails))
+	mockStore.AssertExpectations(t)
+}
+
+func TestRun_UserRuleRunsOnAccount(t *testing.T) {
+	fake := &fakeGoogle{}
+	processor, mockStore := newTestProcessor(t, fake)
+	userID := uuid.New()
+	acc := &domain.ConnectedAccount{ID: uuid.New(), UserID: userID, Status: domain.StatusActive}
+	delivery := domain.HookDelivery{ID: 8, HookID: uuid.New(), Payload: json.RawMessage(releaseBody)}
+	rule := hookRule(delivery.HookID, uuid.Nil, domain.HookActionSendEmail,
+		`{"to": ["pm@example.com"], "subject": "Release {{version}}", "body": "Hoi"}`)
+	rule.UserID = &userID
+	rule.AccountSelector = &domain.AccountSelector{Mode: domain.AccountSelectorAll}
+
+	mockStore.On("GetValidTokenForAccount", mock.Anything, acc.ID).Return(&oauth2.Token{AccessToken: "fake-token"}, nil).Once()
+	logs := captureLogs(mockStore)
+
+	outcome := processor.Run(context.Background(), acc, rule, delivery)
+
+	assert.Equal(t, rules.OutcomeSuccess, outcome)
+	require.Len(t, *logs, 1)
+	assert.Equal(t, acc.ID, (*logs)[0].ConnectedAccountID)
+	mockStore.AssertCalled(t, "CountRuleExecution", mock.Anything, domain.RuleTypeCalendar, rule.ID, acc.ID)
+}
+
+func TestRun_FailedActionIsLogged(t *testing.T) {
+	fake := &fakeGoogle{}
+	processor, mockStore := newTestProcessor(t, fake)
+	acc := &domain.ConnectedAccount{ID: uuid.New(), Status: domain.StatusActive}
+	delivery := domain.HookDelivery{ID: 9, HookID: uuid.New(), Payload: json.RawMessage(releaseBody)}
+	// release.time bestaat niet, dus de start blijft "{{release.time}}"
+	rule := hookRule(delivery.HookID, acc.ID, domain.HookActionCreateEvent, `{"title": "Release", "start": "{{release.time}}"}`)
+	logs := captureLogs(mockStore)
+
+	outcome := processor.Run(context.Background(), acc, rule, delivery)
+
+	assert.Equal(t, rules.OutcomeFailure, outcome)
+	assert.Empty(t, fake.events)
+	require.Len(t, *logs, 1)
+	assert.Equal(t, domain.LogFailure, (*logs)[0].Status)
+	assert.Contains(t, (*logs)[0].ErrorMessage, `could not parse time "{{release.time}}"`)
+	mockStore.AssertNotCalled(t, "CountRuleExecution")
+}
+
+func TestRun_OtherHook(t *testing.T) {
+	processor, mockStore := newTestProcessor(t, &fakeGoogle{})
+	acc := &domain.ConnectedAccount{ID: uuid.New(), Status: domain.StatusActive}
+	delivery := domain.HookDelivery{ID: 10, HookID: uuid.New(), Payload: json.RawMessage(`{}`)}
+	rule := hookRule(uuid.New(), acc.ID, domain.HookActionSendEmail, `{"to": ["pm@example.com"], "subject": "Hoi", "body": "Hoi"}`)
+
+	outcome := processor.Run(context.Background(), acc, rule, delivery)
+
+	assert.Equal(t, rules.OutcomeNoMatch, outcome)
+	mockStore.AssertNotCalled(t, "CreateAutomationLog")
+}
+
+func TestRun_NotDue(t *testing.T) {
+	processor, mockStore := newTestProcessor(t, &fakeGoogle{})
+	acc := &domain.ConnectedAccount{ID: uuid.New(), Status: domain.StatusActive}
+	delivery := domain.HookDelivery{ID: 11, HookID: uuid.New(), Payload: json.RawMessage(`{}`)}
+	rule := hookRule(delivery.HookID, acc.ID, domain.HookActionSendEmail, `{"to": ["pm@example.com"], "subject": "Hoi", "body": "Hoi"}`)
+	validFrom := time.Now().Add(24 * time.Hour)
+	rule.Schedule = &domain.RuleSchedule{ValidFrom: &validFrom}
+
+	outcome := processor.Run(context.Background(), acc, rule, delivery)
+
+	assert.Equal(t, OutcomeNotDue, outcome)
+	mockStore.AssertNotCalled(t, "CreateAutomationLog")
+}
+
+func TestSendEmailParams_Recipients(t *testing.T) {
+	params := sendEmailParams{To: []string{"{{owners}}", "{{missing}}", "qa@example.com"}}
+
+	to, err := params.recipients(map[string]string{"owners": "a@example.com, b@example.com", "missing": ""})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a@example.com", "b@example.com", "qa@example.com"}, to)
+
+	_, err = params.recipients(map[string]string{"owners": "geen adres"})
+	assert.Error(t, err)
+}
+
+func TestRules_ValidateParams(t *testing.T) {
+	assert.Empty(t, Rules.ValidateRule(rules.Rule{
+		TriggerType:   domain.CalendarTriggerHookReceived,
+		TriggerParams: []byte(`{"hook_id": "` + uuid.NewString() + `"}`),
+		ActionType:    domain.HookActionSendEmail,
+		ActionParams:  []byte(`{"to": ["{{to}}"], "subject": "Hoi", "body": "Hoi"}`),
+	}))
+	assert.NotEmpty(t, Rules.ValidateRule(rules.Rule{
+		TriggerType:  domain.CalendarTriggerHookReceived,
+		ActionType:   domain.HookActionSendEmail,
+		ActionParams: []byte(`{"to": ["{{to}}"], "subject": "Hoi", "body": "Hoi"}`),
+	}))
+	assert.NoError(t, Rules.ValidateAction(domain.HookActionCreateEvent, []byte(`{"title": "Release", "start": "{{date}}"}`)))
+	assert.Error(t, Rules.ValidateAction(domain.HookActionCreateEvent, []byte(`{"title": "Release"}`)))
+	assert.Error(t, Rules.ValidateAction(domain.HookActionCreateEvent, []byte(`{"title": "Release", "start": "{{date}}", "timezone": "Mars/Olympus"}`)))
+	assert.NoError(t, Rules.ValidateAction(domain.HookActionSendEmail, []byte(`{"to": ["{{to}}"], "subject": "Hoi", "body": "Hoi"}`)))
+	assert.Error(t, Rules.ValidateAction(domain.HookActionSendEmail, []byte(`{"to": [""], "subject": "Hoi", "body": "Hoi"}`)))
+	assert.Error(t, Rules.ValidateAction("webhook", []byte(`{}`)))
+}
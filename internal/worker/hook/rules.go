@@ -0,0 +1,247 @@
+package hook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/gmail/v1"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/email"
+	"agenda-automator-api/internal/rules"
+)
+
+// Rules bevat de trigger en acties van calendar rules die aan een inbound hook gebonden zijn.
+var Rules = newRegistry()
+
+func newRegistry() *rules.Registry[*deliverySubject] {
+	r := rules.NewRegistry[*deliverySubject]()
+	r.RegisterTrigger(rules.NewTrigger(domain.CalendarTriggerHookReceived, matchHook))
+	r.RegisterAction(rules.NewAction(domain.HookActionCreateEvent, createEvent, nil))
+	r.RegisterAction(rules.NewAction(domain.HookActionSendEmail, sendEmail, nil))
+	return r
+}
+
+// hookReceivedParams zijn de trigger_conditions van hook_received.
+type hookReceivedParams struct {
+	domain.HookTriggerConditions
+}
+
+func (p hookReceivedParams) Validate() error {
+	var errs rules.FieldErrors
+	if p.HookID == uuid.Nil {
+		errs.Add("hook_id", "is required")
+	}
+	return errs.Err()
+}
+
+// matchHook matcht leveringen aan de hook waaraan de rule gebonden is.
+func matchHook(_ context.Context, s *deliverySubject, params hookReceivedParams) (bool, error) {
+	return params.HookID == s.delivery.HookID, nil
+}
+
+// Grenzen en standaardwaarden voor create_calendar_event
+const (
+	defaultEventDurationMinutes = 60
+	maxEventDurationMinutes     = 24 * 60
+)
+
+// eventTimeLayouts zijn de notaties waarin start en end na het invullen mogen staan.
+var eventTimeLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04"}
+
+// createEventParams zijn de action_params van create_calendar_event. Alle tekstvelden mogen
+// {{pad}} variabelen uit de body bevatten.
+type createEventParams struct {
+	Title       string `json:"title"`
+	Start       string `json:"start"`
+	End         string `json:"end"`          // optioneel; anders duurt het event DurationMin minuten
+	DurationMin int    `json:"duration_min"` // 0 is 60 minuten
+	Location    string `json:"location"`
+	Description string `json:"description"`
+	TimeZone    string `json:"timezone"` // IANA naam voor tijden zonder zone, standaard UTC
+}
+
+func (p createEventParams) Validate() error {
+	var errs rules.FieldErrors
+	if strings.TrimSpace(p.Title) == "" {
+		errs.Add("title", "is required")
+	}
+	if strings.TrimSpace(p.Start) == "" {
+		errs.Add("start", "is required")
+	}
+	if p.DurationMin < 0 || p.DurationMin > maxEventDurationMinutes {
+		errs.Add("duration_min", "must be between 0 and %d", maxEventDurationMinutes)
+	}
+	if p.TimeZone != "" {
+		if _, err := time.LoadLocation(p.TimeZone); err != nil {
+			errs.Add("timezone", "unknown time zone %q", p.TimeZone)
+		}
+	}
+	return errs.Err()
+}
+
+// eventDetails is de action_details van create_calendar_event.
+type eventDetails struct {
+	CreatedEventID string `json:"created_event_id"`
+	HTMLLink       string `json:"html_link,omitempty"`
+}
+
+// createEvent maakt een event in de primaire agenda van het account.
+func createEvent(ctx context.Context, s *deliverySubject, rule rules.Rule, params createEventParams) (rules.Result, error) {
+	loc := time.UTC
+	if params.TimeZone != "" {
+		loc, _ = time.LoadLocation(params.TimeZone) // gevalideerd in Validate
+	}
+
+	start, err := parseTime(rules.ExpandTemplate(params.Start, s.values), loc)
+	if err != nil {
+		return rules.Result{}, fmt.Errorf("start: %w", err)
+	}
+	duration := params.DurationMin
+	if duration == 0 {
+		duration = defaultEventDurationMinutes
+	}
+	end := start.Add(time.Duration(duration) * time.Minute)
+	if params.End != "" {
+		if end, err = parseTime(rules.ExpandTemplate(params.End, s.values), loc); err != nil {
+			return rules.Result{}, fmt.Errorf("end: %w", err)
+		}
+		if !end.After(start) {
+			return rules.Result{}, fmt.Errorf("end is not after the start")
+		}
+	}
+
+	client, err := s.client(ctx)
+	if err != nil {
+		return rules.Result{}, err
+	}
+	srv, err := s.hp.newCalendarService(ctx, client)
+	if err != nil {
+		return rules.Result{}, fmt.Errorf("could not create calendar service: %w", err)
+	}
+
+	created, err := srv.Events.Insert("primary", &calendar.Event{
+		Summary:     rules.ExpandTemplate(params.Title, s.values),
+		Location:    rules.ExpandTemplate(params.Location, s.values),
+		Description: rules.ExpandTemplate(params.Description, s.values),
+		Start:       &calendar.EventDateTime{DateTime: start.Format(time.RFC3339), TimeZone: params.TimeZone},
+		End:         &calendar.EventDateTime{DateTime: end.Format(time.RFC3339), TimeZone: params.TimeZone},
+	}).Context(ctx).Do()
+	if err != nil {
+		return rules.Result{}, fmt.Errorf("could not create calendar event: %w", err)
+	}
+
+	s.hp.logger.Info(
+		"created calendar event for hook delivery",
+		zap.String("event_id", created.Id),
+		zap.String("rule_id", rule.ID.String()),
+		zap.Int64("delivery_id", s.delivery.ID),
+		zap.String("component", "hook"),
+	)
+	return rules.Result{Details: eventDetails{CreatedEventID: created.Id, HTMLLink: created.HtmlLink}}, nil
+}
+
+func parseTime(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range eventTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("could not parse time %q", value)
+}
+
+// sendEmailParams zijn de action_params van send_email. Elk adres in To mag een variabele zijn;
+// een variabele met een lijst levert meerdere adressen op.
+type sendEmailParams struct {
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	Body    string   `json:"body"`
+}
+
+func (p sendEmailParams) Validate() error {
+	var errs rules.FieldErrors
+	if len(p.To) == 0 {
+		errs.Add("to", "is required")
+	}
+	for i, to := range p.To {
+		if strings.TrimSpace(to) == "" {
+			errs.Add(fmt.Sprintf("to[%d]", i), "must not be empty")
+		}
+	}
+	if strings.TrimSpace(p.Subject) == "" {
+		errs.Add("subject", "is required")
+	}
+	if strings.TrimSpace(p.Body) == "" {
+		errs.Add("body", "is required")
+	}
+	return errs.Err()
+}
+
+// recipients vult de adressen in en splitst lijsten. Lege waarden vallen weg.
+func (p sendEmailParams) recipients(values map[string]string) ([]string, error) {
+	var to []string
+	for _, template := range p.To {
+		for _, address := range strings.Split(rules.ExpandTemplate(template, values), ",") {
+			if address = strings.TrimSpace(address); address == "" {
+				continue
+			}
+			if _, err := email.NormalizeAddress(address); err != nil {
+				return nil, fmt.Errorf("invalid recipient %q", address)
+			}
+			to = append(to, address)
+		}
+	}
+	return to, nil
+}
+
+// sendEmail stuurt een e-mail via de Gmail API van het account.
+func sendEmail(ctx context.Context, s *deliverySubject, rule rules.Rule, params sendEmailParams) (rules.Result, error) {
+	to, err := params.recipients(s.values)
+	if err != nil {
+		return rules.Result{}, err
+	}
+	details := domain.EmailActionLogDetails{ActionType: domain.HookActionSendEmail, To: to}
+	if len(to) == 0 {
+		return rules.Result{Skipped: true, Details: details}, nil
+	}
+
+	client, err := s.client(ctx)
+	if err != nil {
+		return rules.Result{}, err
+	}
+	srv, err := s.hp.newGmailService(ctx, client)
+	if err != nil {
+		return rules.Result{}, fmt.Errorf("could not create Gmail service: %w", err)
+	}
+
+	raw, err := (&email.Message{
+		To:      to,
+		Subject: rules.ExpandTemplate(params.Subject, s.values),
+		Text:    rules.ExpandTemplate(params.Body, s.values),
+	}).Raw()
+	if err != nil {
+		return rules.Result{}, fmt.Errorf("could not compose email: %w", err)
+	}
+	sent, err := srv.Users.Messages.Send("me", &gmail.Message{Raw: raw}).Context(ctx).Do()
+	if err != nil {
+		return rules.Result{}, fmt.Errorf("could not send email: %w", err)
+	}
+
+	s.hp.logger.Info(
+		"sent email for hook delivery",
+		zap.String("message_id", sent.Id),
+		zap.Int("recipients", len(to)),
+		zap.String("rule_id", rule.ID.String()),
+		zap.Int64("delivery_id", s.delivery.ID),
+		zap.String("component", "hook"),
+	)
+
+	details.SentMessageID = sent.Id
+	return rules.Result{Details: details}, nil
+}
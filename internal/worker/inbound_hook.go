@@ -0,0 +1,165 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+	"agenda-automator-api/internal/store"
+
+	"go.uber.org/zap"
+)
+
+// hookQueueSize is het maximale aantal leveringen aan inbound hooks dat op de worker wacht.
+const hookQueueSize = 100
+
+// errNoHookRules is de fout van een levering aan een hook zonder actieve gebonden rules.
+var errNoHookRules = errors.New("no active rule is bound to the hook")
+
+// EnqueueHookDelivery zet een levering aan een inbound hook klaar zonder te blokkeren. Geeft false
+// terug als de queue vol is.
+func (w *Worker) EnqueueHookDelivery(delivery domain.HookDelivery) bool {
+	select {
+	case w.hookDeliveries <- delivery:
+		return true
+	default:
+		return false
+	}
+}
+
+// processHookDeliveries verwerkt de leveringen één voor één.
+func (w *Worker) processHookDeliveries() {
+	for delivery := range w.hookDeliveries {
+		w.deliverHook(delivery)
+	}
+}
+
+// failQueuedHookDeliveries sluit leveringen af die bij een vorige start in de queue bleven staan.
+func (w *Worker) failQueuedHookDeliveries() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	n, err := w.store.FailQueuedHookDeliveries(ctx, "worker restarted before the delivery ran")
+	if err != nil {
+		w.logger.Error("failed to close queued hook deliveries", zap.Error(err), zap.String("component", "worker"))
+		return
+	}
+	if n > 0 {
+		w.logger.Warn("closed queued hook deliveries", zap.Int64("count", n), zap.String("component", "worker"))
+	}
+}
+
+// deliverHook draait de rules die aan de hook gebonden zijn en slaat de uitkomst per rule en
+// account op bij de levering.
+func (w *Worker) deliverHook(delivery domain.HookDelivery) {
+	ctx, cancel := context.WithTimeout(context.Background(), 110*time.Second)
+	defer cancel()
+
+	results, err := w.runHookRules(ctx, delivery)
+	params := store.FinishHookDeliveryParams{
+		DeliveryID: delivery.ID,
+		Status:     hookDeliveryStatus(results),
+		Results:    results,
+	}
+	if err != nil {
+		w.logger.Warn(
+			"hook delivery failed",
+			zap.Error(err),
+			zap.Int64("delivery_id", delivery.ID),
+			zap.String("hook_id", delivery.HookID.String()),
+			zap.String("component", "worker"),
+		)
+		msg := err.Error()
+		params.Status, params.ErrorMessage = domain.HookDeliveryFailure, &msg
+	}
+
+	// Los van ctx, zodat ook een levering die op zijn timeout stukliep afgesloten wordt
+	finishCtx, finishCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer finishCancel()
+	if _, err = w.store.FinishHookDelivery(finishCtx, params); err != nil {
+		w.logger.Error(
+			"failed to finish hook delivery",
+			zap.Error(err),
+			zap.Int64("delivery_id", delivery.ID),
+			zap.String("component", "worker"),
+		)
+	}
+}
+
+// runHookRules draait elke gebonden rule op de accounts die hij kiest, met het account vergrendeld
+// zoals bij een handmatige run. Een rule die op geen account kan draaien krijgt een mislukte
+// uitkomst zonder account.
+func (w *Worker) runHookRules(ctx context.Context, delivery domain.HookDelivery) ([]domain.HookRuleResult, error) {
+	hookRules, err := w.store.GetRulesForHook(ctx, delivery.HookID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch hook rules: %w", err)
+	}
+	if len(hookRules) == 0 {
+		return nil, errNoHookRules
+	}
+
+	var results []domain.HookRuleResult
+	for _, rule := range hookRules {
+		accounts, err := w.ruleAccounts(ctx, rule.BaseAutomationRule)
+		if err == nil && len(accounts) == 0 {
+			err = errors.New("rule applies to no active account")
+		}
+		if err != nil {
+			results = append(results, domain.HookRuleResult{
+				RuleID:  rule.ID,
+				Outcome: string(rules.OutcomeFailure),
+				Error:   err.Error(),
+			})
+			continue
+		}
+
+		for i := range accounts {
+			acc := &accounts[i]
+			results = append(results, w.runHookRule(ctx, acc, rule, delivery))
+		}
+	}
+	return results, nil
+}
+
+// runHookRule draait één gebonden rule op één account.
+func (w *Worker) runHookRule(
+	ctx context.Context,
+	acc *domain.ConnectedAccount,
+	rule domain.AutomationRule,
+	delivery domain.HookDelivery,
+) domain.HookRuleResult {
+	result := domain.HookRuleResult{RuleID: rule.ID, ConnectedAccountID: &acc.ID}
+
+	unlock, err := w.accountLocks.lock(ctx, acc.ID)
+	if err != nil {
+		result.Outcome, result.Error = string(rules.OutcomeFailure), fmt.Sprintf("account stayed busy: %v", err)
+		return result
+	}
+	defer unlock()
+
+	result.Outcome = string(w.hookProcessor.Run(ctx, acc, rule, delivery))
+	return result
+}
+
+// hookDeliveryStatus vat de uitkomsten samen. Alleen mislukte en verkeerd geconfigureerde rules
+// tellen als mislukt; overgeslagen rules en rules buiten hun schedule of limiet niet.
+func hookDeliveryStatus(results []domain.HookRuleResult) domain.HookDeliveryStatus {
+	failed := 0
+	for _, result := range results {
+		switch rules.Outcome(result.Outcome) {
+		case rules.OutcomeFailure, rules.OutcomeInvalid:
+			failed++
+		}
+	}
+	switch {
+	case failed == 0:
+		return domain.HookDeliverySuccess
+	case failed == len(results):
+		return domain.HookDeliveryFailure
+	default:
+		return domain.HookDeliveryPartial
+	}
+}
@@ -0,0 +1,267 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"agenda-automator-api/internal/domain"
+	"agenda-automator-api/internal/rules"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+)
+
+// runQueueSize is het maximale aantal handmatige runs dat op de worker wacht.
+const runQueueSize = 50
+
+// accountLocks zorgt dat er per account één verwerking tegelijk loopt, zodat een handmatige run
+// nooit tegelijk met die van de ticker draait.
+type accountLocks struct {
+	mu    sync.Mutex
+	locks map[uuid.UUID]chan struct{}
+}
+
+func newAccountLocks() *accountLocks {
+	return &accountLocks{locks: make(map[uuid.UUID]chan struct{})}
+}
+
+func (l *accountLocks) get(accountID uuid.UUID) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lock, ok := l.locks[accountID]
+	if !ok {
+		lock = make(chan struct{}, 1)
+		l.locks[accountID] = lock
+	}
+	return lock
+}
+
+// lock wacht tot het account vrij is, of tot ctx afloopt.
+func (l *accountLocks) lock(ctx context.Context, accountID uuid.UUID) (unlock func(), err error) {
+	lock := l.get(accountID)
+	select {
+	case lock <- struct{}{}:
+		return func() { <-lock }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// tryLock pakt het account alleen als het vrij is.
+func (l *accountLocks) tryLock(accountID uuid.UUID) (unlock func(), ok bool) {
+	lock := l.get(accountID)
+	select {
+	case lock <- struct{}{}:
+		return func() { <-lock }, true
+	default:
+		return nil, false
+	}
+}
+
+// EnqueueRun zet een handmatige run klaar zonder te blokkeren. Geeft false terug als de queue vol is.
+func (w *Worker) EnqueueRun(run domain.ProcessingRun) bool {
+	select {
+	case w.runs <- run:
+		return true
+	default:
+		return false
+	}
+}
+
+// processRuns verwerkt de handmatige runs één voor één.
+func (w *Worker) processRuns() {
+	for run := range w.runs {
+		w.executeRun(run)
+	}
+}
+
+// failUnfinishedRuns sluit runs af die bij een vorige start in de queue bleven staan.
+func (w *Worker) failUnfinishedRuns() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	n, err := w.store.FailUnfinishedProcessingRuns(ctx, "worker restarted before the run finished")
+	if err != nil {
+		w.logger.Error("failed to close unfinished runs", zap.Error(err), zap.String("component", "worker"))
+		return
+	}
+	if n > 0 {
+		w.logger.Warn("closed unfinished runs", zap.Int64("count", n), zap.String("component", "worker"))
+	}
+}
+
+// executeRun draait een handmatige run en slaat de uitkomst op. De automation logs van de run
+// krijgen zijn ID, zodat zijn resultaten los staan van wat de ticker of een push sync schrijft.
+func (w *Worker) executeRun(run domain.ProcessingRun) {
+	ctx, cancel := context.WithTimeout(context.Background(), 110*time.Second)
+	defer cancel()
+	ctx = rules.WithRunID(ctx, run.ID)
+
+	var err error
+	switch {
+	case run.RuleID != nil:
+		err = w.runRule(ctx, run)
+	case run.GmailRuleID != nil:
+		err = w.runGmailRule(ctx, run)
+	default:
+		err = w.runAccount(ctx, run)
+	}
+
+	status := domain.RunCompleted
+	var message *string
+	if err != nil {
+		w.logger.Warn(
+			"manual run failed",
+			zap.Error(err),
+			zap.String("run_id", run.ID.String()),
+			zap.String("component", "worker"),
+		)
+		status = domain.RunFailed
+		msg := err.Error()
+		message = &msg
+	}
+
+	// Los van ctx, zodat ook een run die op zijn timeout stukliep afgesloten wordt
+	finishCtx, finishCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer finishCancel()
+	if _, err = w.store.FinishProcessingRun(finishCtx, run.ID, status, message); err != nil {
+		w.logger.Error(
+			"failed to finish run",
+			zap.Error(err),
+			zap.String("run_id", run.ID.String()),
+			zap.String("component", "worker"),
+		)
+	}
+}
+
+// runAccount verwerkt een account zoals de ticker dat doet.
+func (w *Worker) runAccount(ctx context.Context, run domain.ProcessingRun) error {
+	acc, err := w.store.GetConnectedAccountByID(ctx, *run.ConnectedAccountID)
+	if err != nil {
+		return fmt.Errorf("could not get account: %w", err)
+	}
+	if acc.Status != domain.StatusActive {
+		return fmt.Errorf("account is %s", acc.Status)
+	}
+
+	return w.withAccountLock(ctx, run, acc.ID, func() error {
+		return w.syncAccount(ctx, &acc)
+	})
+}
+
+// runRule verwerkt één calendar rule op elk actief account waar hij op van toepassing is.
+func (w *Worker) runRule(ctx context.Context, run domain.ProcessingRun) error {
+	rule, err := w.store.GetRuleByID(ctx, *run.RuleID)
+	if err != nil {
+		return fmt.Errorf("could not get rule: %w", err)
+	}
+	accounts, err := w.ruleAccounts(ctx, rule.BaseAutomationRule)
+	if err != nil {
+		return err
+	}
+	if len(accounts) == 0 {
+		return errors.New("rule applies to no active account")
+	}
+
+	return w.runOnAccounts(ctx, run, accounts, func(acc *domain.ConnectedAccount, token *oauth2.Token) error {
+		return w.calendarProcessor.ProcessRule(ctx, acc, token, rule.ID)
+	})
+}
+
+// runGmailRule verwerkt één Gmail rule op elk actief account met Gmail sync waar hij op van
+// toepassing is; de ticker slaat accounts zonder Gmail sync ook over.
+func (w *Worker) runGmailRule(ctx context.Context, run domain.ProcessingRun) error {
+	rule, err := w.store.GetGmailRuleByID(ctx, *run.GmailRuleID)
+	if err != nil {
+		return fmt.Errorf("could not get rule: %w", err)
+	}
+	all, err := w.ruleAccounts(ctx, rule.BaseAutomationRule)
+	if err != nil {
+		return err
+	}
+	var accounts []domain.ConnectedAccount
+	for _, acc := range all {
+		if acc.GmailSyncEnabled {
+			accounts = append(accounts, acc)
+		}
+	}
+	if len(accounts) == 0 {
+		return errors.New("rule applies to no active account with Gmail sync")
+	}
+
+	return w.runOnAccounts(ctx, run, accounts, func(acc *domain.ConnectedAccount, token *oauth2.Token) error {
+		return w.gmailProcessor.ProcessRule(ctx, acc, token, rule.ID)
+	})
+}
+
+// runOnAccounts draait process voor een rule run op elk account, één account tegelijk vast. Een
+// fout op het ene account houdt de andere niet tegen; de fouten komen samen terug.
+func (w *Worker) runOnAccounts(
+	ctx context.Context,
+	run domain.ProcessingRun,
+	accounts []domain.ConnectedAccount,
+	process func(acc *domain.ConnectedAccount, token *oauth2.Token) error,
+) error {
+	var errs []error
+	for i := range accounts {
+		acc := &accounts[i]
+		err := w.withAccountLock(ctx, run, acc.ID, func() error {
+			token, err := w.store.GetValidTokenForAccount(ctx, acc.ID)
+			if err != nil {
+				return fmt.Errorf("could not get token: %w", err)
+			}
+			return process(acc, token)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", acc.Email, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ruleAccounts geeft de actieve accounts waar een rule op draait: het eigen account, of voor een
+// user-level rule de accounts van de gebruiker die de selector kiest.
+func (w *Worker) ruleAccounts(ctx context.Context, rule domain.BaseAutomationRule) ([]domain.ConnectedAccount, error) {
+	if !rule.IsUserRule() {
+		acc, err := w.store.GetConnectedAccountByID(ctx, rule.ConnectedAccountID)
+		if err != nil {
+			return nil, fmt.Errorf("could not get account: %w", err)
+		}
+		if acc.Status != domain.StatusActive {
+			return nil, fmt.Errorf("account is %s", acc.Status)
+		}
+		return []domain.ConnectedAccount{acc}, nil
+	}
+
+	all, err := w.store.GetAccountsForUser(ctx, *rule.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get accounts: %w", err)
+	}
+	var accounts []domain.ConnectedAccount
+	for _, acc := range all {
+		if acc.Status == domain.StatusActive && rule.AppliesTo(&acc) {
+			accounts = append(accounts, acc)
+		}
+	}
+	return accounts, nil
+}
+
+// withAccountLock wacht tot het account vrij is en draait fn. De run telt als gestart zodra hij het
+// eerste account heeft.
+func (w *Worker) withAccountLock(ctx context.Context, run domain.ProcessingRun, accountID uuid.UUID, fn func() error) error {
+	unlock, err := w.accountLocks.lock(ctx, accountID)
+	if err != nil {
+		return fmt.Errorf("account stayed busy: %w", err)
+	}
+	defer unlock()
+
+	if err = w.store.StartProcessingRun(ctx, run.ID); err != nil {
+		return fmt.Errorf("could not start run: %w", err)
+	}
+	return fn()
+}
@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// scheduledJob is werk dat op een vast interval draait, los van de account-cyclus.
+// Gebruik dit voor dingen die op een bepaald tijdstip moeten gebeuren (bijv. snoozes).
+type scheduledJob struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context) error
+}
+
+// scheduledJobs geeft alle geplande jobs van de worker terug.
+func (w *Worker) scheduledJobs() []scheduledJob {
+	return []scheduledJob{
+		{name: "gmail snoozes", interval: time.Minute, run: w.wakeSnoozedMessages},
+		{name: "gmail scheduled sends", interval: time.Minute, run: w.sendScheduledMessages},
+	}
+}
+
+// runScheduledJob draait een job op zijn eigen ticker. De eerste run is na één interval.
+func (w *Worker) runScheduledJob(job scheduledJob) {
+	ticker := time.NewTicker(job.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), job.interval)
+		if err := job.run(ctx); err != nil {
+			w.logger.Error(
+				"scheduled job failed",
+				zap.Error(err),
+				zap.String("job", job.name),
+				zap.String("component", "worker"),
+			)
+		}
+		cancel()
+	}
+}
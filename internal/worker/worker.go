@@ -7,12 +7,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"sync"
 	"time"
 
 	"agenda-automator-api/internal/store"
 	"agenda-automator-api/internal/worker/calendar"
 	"agenda-automator-api/internal/worker/gmail"
+	"agenda-automator-api/internal/worker/hook"
 
 	"go.uber.org/zap"
 )
@@ -26,16 +28,35 @@ type Worker struct {
 	logger            *zap.Logger
 	calendarProcessor *calendar.CalendarProcessor
 	gmailProcessor    *gmail.GmailProcessor
+	hookProcessor     *hook.HookProcessor
 	// googleOAuthConfig *oauth2.Config // <-- VERWIJDERD (zit nu in store)
+
+	// calendarWebhookURL is het publieke adres voor Calendar push notificaties.
+	// Leeg betekent: geen watch channels, alleen de ticker.
+	calendarWebhookURL string
+	syncQueue          *calendarSyncQueue
+
+	// runs zijn handmatige runs uit de API; accountLocks houdt ze gescheiden van de ticker
+	runs         chan domain.ProcessingRun
+	accountLocks *accountLocks
+
+	// hookDeliveries zijn leveringen aan inbound hooks die de API heeft aangenomen
+	hookDeliveries chan domain.HookDelivery
 }
 
 // NewWorker (AANGEPAST)
 func NewWorker(s store.Storer, logger *zap.Logger) (*Worker, error) {
 	return &Worker{
-		store:             s,
-		logger:            logger,
-		calendarProcessor: calendar.NewCalendarProcessor(s),
-		gmailProcessor:    gmail.NewGmailProcessor(s),
+		store:              s,
+		logger:             logger,
+		calendarProcessor:  calendar.NewCalendarProcessor(s),
+		gmailProcessor:     gmail.NewGmailProcessor(s),
+		hookProcessor:      hook.NewHookProcessor(s, logger),
+		calendarWebhookURL: os.Getenv("CALENDAR_WEBHOOK_URL"),
+		syncQueue:          newCalendarSyncQueue(),
+		runs:               make(chan domain.ProcessingRun, runQueueSize),
+		accountLocks:       newAccountLocks(),
+		hookDeliveries:     make(chan domain.HookDelivery, hookQueueSize),
 	}, nil
 }
 
@@ -43,7 +64,17 @@ func NewWorker(s store.Storer, logger *zap.Logger) (*Worker, error) {
 func (w *Worker) Start() {
 	w.logger.Info("starting worker", zap.String("component", "worker"))
 
+	// Runs en hook leveringen uit de queue van een vorige start worden nooit meer opgepakt
+	w.failUnfinishedRuns()
+	w.failQueuedHookDeliveries()
+
 	go w.run()
+	go w.processRuns()
+	go w.processHookDeliveries()
+
+	for _, job := range w.scheduledJobs() {
+		go w.runScheduledJob(job)
+	}
 }
 
 // run is de hoofdloop die periodiek de accounts controleert (real-time monitoring)
@@ -56,8 +87,13 @@ func (w *Worker) run() {
 	w.doWork()
 
 	for {
-		<-ticker.C
-		w.doWork()
+		select {
+		case <-ticker.C:
+			w.doWork()
+		case req := <-w.syncQueue.requests:
+			w.syncQueue.done(req.accountID)
+			w.syncCalendar(req)
+		}
 	}
 }
 
@@ -76,6 +112,10 @@ func (w *Worker) doWork() {
 
 // checkAccounts haalt alle accounts op, beheert tokens, en start de verwerking (parallel)
 func (w *Worker) checkAccounts(ctx context.Context) error {
+	if w.calendarWebhookURL != "" {
+		w.stopInactiveWatches(ctx)
+	}
+
 	accounts, err := w.store.GetActiveAccounts(ctx)
 	if err != nil {
 		return fmt.Errorf("could not get active accounts: %w", err)
@@ -101,8 +141,26 @@ func (w *Worker) checkAccounts(ctx context.Context) error {
 	return nil
 }
 
-// processAccount (ZWAAR VEREENVOUDIGD)
+// processAccount verwerkt een account voor de ticker. Wordt het account al verwerkt, bijv. door een
+// handmatige run, dan slaat de ticker het over; de volgende tick pakt het weer op.
 func (w *Worker) processAccount(ctx context.Context, acc *domain.ConnectedAccount) {
+	unlock, ok := w.accountLocks.tryLock(acc.ID)
+	if !ok {
+		w.logger.Info(
+			"account already being processed, skipping",
+			zap.String("account_id", acc.ID.String()),
+			zap.String("component", "worker"),
+		)
+		return
+	}
+	defer unlock()
+
+	_ = w.syncAccount(ctx, acc) // De fouten zijn al gelogd
+}
+
+// syncAccount (ZWAAR VEREENVOUDIGD) verwerkt de agenda en Gmail van een account. Fouten worden
+// gelogd en samen teruggegeven; een fout in de ene stap houdt de volgende niet tegen.
+func (w *Worker) syncAccount(ctx context.Context, acc *domain.ConnectedAccount) error {
 	// 1. Haal een gegarandeerd geldig token op.
 	// De store regelt de decryptie, check, refresh, en update.
 	token, err := w.store.GetValidTokenForAccount(ctx, acc.ID)
@@ -123,9 +181,11 @@ func (w *Worker) processAccount(ctx context.Context, acc *domain.ConnectedAccoun
 				zap.String("component", "worker"),
 			)
 		}
-		return // Stop verwerking voor dit account
+		return err // Stop verwerking voor dit account
 	}
 
+	var errs []error
+
 	// 2. Process calendar
 	// AANGEPAST: Gebruik w.logger
 	w.logger.Info(
@@ -141,6 +201,20 @@ func (w *Worker) processAccount(ctx context.Context, acc *domain.ConnectedAccoun
 			zap.String("account_id", acc.ID.String()),
 			zap.String("component", "worker"),
 		)
+		errs = append(errs, err)
+	}
+
+	// 2.2. Houd het Calendar watch channel open (alleen met een webhook URL)
+	if w.calendarWebhookURL != "" {
+		if err := w.calendarProcessor.EnsureWatch(ctx, acc, token, w.calendarWebhookURL); err != nil {
+			w.logger.Error(
+				"failed to ensure calendar watch channel",
+				zap.Error(err),
+				zap.String("account_id", acc.ID.String()),
+				zap.String("component", "worker"),
+			)
+			errs = append(errs, err)
+		}
 	}
 
 	// 2.5. Process Gmail (only if Gmail sync is enabled)
@@ -159,6 +233,7 @@ func (w *Worker) processAccount(ctx context.Context, acc *domain.ConnectedAccoun
 				zap.String("account_id", acc.ID.String()),
 				zap.String("component", "worker"),
 			)
+			errs = append(errs, err)
 		}
 	}
 
@@ -172,4 +247,6 @@ func (w *Worker) processAccount(ctx context.Context, acc *domain.ConnectedAccoun
 			zap.String("component", "worker"),
 		)
 	}
+
+	return errors.Join(errs...)
 }
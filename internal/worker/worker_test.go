@@ -2,6 +2,7 @@ package worker
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"testing"
 	"time"
@@ -12,6 +13,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"golang.org/x/oauth2"
 )
@@ -56,6 +58,9 @@ func TestWorker_Start(t *testing.T) {
 
 	// Mock GetActiveAccounts to return empty slice to avoid processing
 	mockStore.On("GetActiveAccounts", mock.Anything).Return([]domain.ConnectedAccount{}, nil).Maybe()
+	// Runs die bij een vorige start bleven hangen worden afgesloten
+	mockStore.On("FailUnfinishedProcessingRuns", mock.Anything, mock.Anything).Return(int64(0), nil).Once()
+	mockStore.On("FailQueuedHookDeliveries", mock.Anything, mock.Anything).Return(int64(0), nil).Once()
 
 	worker, err := NewWorker(mockStore, testLogger)
 	assert.NoError(t, err)
@@ -128,6 +133,7 @@ func TestWorker_checkAccounts(t *testing.T) {
 	mockStore.On("GetActiveAccounts", mock.Anything).Return(accounts, nil)
 	mockStore.On("GetValidTokenForAccount", mock.Anything, accounts[0].ID).Return(createValidToken(), nil)
 	mockStore.On("GetRulesForAccount", mock.Anything, accounts[0].ID).Return([]domain.AutomationRule{}, nil) // No rules to avoid processing
+	mockStore.On("GetUserRules", mock.Anything, accounts[0].UserID).Return([]domain.AutomationRule{}, nil)
 	mockStore.On("UpdateAccountLastChecked", mock.Anything, accounts[0].ID).Return(nil)
 
 	worker, err := NewWorker(mockStore, testLogger)
@@ -181,6 +187,7 @@ func TestWorker_processAccount_ValidToken(t *testing.T) {
 
 	mockStore.On("GetValidTokenForAccount", mock.Anything, account.ID).Return(createValidToken(), nil)
 	mockStore.On("GetRulesForAccount", mock.Anything, account.ID).Return([]domain.AutomationRule{}, nil) // No rules to avoid processing
+	mockStore.On("GetUserRules", mock.Anything, account.UserID).Return([]domain.AutomationRule{}, nil)
 	mockStore.On("UpdateAccountLastChecked", mock.Anything, account.ID).Return(nil)
 
 	worker, err := NewWorker(mockStore, testLogger)
@@ -279,6 +286,7 @@ func TestWorker_processAccount_UpdateLastCheckedError(t *testing.T) {
 
 	mockStore.On("GetValidTokenForAccount", mock.Anything, account.ID).Return(createValidToken(), nil)
 	mockStore.On("GetRulesForAccount", mock.Anything, account.ID).Return([]domain.AutomationRule{}, nil) // No rules to avoid processing
+	mockStore.On("GetUserRules", mock.Anything, account.UserID).Return([]domain.AutomationRule{}, nil)
 	expectedErr := errors.New("update failed")
 	mockStore.On("UpdateAccountLastChecked", mock.Anything, account.ID).Return(expectedErr)
 
@@ -312,6 +320,7 @@ func TestWorker_processAccount_GmailSyncDisabled(t *testing.T) {
 
 	mockStore.On("GetValidTokenForAccount", mock.Anything, account.ID).Return(createValidToken(), nil)
 	mockStore.On("GetRulesForAccount", mock.Anything, account.ID).Return([]domain.AutomationRule{}, nil) // No rules to avoid processing
+	mockStore.On("GetUserRules", mock.Anything, account.UserID).Return([]domain.AutomationRule{}, nil)
 	mockStore.On("UpdateAccountLastChecked", mock.Anything, account.ID).Return(nil)
 
 	worker, err := NewWorker(mockStore, testLogger)
@@ -322,3 +331,478 @@ func TestWorker_processAccount_GmailSyncDisabled(t *testing.T) {
 
 	mockStore.AssertExpectations(t)
 }
+
+func TestWorker_EnqueueCalendarSync_Coalesces(t *testing.T) {
+	worker, err := NewWorker(&store.MockStore{}, zap.NewNop())
+	assert.NoError(t, err)
+
+	accountID := uuid.New()
+	assert.True(t, worker.EnqueueCalendarSync(accountID, "primary"))
+	assert.True(t, worker.EnqueueCalendarSync(accountID, "primary"))
+	assert.Len(t, worker.syncQueue.requests, 1)
+
+	// Na het oppakken kan er weer een nieuwe sync worden ingepland
+	req := <-worker.syncQueue.requests
+	worker.syncQueue.done(req.accountID)
+	assert.True(t, worker.EnqueueCalendarSync(accountID, "primary"))
+	assert.Len(t, worker.syncQueue.requests, 1)
+}
+
+func TestWorker_syncCalendar_SkipsInactiveAccount(t *testing.T) {
+	mockStore := &store.MockStore{}
+	accountID := uuid.New()
+
+	mockStore.On("GetConnectedAccountByID", mock.Anything, accountID).
+		Return(domain.ConnectedAccount{ID: accountID, Status: domain.StatusPaused}, nil)
+
+	worker, err := NewWorker(mockStore, zap.NewNop())
+	assert.NoError(t, err)
+
+	worker.syncCalendar(calendarSyncRequest{accountID: accountID, calendarID: "primary"})
+
+	mockStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "GetValidTokenForAccount", mock.Anything, mock.Anything)
+}
+
+func TestWorker_syncCalendar_RetriesBusyAccount(t *testing.T) {
+	mockStore := &store.MockStore{}
+	accountID := uuid.New()
+
+	mockStore.On("GetConnectedAccountByID", mock.Anything, accountID).
+		Return(domain.ConnectedAccount{ID: accountID, Status: domain.StatusActive}, nil)
+
+	worker, err := NewWorker(mockStore, zap.NewNop())
+	assert.NoError(t, err)
+	worker.syncQueue.retryDelay = time.Millisecond
+
+	unlock, ok := worker.accountLocks.tryLock(accountID)
+	assert.True(t, ok)
+	defer unlock()
+
+	worker.syncCalendar(calendarSyncRequest{accountID: accountID, calendarID: "primary"})
+
+	// Het account is bezet door een handmatige run: de notificatie komt terug in de queue
+	select {
+	case req := <-worker.syncQueue.requests:
+		assert.Equal(t, accountID, req.accountID)
+		assert.Equal(t, "primary", req.calendarID)
+	case <-time.After(time.Second):
+		t.Fatal("calendar sync was not retried")
+	}
+	mockStore.AssertNotCalled(t, "GetValidTokenForAccount", mock.Anything, mock.Anything)
+}
+
+func TestWorker_checkAccounts_StopsWatchesOfRevokedAccounts(t *testing.T) {
+	t.Setenv("CALENDAR_WEBHOOK_URL", "https://example.com/api/v1/webhooks/google/calendar")
+	mockStore := &store.MockStore{}
+
+	channel := domain.CalendarWatchChannel{ConnectedAccountID: uuid.New(), ChannelID: "channel-1"}
+	mockStore.On("GetCalendarWatchChannelsForInactiveAccounts", mock.Anything).
+		Return([]domain.CalendarWatchChannel{channel}, nil)
+	mockStore.On("GetValidTokenForAccount", mock.Anything, channel.ConnectedAccountID).
+		Return((*oauth2.Token)(nil), store.ErrTokenRevoked)
+	// Zonder token kan Google niet worden aangeroepen; alleen de rij wordt opgeruimd
+	mockStore.On("DeleteCalendarWatchChannel", mock.Anything, "channel-1").Return(nil)
+	mockStore.On("GetActiveAccounts", mock.Anything).Return([]domain.ConnectedAccount{}, nil)
+
+	worker, err := NewWorker(mockStore, zap.NewNop())
+	assert.NoError(t, err)
+
+	err = worker.checkAccounts(context.Background())
+
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+}
+
+func TestWorker_wakeSnoozedMessages_RevokedAccount(t *testing.T) {
+	mockStore := &store.MockStore{}
+	snooze := domain.GmailSnooze{
+		AccountEntity:  domain.AccountEntity{BaseEntity: domain.BaseEntity{ID: uuid.New()}, ConnectedAccountID: uuid.New()},
+		GmailMessageID: "msg-1",
+	}
+
+	mockStore.On("GetDueGmailSnoozes", mock.Anything, mock.Anything, snoozeBatchSize).
+		Return([]domain.GmailSnooze{snooze}, nil)
+	mockStore.On("GetValidTokenForAccount", mock.Anything, snooze.ConnectedAccountID).
+		Return((*oauth2.Token)(nil), store.ErrTokenRevoked)
+	// Zonder toegang heeft opnieuw proberen geen zin: de snooze faalt definitief
+	mockStore.On("UpdateGmailSnoozeStatus", mock.Anything, snooze.ID, domain.GmailSnoozeFailed, mock.Anything).Return(nil)
+
+	worker, err := NewWorker(mockStore, zap.NewNop())
+	assert.NoError(t, err)
+
+	err = worker.wakeSnoozedMessages(context.Background())
+
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+}
+
+func TestWorker_wakeSnoozedMessages_TemporaryErrorIsRetried(t *testing.T) {
+	mockStore := &store.MockStore{}
+	snooze := domain.GmailSnooze{
+		AccountEntity: domain.AccountEntity{BaseEntity: domain.BaseEntity{ID: uuid.New()}, ConnectedAccountID: uuid.New()},
+	}
+
+	mockStore.On("GetDueGmailSnoozes", mock.Anything, mock.Anything, snoozeBatchSize).
+		Return([]domain.GmailSnooze{snooze}, nil)
+	mockStore.On("GetValidTokenForAccount", mock.Anything, snooze.ConnectedAccountID).
+		Return((*oauth2.Token)(nil), errors.New("connection reset"))
+
+	worker, err := NewWorker(mockStore, zap.NewNop())
+	assert.NoError(t, err)
+
+	err = worker.wakeSnoozedMessages(context.Background())
+
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "UpdateGmailSnoozeStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWorker_sendScheduledMessages_TemporaryErrorIsRetried(t *testing.T) {
+	mockStore := &store.MockStore{}
+	send := domain.GmailScheduledSend{
+		AccountEntity: domain.AccountEntity{BaseEntity: domain.BaseEntity{ID: uuid.New()}, ConnectedAccountID: uuid.New()},
+		Attempts:      1,
+	}
+
+	mockStore.On("GetDueGmailScheduledSends", mock.Anything, mock.Anything, scheduledSendBatchSize).
+		Return([]domain.GmailScheduledSend{send}, nil)
+	mockStore.On("GetValidTokenForAccount", mock.Anything, send.ConnectedAccountID).
+		Return((*oauth2.Token)(nil), errors.New("connection reset"))
+	// Tweede poging mislukt: de volgende poging is over 2 minuten
+	mockStore.On("RetryGmailScheduledSend", mock.Anything, send.ID, "connection reset",
+		mock.MatchedBy(func(next time.Time) bool {
+			return next.After(time.Now().Add(time.Minute)) && next.Before(time.Now().Add(3*time.Minute))
+		})).Return(nil)
+
+	worker, err := NewWorker(mockStore, zap.NewNop())
+	assert.NoError(t, err)
+
+	err = worker.sendScheduledMessages(context.Background())
+
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+}
+
+func TestWorker_sendScheduledMessages_FailsAfterMaxAttempts(t *testing.T) {
+	mockStore := &store.MockStore{}
+	send := domain.GmailScheduledSend{
+		AccountEntity: domain.AccountEntity{BaseEntity: domain.BaseEntity{ID: uuid.New()}, ConnectedAccountID: uuid.New()},
+		Attempts:      maxScheduledSendAttempts - 1,
+	}
+
+	mockStore.On("GetDueGmailScheduledSends", mock.Anything, mock.Anything, scheduledSendBatchSize).
+		Return([]domain.GmailScheduledSend{send}, nil)
+	mockStore.On("GetValidTokenForAccount", mock.Anything, send.ConnectedAccountID).
+		Return((*oauth2.Token)(nil), errors.New("connection reset"))
+	mockStore.On("UpdateGmailScheduledSendStatus", mock.Anything, send.ID, domain.GmailScheduledSendFailed,
+		(*string)(nil), mock.Anything).Return(nil)
+
+	worker, err := NewWorker(mockStore, zap.NewNop())
+	assert.NoError(t, err)
+
+	err = worker.sendScheduledMessages(context.Background())
+
+	assert.NoError(t, err)
+	mockStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "RetryGmailScheduledSend", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAccountLocks(t *testing.T) {
+	locks := newAccountLocks()
+	accountID := uuid.New()
+
+	unlock, ok := locks.tryLock(accountID)
+	assert.True(t, ok)
+
+	// Een tweede verwerking van hetzelfde account moet wachten; een ander account niet
+	_, ok = locks.tryLock(accountID)
+	assert.False(t, ok)
+	otherUnlock, ok := locks.tryLock(uuid.New())
+	assert.True(t, ok)
+	otherUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := locks.lock(ctx, accountID)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	unlock()
+	unlock, err = locks.lock(context.Background(), accountID)
+	assert.NoError(t, err)
+	unlock()
+}
+
+func TestWorker_processAccount_SkipsAccountInManualRun(t *testing.T) {
+	mockStore := &store.MockStore{}
+	account := &domain.ConnectedAccount{ID: uuid.New(), Status: domain.StatusActive}
+
+	worker, err := NewWorker(mockStore, zap.NewNop())
+	assert.NoError(t, err)
+
+	unlock, ok := worker.accountLocks.tryLock(account.ID)
+	assert.True(t, ok)
+	defer unlock()
+
+	worker.processAccount(context.Background(), account)
+
+	mockStore.AssertNotCalled(t, "GetValidTokenForAccount", mock.Anything, mock.Anything)
+}
+
+func TestWorker_EnqueueRun_QueueFull(t *testing.T) {
+	worker, err := NewWorker(&store.MockStore{}, zap.NewNop())
+	assert.NoError(t, err)
+
+	for range runQueueSize {
+		assert.True(t, worker.EnqueueRun(domain.ProcessingRun{ID: uuid.New()}))
+	}
+	assert.False(t, worker.EnqueueRun(domain.ProcessingRun{ID: uuid.New()}))
+}
+
+func TestWorker_executeRun_Account(t *testing.T) {
+	mockStore := &store.MockStore{}
+	account := domain.ConnectedAccount{ID: uuid.New(), UserID: uuid.New(), Status: domain.StatusActive}
+	run := domain.ProcessingRun{ID: uuid.New(), UserID: account.UserID, ConnectedAccountID: &account.ID}
+
+	// Dezelfde stappen als de ticker
+	mockStore.On("GetConnectedAccountByID", mock.Anything, account.ID).Return(account, nil)
+	mockStore.On("StartProcessingRun", mock.Anything, run.ID).Return(nil).Once()
+	mockStore.On("GetValidTokenForAccount", mock.Anything, account.ID).Return(createValidToken(), nil)
+	mockStore.On("GetRulesForAccount", mock.Anything, account.ID).Return([]domain.AutomationRule{}, nil)
+	mockStore.On("GetUserRules", mock.Anything, account.UserID).Return([]domain.AutomationRule{}, nil)
+	mockStore.On("UpdateAccountLastChecked", mock.Anything, account.ID).Return(nil)
+	mockStore.On("FinishProcessingRun", mock.Anything, run.ID, domain.RunCompleted, (*string)(nil)).
+		Return(domain.ProcessingRun{}, nil).Once()
+
+	worker, err := NewWorker(mockStore, zap.NewNop())
+	assert.NoError(t, err)
+
+	worker.executeRun(run)
+
+	mockStore.AssertExpectations(t)
+	// Het account is na de run weer vrij voor de ticker
+	unlock, ok := worker.accountLocks.tryLock(account.ID)
+	assert.True(t, ok)
+	unlock()
+}
+
+func TestWorker_executeRun_InactiveAccount(t *testing.T) {
+	mockStore := &store.MockStore{}
+	accountID := uuid.New()
+	run := domain.ProcessingRun{ID: uuid.New(), ConnectedAccountID: &accountID}
+
+	mockStore.On("GetConnectedAccountByID", mock.Anything, accountID).
+		Return(domain.ConnectedAccount{ID: accountID, Status: domain.StatusPaused}, nil)
+	mockStore.On("FinishProcessingRun", mock.Anything, run.ID, domain.RunFailed, mock.MatchedBy(func(msg *string) bool {
+		return msg != nil && *msg == "account is paused"
+	})).Return(domain.ProcessingRun{}, nil).Once()
+
+	worker, err := NewWorker(mockStore, zap.NewNop())
+	assert.NoError(t, err)
+
+	worker.executeRun(run)
+
+	mockStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "StartProcessingRun", mock.Anything, mock.Anything)
+}
+
+func TestWorker_executeRun_UserRule(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	google := domain.ConnectedAccount{ID: uuid.New(), UserID: userID, Provider: domain.ProviderGoogle, Status: domain.StatusActive}
+	paused := domain.ConnectedAccount{ID: uuid.New(), UserID: userID, Provider: domain.ProviderGoogle, Status: domain.StatusPaused}
+	microsoft := domain.ConnectedAccount{ID: uuid.New(), UserID: userID, Provider: domain.ProviderMicrosoft, Status: domain.StatusActive}
+
+	rule := domain.AutomationRule{BaseAutomationRule: domain.BaseAutomationRule{
+		AccountEntity:   domain.AccountEntity{BaseEntity: domain.BaseEntity{ID: uuid.New()}},
+		IsActive:        true,
+		UserID:          &userID,
+		AccountSelector: &domain.AccountSelector{Mode: domain.AccountSelectorProvider, Provider: domain.ProviderGoogle},
+	}}
+	run := domain.ProcessingRun{ID: uuid.New(), UserID: userID, RuleID: &rule.ID}
+
+	mockStore.On("GetRuleByID", mock.Anything, rule.ID).Return(rule, nil)
+	mockStore.On("GetAccountsForUser", mock.Anything, userID).
+		Return([]domain.ConnectedAccount{google, paused, microsoft}, nil)
+	// Alleen het actieve Google account wordt verwerkt
+	mockStore.On("StartProcessingRun", mock.Anything, run.ID).Return(nil).Once()
+	mockStore.On("GetValidTokenForAccount", mock.Anything, google.ID).Return(createValidToken(), nil).Once()
+	mockStore.On("GetRulesForAccount", mock.Anything, google.ID).Return([]domain.AutomationRule{}, nil)
+	mockStore.On("GetUserRules", mock.Anything, userID).Return([]domain.AutomationRule{}, nil)
+	mockStore.On("FinishProcessingRun", mock.Anything, run.ID, domain.RunCompleted, (*string)(nil)).
+		Return(domain.ProcessingRun{}, nil).Once()
+
+	worker, err := NewWorker(mockStore, zap.NewNop())
+	assert.NoError(t, err)
+
+	worker.executeRun(run)
+
+	mockStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "UpdateAccountLastChecked", mock.Anything, mock.Anything)
+}
+
+func TestWorker_executeRun_RuleWithoutAccounts(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	rule := domain.AutomationRule{BaseAutomationRule: domain.BaseAutomationRule{
+		AccountEntity:   domain.AccountEntity{BaseEntity: domain.BaseEntity{ID: uuid.New()}},
+		UserID:          &userID,
+		AccountSelector: &domain.AccountSelector{Mode: domain.AccountSelectorAll},
+	}}
+	run := domain.ProcessingRun{ID: uuid.New(), UserID: userID, RuleID: &rule.ID}
+
+	mockStore.On("GetRuleByID", mock.Anything, rule.ID).Return(rule, nil)
+	mockStore.On("GetAccountsForUser", mock.Anything, userID).Return([]domain.ConnectedAccount{}, nil)
+	mockStore.On("FinishProcessingRun", mock.Anything, run.ID, domain.RunFailed, mock.MatchedBy(func(msg *string) bool {
+		return msg != nil && *msg == "rule applies to no active account"
+	})).Return(domain.ProcessingRun{}, nil).Once()
+
+	worker, err := NewWorker(mockStore, zap.NewNop())
+	assert.NoError(t, err)
+
+	worker.executeRun(run)
+
+	mockStore.AssertExpectations(t)
+}
+
+func TestWorker_executeRun_GmailRule(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	gmailAccount := domain.ConnectedAccount{ID: uuid.New(), UserID: userID, Status: domain.StatusActive, GmailSyncEnabled: true}
+	noGmail := domain.ConnectedAccount{ID: uuid.New(), UserID: userID, Status: domain.StatusActive}
+
+	rule := domain.GmailAutomationRule{BaseAutomationRule: domain.BaseAutomationRule{
+		AccountEntity:   domain.AccountEntity{BaseEntity: domain.BaseEntity{ID: uuid.New()}},
+		IsActive:        true,
+		UserID:          &userID,
+		AccountSelector: &domain.AccountSelector{Mode: domain.AccountSelectorAll},
+	}}
+	run := domain.ProcessingRun{ID: uuid.New(), UserID: userID, GmailRuleID: &rule.ID}
+
+	mockStore.On("GetGmailRuleByID", mock.Anything, rule.ID).Return(rule, nil)
+	mockStore.On("GetAccountsForUser", mock.Anything, userID).
+		Return([]domain.ConnectedAccount{gmailAccount, noGmail}, nil)
+	// Alleen het account met Gmail sync wordt verwerkt
+	mockStore.On("StartProcessingRun", mock.Anything, run.ID).Return(nil).Once()
+	mockStore.On("GetValidTokenForAccount", mock.Anything, gmailAccount.ID).Return(createValidToken(), nil).Once()
+	mockStore.On("GetGmailRulesForAccount", mock.Anything, gmailAccount.ID).Return([]domain.GmailAutomationRule{}, nil)
+	mockStore.On("GetUserGmailRules", mock.Anything, userID).Return([]domain.GmailAutomationRule{}, nil)
+	mockStore.On("FinishProcessingRun", mock.Anything, run.ID, domain.RunCompleted, (*string)(nil)).
+		Return(domain.ProcessingRun{}, nil).Once()
+
+	worker, err := NewWorker(mockStore, zap.NewNop())
+	assert.NoError(t, err)
+
+	worker.executeRun(run)
+
+	mockStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "GetRuleByID", mock.Anything, mock.Anything)
+	mockStore.AssertNotCalled(t, "GetValidTokenForAccount", mock.Anything, noGmail.ID)
+}
+
+func TestWorker_executeRun_GmailRuleWithoutGmailSync(t *testing.T) {
+	mockStore := &store.MockStore{}
+	account := domain.ConnectedAccount{ID: uuid.New(), UserID: uuid.New(), Status: domain.StatusActive}
+	rule := domain.GmailAutomationRule{BaseAutomationRule: domain.BaseAutomationRule{
+		AccountEntity: domain.AccountEntity{
+			BaseEntity:         domain.BaseEntity{ID: uuid.New()},
+			ConnectedAccountID: account.ID,
+		},
+		IsActive: true,
+	}}
+	run := domain.ProcessingRun{ID: uuid.New(), UserID: account.UserID, ConnectedAccountID: &account.ID, GmailRuleID: &rule.ID}
+
+	mockStore.On("GetGmailRuleByID", mock.Anything, rule.ID).Return(rule, nil)
+	mockStore.On("GetConnectedAccountByID", mock.Anything, account.ID).Return(account, nil)
+	mockStore.On("FinishProcessingRun", mock.Anything, run.ID, domain.RunFailed, mock.MatchedBy(func(msg *string) bool {
+		return msg != nil && *msg == "rule applies to no active account with Gmail sync"
+	})).Return(domain.ProcessingRun{}, nil).Once()
+
+	worker, err := NewWorker(mockStore, zap.NewNop())
+	assert.NoError(t, err)
+
+	worker.executeRun(run)
+
+	mockStore.AssertExpectations(t)
+	mockStore.AssertNotCalled(t, "StartProcessingRun", mock.Anything, mock.Anything)
+}
+
+func TestWorker_deliverHook(t *testing.T) {
+	mockStore := &store.MockStore{}
+	userID := uuid.New()
+	active := domain.ConnectedAccount{ID: uuid.New(), UserID: userID, Status: domain.StatusActive}
+	paused := domain.ConnectedAccount{ID: uuid.New(), UserID: userID, Status: domain.StatusPaused}
+	delivery := domain.HookDelivery{ID: 3, HookID: uuid.New(), Payload: json.RawMessage(`{"owners": ""}`)}
+
+	hookRule := func(accountID uuid.UUID) domain.AutomationRule {
+		return domain.AutomationRule{
+			BaseAutomationRule: domain.BaseAutomationRule{
+				AccountEntity: domain.AccountEntity{
+					BaseEntity:         domain.BaseEntity{ID: uuid.New()},
+					ConnectedAccountID: accountID,
+				},
+				IsActive:          true,
+				TriggerConditions: json.RawMessage(`{"hook_id": "` + delivery.HookID.String() + `"}`),
+				// Zonder ontvangers slaat send_email over, zonder Google aan te roepen
+				ActionParams: json.RawMessage(`{"to": ["{{owners}}"], "subject": "Hoi", "body": "Hoi"}`),
+			},
+			TriggerType: domain.CalendarTriggerHookReceived,
+			ActionType:  domain.HookActionSendEmail,
+		}
+	}
+	userRule := hookRule(uuid.Nil)
+	userRule.UserID = &userID
+	userRule.AccountSelector = &domain.AccountSelector{Mode: domain.AccountSelectorAll}
+	pausedRule := hookRule(paused.ID)
+
+	mockStore.On("GetRulesForHook", mock.Anything, delivery.HookID).
+		Return([]domain.AutomationRule{userRule, pausedRule}, nil).Once()
+	mockStore.On("GetAccountsForUser", mock.Anything, userID).
+		Return([]domain.ConnectedAccount{active, paused}, nil).Once()
+	mockStore.On("GetConnectedAccountByID", mock.Anything, paused.ID).Return(paused, nil).Once()
+	mockStore.On("CreateAutomationLog", mock.Anything, mock.MatchedBy(func(p store.CreateLogParams) bool {
+		return p.ConnectedAccountID == active.ID && p.Status == domain.LogSkipped
+	})).Return(nil).Once()
+	mockStore.On("GetRuleExecutionCounts", mock.Anything, userRule.ID, active.ID).Return(0, 0, nil).Once()
+
+	var finished store.FinishHookDeliveryParams
+	mockStore.On("FinishHookDelivery", mock.Anything, mock.AnythingOfType("webhook.FinishHookDeliveryParams")).
+		Run(func(args mock.Arguments) { finished = args.Get(1).(store.FinishHookDeliveryParams) }).
+		Return(domain.HookDelivery{}, nil).Once()
+
+	worker, err := NewWorker(mockStore, zap.NewNop())
+	assert.NoError(t, err)
+
+	worker.deliverHook(delivery)
+
+	mockStore.AssertExpectations(t)
+	// De user-level rule draait alleen op het actieve account; de rule van het gepauzeerde account mislukt
+	assert.Equal(t, int64(3), finished.DeliveryID)
+	assert.Equal(t, domain.HookDeliveryPartial, finished.Status)
+	require.Len(t, finished.Results, 2)
+	assert.Equal(t, userRule.ID, finished.Results[0].RuleID)
+	assert.Equal(t, &active.ID, finished.Results[0].ConnectedAccountID)
+	assert.Equal(t, "skipped", finished.Results[0].Outcome)
+	assert.Equal(t, pausedRule.ID, finished.Results[1].RuleID)
+	assert.Nil(t, finished.Results[1].ConnectedAccountID)
+	assert.Equal(t, "failure", finished.Results[1].Outcome)
+	assert.Equal(t, "account is paused", finished.Results[1].Error)
+}
+
+func TestWorker_deliverHook_NoRules(t *testing.T) {
+	mockStore := &store.MockStore{}
+	delivery := domain.HookDelivery{ID: 4, HookID: uuid.New(), Payload: json.RawMessage(`{}`)}
+
+	mockStore.On("GetRulesForHook", mock.Anything, delivery.HookID).Return([]domain.AutomationRule{}, nil).Once()
+	mockStore.On("FinishHookDelivery", mock.Anything, mock.MatchedBy(func(p store.FinishHookDeliveryParams) bool {
+		return p.DeliveryID == delivery.ID && p.Status == domain.HookDeliveryFailure &&
+			p.ErrorMessage != nil && *p.ErrorMessage == "no active rule is bound to the hook"
+	})).Return(domain.HookDelivery{}, nil).Once()
+
+	worker, err := NewWorker(mockStore, zap.NewNop())
+	assert.NoError(t, err)
+
+	worker.deliverHook(delivery)
+
+	mockStore.AssertExpectations(t)
+}